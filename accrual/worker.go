@@ -0,0 +1,145 @@
+// Package accrual accrues the interest of accounts every day and posts it every month
+package accrual
+
+import (
+	"context"
+	"log/slog"
+	db "simplebank/db/sqlc"
+	"simplebank/interest"
+	"simplebank/logging"
+	"time"
+)
+
+const (
+	// catchUpDays is how many past days a run accrues, so that a worker that was down for a while catches up
+	catchUpDays = 7
+	// pageSize bounds the accounts listed at once
+	pageSize = 100
+)
+
+// RunResult counts the accruals and postings a run made
+type RunResult struct {
+	Accrued int
+	Posted  int
+}
+
+// Worker accrues the interest of the days that ended and posts the months that ended
+// runs are idempotent, replicas can run side by side
+type Worker struct {
+	store    *db.Store
+	interval time.Duration
+	now      func() time.Time
+}
+
+// NewWorker creates a worker running every interval
+func NewWorker(store *db.Store, interval time.Duration) *Worker {
+	return &Worker{
+		store:    store,
+		interval: interval,
+		now:      time.Now,
+	}
+}
+
+// Run accrues and posts interest until ctx is done
+func (w *Worker) Run(ctx context.Context) error {
+	logger := logging.FromContext(ctx).With(slog.String("worker", "interest_accrual"))
+	ctx = logging.WithLogger(ctx, logger)
+
+	for {
+		result, err := w.RunOnce(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		if err != nil {
+			logger.ErrorContext(ctx, "interest accrual failed", slog.Any("error", err))
+		} else if result.Accrued > 0 || result.Posted > 0 {
+			logger.InfoContext(ctx, "interest accrued",
+				slog.Int("accrued", result.Accrued),
+				slog.Int("posted", result.Posted),
+			)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(w.interval):
+		}
+	}
+}
+
+// RunOnce accrues the last days that ended, then posts every month that ended
+func (w *Worker) RunOnce(ctx context.Context) (RunResult, error) {
+	var result RunResult
+	today := interest.DayStart(w.now())
+
+	for day := today.AddDate(0, 0, -catchUpDays); day.Before(today); day = day.AddDate(0, 0, 1) {
+		accrued, err := w.accrueDay(ctx, day)
+		result.Accrued += accrued
+		if err != nil {
+			return result, err
+		}
+	}
+
+	for {
+		months, err := w.store.ListUnpostedInterestMonths(ctx, db.ListUnpostedInterestMonthsParams{
+			Before:     interest.MonthStart(today),
+			LimitCount: pageSize,
+		})
+		if err != nil {
+			return result, err
+		}
+
+		for _, month := range months {
+			_, posted, err := w.store.PostInterestTx(ctx, db.PostInterestTxParams{
+				AccountID: month.AccountID,
+				Month:     month.Month,
+			})
+			if err != nil {
+				return result, err
+			}
+
+			if posted {
+				result.Posted++
+			}
+		}
+
+		if len(months) < pageSize {
+			return result, nil
+		}
+	}
+}
+
+// accrueDay accrues the day for every account with a rate that has not accrued it yet
+func (w *Worker) accrueDay(ctx context.Context, day time.Time) (int, error) {
+	var accrued int
+	var afterID int64
+
+	for {
+		ids, err := w.store.ListAccountsToAccrue(ctx, db.ListAccountsToAccrueParams{
+			AfterID:    afterID,
+			EndOfDay:   day.AddDate(0, 0, 1),
+			Day:        day,
+			LimitCount: pageSize,
+		})
+		if err != nil {
+			return accrued, err
+		}
+
+		for _, id := range ids {
+			_, ok, err := w.store.AccrueInterest(ctx, db.AccrueInterestParams{AccountID: id, Day: day})
+			if err != nil {
+				return accrued, err
+			}
+
+			if ok {
+				accrued++
+			}
+		}
+
+		if len(ids) < pageSize {
+			return accrued, nil
+		}
+		afterID = ids[len(ids)-1]
+	}
+}
@@ -0,0 +1,110 @@
+package accrual
+
+import (
+	"context"
+	"database/sql"
+	db "simplebank/db/sqlc"
+	"simplebank/interest"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createAccount(t *testing.T, product string, balance int64) db.Account {
+	user, err := testStore.CreateUser(context.Background(), db.CreateUserParams{
+		Username:       util.RandomOwner(),
+		HashedPassword: "secret",
+		FullName:       util.RandomOwner(),
+		Email:          util.RandomEmail(),
+	})
+	require.NoError(t, err)
+
+	account, err := testStore.CreateAccount(context.Background(), db.CreateAccountParams{
+		Owner:    user.Username,
+		Balance:  balance,
+		Currency: "USD",
+		Product:  sql.NullString{String: product, Valid: product != ""},
+	})
+	require.NoError(t, err)
+
+	return account
+}
+
+// createSavingsProduct creates a product of its own paying rateBps, so that its rate applies to no other account
+func createSavingsProduct(t *testing.T, rateBps int32) (db.Product, db.Account) {
+	product, err := testStore.CreateProduct(context.Background(), db.CreateProductParams{
+		Code:     "test-" + util.RandomString(8),
+		Name:     "Test savings",
+		DayCount: string(interest.Actual365),
+	})
+	require.NoError(t, err)
+
+	expense := createAccount(t, "", 0)
+
+	_, err = testStore.CreateInterestRate(context.Background(), db.CreateInterestRateParams{
+		Product:          product.Code,
+		Currency:         "USD",
+		RateBps:          rateBps,
+		EffectiveFrom:    time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC),
+		ExpenseAccountID: expense.ID,
+		CreatedBy:        expense.Owner,
+	})
+	require.NoError(t, err)
+
+	return product, expense
+}
+
+func listAccruals(t *testing.T, accountID int64) []db.InterestAccrual {
+	accruals, err := testStore.ListAccountInterestAccruals(context.Background(), db.ListAccountInterestAccrualsParams{
+		AccountID:  accountID,
+		LimitCount: 100,
+	})
+	require.NoError(t, err)
+
+	return accruals
+}
+
+func TestWorkerAccruesAndPosts(t *testing.T) {
+	product, expense := createSavingsProduct(t, 500)
+	account := createAccount(t, product.Code, 100000)
+	checking := createAccount(t, "", 100000)
+
+	// the first day of the month after next: the run accrues the last week of next month and posts it
+	worker := NewWorker(testStore, time.Hour)
+	now := interest.MonthStart(time.Now()).AddDate(0, 2, 0)
+	worker.now = func() time.Time { return now }
+
+	result, err := worker.RunOnce(context.Background())
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, result.Accrued, catchUpDays)
+	require.GreaterOrEqual(t, result.Posted, 1)
+
+	accruals := listAccruals(t, account.ID)
+	require.Len(t, accruals, catchUpDays)
+	for i, accrual := range accruals {
+		require.True(t, accrual.Day.Equal(now.AddDate(0, 0, i-catchUpDays)))
+		require.Equal(t, int64(13698630), accrual.AmountMicros)
+		require.True(t, accrual.PostingID.Valid)
+	}
+
+	require.Empty(t, listAccruals(t, checking.ID))
+
+	account, err = testStore.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(100000+95), account.Balance)
+
+	expense, err = testStore.GetAccount(context.Background(), expense.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(-95), expense.Balance)
+
+	// reruns accrue and post nothing twice
+	_, err = worker.RunOnce(context.Background())
+	require.NoError(t, err)
+	require.Len(t, listAccruals(t, account.ID), catchUpDays)
+
+	account, err = testStore.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(100000+95), account.Balance)
+}
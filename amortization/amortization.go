@@ -0,0 +1,141 @@
+// Package amortization computes the repayment schedules of loans and splits repayments over them
+package amortization
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// monthsBasisPoints turns an annual rate in basis points into a monthly rate: 12 months of 10000
+const monthsBasisPoints = 12 * 10000
+
+// Method is how a loan is paid back
+type Method string
+
+// Amortization methods
+const (
+	// Annuity pays the same total every month, the interest part shrinks as the principal is paid back
+	Annuity Method = "annuity"
+	// StraightLine pays the same principal every month, with the interest on what is left
+	StraightLine Method = "straight_line"
+)
+
+// ParseMethod checks that s is a known method
+func ParseMethod(s string) (Method, error) {
+	switch m := Method(s); m {
+	case Annuity, StraightLine:
+		return m, nil
+	default:
+		return "", fmt.Errorf("unknown amortization method %q", s)
+	}
+}
+
+// Installment is one monthly payment of a schedule
+type Installment struct {
+	Number    int32
+	DueDate   time.Time
+	Principal int64
+	Interest  int64
+}
+
+// Total is what the installment asks for
+func (i Installment) Total() int64 {
+	return i.Principal + i.Interest
+}
+
+// Schedule splits a principal lent at an annual rate in basis points over term monthly installments,
+// the first one due on firstDue; amounts are rounded half up and the last installment pays off what is left
+func Schedule(principal int64, rateBps int32, term int32, method Method, firstDue time.Time) []Installment {
+	installments := make([]Installment, 0, term)
+	payment := annuityPayment(principal, rateBps, term)
+	balance := principal
+
+	for n := int32(1); n <= term; n++ {
+		interest := monthlyInterest(balance, rateBps)
+
+		var part int64
+		switch {
+		case n == term:
+			part = balance
+		case method == Annuity:
+			part = min(max(payment-interest, 0), balance)
+		default:
+			part = principal / int64(term)
+		}
+
+		installments = append(installments, Installment{
+			Number:    n,
+			DueDate:   AddMonths(firstDue, int(n-1)),
+			Principal: part,
+			Interest:  interest,
+		})
+		balance -= part
+	}
+
+	return installments
+}
+
+// monthlyInterest is the interest of a month on balance, rounded half up
+func monthlyInterest(balance int64, rateBps int32) int64 {
+	bps := int64(rateBps)
+
+	// split the balance so that large balances cannot overflow
+	return balance/monthsBasisPoints*bps + (balance%monthsBasisPoints*bps+monthsBasisPoints/2)/monthsBasisPoints
+}
+
+// annuityPayment is the constant monthly total paying off principal over term months, rounded to the minor unit
+func annuityPayment(principal int64, rateBps int32, term int32) int64 {
+	if rateBps == 0 {
+		return (principal + int64(term) - 1) / int64(term)
+	}
+
+	r := float64(rateBps) / monthsBasisPoints
+	return int64(math.Round(float64(principal) * r / (1 - math.Pow(1+r, -float64(term)))))
+}
+
+// AddMonths moves t by months, a day that does not exist in the target month becomes its last day
+func AddMonths(t time.Time, months int) time.Time {
+	y, m, d := t.Date()
+	first := time.Date(y, m+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	last := first.AddDate(0, 1, -1).Day()
+
+	return first.AddDate(0, 0, min(d, last)-1)
+}
+
+// Due is what an installment still owes
+type Due struct {
+	Number    int32
+	Penalty   int64
+	Interest  int64
+	Principal int64
+}
+
+// Total is what is left to pay
+func (d Due) Total() int64 {
+	return d.Penalty + d.Interest + d.Principal
+}
+
+// Allocate splits a repayment over the installments in order: each one pays its penalty, then its interest,
+// then its principal before the next one gets anything; the rest is what amount left over
+func Allocate(amount int64, dues []Due) (paid []Due, rest int64) {
+	for _, due := range dues {
+		if amount == 0 {
+			break
+		}
+
+		p := Due{Number: due.Number}
+		p.Penalty, amount = take(amount, due.Penalty)
+		p.Interest, amount = take(amount, due.Interest)
+		p.Principal, amount = take(amount, due.Principal)
+
+		paid = append(paid, p)
+	}
+
+	return paid, amount
+}
+
+func take(amount int64, owed int64) (taken int64, rest int64) {
+	taken = min(amount, owed)
+	return taken, amount - taken
+}
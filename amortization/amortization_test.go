@@ -0,0 +1,130 @@
+package amortization
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func requirePaysOff(t *testing.T, principal int64, installments []Installment) {
+	var total int64
+	for _, installment := range installments {
+		require.GreaterOrEqual(t, installment.Principal, int64(0))
+		total += installment.Principal
+	}
+	require.Equal(t, principal, total)
+}
+
+func TestScheduleAnnuity(t *testing.T) {
+	installments := Schedule(100000, 1200, 12, Annuity, date(2026, time.January, 15))
+	require.Len(t, installments, 12)
+	requirePaysOff(t, 100000, installments)
+
+	require.Equal(t, Installment{Number: 1, DueDate: date(2026, time.January, 15), Principal: 7885, Interest: 1000}, installments[0])
+	require.Equal(t, int64(7964), installments[1].Principal)
+	require.Equal(t, int64(921), installments[1].Interest)
+
+	for _, installment := range installments[:11] {
+		require.Equal(t, int64(8885), installment.Total())
+	}
+	require.InDelta(t, 8885, installments[11].Total(), 5)
+	require.Equal(t, date(2026, time.December, 15), installments[11].DueDate)
+}
+
+func TestScheduleStraightLine(t *testing.T) {
+	installments := Schedule(100000, 1200, 3, StraightLine, date(2026, time.January, 15))
+	require.Equal(t, []Installment{
+		{Number: 1, DueDate: date(2026, time.January, 15), Principal: 33333, Interest: 1000},
+		{Number: 2, DueDate: date(2026, time.February, 15), Principal: 33333, Interest: 667},
+		{Number: 3, DueDate: date(2026, time.March, 15), Principal: 33334, Interest: 333},
+	}, installments)
+}
+
+func TestScheduleZeroRate(t *testing.T) {
+	installments := Schedule(100000, 0, 12, Annuity, date(2026, time.January, 15))
+	requirePaysOff(t, 100000, installments)
+
+	for _, installment := range installments[:11] {
+		require.Equal(t, int64(8334), installment.Principal)
+		require.Zero(t, installment.Interest)
+	}
+	require.Equal(t, int64(8326), installments[11].Principal)
+}
+
+func TestScheduleSingleInstallment(t *testing.T) {
+	for _, method := range []Method{Annuity, StraightLine} {
+		installments := Schedule(5000, 600, 1, method, date(2026, time.January, 15))
+		require.Equal(t, []Installment{{Number: 1, DueDate: date(2026, time.January, 15), Principal: 5000, Interest: 25}}, installments)
+	}
+}
+
+func TestAddMonths(t *testing.T) {
+	require.Equal(t, date(2026, time.February, 28), AddMonths(date(2026, time.January, 31), 1))
+	require.Equal(t, date(2024, time.February, 29), AddMonths(date(2024, time.January, 31), 1))
+	require.Equal(t, date(2026, time.March, 31), AddMonths(date(2026, time.January, 31), 2))
+	require.Equal(t, date(2027, time.January, 15), AddMonths(date(2026, time.December, 15), 1))
+}
+
+func TestAllocate(t *testing.T) {
+	dues := []Due{
+		{Number: 1, Penalty: 500, Interest: 1000, Principal: 7885},
+		{Number: 2, Interest: 921, Principal: 7964},
+	}
+
+	testCases := []struct {
+		name   string
+		amount int64
+		paid   []Due
+		rest   int64
+	}{
+		{
+			name:   "PenaltyFirst",
+			amount: 300,
+			paid:   []Due{{Number: 1, Penalty: 300}},
+		},
+		{
+			name:   "InterestBeforePrincipal",
+			amount: 2000,
+			paid:   []Due{{Number: 1, Penalty: 500, Interest: 1000, Principal: 500}},
+		},
+		{
+			name:   "OldestInstallmentFirst",
+			amount: 10000,
+			paid: []Due{
+				{Number: 1, Penalty: 500, Interest: 1000, Principal: 7885},
+				{Number: 2, Interest: 615},
+			},
+		},
+		{
+			name:   "MoreThanOwed",
+			amount: 20000,
+			paid: []Due{
+				{Number: 1, Penalty: 500, Interest: 1000, Principal: 7885},
+				{Number: 2, Interest: 921, Principal: 7964},
+			},
+			rest: 1730,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			paid, rest := Allocate(tc.amount, dues)
+			require.Equal(t, tc.paid, paid)
+			require.Equal(t, tc.rest, rest)
+		})
+	}
+}
+
+func TestParseMethod(t *testing.T) {
+	method, err := ParseMethod("straight_line")
+	require.NoError(t, err)
+	require.Equal(t, StraightLine, method)
+
+	_, err = ParseMethod("balloon")
+	require.Error(t, err)
+}
@@ -1,16 +1,23 @@
 package api
 
 import (
+	"database/sql"
+	"errors"
+	"fmt"
 	"net/http"
 	db "simplebank/db/sqlc"
-	"strconv"
+	"simplebank/rbac"
+	"simplebank/token"
+	"simplebank/util"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 type createAccountRequest struct {
-	Owner    string `json:"owner" binding:"required"`
 	Currency string `json:"currency" binding:"required,oneof=USD EUR"`
+	// Product is checking when left out
+	Product string `json:"product" binding:"omitempty,max=50"`
 }
 
 func (server *Server) createAccount(ctx *gin.Context) {
@@ -20,49 +27,124 @@ func (server *Server) createAccount(ctx *gin.Context) {
 		return
 	}
 
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
 	arg := db.CreateAccountParams{
-		Owner:    req.Owner,
+		Owner:    authPayload.Username,
 		Currency: req.Currency,
 		Balance:  0,
 	}
 
-	account, err := server.store.CreateAccount(ctx, arg)
+	if req.Product != "" {
+		_, err := server.store.GetProduct(ctx, req.Product)
+		if err == nil && !db.CustomerProduct(req.Product) {
+			err = sql.ErrNoRows
+		}
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				err = fmt.Errorf("unknown product %q", req.Product)
+				ctx.JSON(http.StatusBadRequest, errorResponse(err))
+				return
+			}
+
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		arg.Product = sql.NullString{String: req.Product, Valid: true}
+	}
+
+	account, err := server.store.CreateAccountTx(ctx, arg)
 
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
 	}
 
 	ctx.JSON(http.StatusOK, &account)
 }
 
 type getAccountRequest struct {
-	ID int64 `uri:"name" binding:"required"`
+	ID int64 `uri:"id" binding:"required,min=1"`
 }
 
 func (server *Server) getAccount(ctx *gin.Context) {
-	idParam := ctx.Param("id")
+	var req getAccountRequest
+
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
 
-	idParamInt, err := strconv.ParseInt(idParam, 10, 64)
+	account, ok := server.readableAccount(ctx, req.ID)
+	if !ok {
+		return
+	}
 
-	if err != nil {
+	ctx.JSON(http.StatusOK, &account)
+}
+
+type getAccountBalanceQuery struct {
+	// At is the moment the balance is asked for, now when left out
+	At *time.Time `form:"at" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+type accountBalanceResponse struct {
+	AccountID int64     `json:"account_id"`
+	Currency  string    `json:"currency"`
+	Balance   int64     `json:"balance"`
+	At        time.Time `json:"at"`
+}
+
+// getAccountBalance returns the ledger balance an account of the authenticated user had at a point in time
+func (server *Server) getAccountBalance(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var query getAccountBalanceQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
 	}
 
-	req := &getAccountRequest{idParamInt}
+	at := time.Now()
+	if query.At != nil {
+		if query.At.After(at) {
+			err := errors.New("at cannot be in the future")
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		at = *query.At
+	}
 
-	account, err := server.store.GetAccount(ctx, req.ID)
+	account, ok := server.readableAccount(ctx, uri.ID)
+	if !ok {
+		return
+	}
 
+	balance, err := server.store.GetAccountBalanceAt(ctx, db.GetAccountBalanceAtParams{
+		AccountID: account.ID,
+		At:        at,
+	})
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	ctx.JSON(http.StatusOK, &account)
+	ctx.JSON(http.StatusOK, accountBalanceResponse{
+		AccountID: account.ID,
+		Currency:  account.Currency,
+		Balance:   balance,
+		At:        at,
+	})
 }
 
 type listAccountRequest struct {
-	PageID   int32 `form:"page_id" binding:"required,min=1"`
-	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+	PageID   int32  `form:"page_id" binding:"omitempty,min=1"`
+	PageSize int32  `form:"page_size" binding:"required,min=5,max=10"`
+	Cursor   string `form:"cursor"`
 }
 
 func (server *Server) listAccounts(ctx *gin.Context) {
@@ -70,19 +152,172 @@ func (server *Server) listAccounts(ctx *gin.Context) {
 
 	if err := ctx.ShouldBindQuery(&req); err != nil {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
 	}
 
-	arg := db.ListAccountsParams{
-		Limit:  req.PageSize,
-		Offset: (req.PageID - 1) * req.PageSize,
+	if req.PageID != 0 && req.Cursor != "" {
+		err := errors.New("page_id and cursor cannot be used together")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
 	}
 
-	accounts, err := server.store.ListAccounts(ctx, arg)
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	var (
+		accounts []db.Account
+		err      error
+	)
+
+	if req.PageID != 0 {
+		accounts, err = server.store.ListAccounts(ctx, db.ListAccountsParams{
+			Owner:  authPayload.Username,
+			Limit:  req.PageSize,
+			Offset: (req.PageID - 1) * req.PageSize,
+		})
+	} else {
+		afterID, cursorErr := util.DecodeCursor(req.Cursor)
+		if cursorErr != nil {
+			ctx.JSON(http.StatusBadRequest, errorResponse(cursorErr))
+			return
+		}
+
+		accounts, err = server.store.ListAccountsAfter(ctx, db.ListAccountsAfterParams{
+			Owner:      authPayload.Username,
+			AfterID:    afterID,
+			LimitCount: req.PageSize,
+		})
+	}
 
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
+	if len(accounts) > 0 {
+		setNextCursor(ctx, len(accounts), req.PageSize, accounts[len(accounts)-1].ID)
+	}
+
 	ctx.JSON(http.StatusOK, &accounts)
 }
+
+// ownedAccount loads an account that belongs to the authenticated user
+// it writes the error response and returns false when the account is missing or owned by someone else
+func (server *Server) ownedAccount(ctx *gin.Context, accountID int64) (db.Account, bool) {
+	return server.accessibleAccount(ctx, accountID, false)
+}
+
+// readableAccount loads an account the authenticated user may read: one of theirs, or any account
+// when their role is granted rbac.AccountsReadAny
+func (server *Server) readableAccount(ctx *gin.Context, accountID int64) (db.Account, bool) {
+	return server.accessibleAccount(ctx, accountID, true)
+}
+
+func (server *Server) accessibleAccount(ctx *gin.Context, accountID int64, readOnly bool) (db.Account, bool) {
+	account, err := server.store.GetAccount(ctx, accountID)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return account, false
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return account, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if account.Owner != authPayload.Username && !(readOnly && rbac.Can(authPayload.Role, rbac.AccountsReadAny)) {
+		err := errors.New("account doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return account, false
+	}
+
+	return account, true
+}
+
+type listAllAccountsQuery struct {
+	Owner    string `form:"owner" binding:"omitempty,max=100"`
+	Status   string `form:"status" binding:"omitempty,oneof=active frozen closed"`
+	Currency string `form:"currency" binding:"omitempty,oneof=USD EUR"`
+	PageSize int32  `form:"page_size" binding:"required,min=1,max=100"`
+	Cursor   string `form:"cursor"`
+}
+
+// listAllAccounts lists the accounts of every owner in the order they were opened
+func (server *Server) listAllAccounts(ctx *gin.Context) {
+	var query listAllAccountsQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	afterID, err := util.DecodeCursor(query.Cursor)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	accounts, err := server.store.ListAllAccounts(ctx, db.ListAllAccountsParams{
+		Owner:      sql.NullString{String: query.Owner, Valid: query.Owner != ""},
+		Status:     sql.NullString{String: query.Status, Valid: query.Status != ""},
+		Currency:   sql.NullString{String: query.Currency, Valid: query.Currency != ""},
+		AfterID:    afterID,
+		LimitCount: query.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if len(accounts) > 0 {
+		setNextCursor(ctx, len(accounts), query.PageSize, accounts[len(accounts)-1].ID)
+	}
+
+	ctx.JSON(http.StatusOK, accounts)
+}
+
+// freezeAccount stops money from moving in or out of any account
+func (server *Server) freezeAccount(ctx *gin.Context) {
+	server.setAccountStatus(ctx, db.AccountStatusFrozen)
+}
+
+// unfreezeAccount lets money move again through a frozen account
+func (server *Server) unfreezeAccount(ctx *gin.Context) {
+	server.setAccountStatus(ctx, db.AccountStatusActive)
+}
+
+func (server *Server) setAccountStatus(ctx *gin.Context, status string) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, err := server.store.GetAccount(ctx, uri.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if account.Status == db.AccountStatusClosed {
+		err := fmt.Errorf("account %d is closed", account.ID)
+		ctx.JSON(http.StatusConflict, errorResponse(err))
+		return
+	}
+
+	account, err = server.store.UpdateAccountStatusTx(ctx, db.UpdateAccountStatusParams{
+		ID:     account.ID,
+		Status: status,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, account)
+}
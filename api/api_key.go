@@ -0,0 +1,194 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"simplebank/apikey"
+	db "simplebank/db/sqlc"
+	"simplebank/rbac"
+	"simplebank/signing"
+	"simplebank/token"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type createApiKeyRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+	// Scopes are the permissions the key is limited to, the role of the caller must grant every one of them
+	Scopes []string `json:"scopes" binding:"required,min=1,max=20,dive,required"`
+	// AllowedIPs are the IPs and CIDR ranges the key is accepted from, empty for anywhere
+	AllowedIPs    []string `json:"allowed_ips" binding:"omitempty,max=20,dive,required"`
+	ExpiresInDays int32    `json:"expires_in_days" binding:"required,min=1,max=365"`
+}
+
+type apiKeyResponse struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	AllowedIPs []string   `json:"allowed_ips"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	// Key is sent in the X-API-Key header, it is only returned when the key is created
+	Key string `json:"key,omitempty"`
+	// SigningSecret signs the requests to the routes that require a signature, it is only returned when the key is created
+	SigningSecret string `json:"signing_secret,omitempty"`
+}
+
+func newApiKeyResponse(key db.ApiKey) apiKeyResponse {
+	rsp := apiKeyResponse{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.Scopes,
+		AllowedIPs: key.AllowedIps,
+		ExpiresAt:  key.ExpiresAt,
+		CreatedAt:  key.CreatedAt,
+	}
+
+	if key.LastUsedAt.Valid {
+		rsp.LastUsedAt = &key.LastUsedAt.Time
+	}
+	if key.RevokedAt.Valid {
+		rsp.RevokedAt = &key.RevokedAt.Time
+	}
+
+	return rsp
+}
+
+// createApiKey issues an API key for the caller, its secret is returned once and only its hash is kept;
+// the signing secret of the key is returned once as well
+func (server *Server) createApiKey(ctx *gin.Context) {
+	var req createApiKeyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	for _, scope := range req.Scopes {
+		permission := rbac.Permission(scope)
+
+		switch {
+		case !rbac.ValidPermission(permission):
+			err := fmt.Errorf("unknown scope %s", scope)
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		case permission == rbac.APIKeysManage:
+			err := fmt.Errorf("scope %s cannot be granted to an api key", scope)
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		case !rbac.Can(authPayload.Role, permission):
+			err := fmt.Errorf("scope %s is not granted to your role", scope)
+			ctx.JSON(http.StatusForbidden, errorResponse(err))
+			return
+		}
+	}
+
+	for _, entry := range req.AllowedIPs {
+		if err := apikey.ValidAllowedIP(entry); err != nil {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+	}
+
+	key, err := apikey.Generate()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	signingSecret, err := signing.NewSecret()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	allowedIPs := req.AllowedIPs
+	if allowedIPs == nil {
+		allowedIPs = []string{}
+	}
+
+	created, err := server.store.CreateApiKeyTx(ctx, db.CreateApiKeyParams{
+		Owner:         authPayload.Username,
+		Name:          req.Name,
+		Prefix:        key.Prefix,
+		HashedSecret:  key.Hash,
+		Scopes:        req.Scopes,
+		AllowedIps:    allowedIPs,
+		ExpiresAt:     time.Now().AddDate(0, 0, int(req.ExpiresInDays)),
+		SigningSecret: signingSecret,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := newApiKeyResponse(created)
+	rsp.Key = key.String()
+	rsp.SigningSecret = created.SigningSecret
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// listApiKeys returns the API keys of the caller, revoked and expired ones included
+func (server *Server) listApiKeys(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	keys, err := server.store.ListApiKeys(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]apiKeyResponse, 0, len(keys))
+	for _, key := range keys {
+		rsp = append(rsp, newApiKeyResponse(key))
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type apiKeyURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// revokeApiKey revokes an API key of the caller for good, revoking it again returns it unchanged
+func (server *Server) revokeApiKey(ctx *gin.Context) {
+	var uri apiKeyURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	key, err := server.store.GetApiKey(ctx, uri.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if key.Owner != authPayload.Username {
+		err := errors.New("api key doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return
+	}
+
+	key, err = server.store.RevokeApiKeyTx(ctx, uri.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newApiKeyResponse(key))
+}
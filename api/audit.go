@@ -0,0 +1,134 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"net/http"
+	"simplebank/audit"
+	db "simplebank/db/sqlc"
+	"simplebank/logging"
+	"simplebank/token"
+	"simplebank/util"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readOnlyMethods change nothing, the audit log skips them
+var readOnlyMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodOptions: true,
+}
+
+// rejectedStatuses turn a request away for its shape or its rate before it touches anything, the audit log skips them
+var rejectedStatuses = map[int]bool{
+	http.StatusBadRequest:            true,
+	http.StatusRequestEntityTooLarge: true,
+	http.StatusUnsupportedMediaType:  true,
+	http.StatusTooManyRequests:       true,
+}
+
+// audit hands the caller to the store hooks through the request context, then appends every state-changing
+// request, and every request made with an API key, to the audit log once it is served;
+// it runs after authMiddleware on the routes that have one
+func (server *Server) audit() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		actor := audit.Actor{
+			Username:  audit.AnonymousActor,
+			IP:        ctx.ClientIP(),
+			UserAgent: ctx.Request.UserAgent(),
+			RequestID: logging.RequestIDFromContext(ctx.Request.Context()),
+		}
+		if payload, ok := ctx.Get(authorizationPayloadKey); ok {
+			actor.Username = payload.(*token.Payload).Username
+		}
+
+		reqCtx := audit.WithActor(ctx.Request.Context(), actor)
+		ctx.Request = ctx.Request.WithContext(reqCtx)
+
+		ctx.Next()
+
+		apiKey, usedApiKey := ctx.Get(authorizationApiKeyKey)
+
+		status := ctx.Writer.Status()
+		if (readOnlyMethods[ctx.Request.Method] && !usedApiKey) || rejectedStatuses[status] {
+			return
+		}
+
+		request := gin.H{
+			"method": ctx.Request.Method,
+			"route":  ctx.FullPath(),
+			"status": status,
+		}
+		if usedApiKey {
+			request["api_key"] = apiKey.(db.GetApiKeyByPrefixRow).Prefix
+		}
+
+		// the caller hanging up must not lose the row
+		_, err := server.store.RecordAudit(context.WithoutCancel(reqCtx), db.AuditEntry{
+			Action:     db.AuditRequest,
+			TargetType: db.AuditTargetRoute,
+			TargetID:   ctx.Request.URL.Path,
+			After:      request,
+		})
+		if err != nil {
+			logging.FromContext(reqCtx).ErrorContext(reqCtx, "audit log write failed", slog.Any("error", err))
+		}
+	}
+}
+
+type listAuditLogQuery struct {
+	Actor      string `form:"actor" binding:"omitempty,max=100"`
+	Action     string `form:"action" binding:"omitempty,max=100"`
+	TargetType string `form:"target_type" binding:"omitempty,max=100"`
+	TargetID   string `form:"target_id" binding:"omitempty,max=200"`
+	// From and To bound when the rows were written, From included and To excluded
+	From     *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To       *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	PageSize int32      `form:"page_size" binding:"required,min=1,max=100"`
+	Cursor   string     `form:"cursor"`
+}
+
+// listAuditLog pages through the audit log in the order it was written, every filter is optional
+func (server *Server) listAuditLog(ctx *gin.Context) {
+	var query listAuditLogQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	afterID, err := util.DecodeCursor(query.Cursor)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	arg := db.ListAuditLogParams{
+		Actor:      sql.NullString{String: query.Actor, Valid: query.Actor != ""},
+		Action:     sql.NullString{String: query.Action, Valid: query.Action != ""},
+		TargetType: sql.NullString{String: query.TargetType, Valid: query.TargetType != ""},
+		TargetID:   sql.NullString{String: query.TargetID, Valid: query.TargetID != ""},
+		AfterID:    afterID,
+		LimitCount: query.PageSize,
+	}
+	if query.From != nil {
+		arg.FromAt = sql.NullTime{Time: *query.From, Valid: true}
+	}
+	if query.To != nil {
+		arg.ToAt = sql.NullTime{Time: *query.To, Valid: true}
+	}
+
+	logs, err := server.store.ListAuditLog(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if len(logs) > 0 {
+		setNextCursor(ctx, len(logs), query.PageSize, logs[len(logs)-1].ID)
+	}
+
+	ctx.JSON(http.StatusOK, logs)
+}
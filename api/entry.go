@@ -0,0 +1,60 @@
+package api
+
+import (
+	"net/http"
+	db "simplebank/db/sqlc"
+	"simplebank/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+type listAccountEntriesURI struct {
+	AccountID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type listAccountEntriesQuery struct {
+	PageSize int32  `form:"page_size" binding:"required,min=1,max=100"`
+	Cursor   string `form:"cursor"`
+}
+
+// listAccountEntries returns the statement of an account, oldest entry first
+func (server *Server) listAccountEntries(ctx *gin.Context) {
+	var uri listAccountEntriesURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var query listAccountEntriesQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	afterID, err := util.DecodeCursor(query.Cursor)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if _, ok := server.readableAccount(ctx, uri.AccountID); !ok {
+		return
+	}
+
+	entries, err := server.store.ListAccountEntries(ctx, db.ListAccountEntriesParams{
+		AccountID:  uri.AccountID,
+		AfterID:    afterID,
+		LimitCount: query.PageSize,
+	})
+
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if len(entries) > 0 {
+		setNextCursor(ctx, len(entries), query.PageSize, entries[len(entries)-1].ID)
+	}
+
+	ctx.JSON(http.StatusOK, &entries)
+}
@@ -0,0 +1,295 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	db "simplebank/db/sqlc"
+	"simplebank/token"
+	"simplebank/util"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// defaultHoldTTL is how long a hold reserves funds when the request has no expires_at
+	defaultHoldTTL = 7 * 24 * time.Hour
+	// maxHoldTTL bounds how long funds can be held
+	maxHoldTTL = 30 * 24 * time.Hour
+)
+
+type createHoldRequest struct {
+	AccountID   int64  `json:"account_id" binding:"required,min=1"`
+	ToAccountID int64  `json:"to_account_id" binding:"required,min=1"`
+	Amount      int64  `json:"amount" binding:"required,gt=0"`
+	Currency    string `json:"currency" binding:"required,oneof=USD EUR"`
+	Description string `json:"description" binding:"max=200"`
+	// ExpiresAt releases the funds when the hold is not captured by then, a week from now when left out
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// createHold reserves funds of an account of the authenticated user for a later capture to the to account
+func (server *Server) createHold(ctx *gin.Context) {
+	var req createHoldRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if req.AccountID == req.ToAccountID {
+		err := errors.New("cannot hold funds for the same account")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(defaultHoldTTL)
+	if req.ExpiresAt != nil {
+		expiresAt = *req.ExpiresAt
+	}
+
+	if !expiresAt.After(now) || expiresAt.After(now.Add(maxHoldTTL)) {
+		err := errors.New("expires_at must be in the next 30 days")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, ok := server.customerAccount(ctx, req.AccountID, req.Currency)
+	if !ok {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if account.Owner != authPayload.Username {
+		err := errors.New("account doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return
+	}
+
+	if _, ok := server.customerAccount(ctx, req.ToAccountID, req.Currency); !ok {
+		return
+	}
+
+	ok = server.screenDeferredTransfer(ctx, db.ScreenTransferTxParams{
+		TransferCreateParams: db.TransferCreateParams{
+			FromAccountID: req.AccountID,
+			ToAccountID:   req.ToAccountID,
+			Amount:        req.Amount,
+		},
+		Currency: req.Currency,
+		Owner:    authPayload.Username,
+	})
+	if !ok {
+		return
+	}
+
+	result, err := server.store.AuthorizeHoldTx(ctx, db.AuthorizeHoldTxParams{
+		AccountID:   req.AccountID,
+		ToAccountID: req.ToAccountID,
+		Amount:      req.Amount,
+		Currency:    req.Currency,
+		Description: req.Description,
+		ExpiresAt:   expiresAt,
+	})
+	if err != nil {
+		server.holdError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, result)
+}
+
+type holdURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// getHold returns a hold on or for an account of the authenticated user
+func (server *Server) getHold(ctx *gin.Context) {
+	var uri holdURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	hold, ok := server.visibleHold(ctx, uri.ID)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, hold)
+}
+
+type captureHoldRequest struct {
+	// Amount is posted now, the rest of the hold can be captured later
+	Amount int64 `json:"amount" binding:"required,gt=0"`
+}
+
+// captureHold posts part or all of a hold as a transfer, only the owner of the held account can capture it
+func (server *Server) captureHold(ctx *gin.Context) {
+	var uri holdURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req captureHoldRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if _, ok := server.ownedHold(ctx, uri.ID); !ok {
+		return
+	}
+
+	result, err := server.store.CaptureHoldTx(ctx, db.CaptureHoldTxParams{
+		ID:     uri.ID,
+		Amount: req.Amount,
+	})
+	if err != nil {
+		server.holdError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+// voidHold releases what a hold still reserves, the owner of either account can void it
+func (server *Server) voidHold(ctx *gin.Context) {
+	var uri holdURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if _, ok := server.visibleHold(ctx, uri.ID); !ok {
+		return
+	}
+
+	result, err := server.store.VoidHoldTx(ctx, uri.ID)
+	if err != nil {
+		server.holdError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+type listAccountHoldsQuery struct {
+	PageSize int32  `form:"page_size" binding:"required,min=1,max=100"`
+	Cursor   string `form:"cursor"`
+}
+
+// listAccountHolds returns the holds on an account, oldest first
+func (server *Server) listAccountHolds(ctx *gin.Context) {
+	var uri listAccountEntriesURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var query listAccountHoldsQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	afterID, err := util.DecodeCursor(query.Cursor)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if _, ok := server.readableAccount(ctx, uri.AccountID); !ok {
+		return
+	}
+
+	holds, err := server.store.ListAccountHolds(ctx, db.ListAccountHoldsParams{
+		AccountID:  uri.AccountID,
+		AfterID:    afterID,
+		LimitCount: query.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if len(holds) > 0 {
+		setNextCursor(ctx, len(holds), query.PageSize, holds[len(holds)-1].ID)
+	}
+
+	ctx.JSON(http.StatusOK, holds)
+}
+
+// ownedHold loads a hold on an account of the authenticated user
+func (server *Server) ownedHold(ctx *gin.Context, id int64) (db.Hold, bool) {
+	hold, ok := server.loadHold(ctx, id)
+	if !ok {
+		return hold, false
+	}
+
+	if _, ok := server.ownedAccount(ctx, hold.AccountID); !ok {
+		return hold, false
+	}
+
+	return hold, true
+}
+
+// visibleHold loads a hold on or for an account of the authenticated user
+func (server *Server) visibleHold(ctx *gin.Context, id int64) (db.Hold, bool) {
+	hold, ok := server.loadHold(ctx, id)
+	if !ok {
+		return hold, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	for _, accountID := range []int64{hold.AccountID, hold.ToAccountID} {
+		account, err := server.store.GetAccount(ctx, accountID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return hold, false
+		}
+
+		if account.Owner == authPayload.Username {
+			return hold, true
+		}
+	}
+
+	err := errors.New("hold doesn't involve an account of the authenticated user")
+	ctx.JSON(http.StatusForbidden, errorResponse(err))
+	return hold, false
+}
+
+func (server *Server) loadHold(ctx *gin.Context, id int64) (db.Hold, bool) {
+	hold, err := server.store.GetHold(ctx, id)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return hold, false
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return hold, false
+	}
+
+	return hold, true
+}
+
+func (server *Server) holdError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, db.ErrHoldNotAuthorized):
+		ctx.JSON(http.StatusConflict, errorResponse(err))
+	case errors.Is(err, db.ErrCaptureExceedsHold),
+		errors.Is(err, db.ErrInsufficientFunds),
+		errors.Is(err, db.ErrAccountNotActive),
+		errors.Is(err, db.ErrCurrencyMismatch),
+		errors.Is(err, db.ErrTransferLimitExceeded):
+		ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+	case errors.Is(err, sql.ErrNoRows):
+		ctx.JSON(http.StatusNotFound, errorResponse(err))
+	default:
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+	}
+}
@@ -0,0 +1,174 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	db "simplebank/db/sqlc"
+	"simplebank/logging"
+	"simplebank/token"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	idempotencyKeyHeader     = "Idempotency-Key"
+	idempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+)
+
+// idempotent makes a POST safe to retry: the first response sent with an Idempotency-Key is stored
+// and replayed to every later request that carries the same key
+// keys are scoped to the authenticated user, 5xx responses are not stored so the request can be retried
+func (server *Server) idempotent() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		key := ctx.GetHeader(idempotencyKeyHeader)
+		if key == "" {
+			ctx.Next()
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			err := fmt.Errorf("%s must be at most %d characters", idempotencyKeyHeader, maxIdempotencyKeyLength)
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxBufferedBodyBytes))
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				ctx.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, errorResponse(err))
+				return
+			}
+
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+		requestHash := hashRequest(ctx.Request.Method, ctx.Request.URL.Path, body)
+
+		_, err = server.store.CreateIdempotencyKey(ctx, db.CreateIdempotencyKeyParams{
+			Username:      authPayload.Username,
+			Key:           key,
+			RequestMethod: ctx.Request.Method,
+			RequestPath:   ctx.Request.URL.Path,
+			RequestHash:   requestHash,
+		})
+
+		if errors.Is(err, sql.ErrNoRows) {
+			server.replayIdempotentResponse(ctx, authPayload.Username, key, requestHash)
+			return
+		}
+
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		writer := &recordingWriter{ResponseWriter: ctx.Writer}
+		ctx.Writer = writer
+
+		defer func() {
+			if r := recover(); r != nil {
+				server.storeIdempotentResponse(ctx, authPayload.Username, key, http.StatusInternalServerError, nil)
+				panic(r)
+			}
+		}()
+
+		ctx.Next()
+
+		server.storeIdempotentResponse(ctx, authPayload.Username, key, writer.Status(), writer.body.Bytes())
+	}
+}
+
+func (server *Server) replayIdempotentResponse(ctx *gin.Context, username string, key string, requestHash string) {
+	stored, err := server.store.GetIdempotencyKey(ctx, db.GetIdempotencyKeyParams{
+		Username: username,
+		Key:      key,
+	})
+
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if stored.RequestHash != requestHash {
+		err := fmt.Errorf("%s was already used with a different request", idempotencyKeyHeader)
+		ctx.AbortWithStatusJSON(http.StatusUnprocessableEntity, errorResponse(err))
+		return
+	}
+
+	if !stored.ResponseStatus.Valid {
+		err := fmt.Errorf("a request with this %s is still in progress", idempotencyKeyHeader)
+		ctx.AbortWithStatusJSON(http.StatusConflict, errorResponse(err))
+		return
+	}
+
+	ctx.Header(idempotentReplayedHeader, "true")
+	ctx.Data(int(stored.ResponseStatus.Int32), "application/json; charset=utf-8", stored.ResponseBody)
+	ctx.Abort()
+}
+
+func (server *Server) storeIdempotentResponse(ctx *gin.Context, username string, key string, status int, body []byte) {
+	// the response is already written, so a cancelled request must not stop the key from being settled
+	storeCtx := context.WithoutCancel(ctx.Request.Context())
+	logger := logging.FromContext(storeCtx)
+
+	if status >= http.StatusInternalServerError {
+		err := server.store.DeleteIdempotencyKey(storeCtx, db.DeleteIdempotencyKeyParams{
+			Username: username,
+			Key:      key,
+		})
+		if err != nil {
+			logger.ErrorContext(storeCtx, "cannot release idempotency key", slog.Any("error", err))
+		}
+		return
+	}
+
+	err := server.store.UpdateIdempotencyKeyResponse(storeCtx, db.UpdateIdempotencyKeyResponseParams{
+		Username:       username,
+		Key:            key,
+		ResponseStatus: sql.NullInt32{Int32: int32(status), Valid: true},
+		ResponseBody:   body,
+	})
+	if err != nil {
+		logger.ErrorContext(storeCtx, "cannot store idempotent response", slog.Any("error", err))
+	}
+}
+
+func hashRequest(method string, path string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method))
+	hash.Write([]byte{0})
+	hash.Write([]byte(path))
+	hash.Write([]byte{0})
+	hash.Write(body)
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// recordingWriter keeps a copy of the response body so it can be replayed
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
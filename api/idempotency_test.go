@@ -0,0 +1,31 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"simplebank/rbac"
+	"simplebank/token"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotentBodyTooLarge(t *testing.T) {
+	server := newTestServer(t, nil)
+
+	path := "/idempotent"
+	server.router.POST(path, func(ctx *gin.Context) {
+		ctx.Set(authorizationPayloadKey, &token.Payload{Username: "alice", Role: rbac.RoleCustomer})
+	}, server.idempotent(), func(ctx *gin.Context) {
+		t.Error("the handler must not be reached")
+	})
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodPost, path, strings.NewReader(strings.Repeat("x", maxBufferedBodyBytes+1)))
+	request.Header.Set(idempotencyKeyHeader, "retry-me")
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+}
@@ -0,0 +1,238 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	db "simplebank/db/sqlc"
+	"simplebank/token"
+	"simplebank/util"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// dateLayout formats the UTC days of rates and accruals
+const dateLayout = "2006-01-02"
+
+// listProducts returns the account products an account can be opened with
+func (server *Server) listProducts(ctx *gin.Context) {
+	products, err := server.store.ListProducts(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]db.Product, 0, len(products))
+	for _, product := range products {
+		if db.CustomerProduct(product.Code) {
+			rsp = append(rsp, product)
+		}
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type interestAccrualResponse struct {
+	ID        int64  `json:"id"`
+	AccountID int64  `json:"account_id"`
+	Day       string `json:"day"`
+	// Balance is the ledger balance at the end of the day
+	Balance  int64  `json:"balance"`
+	RateBps  int32  `json:"rate_bps"`
+	DayCount string `json:"day_count"`
+	// AmountMicros is the interest earned on the day, in millionths of the minor unit
+	AmountMicros int64 `json:"amount_micros"`
+	// PostingID is set once the month is posted
+	PostingID *int64    `json:"posting_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func newInterestAccrualResponse(accrual db.InterestAccrual) interestAccrualResponse {
+	return interestAccrualResponse{
+		ID:           accrual.ID,
+		AccountID:    accrual.AccountID,
+		Day:          accrual.Day.Format(dateLayout),
+		Balance:      accrual.Balance,
+		RateBps:      accrual.RateBps,
+		DayCount:     accrual.DayCount,
+		AmountMicros: accrual.AmountMicros,
+		PostingID:    nullInt64(accrual.PostingID),
+		CreatedAt:    accrual.CreatedAt,
+	}
+}
+
+type listInterestAccrualsQuery struct {
+	PageSize int32  `form:"page_size" binding:"required,min=1,max=100"`
+	Cursor   string `form:"cursor"`
+}
+
+// listInterestAccruals returns the daily interest accruals of an account, oldest first
+func (server *Server) listInterestAccruals(ctx *gin.Context) {
+	var uri listAccountEntriesURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var query listInterestAccrualsQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	afterID, err := util.DecodeCursor(query.Cursor)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if _, ok := server.readableAccount(ctx, uri.AccountID); !ok {
+		return
+	}
+
+	accruals, err := server.store.ListAccountInterestAccruals(ctx, db.ListAccountInterestAccrualsParams{
+		AccountID:  uri.AccountID,
+		AfterID:    afterID,
+		LimitCount: query.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if len(accruals) > 0 {
+		setNextCursor(ctx, len(accruals), query.PageSize, accruals[len(accruals)-1].ID)
+	}
+
+	rsp := make([]interestAccrualResponse, 0, len(accruals))
+	for _, accrual := range accruals {
+		rsp = append(rsp, newInterestAccrualResponse(accrual))
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// createInterestRateRequest changes the rate of a product and currency from a UTC day on
+type createInterestRateRequest struct {
+	Product  string `json:"product" binding:"required,max=50"`
+	Currency string `json:"currency" binding:"required,oneof=USD EUR"`
+	// RateBps is the annual rate in basis points, 100 is 1%
+	RateBps       int32  `json:"rate_bps" binding:"min=0,max=10000"`
+	EffectiveFrom string `json:"effective_from" binding:"required,datetime=2006-01-02"`
+	// ExpenseAccountID is the account paying the interest, the internal interest expense account when left out
+	ExpenseAccountID int64 `json:"expense_account_id" binding:"omitempty,min=1"`
+}
+
+type interestRateResponse struct {
+	ID               int64     `json:"id"`
+	Product          string    `json:"product"`
+	Currency         string    `json:"currency"`
+	RateBps          int32     `json:"rate_bps"`
+	EffectiveFrom    string    `json:"effective_from"`
+	ExpenseAccountID int64     `json:"expense_account_id"`
+	CreatedBy        string    `json:"created_by"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+func newInterestRateResponse(rate db.InterestRate) interestRateResponse {
+	return interestRateResponse{
+		ID:               rate.ID,
+		Product:          rate.Product,
+		Currency:         rate.Currency,
+		RateBps:          rate.RateBps,
+		EffectiveFrom:    rate.EffectiveFrom.Format(dateLayout),
+		ExpenseAccountID: rate.ExpenseAccountID,
+		CreatedBy:        rate.CreatedBy,
+		CreatedAt:        rate.CreatedAt,
+	}
+}
+
+// createInterestRate adds a rate to the schedule of a product, it applies until a rate with a later day
+func (server *Server) createInterestRate(ctx *gin.Context) {
+	var req createInterestRateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	effectiveFrom, err := time.Parse(dateLayout, req.EffectiveFrom)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if _, err := server.store.GetProduct(ctx, req.Product); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	expenseAccountID, ok := server.ledgerAccount(ctx, req.ExpenseAccountID, db.GLInterestExpense, req.Currency)
+	if !ok {
+		return
+	}
+
+	rate, err := server.store.CreateInterestRate(ctx, db.CreateInterestRateParams{
+		Product:          req.Product,
+		Currency:         req.Currency,
+		RateBps:          req.RateBps,
+		EffectiveFrom:    effectiveFrom,
+		ExpenseAccountID: expenseAccountID,
+		CreatedBy:        ctx.MustGet(authorizationPayloadKey).(*token.Payload).Username,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, newInterestRateResponse(rate))
+}
+
+type listInterestRatesQuery struct {
+	Product  string `form:"product" binding:"omitempty,max=50"`
+	Currency string `form:"currency" binding:"omitempty,oneof=USD EUR"`
+	PageSize int32  `form:"page_size" binding:"required,min=1,max=100"`
+	Cursor   string `form:"cursor"`
+}
+
+// listInterestRates lists the rate schedules in the order the rates were added
+func (server *Server) listInterestRates(ctx *gin.Context) {
+	var query listInterestRatesQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	afterID, err := util.DecodeCursor(query.Cursor)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	rates, err := server.store.ListInterestRates(ctx, db.ListInterestRatesParams{
+		Product:    sql.NullString{String: query.Product, Valid: query.Product != ""},
+		Currency:   sql.NullString{String: query.Currency, Valid: query.Currency != ""},
+		AfterID:    afterID,
+		LimitCount: query.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if len(rates) > 0 {
+		setNextCursor(ctx, len(rates), query.PageSize, rates[len(rates)-1].ID)
+	}
+
+	rsp := make([]interestRateResponse, 0, len(rates))
+	for _, rate := range rates {
+		rsp = append(rsp, newInterestRateResponse(rate))
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
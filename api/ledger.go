@@ -0,0 +1,84 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	db "simplebank/db/sqlc"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// listInternalAccounts returns the chart of internal accounts of every currency
+func (server *Server) listInternalAccounts(ctx *gin.Context) {
+	accounts, err := server.store.ListInternalAccounts(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, accounts)
+}
+
+type getTrialBalanceQuery struct {
+	// At is the instant the balances are taken at, now when left out
+	At *time.Time `form:"at" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+type trialBalanceResponse struct {
+	At time.Time `json:"at"`
+	// Currencies sum the balances by account type, the negative ones as debits and the positive ones as credits
+	Currencies []db.CurrencyTrialBalance `json:"currencies"`
+}
+
+// getTrialBalance sums the balances of the customer and internal accounts by currency and account type
+func (server *Server) getTrialBalance(ctx *gin.Context) {
+	var query getTrialBalanceQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	at := time.Now()
+	if query.At != nil {
+		if query.At.After(at) {
+			err := errors.New("at cannot be in the future")
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		at = *query.At
+	}
+
+	rows, err := server.store.GetTrialBalanceAt(ctx, at)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, trialBalanceResponse{
+		At:         at,
+		Currencies: db.GroupTrialBalance(rows),
+	})
+}
+
+// ledgerAccount checks the account given in a request, or picks the internal account with the code when none is given
+func (server *Server) ledgerAccount(ctx *gin.Context, accountID int64, code string, currency string) (int64, bool) {
+	if accountID != 0 {
+		account, ok := server.validAccount(ctx, accountID, currency)
+		return account.ID, ok
+	}
+
+	account, err := server.store.InternalAccount(ctx, code, currency)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return 0, false
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return 0, false
+	}
+
+	return account.ID, true
+}
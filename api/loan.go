@@ -0,0 +1,337 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"simplebank/amortization"
+	db "simplebank/db/sqlc"
+	"simplebank/token"
+	"simplebank/util"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type createLoanRequest struct {
+	// BorrowerAccountID receives the principal and pays the repayments
+	BorrowerAccountID int64 `json:"borrower_account_id" binding:"required,min=1"`
+	// IncomeAccountID is credited with the interest and the late fees, the internal interest income account when left out
+	IncomeAccountID int64  `json:"income_account_id" binding:"omitempty,min=1"`
+	Currency        string `json:"currency" binding:"required,oneof=USD EUR"`
+	Principal       int64  `json:"principal" binding:"required,gt=0"`
+	// RateBps is the annual rate in basis points, 100 is 1%
+	RateBps    int32  `json:"rate_bps" binding:"min=0,max=10000"`
+	TermMonths int32  `json:"term_months" binding:"required,min=1,max=360"`
+	Method     string `json:"method" binding:"required,oneof=annuity straight_line"`
+	// LateFee is charged on an installment once it is overdue
+	LateFee int64 `json:"late_fee" binding:"min=0"`
+	// GraceDays is how long after its due date an unpaid installment becomes overdue
+	GraceDays    int32  `json:"grace_days" binding:"min=0,max=90"`
+	FirstDueDate string `json:"first_due_date" binding:"required,datetime=2006-01-02"`
+}
+
+type loanInstallmentResponse struct {
+	ID        int64  `json:"id"`
+	LoanID    int64  `json:"loan_id"`
+	Number    int32  `json:"number"`
+	DueDate   string `json:"due_date"`
+	Principal int64  `json:"principal"`
+	Interest  int64  `json:"interest"`
+	// Penalty is the late fees charged on the installment
+	Penalty       int64     `json:"penalty"`
+	PaidPrincipal int64     `json:"paid_principal"`
+	PaidInterest  int64     `json:"paid_interest"`
+	PaidPenalty   int64     `json:"paid_penalty"`
+	Status        string    `json:"status"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+func newLoanInstallmentResponses(installments []db.LoanInstallment) []loanInstallmentResponse {
+	rsp := make([]loanInstallmentResponse, 0, len(installments))
+	for _, installment := range installments {
+		rsp = append(rsp, loanInstallmentResponse{
+			ID:            installment.ID,
+			LoanID:        installment.LoanID,
+			Number:        installment.Number,
+			DueDate:       installment.DueDate.Format(dateLayout),
+			Principal:     installment.Principal,
+			Interest:      installment.Interest,
+			Penalty:       installment.Penalty,
+			PaidPrincipal: installment.PaidPrincipal,
+			PaidInterest:  installment.PaidInterest,
+			PaidPenalty:   installment.PaidPenalty,
+			Status:        installment.Status,
+			UpdatedAt:     installment.UpdatedAt,
+		})
+	}
+	return rsp
+}
+
+type createLoanResponse struct {
+	Loan         db.Loan                   `json:"loan"`
+	Account      db.Account                `json:"account"`
+	Installments []loanInstallmentResponse `json:"installments"`
+	Disbursement db.TransferTxResult       `json:"disbursement"`
+}
+
+// createLoan opens a loan for the owner of the borrower account and disburses its principal into that account
+func (server *Server) createLoan(ctx *gin.Context) {
+	var req createLoanRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if req.BorrowerAccountID == req.IncomeAccountID {
+		err := errors.New("borrower and income accounts must differ")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	firstDueDate, err := time.Parse(dateLayout, req.FirstDueDate)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if _, ok := server.validAccount(ctx, req.BorrowerAccountID, req.Currency); !ok {
+		return
+	}
+
+	incomeAccountID, ok := server.ledgerAccount(ctx, req.IncomeAccountID, db.GLInterestIncome, req.Currency)
+	if !ok {
+		return
+	}
+
+	result, err := server.store.CreateLoanTx(ctx, db.CreateLoanTxParams{
+		BorrowerAccountID: req.BorrowerAccountID,
+		IncomeAccountID:   incomeAccountID,
+		Principal:         req.Principal,
+		RateBps:           req.RateBps,
+		TermMonths:        req.TermMonths,
+		Method:            amortization.Method(req.Method),
+		LateFee:           req.LateFee,
+		GraceDays:         req.GraceDays,
+		FirstDueDate:      firstDueDate,
+		CreatedBy:         ctx.MustGet(authorizationPayloadKey).(*token.Payload).Username,
+	})
+	if err != nil {
+		server.loanError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, createLoanResponse{
+		Loan:         result.Loan,
+		Account:      result.Account,
+		Installments: newLoanInstallmentResponses(result.Installments),
+		Disbursement: result.Disbursement,
+	})
+}
+
+type listLoansQuery struct {
+	PageSize int32  `form:"page_size" binding:"required,min=1,max=100"`
+	Cursor   string `form:"cursor"`
+}
+
+// listLoans returns the loans of the authenticated user, oldest first
+func (server *Server) listLoans(ctx *gin.Context) {
+	var query listLoansQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	afterID, err := util.DecodeCursor(query.Cursor)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	loans, err := server.store.ListOwnerLoans(ctx, db.ListOwnerLoansParams{
+		Owner:      authPayload.Username,
+		AfterID:    afterID,
+		LimitCount: query.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if len(loans) > 0 {
+		setNextCursor(ctx, len(loans), query.PageSize, loans[len(loans)-1].ID)
+	}
+
+	ctx.JSON(http.StatusOK, loans)
+}
+
+type loanURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// getLoan returns a loan of the authenticated user
+func (server *Server) getLoan(ctx *gin.Context) {
+	var uri loanURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	loan, ok := server.ownedLoan(ctx, uri.ID)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, loan)
+}
+
+// listLoanInstallments returns the amortization schedule of a loan with what is paid of each installment
+func (server *Server) listLoanInstallments(ctx *gin.Context) {
+	var uri loanURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if _, ok := server.ownedLoan(ctx, uri.ID); !ok {
+		return
+	}
+
+	installments, err := server.store.ListLoanInstallments(ctx, uri.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newLoanInstallmentResponses(installments))
+}
+
+type repayLoanRequest struct {
+	// Amount is paid out of the borrower account, it can't exceed what the loan still owes
+	Amount int64 `json:"amount" binding:"required,gt=0"`
+}
+
+type repayLoanResponse struct {
+	Loan         db.Loan                   `json:"loan"`
+	Repayment    db.LoanRepayment          `json:"repayment"`
+	Installments []loanInstallmentResponse `json:"installments"`
+	Journal      db.PostJournalTxResult    `json:"journal"`
+}
+
+// repayLoan pays part of a loan of the authenticated user, penalties first, then interest, then principal
+func (server *Server) repayLoan(ctx *gin.Context) {
+	var uri loanURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req repayLoanRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if _, ok := server.ownedLoan(ctx, uri.ID); !ok {
+		return
+	}
+
+	result, err := server.store.RepayLoanTx(ctx, db.RepayLoanTxParams{
+		LoanID: uri.ID,
+		Amount: req.Amount,
+	})
+	if err != nil {
+		server.loanError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, repayLoanResponse{
+		Loan:         result.Loan,
+		Repayment:    result.Repayment,
+		Installments: newLoanInstallmentResponses(result.Installments),
+		Journal:      result.Journal,
+	})
+}
+
+type listLoanRepaymentsQuery struct {
+	PageSize int32  `form:"page_size" binding:"required,min=1,max=100"`
+	Cursor   string `form:"cursor"`
+}
+
+// listLoanRepayments returns the repayments of a loan, oldest first
+func (server *Server) listLoanRepayments(ctx *gin.Context) {
+	var uri loanURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var query listLoanRepaymentsQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	afterID, err := util.DecodeCursor(query.Cursor)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if _, ok := server.ownedLoan(ctx, uri.ID); !ok {
+		return
+	}
+
+	repayments, err := server.store.ListLoanRepayments(ctx, db.ListLoanRepaymentsParams{
+		LoanID:     uri.ID,
+		AfterID:    afterID,
+		LimitCount: query.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if len(repayments) > 0 {
+		setNextCursor(ctx, len(repayments), query.PageSize, repayments[len(repayments)-1].ID)
+	}
+
+	ctx.JSON(http.StatusOK, repayments)
+}
+
+// ownedLoan loads a loan whose borrower account belongs to the authenticated user
+func (server *Server) ownedLoan(ctx *gin.Context, id int64) (db.Loan, bool) {
+	loan, err := server.store.GetLoan(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return loan, false
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return loan, false
+	}
+
+	if _, ok := server.ownedAccount(ctx, loan.BorrowerAccountID); !ok {
+		return loan, false
+	}
+
+	return loan, true
+}
+
+func (server *Server) loanError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, db.ErrLoanNotActive):
+		ctx.JSON(http.StatusConflict, errorResponse(err))
+	case errors.Is(err, db.ErrRepaymentExceedsLoan),
+		errors.Is(err, db.ErrInsufficientFunds),
+		errors.Is(err, db.ErrAccountNotActive),
+		errors.Is(err, db.ErrCurrencyMismatch):
+		ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+	case errors.Is(err, sql.ErrNoRows):
+		ctx.JSON(http.StatusNotFound, errorResponse(err))
+	default:
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+	}
+}
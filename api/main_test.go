@@ -0,0 +1,43 @@
+package api
+
+import (
+	"net/http"
+	"os"
+	db "simplebank/db/sqlc"
+	"simplebank/ratelimit"
+	"simplebank/token"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T, store *db.Store) *Server {
+	config := util.Config{
+		TokenSymmetricKey:    util.RandomString(32),
+		AccessTokenDuration:  time.Minute,
+		RefreshTokenDuration: time.Hour,
+		RequestSigningSkew:   time.Minute,
+	}
+
+	server, err := NewServer(config, store, ratelimit.NewLimiter(ratelimit.NewMemoryStore()))
+	require.NoError(t, err)
+
+	return server
+}
+
+func addAuthorization(t *testing.T, request *http.Request, tokenMaker token.Maker, username string, role string) {
+	accessToken, payload, err := tokenMaker.CreateToken(username, role, time.Minute)
+	require.NoError(t, err)
+	require.NotEmpty(t, payload)
+
+	request.Header.Set(authorizationHeaderKey, authorizationTypeBearer+" "+accessToken)
+}
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+
+	os.Exit(m.Run())
+}
@@ -0,0 +1,239 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"simplebank/apikey"
+	db "simplebank/db/sqlc"
+	"simplebank/logging"
+	"simplebank/rbac"
+	"simplebank/token"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	requestIDHeader    = "X-Request-ID"
+	maxRequestIDLength = 128
+)
+
+// requestLogger tags every request with a request ID and carries a logger for it in the request context
+func requestLogger() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+
+		requestID := ctx.GetHeader(requestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = uuid.NewString()
+		}
+		ctx.Header(requestIDHeader, requestID)
+
+		logger := logging.FromContext(ctx.Request.Context()).With(slog.String("request_id", requestID))
+
+		span := trace.SpanFromContext(ctx.Request.Context())
+		if span.SpanContext().IsValid() {
+			span.SetAttributes(attribute.String("http.request_id", requestID))
+			logger = logger.With(slog.String("trace_id", span.SpanContext().TraceID().String()))
+		}
+
+		reqCtx := logging.WithRequestID(ctx.Request.Context(), requestID)
+		reqCtx = logging.WithLogger(reqCtx, logger)
+		ctx.Request = ctx.Request.WithContext(reqCtx)
+
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+		attrs := []slog.Attr{
+			slog.String("method", ctx.Request.Method),
+			slog.String("route", ctx.FullPath()),
+			slog.String("path", ctx.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("client_ip", ctx.ClientIP()),
+		}
+
+		if len(ctx.Errors) > 0 {
+			attrs = append(attrs, slog.String("error", ctx.Errors.String()))
+		}
+
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		}
+
+		logger.LogAttrs(reqCtx, level, "http request", attrs...)
+	}
+}
+
+// validRequestID accepts only short printable IDs so callers cannot inject into the logs
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+
+	for _, c := range requestID {
+		if c < 0x21 || c > 0x7e {
+			return false
+		}
+	}
+
+	return true
+}
+
+const (
+	authorizationHeaderKey  = "authorization"
+	authorizationTypeBearer = "bearer"
+	authorizationPayloadKey = "authorization_payload"
+	// apiKeyHeader carries an API key, backend services send one instead of a bearer access token
+	apiKeyHeader = "X-API-Key"
+	// authorizationApiKeyKey holds the API key of the callers that sent one
+	authorizationApiKeyKey = "authorization_api_key"
+)
+
+// authMiddleware rejects requests without a valid bearer access token or API key,
+// a request authenticated by an API key acts as the owner of the key
+func authMiddleware(tokenMaker token.Maker, store *db.Store) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if key := ctx.GetHeader(apiKeyHeader); key != "" {
+			apiKey, ok := authenticateApiKey(ctx, store, key)
+			if !ok {
+				return
+			}
+
+			logger := logging.FromContext(ctx.Request.Context()).With(
+				slog.String("username", apiKey.Owner),
+				slog.String("api_key", apiKey.Prefix),
+			)
+			ctx.Request = ctx.Request.WithContext(logging.WithLogger(ctx.Request.Context(), logger))
+
+			ctx.Set(authorizationPayloadKey, &token.Payload{
+				Username:  apiKey.Owner,
+				Role:      apiKey.OwnerRole,
+				IssuedAt:  apiKey.CreatedAt,
+				ExpiredAt: apiKey.ExpiresAt,
+			})
+			ctx.Set(authorizationApiKeyKey, apiKey)
+			ctx.Next()
+			return
+		}
+
+		authorizationHeader := ctx.GetHeader(authorizationHeaderKey)
+		if len(authorizationHeader) == 0 {
+			err := errors.New("authorization header is not provided")
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+
+		fields := strings.Fields(authorizationHeader)
+		if len(fields) < 2 {
+			err := errors.New("invalid authorization header format")
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+
+		authorizationType := strings.ToLower(fields[0])
+		if authorizationType != authorizationTypeBearer {
+			err := fmt.Errorf("unsupported authorization type %s", authorizationType)
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+
+		accessToken := fields[1]
+		payload, err := tokenMaker.VerifyToken(accessToken)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+
+		logger := logging.FromContext(ctx.Request.Context()).With(slog.String("username", payload.Username))
+		ctx.Request = ctx.Request.WithContext(logging.WithLogger(ctx.Request.Context(), logger))
+
+		ctx.Set(authorizationPayloadKey, payload)
+		ctx.Next()
+	}
+}
+
+// authenticateApiKey looks up the key sent by the caller and checks its secret, its expiry and the IP it comes from,
+// it writes the error response itself when the key is rejected
+func authenticateApiKey(ctx *gin.Context, store *db.Store, key string) (db.GetApiKeyByPrefixRow, bool) {
+	prefix, secret, err := apikey.Parse(key)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+		return db.GetApiKeyByPrefixRow{}, false
+	}
+
+	apiKey, err := store.GetApiKeyByPrefix(ctx, prefix)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(apikey.ErrInvalidKey))
+			return apiKey, false
+		}
+
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(err))
+		return apiKey, false
+	}
+
+	if !apikey.Verify(secret, apiKey.HashedSecret) {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(apikey.ErrInvalidKey))
+		return apiKey, false
+	}
+
+	if apiKey.RevokedAt.Valid {
+		err := errors.New("api key has been revoked")
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+		return apiKey, false
+	}
+
+	if time.Now().After(apiKey.ExpiresAt) {
+		err := errors.New("api key has expired")
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+		return apiKey, false
+	}
+
+	if !apikey.AllowsIP(apiKey.AllowedIps, ctx.ClientIP()) {
+		err := fmt.Errorf("api key is not allowed from %s", ctx.ClientIP())
+		ctx.AbortWithStatusJSON(http.StatusForbidden, errorResponse(err))
+		return apiKey, false
+	}
+
+	// last_used_at is a hint for the owner, failing to bump it must not fail the request
+	if err := store.TouchApiKey(ctx, apiKey.ID); err != nil {
+		reqCtx := ctx.Request.Context()
+		logging.FromContext(reqCtx).WarnContext(reqCtx, "api key last use not recorded", slog.Any("error", err))
+	}
+
+	return apiKey, true
+}
+
+// requirePermission lets through the callers whose role is granted permission and, for the callers sending an API key,
+// whose key has permission among its scopes; it runs after authMiddleware
+func requirePermission(permission rbac.Permission) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+		if !rbac.Can(authPayload.Role, permission) {
+			err := fmt.Errorf("permission %s required", permission)
+			ctx.AbortWithStatusJSON(http.StatusForbidden, errorResponse(err))
+			return
+		}
+
+		if apiKey, ok := ctx.Get(authorizationApiKeyKey); ok && !slices.Contains(apiKey.(db.GetApiKeyByPrefixRow).Scopes, string(permission)) {
+			err := fmt.Errorf("api key scope %s required", permission)
+			ctx.AbortWithStatusJSON(http.StatusForbidden, errorResponse(err))
+			return
+		}
+
+		ctx.Next()
+	}
+}
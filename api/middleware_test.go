@@ -0,0 +1,330 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"simplebank/apikey"
+	db "simplebank/db/sqlc"
+	"simplebank/logging"
+	"simplebank/ratelimit"
+	"simplebank/rbac"
+	"simplebank/token"
+	"simplebank/util"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestRequestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	defaultLogger := slog.Default()
+	slog.SetDefault(logging.New(&buf, slog.LevelInfo))
+	defer slog.SetDefault(defaultLogger)
+
+	router := gin.New()
+	router.Use(requestLogger())
+
+	var seenID string
+	router.GET("/ping", func(ctx *gin.Context) {
+		seenID = logging.RequestIDFromContext(ctx.Request.Context())
+		ctx.Status(http.StatusNoContent)
+	})
+
+	testCases := []struct {
+		name     string
+		incoming string
+		check    func(t *testing.T, requestID string)
+	}{
+		{
+			name:     "HonorIncoming",
+			incoming: "abc-123",
+			check: func(t *testing.T, requestID string) {
+				require.Equal(t, "abc-123", requestID)
+			},
+		},
+		{
+			name:     "GenerateMissing",
+			incoming: "",
+			check: func(t *testing.T, requestID string) {
+				require.Len(t, requestID, 36)
+			},
+		},
+		{
+			name:     "RejectInvalid",
+			incoming: "bad id\n",
+			check: func(t *testing.T, requestID string) {
+				require.NotEqual(t, "bad id\n", requestID)
+				require.Len(t, requestID, 36)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			buf.Reset()
+
+			request := httptest.NewRequest(http.MethodGet, "/ping", nil)
+			if tc.incoming != "" {
+				request.Header.Set(requestIDHeader, tc.incoming)
+			}
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+
+			requestID := recorder.Header().Get(requestIDHeader)
+			tc.check(t, requestID)
+			require.Equal(t, requestID, seenID)
+
+			var line map[string]any
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+			require.Equal(t, requestID, line["request_id"])
+			require.Equal(t, float64(http.StatusNoContent), line["status"])
+			require.Equal(t, "/ping", line["route"])
+		})
+	}
+}
+
+func TestTraceContextPropagation(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	previousProvider := otel.GetTracerProvider()
+	previousPropagator := otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	}()
+
+	server := newTestServer(t, nil)
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	request := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{}`))
+	request.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	response := httptest.NewRecorder()
+	server.router.ServeHTTP(response, request)
+	require.Equal(t, http.StatusBadRequest, response.Code)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	require.Equal(t, traceID, spans[0].SpanContext().TraceID().String())
+	require.Equal(t, "/users", spans[0].Name())
+	require.Contains(t, spans[0].Attributes(), attribute.String("http.request_id", response.Header().Get(requestIDHeader)))
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	testCases := []struct {
+		name          string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, "user", rbac.RoleCustomer)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:      "NoAuthorization",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "UnsupportedAuthorization",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				request.Header.Set(authorizationHeaderKey, "unsupported token")
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidAuthorizationFormat",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				request.Header.Set(authorizationHeaderKey, authorizationTypeBearer)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "MalformedApiKey",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				request.Header.Set(apiKeyHeader, "not-a-key")
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "ExpiredToken",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				accessToken, _, err := tokenMaker.CreateToken("user", rbac.RoleCustomer, -time.Minute)
+				require.NoError(t, err)
+				request.Header.Set(authorizationHeaderKey, authorizationTypeBearer+" "+accessToken)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestServer(t, nil)
+
+			authPath := "/auth"
+			server.router.GET(authPath, authMiddleware(server.tokenMaker, server.store), func(ctx *gin.Context) {
+				ctx.JSON(http.StatusOK, gin.H{})
+			})
+
+			recorder := httptest.NewRecorder()
+			request := httptest.NewRequest(http.MethodGet, authPath, nil)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestRequirePermission(t *testing.T) {
+	testCases := []struct {
+		name       string
+		role       string
+		permission rbac.Permission
+		status     int
+	}{
+		{name: "Admin", role: rbac.RoleAdmin, permission: rbac.LimitsManage, status: http.StatusOK},
+		{name: "SupportFreezes", role: rbac.RoleSupport, permission: rbac.AccountsFreeze, status: http.StatusOK},
+		{name: "SupportCannotAdjust", role: rbac.RoleSupport, permission: rbac.BalancesAdjust, status: http.StatusForbidden},
+		{name: "Customer", role: rbac.RoleCustomer, permission: rbac.TransfersWrite, status: http.StatusOK},
+		{name: "CustomerCannotListAll", role: rbac.RoleCustomer, permission: rbac.AccountsList, status: http.StatusForbidden},
+		{name: "NoRole", role: "", permission: rbac.AccountsRead, status: http.StatusForbidden},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestServer(t, nil)
+
+			path := "/permission-only"
+			server.router.GET(path, authMiddleware(server.tokenMaker, server.store), requirePermission(tc.permission), func(ctx *gin.Context) {
+				ctx.JSON(http.StatusOK, gin.H{})
+			})
+
+			recorder := httptest.NewRecorder()
+			request := httptest.NewRequest(http.MethodGet, path, nil)
+
+			addAuthorization(t, request, server.tokenMaker, "user", tc.role)
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, tc.status, recorder.Code)
+		})
+	}
+}
+
+func TestRequirePermissionApiKeyScopes(t *testing.T) {
+	testCases := []struct {
+		name       string
+		role       string
+		scopes     []string
+		permission rbac.Permission
+		status     int
+	}{
+		{name: "InScope", role: rbac.RoleCustomer, scopes: []string{"accounts:read"}, permission: rbac.AccountsRead, status: http.StatusOK},
+		{name: "OutOfScope", role: rbac.RoleCustomer, scopes: []string{"accounts:read"}, permission: rbac.TransfersWrite, status: http.StatusForbidden},
+		{name: "ScopeBeyondRole", role: rbac.RoleCustomer, scopes: []string{"limits:manage"}, permission: rbac.LimitsManage, status: http.StatusForbidden},
+		{name: "NoKeyManagement", role: rbac.RoleAdmin, scopes: []string{"accounts:read"}, permission: rbac.APIKeysManage, status: http.StatusForbidden},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			router := gin.New()
+
+			path := "/scope-only"
+			router.GET(path, func(ctx *gin.Context) {
+				ctx.Set(authorizationPayloadKey, &token.Payload{Username: "service", Role: tc.role})
+				ctx.Set(authorizationApiKeyKey, db.GetApiKeyByPrefixRow{Owner: "service", Scopes: tc.scopes})
+			}, requirePermission(tc.permission), func(ctx *gin.Context) {
+				ctx.JSON(http.StatusOK, gin.H{})
+			})
+
+			recorder := httptest.NewRecorder()
+			request := httptest.NewRequest(http.MethodGet, path, nil)
+			router.ServeHTTP(recorder, request)
+			require.Equal(t, tc.status, recorder.Code)
+		})
+	}
+}
+
+func TestClientIPTrustedProxies(t *testing.T) {
+	testCases := []struct {
+		name           string
+		trustedProxies []string
+		clientIP       string
+	}{
+		{
+			name:     "ForgedForwardedFor",
+			clientIP: "192.0.2.1",
+		},
+		{
+			name:           "UntrustedProxy",
+			trustedProxies: []string{"198.51.100.0/24"},
+			clientIP:       "192.0.2.1",
+		},
+		{
+			name:           "TrustedProxy",
+			trustedProxies: []string{"192.0.2.0/24"},
+			clientIP:       "10.0.0.1",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server, err := NewServer(util.Config{
+				TokenSymmetricKey: util.RandomString(32),
+				TrustedProxies:    tc.trustedProxies,
+			}, nil, ratelimit.NewLimiter(ratelimit.NewMemoryStore()))
+			require.NoError(t, err)
+
+			path := "/client-ip"
+			server.router.GET(path, func(ctx *gin.Context) {
+				ctx.String(http.StatusOK, ctx.ClientIP())
+			})
+
+			recorder := httptest.NewRecorder()
+			request := httptest.NewRequest(http.MethodGet, path, nil)
+			request.RemoteAddr = "192.0.2.1:1234"
+			request.Header.Set("X-Forwarded-For", "10.0.0.1")
+
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, tc.clientIP, recorder.Body.String())
+
+			// an API key allowed from 10.0.0.1 only is refused the forged address
+			require.Equal(t, tc.clientIP == "10.0.0.1", apikey.AllowsIP([]string{"10.0.0.1"}, recorder.Body.String()))
+		})
+	}
+}
+
+func TestNewServerInvalidTrustedProxies(t *testing.T) {
+	_, err := NewServer(util.Config{
+		TokenSymmetricKey: util.RandomString(32),
+		TrustedProxies:    []string{"not an ip"},
+	}, nil, ratelimit.NewLimiter(ratelimit.NewMemoryStore()))
+	require.Error(t, err)
+}
@@ -0,0 +1,1290 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	db "simplebank/db/sqlc"
+	"simplebank/event"
+	"simplebank/signing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// apiOperation documents one route registered in NewServer
+type apiOperation struct {
+	Method  string
+	Path    string
+	ID      string
+	Summary string
+	// URI is a struct whose uri tags describe the path parameters
+	URI any
+	// Query is a struct whose form tags describe the query parameters
+	Query any
+	// Body is the JSON request body
+	Body any
+	// Upload takes Body one per line, as CSV with a header or as JSON lines
+	Upload bool
+	// Response is the JSON body returned with Status, nil when Status has no body
+	Response any
+	Status   int
+	// Held is the JSON body returned with 202 Accepted when the request is held for review instead
+	Held any
+	// Errors lists the statuses that return an ErrorResponse
+	Errors []int
+	// Auth requires a bearer access token or an API key
+	Auth bool
+	// Idempotent accepts an Idempotency-Key header
+	Idempotent bool
+	// Signed requires the callers sending an API key to sign the request
+	Signed bool
+	// Paginated returns the cursor of the next page in the X-Next-Cursor header
+	Paginated bool
+	// EventStream streams Response as Server-Sent Events, resumed after a Last-Event-ID header
+	EventStream bool
+	// CSV downloads a CSV file instead of Response
+	CSV bool
+	// Unlimited skips the rate limits, every other route answers 429 once its caller has no token left
+	Unlimited bool
+}
+
+// apiOperations is the source of the OpenAPI document, keep it in sync with setupRouter
+var apiOperations = []apiOperation{
+	{
+		Method:   http.MethodPost,
+		Path:     "/users",
+		ID:       "createUser",
+		Summary:  "Register a user",
+		Body:     createUserRequest{},
+		Response: userResponse{},
+		Status:   http.StatusOK,
+		Errors:   []int{http.StatusBadRequest, http.StatusConflict, http.StatusInternalServerError},
+	},
+	{
+		Method:   http.MethodPost,
+		Path:     "/users/login",
+		ID:       "loginUser",
+		Summary:  "Log in and get an access and a refresh token",
+		Body:     loginUserRequest{},
+		Response: loginUserResponse{},
+		Status:   http.StatusOK,
+		Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusLocked, http.StatusInternalServerError},
+	},
+	{
+		Method:   http.MethodPost,
+		Path:     "/tokens/renew_access",
+		ID:       "renewAccessToken",
+		Summary:  "Get a new access token with a refresh token",
+		Body:     renewAccessTokenRequest{},
+		Response: renewAccessTokenResponse{},
+		Status:   http.StatusOK,
+		Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusInternalServerError},
+	},
+	{
+		Method:     http.MethodPost,
+		Path:       "/accounts",
+		ID:         "createAccount",
+		Summary:    "Create an account for the authenticated user",
+		Body:       createAccountRequest{},
+		Response:   db.Account{},
+		Status:     http.StatusOK,
+		Errors:     []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusConflict, http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity, http.StatusInternalServerError},
+		Auth:       true,
+		Idempotent: true,
+	},
+	{
+		Method:   http.MethodGet,
+		Path:     "/accounts/:id",
+		ID:       "getAccount",
+		Summary:  "Get an account by ID",
+		URI:      getAccountRequest{},
+		Response: db.Account{},
+		Status:   http.StatusOK,
+		Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+		Auth:     true,
+	},
+	{
+		Method:    http.MethodGet,
+		Path:      "/accounts",
+		ID:        "listAccounts",
+		Summary:   "List the accounts of the authenticated user, by page_id or cursor",
+		Query:     listAccountRequest{},
+		Response:  []db.Account{},
+		Status:    http.StatusOK,
+		Errors:    []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError},
+		Auth:      true,
+		Paginated: true,
+	},
+	{
+		Method:      http.MethodGet,
+		Path:        "/accounts/:id/stream",
+		ID:          "streamAccount",
+		Summary:     "Stream the events of an account, such as new entries and the balance after them, as Server-Sent Events",
+		URI:         streamAccountURI{},
+		Response:    event.Event{},
+		Status:      http.StatusOK,
+		Errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+		Auth:        true,
+		EventStream: true,
+	},
+	{
+		Method:   http.MethodGet,
+		Path:     "/accounts/:id/balance",
+		ID:       "getAccountBalance",
+		Summary:  "Get the ledger balance an account of the authenticated user had at a point in time",
+		URI:      getAccountRequest{},
+		Query:    getAccountBalanceQuery{},
+		Response: accountBalanceResponse{},
+		Status:   http.StatusOK,
+		Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+		Auth:     true,
+	},
+	{
+		Method:    http.MethodGet,
+		Path:      "/accounts/:id/entries",
+		ID:        "listAccountEntries",
+		Summary:   "List the entries of an account, oldest first",
+		URI:       listAccountEntriesURI{},
+		Query:     listAccountEntriesQuery{},
+		Response:  []db.Entry{},
+		Status:    http.StatusOK,
+		Errors:    []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+		Auth:      true,
+		Paginated: true,
+	},
+	{
+		Method:    http.MethodGet,
+		Path:      "/accounts/:id/holds",
+		ID:        "listAccountHolds",
+		Summary:   "List the holds on an account, oldest first",
+		URI:       listAccountEntriesURI{},
+		Query:     listAccountHoldsQuery{},
+		Response:  []db.Hold{},
+		Status:    http.StatusOK,
+		Errors:    []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+		Auth:      true,
+		Paginated: true,
+	},
+	{
+		Method:    http.MethodGet,
+		Path:      "/accounts/:id/interest-accruals",
+		ID:        "listInterestAccruals",
+		Summary:   "List the daily interest accruals of an account, oldest first",
+		URI:       listAccountEntriesURI{},
+		Query:     listInterestAccrualsQuery{},
+		Response:  []interestAccrualResponse{},
+		Status:    http.StatusOK,
+		Errors:    []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+		Auth:      true,
+		Paginated: true,
+	},
+	{
+		Method:   http.MethodGet,
+		Path:     "/products",
+		ID:       "listProducts",
+		Summary:  "List the account products, such as checking and savings",
+		Response: []db.Product{},
+		Status:   http.StatusOK,
+		Errors:   []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError},
+		Auth:     true,
+	},
+	{
+		Method:     http.MethodPost,
+		Path:       "/transfers",
+		ID:         "createTransfer",
+		Summary:    "Transfer money between two accounts with the same currency",
+		Body:       transferRequest{},
+		Response:   db.TransferTxResult{},
+		Status:     http.StatusOK,
+		Held:       riskAssessmentResponse{},
+		Errors:     []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusConflict, http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity, http.StatusInternalServerError},
+		Auth:       true,
+		Idempotent: true,
+		Signed:     true,
+	},
+	{
+		Method:   http.MethodGet,
+		Path:     "/transfers/quote",
+		ID:       "quoteTransfer",
+		Summary:  "Preview the fee of a transfer before submitting it",
+		Query:    quoteTransferRequest{},
+		Response: transferQuoteResponse{},
+		Status:   http.StatusOK,
+		Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+		Auth:     true,
+	},
+	{
+		Method:     http.MethodPost,
+		Path:       "/holds",
+		ID:         "createHold",
+		Summary:    "Reserve funds of an account of the authenticated user until they are captured, voided or expire",
+		Body:       createHoldRequest{},
+		Response:   db.HoldTxResult{},
+		Status:     http.StatusCreated,
+		Errors:     []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusConflict, http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity, http.StatusInternalServerError},
+		Auth:       true,
+		Idempotent: true,
+	},
+	{
+		Method:   http.MethodGet,
+		Path:     "/holds/:id",
+		ID:       "getHold",
+		Summary:  "Get a hold on or for an account of the authenticated user",
+		URI:      holdURI{},
+		Response: db.Hold{},
+		Status:   http.StatusOK,
+		Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+		Auth:     true,
+	},
+	{
+		Method:   http.MethodPost,
+		Path:     "/holds/:id/capture",
+		ID:       "captureHold",
+		Summary:  "Post part or all of a hold as a transfer",
+		URI:      holdURI{},
+		Body:     captureHoldRequest{},
+		Response: db.HoldTxResult{},
+		Status:   http.StatusOK,
+		Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity, http.StatusInternalServerError},
+		Auth:     true,
+	},
+	{
+		Method:   http.MethodPost,
+		Path:     "/holds/:id/void",
+		ID:       "voidHold",
+		Summary:  "Release what a hold still reserves",
+		URI:      holdURI{},
+		Response: db.HoldTxResult{},
+		Status:   http.StatusOK,
+		Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusConflict, http.StatusInternalServerError},
+		Auth:     true,
+	},
+	{
+		Method:    http.MethodGet,
+		Path:      "/loans",
+		ID:        "listLoans",
+		Summary:   "List the loans of the authenticated user, oldest first",
+		Query:     listLoansQuery{},
+		Response:  []db.Loan{},
+		Status:    http.StatusOK,
+		Errors:    []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError},
+		Auth:      true,
+		Paginated: true,
+	},
+	{
+		Method:   http.MethodGet,
+		Path:     "/loans/:id",
+		ID:       "getLoan",
+		Summary:  "Get a loan of the authenticated user",
+		URI:      loanURI{},
+		Response: db.Loan{},
+		Status:   http.StatusOK,
+		Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+		Auth:     true,
+	},
+	{
+		Method:   http.MethodGet,
+		Path:     "/loans/:id/installments",
+		ID:       "listLoanInstallments",
+		Summary:  "Get the amortization schedule of a loan with what is paid of each installment",
+		URI:      loanURI{},
+		Response: []loanInstallmentResponse{},
+		Status:   http.StatusOK,
+		Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+		Auth:     true,
+	},
+	{
+		Method:     http.MethodPost,
+		Path:       "/loans/:id/repayments",
+		ID:         "repayLoan",
+		Summary:    "Repay part of a loan out of its borrower account, penalties first, then interest, then principal",
+		URI:        loanURI{},
+		Body:       repayLoanRequest{},
+		Response:   repayLoanResponse{},
+		Status:     http.StatusCreated,
+		Errors:     []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusConflict, http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity, http.StatusInternalServerError},
+		Auth:       true,
+		Idempotent: true,
+	},
+	{
+		Method:    http.MethodGet,
+		Path:      "/loans/:id/repayments",
+		ID:        "listLoanRepayments",
+		Summary:   "List the repayments of a loan, oldest first",
+		URI:       loanURI{},
+		Query:     listLoanRepaymentsQuery{},
+		Response:  []db.LoanRepayment{},
+		Status:    http.StatusOK,
+		Errors:    []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+		Auth:      true,
+		Paginated: true,
+	},
+	{
+		Method:     http.MethodPost,
+		Path:       "/scheduled-transfers",
+		ID:         "createScheduledTransfer",
+		Summary:    "Schedule a one-off or recurring transfer out of an account of the authenticated user",
+		Body:       createScheduledTransferRequest{},
+		Response:   scheduledTransferResponse{},
+		Status:     http.StatusOK,
+		Errors:     []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusConflict, http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity, http.StatusInternalServerError},
+		Auth:       true,
+		Idempotent: true,
+	},
+	{
+		Method:    http.MethodGet,
+		Path:      "/scheduled-transfers",
+		ID:        "listScheduledTransfers",
+		Summary:   "List the scheduled transfers of the authenticated user",
+		Query:     listScheduledTransfersQuery{},
+		Response:  []scheduledTransferResponse{},
+		Status:    http.StatusOK,
+		Errors:    []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError},
+		Auth:      true,
+		Paginated: true,
+	},
+	{
+		Method:   http.MethodGet,
+		Path:     "/scheduled-transfers/:id",
+		ID:       "getScheduledTransfer",
+		Summary:  "Get a scheduled transfer by ID",
+		URI:      scheduledTransferURI{},
+		Response: scheduledTransferResponse{},
+		Status:   http.StatusOK,
+		Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+		Auth:     true,
+	},
+	{
+		Method:   http.MethodPatch,
+		Path:     "/scheduled-transfers/:id",
+		ID:       "updateScheduledTransfer",
+		Summary:  "Change the amount or end of a scheduled transfer, or pause and resume it",
+		URI:      scheduledTransferURI{},
+		Body:     updateScheduledTransferRequest{},
+		Response: scheduledTransferResponse{},
+		Status:   http.StatusOK,
+		Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity, http.StatusInternalServerError},
+		Auth:     true,
+	},
+	{
+		Method:  http.MethodDelete,
+		Path:    "/scheduled-transfers/:id",
+		ID:      "deleteScheduledTransfer",
+		Summary: "Cancel a scheduled transfer, its runs are kept",
+		URI:     scheduledTransferURI{},
+		Status:  http.StatusNoContent,
+		Errors:  []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusConflict, http.StatusInternalServerError},
+		Auth:    true,
+	},
+	{
+		Method:    http.MethodGet,
+		Path:      "/scheduled-transfers/:id/runs",
+		ID:        "listScheduledTransferRuns",
+		Summary:   "List the executed and failed occurrences of a scheduled transfer, oldest first",
+		URI:       scheduledTransferURI{},
+		Query:     listScheduledTransferRunsQuery{},
+		Response:  []scheduledTransferRunResponse{},
+		Status:    http.StatusOK,
+		Errors:    []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+		Auth:      true,
+		Paginated: true,
+	},
+	{
+		Method:     http.MethodPost,
+		Path:       "/transfer-batches",
+		ID:         "createTransferBatch",
+		Summary:    "Upload transfers out of accounts of the authenticated user, every row is validated before the batch is queued",
+		Query:      createTransferBatchQuery{},
+		Body:       transferRequest{},
+		Upload:     true,
+		Response:   transferBatchResponse{},
+		Status:     http.StatusAccepted,
+		Errors:     []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusConflict, http.StatusRequestEntityTooLarge, http.StatusUnsupportedMediaType, http.StatusUnprocessableEntity, http.StatusInternalServerError},
+		Auth:       true,
+		Idempotent: true,
+	},
+	{
+		Method:    http.MethodGet,
+		Path:      "/transfer-batches",
+		ID:        "listTransferBatches",
+		Summary:   "List the transfer batches of the authenticated user",
+		Query:     listTransferBatchesQuery{},
+		Response:  []transferBatchResponse{},
+		Status:    http.StatusOK,
+		Errors:    []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError},
+		Auth:      true,
+		Paginated: true,
+	},
+	{
+		Method:   http.MethodGet,
+		Path:     "/transfer-batches/:id",
+		ID:       "getTransferBatch",
+		Summary:  "Get the status and progress of a transfer batch",
+		URI:      transferBatchURI{},
+		Response: transferBatchResponse{},
+		Status:   http.StatusOK,
+		Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+		Auth:     true,
+	},
+	{
+		Method:    http.MethodGet,
+		Path:      "/transfer-batches/:id/items",
+		ID:        "listTransferBatchItems",
+		Summary:   "List the rows of a transfer batch in upload order, with the transfer or error of each",
+		URI:       transferBatchURI{},
+		Query:     listTransferBatchItemsQuery{},
+		Response:  []transferBatchItemResponse{},
+		Status:    http.StatusOK,
+		Errors:    []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+		Auth:      true,
+		Paginated: true,
+	},
+	{
+		Method:  http.MethodGet,
+		Path:    "/transfer-batches/:id/report",
+		ID:      "getTransferBatchReport",
+		Summary: "Download the rows of a transfer batch with their outcome as CSV",
+		URI:     transferBatchURI{},
+		Status:  http.StatusOK,
+		Errors:  []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+		Auth:    true,
+		CSV:     true,
+	},
+	{
+		Method:   http.MethodPost,
+		Path:     "/webhooks",
+		ID:       "createWebhookSubscription",
+		Summary:  "Subscribe a URL to events of the accounts of the authenticated user, the response carries the signing secret",
+		Body:     createWebhookSubscriptionRequest{},
+		Response: webhookSubscriptionResponse{},
+		Status:   http.StatusOK,
+		Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError},
+		Auth:     true,
+	},
+	{
+		Method:   http.MethodGet,
+		Path:     "/webhooks",
+		ID:       "listWebhookSubscriptions",
+		Summary:  "List the webhook subscriptions of the authenticated user",
+		Response: []webhookSubscriptionResponse{},
+		Status:   http.StatusOK,
+		Errors:   []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError},
+		Auth:     true,
+	},
+	{
+		Method:   http.MethodGet,
+		Path:     "/webhooks/:id",
+		ID:       "getWebhookSubscription",
+		Summary:  "Get a webhook subscription by ID",
+		URI:      webhookSubscriptionURI{},
+		Response: webhookSubscriptionResponse{},
+		Status:   http.StatusOK,
+		Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+		Auth:     true,
+	},
+	{
+		Method:  http.MethodDelete,
+		Path:    "/webhooks/:id",
+		ID:      "deleteWebhookSubscription",
+		Summary: "Delete a webhook subscription and its delivery log",
+		URI:     webhookSubscriptionURI{},
+		Status:  http.StatusNoContent,
+		Errors:  []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+		Auth:    true,
+	},
+	{
+		Method:    http.MethodGet,
+		Path:      "/webhooks/:id/deliveries",
+		ID:        "listWebhookDeliveries",
+		Summary:   "List the deliveries of a webhook subscription, oldest first",
+		URI:       webhookSubscriptionURI{},
+		Query:     listWebhookDeliveriesQuery{},
+		Response:  []webhookDeliveryResponse{},
+		Status:    http.StatusOK,
+		Errors:    []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+		Auth:      true,
+		Paginated: true,
+	},
+	{
+		Method:   http.MethodPost,
+		Path:     "/webhooks/:id/deliveries/:delivery_id/redeliver",
+		ID:       "redeliverWebhook",
+		Summary:  "Send a delivery again now, with a new 24 hour retry window",
+		URI:      redeliverWebhookURI{},
+		Response: webhookDeliveryResponse{},
+		Status:   http.StatusOK,
+		Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+		Auth:     true,
+	},
+	{
+		Method:   http.MethodPost,
+		Path:     "/api-keys",
+		ID:       "createApiKey",
+		Summary:  "Create an API key for the authenticated user, its secret is only returned now",
+		Body:     createApiKeyRequest{},
+		Response: apiKeyResponse{},
+		Status:   http.StatusOK,
+		Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError},
+		Auth:     true,
+	},
+	{
+		Method:   http.MethodGet,
+		Path:     "/api-keys",
+		ID:       "listApiKeys",
+		Summary:  "List the API keys of the authenticated user, revoked and expired ones included",
+		Response: []apiKeyResponse{},
+		Status:   http.StatusOK,
+		Errors:   []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError},
+		Auth:     true,
+	},
+	{
+		Method:   http.MethodDelete,
+		Path:     "/api-keys/:id",
+		ID:       "revokeApiKey",
+		Summary:  "Revoke an API key, it is rejected from then on",
+		URI:      apiKeyURI{},
+		Response: apiKeyResponse{},
+		Status:   http.StatusOK,
+		Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+		Auth:     true,
+	},
+	{
+		Method:    http.MethodGet,
+		Path:      "/admin/accounts",
+		ID:        "listAllAccounts",
+		Summary:   "List the accounts of every owner in the order they were opened",
+		Query:     listAllAccountsQuery{},
+		Response:  []db.Account{},
+		Status:    http.StatusOK,
+		Errors:    []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError},
+		Auth:      true,
+		Paginated: true,
+	},
+	{
+		Method:   http.MethodPost,
+		Path:     "/admin/accounts/:id/freeze",
+		ID:       "freezeAccount",
+		Summary:  "Freeze an account so that no money moves in or out of it",
+		URI:      getAccountRequest{},
+		Response: db.Account{},
+		Status:   http.StatusOK,
+		Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusConflict, http.StatusInternalServerError},
+		Auth:     true,
+	},
+	{
+		Method:   http.MethodPost,
+		Path:     "/admin/accounts/:id/unfreeze",
+		ID:       "unfreezeAccount",
+		Summary:  "Unfreeze a frozen account",
+		URI:      getAccountRequest{},
+		Response: db.Account{},
+		Status:   http.StatusOK,
+		Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusConflict, http.StatusInternalServerError},
+		Auth:     true,
+	},
+	{
+		Method:   http.MethodPut,
+		Path:     "/admin/users/:username/role",
+		ID:       "updateUserRole",
+		Summary:  "Change the role of a user, it applies to the access tokens issued from then on",
+		URI:      updateUserRoleURI{},
+		Body:     updateUserRoleRequest{},
+		Response: userResponse{},
+		Status:   http.StatusOK,
+		Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+		Auth:     true,
+	},
+	{
+		Method:   http.MethodPost,
+		Path:     "/admin/transfer-limits",
+		ID:       "createTransferLimit",
+		Summary:  "Change the transfer limits of an account, of a user or of every account, for good or until expires_at",
+		Body:     createTransferLimitRequest{},
+		Response: transferLimitResponse{},
+		Status:   http.StatusCreated,
+		Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+		Auth:     true,
+	},
+	{
+		Method:    http.MethodGet,
+		Path:      "/admin/transfer-limits",
+		ID:        "listTransferLimits",
+		Summary:   "List the changes of transfer limits, the audit trail of who changed which limits and why",
+		Query:     listTransferLimitsQuery{},
+		Response:  []transferLimitResponse{},
+		Status:    http.StatusOK,
+		Errors:    []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError},
+		Auth:      true,
+		Paginated: true,
+	},
+	{
+		Method:   http.MethodPost,
+		Path:     "/admin/interest-rates",
+		ID:       "createInterestRate",
+		Summary:  "Add a rate to the interest rate schedule of a product and currency, from effective_from on",
+		Body:     createInterestRateRequest{},
+		Response: interestRateResponse{},
+		Status:   http.StatusCreated,
+		Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+		Auth:     true,
+	},
+	{
+		Method:    http.MethodGet,
+		Path:      "/admin/interest-rates",
+		ID:        "listInterestRates",
+		Summary:   "List the interest rates of the products, in the order they were added",
+		Query:     listInterestRatesQuery{},
+		Response:  []interestRateResponse{},
+		Status:    http.StatusOK,
+		Errors:    []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError},
+		Auth:      true,
+		Paginated: true,
+	},
+	{
+		Method:   http.MethodPost,
+		Path:     "/admin/loans",
+		ID:       "createLoan",
+		Summary:  "Open a loan for the owner of the borrower account and disburse its principal into that account",
+		Body:     createLoanRequest{},
+		Response: createLoanResponse{},
+		Status:   http.StatusCreated,
+		Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusUnprocessableEntity, http.StatusInternalServerError},
+		Auth:     true,
+	},
+	{
+		Method:   http.MethodPost,
+		Path:     "/admin/pricing-rules",
+		ID:       "createPricingRule",
+		Summary:  "Add a pricing rule, its fees are credited to an active internal income account in its currency",
+		Body:     pricingRuleRequest{},
+		Response: pricingRuleResponse{},
+		Status:   http.StatusCreated,
+		Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusUnprocessableEntity, http.StatusInternalServerError},
+		Auth:     true,
+	},
+	{
+		Method:    http.MethodGet,
+		Path:      "/admin/pricing-rules",
+		ID:        "listPricingRules",
+		Summary:   "List the active and deactivated pricing rules, in the order they were added",
+		Query:     listPricingRulesQuery{},
+		Response:  []pricingRuleResponse{},
+		Status:    http.StatusOK,
+		Errors:    []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError},
+		Auth:      true,
+		Paginated: true,
+	},
+	{
+		Method:   http.MethodGet,
+		Path:     "/admin/pricing-rules/:id",
+		ID:       "getPricingRule",
+		Summary:  "Get a pricing rule by ID",
+		URI:      pricingRuleURI{},
+		Response: pricingRuleResponse{},
+		Status:   http.StatusOK,
+		Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+		Auth:     true,
+	},
+	{
+		Method:   http.MethodPut,
+		Path:     "/admin/pricing-rules/:id",
+		ID:       "updatePricingRule",
+		Summary:  "Replace a pricing rule, or deactivate it with active set to false",
+		URI:      pricingRuleURI{},
+		Body:     pricingRuleRequest{},
+		Response: pricingRuleResponse{},
+		Status:   http.StatusOK,
+		Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusUnprocessableEntity, http.StatusInternalServerError},
+		Auth:     true,
+	},
+	{
+		Method:   http.MethodPut,
+		Path:     "/admin/accounts/:id/tier",
+		ID:       "updateAccountTier",
+		Summary:  "Move a customer account to another pricing tier",
+		URI:      getAccountRequest{},
+		Body:     updateAccountTierRequest{},
+		Response: db.Account{},
+		Status:   http.StatusOK,
+		Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+		Auth:     true,
+	},
+	{
+		Method:   http.MethodPost,
+		Path:     "/admin/periods/close",
+		ID:       "closePeriod",
+		Summary:  "Close a day or a month: snapshot the balances, check the journals, record the trial balance and lock the period",
+		Body:     closePeriodRequest{},
+		Response: accountingPeriodResponse{},
+		Status:   http.StatusCreated,
+		Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusConflict, http.StatusUnprocessableEntity, http.StatusInternalServerError},
+		Auth:     true,
+	},
+	{
+		Method:    http.MethodGet,
+		Path:      "/admin/periods",
+		ID:        "listAccountingPeriods",
+		Summary:   "List the closed accounting periods in the order they were closed",
+		Query:     listAccountingPeriodsQuery{},
+		Response:  []accountingPeriodResponse{},
+		Status:    http.StatusOK,
+		Errors:    []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError},
+		Auth:      true,
+		Paginated: true,
+	},
+	{
+		Method:   http.MethodGet,
+		Path:     "/admin/periods/:id",
+		ID:       "getAccountingPeriod",
+		Summary:  "Get a closed accounting period with its trial balance",
+		URI:      accountingPeriodURI{},
+		Response: accountingPeriodResponse{},
+		Status:   http.StatusOK,
+		Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+		Auth:     true,
+	},
+	{
+		Method:   http.MethodPost,
+		Path:     "/admin/adjustments",
+		ID:       "createAdjustment",
+		Summary:  "Post a correcting journal, back-dated into an open period when effective_at is set",
+		Body:     createAdjustmentRequest{},
+		Response: db.PostJournalTxResult{},
+		Status:   http.StatusCreated,
+		Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity, http.StatusInternalServerError},
+		Auth:     true,
+	},
+	{
+		Method:   http.MethodGet,
+		Path:     "/admin/trial-balance",
+		ID:       "getTrialBalance",
+		Summary:  "Sum the balances of the customer and internal accounts by currency and account type at an instant",
+		Query:    getTrialBalanceQuery{},
+		Response: trialBalanceResponse{},
+		Status:   http.StatusOK,
+		Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError},
+		Auth:     true,
+	},
+	{
+		Method:   http.MethodGet,
+		Path:     "/admin/internal-accounts",
+		ID:       "listInternalAccounts",
+		Summary:  "List the internal general ledger accounts of every currency",
+		Response: []db.Account{},
+		Status:   http.StatusOK,
+		Errors:   []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError},
+		Auth:     true,
+	},
+	{
+		Method:    http.MethodGet,
+		Path:      "/admin/audit-log",
+		ID:        "listAuditLog",
+		Summary:   "List the audit log in the order it was written, filtered by actor, action, target and time",
+		Query:     listAuditLogQuery{},
+		Response:  []db.AuditLog{},
+		Status:    http.StatusOK,
+		Errors:    []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError},
+		Auth:      true,
+		Paginated: true,
+	},
+	{
+		Method:    http.MethodGet,
+		Path:      "/admin/risk-assessments",
+		ID:        "listRiskAssessments",
+		Summary:   "List the risk screening decisions of transfers, status=pending is the review queue",
+		Query:     listRiskAssessmentsQuery{},
+		Response:  []riskAssessmentResponse{},
+		Status:    http.StatusOK,
+		Errors:    []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError},
+		Auth:      true,
+		Paginated: true,
+	},
+	{
+		Method:   http.MethodGet,
+		Path:     "/admin/risk-assessments/:id",
+		ID:       "getRiskAssessment",
+		Summary:  "Get a risk screening decision with the rules that fired",
+		URI:      riskAssessmentURI{},
+		Response: riskAssessmentResponse{},
+		Status:   http.StatusOK,
+		Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+		Auth:     true,
+	},
+	{
+		Method:   http.MethodPost,
+		Path:     "/admin/risk-assessments/:id/approve",
+		ID:       "approveRiskAssessment",
+		Summary:  "Approve a transfer held for review and make it",
+		URI:      riskAssessmentURI{},
+		Response: reviewedTransferResponse{},
+		Status:   http.StatusOK,
+		Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity, http.StatusInternalServerError},
+		Auth:     true,
+	},
+	{
+		Method:   http.MethodPost,
+		Path:     "/admin/risk-assessments/:id/reject",
+		ID:       "rejectRiskAssessment",
+		Summary:  "Reject a transfer held for review",
+		URI:      riskAssessmentURI{},
+		Response: reviewedTransferResponse{},
+		Status:   http.StatusOK,
+		Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusConflict, http.StatusInternalServerError},
+		Auth:     true,
+	},
+	{
+		Method:    http.MethodGet,
+		Path:      "/openapi.json",
+		ID:        "getOpenAPI",
+		Summary:   "Get this OpenAPI document",
+		Response:  map[string]any{},
+		Status:    http.StatusOK,
+		Unlimited: true,
+	},
+}
+
+// Security schemes, an authenticated route accepts either one
+const (
+	// bearerAuth names the security scheme of the access tokens
+	bearerAuth = "bearerAuth"
+	// apiKeyAuth names the security scheme of the API keys
+	apiKeyAuth = "apiKeyAuth"
+)
+
+// ErrorResponse is the envelope returned with every error status
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// OpenAPIDocument builds the OpenAPI 3 document of the HTTP API
+// schemas and constraints are derived from the same struct tags gin uses to bind and validate requests
+func OpenAPIDocument() *openapi3.T {
+	builder := &schemaBuilder{schemas: openapi3.Schemas{}}
+	errorSchema := builder.schemaRef(reflect.TypeOf(ErrorResponse{}), false)
+
+	doc := &openapi3.T{
+		OpenAPI: "3.0.3",
+		Info: &openapi3.Info{
+			Title:   "Simple Bank API",
+			Version: "1.0.0",
+		},
+		Paths: openapi3.Paths{},
+	}
+
+	for _, op := range apiOperations {
+		operation := openapi3.NewOperation()
+		operation.OperationID = op.ID
+		operation.Summary = op.Summary
+		operation.Responses = openapi3.Responses{}
+
+		if op.URI != nil {
+			operation.Parameters = append(operation.Parameters, builder.parameters(reflect.TypeOf(op.URI))...)
+		}
+
+		if op.Query != nil {
+			operation.Parameters = append(operation.Parameters, builder.parameters(reflect.TypeOf(op.Query))...)
+		}
+
+		if op.Idempotent {
+			maxLength := uint64(maxIdempotencyKeyLength)
+			schema := openapi3.NewStringSchema()
+			schema.MaxLength = &maxLength
+			param := openapi3.NewHeaderParameter(idempotencyKeyHeader).
+				WithDescription("Replays the stored response when the same key is sent again").
+				WithSchema(schema)
+			operation.Parameters = append(operation.Parameters, &openapi3.ParameterRef{Value: param})
+		}
+
+		if op.Signed {
+			for _, header := range []struct{ name, description string }{
+				{signing.TimestampHeader, "Unix seconds when the request was signed, required with an API key"},
+				{signing.NonceHeader, "Random value used once per signing secret, required with an API key"},
+				{signing.SignatureHeader, "Hex HMAC-SHA256 of the method, path, timestamp, nonce and body SHA-256 of the request, one per line, keyed by the signing secret of the API key; required with an API key"},
+			} {
+				param := openapi3.NewHeaderParameter(header.name).
+					WithDescription(header.description).
+					WithSchema(openapi3.NewStringSchema())
+				operation.Parameters = append(operation.Parameters, &openapi3.ParameterRef{Value: param})
+			}
+		}
+
+		if op.EventStream {
+			schema := openapi3.NewInt64Schema()
+			schema.Min = new(float64)
+			param := openapi3.NewHeaderParameter(lastEventIDHeader).
+				WithDescription("Resumes the stream after this event id, without it the stream starts with the next event").
+				WithSchema(schema)
+			operation.Parameters = append(operation.Parameters, &openapi3.ParameterRef{Value: param})
+		}
+
+		if op.Upload {
+			operation.RequestBody = &openapi3.RequestBodyRef{
+				Value: openapi3.NewRequestBody().
+					WithRequired(true).
+					WithDescription("One transfer per line, as CSV with a header naming the columns or as JSON lines").
+					WithContent(openapi3.Content{
+						csvContentType:       openapi3.NewMediaType().WithSchema(openapi3.NewStringSchema()),
+						jsonLinesContentType: openapi3.NewMediaType().WithSchemaRef(builder.schemaRef(reflect.TypeOf(op.Body), true)),
+					}),
+			}
+		} else if op.Body != nil {
+			operation.RequestBody = &openapi3.RequestBodyRef{
+				Value: openapi3.NewRequestBody().
+					WithRequired(true).
+					WithJSONSchemaRef(builder.schemaRef(reflect.TypeOf(op.Body), true)),
+			}
+		}
+
+		if op.Auth {
+			operation.Security = openapi3.NewSecurityRequirements().
+				With(openapi3.NewSecurityRequirement().Authenticate(bearerAuth)).
+				With(openapi3.NewSecurityRequirement().Authenticate(apiKeyAuth))
+		}
+
+		response := openapi3.NewResponse().WithDescription(http.StatusText(op.Status))
+		switch {
+		case op.EventStream:
+			response.WithDescription("Server-Sent Events whose data is the JSON event and id the event id").
+				WithContent(openapi3.NewContentWithSchemaRef(builder.schemaRef(reflect.TypeOf(op.Response), false), []string{"text/event-stream"}))
+		case op.CSV:
+			response.WithContent(openapi3.NewContentWithSchema(openapi3.NewStringSchema(), []string{csvContentType}))
+		case op.Response != nil:
+			response.WithJSONSchemaRef(builder.schemaRef(reflect.TypeOf(op.Response), false))
+		}
+		response.Headers = responseHeaders(op)
+		operation.AddResponse(op.Status, response)
+
+		if op.Held != nil {
+			operation.AddResponse(http.StatusAccepted, openapi3.NewResponse().
+				WithDescription("Held for review").
+				WithJSONSchemaRef(builder.schemaRef(reflect.TypeOf(op.Held), false)))
+		}
+
+		for _, status := range op.Errors {
+			operation.AddResponse(status, openapi3.NewResponse().
+				WithDescription(http.StatusText(status)).
+				WithJSONSchemaRef(errorSchema))
+		}
+
+		if !op.Unlimited {
+			rateLimited := openapi3.NewResponse().
+				WithDescription(http.StatusText(http.StatusTooManyRequests)).
+				WithJSONSchemaRef(errorSchema)
+			rateLimited.Headers = openapi3.Headers{
+				retryAfterHeader: intHeader("Seconds until the next token"),
+			}
+			operation.AddResponse(http.StatusTooManyRequests, rateLimited)
+		}
+
+		doc.AddOperation(openAPIPath(op.Path), op.Method, operation)
+	}
+
+	doc.Components = &openapi3.Components{
+		Schemas: builder.schemas,
+		SecuritySchemes: openapi3.SecuritySchemes{
+			bearerAuth: &openapi3.SecuritySchemeRef{
+				Value: openapi3.NewJWTSecurityScheme(),
+			},
+			apiKeyAuth: &openapi3.SecuritySchemeRef{
+				Value: openapi3.NewSecurityScheme().
+					WithType("apiKey").
+					WithIn(openapi3.ParameterInHeader).
+					WithName(apiKeyHeader).
+					WithDescription("Limited to the scopes of the key, API keys cannot manage API keys"),
+			},
+		},
+	}
+
+	return doc
+}
+
+func responseHeaders(op apiOperation) openapi3.Headers {
+	headers := openapi3.Headers{}
+
+	if op.Paginated {
+		headers[nextCursorHeader] = &openapi3.HeaderRef{Value: &openapi3.Header{Parameter: openapi3.Parameter{
+			Description: "Cursor of the next page, absent on the last page",
+			Schema:      openapi3.NewStringSchema().NewRef(),
+		}}}
+	}
+
+	if op.Idempotent {
+		headers[idempotentReplayedHeader] = &openapi3.HeaderRef{Value: &openapi3.Header{Parameter: openapi3.Parameter{
+			Description: "Set to true when the response is replayed for a repeated Idempotency-Key",
+			Schema:      openapi3.NewStringSchema().NewRef(),
+		}}}
+	}
+
+	if !op.Unlimited {
+		headers[rateLimitLimitHeader] = intHeader("Tokens the bucket of the caller holds when full")
+		headers[rateLimitRemainingHeader] = intHeader("Tokens left in the bucket of the caller")
+		headers[rateLimitResetHeader] = intHeader("Seconds until the bucket of the caller is full again")
+	}
+
+	if len(headers) == 0 {
+		return nil
+	}
+
+	return headers
+}
+
+func intHeader(description string) *openapi3.HeaderRef {
+	return &openapi3.HeaderRef{Value: &openapi3.Header{Parameter: openapi3.Parameter{
+		Description: description,
+		Schema:      openapi3.NewIntegerSchema().NewRef(),
+	}}}
+}
+
+func (server *Server) getOpenAPI(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, server.openAPI)
+}
+
+// openAPIPath turns the gin path /accounts/:id into /accounts/{id}
+func openAPIPath(path string) string {
+	segments := strings.Split(path, "/")
+
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// schemaBuilder converts Go types into schemas registered under components
+type schemaBuilder struct {
+	schemas openapi3.Schemas
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	nullTimeType = reflect.TypeOf(sql.NullTime{})
+	uuidType     = reflect.TypeOf(uuid.UUID{})
+	rawJSONType  = reflect.TypeOf(json.RawMessage{})
+)
+
+// schemaRef returns the schema of t, named structs become references to components
+// request types take required from their binding tags, response types require every field without omitempty
+func (builder *schemaBuilder) schemaRef(t reflect.Type, request bool) *openapi3.SchemaRef {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return openapi3.NewSchemaRef("", openapi3.NewDateTimeSchema())
+	case t == uuidType:
+		return openapi3.NewSchemaRef("", openapi3.NewUUIDSchema())
+	case t == rawJSONType:
+		return openapi3.NewSchemaRef("", openapi3.NewSchema())
+	case t == nullTimeType:
+		schema := openapi3.NewObjectSchema().
+			WithProperty("Time", openapi3.NewDateTimeSchema()).
+			WithProperty("Valid", openapi3.NewBoolSchema())
+		schema.Required = []string{"Time", "Valid"}
+		return openapi3.NewSchemaRef("", schema)
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return openapi3.NewSchemaRef("", openapi3.NewBoolSchema())
+	case reflect.Int32:
+		return openapi3.NewSchemaRef("", openapi3.NewInt32Schema())
+	case reflect.Int, reflect.Int64:
+		return openapi3.NewSchemaRef("", openapi3.NewInt64Schema())
+	case reflect.Float32, reflect.Float64:
+		return openapi3.NewSchemaRef("", openapi3.NewFloat64Schema())
+	case reflect.String:
+		return openapi3.NewSchemaRef("", openapi3.NewStringSchema())
+	case reflect.Slice, reflect.Array:
+		schema := openapi3.NewArraySchema()
+		schema.Items = builder.schemaRef(t.Elem(), request)
+		return openapi3.NewSchemaRef("", schema)
+	case reflect.Map:
+		return openapi3.NewSchemaRef("", openapi3.NewObjectSchema().WithAnyAdditionalProperties())
+	case reflect.Struct:
+		return builder.structRef(t, request)
+	}
+
+	return openapi3.NewSchemaRef("", openapi3.NewSchema())
+}
+
+func (builder *schemaBuilder) structRef(t reflect.Type, request bool) *openapi3.SchemaRef {
+	name := strings.ToUpper(t.Name()[:1]) + t.Name()[1:]
+	ref := "#/components/schemas/" + name
+
+	if existing, ok := builder.schemas[name]; ok {
+		return openapi3.NewSchemaRef(ref, existing.Value)
+	}
+
+	schema := openapi3.NewObjectSchema()
+	builder.schemas[name] = openapi3.NewSchemaRef("", schema)
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		jsonName, omitEmpty := jsonFieldName(field)
+		if jsonName == "" {
+			continue
+		}
+
+		property := builder.schemaRef(field.Type, request)
+		if property.Ref == "" {
+			applyBinding(property.Value, field.Tag.Get("binding"))
+		}
+		schema.WithPropertyRef(jsonName, property)
+
+		if (request && hasBindingRule(field.Tag.Get("binding"), "required")) || (!request && !omitEmpty) {
+			schema.Required = append(schema.Required, jsonName)
+		}
+	}
+
+	return openapi3.NewSchemaRef(ref, schema)
+}
+
+// parameters describes the uri and form fields of t as path and query parameters
+func (builder *schemaBuilder) parameters(t reflect.Type) openapi3.Parameters {
+	var params openapi3.Parameters
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		binding := field.Tag.Get("binding")
+
+		schema := builder.schemaRef(field.Type, true).Value
+		applyBinding(schema, binding)
+
+		var param *openapi3.Parameter
+		if name := field.Tag.Get("uri"); name != "" {
+			param = openapi3.NewPathParameter(name)
+		} else if name := field.Tag.Get("form"); name != "" {
+			param = openapi3.NewQueryParameter(name).WithRequired(hasBindingRule(binding, "required"))
+		} else {
+			continue
+		}
+
+		params = append(params, &openapi3.ParameterRef{Value: param.WithSchema(schema)})
+	}
+
+	return params
+}
+
+func jsonFieldName(field reflect.StructField) (string, bool) {
+	if !field.IsExported() {
+		return "", false
+	}
+
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+
+	name, options, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = field.Name
+	}
+
+	return name, strings.Contains(options, "omitempty")
+}
+
+// hasBindingRule reports whether binding applies rule to the field itself, the rules after dive apply to its items
+func hasBindingRule(binding string, rule string) bool {
+	for _, r := range strings.Split(binding, ",") {
+		if r == "dive" {
+			return false
+		}
+		if r == rule {
+			return true
+		}
+	}
+
+	return false
+}
+
+// applyBinding copies the validator rules gin enforces onto the schema
+// the rules after dive apply to the items of an array
+func applyBinding(schema *openapi3.Schema, binding string) {
+	rules := strings.Split(binding, ",")
+
+	for i, rule := range rules {
+		name, value, _ := strings.Cut(rule, "=")
+
+		switch name {
+		case "dive":
+			if schema.Items != nil && schema.Items.Ref == "" {
+				applyBinding(schema.Items.Value, strings.Join(rules[i+1:], ","))
+			}
+			return
+		case "oneof":
+			for _, option := range strings.Fields(value) {
+				schema.Enum = append(schema.Enum, option)
+			}
+		case "min", "gte":
+			setMinimum(schema, value, false)
+		case "gt":
+			setMinimum(schema, value, true)
+		case "max", "lte":
+			setMaximum(schema, value, false)
+		case "lt":
+			setMaximum(schema, value, true)
+		case "email":
+			schema.Format = "email"
+		case "url":
+			schema.Format = "uri"
+		case "alphanum":
+			schema.Pattern = "^[a-zA-Z0-9]+$"
+		case "datetime":
+			if value == dateLayout {
+				schema.Format = "date"
+			}
+		}
+	}
+}
+
+func setMinimum(schema *openapi3.Schema, value string, exclusive bool) {
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return
+	}
+
+	if schema.Type == openapi3.TypeString {
+		schema.MinLength = uint64(n)
+		return
+	}
+
+	if schema.Type == openapi3.TypeArray {
+		schema.MinItems = uint64(n)
+		return
+	}
+
+	schema.Min = &n
+	schema.ExclusiveMin = exclusive
+}
+
+func setMaximum(schema *openapi3.Schema, value string, exclusive bool) {
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return
+	}
+
+	if schema.Type == openapi3.TypeString {
+		max := uint64(n)
+		schema.MaxLength = &max
+		return
+	}
+
+	if schema.Type == openapi3.TypeArray {
+		max := uint64(n)
+		schema.MaxItems = &max
+		return
+	}
+
+	schema.Max = &n
+	schema.ExclusiveMax = exclusive
+}
@@ -0,0 +1,312 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"simplebank/rbac"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAPIDocumentIsValid(t *testing.T) {
+	doc := OpenAPIDocument()
+	require.NoError(t, doc.Validate(context.Background()))
+}
+
+func TestOpenAPICoversEveryRoute(t *testing.T) {
+	server := newTestServer(t, nil)
+	doc := OpenAPIDocument()
+
+	registered := map[string]bool{}
+	for _, route := range server.router.Routes() {
+		key := route.Method + " " + openAPIPath(route.Path)
+		registered[key] = true
+
+		pathItem := doc.Paths.Find(openAPIPath(route.Path))
+		require.NotNil(t, pathItem, "route %s is missing from the spec", key)
+		require.NotNil(t, pathItem.GetOperation(route.Method), "route %s is missing from the spec", key)
+	}
+
+	for path, pathItem := range doc.Paths {
+		for method := range pathItem.Operations() {
+			key := method + " " + path
+			require.True(t, registered[key], "spec operation %s has no handler", key)
+		}
+	}
+}
+
+func TestOpenAPIDocsUpToDate(t *testing.T) {
+	var generated bytes.Buffer
+	encoder := json.NewEncoder(&generated)
+	encoder.SetIndent("", "  ")
+	require.NoError(t, encoder.Encode(OpenAPIDocument()))
+
+	committed, err := os.ReadFile("../docs/openapi.json")
+	require.NoError(t, err)
+	require.Equal(t, string(committed), generated.String(), "docs/openapi.json is stale, run make openapi")
+}
+
+func TestServeOpenAPI(t *testing.T) {
+	server := newTestServer(t, nil)
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/openapi.json", nil)
+	server.router.ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &doc))
+	require.Equal(t, "3.0.3", doc["openapi"])
+}
+
+// TestOpenAPIRequestValidation checks that requests the spec rejects are also rejected by the handlers
+// and that the error envelope they return matches the spec
+func TestOpenAPIRequestValidation(t *testing.T) {
+	server := newTestServer(t, nil)
+
+	openapi3.DefineStringFormat("email", openapi3.FormatOfStringForEmail)
+
+	doc := OpenAPIDocument()
+	require.NoError(t, doc.Validate(context.Background()))
+	router, err := gorillamux.NewRouter(doc)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name   string
+		method string
+		url    string
+		body   string
+	}{
+		{
+			name:   "CreateUserInvalidEmail",
+			method: http.MethodPost,
+			url:    "/users",
+			body:   `{"username":"alice","password":"secret","full_name":"Alice","email":"alice"}`,
+		},
+		{
+			name:   "CreateUserShortPassword",
+			method: http.MethodPost,
+			url:    "/users",
+			body:   `{"username":"alice","password":"abc","full_name":"Alice","email":"alice@email.com"}`,
+		},
+		{
+			name:   "LoginUserMissingPassword",
+			method: http.MethodPost,
+			url:    "/users/login",
+			body:   `{"username":"alice"}`,
+		},
+		{
+			name:   "CreateAccountMissingCurrency",
+			method: http.MethodPost,
+			url:    "/accounts",
+			body:   `{}`,
+		},
+		{
+			name:   "CreateAccountUnsupportedCurrency",
+			method: http.MethodPost,
+			url:    "/accounts",
+			body:   `{"currency":"GBP"}`,
+		},
+		{
+			name:   "GetAccountInvalidID",
+			method: http.MethodGet,
+			url:    "/accounts/0",
+		},
+		{
+			name:   "StreamAccountInvalidID",
+			method: http.MethodGet,
+			url:    "/accounts/0/stream",
+		},
+		{
+			name:   "ListAccountsMissingPageSize",
+			method: http.MethodGet,
+			url:    "/accounts?page_id=1",
+		},
+		{
+			name:   "ListAccountsPageTooLarge",
+			method: http.MethodGet,
+			url:    "/accounts?page_id=1&page_size=50",
+		},
+		{
+			name:   "ListAccountEntriesPageTooLarge",
+			method: http.MethodGet,
+			url:    "/accounts/1/entries?page_size=500",
+		},
+		{
+			name:   "CreateTransferZeroAmount",
+			method: http.MethodPost,
+			url:    "/transfers",
+			body:   `{"from_account_id":1,"to_account_id":2,"amount":0,"currency":"USD"}`,
+		},
+		{
+			name:   "CreateTransferUnsupportedCurrency",
+			method: http.MethodPost,
+			url:    "/transfers",
+			body:   `{"from_account_id":1,"to_account_id":2,"amount":10,"currency":"GBP"}`,
+		},
+		{
+			name:   "QuoteTransferZeroAmount",
+			method: http.MethodGet,
+			url:    "/transfers/quote?from_account_id=1&amount=0&currency=USD",
+		},
+		{
+			name:   "CreateAccountLongProduct",
+			method: http.MethodPost,
+			url:    "/accounts",
+			body:   `{"currency":"USD","product":"` + strings.Repeat("x", 51) + `"}`,
+		},
+		{
+			name:   "GetAccountBalanceMalformedAt",
+			method: http.MethodGet,
+			url:    "/accounts/1/balance?at=yesterday",
+		},
+		{
+			name:   "ListInterestAccrualsMissingPageSize",
+			method: http.MethodGet,
+			url:    "/accounts/1/interest-accruals",
+		},
+		{
+			name:   "CreateHoldUnknownCurrency",
+			method: http.MethodPost,
+			url:    "/holds",
+			body:   `{"account_id":1,"to_account_id":2,"amount":10,"currency":"GBP"}`,
+		},
+		{
+			name:   "CaptureHoldZeroAmount",
+			method: http.MethodPost,
+			url:    "/holds/1/capture",
+			body:   `{"amount":0}`,
+		},
+		{
+			name:   "ListAccountHoldsMissingPageSize",
+			method: http.MethodGet,
+			url:    "/accounts/1/holds",
+		},
+		{
+			name:   "ListLoansMissingPageSize",
+			method: http.MethodGet,
+			url:    "/loans",
+		},
+		{
+			name:   "RepayLoanZeroAmount",
+			method: http.MethodPost,
+			url:    "/loans/1/repayments",
+			body:   `{"amount":0}`,
+		},
+		{
+			name:   "CreateScheduledTransferMissingStartAt",
+			method: http.MethodPost,
+			url:    "/scheduled-transfers",
+			body:   `{"from_account_id":1,"to_account_id":2,"amount":10,"currency":"USD"}`,
+		},
+		{
+			name:   "UpdateScheduledTransferUnknownStatus",
+			method: http.MethodPatch,
+			url:    "/scheduled-transfers/1",
+			body:   `{"status":"completed"}`,
+		},
+		{
+			name:   "CreateTransferBatchUnknownMode",
+			method: http.MethodPost,
+			url:    "/transfer-batches?mode=sometimes",
+		},
+		{
+			name:   "ListTransferBatchItemsPageTooLarge",
+			method: http.MethodGet,
+			url:    "/transfer-batches/1/items?page_size=500",
+		},
+		{
+			name:   "CreateWebhookSubscriptionNoEventTypes",
+			method: http.MethodPost,
+			url:    "/webhooks",
+			body:   `{"url":"https://example.com/hook","event_types":[]}`,
+		},
+		{
+			name:   "CreateWebhookSubscriptionUnknownEventType",
+			method: http.MethodPost,
+			url:    "/webhooks",
+			body:   `{"url":"https://example.com/hook","event_types":["account.created"]}`,
+		},
+		{
+			name:   "CreateWebhookSubscriptionShortSecret",
+			method: http.MethodPost,
+			url:    "/webhooks",
+			body:   `{"url":"https://example.com/hook","event_types":["entry.posted"],"secret":"short"}`,
+		},
+		{
+			name:   "ListWebhookDeliveriesPageTooLarge",
+			method: http.MethodGet,
+			url:    "/webhooks/1/deliveries?page_size=500",
+		},
+		{
+			name:   "ListAllAccountsUnknownStatus",
+			method: http.MethodGet,
+			url:    "/admin/accounts?page_size=10&status=open",
+		},
+		{
+			name:   "UpdateUserRoleUnknownRole",
+			method: http.MethodPut,
+			url:    "/admin/users/bob/role",
+			body:   `{"role":"root"}`,
+		},
+		{
+			name:   "CreateApiKeyNoScopes",
+			method: http.MethodPost,
+			url:    "/api-keys",
+			body:   `{"name":"payouts","scopes":[],"expires_in_days":30}`,
+		},
+		{
+			name:   "CreateApiKeyExpiryTooLong",
+			method: http.MethodPost,
+			url:    "/api-keys",
+			body:   `{"name":"payouts","scopes":["transfers:write"],"expires_in_days":366}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			request := httptest.NewRequest(tc.method, "http://localhost"+tc.url, strings.NewReader(tc.body))
+			if tc.body != "" {
+				request.Header.Set("Content-Type", "application/json")
+			}
+
+			route, pathParams, err := router.FindRoute(request)
+			require.NoError(t, err)
+
+			input := &openapi3filter.RequestValidationInput{
+				Request:    request,
+				PathParams: pathParams,
+				Route:      route,
+				Options: &openapi3filter.Options{
+					AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+				},
+			}
+			require.Error(t, openapi3filter.ValidateRequest(context.Background(), input), "spec accepts the request")
+
+			request = httptest.NewRequest(tc.method, tc.url, strings.NewReader(tc.body))
+			addAuthorization(t, request, server.tokenMaker, "alice", rbac.RoleAdmin)
+			recorder := httptest.NewRecorder()
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, http.StatusBadRequest, recorder.Code, "handler accepts the request")
+
+			err = openapi3filter.ValidateResponse(context.Background(), &openapi3filter.ResponseValidationInput{
+				RequestValidationInput: input,
+				Status:                 recorder.Code,
+				Header:                 recorder.Header(),
+				Body:                   io.NopCloser(bytes.NewReader(recorder.Body.Bytes())),
+			})
+			require.NoError(t, err)
+		})
+	}
+}
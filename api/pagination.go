@@ -0,0 +1,17 @@
+package api
+
+import (
+	"simplebank/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+// nextCursorHeader carries the cursor of the next page, it is absent on the last page
+const nextCursorHeader = "X-Next-Cursor"
+
+// setNextCursor advertises the next page when the current one is full
+func setNextCursor(ctx *gin.Context, count int, pageSize int32, lastID int64) {
+	if cursor := util.NextCursor(count, pageSize, lastID); cursor != "" {
+		ctx.Header(nextCursorHeader, cursor)
+	}
+}
@@ -0,0 +1,210 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	db "simplebank/db/sqlc"
+	"simplebank/token"
+	"simplebank/util"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type closePeriodRequest struct {
+	Kind string `json:"kind" binding:"required,oneof=day month"`
+	// StartDate is the UTC day to close, or the first day of the month to close
+	StartDate string `json:"start_date" binding:"required,datetime=2006-01-02"`
+}
+
+type accountingPeriodResponse struct {
+	ID        int64     `json:"id"`
+	Kind      string    `json:"kind"`
+	StartDate string    `json:"start_date"`
+	EndDate   string    `json:"end_date"`
+	ClosedBy  string    `json:"closed_by"`
+	ClosedAt  time.Time `json:"closed_at"`
+	// TrialBalance sums the closing balances by currency and account type, the negative ones as debits and the positive ones as credits
+	TrialBalance []db.CurrencyTrialBalance `json:"trial_balance,omitempty"`
+}
+
+func newAccountingPeriodResponse(period db.AccountingPeriod, trialBalance []db.PeriodTrialBalance) accountingPeriodResponse {
+	return accountingPeriodResponse{
+		ID:           period.ID,
+		Kind:         period.Kind,
+		StartDate:    period.StartDay.Format(dateLayout),
+		EndDate:      period.EndDay.Format(dateLayout),
+		ClosedBy:     period.ClosedBy,
+		ClosedAt:     period.ClosedAt,
+		TrialBalance: db.GroupPeriodTrialBalance(trialBalance),
+	}
+}
+
+// closePeriod runs the end of day or month close: snapshots, integrity check, trial balance, then the lock
+func (server *Server) closePeriod(ctx *gin.Context) {
+	var req closePeriodRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	start, err := time.Parse(dateLayout, req.StartDate)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if _, _, err := db.PeriodBounds(req.Kind, start); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	result, err := server.store.ClosePeriodTx(ctx, db.ClosePeriodTxParams{
+		Kind:     req.Kind,
+		Start:    start,
+		ClosedBy: ctx.MustGet(authorizationPayloadKey).(*token.Payload).Username,
+		Now:      time.Now(),
+	})
+	if err != nil {
+		server.periodError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, newAccountingPeriodResponse(result.Period, result.TrialBalance))
+}
+
+type listAccountingPeriodsQuery struct {
+	PageSize int32  `form:"page_size" binding:"required,min=1,max=100"`
+	Cursor   string `form:"cursor"`
+}
+
+// listAccountingPeriods lists the closed periods in the order they were closed
+func (server *Server) listAccountingPeriods(ctx *gin.Context) {
+	var query listAccountingPeriodsQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	afterID, err := util.DecodeCursor(query.Cursor)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	periods, err := server.store.ListAccountingPeriods(ctx, db.ListAccountingPeriodsParams{
+		AfterID:    afterID,
+		LimitCount: query.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if len(periods) > 0 {
+		setNextCursor(ctx, len(periods), query.PageSize, periods[len(periods)-1].ID)
+	}
+
+	rsp := make([]accountingPeriodResponse, 0, len(periods))
+	for _, period := range periods {
+		rsp = append(rsp, newAccountingPeriodResponse(period, nil))
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type accountingPeriodURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// getAccountingPeriod returns a closed period with its trial balance
+func (server *Server) getAccountingPeriod(ctx *gin.Context) {
+	var uri accountingPeriodURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	period, err := server.store.GetAccountingPeriod(ctx, uri.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	trialBalance, err := server.store.ListPeriodTrialBalances(ctx, period.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newAccountingPeriodResponse(period, trialBalance))
+}
+
+type adjustmentLeg struct {
+	AccountID int64 `json:"account_id" binding:"required,min=1"`
+	// Amount is taken out of the account when negative and added to it when positive
+	Amount int64 `json:"amount" binding:"required"`
+}
+
+type createAdjustmentRequest struct {
+	Description string          `json:"description" binding:"required,max=200"`
+	Legs        []adjustmentLeg `json:"legs" binding:"required,min=2,max=20,dive"`
+	// EffectiveAt back-dates the adjustment into an open period, now when left out
+	EffectiveAt *time.Time `json:"effective_at"`
+}
+
+// createAdjustment posts a correcting journal, the legs must sum to zero in the currency of each account
+func (server *Server) createAdjustment(ctx *gin.Context) {
+	var req createAdjustmentRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	arg := db.PostJournalTxParams{
+		Kind:        db.JournalKindAdjustment,
+		Description: req.Description,
+	}
+	for _, leg := range req.Legs {
+		arg.Legs = append(arg.Legs, db.Leg{AccountID: leg.AccountID, Amount: leg.Amount})
+	}
+
+	if req.EffectiveAt != nil {
+		if req.EffectiveAt.After(time.Now()) {
+			err := errors.New("effective_at cannot be in the future")
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		arg.EffectiveAt = *req.EffectiveAt
+	}
+
+	result, err := server.store.PostJournalTx(ctx, arg)
+	if err != nil {
+		server.periodError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, result)
+}
+
+func (server *Server) periodError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, db.ErrPeriodClosed):
+		ctx.JSON(http.StatusConflict, errorResponse(err))
+	case errors.Is(err, db.ErrPeriodNotEnded),
+		errors.Is(err, db.ErrIntegrityCheckFailed),
+		errors.Is(err, db.ErrInvalidJournal),
+		errors.Is(err, db.ErrUnbalancedJournal):
+		ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+	case errors.Is(err, sql.ErrNoRows):
+		ctx.JSON(http.StatusNotFound, errorResponse(err))
+	default:
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+	}
+}
@@ -0,0 +1,299 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	db "simplebank/db/sqlc"
+	"simplebank/util"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// pricingRuleRequest creates or replaces a pricing rule, it prices the transfers out of the accounts of its tier and currency
+type pricingRuleRequest struct {
+	// Tier limits the rule to the accounts of a pricing tier, every tier when left out
+	Tier     *string `json:"tier" binding:"omitempty,oneof=standard premium"`
+	Currency string  `json:"currency" binding:"required,oneof=USD EUR"`
+	// MinAmount and MaxAmount bound the amounts the rule prices, MaxAmount is exclusive and unbounded when left out
+	MinAmount int64  `json:"min_amount" binding:"min=0"`
+	MaxAmount *int64 `json:"max_amount" binding:"omitempty,gt=0"`
+	FlatFee   int64  `json:"flat_fee" binding:"min=0"`
+	// PercentBps is the percentage of the amount in basis points, 100 is 1%
+	PercentBps int32 `json:"percent_bps" binding:"min=0,max=10000"`
+	MinFee     int64 `json:"min_fee" binding:"min=0"`
+	// MaxFee caps the fee, no cap when left out
+	MaxFee *int64 `json:"max_fee" binding:"omitempty,min=0"`
+	// FeeAccountID is the internal income account credited with the fees, the fee income account when left out
+	FeeAccountID int64 `json:"fee_account_id" binding:"omitempty,min=1"`
+	// Priority picks the rule among the ones matching a transfer, the highest wins
+	Priority int32 `json:"priority"`
+	// Active is true when left out, rules are deactivated rather than deleted
+	Active *bool `json:"active"`
+}
+
+// check rejects the bounds the constraints of the table would refuse
+func (req pricingRuleRequest) check() error {
+	if req.MaxAmount != nil && *req.MaxAmount <= req.MinAmount {
+		return errors.New("max_amount must be above min_amount")
+	}
+
+	if req.MaxFee != nil && *req.MaxFee < req.MinFee {
+		return errors.New("max_fee cannot be below min_fee")
+	}
+
+	return nil
+}
+
+type pricingRuleURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type pricingRuleResponse struct {
+	ID           int64     `json:"id"`
+	Tier         *string   `json:"tier,omitempty"`
+	Currency     string    `json:"currency"`
+	MinAmount    int64     `json:"min_amount"`
+	MaxAmount    *int64    `json:"max_amount,omitempty"`
+	FlatFee      int64     `json:"flat_fee"`
+	PercentBps   int32     `json:"percent_bps"`
+	MinFee       int64     `json:"min_fee"`
+	MaxFee       *int64    `json:"max_fee,omitempty"`
+	FeeAccountID int64     `json:"fee_account_id"`
+	Priority     int32     `json:"priority"`
+	Active       bool      `json:"active"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func newPricingRuleResponse(rule db.PricingRule) pricingRuleResponse {
+	return pricingRuleResponse{
+		ID:           rule.ID,
+		Tier:         nullString(rule.Tier),
+		Currency:     rule.Currency,
+		MinAmount:    rule.MinAmount,
+		MaxAmount:    nullInt64(rule.MaxAmount),
+		FlatFee:      rule.FlatFee,
+		PercentBps:   rule.PercentBps,
+		MinFee:       rule.MinFee,
+		MaxFee:       nullInt64(rule.MaxFee),
+		FeeAccountID: rule.FeeAccountID,
+		Priority:     rule.Priority,
+		Active:       rule.Active,
+		CreatedAt:    rule.CreatedAt,
+	}
+}
+
+// bindPricingRule binds and checks the body of a pricing rule and resolves its fee account
+func (server *Server) bindPricingRule(ctx *gin.Context) (pricingRuleRequest, int64, bool) {
+	var req pricingRuleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return req, 0, false
+	}
+
+	if err := req.check(); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return req, 0, false
+	}
+
+	feeAccountID, ok := server.ledgerAccount(ctx, req.FeeAccountID, db.GLFeeIncome, req.Currency)
+	return req, feeAccountID, ok
+}
+
+// pricingRuleError answers a failed change of a pricing rule
+func pricingRuleError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		ctx.JSON(http.StatusNotFound, errorResponse(err))
+	case errors.Is(err, db.ErrInvalidFeeAccount):
+		ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+	default:
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+	}
+}
+
+// createPricingRule adds a pricing rule, the transfers are priced with it from then on
+func (server *Server) createPricingRule(ctx *gin.Context) {
+	req, feeAccountID, ok := server.bindPricingRule(ctx)
+	if !ok {
+		return
+	}
+
+	if req.Active != nil && !*req.Active {
+		err := errors.New("a pricing rule is created active")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var tier sql.NullString
+	if req.Tier != nil {
+		tier = sql.NullString{String: *req.Tier, Valid: true}
+	}
+
+	rule, err := server.store.CreatePricingRuleTx(ctx, db.CreatePricingRuleParams{
+		Tier:         tier,
+		Currency:     req.Currency,
+		MinAmount:    req.MinAmount,
+		MaxAmount:    toNullInt64(req.MaxAmount),
+		FlatFee:      req.FlatFee,
+		PercentBps:   req.PercentBps,
+		MinFee:       req.MinFee,
+		MaxFee:       toNullInt64(req.MaxFee),
+		FeeAccountID: feeAccountID,
+		Priority:     req.Priority,
+	})
+	if err != nil {
+		pricingRuleError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, newPricingRuleResponse(rule))
+}
+
+type listPricingRulesQuery struct {
+	Currency string `form:"currency" binding:"omitempty,oneof=USD EUR"`
+	PageSize int32  `form:"page_size" binding:"required,min=1,max=100"`
+	Cursor   string `form:"cursor"`
+}
+
+// listPricingRules lists the active and deactivated pricing rules in the order they were added
+func (server *Server) listPricingRules(ctx *gin.Context) {
+	var query listPricingRulesQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	afterID, err := util.DecodeCursor(query.Cursor)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	rules, err := server.store.ListPricingRules(ctx, db.ListPricingRulesParams{
+		Currency:   sql.NullString{String: query.Currency, Valid: query.Currency != ""},
+		AfterID:    afterID,
+		LimitCount: query.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if len(rules) > 0 {
+		setNextCursor(ctx, len(rules), query.PageSize, rules[len(rules)-1].ID)
+	}
+
+	rsp := make([]pricingRuleResponse, 0, len(rules))
+	for _, rule := range rules {
+		rsp = append(rsp, newPricingRuleResponse(rule))
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// getPricingRule returns a pricing rule by ID
+func (server *Server) getPricingRule(ctx *gin.Context) {
+	var uri pricingRuleURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	rule, err := server.store.GetPricingRule(ctx, uri.ID)
+	if err != nil {
+		pricingRuleError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newPricingRuleResponse(rule))
+}
+
+// updatePricingRule replaces a pricing rule, or deactivates it with active set to false
+func (server *Server) updatePricingRule(ctx *gin.Context) {
+	var uri pricingRuleURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	req, feeAccountID, ok := server.bindPricingRule(ctx)
+	if !ok {
+		return
+	}
+
+	var tier sql.NullString
+	if req.Tier != nil {
+		tier = sql.NullString{String: *req.Tier, Valid: true}
+	}
+
+	rule, err := server.store.UpdatePricingRuleTx(ctx, db.UpdatePricingRuleParams{
+		ID:           uri.ID,
+		Tier:         tier,
+		Currency:     req.Currency,
+		MinAmount:    req.MinAmount,
+		MaxAmount:    toNullInt64(req.MaxAmount),
+		FlatFee:      req.FlatFee,
+		PercentBps:   req.PercentBps,
+		MinFee:       req.MinFee,
+		MaxFee:       toNullInt64(req.MaxFee),
+		FeeAccountID: feeAccountID,
+		Priority:     req.Priority,
+		Active:       req.Active == nil || *req.Active,
+	})
+	if err != nil {
+		pricingRuleError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newPricingRuleResponse(rule))
+}
+
+type updateAccountTierRequest struct {
+	Tier string `json:"tier" binding:"required,oneof=standard premium"`
+}
+
+// updateAccountTier moves a customer account to another pricing tier, its next transfers are priced by the rules of that tier
+func (server *Server) updateAccountTier(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req updateAccountTierRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, err := server.store.GetAccount(ctx, uri.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if !db.CustomerProduct(account.Product) {
+		err := fmt.Errorf("account [%d] is not a customer account", account.ID)
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return
+	}
+
+	account, err = server.store.UpdateAccountTierTx(ctx, db.UpdateAccountTierParams{
+		ID:   account.ID,
+		Tier: req.Tier,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, account)
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"errors"
+	"log/slog"
+	"math"
+	"net/http"
+	db "simplebank/db/sqlc"
+	"simplebank/logging"
+	"simplebank/ratelimit"
+	"simplebank/token"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	rateLimitLimitHeader     = "X-RateLimit-Limit"
+	rateLimitRemainingHeader = "X-RateLimit-Remaining"
+	rateLimitResetHeader     = "X-RateLimit-Reset"
+	retryAfterHeader         = "Retry-After"
+)
+
+// errRateLimited is returned once a caller has no token left in one of the buckets of its route
+var errRateLimited = errors.New("too many requests, retry later")
+
+// rateLimit takes a token from the bucket of the caller for policy and turns the request away with 429
+// once none is left; it fails open when the store is unreachable so that an outage of the limiter
+// does not take the API down with it
+func (server *Server) rateLimit(policy ratelimit.Policy) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		decision, err := server.limiter.Allow(ctx, rateLimitKey(ctx), policy)
+		if err != nil {
+			logging.FromContext(ctx).ErrorContext(ctx, "rate limit not checked",
+				slog.String("policy", policy.Name), slog.Any("error", err))
+			ctx.Next()
+			return
+		}
+
+		ctx.Header(rateLimitLimitHeader, strconv.Itoa(decision.Limit))
+		ctx.Header(rateLimitRemainingHeader, strconv.Itoa(decision.Remaining))
+		ctx.Header(rateLimitResetHeader, strconv.FormatInt(ceilSeconds(decision.ResetAfter), 10))
+
+		if !decision.Allowed {
+			ctx.Header(retryAfterHeader, strconv.FormatInt(max(ceilSeconds(decision.RetryAfter), 1), 10))
+			ctx.AbortWithStatusJSON(http.StatusTooManyRequests, errorResponse(errRateLimited))
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// rateLimitKey keys the bucket by the API key, then the user, then the client IP for the public routes,
+// so that every key of a user has a bucket of its own
+func rateLimitKey(ctx *gin.Context) string {
+	if apiKey, ok := ctx.Get(authorizationApiKeyKey); ok {
+		return "api_key:" + apiKey.(db.GetApiKeyByPrefixRow).Prefix
+	}
+
+	if payload, ok := ctx.Get(authorizationPayloadKey); ok {
+		return "user:" + payload.(*token.Payload).Username
+	}
+
+	return "ip:" + ctx.ClientIP()
+}
+
+func ceilSeconds(d time.Duration) int64 {
+	return int64(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	db "simplebank/db/sqlc"
+	"simplebank/ratelimit"
+	"simplebank/rbac"
+	"simplebank/token"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+type unavailableRateLimitStore struct{}
+
+func (unavailableRateLimitStore) Take(ctx context.Context, key string, policy ratelimit.Policy, now time.Time) (time.Time, bool, error) {
+	return time.Time{}, false, errors.New("store unavailable")
+}
+
+func TestRateLimit(t *testing.T) {
+	policy := ratelimit.Policy{Name: "test", Limit: 2, Period: time.Hour}
+
+	server := newTestServer(t, nil)
+
+	path := "/limited"
+	server.router.GET(path, func(ctx *gin.Context) {
+		if username := ctx.GetHeader("X-Test-User"); username != "" {
+			ctx.Set(authorizationPayloadKey, &token.Payload{Username: username, Role: rbac.RoleCustomer})
+		}
+		if prefix := ctx.GetHeader("X-Test-Api-Key"); prefix != "" {
+			ctx.Set(authorizationApiKeyKey, db.GetApiKeyByPrefixRow{Prefix: prefix})
+		}
+	}, server.rateLimit(policy), func(ctx *gin.Context) {
+		ctx.Status(http.StatusOK)
+	})
+
+	send := func(setupRequest func(request *http.Request)) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodGet, path, nil)
+		request.RemoteAddr = "192.0.2.1:1234"
+		setupRequest(request)
+
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	asAlice := func(request *http.Request) {
+		request.Header.Set("X-Test-User", "alice")
+	}
+
+	for remaining := 1; remaining >= 0; remaining-- {
+		recorder := send(asAlice)
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.Equal(t, "2", recorder.Header().Get(rateLimitLimitHeader))
+		require.Equal(t, strconv.Itoa(remaining), recorder.Header().Get(rateLimitRemainingHeader))
+		require.Empty(t, recorder.Header().Get(retryAfterHeader))
+	}
+
+	recorder := send(asAlice)
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	require.Equal(t, "0", recorder.Header().Get(rateLimitRemainingHeader))
+	require.Equal(t, "1800", recorder.Header().Get(retryAfterHeader))
+	require.Equal(t, "3600", recorder.Header().Get(rateLimitResetHeader))
+
+	// another user, an API key of alice and an anonymous caller each have a bucket of their own
+	recorder = send(func(request *http.Request) {
+		request.Header.Set("X-Test-User", "bob")
+	})
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = send(func(request *http.Request) {
+		asAlice(request)
+		request.Header.Set("X-Test-Api-Key", "0123abcd")
+	})
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	for i := 0; i < policy.Limit; i++ {
+		recorder = send(func(request *http.Request) {})
+		require.Equal(t, http.StatusOK, recorder.Code)
+	}
+
+	recorder = send(func(request *http.Request) {})
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+
+	recorder = send(func(request *http.Request) {
+		request.RemoteAddr = "192.0.2.2:1234"
+	})
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	// the requests go through while the store is unavailable
+	server.limiter = ratelimit.NewLimiter(unavailableRateLimitStore{})
+
+	recorder = send(asAlice)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Empty(t, recorder.Header().Get(rateLimitLimitHeader))
+}
+
+// TestRateLimitBeforeAuth checks that callers guessing tokens are limited by IP before they are authenticated
+func TestRateLimitBeforeAuth(t *testing.T) {
+	server := newTestServer(t, nil)
+
+	send := func() *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodGet, "/accounts", nil)
+		request.RemoteAddr = "192.0.2.1:1234"
+		request.Header.Set(authorizationHeaderKey, authorizationTypeBearer+" not-a-token")
+
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	for i := 0; i < ratelimit.IPPolicy.Limit; i++ {
+		require.Equal(t, http.StatusUnauthorized, send().Code)
+	}
+
+	require.Equal(t, http.StatusTooManyRequests, send().Code)
+}
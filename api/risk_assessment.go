@@ -0,0 +1,193 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	db "simplebank/db/sqlc"
+	"simplebank/risk"
+	"simplebank/token"
+	"simplebank/util"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type riskAssessmentResponse struct {
+	ID            int64      `json:"id"`
+	Owner         string     `json:"owner"`
+	FromAccountID int64      `json:"from_account_id"`
+	ToAccountID   int64      `json:"to_account_id"`
+	Amount        int64      `json:"amount"`
+	Currency      string     `json:"currency"`
+	Decision      string     `json:"decision"`
+	Score         int32      `json:"score"`
+	Hits          []risk.Hit `json:"hits"`
+	// Status is allowed, blocked, or pending review until approved or rejected
+	Status     string     `json:"status"`
+	TransferID *int64     `json:"transfer_id,omitempty"`
+	ReviewedBy *string    `json:"reviewed_by,omitempty"`
+	ReviewedAt *time.Time `json:"reviewed_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func newRiskAssessmentResponse(assessment db.RiskAssessment) riskAssessmentResponse {
+	hits := []risk.Hit{}
+	// the hits are written by ScreenTransferTx, they always decode
+	_ = json.Unmarshal(assessment.Hits, &hits)
+
+	return riskAssessmentResponse{
+		ID:            assessment.ID,
+		Owner:         assessment.Owner,
+		FromAccountID: assessment.FromAccountID,
+		ToAccountID:   assessment.ToAccountID,
+		Amount:        assessment.Amount,
+		Currency:      assessment.Currency,
+		Decision:      assessment.Decision,
+		Score:         assessment.Score,
+		Hits:          hits,
+		Status:        assessment.Status,
+		TransferID:    nullInt64(assessment.TransferID),
+		ReviewedBy:    nullString(assessment.ReviewedBy),
+		ReviewedAt:    nullTime(assessment.ReviewedAt),
+		CreatedAt:     assessment.CreatedAt,
+	}
+}
+
+// reviewedTransferResponse is a settled review, with the transfer it made when approved
+type reviewedTransferResponse struct {
+	Assessment riskAssessmentResponse `json:"assessment"`
+	Transfer   *db.TransferTxResult   `json:"transfer,omitempty"`
+}
+
+type listRiskAssessmentsQuery struct {
+	Status   string `form:"status" binding:"omitempty,oneof=allowed blocked pending approved rejected"`
+	PageSize int32  `form:"page_size" binding:"required,min=1,max=100"`
+	Cursor   string `form:"cursor"`
+}
+
+// listRiskAssessments lists the screening decisions, status=pending is the review queue
+func (server *Server) listRiskAssessments(ctx *gin.Context) {
+	var query listRiskAssessmentsQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	afterID, err := util.DecodeCursor(query.Cursor)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	assessments, err := server.store.ListRiskAssessments(ctx, db.ListRiskAssessmentsParams{
+		Status:     sql.NullString{String: query.Status, Valid: query.Status != ""},
+		AfterID:    afterID,
+		LimitCount: query.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if len(assessments) > 0 {
+		setNextCursor(ctx, len(assessments), query.PageSize, assessments[len(assessments)-1].ID)
+	}
+
+	rsp := make([]riskAssessmentResponse, 0, len(assessments))
+	for _, assessment := range assessments {
+		rsp = append(rsp, newRiskAssessmentResponse(assessment))
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type riskAssessmentURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (server *Server) getRiskAssessment(ctx *gin.Context) {
+	var uri riskAssessmentURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	assessment, err := server.store.GetRiskAssessment(ctx, uri.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newRiskAssessmentResponse(assessment))
+}
+
+// approveRiskAssessment makes a transfer held for review
+func (server *Server) approveRiskAssessment(ctx *gin.Context) {
+	server.reviewRiskAssessment(ctx, true)
+}
+
+// rejectRiskAssessment drops a transfer held for review
+func (server *Server) rejectRiskAssessment(ctx *gin.Context) {
+	server.reviewRiskAssessment(ctx, false)
+}
+
+func (server *Server) reviewRiskAssessment(ctx *gin.Context, approve bool) {
+	var uri riskAssessmentURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	result, err := server.store.ReviewTransferTx(ctx, db.ReviewTransferTxParams{
+		ID:         uri.ID,
+		ReviewedBy: authPayload.Username,
+		Approve:    approve,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+		case errors.Is(err, db.ErrReviewNotPending):
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+		case errors.Is(err, db.ErrTransferLimitExceeded),
+			errors.Is(err, db.ErrInsufficientFunds),
+			errors.Is(err, db.ErrAccountNotActive),
+			errors.Is(err, db.ErrCurrencyMismatch):
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		default:
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, reviewedTransferResponse{
+		Assessment: newRiskAssessmentResponse(result.Assessment),
+		Transfer:   result.Transfer,
+	})
+}
+
+// screenDeferredTransfer screens a transfer set up now and posted later by a worker,
+// it writes the error response and returns false when the transfer is refused
+func (server *Server) screenDeferredTransfer(ctx *gin.Context, arg db.ScreenTransferTxParams) bool {
+	_, err := server.store.ScreenDeferredTransfer(ctx, server.risk, arg)
+	if err != nil {
+		if errors.Is(err, db.ErrTransferBlocked) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return false
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,503 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	db "simplebank/db/sqlc"
+	"simplebank/schedule"
+	"simplebank/scheduler"
+	"simplebank/token"
+	"simplebank/util"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// startAtLeeway tolerates clock skew between clients and the server for schedules starting now
+const startAtLeeway = time.Minute
+
+var (
+	errScheduledTransferFinished = errors.New("scheduled transfer is over")
+	errEndBeforeStart            = errors.New("end_at must not be before start_at")
+)
+
+type createScheduledTransferRequest struct {
+	FromAccountID int64     `json:"from_account_id" binding:"required,min=1"`
+	ToAccountID   int64     `json:"to_account_id" binding:"required,min=1"`
+	Amount        int64     `json:"amount" binding:"required,gt=0"`
+	Currency      string    `json:"currency" binding:"required,oneof=USD EUR"`
+	StartAt       time.Time `json:"start_at" binding:"required"`
+	// Recurrence is an RRULE such as FREQ=MONTHLY;INTERVAL=1, empty for a one-off transfer at start_at
+	Recurrence string     `json:"recurrence" binding:"omitempty,max=100"`
+	EndAt      *time.Time `json:"end_at"`
+}
+
+type scheduledTransferResponse struct {
+	ID               int64      `json:"id"`
+	FromAccountID    int64      `json:"from_account_id"`
+	ToAccountID      int64      `json:"to_account_id"`
+	Amount           int64      `json:"amount"`
+	Currency         string     `json:"currency"`
+	StartAt          time.Time  `json:"start_at"`
+	Recurrence       string     `json:"recurrence"`
+	EndAt            *time.Time `json:"end_at,omitempty"`
+	Status           string     `json:"status"`
+	NextOccurrenceAt *time.Time `json:"next_occurrence_at,omitempty"`
+	NextRunAt        *time.Time `json:"next_run_at,omitempty"`
+	Attempts         int32      `json:"attempts"`
+	LastError        *string    `json:"last_error,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+func newScheduledTransferResponse(st db.ScheduledTransfer) scheduledTransferResponse {
+	return scheduledTransferResponse{
+		ID:               st.ID,
+		FromAccountID:    st.FromAccountID,
+		ToAccountID:      st.ToAccountID,
+		Amount:           st.Amount,
+		Currency:         st.Currency,
+		StartAt:          st.StartAt,
+		Recurrence:       st.Recurrence,
+		EndAt:            nullTime(st.EndAt),
+		Status:           st.Status,
+		NextOccurrenceAt: nullTime(st.NextOccurrenceAt),
+		NextRunAt:        nullTime(st.NextRunAt),
+		Attempts:         st.Attempts,
+		LastError:        nullString(st.LastError),
+		CreatedAt:        st.CreatedAt,
+		UpdatedAt:        st.UpdatedAt,
+	}
+}
+
+type scheduledTransferRunResponse struct {
+	ID                  int64     `json:"id"`
+	ScheduledTransferID int64     `json:"scheduled_transfer_id"`
+	OccurrenceAt        time.Time `json:"occurrence_at"`
+	Status              string    `json:"status"`
+	Attempts            int32     `json:"attempts"`
+	TransferID          *int64    `json:"transfer_id,omitempty"`
+	LastError           *string   `json:"last_error,omitempty"`
+	CreatedAt           time.Time `json:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at"`
+}
+
+func newScheduledTransferRunResponse(run db.ScheduledTransferRun) scheduledTransferRunResponse {
+	rsp := scheduledTransferRunResponse{
+		ID:                  run.ID,
+		ScheduledTransferID: run.ScheduledTransferID,
+		OccurrenceAt:        run.OccurrenceAt,
+		Status:              run.Status,
+		Attempts:            run.Attempts,
+		LastError:           nullString(run.LastError),
+		CreatedAt:           run.CreatedAt,
+		UpdatedAt:           run.UpdatedAt,
+	}
+
+	if run.TransferID.Valid {
+		rsp.TransferID = &run.TransferID.Int64
+	}
+
+	return rsp
+}
+
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+func nullString(s sql.NullString) *string {
+	if !s.Valid {
+		return nil
+	}
+	return &s.String
+}
+
+func (server *Server) createScheduledTransfer(ctx *gin.Context) {
+	var req createScheduledTransferRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if req.FromAccountID == req.ToAccountID {
+		err := errors.New("cannot transfer to the same account")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if req.StartAt.Before(time.Now().Add(-startAtLeeway)) {
+		err := errors.New("start_at must not be in the past")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	st := db.ScheduledTransfer{StartAt: req.StartAt, Recurrence: req.Recurrence}
+	if req.EndAt != nil {
+		st.EndAt = sql.NullTime{Time: *req.EndAt, Valid: true}
+	}
+
+	first, ok, err := scheduler.NextOccurrence(st, req.StartAt.Add(-time.Nanosecond))
+	if err == nil && !ok {
+		err = errEndBeforeStart
+	}
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	fromAccount, ok := server.customerAccount(ctx, req.FromAccountID, req.Currency)
+	if !ok {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if fromAccount.Owner != authPayload.Username {
+		err := errors.New("from account doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return
+	}
+
+	if _, ok := server.customerAccount(ctx, req.ToAccountID, req.Currency); !ok {
+		return
+	}
+
+	ok = server.screenDeferredTransfer(ctx, db.ScreenTransferTxParams{
+		TransferCreateParams: db.TransferCreateParams{
+			FromAccountID: req.FromAccountID,
+			ToAccountID:   req.ToAccountID,
+			Amount:        req.Amount,
+		},
+		Currency: req.Currency,
+		Owner:    authPayload.Username,
+	})
+	if !ok {
+		return
+	}
+
+	created, err := server.store.CreateScheduledTransfer(ctx, db.CreateScheduledTransferParams{
+		Owner:            authPayload.Username,
+		FromAccountID:    req.FromAccountID,
+		ToAccountID:      req.ToAccountID,
+		Amount:           req.Amount,
+		Currency:         req.Currency,
+		StartAt:          req.StartAt,
+		Recurrence:       req.Recurrence,
+		EndAt:            st.EndAt,
+		NextOccurrenceAt: sql.NullTime{Time: first, Valid: true},
+		NextRunAt:        sql.NullTime{Time: first, Valid: true},
+	})
+
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newScheduledTransferResponse(created))
+}
+
+type listScheduledTransfersQuery struct {
+	PageSize int32  `form:"page_size" binding:"required,min=1,max=100"`
+	Cursor   string `form:"cursor"`
+}
+
+func (server *Server) listScheduledTransfers(ctx *gin.Context) {
+	var query listScheduledTransfersQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	afterID, err := util.DecodeCursor(query.Cursor)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	scheduled, err := server.store.ListScheduledTransfers(ctx, db.ListScheduledTransfersParams{
+		Owner:      authPayload.Username,
+		AfterID:    afterID,
+		LimitCount: query.PageSize,
+	})
+
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if len(scheduled) > 0 {
+		setNextCursor(ctx, len(scheduled), query.PageSize, scheduled[len(scheduled)-1].ID)
+	}
+
+	rsp := make([]scheduledTransferResponse, 0, len(scheduled))
+	for _, st := range scheduled {
+		rsp = append(rsp, newScheduledTransferResponse(st))
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type scheduledTransferURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (server *Server) getScheduledTransfer(ctx *gin.Context) {
+	var uri scheduledTransferURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	st, ok := server.ownedScheduledTransfer(ctx, uri.ID)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newScheduledTransferResponse(st))
+}
+
+type updateScheduledTransferRequest struct {
+	Amount *int64     `json:"amount" binding:"omitempty,gt=0"`
+	EndAt  *time.Time `json:"end_at"`
+	// Status pauses or resumes the schedule, the occurrences missed while paused are skipped
+	Status *string `json:"status" binding:"omitempty,oneof=active paused"`
+}
+
+// updateScheduledTransfer changes the amount or end of a schedule, or pauses and resumes it
+func (server *Server) updateScheduledTransfer(ctx *gin.Context) {
+	var uri scheduledTransferURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req updateScheduledTransferRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if req.Amount == nil && req.EndAt == nil && req.Status == nil {
+		err := errors.New("nothing to update")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	current, ok := server.ownedScheduledTransfer(ctx, uri.ID)
+	if !ok {
+		return
+	}
+
+	// a new amount is screened like a new schedule
+	if req.Amount != nil {
+		ok = server.screenDeferredTransfer(ctx, db.ScreenTransferTxParams{
+			TransferCreateParams: db.TransferCreateParams{
+				FromAccountID: current.FromAccountID,
+				ToAccountID:   current.ToAccountID,
+				Amount:        *req.Amount,
+			},
+			Currency: current.Currency,
+			Owner:    current.Owner,
+		})
+		if !ok {
+			return
+		}
+	}
+
+	now := time.Now()
+	st, err := server.store.UpdateScheduledTransferTx(ctx, uri.ID, func(current db.ScheduledTransfer) (db.UpdateScheduledTransferParams, error) {
+		return applyScheduledTransferUpdate(current, req, now)
+	})
+
+	if err != nil {
+		server.scheduledTransferError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newScheduledTransferResponse(st))
+}
+
+// applyScheduledTransferUpdate computes the new state of current from the request
+func applyScheduledTransferUpdate(current db.ScheduledTransfer, req updateScheduledTransferRequest, now time.Time) (db.UpdateScheduledTransferParams, error) {
+	if current.Status != db.ScheduledTransferStatusActive && current.Status != db.ScheduledTransferStatusPaused {
+		return db.UpdateScheduledTransferParams{}, fmt.Errorf("%w: it is %s", errScheduledTransferFinished, current.Status)
+	}
+
+	arg := db.UpdateScheduledTransferParams{
+		Amount:           current.Amount,
+		EndAt:            current.EndAt,
+		Status:           current.Status,
+		NextOccurrenceAt: current.NextOccurrenceAt,
+		NextRunAt:        current.NextRunAt,
+		Attempts:         current.Attempts,
+		LastError:        current.LastError,
+	}
+
+	if req.Amount != nil {
+		arg.Amount = *req.Amount
+	}
+
+	if req.EndAt != nil {
+		if req.EndAt.Before(current.StartAt) {
+			return arg, errEndBeforeStart
+		}
+		arg.EndAt = sql.NullTime{Time: *req.EndAt, Valid: true}
+	}
+
+	if req.Status != nil && *req.Status != current.Status {
+		arg.Status = *req.Status
+
+		// resuming skips what was missed while paused and starts the next occurrence afresh
+		if arg.Status == db.ScheduledTransferStatusActive && !arg.NextOccurrenceAt.Time.After(now) {
+			current.EndAt = arg.EndAt
+			next, ok, err := scheduler.NextOccurrence(current, now)
+			if err != nil {
+				return arg, err
+			}
+			arg.NextOccurrenceAt = sql.NullTime{Time: next, Valid: ok}
+			arg.NextRunAt = arg.NextOccurrenceAt
+			arg.Attempts = 0
+		}
+	}
+
+	if !arg.NextOccurrenceAt.Valid || (arg.EndAt.Valid && arg.NextOccurrenceAt.Time.After(arg.EndAt.Time)) {
+		arg.Status = db.ScheduledTransferStatusCompleted
+		arg.NextOccurrenceAt = sql.NullTime{}
+		arg.NextRunAt = sql.NullTime{}
+	}
+
+	return arg, nil
+}
+
+// deleteScheduledTransfer cancels a schedule, its runs are kept
+func (server *Server) deleteScheduledTransfer(ctx *gin.Context) {
+	var uri scheduledTransferURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if _, ok := server.ownedScheduledTransfer(ctx, uri.ID); !ok {
+		return
+	}
+
+	_, err := server.store.UpdateScheduledTransferTx(ctx, uri.ID, func(current db.ScheduledTransfer) (db.UpdateScheduledTransferParams, error) {
+		arg := db.UpdateScheduledTransferParams{
+			Amount:    current.Amount,
+			EndAt:     current.EndAt,
+			Status:    db.ScheduledTransferStatusCancelled,
+			Attempts:  current.Attempts,
+			LastError: current.LastError,
+		}
+
+		switch current.Status {
+		case db.ScheduledTransferStatusActive, db.ScheduledTransferStatusPaused, db.ScheduledTransferStatusCancelled:
+			return arg, nil
+		}
+
+		return arg, fmt.Errorf("%w: it is %s", errScheduledTransferFinished, current.Status)
+	})
+
+	if err != nil {
+		server.scheduledTransferError(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+type listScheduledTransferRunsQuery struct {
+	PageSize int32  `form:"page_size" binding:"required,min=1,max=100"`
+	Cursor   string `form:"cursor"`
+}
+
+// listScheduledTransferRuns returns the executed and failed occurrences of a schedule, oldest first
+func (server *Server) listScheduledTransferRuns(ctx *gin.Context) {
+	var uri scheduledTransferURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var query listScheduledTransferRunsQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	afterID, err := util.DecodeCursor(query.Cursor)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if _, ok := server.ownedScheduledTransfer(ctx, uri.ID); !ok {
+		return
+	}
+
+	runs, err := server.store.ListScheduledTransferRuns(ctx, db.ListScheduledTransferRunsParams{
+		ScheduledTransferID: uri.ID,
+		AfterID:             afterID,
+		LimitCount:          query.PageSize,
+	})
+
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if len(runs) > 0 {
+		setNextCursor(ctx, len(runs), query.PageSize, runs[len(runs)-1].ID)
+	}
+
+	rsp := make([]scheduledTransferRunResponse, 0, len(runs))
+	for _, run := range runs {
+		rsp = append(rsp, newScheduledTransferRunResponse(run))
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// ownedScheduledTransfer loads a scheduled transfer of the authenticated user
+// it writes the error response and returns false when it is missing or owned by someone else
+func (server *Server) ownedScheduledTransfer(ctx *gin.Context, id int64) (db.ScheduledTransfer, bool) {
+	st, err := server.store.GetScheduledTransfer(ctx, id)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return st, false
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return st, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if st.Owner != authPayload.Username {
+		err := errors.New("scheduled transfer doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return st, false
+	}
+
+	return st, true
+}
+
+func (server *Server) scheduledTransferError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, errScheduledTransferFinished):
+		ctx.JSON(http.StatusConflict, errorResponse(err))
+	case errors.Is(err, errEndBeforeStart), errors.Is(err, schedule.ErrInvalidRule):
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+	case errors.Is(err, sql.ErrNoRows):
+		ctx.JSON(http.StatusNotFound, errorResponse(err))
+	default:
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+	}
+}
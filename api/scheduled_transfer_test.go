@@ -0,0 +1,113 @@
+package api
+
+import (
+	"database/sql"
+	db "simplebank/db/sqlc"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyScheduledTransferUpdate(t *testing.T) {
+	now := time.Date(2024, time.May, 20, 12, 0, 0, 0, time.UTC)
+	start := time.Date(2024, time.January, 1, 9, 0, 0, 0, time.UTC)
+
+	monthly := db.ScheduledTransfer{
+		Amount:           10,
+		StartAt:          start,
+		Recurrence:       "FREQ=MONTHLY",
+		Status:           db.ScheduledTransferStatusActive,
+		NextOccurrenceAt: sql.NullTime{Time: time.Date(2024, time.June, 1, 9, 0, 0, 0, time.UTC), Valid: true},
+		NextRunAt:        sql.NullTime{Time: time.Date(2024, time.June, 1, 9, 0, 0, 0, time.UTC), Valid: true},
+	}
+
+	paused := monthly
+	paused.Status = db.ScheduledTransferStatusPaused
+	paused.NextOccurrenceAt = sql.NullTime{Time: time.Date(2024, time.March, 1, 9, 0, 0, 0, time.UTC), Valid: true}
+	paused.NextRunAt = sql.NullTime{Time: time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC), Valid: true}
+	paused.Attempts = 2
+
+	completed := monthly
+	completed.Status = db.ScheduledTransferStatusCompleted
+
+	amount := int64(25)
+	active := db.ScheduledTransferStatusActive
+	pause := db.ScheduledTransferStatusPaused
+	earlyEnd := time.Date(2024, time.May, 31, 0, 0, 0, 0, time.UTC)
+	beforeStart := start.Add(-time.Hour)
+
+	testCases := []struct {
+		name    string
+		current db.ScheduledTransfer
+		req     updateScheduledTransferRequest
+		check   func(t *testing.T, arg db.UpdateScheduledTransferParams, err error)
+	}{
+		{
+			name:    "Amount",
+			current: monthly,
+			req:     updateScheduledTransferRequest{Amount: &amount},
+			check: func(t *testing.T, arg db.UpdateScheduledTransferParams, err error) {
+				require.NoError(t, err)
+				require.Equal(t, amount, arg.Amount)
+				require.Equal(t, monthly.NextRunAt, arg.NextRunAt)
+			},
+		},
+		{
+			name:    "Pause",
+			current: monthly,
+			req:     updateScheduledTransferRequest{Status: &pause},
+			check: func(t *testing.T, arg db.UpdateScheduledTransferParams, err error) {
+				require.NoError(t, err)
+				require.Equal(t, db.ScheduledTransferStatusPaused, arg.Status)
+				require.Equal(t, monthly.NextOccurrenceAt, arg.NextOccurrenceAt)
+			},
+		},
+		{
+			name:    "ResumeSkipsMissedOccurrences",
+			current: paused,
+			req:     updateScheduledTransferRequest{Status: &active},
+			check: func(t *testing.T, arg db.UpdateScheduledTransferParams, err error) {
+				require.NoError(t, err)
+				require.Equal(t, db.ScheduledTransferStatusActive, arg.Status)
+				require.Equal(t, time.Date(2024, time.June, 1, 9, 0, 0, 0, time.UTC), arg.NextOccurrenceAt.Time)
+				require.Equal(t, arg.NextOccurrenceAt, arg.NextRunAt)
+				require.Zero(t, arg.Attempts)
+			},
+		},
+		{
+			name:    "EndBeforeNextOccurrenceCompletes",
+			current: monthly,
+			req:     updateScheduledTransferRequest{EndAt: &earlyEnd},
+			check: func(t *testing.T, arg db.UpdateScheduledTransferParams, err error) {
+				require.NoError(t, err)
+				require.Equal(t, db.ScheduledTransferStatusCompleted, arg.Status)
+				require.False(t, arg.NextRunAt.Valid)
+				require.Equal(t, earlyEnd, arg.EndAt.Time)
+			},
+		},
+		{
+			name:    "EndBeforeStart",
+			current: monthly,
+			req:     updateScheduledTransferRequest{EndAt: &beforeStart},
+			check: func(t *testing.T, arg db.UpdateScheduledTransferParams, err error) {
+				require.ErrorIs(t, err, errEndBeforeStart)
+			},
+		},
+		{
+			name:    "Finished",
+			current: completed,
+			req:     updateScheduledTransferRequest{Amount: &amount},
+			check: func(t *testing.T, arg db.UpdateScheduledTransferParams, err error) {
+				require.ErrorIs(t, err, errScheduledTransferFinished)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			arg, err := applyScheduledTransferUpdate(tc.current, tc.req, now)
+			tc.check(t, arg, err)
+		})
+	}
+}
@@ -1,33 +1,205 @@
 package api
 
 import (
+	"fmt"
+	"net/http"
 	db "simplebank/db/sqlc"
+	"simplebank/event"
+	"simplebank/ratelimit"
+	"simplebank/rbac"
+	"simplebank/risk"
+	"simplebank/token"
+	"simplebank/util"
 
+	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
+// serviceName names the server spans of every HTTP request
+const serviceName = "simplebank"
+
+// Server serves HTTP requests for our banking service
 type Server struct {
-	store  *db.Store
-	router *gin.Engine
+	config     util.Config
+	store      *db.Store
+	tokenMaker token.Maker
+	router     *gin.Engine
+	openAPI    *openapi3.T
+	hub        *event.Hub
+	risk       *risk.Engine
+	limiter    *ratelimit.Limiter
+}
+
+// NewServer creates a new HTTP server and setup routing
+// limiter is shared with the gRPC server, so that a caller takes its tokens from the same buckets on both
+func NewServer(config util.Config, store *db.Store, limiter *ratelimit.Limiter) (*Server, error) {
+	tokenMaker, err := token.NewJWTMaker(config.TokenSymmetricKey)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create token maker: %w", err)
+	}
+
+	server := &Server{
+		config:     config,
+		store:      store,
+		tokenMaker: tokenMaker,
+		openAPI:    OpenAPIDocument(),
+		hub:        event.NewHub(),
+		risk:       risk.NewEngine(risk.DefaultRules()...),
+		limiter:    limiter,
+	}
+
+	if err := server.setupRouter(); err != nil {
+		return nil, err
+	}
+
+	return server, nil
 }
 
-func NewServer(store *db.Store) *Server {
-	server := &Server{store: store}
-	router := gin.Default()
+func (server *Server) setupRouter() error {
+	router := gin.New()
+
+	// ClientIP reads X-Forwarded-For only when the request comes through one of these proxies, none by default,
+	// so that callers cannot forge the IP the API key allowlists and the rate limits go by
+	if err := router.SetTrustedProxies(server.config.TrustedProxies); err != nil {
+		return fmt.Errorf("invalid trusted proxies: %w", err)
+	}
+
+	router.ContextWithFallback = true
+	router.Use(otelgin.Middleware(serviceName), requestLogger(), gin.Recovery())
+
+	router.POST("/users", server.rateLimit(ratelimit.CreateUserPolicy), server.audit(), server.createUser)
+	router.POST("/users/login", server.rateLimit(ratelimit.LoginPolicy), server.audit(), server.loginUser)
+	router.POST("/tokens/renew_access", server.rateLimit(ratelimit.RenewTokenPolicy), server.audit(), server.renewAccessToken)
+	router.GET("/openapi.json", server.getOpenAPI)
+
+	// limitIPs runs ahead of authMiddleware, before the caller is known, so it keys the bucket by client IP
+	// and throttles guessing tokens and API keys as well
+	limitIPs := server.rateLimit(ratelimit.IPPolicy)
+
+	authRoutes := router.Group("/").Use(limitIPs, authMiddleware(server.tokenMaker, server.store), server.rateLimit(ratelimit.DefaultPolicy), server.audit())
+
+	readAccounts := requirePermission(rbac.AccountsRead)
+	writeTransfers := requirePermission(rbac.TransfersWrite)
+	limitLists := server.rateLimit(ratelimit.ListPolicy)
+
+	authRoutes.POST("/accounts", requirePermission(rbac.AccountsWrite), server.rateLimit(ratelimit.CreateAccountPolicy), server.idempotent(), server.createAccount)
+	authRoutes.GET("/accounts/:id", readAccounts, server.getAccount)
+	authRoutes.GET("/accounts", readAccounts, limitLists, server.listAccounts)
+	authRoutes.GET("/accounts/:id/balance", readAccounts, server.getAccountBalance)
+	authRoutes.GET("/accounts/:id/entries", readAccounts, limitLists, server.listAccountEntries)
+	authRoutes.GET("/accounts/:id/stream", readAccounts, server.streamAccount)
+	authRoutes.GET("/accounts/:id/holds", readAccounts, limitLists, server.listAccountHolds)
+	authRoutes.GET("/accounts/:id/interest-accruals", readAccounts, limitLists, server.listInterestAccruals)
+	authRoutes.GET("/products", readAccounts, server.listProducts)
+
+	authRoutes.POST("/transfers", writeTransfers, server.rateLimit(ratelimit.CreateTransferPolicy), server.requireSignature(), server.idempotent(), server.createTransfer)
+	authRoutes.GET("/transfers/quote", writeTransfers, server.quoteTransfer)
+
+	authRoutes.POST("/holds", writeTransfers, server.idempotent(), server.createHold)
+	authRoutes.GET("/holds/:id", readAccounts, server.getHold)
+	authRoutes.POST("/holds/:id/capture", writeTransfers, server.captureHold)
+	authRoutes.POST("/holds/:id/void", writeTransfers, server.voidHold)
+
+	authRoutes.GET("/loans", readAccounts, limitLists, server.listLoans)
+	authRoutes.GET("/loans/:id", readAccounts, server.getLoan)
+	authRoutes.GET("/loans/:id/installments", readAccounts, limitLists, server.listLoanInstallments)
+	authRoutes.POST("/loans/:id/repayments", writeTransfers, server.idempotent(), server.repayLoan)
+	authRoutes.GET("/loans/:id/repayments", readAccounts, limitLists, server.listLoanRepayments)
+
+	authRoutes.POST("/scheduled-transfers", writeTransfers, server.idempotent(), server.createScheduledTransfer)
+	authRoutes.GET("/scheduled-transfers", readAccounts, limitLists, server.listScheduledTransfers)
+	authRoutes.GET("/scheduled-transfers/:id", readAccounts, server.getScheduledTransfer)
+	authRoutes.PATCH("/scheduled-transfers/:id", writeTransfers, server.updateScheduledTransfer)
+	authRoutes.DELETE("/scheduled-transfers/:id", writeTransfers, server.deleteScheduledTransfer)
+	authRoutes.GET("/scheduled-transfers/:id/runs", readAccounts, limitLists, server.listScheduledTransferRuns)
+
+	authRoutes.POST("/transfer-batches", writeTransfers, server.idempotent(), server.createTransferBatch)
+	authRoutes.GET("/transfer-batches", readAccounts, limitLists, server.listTransferBatches)
+	authRoutes.GET("/transfer-batches/:id", readAccounts, server.getTransferBatch)
+	authRoutes.GET("/transfer-batches/:id/items", readAccounts, limitLists, server.listTransferBatchItems)
+	authRoutes.GET("/transfer-batches/:id/report", readAccounts, server.getTransferBatchReport)
+
+	manageWebhooks := requirePermission(rbac.WebhooksManage)
+
+	authRoutes.POST("/webhooks", manageWebhooks, server.createWebhookSubscription)
+	authRoutes.GET("/webhooks", manageWebhooks, server.listWebhookSubscriptions)
+	authRoutes.GET("/webhooks/:id", manageWebhooks, server.getWebhookSubscription)
+	authRoutes.DELETE("/webhooks/:id", manageWebhooks, server.deleteWebhookSubscription)
+	authRoutes.GET("/webhooks/:id/deliveries", manageWebhooks, server.listWebhookDeliveries)
+	authRoutes.POST("/webhooks/:id/deliveries/:delivery_id/redeliver", manageWebhooks, server.redeliverWebhook)
 
-	router.POST("/accounts", server.createAccount)
-	router.GET("/accounts/:id", server.getAccount)
-	router.GET("/accounts", server.listAccounts)
+	manageApiKeys := requirePermission(rbac.APIKeysManage)
+
+	authRoutes.POST("/api-keys", manageApiKeys, server.createApiKey)
+	authRoutes.GET("/api-keys", manageApiKeys, server.listApiKeys)
+	authRoutes.DELETE("/api-keys/:id", manageApiKeys, server.revokeApiKey)
+
+	// the staff routes, each one requires a permission only support or admin roles are granted
+	adminRoutes := router.Group("/admin").Use(limitIPs, authMiddleware(server.tokenMaker, server.store), server.rateLimit(ratelimit.DefaultPolicy), server.audit())
+
+	adminRoutes.GET("/accounts", requirePermission(rbac.AccountsList), server.listAllAccounts)
+	adminRoutes.POST("/accounts/:id/freeze", requirePermission(rbac.AccountsFreeze), server.freezeAccount)
+	adminRoutes.POST("/accounts/:id/unfreeze", requirePermission(rbac.AccountsFreeze), server.unfreezeAccount)
+
+	adminRoutes.PUT("/users/:username/role", requirePermission(rbac.UsersManage), server.updateUserRole)
+
+	manageLimits := requirePermission(rbac.LimitsManage)
+
+	adminRoutes.POST("/transfer-limits", manageLimits, server.createTransferLimit)
+	adminRoutes.GET("/transfer-limits", manageLimits, server.listTransferLimits)
+
+	manageProducts := requirePermission(rbac.ProductsManage)
+
+	adminRoutes.POST("/interest-rates", manageProducts, server.createInterestRate)
+	adminRoutes.GET("/interest-rates", manageProducts, server.listInterestRates)
+
+	adminRoutes.POST("/loans", manageProducts, server.createLoan)
+
+	adminRoutes.POST("/pricing-rules", manageProducts, server.createPricingRule)
+	adminRoutes.GET("/pricing-rules", manageProducts, server.listPricingRules)
+	adminRoutes.GET("/pricing-rules/:id", manageProducts, server.getPricingRule)
+	adminRoutes.PUT("/pricing-rules/:id", manageProducts, server.updatePricingRule)
+	adminRoutes.PUT("/accounts/:id/tier", manageProducts, server.updateAccountTier)
+
+	adjustBalances := requirePermission(rbac.BalancesAdjust)
+	readLedger := requirePermission(rbac.LedgerRead)
+
+	adminRoutes.POST("/periods/close", adjustBalances, server.closePeriod)
+	adminRoutes.GET("/periods", readLedger, server.listAccountingPeriods)
+	adminRoutes.GET("/periods/:id", readLedger, server.getAccountingPeriod)
+	adminRoutes.POST("/adjustments", adjustBalances, server.createAdjustment)
+	adminRoutes.GET("/trial-balance", readLedger, server.getTrialBalance)
+	adminRoutes.GET("/internal-accounts", readLedger, server.listInternalAccounts)
+
+	adminRoutes.GET("/audit-log", requirePermission(rbac.AuditRead), server.listAuditLog)
+
+	reviewRisk := requirePermission(rbac.RiskReview)
+
+	adminRoutes.GET("/risk-assessments", reviewRisk, server.listRiskAssessments)
+	adminRoutes.GET("/risk-assessments/:id", reviewRisk, server.getRiskAssessment)
+	adminRoutes.POST("/risk-assessments/:id/approve", reviewRisk, server.approveRiskAssessment)
+	adminRoutes.POST("/risk-assessments/:id/reject", reviewRisk, server.rejectRiskAssessment)
 
 	server.router = router
-	return server
+	return nil
 }
 
-// start runs the HTTP server
+// Start runs the HTTP server
 func (server *Server) Start(address string) error {
 	return server.router.Run(address)
 }
 
-func errorResponse(err error) gin.H {
-	return gin.H{"error": err.Error()}
+// Hub returns the hub feeding the account streams, feed it every event inserted into the outbox
+func (server *Server) Hub() *event.Hub {
+	return server.hub
+}
+
+// Handler returns the http.Handler serving the API
+func (server *Server) Handler() http.Handler {
+	return server.router
+}
+
+func errorResponse(err error) ErrorResponse {
+	return ErrorResponse{Error: err.Error()}
 }
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	db "simplebank/db/sqlc"
+	"simplebank/signing"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxBufferedBodyBytes bounds the body the middlewares read into memory, to check a signature or key an idempotent request
+const maxBufferedBodyBytes = 1 << 20
+
+var errNonceReused = errors.New("signature nonce already used")
+
+// requireSignature makes the callers sending an API key sign the request with the signing secret of the key:
+// the signature covers the method, the path, the timestamp, the nonce and the body, the timestamp must be within
+// the skew window and the nonce must not have been used; callers sending an access token pass through.
+// It runs after authMiddleware on the routes moving money for partner integrations
+func (server *Server) requireSignature() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		value, ok := ctx.Get(authorizationApiKeyKey)
+		if !ok {
+			ctx.Next()
+			return
+		}
+		apiKey := value.(db.GetApiKeyByPrefixRow)
+
+		if apiKey.SigningSecret == "" {
+			err := errors.New("api key has no signing secret, create a new key to call this route")
+			ctx.AbortWithStatusJSON(http.StatusForbidden, errorResponse(err))
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxBufferedBodyBytes))
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				ctx.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, errorResponse(err))
+				return
+			}
+
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		timestamp := ctx.GetHeader(signing.TimestampHeader)
+		nonce := ctx.GetHeader(signing.NonceHeader)
+
+		err = signing.Verify(apiKey.SigningSecret, ctx.Request.Method, ctx.Request.URL.RequestURI(),
+			timestamp, nonce, ctx.GetHeader(signing.SignatureHeader), body, time.Now(), server.config.RequestSigningSkew)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+
+		// Verify accepted the timestamp, past the window it is rejected before the nonce is looked up
+		seconds, _ := strconv.ParseInt(timestamp, 10, 64)
+		remembered, err := server.store.RememberRequestNonce(ctx, db.RememberRequestNonceParams{
+			ApiKeyID:  apiKey.ID,
+			Nonce:     nonce,
+			ExpiresAt: time.Unix(seconds, 0).Add(server.config.RequestSigningSkew),
+		})
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		if remembered == 0 {
+			err := fmt.Errorf("%w: %s", errNonceReused, nonce)
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+
+		ctx.Next()
+	}
+}
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	db "simplebank/db/sqlc"
+	"simplebank/signing"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequireSignature(t *testing.T) {
+	secret, err := signing.NewSecret()
+	require.NoError(t, err)
+
+	body := `{"from_account_id":1,"to_account_id":2,"amount":100,"currency":"USD"}`
+
+	sign := func(request *http.Request, signedAt time.Time, signedBody string) {
+		nonce, err := signing.NewNonce()
+		require.NoError(t, err)
+
+		request.Header.Set(signing.TimestampHeader, strconv.FormatInt(signedAt.Unix(), 10))
+		request.Header.Set(signing.NonceHeader, nonce)
+		request.Header.Set(signing.SignatureHeader, signing.Sign(secret, request.Method, request.URL.RequestURI(), signedAt, nonce, []byte(signedBody)))
+	}
+
+	testCases := []struct {
+		name          string
+		apiKey        *db.GetApiKeyByPrefixRow
+		setupRequest  func(request *http.Request)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:         "AccessTokenNotSigned",
+			setupRequest: func(request *http.Request) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, body, recorder.Body.String())
+			},
+		},
+		{
+			name:         "ApiKeyNotSigned",
+			apiKey:       &db.GetApiKeyByPrefixRow{ID: 1, SigningSecret: secret},
+			setupRequest: func(request *http.Request) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				require.Contains(t, recorder.Body.String(), signing.ErrMissingSignature.Error())
+			},
+		},
+		{
+			name:   "ApiKeyWithoutSigningSecret",
+			apiKey: &db.GetApiKeyByPrefixRow{ID: 1},
+			setupRequest: func(request *http.Request) {
+				sign(request, time.Now(), body)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:   "TamperedBody",
+			apiKey: &db.GetApiKeyByPrefixRow{ID: 1, SigningSecret: secret},
+			setupRequest: func(request *http.Request) {
+				sign(request, time.Now(), strings.Replace(body, `"amount":100`, `"amount":1`, 1))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				require.Contains(t, recorder.Body.String(), signing.ErrSignatureMismatch.Error())
+			},
+		},
+		{
+			name:   "Stale",
+			apiKey: &db.GetApiKeyByPrefixRow{ID: 1, SigningSecret: secret},
+			setupRequest: func(request *http.Request) {
+				sign(request, time.Now().Add(-2*time.Minute), body)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				require.Contains(t, recorder.Body.String(), signing.ErrTimestampSkew.Error())
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestServer(t, nil)
+
+			path := "/signed"
+			server.router.POST(path, func(ctx *gin.Context) {
+				if tc.apiKey != nil {
+					ctx.Set(authorizationApiKeyKey, *tc.apiKey)
+				}
+			}, server.requireSignature(), func(ctx *gin.Context) {
+				// the handler still reads the body the signature was checked against
+				ctx.DataFromReader(http.StatusOK, ctx.Request.ContentLength, "application/json", ctx.Request.Body, nil)
+			})
+
+			recorder := httptest.NewRecorder()
+			request := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+			tc.setupRequest(request)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
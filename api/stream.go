@@ -0,0 +1,203 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	db "simplebank/db/sqlc"
+	"simplebank/event"
+	"simplebank/logging"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	lastEventIDHeader = "Last-Event-ID"
+	// streamBuffer is how many live events a stream may fall behind before it is dropped from the hub
+	// and catches up from the outbox instead
+	streamBuffer = 64
+	// streamReplayPageSize bounds the outbox events read per query while catching up
+	streamReplayPageSize = 100
+	// streamHeartbeat keeps idle connections open through proxies
+	streamHeartbeat = 15 * time.Second
+)
+
+// eventLog reads the history of the events of an account
+type eventLog interface {
+	latestEventID(ctx context.Context, accountID int64) (int64, error)
+	eventsAfter(ctx context.Context, accountID int64, afterID int64, limit int32) ([]event.Event, error)
+}
+
+// outboxLog reads the events of an account from the outbox
+type outboxLog struct {
+	store *db.Store
+}
+
+func (l outboxLog) latestEventID(ctx context.Context, accountID int64) (int64, error) {
+	return l.store.GetLatestAccountOutboxEventID(ctx, accountID)
+}
+
+func (l outboxLog) eventsAfter(ctx context.Context, accountID int64, afterID int64, limit int32) ([]event.Event, error) {
+	rows, err := l.store.ListAccountOutboxEvents(ctx, db.ListAccountOutboxEventsParams{
+		AccountID:  accountID,
+		AfterID:    afterID,
+		LimitCount: limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]event.Event, len(rows))
+	for i, row := range rows {
+		events[i] = row.Event()
+	}
+	return events, nil
+}
+
+type streamAccountURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// streamAccount pushes the events of an account as Server-Sent Events, each with its outbox id,
+// starting after the Last-Event-ID header or, without it, with the next event
+func (server *Server) streamAccount(ctx *gin.Context) {
+	var uri streamAccountURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	lastEventID := int64(-1)
+	if header := ctx.GetHeader(lastEventIDHeader); header != "" {
+		id, err := strconv.ParseInt(header, 10, 64)
+		if err != nil || id < 0 {
+			err := fmt.Errorf("%s must be a non-negative event id", lastEventIDHeader)
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		lastEventID = id
+	}
+
+	if _, ok := server.readableAccount(ctx, uri.ID); !ok {
+		return
+	}
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+	ctx.Writer.Flush()
+
+	reqCtx := ctx.Request.Context()
+	err := streamEvents(reqCtx, ctx.Writer, server.hub, outboxLog{store: server.store}, uri.ID, lastEventID)
+	if err != nil && reqCtx.Err() == nil {
+		logging.FromContext(reqCtx).ErrorContext(reqCtx, "account stream failed", slog.Any("error", err))
+	}
+}
+
+// flushWriter is a response writer that can push what was written to the client
+type flushWriter interface {
+	io.Writer
+	http.Flusher
+}
+
+// streamEvents writes the events of accountID after lastEventID until ctx is done or writing fails
+// live events come from hub, the ones missed before subscribing or after falling behind are replayed from log,
+// so a slow client never blocks the publisher; a negative lastEventID starts after the latest event
+func streamEvents(ctx context.Context, w flushWriter, hub *event.Hub, log eventLog, accountID int64, lastEventID int64) error {
+	for {
+		// subscribing before reading the log leaves no gap, duplicates are skipped by id
+		sub := hub.Subscribe(accountID, streamBuffer)
+
+		var err error
+		if lastEventID < 0 {
+			lastEventID, err = log.latestEventID(ctx, accountID)
+		} else {
+			lastEventID, err = replayEvents(ctx, w, log, accountID, lastEventID)
+		}
+
+		if err == nil {
+			lastEventID, err = streamLive(ctx, w, sub, lastEventID)
+		}
+		sub.Close()
+
+		if err != nil || ctx.Err() != nil {
+			return err
+		}
+	}
+}
+
+func replayEvents(ctx context.Context, w flushWriter, log eventLog, accountID int64, lastEventID int64) (int64, error) {
+	for {
+		events, err := log.eventsAfter(ctx, accountID, lastEventID, streamReplayPageSize)
+		if err != nil {
+			return lastEventID, err
+		}
+
+		for _, e := range events {
+			if err := writeEvent(w, e); err != nil {
+				return lastEventID, err
+			}
+			lastEventID = e.ID
+		}
+
+		if len(events) < streamReplayPageSize {
+			return lastEventID, nil
+		}
+	}
+}
+
+// streamLive writes the events of sub until ctx is done, returning without error when sub was dropped for lagging
+func streamLive(ctx context.Context, w flushWriter, sub *event.Subscription, lastEventID int64) (int64, error) {
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return lastEventID, nil
+		case <-heartbeat.C:
+			if _, err := io.WriteString(w, ": heartbeat\n\n"); err != nil {
+				return lastEventID, err
+			}
+			w.Flush()
+		case e, ok := <-sub.C():
+			if !ok {
+				if !sub.Lagged() {
+					return lastEventID, errors.New("stream subscription closed")
+				}
+				return lastEventID, nil
+			}
+
+			if e.ID <= lastEventID {
+				continue
+			}
+
+			if err := writeEvent(w, e); err != nil {
+				return lastEventID, err
+			}
+			lastEventID = e.ID
+		}
+	}
+}
+
+func writeEvent(w flushWriter, e event.Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data); err != nil {
+		return err
+	}
+
+	w.Flush()
+	return nil
+}
@@ -0,0 +1,174 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"simplebank/event"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeEventLog is an in-memory outbox of a single account
+type fakeEventLog struct {
+	mu     sync.Mutex
+	events []event.Event
+}
+
+func (l *fakeEventLog) append(id int64) event.Event {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e := event.Event{ID: id, Type: event.EntryPosted, Version: 1, AccountID: 1, Data: []byte(`{}`)}
+	l.events = append(l.events, e)
+	return e
+}
+
+func (l *fakeEventLog) latestEventID(ctx context.Context, accountID int64) (int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.events) == 0 {
+		return 0, nil
+	}
+	return l.events[len(l.events)-1].ID, nil
+}
+
+func (l *fakeEventLog) eventsAfter(ctx context.Context, accountID int64, afterID int64, limit int32) ([]event.Event, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var events []event.Event
+	for _, e := range l.events {
+		if e.ID > afterID && len(events) < int(limit) {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+// streamRecorder collects the stream, writes block while gate is set
+type streamRecorder struct {
+	mu   sync.Mutex
+	buf  bytes.Buffer
+	gate chan struct{}
+}
+
+func (r *streamRecorder) Write(p []byte) (int, error) {
+	if r.gate != nil {
+		<-r.gate
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.buf.Write(p)
+}
+
+func (r *streamRecorder) Flush() {}
+
+func (r *streamRecorder) String() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.buf.String()
+}
+
+var eventIDPattern = regexp.MustCompile(`(?m)^id: (\d+)$`)
+
+func (r *streamRecorder) ids() []int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var ids []int64
+	for _, match := range eventIDPattern.FindAllStringSubmatch(r.buf.String(), -1) {
+		id, _ := strconv.ParseInt(match[1], 10, 64)
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func idRange(from int64, to int64) []int64 {
+	var ids []int64
+	for id := from; id <= to; id++ {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// startStream runs streamEvents until the test ends
+func startStream(t *testing.T, w *streamRecorder, hub *event.Hub, log eventLog, lastEventID int64) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+
+	go func() {
+		done <- streamEvents(ctx, w, hub, log, 1, lastEventID)
+	}()
+
+	t.Cleanup(func() {
+		cancel()
+		require.NoError(t, <-done)
+		require.Zero(t, hub.Subscribers())
+	})
+
+	require.Eventually(t, func() bool { return hub.Subscribers() == 1 }, time.Second, time.Millisecond)
+}
+
+func TestStreamEventsResumesAfterLastEventID(t *testing.T) {
+	hub := event.NewHub()
+	log := &fakeEventLog{}
+	for id := int64(1); id <= 3; id++ {
+		log.append(id)
+	}
+
+	w := &streamRecorder{}
+	startStream(t, w, hub, log, 1)
+	require.Eventually(t, func() bool { return len(w.ids()) == 2 }, time.Second, time.Millisecond)
+
+	// an event replayed from the log and then published is sent once
+	require.NoError(t, hub.Publish(context.Background(), log.events[2]))
+	require.NoError(t, hub.Publish(context.Background(), log.append(4)))
+
+	require.Eventually(t, func() bool { return len(w.ids()) == 3 }, time.Second, time.Millisecond)
+	require.Equal(t, []int64{2, 3, 4}, w.ids())
+	require.Contains(t, w.String(), "event: entry.posted\ndata: {\"id\":4,")
+}
+
+func TestStreamEventsStartsAfterLatestEvent(t *testing.T) {
+	hub := event.NewHub()
+	log := &fakeEventLog{}
+	for id := int64(1); id <= 3; id++ {
+		log.append(id)
+	}
+
+	w := &streamRecorder{}
+	startStream(t, w, hub, log, -1)
+
+	require.NoError(t, hub.Publish(context.Background(), log.append(4)))
+	require.Eventually(t, func() bool { return len(w.ids()) == 1 }, time.Second, time.Millisecond)
+	require.Equal(t, []int64{4}, w.ids())
+}
+
+func TestStreamEventsCatchesUpAfterLagging(t *testing.T) {
+	hub := event.NewHub()
+	log := &fakeEventLog{}
+
+	// the client stops reading while more events than the buffer holds are published
+	w := &streamRecorder{gate: make(chan struct{})}
+	startStream(t, w, hub, log, 0)
+
+	const count = streamBuffer + 50
+	for id := int64(1); id <= count; id++ {
+		require.NoError(t, hub.Publish(context.Background(), log.append(id)))
+	}
+	require.Zero(t, hub.Subscribers(), "the slow stream still holds up the hub")
+
+	close(w.gate)
+
+	require.Eventually(t, func() bool { return len(w.ids()) == count }, time.Second, time.Millisecond)
+	require.Equal(t, idRange(1, count), w.ids())
+	require.Eventually(t, func() bool { return hub.Subscribers() == 1 }, time.Second, time.Millisecond)
+}
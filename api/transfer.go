@@ -0,0 +1,169 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	db "simplebank/db/sqlc"
+	"simplebank/pricing"
+	"simplebank/token"
+
+	"github.com/gin-gonic/gin"
+)
+
+type transferRequest struct {
+	FromAccountID int64  `json:"from_account_id" binding:"required,min=1"`
+	ToAccountID   int64  `json:"to_account_id" binding:"required,min=1"`
+	Amount        int64  `json:"amount" binding:"required,gt=0"`
+	Currency      string `json:"currency" binding:"required,oneof=USD EUR"`
+}
+
+func (server *Server) createTransfer(ctx *gin.Context) {
+	var req transferRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if req.FromAccountID == req.ToAccountID {
+		err := errors.New("cannot transfer to the same account")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	fromAccount, ok := server.customerAccount(ctx, req.FromAccountID, req.Currency)
+	if !ok {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if fromAccount.Owner != authPayload.Username {
+		err := errors.New("from account doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return
+	}
+
+	if _, ok := server.customerAccount(ctx, req.ToAccountID, req.Currency); !ok {
+		return
+	}
+
+	arg := db.ScreenTransferTxParams{
+		TransferCreateParams: db.TransferCreateParams{
+			FromAccountID: req.FromAccountID,
+			ToAccountID:   req.ToAccountID,
+			Amount:        req.Amount,
+		},
+		Currency: req.Currency,
+		Owner:    authPayload.Username,
+	}
+
+	result, err := server.store.ScreenTransferTx(ctx, server.risk, arg)
+	if err != nil {
+		if errors.Is(err, db.ErrTransferLimitExceeded) || errors.Is(err, db.ErrTransferBlocked) ||
+			errors.Is(err, db.ErrInsufficientFunds) || errors.Is(err, db.ErrAccountNotActive) || errors.Is(err, db.ErrCurrencyMismatch) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if result.Transfer == nil {
+		ctx.JSON(http.StatusAccepted, newRiskAssessmentResponse(result.Assessment))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result.Transfer)
+}
+
+type quoteTransferRequest struct {
+	FromAccountID int64  `form:"from_account_id" binding:"required,min=1"`
+	Amount        int64  `form:"amount" binding:"required,gt=0"`
+	Currency      string `form:"currency" binding:"required,oneof=USD EUR"`
+}
+
+type transferQuoteResponse struct {
+	FromAccountID int64       `json:"from_account_id"`
+	Amount        int64       `json:"amount"`
+	Currency      string      `json:"currency"`
+	Fee           pricing.Fee `json:"fee"`
+	// Total is taken from the from account: the amount and the fee
+	Total int64 `json:"total"`
+}
+
+// quoteTransfer previews the fee of a transfer out of an account of the authenticated user
+func (server *Server) quoteTransfer(ctx *gin.Context) {
+	var req quoteTransferRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	fromAccount, ok := server.customerAccount(ctx, req.FromAccountID, req.Currency)
+	if !ok {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if fromAccount.Owner != authPayload.Username {
+		err := errors.New("from account doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return
+	}
+
+	fee, err := server.store.QuoteTransferFee(ctx, fromAccount, req.Amount)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, transferQuoteResponse{
+		FromAccountID: fromAccount.ID,
+		Amount:        req.Amount,
+		Currency:      fromAccount.Currency,
+		Fee:           fee,
+		Total:         req.Amount + fee.Amount,
+	})
+}
+
+// validAccount checks that the account exists and holds the given currency
+func (server *Server) validAccount(ctx *gin.Context, accountID int64, currency string) (db.Account, bool) {
+	account, err := server.store.GetAccount(ctx, accountID)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return account, false
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return account, false
+	}
+
+	if account.Currency != currency {
+		err := fmt.Errorf("account [%d] currency mismatch: %s vs %s", account.ID, account.Currency, currency)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return account, false
+	}
+
+	return account, true
+}
+
+// customerAccount checks that the account is a valid customer account, loan and internal accounts
+// only move through the bank's own postings and cannot be a leg of a customer transfer
+func (server *Server) customerAccount(ctx *gin.Context, accountID int64, currency string) (db.Account, bool) {
+	account, ok := server.validAccount(ctx, accountID, currency)
+	if !ok {
+		return account, false
+	}
+
+	if !db.CustomerProduct(account.Product) {
+		err := fmt.Errorf("account [%d] is not a customer account", account.ID)
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return account, false
+	}
+
+	return account, true
+}
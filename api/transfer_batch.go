@@ -0,0 +1,574 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	db "simplebank/db/sqlc"
+	"simplebank/token"
+	"simplebank/util"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// Media types a batch is uploaded as, one transfer per line
+const (
+	csvContentType       = "text/csv"
+	jsonLinesContentType = "application/x-ndjson"
+)
+
+const (
+	maxTransferBatchRows  = 1000
+	maxTransferBatchBytes = 1 << 20
+)
+
+// transferBatchColumns is the header of an uploaded CSV, in any order
+var transferBatchColumns = []string{"from_account_id", "to_account_id", "amount", "currency"}
+
+// transferBatchReportColumns is the header of the report of a batch
+var transferBatchReportColumns = []string{"row", "from_account_id", "to_account_id", "amount", "currency", "status", "transfer_id", "error"}
+
+var errUnsupportedBatchType = fmt.Errorf("upload the batch as %s or %s", csvContentType, jsonLinesContentType)
+
+type createTransferBatchQuery struct {
+	// Mode is atomic when omitted
+	Mode string `form:"mode" binding:"omitempty,oneof=atomic best_effort"`
+}
+
+type transferBatchResponse struct {
+	ID            int64      `json:"id"`
+	Mode          string     `json:"mode"`
+	Status        string     `json:"status"`
+	TotalRows     int32      `json:"total_rows"`
+	ProcessedRows int32      `json:"processed_rows"`
+	SucceededRows int32      `json:"succeeded_rows"`
+	FailedRows    int32      `json:"failed_rows"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	CompletedAt   *time.Time `json:"completed_at,omitempty"`
+}
+
+func newTransferBatchResponse(batch db.TransferBatch) transferBatchResponse {
+	return transferBatchResponse{
+		ID:            batch.ID,
+		Mode:          batch.Mode,
+		Status:        batch.Status,
+		TotalRows:     batch.TotalRows,
+		ProcessedRows: batch.SucceededRows + batch.FailedRows,
+		SucceededRows: batch.SucceededRows,
+		FailedRows:    batch.FailedRows,
+		CreatedAt:     batch.CreatedAt,
+		UpdatedAt:     batch.UpdatedAt,
+		CompletedAt:   nullTime(batch.CompletedAt),
+	}
+}
+
+type transferBatchItemResponse struct {
+	ID            int64   `json:"id"`
+	Row           int32   `json:"row"`
+	FromAccountID int64   `json:"from_account_id"`
+	ToAccountID   int64   `json:"to_account_id"`
+	Amount        int64   `json:"amount"`
+	Currency      string  `json:"currency"`
+	Status        string  `json:"status"`
+	TransferID    *int64  `json:"transfer_id,omitempty"`
+	Error         *string `json:"error,omitempty"`
+}
+
+func newTransferBatchItemResponse(item db.TransferBatchItem) transferBatchItemResponse {
+	rsp := transferBatchItemResponse{
+		ID:            item.ID,
+		Row:           item.RowNumber,
+		FromAccountID: item.FromAccountID,
+		ToAccountID:   item.ToAccountID,
+		Amount:        item.Amount,
+		Currency:      item.Currency,
+		Status:        item.Status,
+		Error:         nullString(item.Error),
+	}
+
+	if item.TransferID.Valid {
+		rsp.TransferID = &item.TransferID.Int64
+	}
+
+	return rsp
+}
+
+// createTransferBatch validates every row of an upload and queues the batch, the rows run in the background
+func (server *Server) createTransferBatch(ctx *gin.Context) {
+	var query createTransferBatchQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	mode := query.Mode
+	if mode == "" {
+		mode = db.TransferBatchModeAtomic
+	}
+
+	body := http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxTransferBatchBytes)
+	rows, err := parseTransferBatch(ctx.ContentType(), body)
+
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.Is(err, errUnsupportedBatchType):
+		ctx.JSON(http.StatusUnsupportedMediaType, errorResponse(err))
+		return
+	case errors.As(err, &maxBytesErr):
+		err := fmt.Errorf("a batch is at most %d bytes", maxBytesErr.Limit)
+		ctx.JSON(http.StatusRequestEntityTooLarge, errorResponse(err))
+		return
+	case err != nil:
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if err := validateTransferBatch(rows); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if !server.checkTransferBatchAccounts(ctx, rows, authPayload.Username) {
+		return
+	}
+
+	if !server.screenTransferBatch(ctx, rows, authPayload.Username) {
+		return
+	}
+
+	arg := db.CreateTransferBatchTxParams{
+		Owner: authPayload.Username,
+		Mode:  mode,
+	}
+	for _, row := range rows {
+		arg.Rows = append(arg.Rows, db.TransferBatchRow{
+			FromAccountID: row.FromAccountID,
+			ToAccountID:   row.ToAccountID,
+			Amount:        row.Amount,
+			Currency:      row.Currency,
+		})
+	}
+
+	batch, err := server.store.CreateTransferBatchTx(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, newTransferBatchResponse(batch))
+}
+
+// parseTransferBatch reads the rows of an upload, as CSV with a header or as JSON lines
+func parseTransferBatch(contentType string, r io.Reader) ([]transferRequest, error) {
+	var rows []transferRequest
+	var err error
+
+	switch contentType {
+	case csvContentType:
+		rows, err = parseTransferBatchCSV(r)
+	case jsonLinesContentType:
+		rows, err = parseTransferBatchJSONLines(r)
+	default:
+		return nil, errUnsupportedBatchType
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rows) == 0 {
+		return nil, errors.New("the batch has no rows")
+	}
+
+	return rows, nil
+}
+
+func parseTransferBatchCSV(r io.Reader) ([]transferRequest, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[name] = i
+	}
+	for _, name := range transferBatchColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("the header has no %s column", name)
+		}
+	}
+
+	var rows []transferRequest
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if len(rows) == maxTransferBatchRows {
+			return nil, fmt.Errorf("a batch has at most %d rows", maxTransferBatchRows)
+		}
+
+		row := transferRequest{Currency: record[columns["currency"]]}
+		fields := []struct {
+			name  string
+			value *int64
+		}{
+			{"from_account_id", &row.FromAccountID},
+			{"to_account_id", &row.ToAccountID},
+			{"amount", &row.Amount},
+		}
+		for _, field := range fields {
+			*field.value, err = strconv.ParseInt(record[columns[field.name]], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("row %d: %s must be an integer", len(rows)+1, field.name)
+			}
+		}
+
+		rows = append(rows, row)
+	}
+}
+
+func parseTransferBatchJSONLines(r io.Reader) ([]transferRequest, error) {
+	decoder := json.NewDecoder(r)
+
+	var rows []transferRequest
+	for {
+		var row transferRequest
+		err := decoder.Decode(&row)
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", len(rows)+1, err)
+		}
+
+		if len(rows) == maxTransferBatchRows {
+			return nil, fmt.Errorf("a batch has at most %d rows", maxTransferBatchRows)
+		}
+
+		rows = append(rows, row)
+	}
+}
+
+// validateTransferBatch applies the rules of a single transfer to every row and reports all the rows that break them
+func validateTransferBatch(rows []transferRequest) error {
+	var errs []error
+
+	for i := range rows {
+		if err := binding.Validator.ValidateStruct(&rows[i]); err != nil {
+			errs = append(errs, fmt.Errorf("row %d: %w", i+1, err))
+			continue
+		}
+
+		if rows[i].FromAccountID == rows[i].ToAccountID {
+			errs = append(errs, fmt.Errorf("row %d: cannot transfer to the same account", i+1))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// checkTransferBatchAccounts checks that every row moves money out of an account of owner
+// into an existing customer account, both holding the currency of the row
+// it writes the error response and returns false otherwise
+func (server *Server) checkTransferBatchAccounts(ctx *gin.Context, rows []transferRequest, owner string) bool {
+	seen := map[int64]bool{}
+	var ids []int64
+	for _, row := range rows {
+		for _, id := range []int64{row.FromAccountID, row.ToAccountID} {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	found, err := server.store.ListAccountsByIDs(ctx, ids)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+
+	accounts := make(map[int64]db.Account, len(found))
+	for _, account := range found {
+		accounts[account.ID] = account
+	}
+
+	var errs []error
+	for i, row := range rows {
+		from, ok := accounts[row.FromAccountID]
+		if ok && from.Owner != owner {
+			err := fmt.Errorf("row %d: from account doesn't belong to the authenticated user", i+1)
+			ctx.JSON(http.StatusForbidden, errorResponse(err))
+			return false
+		}
+
+		for _, id := range []int64{row.FromAccountID, row.ToAccountID} {
+			if account, ok := accounts[id]; ok && !db.CustomerProduct(account.Product) {
+				err := fmt.Errorf("row %d: account [%d] is not a customer account", i+1, id)
+				ctx.JSON(http.StatusForbidden, errorResponse(err))
+				return false
+			}
+		}
+
+		for _, id := range []int64{row.FromAccountID, row.ToAccountID} {
+			account, ok := accounts[id]
+			switch {
+			case !ok:
+				errs = append(errs, fmt.Errorf("row %d: account [%d] not found", i+1, id))
+			case account.Currency != row.Currency:
+				errs = append(errs, fmt.Errorf("row %d: account [%d] currency mismatch: %s vs %s", i+1, id, account.Currency, row.Currency))
+			}
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return false
+	}
+
+	return true
+}
+
+// screenTransferBatch screens every row of a batch, the rows are posted by the worker without screening
+// it writes the error response and returns false when a row is refused
+func (server *Server) screenTransferBatch(ctx *gin.Context, rows []transferRequest, owner string) bool {
+	var errs []error
+	for i, row := range rows {
+		_, err := server.store.ScreenDeferredTransfer(ctx, server.risk, db.ScreenTransferTxParams{
+			TransferCreateParams: db.TransferCreateParams{
+				FromAccountID: row.FromAccountID,
+				ToAccountID:   row.ToAccountID,
+				Amount:        row.Amount,
+			},
+			Currency: row.Currency,
+			Owner:    owner,
+		})
+		if err != nil && !errors.Is(err, db.ErrTransferBlocked) {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return false
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("row %d: %w", i+1, err))
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		return false
+	}
+
+	return true
+}
+
+type listTransferBatchesQuery struct {
+	PageSize int32  `form:"page_size" binding:"required,min=1,max=100"`
+	Cursor   string `form:"cursor"`
+}
+
+func (server *Server) listTransferBatches(ctx *gin.Context) {
+	var query listTransferBatchesQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	afterID, err := util.DecodeCursor(query.Cursor)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	batches, err := server.store.ListTransferBatches(ctx, db.ListTransferBatchesParams{
+		Owner:      authPayload.Username,
+		AfterID:    afterID,
+		LimitCount: query.PageSize,
+	})
+
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if len(batches) > 0 {
+		setNextCursor(ctx, len(batches), query.PageSize, batches[len(batches)-1].ID)
+	}
+
+	rsp := make([]transferBatchResponse, 0, len(batches))
+	for _, batch := range batches {
+		rsp = append(rsp, newTransferBatchResponse(batch))
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type transferBatchURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// getTransferBatch returns the status and progress of a batch
+func (server *Server) getTransferBatch(ctx *gin.Context) {
+	var uri transferBatchURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	batch, ok := server.ownedTransferBatch(ctx, uri.ID)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newTransferBatchResponse(batch))
+}
+
+type listTransferBatchItemsQuery struct {
+	PageSize int32  `form:"page_size" binding:"required,min=1,max=100"`
+	Cursor   string `form:"cursor"`
+}
+
+// listTransferBatchItems returns the rows of a batch in upload order, with the transfer or error of each
+func (server *Server) listTransferBatchItems(ctx *gin.Context) {
+	var uri transferBatchURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var query listTransferBatchItemsQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	afterID, err := util.DecodeCursor(query.Cursor)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if _, ok := server.ownedTransferBatch(ctx, uri.ID); !ok {
+		return
+	}
+
+	items, err := server.store.ListTransferBatchItems(ctx, db.ListTransferBatchItemsParams{
+		BatchID:    uri.ID,
+		AfterID:    afterID,
+		LimitCount: query.PageSize,
+	})
+
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if len(items) > 0 {
+		setNextCursor(ctx, len(items), query.PageSize, items[len(items)-1].ID)
+	}
+
+	rsp := make([]transferBatchItemResponse, 0, len(items))
+	for _, item := range items {
+		rsp = append(rsp, newTransferBatchItemResponse(item))
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// getTransferBatchReport downloads the rows of a batch with their outcome as CSV
+func (server *Server) getTransferBatchReport(ctx *gin.Context) {
+	var uri transferBatchURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	batch, ok := server.ownedTransferBatch(ctx, uri.ID)
+	if !ok {
+		return
+	}
+
+	items, err := server.store.ListTransferBatchItems(ctx, db.ListTransferBatchItemsParams{
+		BatchID:    batch.ID,
+		AfterID:    0,
+		LimitCount: batch.TotalRows,
+	})
+
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.Header("Content-Type", csvContentType)
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="transfer-batch-%d.csv"`, batch.ID))
+	ctx.Status(http.StatusOK)
+
+	writer := csv.NewWriter(ctx.Writer)
+	writer.Write(transferBatchReportColumns)
+
+	for _, item := range items {
+		var transferID string
+		if item.TransferID.Valid {
+			transferID = strconv.FormatInt(item.TransferID.Int64, 10)
+		}
+
+		writer.Write([]string{
+			strconv.Itoa(int(item.RowNumber)),
+			strconv.FormatInt(item.FromAccountID, 10),
+			strconv.FormatInt(item.ToAccountID, 10),
+			strconv.FormatInt(item.Amount, 10),
+			item.Currency,
+			item.Status,
+			transferID,
+			item.Error.String,
+		})
+	}
+
+	writer.Flush()
+}
+
+// ownedTransferBatch loads a transfer batch of the authenticated user
+// it writes the error response and returns false when it is missing or owned by someone else
+func (server *Server) ownedTransferBatch(ctx *gin.Context, id int64) (db.TransferBatch, bool) {
+	batch, err := server.store.GetTransferBatch(ctx, id)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return batch, false
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return batch, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if batch.Owner != authPayload.Username {
+		err := errors.New("transfer batch doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return batch, false
+	}
+
+	return batch, true
+}
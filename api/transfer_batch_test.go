@@ -0,0 +1,169 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"simplebank/rbac"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTransferBatch(t *testing.T) {
+	testCases := []struct {
+		name        string
+		contentType string
+		body        string
+		check       func(t *testing.T, rows []transferRequest, err error)
+	}{
+		{
+			name:        "CSV",
+			contentType: csvContentType,
+			body:        "currency,amount,from_account_id,to_account_id\nUSD,10,1,2\nEUR, 20,3,4\n",
+			check: func(t *testing.T, rows []transferRequest, err error) {
+				require.NoError(t, err)
+				require.Equal(t, []transferRequest{
+					{FromAccountID: 1, ToAccountID: 2, Amount: 10, Currency: "USD"},
+					{FromAccountID: 3, ToAccountID: 4, Amount: 20, Currency: "EUR"},
+				}, rows)
+			},
+		},
+		{
+			name:        "CSVMissingColumn",
+			contentType: csvContentType,
+			body:        "from_account_id,to_account_id,amount\n1,2,10\n",
+			check: func(t *testing.T, rows []transferRequest, err error) {
+				require.EqualError(t, err, "the header has no currency column")
+			},
+		},
+		{
+			name:        "CSVNotAnInteger",
+			contentType: csvContentType,
+			body:        "from_account_id,to_account_id,amount,currency\n1,2,10,USD\n1,2,ten,USD\n",
+			check: func(t *testing.T, rows []transferRequest, err error) {
+				require.EqualError(t, err, "row 2: amount must be an integer")
+			},
+		},
+		{
+			name:        "CSVWrongFieldCount",
+			contentType: csvContentType,
+			body:        "from_account_id,to_account_id,amount,currency\n1,2,10\n",
+			check: func(t *testing.T, rows []transferRequest, err error) {
+				require.Error(t, err)
+			},
+		},
+		{
+			name:        "JSONLines",
+			contentType: jsonLinesContentType,
+			body:        "{\"from_account_id\":1,\"to_account_id\":2,\"amount\":10,\"currency\":\"USD\"}\n\n{\"from_account_id\":3,\"to_account_id\":4,\"amount\":20,\"currency\":\"EUR\"}\n",
+			check: func(t *testing.T, rows []transferRequest, err error) {
+				require.NoError(t, err)
+				require.Len(t, rows, 2)
+				require.Equal(t, int64(3), rows[1].FromAccountID)
+			},
+		},
+		{
+			name:        "JSONLinesMalformed",
+			contentType: jsonLinesContentType,
+			body:        "{\"from_account_id\":1,\"to_account_id\":2,\"amount\":10,\"currency\":\"USD\"}\n{\"amount\":\n",
+			check: func(t *testing.T, rows []transferRequest, err error) {
+				require.ErrorContains(t, err, "row 2:")
+			},
+		},
+		{
+			name:        "Empty",
+			contentType: csvContentType,
+			body:        "from_account_id,to_account_id,amount,currency\n",
+			check: func(t *testing.T, rows []transferRequest, err error) {
+				require.EqualError(t, err, "the batch has no rows")
+			},
+		},
+		{
+			name:        "TooManyRows",
+			contentType: csvContentType,
+			body:        "from_account_id,to_account_id,amount,currency\n" + strings.Repeat("1,2,10,USD\n", maxTransferBatchRows+1),
+			check: func(t *testing.T, rows []transferRequest, err error) {
+				require.EqualError(t, err, fmt.Sprintf("a batch has at most %d rows", maxTransferBatchRows))
+			},
+		},
+		{
+			name:        "UnsupportedType",
+			contentType: "application/json",
+			body:        "[]",
+			check: func(t *testing.T, rows []transferRequest, err error) {
+				require.ErrorIs(t, err, errUnsupportedBatchType)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rows, err := parseTransferBatch(tc.contentType, strings.NewReader(tc.body))
+			tc.check(t, rows, err)
+		})
+	}
+}
+
+func TestValidateTransferBatchReportsEveryRow(t *testing.T) {
+	rows := []transferRequest{
+		{FromAccountID: 1, ToAccountID: 2, Amount: 10, Currency: "USD"},
+		{FromAccountID: 1, ToAccountID: 2, Amount: 0, Currency: "USD"},
+		{FromAccountID: 1, ToAccountID: 2, Amount: 10, Currency: "USD"},
+		{FromAccountID: 3, ToAccountID: 3, Amount: 10, Currency: "EUR"},
+	}
+
+	err := validateTransferBatch(rows)
+	require.Error(t, err)
+
+	lines := strings.Split(err.Error(), "\n")
+	require.Len(t, lines, 2)
+	require.True(t, strings.HasPrefix(lines[0], "row 2: "))
+	require.Equal(t, "row 4: cannot transfer to the same account", lines[1])
+
+	require.NoError(t, validateTransferBatch(rows[:1]))
+}
+
+// TestCreateTransferBatchRejectsUpload checks that bad uploads are rejected before the store is used
+func TestCreateTransferBatchRejectsUpload(t *testing.T) {
+	server := newTestServer(t, nil)
+
+	testCases := []struct {
+		name        string
+		contentType string
+		body        string
+		status      int
+	}{
+		{
+			name:        "UnsupportedType",
+			contentType: "application/json",
+			body:        `[{"from_account_id":1,"to_account_id":2,"amount":10,"currency":"USD"}]`,
+			status:      http.StatusUnsupportedMediaType,
+		},
+		{
+			name:        "TooLarge",
+			contentType: csvContentType,
+			body:        "from_account_id,to_account_id,amount,currency,note\n" + strings.Repeat("1,2,10,USD,"+strings.Repeat("x", 4096)+"\n", 300),
+			status:      http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:        "InvalidRow",
+			contentType: csvContentType,
+			body:        "from_account_id,to_account_id,amount,currency\n1,2,10,GBP\n",
+			status:      http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPost, "/transfer-batches", strings.NewReader(tc.body))
+			request.Header.Set("Content-Type", tc.contentType)
+			addAuthorization(t, request, server.tokenMaker, "alice", rbac.RoleCustomer)
+
+			recorder := httptest.NewRecorder()
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, tc.status, recorder.Code, recorder.Body.String())
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	db "simplebank/db/sqlc"
+	"simplebank/token"
+	"simplebank/util"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// createTransferLimitRequest changes the limits of an account, of every account of a user, or of every account
+// without account_id and owner; a limit left out is not limited
+type createTransferLimitRequest struct {
+	AccountID     *int64  `json:"account_id" binding:"omitempty,min=1,excluded_with=Owner"`
+	Owner         *string `json:"owner" binding:"omitempty,alphanum"`
+	MaxAmount     *int64  `json:"max_amount" binding:"omitempty,gt=0"`
+	DailyAmount   *int64  `json:"daily_amount" binding:"omitempty,gt=0"`
+	MonthlyAmount *int64  `json:"monthly_amount" binding:"omitempty,gt=0"`
+	HourlyCount   *int32  `json:"hourly_count" binding:"omitempty,gt=0"`
+	// ExpiresAt makes the change temporary, the previous limits apply again after it
+	ExpiresAt *time.Time `json:"expires_at"`
+	Reason    string     `json:"reason" binding:"required,max=500"`
+}
+
+type transferLimitResponse struct {
+	ID            int64      `json:"id"`
+	AccountID     *int64     `json:"account_id,omitempty"`
+	Owner         *string    `json:"owner,omitempty"`
+	MaxAmount     *int64     `json:"max_amount,omitempty"`
+	DailyAmount   *int64     `json:"daily_amount,omitempty"`
+	MonthlyAmount *int64     `json:"monthly_amount,omitempty"`
+	HourlyCount   *int32     `json:"hourly_count,omitempty"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+	Reason        string     `json:"reason"`
+	CreatedBy     string     `json:"created_by"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+func newTransferLimitResponse(limit db.TransferLimit) transferLimitResponse {
+	rsp := transferLimitResponse{
+		ID:            limit.ID,
+		Owner:         nullString(limit.Owner),
+		AccountID:     nullInt64(limit.AccountID),
+		MaxAmount:     nullInt64(limit.MaxAmount),
+		DailyAmount:   nullInt64(limit.DailyAmount),
+		MonthlyAmount: nullInt64(limit.MonthlyAmount),
+		ExpiresAt:     nullTime(limit.ExpiresAt),
+		Reason:        limit.Reason,
+		CreatedBy:     limit.CreatedBy,
+		CreatedAt:     limit.CreatedAt,
+	}
+
+	if limit.HourlyCount.Valid {
+		rsp.HourlyCount = &limit.HourlyCount.Int32
+	}
+
+	return rsp
+}
+
+func nullInt64(n sql.NullInt64) *int64 {
+	if !n.Valid {
+		return nil
+	}
+	return &n.Int64
+}
+
+func toNullInt64(n *int64) sql.NullInt64 {
+	if n == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: *n, Valid: true}
+}
+
+// createTransferLimit records a change of limits made by an admin, the rows form the audit trail of the limits
+func (server *Server) createTransferLimit(ctx *gin.Context) {
+	var req createTransferLimitRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		err := errors.New("expires_at must be in the future")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	arg := db.CreateTransferLimitParams{
+		AccountID:     toNullInt64(req.AccountID),
+		MaxAmount:     toNullInt64(req.MaxAmount),
+		DailyAmount:   toNullInt64(req.DailyAmount),
+		MonthlyAmount: toNullInt64(req.MonthlyAmount),
+		Reason:        req.Reason,
+		CreatedBy:     ctx.MustGet(authorizationPayloadKey).(*token.Payload).Username,
+	}
+	if req.Owner != nil {
+		arg.Owner = sql.NullString{String: *req.Owner, Valid: true}
+	}
+	if req.HourlyCount != nil {
+		arg.HourlyCount = sql.NullInt32{Int32: *req.HourlyCount, Valid: true}
+	}
+	if req.ExpiresAt != nil {
+		arg.ExpiresAt = sql.NullTime{Time: *req.ExpiresAt, Valid: true}
+	}
+
+	var err error
+	switch {
+	case arg.AccountID.Valid:
+		_, err = server.store.GetAccount(ctx, arg.AccountID.Int64)
+	case arg.Owner.Valid:
+		_, err = server.store.GetUser(ctx, arg.Owner.String)
+	}
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	limit, err := server.store.CreateTransferLimit(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, newTransferLimitResponse(limit))
+}
+
+type listTransferLimitsQuery struct {
+	AccountID int64  `form:"account_id" binding:"omitempty,min=1"`
+	Owner     string `form:"owner" binding:"omitempty,alphanum"`
+	PageSize  int32  `form:"page_size" binding:"required,min=1,max=100"`
+	Cursor    string `form:"cursor"`
+}
+
+// listTransferLimits lists the changes of limits in the order they were made, for an account or a user when given
+func (server *Server) listTransferLimits(ctx *gin.Context) {
+	var query listTransferLimitsQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	afterID, err := util.DecodeCursor(query.Cursor)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	limits, err := server.store.ListTransferLimits(ctx, db.ListTransferLimitsParams{
+		AccountID:  sql.NullInt64{Int64: query.AccountID, Valid: query.AccountID != 0},
+		Owner:      sql.NullString{String: query.Owner, Valid: query.Owner != ""},
+		AfterID:    afterID,
+		LimitCount: query.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if len(limits) > 0 {
+		setNextCursor(ctx, len(limits), query.PageSize, limits[len(limits)-1].ID)
+	}
+
+	rsp := make([]transferLimitResponse, 0, len(limits))
+	for _, limit := range limits {
+		rsp = append(rsp, newTransferLimitResponse(limit))
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
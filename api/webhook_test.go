@@ -0,0 +1,42 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"simplebank/rbac"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateWebhookSubscriptionInternalURL(t *testing.T) {
+	testCases := []struct {
+		name string
+		url  string
+	}{
+		{name: "Loopback", url: "http://127.0.0.1:8080/hooks"},
+		{name: "Localhost", url: "http://localhost/hooks"},
+		{name: "Metadata", url: "http://169.254.169.254/latest/meta-data"},
+		{name: "Private", url: "https://192.168.1.10/hooks"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestServer(t, nil)
+
+			body, err := json.Marshal(gin.H{"url": tc.url, "event_types": []string{"transfer.completed"}})
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			request := httptest.NewRequest(http.MethodPost, "/webhooks", bytes.NewReader(body))
+			addAuthorization(t, request, server.tokenMaker, "alice", rbac.RoleCustomer)
+
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, http.StatusBadRequest, recorder.Code)
+			require.Contains(t, recorder.Body.String(), "not a public address")
+		})
+	}
+}
@@ -0,0 +1,122 @@
+// Package apikey generates the API keys backend services authenticate with and checks the keys they send
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// keyPrefix makes API keys recognizable in logs and secret scanners
+const keyPrefix = "sbk"
+
+const (
+	prefixBytes = 4
+	secretBytes = 32
+)
+
+var ErrInvalidKey = errors.New("api key is invalid")
+
+// Key is a new API key, String is handed to its owner once and only Hash is stored
+type Key struct {
+	// Prefix is the public part of the key, it finds the key without its secret
+	Prefix string
+	Secret string
+	Hash   string
+}
+
+// String returns the key as the caller sends it: sbk_<prefix>_<secret>
+func (key Key) String() string {
+	return keyPrefix + "_" + key.Prefix + "_" + key.Secret
+}
+
+// Generate returns a new random key
+func Generate() (Key, error) {
+	prefix, err := randomHex(prefixBytes)
+	if err != nil {
+		return Key{}, err
+	}
+
+	secret, err := randomHex(secretBytes)
+	if err != nil {
+		return Key{}, err
+	}
+
+	return Key{Prefix: prefix, Secret: secret, Hash: Hash(secret)}, nil
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// Parse splits a key sent by a caller into its prefix and its secret
+func Parse(key string) (prefix string, secret string, err error) {
+	parts := strings.Split(key, "_")
+	if len(parts) != 3 || parts[0] != keyPrefix ||
+		len(parts[1]) != 2*prefixBytes || len(parts[2]) != 2*secretBytes {
+		return "", "", ErrInvalidKey
+	}
+
+	return parts[1], parts[2], nil
+}
+
+// Hash returns the hex SHA-256 of secret, the secret is random enough that a slow hash buys nothing
+func Hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// Verify reports whether secret hashes to hash, in constant time
+func Verify(secret string, hash string) bool {
+	return subtle.ConstantTimeCompare([]byte(Hash(secret)), []byte(hash)) == 1
+}
+
+// ValidAllowedIP checks that entry is an IP or a CIDR range
+func ValidAllowedIP(entry string) error {
+	if net.ParseIP(entry) != nil {
+		return nil
+	}
+
+	if _, _, err := net.ParseCIDR(entry); err != nil {
+		return fmt.Errorf("%q is neither an IP nor a CIDR range", entry)
+	}
+
+	return nil
+}
+
+// AllowsIP reports whether ip matches an IP or a CIDR range of allowed, an empty allowlist allows every IP
+func AllowsIP(allowed []string, ip string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+
+	for _, entry := range allowed {
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			if network.Contains(parsed) {
+				return true
+			}
+			continue
+		}
+
+		if allowedIP := net.ParseIP(entry); allowedIP != nil && allowedIP.Equal(parsed) {
+			return true
+		}
+	}
+
+	return false
+}
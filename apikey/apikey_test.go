@@ -0,0 +1,66 @@
+package apikey
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateAndParse(t *testing.T) {
+	key, err := Generate()
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(key.String(), keyPrefix+"_"+key.Prefix+"_"))
+	require.NotEqual(t, key.Secret, key.Hash)
+
+	prefix, secret, err := Parse(key.String())
+	require.NoError(t, err)
+	require.Equal(t, key.Prefix, prefix)
+	require.True(t, Verify(secret, key.Hash))
+	require.False(t, Verify(secret[1:]+"0", key.Hash))
+
+	other, err := Generate()
+	require.NoError(t, err)
+	require.NotEqual(t, key.Prefix, other.Prefix)
+	require.False(t, Verify(other.Secret, key.Hash))
+
+	for _, invalid := range []string{
+		"",
+		key.Secret,
+		"sk_" + key.Prefix + "_" + key.Secret,
+		keyPrefix + "_" + key.Prefix + "_" + key.Secret[1:],
+		keyPrefix + "_" + key.Prefix + "_" + key.Secret + "_x",
+	} {
+		_, _, err := Parse(invalid)
+		require.ErrorIs(t, err, ErrInvalidKey, invalid)
+	}
+}
+
+func TestAllowsIP(t *testing.T) {
+	allowed := []string{"192.0.2.10", "198.51.100.0/24", "2001:db8::/32"}
+
+	testCases := []struct {
+		ip      string
+		allowed bool
+	}{
+		{ip: "192.0.2.10", allowed: true},
+		{ip: "192.0.2.11", allowed: false},
+		{ip: "198.51.100.77", allowed: true},
+		{ip: "2001:db8::1", allowed: true},
+		{ip: "2001:db9::1", allowed: false},
+		{ip: "not-an-ip", allowed: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.ip, func(t *testing.T) {
+			require.Equal(t, tc.allowed, AllowsIP(allowed, tc.ip))
+		})
+	}
+
+	require.True(t, AllowsIP(nil, "203.0.113.5"))
+
+	require.NoError(t, ValidAllowedIP("10.0.0.0/8"))
+	require.NoError(t, ValidAllowedIP("::1"))
+	require.Error(t, ValidAllowedIP("10.0.0.0/33"))
+	require.Error(t, ValidAllowedIP("example.com"))
+}
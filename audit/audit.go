@@ -0,0 +1,35 @@
+// Package audit carries who acts on the bank through the context, so that the store records it in the audit log
+package audit
+
+import "context"
+
+const (
+	// SystemActor acts when no caller does: workers and commands
+	SystemActor = "system"
+	// AnonymousActor calls the routes open before login
+	AnonymousActor = "anonymous"
+)
+
+// Actor is the caller of a request, recorded with every audit log row written on its behalf
+type Actor struct {
+	Username  string
+	IP        string
+	UserAgent string
+	RequestID string
+}
+
+type actorKey struct{}
+
+// WithActor returns a copy of ctx that carries actor
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// FromContext returns the actor carried by ctx, or the system
+func FromContext(ctx context.Context) Actor {
+	if actor, ok := ctx.Value(actorKey{}).(Actor); ok {
+		return actor
+	}
+
+	return Actor{Username: SystemActor}
+}
@@ -0,0 +1,16 @@
+package audit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestActorContext(t *testing.T) {
+	require.Equal(t, Actor{Username: SystemActor}, FromContext(context.Background()))
+
+	actor := Actor{Username: "alice", IP: "192.0.2.1", UserAgent: "curl/8.0", RequestID: "req-1"}
+	ctx := WithActor(context.Background(), actor)
+	require.Equal(t, actor, FromContext(ctx))
+}
@@ -0,0 +1,133 @@
+// Package batch runs uploaded transfer batches in the background
+package batch
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	db "simplebank/db/sqlc"
+	"simplebank/logging"
+	"time"
+)
+
+const (
+	// defaultChunkSize bounds the rows of a best-effort batch run in one tx, progress is visible after each chunk
+	defaultChunkSize = 100
+	// defaultBatchSize bounds how many batches a worker runs before polling again
+	defaultBatchSize = 10
+	// lease is how long a batch stays claimed after its last chunk before another worker may take it over
+	lease = time.Minute
+)
+
+// RunResult counts the batches a worker finished
+type RunResult struct {
+	Completed int
+	Failed    int
+}
+
+// Worker claims pending transfer batches and runs them chunk by chunk
+// replicas can run side by side: a batch is claimed by one of them and taken over only when its lease runs out
+type Worker struct {
+	store     *db.Store
+	interval  time.Duration
+	chunkSize int32
+	batchSize int
+	now       func() time.Time
+}
+
+// NewWorker creates a worker polling for pending batches every interval
+func NewWorker(store *db.Store, interval time.Duration) *Worker {
+	return &Worker{
+		store:     store,
+		interval:  interval,
+		chunkSize: defaultChunkSize,
+		batchSize: defaultBatchSize,
+		now:       time.Now,
+	}
+}
+
+// Run processes batches until ctx is done
+func (w *Worker) Run(ctx context.Context) error {
+	logger := logging.FromContext(ctx).With(slog.String("worker", "transfer_batches"))
+	ctx = logging.WithLogger(ctx, logger)
+
+	for {
+		result, err := w.RunOnce(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		total := result.Completed + result.Failed
+		if err != nil {
+			logger.ErrorContext(ctx, "transfer batches failed", slog.Any("error", err))
+		} else if total > 0 {
+			logger.InfoContext(ctx, "transfer batches run",
+				slog.Int("completed", result.Completed),
+				slog.Int("failed", result.Failed),
+			)
+		}
+
+		if err == nil && total == w.batchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(w.interval):
+		}
+	}
+}
+
+// RunOnce claims and runs batches one at a time until none is pending or the batch size is reached
+func (w *Worker) RunOnce(ctx context.Context) (RunResult, error) {
+	var result RunResult
+
+	for i := 0; i < w.batchSize; i++ {
+		now := w.now()
+
+		batch, err := w.store.ClaimTransferBatch(ctx, db.ClaimTransferBatchParams{
+			LeaseUntil: now.Add(lease),
+			Now:        now,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return result, nil
+		}
+		if err != nil {
+			return result, err
+		}
+
+		batch, err = w.process(ctx, batch)
+		if err != nil {
+			return result, err
+		}
+
+		if batch.Status == db.TransferBatchStatusFailed {
+			result.Failed++
+		} else {
+			result.Completed++
+		}
+	}
+
+	return result, nil
+}
+
+// process runs the chunks of a claimed batch until it is over
+func (w *Worker) process(ctx context.Context, batch db.TransferBatch) (db.TransferBatch, error) {
+	var err error
+
+	for batch.Status == db.TransferBatchStatusProcessing {
+		batch, err = w.store.ProcessTransferBatchTx(ctx, db.ProcessTransferBatchTxParams{
+			BatchID:   batch.ID,
+			Now:       w.now(),
+			ChunkSize: w.chunkSize,
+			Lease:     lease,
+		})
+		if err != nil {
+			return batch, err
+		}
+	}
+
+	return batch, nil
+}
@@ -0,0 +1,308 @@
+package batch
+
+import (
+	"context"
+	"database/sql"
+	db "simplebank/db/sqlc"
+	"simplebank/util"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomUser(t *testing.T) string {
+	user, err := testStore.CreateUser(context.Background(), db.CreateUserParams{
+		Username:       util.RandomOwner(),
+		HashedPassword: "secret",
+		FullName:       util.RandomOwner(),
+		Email:          util.RandomEmail(),
+	})
+	require.NoError(t, err)
+
+	return user.Username
+}
+
+func createAccount(t *testing.T, owner string, balance int64) db.Account {
+	account, err := testStore.CreateAccountTx(context.Background(), db.CreateAccountParams{
+		Owner:    owner,
+		Balance:  balance,
+		Currency: "USD",
+	})
+	require.NoError(t, err)
+
+	return account
+}
+
+func createBatch(t *testing.T, owner string, mode string, rows ...db.TransferBatchRow) db.TransferBatch {
+	batch, err := testStore.CreateTransferBatchTx(context.Background(), db.CreateTransferBatchTxParams{
+		Owner: owner,
+		Mode:  mode,
+		Rows:  rows,
+	})
+	require.NoError(t, err)
+	require.Equal(t, db.TransferBatchStatusPending, batch.Status)
+	require.Equal(t, int32(len(rows)), batch.TotalRows)
+
+	return batch
+}
+
+func row(from db.Account, to db.Account, amount int64) db.TransferBatchRow {
+	return db.TransferBatchRow{
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        amount,
+		Currency:      "USD",
+	}
+}
+
+func listItems(t *testing.T, batchID int64) []db.TransferBatchItem {
+	items, err := testStore.ListTransferBatchItems(context.Background(), db.ListTransferBatchItemsParams{
+		BatchID:    batchID,
+		AfterID:    0,
+		LimitCount: 1000,
+	})
+	require.NoError(t, err)
+
+	return items
+}
+
+func requireBalance(t *testing.T, accountID int64, balance int64) {
+	account, err := testStore.GetAccount(context.Background(), accountID)
+	require.NoError(t, err)
+	require.Equal(t, balance, account.Balance)
+}
+
+func runBatches(t *testing.T) {
+	_, err := NewWorker(testStore, time.Second).RunOnce(context.Background())
+	require.NoError(t, err)
+}
+
+func TestBestEffortBatchRunsWhatItCan(t *testing.T) {
+	owner := createRandomUser(t)
+	from := createAccount(t, owner, 100)
+	to := createAccount(t, createRandomUser(t), 0)
+
+	batch := createBatch(t, owner, db.TransferBatchModeBestEffort,
+		row(from, to, 60),
+		row(from, to, 60),
+		row(from, to, 40),
+	)
+
+	runBatches(t)
+
+	batch, err := testStore.GetTransferBatch(context.Background(), batch.ID)
+	require.NoError(t, err)
+	require.Equal(t, db.TransferBatchStatusCompleted, batch.Status)
+	require.Equal(t, int32(2), batch.SucceededRows)
+	require.Equal(t, int32(1), batch.FailedRows)
+	require.True(t, batch.CompletedAt.Valid)
+	require.False(t, batch.LeaseUntil.Valid)
+
+	items := listItems(t, batch.ID)
+	require.Len(t, items, 3)
+	require.Equal(t, db.TransferBatchItemSucceeded, items[0].Status)
+	require.True(t, items[0].TransferID.Valid)
+	require.Equal(t, db.TransferBatchItemFailed, items[1].Status)
+	require.Contains(t, items[1].Error.String, "insufficient funds")
+	require.False(t, items[1].TransferID.Valid)
+	require.Equal(t, db.TransferBatchItemSucceeded, items[2].Status)
+
+	requireBalance(t, from.ID, 0)
+	requireBalance(t, to.ID, 100)
+}
+
+func TestAtomicBatchRunsEveryRowOrNone(t *testing.T) {
+	owner := createRandomUser(t)
+	from := createAccount(t, owner, 100)
+	to := createAccount(t, createRandomUser(t), 0)
+
+	failed := createBatch(t, owner, db.TransferBatchModeAtomic,
+		row(from, to, 60),
+		row(from, to, 60),
+		row(from, to, 10),
+	)
+	runBatches(t)
+
+	failed, err := testStore.GetTransferBatch(context.Background(), failed.ID)
+	require.NoError(t, err)
+	require.Equal(t, db.TransferBatchStatusFailed, failed.Status)
+	require.Zero(t, failed.SucceededRows)
+	require.Equal(t, int32(3), failed.FailedRows)
+
+	items := listItems(t, failed.ID)
+	require.Equal(t, db.TransferBatchItemSkipped, items[0].Status)
+	require.Equal(t, "not run because row 2 failed", items[0].Error.String)
+	require.Equal(t, db.TransferBatchItemFailed, items[1].Status)
+	require.Contains(t, items[1].Error.String, "insufficient funds")
+	require.Equal(t, db.TransferBatchItemSkipped, items[2].Status)
+
+	requireBalance(t, from.ID, 100)
+	requireBalance(t, to.ID, 0)
+
+	completed := createBatch(t, owner, db.TransferBatchModeAtomic,
+		row(from, to, 60),
+		row(from, to, 40),
+	)
+	runBatches(t)
+
+	completed, err = testStore.GetTransferBatch(context.Background(), completed.ID)
+	require.NoError(t, err)
+	require.Equal(t, db.TransferBatchStatusCompleted, completed.Status)
+	require.Equal(t, int32(2), completed.SucceededRows)
+
+	requireBalance(t, from.ID, 0)
+	requireBalance(t, to.ID, 100)
+}
+
+func TestBestEffortBatchRunsInChunks(t *testing.T) {
+	owner := createRandomUser(t)
+	from := createAccount(t, owner, 100)
+	to := createAccount(t, createRandomUser(t), 0)
+
+	var rows []db.TransferBatchRow
+	for i := 0; i < 5; i++ {
+		rows = append(rows, row(from, to, 10))
+	}
+	batch := createBatch(t, owner, db.TransferBatchModeBestEffort, rows...)
+
+	now := time.Now()
+	batch, err := testStore.ClaimTransferBatch(context.Background(), db.ClaimTransferBatchParams{
+		LeaseUntil: now.Add(lease),
+		Now:        now,
+	})
+	require.NoError(t, err)
+	require.Equal(t, db.TransferBatchStatusProcessing, batch.Status)
+
+	arg := db.ProcessTransferBatchTxParams{
+		BatchID:   batch.ID,
+		Now:       now,
+		ChunkSize: 2,
+		Lease:     lease,
+	}
+
+	for _, succeeded := range []int32{2, 4} {
+		batch, err = testStore.ProcessTransferBatchTx(context.Background(), arg)
+		require.NoError(t, err)
+		require.Equal(t, db.TransferBatchStatusProcessing, batch.Status)
+		require.Equal(t, succeeded, batch.SucceededRows)
+	}
+
+	batch, err = testStore.ProcessTransferBatchTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, db.TransferBatchStatusCompleted, batch.Status)
+	require.Equal(t, int32(5), batch.SucceededRows)
+
+	// a finished batch is left alone
+	batch, err = testStore.ProcessTransferBatchTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, db.TransferBatchStatusCompleted, batch.Status)
+
+	requireBalance(t, from.ID, 50)
+}
+
+// TestConcurrentBatchesDoNotDeadlock runs batches moving money both ways between the same accounts side by side
+func TestConcurrentBatchesDoNotDeadlock(t *testing.T) {
+	owner := createRandomUser(t)
+	accounts := []db.Account{
+		createAccount(t, owner, 1000),
+		createAccount(t, owner, 1000),
+		createAccount(t, owner, 1000),
+	}
+
+	n := 6
+	for i := 0; i < n; i++ {
+		var rows []db.TransferBatchRow
+		for j := range accounts {
+			from, to := accounts[j], accounts[(j+1)%len(accounts)]
+			if i%2 == 1 {
+				from, to = to, from
+			}
+			rows = append(rows, row(from, to, 10))
+		}
+
+		mode := db.TransferBatchModeAtomic
+		if i%3 == 0 {
+			mode = db.TransferBatchModeBestEffort
+		}
+		createBatch(t, owner, mode, rows...)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := NewWorker(testStore, time.Second).RunOnce(context.Background())
+			errs <- err
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	// every account sends and receives the same amount
+	for _, account := range accounts {
+		requireBalance(t, account.ID, 1000)
+	}
+}
+
+// TestConcurrentFeeBatchesDoNotDeadlock runs batches that charge a fee to an account the other batches move money out of
+func TestConcurrentFeeBatchesDoNotDeadlock(t *testing.T) {
+	owner := createRandomUser(t)
+	revenue := createAccount(t, owner, 1000)
+
+	tier := "test-" + util.RandomString(8)
+	var payers []db.Account
+	for i := 0; i < 2; i++ {
+		payer, err := testStore.UpdateAccountTier(context.Background(), db.UpdateAccountTierParams{
+			ID:   createAccount(t, owner, 1000).ID,
+			Tier: tier,
+		})
+		require.NoError(t, err)
+		payers = append(payers, payer)
+	}
+
+	_, err := testStore.CreatePricingRule(context.Background(), db.CreatePricingRuleParams{
+		Tier:         sql.NullString{String: tier, Valid: true},
+		Currency:     "USD",
+		FlatFee:      1,
+		FeeAccountID: revenue.ID,
+	})
+	require.NoError(t, err)
+
+	n := 6
+	for i := 0; i < n; i++ {
+		from, to := payers[i%2], payers[(i+1)%2]
+		createBatch(t, owner, db.TransferBatchModeBestEffort, row(from, to, 10), row(revenue, from, 10))
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := NewWorker(testStore, time.Second).RunOnce(context.Background())
+			errs <- err
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	// every payer sends and receives the same amounts and pays a fee of 1 per row
+	requireBalance(t, payers[0].ID, 1000+30-3)
+	requireBalance(t, payers[1].ID, 1000+30-3)
+	requireBalance(t, revenue.ID, 1000-60+6)
+}
@@ -0,0 +1,77 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	db "simplebank/db/sqlc"
+	"strconv"
+)
+
+// CreateAccountRequest opens an account for the authenticated user
+type CreateAccountRequest struct {
+	Currency string `json:"currency"`
+}
+
+// CreateAccount opens an account for the authenticated user
+func (c *Client) CreateAccount(ctx context.Context, arg CreateAccountRequest, opts ...RequestOption) (db.Account, error) {
+	var account db.Account
+
+	_, err := c.do(ctx, newPost("/accounts", arg, opts), &account)
+
+	return account, err
+}
+
+// GetAccount returns an account of the authenticated user
+func (c *Client) GetAccount(ctx context.Context, id int64) (db.Account, error) {
+	var account db.Account
+
+	_, err := c.do(ctx, request{
+		method: http.MethodGet,
+		path:   fmt.Sprintf("/accounts/%d", id),
+		auth:   true,
+	}, &account)
+
+	return account, err
+}
+
+// ListAccounts iterates over the accounts of the authenticated user, pageSize at a time
+func (c *Client) ListAccounts(pageSize int32) *Iterator[db.Account] {
+	return newIterator(func(ctx context.Context, cursor string) ([]db.Account, string, error) {
+		var accounts []db.Account
+		next, err := c.list(ctx, "/accounts", pageSize, cursor, &accounts)
+		return accounts, next, err
+	})
+}
+
+// ListEntries iterates over the statement of an account, oldest entry first
+func (c *Client) ListEntries(accountID int64, pageSize int32) *Iterator[db.Entry] {
+	return newIterator(func(ctx context.Context, cursor string) ([]db.Entry, string, error) {
+		var entries []db.Entry
+		next, err := c.list(ctx, fmt.Sprintf("/accounts/%d/entries", accountID), pageSize, cursor, &entries)
+		return entries, next, err
+	})
+}
+
+// list fetches one page of a cursor paginated endpoint and returns the cursor of the next page
+func (c *Client) list(ctx context.Context, path string, pageSize int32, cursor string, out any) (string, error) {
+	query := url.Values{}
+	query.Set("page_size", strconv.Itoa(int(pageSize)))
+	if cursor != "" {
+		query.Set("cursor", cursor)
+	}
+
+	rsp, err := c.do(ctx, request{
+		method: http.MethodGet,
+		path:   path,
+		query:  query,
+		auth:   true,
+	}, out)
+
+	if err != nil {
+		return "", err
+	}
+
+	return rsp.header.Get(nextCursorHeader), nil
+}
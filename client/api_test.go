@@ -0,0 +1,118 @@
+package client
+
+import (
+	"context"
+	"errors"
+	db "simplebank/db/sqlc"
+	"simplebank/util"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newTestUser registers a random user against the test server and returns a client logged in as them
+func newTestUser(t *testing.T) (*Client, User) {
+	ctx := context.Background()
+	password := util.RandomString(8)
+
+	anonymous := New(testServer.URL)
+	user, err := anonymous.CreateUser(ctx, CreateUserRequest{
+		Username: util.RandomOwner(),
+		Password: password,
+		FullName: util.RandomOwner(),
+		Email:    util.RandomEmail(),
+	})
+	require.NoError(t, err)
+
+	c := New(testServer.URL)
+	c.tokens = c.LoginTokenSource(user.Username, password)
+
+	return c, user
+}
+
+func TestAccounts(t *testing.T) {
+	ctx := context.Background()
+	c, user := newTestUser(t)
+
+	var ids []int64
+	for i := 0; i < 3; i++ {
+		account, err := c.CreateAccount(ctx, CreateAccountRequest{Currency: "USD"})
+		require.NoError(t, err)
+		require.Equal(t, user.Username, account.Owner)
+		ids = append(ids, account.ID)
+	}
+
+	account, err := c.GetAccount(ctx, ids[0])
+	require.NoError(t, err)
+	require.Equal(t, ids[0], account.ID)
+
+	accounts, err := c.ListAccounts(5).All(ctx)
+	require.NoError(t, err)
+	require.Len(t, accounts, 3)
+	for i, account := range accounts {
+		require.Equal(t, ids[i], account.ID)
+	}
+
+	_, err = c.GetAccount(ctx, ids[2]+1000000)
+	require.ErrorIs(t, err, ErrNotFound)
+
+	var apiErr *APIError
+	require.True(t, errors.As(err, &apiErr))
+	require.NotEmpty(t, apiErr.RequestID)
+}
+
+func TestTransfer(t *testing.T) {
+	ctx := context.Background()
+	c, _ := newTestUser(t)
+
+	from, err := c.CreateAccount(ctx, CreateAccountRequest{Currency: "USD"})
+	require.NoError(t, err)
+	to, err := c.CreateAccount(ctx, CreateAccountRequest{Currency: "USD"})
+	require.NoError(t, err)
+
+	// a new account is empty, the transfer needs funds to spend
+	_, err = testStore.AddAccountBalance(ctx, db.AddAccountBalanceParams{ID: from.ID, Amount: 100})
+	require.NoError(t, err)
+
+	arg := TransferRequest{
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        10,
+		Currency:      "USD",
+	}
+	key := util.RandomString(16)
+
+	result, err := c.Transfer(ctx, arg, WithIdempotencyKey(key))
+	require.NoError(t, err)
+	require.Equal(t, from.ID, result.FromAccount.ID)
+	require.Equal(t, int64(90), result.FromAccount.Balance)
+
+	// the same key replays the first transfer instead of moving the money twice
+	replayed, err := c.Transfer(ctx, arg, WithIdempotencyKey(key))
+	require.NoError(t, err)
+	require.Equal(t, result.Transfer.ID, replayed.Transfer.ID)
+
+	arg.Amount = 20
+	_, err = c.Transfer(ctx, arg, WithIdempotencyKey(key))
+	require.ErrorIs(t, err, ErrUnprocessable)
+
+	entries, err := c.ListEntries(from.ID, 1).All(ctx)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, int64(-10), entries[0].Amount)
+
+	_, err = c.Transfer(ctx, TransferRequest{FromAccountID: from.ID, ToAccountID: to.ID, Amount: 10, Currency: "EUR"})
+	require.ErrorIs(t, err, ErrBadRequest)
+
+	// the internal accounts of the bank cannot be a leg of a customer transfer
+	suspense, err := testStore.InternalAccount(ctx, db.GLSuspense, "USD")
+	require.NoError(t, err)
+
+	_, err = c.Transfer(ctx, TransferRequest{FromAccountID: from.ID, ToAccountID: suspense.ID, Amount: 10, Currency: "USD"})
+	require.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestAnonymousRequestIsUnauthorized(t *testing.T) {
+	_, err := New(testServer.URL).GetAccount(context.Background(), 1)
+	require.ErrorIs(t, err, ErrUnauthorized)
+}
@@ -0,0 +1,102 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// expiryLeeway renews access tokens a little before they expire to absorb clock skew and latency
+const expiryLeeway = 10 * time.Second
+
+// TokenSource supplies the access token sent with authenticated requests
+type TokenSource interface {
+	// Token returns the current access token
+	Token(ctx context.Context) (string, error)
+	// Refresh obtains a new access token after the API rejected the current one
+	Refresh(ctx context.Context) (string, error)
+}
+
+// StaticToken is a TokenSource that always returns the same access token
+type StaticToken string
+
+// Token returns the token
+func (t StaticToken) Token(ctx context.Context) (string, error) {
+	return string(t), nil
+}
+
+// Refresh returns the token, a static token cannot be renewed
+func (t StaticToken) Refresh(ctx context.Context) (string, error) {
+	return string(t), nil
+}
+
+// SessionTokenSource logs in with a username and password and keeps the access token fresh,
+// renewing it with the refresh token and logging in again once the session is over
+type SessionTokenSource struct {
+	client   *Client
+	username string
+	password string
+
+	mu                    sync.Mutex
+	accessToken           string
+	accessTokenExpiresAt  time.Time
+	refreshToken          string
+	refreshTokenExpiresAt time.Time
+}
+
+// LoginTokenSource creates a token source logging in as username through c
+func (c *Client) LoginTokenSource(username string, password string) *SessionTokenSource {
+	return &SessionTokenSource{
+		client:   c,
+		username: username,
+		password: password,
+	}
+}
+
+// Token returns the cached access token, renewing it when it is about to expire
+func (s *SessionTokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.accessToken != "" && time.Now().Add(expiryLeeway).Before(s.accessTokenExpiresAt) {
+		return s.accessToken, nil
+	}
+
+	return s.renew(ctx)
+}
+
+// Refresh drops the cached access token and obtains a new one
+func (s *SessionTokenSource) Refresh(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.accessToken = ""
+	return s.renew(ctx)
+}
+
+func (s *SessionTokenSource) renew(ctx context.Context) (string, error) {
+	if s.refreshToken != "" && time.Now().Add(expiryLeeway).Before(s.refreshTokenExpiresAt) {
+		rsp, err := s.client.RenewAccessToken(ctx, s.refreshToken)
+		if err == nil {
+			s.accessToken = rsp.AccessToken
+			s.accessTokenExpiresAt = rsp.AccessTokenExpiresAt
+			return s.accessToken, nil
+		}
+
+		if !isUnauthorized(err) {
+			return "", err
+		}
+	}
+
+	rsp, err := s.client.Login(ctx, s.username, s.password)
+	if err != nil {
+		return "", err
+	}
+
+	s.accessToken = rsp.AccessToken
+	s.accessTokenExpiresAt = rsp.AccessTokenExpiresAt
+	s.refreshToken = rsp.RefreshToken
+	s.refreshTokenExpiresAt = rsp.RefreshTokenExpiresAt
+
+	return s.accessToken, nil
+}
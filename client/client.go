@@ -0,0 +1,330 @@
+// Package client is the Go SDK of the simplebank HTTP API
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultMaxRetries = 3
+	defaultMinBackoff = 100 * time.Millisecond
+	defaultMaxBackoff = 5 * time.Second
+
+	idempotencyKeyHeader = "Idempotency-Key"
+	nextCursorHeader     = "X-Next-Cursor"
+	requestIDHeader      = "X-Request-ID"
+	apiKeyHeader         = "X-API-Key"
+)
+
+// Client calls the simplebank HTTP API
+type Client struct {
+	baseURL       string
+	httpClient    *http.Client
+	tokens        TokenSource
+	apiKey        string
+	signingSecret string
+	maxRetries    int
+	minBackoff    time.Duration
+	maxBackoff    time.Duration
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHTTPClient sends requests through httpClient instead of http.DefaultClient
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithTokenSource authenticates requests with the access tokens of source
+func WithTokenSource(source TokenSource) Option {
+	return func(c *Client) {
+		c.tokens = source
+	}
+}
+
+// WithAPIKey authenticates requests with an API key instead of access tokens
+func WithAPIKey(key string) Option {
+	return func(c *Client) {
+		c.apiKey = key
+	}
+}
+
+// WithSigningSecret signs every authenticated request with the signing secret of the API key,
+// the routes moving money require a signature from the callers sending an API key
+func WithSigningSecret(secret string) Option {
+	return func(c *Client) {
+		c.signingSecret = secret
+	}
+}
+
+// WithRetry sets how many times a request is retried after a 429, a 5xx or a network error
+// and the bounds of the exponential backoff between attempts
+func WithRetry(maxRetries int, minBackoff time.Duration, maxBackoff time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.minBackoff = minBackoff
+		c.maxBackoff = maxBackoff
+	}
+}
+
+// New creates a client for the API served at baseURL
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: http.DefaultClient,
+		maxRetries: defaultMaxRetries,
+		minBackoff: defaultMinBackoff,
+		maxBackoff: defaultMaxBackoff,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// RequestOption customizes a single call
+type RequestOption func(*request)
+
+// WithIdempotencyKey sets the Idempotency-Key of a POST
+// without it the client generates one per call, so retries of that call are still safe
+func WithIdempotencyKey(key string) RequestOption {
+	return func(req *request) {
+		req.idempotencyKey = key
+	}
+}
+
+type request struct {
+	method         string
+	path           string
+	query          url.Values
+	body           any
+	idempotencyKey string
+	auth           bool
+}
+
+// response is what the caller needs from a successful call besides the decoded body
+type response struct {
+	status int
+	header http.Header
+}
+
+// do sends req and decodes the JSON response into out
+// requests are retried with backoff on network errors, 429 and 5xx when it is safe to do so,
+// and retried once with a refreshed token after a 401
+func (c *Client) do(ctx context.Context, req request, out any) (*response, error) {
+	var body []byte
+	if req.body != nil {
+		var err error
+		body, err = json.Marshal(req.body)
+		if err != nil {
+			return nil, fmt.Errorf("cannot encode request: %w", err)
+		}
+	}
+
+	retryable := req.method == http.MethodGet || req.idempotencyKey != ""
+	refreshed := false
+
+	for attempt := 0; ; attempt++ {
+		httpReq, err := c.newHTTPRequest(ctx, req, body)
+		if err != nil {
+			return nil, err
+		}
+
+		httpResp, err := c.httpClient.Do(httpReq)
+		if err != nil {
+			if ctx.Err() != nil || !retryable || attempt >= c.maxRetries {
+				return nil, err
+			}
+
+			if err := c.wait(ctx, c.backoff(attempt)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if httpResp.StatusCode == http.StatusUnauthorized && req.auth && c.tokens != nil && !refreshed {
+			drain(httpResp)
+			refreshed = true
+
+			if _, err := c.tokens.Refresh(ctx); err != nil {
+				return nil, fmt.Errorf("cannot refresh access token: %w", err)
+			}
+			attempt--
+			continue
+		}
+
+		shouldRetry := httpResp.StatusCode == http.StatusTooManyRequests ||
+			(httpResp.StatusCode >= http.StatusInternalServerError && retryable)
+
+		if shouldRetry && attempt < c.maxRetries {
+			delay, ok := retryAfter(httpResp.Header)
+			if !ok {
+				delay = c.backoff(attempt)
+			}
+			drain(httpResp)
+
+			if err := c.wait(ctx, delay); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		return c.decode(httpResp, out)
+	}
+}
+
+func (c *Client) newHTTPRequest(ctx context.Context, req request, body []byte) (*http.Request, error) {
+	target := c.baseURL + req.path
+	if len(req.query) > 0 {
+		target += "?" + req.query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, target, reader)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq.Header.Set("Accept", "application/json")
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+
+	if req.idempotencyKey != "" {
+		httpReq.Header.Set(idempotencyKeyHeader, req.idempotencyKey)
+	}
+
+	if req.auth && c.apiKey != "" {
+		httpReq.Header.Set(apiKeyHeader, c.apiKey)
+	} else if req.auth && c.tokens != nil {
+		accessToken, err := c.tokens.Token(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("cannot get access token: %w", err)
+		}
+		httpReq.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+
+	// every attempt is signed again, a retry must not reuse the nonce of the attempt before
+	if req.auth && c.signingSecret != "" {
+		if err := SignRequest(httpReq, body, c.signingSecret); err != nil {
+			return nil, fmt.Errorf("cannot sign request: %w", err)
+		}
+	}
+
+	return httpReq, nil
+}
+
+func (c *Client) decode(httpResp *http.Response, out any) (*response, error) {
+	defer httpResp.Body.Close()
+
+	data, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if httpResp.StatusCode >= http.StatusBadRequest {
+		apiErr := &APIError{
+			StatusCode: httpResp.StatusCode,
+			RequestID:  httpResp.Header.Get(requestIDHeader),
+		}
+
+		var envelope struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(data, &envelope) == nil && envelope.Error != "" {
+			apiErr.Message = envelope.Error
+		} else {
+			apiErr.Message = strings.TrimSpace(string(data))
+		}
+
+		return nil, apiErr
+	}
+
+	if out != nil {
+		if err := json.Unmarshal(data, out); err != nil {
+			return nil, fmt.Errorf("cannot decode response: %w", err)
+		}
+	}
+
+	return &response{status: httpResp.StatusCode, header: httpResp.Header}, nil
+}
+
+// backoff returns the exponential delay before the next attempt, with full jitter
+func (c *Client) backoff(attempt int) time.Duration {
+	delay := c.maxBackoff
+	if attempt < 32 {
+		if d := c.minBackoff << attempt; d > 0 && d < c.maxBackoff {
+			delay = d
+		}
+	}
+
+	if delay <= 0 {
+		return 0
+	}
+
+	return time.Duration(rand.Int63n(int64(delay) + 1))
+}
+
+func (c *Client) wait(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// retryAfter reads the Retry-After header, in seconds or as an HTTP date
+func retryAfter(header http.Header) (time.Duration, bool) {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		delay := time.Until(date)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+
+	return 0, false
+}
+
+func drain(httpResp *http.Response) {
+	io.Copy(io.Discard, httpResp.Body)
+	httpResp.Body.Close()
+}
+
+// isUnauthorized reports whether err is a 401 from the API
+func isUnauthorized(err error) bool {
+	return errors.Is(err, ErrUnauthorized)
+}
@@ -0,0 +1,204 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"simplebank/signing"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newFakeClient(t *testing.T, handler http.HandlerFunc, opts ...Option) *Client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	opts = append([]Option{WithRetry(3, time.Millisecond, 5*time.Millisecond)}, opts...)
+	return New(server.URL, opts...)
+}
+
+func TestRetryKeepsIdempotencyKey(t *testing.T) {
+	var attempts int32
+	var keys []string
+
+	c := newFakeClient(t, func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get(idempotencyKeyHeader))
+
+		switch atomic.AddInt32(&attempts, 1) {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			json.NewEncoder(w).Encode(map[string]any{"id": 7, "currency": "USD"})
+		}
+	}, WithTokenSource(StaticToken("token")))
+
+	account, err := c.CreateAccount(context.Background(), CreateAccountRequest{Currency: "USD"})
+	require.NoError(t, err)
+	require.Equal(t, int64(7), account.ID)
+
+	require.Len(t, keys, 3)
+	require.NotEmpty(t, keys[0])
+	require.Equal(t, keys[0], keys[1])
+	require.Equal(t, keys[0], keys[2])
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	var attempts int32
+
+	c := newFakeClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.Header().Set(requestIDHeader, "req-1")
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte(`{"error":"upstream down"}`))
+	}, WithTokenSource(StaticToken("token")))
+
+	_, err := c.GetAccount(context.Background(), 1)
+	require.ErrorIs(t, err, ErrServer)
+
+	var apiErr *APIError
+	require.True(t, errors.As(err, &apiErr))
+	require.Equal(t, http.StatusBadGateway, apiErr.StatusCode)
+	require.Equal(t, "upstream down", apiErr.Message)
+	require.Equal(t, "req-1", apiErr.RequestID)
+	require.Equal(t, int32(4), atomic.LoadInt32(&attempts))
+}
+
+func TestNoRetryWithoutIdempotencyKey(t *testing.T) {
+	var attempts int32
+
+	c := newFakeClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	_, err := c.Login(context.Background(), "alice", "secret")
+	require.ErrorIs(t, err, ErrServer)
+	require.Equal(t, int32(1), atomic.LoadInt32(&attempts))
+}
+
+func TestRefreshOnUnauthorized(t *testing.T) {
+	var refreshed int32
+
+	c := newFakeClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer fresh" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"token has expired"}`))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"id": 1})
+	}, WithTokenSource(&fakeTokenSource{token: "stale", refreshed: &refreshed}))
+
+	account, err := c.GetAccount(context.Background(), 1)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), account.ID)
+	require.Equal(t, int32(1), atomic.LoadInt32(&refreshed))
+}
+
+func TestTransferHeldForReview(t *testing.T) {
+	c := newFakeClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(map[string]any{"id": 42, "score": 60, "status": "pending"})
+	}, WithTokenSource(StaticToken("token")))
+
+	_, err := c.Transfer(context.Background(), TransferRequest{FromAccountID: 1, ToAccountID: 2, Amount: 500000, Currency: "USD"})
+	require.ErrorIs(t, err, ErrTransferHeld)
+
+	var held *HeldTransferError
+	require.True(t, errors.As(err, &held))
+	require.Equal(t, int64(42), held.AssessmentID)
+	require.Equal(t, "pending", held.Status)
+}
+
+func TestIteratorFollowsCursor(t *testing.T) {
+	c := newFakeClient(t, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "2", r.URL.Query().Get("page_size"))
+
+		switch r.URL.Query().Get("cursor") {
+		case "":
+			w.Header().Set(nextCursorHeader, "next")
+			w.Write([]byte(`[{"id":1},{"id":2}]`))
+		case "next":
+			w.Write([]byte(`[{"id":3}]`))
+		default:
+			t.Errorf("unexpected cursor %q", r.URL.Query().Get("cursor"))
+		}
+	}, WithTokenSource(StaticToken("token")))
+
+	accounts, err := c.ListAccounts(2).All(context.Background())
+	require.NoError(t, err)
+	require.Len(t, accounts, 3)
+	require.Equal(t, int64(3), accounts[2].ID)
+}
+
+func TestRetryAfter(t *testing.T) {
+	header := http.Header{}
+
+	_, ok := retryAfter(header)
+	require.False(t, ok)
+
+	header.Set("Retry-After", "3")
+	delay, ok := retryAfter(header)
+	require.True(t, ok)
+	require.Equal(t, 3*time.Second, delay)
+
+	header.Set("Retry-After", time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat))
+	delay, ok = retryAfter(header)
+	require.True(t, ok)
+	require.Zero(t, delay)
+}
+
+type fakeTokenSource struct {
+	token     string
+	refreshed *int32
+}
+
+func (s *fakeTokenSource) Token(ctx context.Context) (string, error) {
+	return s.token, nil
+}
+
+func (s *fakeTokenSource) Refresh(ctx context.Context) (string, error) {
+	atomic.AddInt32(s.refreshed, 1)
+	s.token = "fresh"
+	return s.token, nil
+}
+
+func TestSignedRequestsWithAPIKey(t *testing.T) {
+	const secret = "sbsig_test"
+	var attempts int32
+	var nonces []string
+
+	c := newFakeClient(t, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "sbk_key", r.Header.Get(apiKeyHeader))
+		require.Empty(t, r.Header.Get("Authorization"))
+
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		nonce := r.Header.Get(signing.NonceHeader)
+		nonces = append(nonces, nonce)
+		err = signing.Verify(secret, r.Method, r.URL.RequestURI(), r.Header.Get(signing.TimestampHeader),
+			nonce, r.Header.Get(signing.SignatureHeader), body, time.Now(), time.Minute)
+		require.NoError(t, err)
+
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"id": 7, "currency": "USD"})
+	}, WithAPIKey("sbk_key"), WithSigningSecret(secret))
+
+	_, err := c.CreateAccount(context.Background(), CreateAccountRequest{Currency: "USD"})
+	require.NoError(t, err)
+
+	require.Len(t, nonces, 2)
+	require.NotEqual(t, nonces[0], nonces[1])
+}
@@ -0,0 +1,72 @@
+package client
+
+import "context"
+
+// pageFetcher loads the page starting at cursor and returns the cursor of the next one
+type pageFetcher[T any] func(ctx context.Context, cursor string) ([]T, string, error)
+
+// Iterator walks a cursor paginated list one item at a time, fetching pages lazily
+//
+//	it := c.ListAccounts(10)
+//	for it.Next(ctx) {
+//		account := it.Value()
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type Iterator[T any] struct {
+	fetch   pageFetcher[T]
+	page    []T
+	cursor  string
+	started bool
+	current T
+	err     error
+}
+
+func newIterator[T any](fetch pageFetcher[T]) *Iterator[T] {
+	return &Iterator[T]{fetch: fetch}
+}
+
+// Next advances to the next item and reports whether there is one
+func (it *Iterator[T]) Next(ctx context.Context) bool {
+	for len(it.page) == 0 {
+		if it.err != nil || (it.started && it.cursor == "") {
+			return false
+		}
+
+		page, next, err := it.fetch(ctx, it.cursor)
+		it.started = true
+		if err != nil {
+			it.err = err
+			return false
+		}
+
+		it.page = page
+		it.cursor = next
+	}
+
+	it.current = it.page[0]
+	it.page = it.page[1:]
+	return true
+}
+
+// Value returns the current item
+func (it *Iterator[T]) Value() T {
+	return it.current
+}
+
+// Err returns the error that stopped the iteration, if any
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
+
+// All drains the iterator into a slice
+func (it *Iterator[T]) All(ctx context.Context) ([]T, error) {
+	var items []T
+
+	for it.Next(ctx) {
+		items = append(items, it.Value())
+	}
+
+	return items, it.Err()
+}
@@ -0,0 +1,24 @@
+package client
+
+import (
+	"net/http"
+	"simplebank/signing"
+	"strconv"
+	"time"
+)
+
+// SignRequest signs httpReq, whose body is body, with secret: it sets the timestamp, a new nonce and the HMAC-SHA256
+// of the method, the path, the timestamp, the nonce and the SHA-256 of the body in the headers the API checks
+func SignRequest(httpReq *http.Request, body []byte, secret string) error {
+	nonce, err := signing.NewNonce()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	httpReq.Header.Set(signing.TimestampHeader, strconv.FormatInt(now.Unix(), 10))
+	httpReq.Header.Set(signing.NonceHeader, nonce)
+	httpReq.Header.Set(signing.SignatureHeader, signing.Sign(secret, httpReq.Method, httpReq.URL.RequestURI(), now, nonce, body))
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	db "simplebank/db/sqlc"
+
+	"github.com/google/uuid"
+)
+
+// TransferRequest moves Amount from one account of the authenticated user to another account
+type TransferRequest struct {
+	FromAccountID int64  `json:"from_account_id"`
+	ToAccountID   int64  `json:"to_account_id"`
+	Amount        int64  `json:"amount"`
+	Currency      string `json:"currency"`
+}
+
+// ErrTransferHeld is matched by a *HeldTransferError
+var ErrTransferHeld = errors.New("transfer held for review")
+
+// HeldTransferError reports a transfer that risk screening held for review, it is made only once an admin approves it
+type HeldTransferError struct {
+	// AssessmentID identifies the review
+	AssessmentID int64  `json:"id"`
+	Score        int32  `json:"score"`
+	Status       string `json:"status"`
+}
+
+func (e *HeldTransferError) Error() string {
+	return fmt.Sprintf("simplebank: %s as risk assessment %d", ErrTransferHeld, e.AssessmentID)
+}
+
+func (e *HeldTransferError) Unwrap() error {
+	return ErrTransferHeld
+}
+
+// Transfer moves money between two accounts, it fails with a *HeldTransferError when the transfer is held for review
+// the request carries an Idempotency-Key so it is retried without risking a double transfer
+func (c *Client) Transfer(ctx context.Context, arg TransferRequest, opts ...RequestOption) (db.TransferTxResult, error) {
+	var result db.TransferTxResult
+	var body json.RawMessage
+
+	rsp, err := c.do(ctx, newPost("/transfers", arg, opts), &body)
+	if err != nil {
+		return result, err
+	}
+
+	if rsp.status == http.StatusAccepted {
+		held := &HeldTransferError{}
+		if err := json.Unmarshal(body, held); err != nil {
+			return result, fmt.Errorf("cannot decode response: %w", err)
+		}
+		return result, held
+	}
+
+	if err := json.Unmarshal(body, &result); err != nil {
+		return result, fmt.Errorf("cannot decode response: %w", err)
+	}
+
+	return result, nil
+}
+
+// newPost builds an authenticated POST with an idempotency key, generated unless one is given
+func newPost(path string, body any, opts []RequestOption) request {
+	req := request{
+		method: http.MethodPost,
+		path:   path,
+		body:   body,
+		auth:   true,
+	}
+
+	for _, opt := range opts {
+		opt(&req)
+	}
+
+	if req.idempotencyKey == "" {
+		req.idempotencyKey = uuid.NewString()
+	}
+
+	return req
+}
@@ -0,0 +1,84 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CreateUserRequest registers a new user
+type CreateUserRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	FullName string `json:"full_name"`
+	Email    string `json:"email"`
+}
+
+// User is the public profile of a user
+type User struct {
+	Username          string    `json:"username"`
+	FullName          string    `json:"full_name"`
+	Email             string    `json:"email"`
+	PasswordChangedAt time.Time `json:"password_changed_at"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// LoginResponse holds the tokens of a new session
+type LoginResponse struct {
+	SessionID             uuid.UUID `json:"session_id"`
+	AccessToken           string    `json:"access_token"`
+	AccessTokenExpiresAt  time.Time `json:"access_token_expires_at"`
+	RefreshToken          string    `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
+	User                  User      `json:"user"`
+}
+
+// RenewAccessTokenResponse holds an access token renewed from a refresh token
+type RenewAccessTokenResponse struct {
+	AccessToken          string    `json:"access_token"`
+	AccessTokenExpiresAt time.Time `json:"access_token_expires_at"`
+}
+
+// CreateUser registers a new user
+func (c *Client) CreateUser(ctx context.Context, arg CreateUserRequest) (User, error) {
+	var user User
+
+	_, err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/users",
+		body:   arg,
+	}, &user)
+
+	return user, err
+}
+
+// Login opens a session for username
+func (c *Client) Login(ctx context.Context, username string, password string) (LoginResponse, error) {
+	var rsp LoginResponse
+
+	_, err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/users/login",
+		body: map[string]string{
+			"username": username,
+			"password": password,
+		},
+	}, &rsp)
+
+	return rsp, err
+}
+
+// RenewAccessToken exchanges a refresh token for a new access token
+func (c *Client) RenewAccessToken(ctx context.Context, refreshToken string) (RenewAccessTokenResponse, error) {
+	var rsp RenewAccessTokenResponse
+
+	_, err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/tokens/renew_access",
+		body:   map[string]string{"refresh_token": refreshToken},
+	}, &rsp)
+
+	return rsp, err
+}
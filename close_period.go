@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	db "simplebank/db/sqlc"
+	"time"
+)
+
+// closePeriodCommand names the command closing an accounting period: simplebank close-period
+const closePeriodCommand = "close-period"
+
+// closePeriod closes the period named by args and writes the closed period with its trial balance to out as JSON,
+// it closes yesterday when args name no period
+func closePeriod(ctx context.Context, store *db.Store, args []string, out io.Writer) error {
+	flags := flag.NewFlagSet(closePeriodCommand, flag.ContinueOnError)
+	kind := flags.String("kind", db.PeriodDay, "period to close, day or month")
+	start := flags.String("start", "", "first UTC day of the period as 2006-01-02, yesterday or last month when left out")
+	closedBy := flags.String("by", closePeriodCommand, "who closes the period")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	today := now.UTC().Truncate(24 * time.Hour)
+
+	first := today.AddDate(0, 0, -1)
+	if *kind == db.PeriodMonth {
+		first = time.Date(today.Year(), today.Month()-1, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	if *start != "" {
+		var err error
+		first, err = time.Parse(time.DateOnly, *start)
+		if err != nil {
+			return fmt.Errorf("invalid -start: %w", err)
+		}
+	}
+
+	result, err := store.ClosePeriodTx(ctx, db.ClosePeriodTxParams{
+		Kind:     *kind,
+		Start:    first,
+		ClosedBy: *closedBy,
+		Now:      now,
+	})
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(result)
+}
@@ -0,0 +1,19 @@
+// Command openapi prints the OpenAPI document of the HTTP API
+// it feeds docs/openapi.json, which client generators consume
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"simplebank/api"
+)
+
+func main() {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(api.OpenAPIDocument()); err != nil {
+		log.Fatal("cannot encode openapi document:", err)
+	}
+}
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS outbox_events;
+
+ALTER TABLE accounts DROP CONSTRAINT IF EXISTS accounts_status_check;
+
+ALTER TABLE accounts DROP COLUMN IF EXISTS status;
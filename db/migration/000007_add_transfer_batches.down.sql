@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS transfer_batch_items;
+
+DROP TABLE IF EXISTS transfer_batches;
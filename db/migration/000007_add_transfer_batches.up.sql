@@ -0,0 +1,59 @@
+CREATE TABLE "transfer_batches" (
+  "id" bigserial PRIMARY KEY,
+  "owner" varchar NOT NULL,
+  "mode" varchar NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "total_rows" int NOT NULL,
+  "succeeded_rows" int NOT NULL DEFAULT 0,
+  "failed_rows" int NOT NULL DEFAULT 0,
+  "lease_until" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now()),
+  "completed_at" timestamptz
+);
+
+CREATE TABLE "transfer_batch_items" (
+  "id" bigserial PRIMARY KEY,
+  "batch_id" bigint NOT NULL,
+  "row_number" int NOT NULL,
+  "from_account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "currency" varchar NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "transfer_id" bigint,
+  "error" varchar,
+  UNIQUE ("batch_id", "row_number")
+);
+
+ALTER TABLE "transfer_batches" ADD CONSTRAINT "transfer_batches_mode_check" CHECK ("mode" IN ('atomic', 'best_effort'));
+
+ALTER TABLE "transfer_batches" ADD CONSTRAINT "transfer_batches_status_check" CHECK ("status" IN ('pending', 'processing', 'completed', 'failed'));
+
+ALTER TABLE "transfer_batch_items" ADD CONSTRAINT "transfer_batch_items_amount_check" CHECK ("amount" > 0);
+
+ALTER TABLE "transfer_batch_items" ADD CONSTRAINT "transfer_batch_items_status_check" CHECK ("status" IN ('pending', 'succeeded', 'failed', 'skipped'));
+
+CREATE INDEX ON "transfer_batches" ("owner", "id");
+
+CREATE INDEX ON "transfer_batches" ("id") WHERE "status" IN ('pending', 'processing');
+
+COMMENT ON COLUMN "transfer_batches"."mode" IS 'atomic runs every row in one tx or none, best_effort runs the rows it can';
+
+COMMENT ON COLUMN "transfer_batches"."failed_rows" IS 'rows not run, including the rows skipped by a failed atomic batch';
+
+COMMENT ON COLUMN "transfer_batches"."lease_until" IS 'a processing batch whose lease ran out is picked up by another worker';
+
+COMMENT ON COLUMN "transfer_batch_items"."row_number" IS 'position of the row in the upload, starting at 1';
+
+COMMENT ON COLUMN "transfer_batch_items"."status" IS 'skipped when an atomic batch is rolled back because of another row';
+
+ALTER TABLE "transfer_batches" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+
+ALTER TABLE "transfer_batch_items" ADD FOREIGN KEY ("batch_id") REFERENCES "transfer_batches" ("id");
+
+ALTER TABLE "transfer_batch_items" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "transfer_batch_items" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "transfer_batch_items" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
@@ -0,0 +1,5 @@
+ALTER TABLE entries DROP COLUMN IF EXISTS journal_id;
+
+ALTER TABLE transfers DROP COLUMN IF EXISTS journal_id;
+
+DROP TABLE IF EXISTS journals;
@@ -0,0 +1,22 @@
+CREATE TABLE "journals" (
+  "id" bigserial PRIMARY KEY,
+  "kind" varchar NOT NULL,
+  "description" varchar NOT NULL DEFAULT '',
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "entries" ADD COLUMN "journal_id" bigint;
+
+ALTER TABLE "transfers" ADD COLUMN "journal_id" bigint;
+
+CREATE INDEX ON "entries" ("journal_id");
+
+COMMENT ON COLUMN "journals"."kind" IS 'what posted the journal, such as transfer';
+
+COMMENT ON COLUMN "entries"."journal_id" IS 'the entries of a journal sum to zero per currency, null for entries posted before journals';
+
+COMMENT ON COLUMN "transfers"."journal_id" IS 'null for transfers made before journals';
+
+ALTER TABLE "entries" ADD FOREIGN KEY ("journal_id") REFERENCES "journals" ("id");
+
+ALTER TABLE "transfers" ADD FOREIGN KEY ("journal_id") REFERENCES "journals" ("id");
@@ -0,0 +1,41 @@
+ALTER TABLE "accounts" ADD COLUMN "tier" varchar NOT NULL DEFAULT 'standard';
+
+CREATE TABLE "pricing_rules" (
+  "id" bigserial PRIMARY KEY,
+  "tier" varchar,
+  "currency" varchar NOT NULL,
+  "min_amount" bigint NOT NULL DEFAULT 0,
+  "max_amount" bigint,
+  "flat_fee" bigint NOT NULL DEFAULT 0,
+  "percent_bps" int NOT NULL DEFAULT 0,
+  "min_fee" bigint NOT NULL DEFAULT 0,
+  "max_fee" bigint,
+  "fee_account_id" bigint NOT NULL,
+  "priority" int NOT NULL DEFAULT 0,
+  "active" boolean NOT NULL DEFAULT true,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "pricing_rules" ADD CONSTRAINT "pricing_rules_amounts_check" CHECK ("min_amount" >= 0 AND ("max_amount" IS NULL OR "max_amount" > "min_amount"));
+
+ALTER TABLE "pricing_rules" ADD CONSTRAINT "pricing_rules_fees_check" CHECK ("flat_fee" >= 0 AND "min_fee" >= 0 AND ("max_fee" IS NULL OR "max_fee" >= "min_fee"));
+
+ALTER TABLE "pricing_rules" ADD CONSTRAINT "pricing_rules_percent_bps_check" CHECK ("percent_bps" BETWEEN 0 AND 10000);
+
+CREATE INDEX ON "pricing_rules" ("currency") WHERE "active";
+
+COMMENT ON COLUMN "accounts"."tier" IS 'pricing tier, such as standard or premium';
+
+COMMENT ON COLUMN "pricing_rules"."tier" IS 'null applies to every tier';
+
+COMMENT ON COLUMN "pricing_rules"."max_amount" IS 'exclusive, null for no upper bound';
+
+COMMENT ON COLUMN "pricing_rules"."percent_bps" IS 'percentage of the amount in basis points, 100 is 1%';
+
+COMMENT ON COLUMN "pricing_rules"."max_fee" IS 'null for no cap';
+
+COMMENT ON COLUMN "pricing_rules"."fee_account_id" IS 'revenue account credited with the fee, it holds the currency of the rule';
+
+COMMENT ON COLUMN "pricing_rules"."priority" IS 'the matching rule with the highest priority prices the transfer';
+
+ALTER TABLE "pricing_rules" ADD FOREIGN KEY ("fee_account_id") REFERENCES "accounts" ("id");
@@ -0,0 +1,47 @@
+CREATE TABLE "transfer_limits" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint,
+  "owner" varchar,
+  "max_amount" bigint,
+  "daily_amount" bigint,
+  "monthly_amount" bigint,
+  "hourly_count" int,
+  "expires_at" timestamptz,
+  "reason" varchar NOT NULL,
+  "created_by" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "transfer_limits" ADD CONSTRAINT "transfer_limits_scope_check" CHECK ("account_id" IS NULL OR "owner" IS NULL);
+
+ALTER TABLE "transfer_limits" ADD CONSTRAINT "transfer_limits_values_check" CHECK ("max_amount" > 0 AND "daily_amount" > 0 AND "monthly_amount" > 0 AND "hourly_count" > 0);
+
+CREATE INDEX ON "transfer_limits" ("account_id", "id");
+
+CREATE INDEX ON "transfer_limits" ("owner", "id");
+
+CREATE INDEX ON "transfers" ("from_account_id", "created_at");
+
+COMMENT ON TABLE "transfer_limits" IS 'append only, every change of a limit is a new row';
+
+COMMENT ON COLUMN "transfer_limits"."account_id" IS 'limits the account, a row without account and owner is the default of every account';
+
+COMMENT ON COLUMN "transfer_limits"."owner" IS 'limits every account of the user';
+
+COMMENT ON COLUMN "transfer_limits"."max_amount" IS 'null for no limit, like every limit';
+
+COMMENT ON COLUMN "transfer_limits"."daily_amount" IS 'outgoing volume since the start of the UTC day';
+
+COMMENT ON COLUMN "transfer_limits"."monthly_amount" IS 'outgoing volume since the start of the UTC month';
+
+COMMENT ON COLUMN "transfer_limits"."hourly_count" IS 'outgoing transfers in the last hour';
+
+COMMENT ON COLUMN "transfer_limits"."expires_at" IS 'a temporary change, the previous limits apply again after it';
+
+COMMENT ON COLUMN "transfer_limits"."created_by" IS 'admin who changed the limits';
+
+ALTER TABLE "transfer_limits" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "transfer_limits" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+
+ALTER TABLE "transfer_limits" ADD FOREIGN KEY ("created_by") REFERENCES "users" ("username");
@@ -0,0 +1,40 @@
+CREATE TABLE "risk_assessments" (
+  "id" bigserial PRIMARY KEY,
+  "owner" varchar NOT NULL,
+  "from_account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "currency" varchar NOT NULL,
+  "decision" varchar NOT NULL,
+  "score" int NOT NULL,
+  "hits" jsonb NOT NULL,
+  "status" varchar NOT NULL,
+  "transfer_id" bigint,
+  "reviewed_by" varchar,
+  "reviewed_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "risk_assessments" ADD CONSTRAINT "risk_assessments_decision_check" CHECK ("decision" IN ('allow', 'review', 'block'));
+
+ALTER TABLE "risk_assessments" ADD CONSTRAINT "risk_assessments_status_check" CHECK ("status" IN ('allowed', 'blocked', 'pending', 'approved', 'rejected'));
+
+CREATE INDEX ON "risk_assessments" ("status", "id");
+
+CREATE INDEX ON "risk_assessments" ("owner");
+
+COMMENT ON COLUMN "risk_assessments"."hits" IS 'the rules that fired, with their score, decision and reason';
+
+COMMENT ON COLUMN "risk_assessments"."status" IS 'allowed, blocked, or pending review until approved or rejected';
+
+COMMENT ON COLUMN "risk_assessments"."transfer_id" IS 'the transfer made once allowed or approved';
+
+ALTER TABLE "risk_assessments" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+
+ALTER TABLE "risk_assessments" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "risk_assessments" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "risk_assessments" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "risk_assessments" ADD FOREIGN KEY ("reviewed_by") REFERENCES "users" ("username");
@@ -0,0 +1,43 @@
+ALTER TABLE "accounts" ADD COLUMN "held_balance" bigint NOT NULL DEFAULT 0;
+
+ALTER TABLE "accounts" ADD COLUMN "available_balance" bigint NOT NULL GENERATED ALWAYS AS ("balance" - "held_balance") STORED;
+
+ALTER TABLE "accounts" ADD CONSTRAINT "accounts_held_balance_check" CHECK ("held_balance" >= 0);
+
+CREATE TABLE "holds" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "captured_amount" bigint NOT NULL DEFAULT 0,
+  "currency" varchar NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'authorized',
+  "description" varchar NOT NULL DEFAULT '',
+  "expires_at" timestamptz NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "holds" ADD CONSTRAINT "holds_amount_check" CHECK ("amount" > 0 AND "captured_amount" BETWEEN 0 AND "amount");
+
+ALTER TABLE "holds" ADD CONSTRAINT "holds_status_check" CHECK ("status" IN ('authorized', 'captured', 'voided', 'expired'));
+
+CREATE INDEX ON "holds" ("account_id", "id");
+
+CREATE INDEX ON "holds" ("expires_at") WHERE "status" = 'authorized';
+
+COMMENT ON COLUMN "accounts"."balance" IS 'ledger balance, the sum of the posted entries';
+
+COMMENT ON COLUMN "accounts"."held_balance" IS 'reserved by authorized holds';
+
+COMMENT ON COLUMN "accounts"."available_balance" IS 'what can be spent, the ledger balance less the held balance';
+
+COMMENT ON COLUMN "holds"."to_account_id" IS 'credited when the hold is captured';
+
+COMMENT ON COLUMN "holds"."captured_amount" IS 'posted so far, the rest of amount stays held until captured, voided or expired';
+
+COMMENT ON COLUMN "holds"."status" IS 'authorized until fully captured, voided or expired';
+
+ALTER TABLE "holds" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "holds" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS interest_accruals;
+
+DROP TABLE IF EXISTS interest_postings;
+
+DROP TABLE IF EXISTS interest_rates;
+
+ALTER TABLE accounts DROP COLUMN IF EXISTS product;
+
+DROP TABLE IF EXISTS products;
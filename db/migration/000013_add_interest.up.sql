@@ -0,0 +1,98 @@
+CREATE TABLE "products" (
+  "code" varchar PRIMARY KEY,
+  "name" varchar NOT NULL,
+  "day_count" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "products" ADD CONSTRAINT "products_day_count_check" CHECK ("day_count" IN ('ACT/365', '30/360'));
+
+INSERT INTO "products" ("code", "name", "day_count") VALUES
+  ('checking', 'Checking', 'ACT/365'),
+  ('savings', 'Savings', 'ACT/365');
+
+ALTER TABLE "accounts" ADD COLUMN "product" varchar NOT NULL DEFAULT 'checking';
+
+CREATE TABLE "interest_rates" (
+  "id" bigserial PRIMARY KEY,
+  "product" varchar NOT NULL,
+  "currency" varchar NOT NULL,
+  "rate_bps" int NOT NULL,
+  "effective_from" date NOT NULL,
+  "expense_account_id" bigint NOT NULL,
+  "created_by" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "interest_rates" ADD CONSTRAINT "interest_rates_rate_bps_check" CHECK ("rate_bps" BETWEEN 0 AND 10000);
+
+CREATE INDEX ON "interest_rates" ("product", "currency", "effective_from");
+
+CREATE TABLE "interest_postings" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint NOT NULL,
+  "month" date NOT NULL,
+  "accrued_micros" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "carry_micros" bigint NOT NULL,
+  "journal_id" bigint,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE UNIQUE INDEX ON "interest_postings" ("account_id", "month");
+
+CREATE TABLE "interest_accruals" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint NOT NULL,
+  "day" date NOT NULL,
+  "balance" bigint NOT NULL,
+  "rate_bps" int NOT NULL,
+  "day_count" varchar NOT NULL,
+  "amount_micros" bigint NOT NULL,
+  "posting_id" bigint,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE UNIQUE INDEX ON "interest_accruals" ("account_id", "day");
+
+CREATE INDEX ON "interest_accruals" ("day") WHERE "posting_id" IS NULL;
+
+COMMENT ON COLUMN "products"."day_count" IS 'day-count convention of the interest, ACT/365 or 30/360';
+
+COMMENT ON COLUMN "accounts"."product" IS 'account product, such as checking or savings';
+
+COMMENT ON TABLE "interest_rates" IS 'rate schedule of a product and currency, a rate applies from its effective day until the next one';
+
+COMMENT ON COLUMN "interest_rates"."rate_bps" IS 'annual rate in basis points, 100 is 1%';
+
+COMMENT ON COLUMN "interest_rates"."expense_account_id" IS 'interest-expense account paying the interest, it holds the currency of the rate';
+
+COMMENT ON COLUMN "interest_postings"."month" IS 'first day of the month whose accruals are posted';
+
+COMMENT ON COLUMN "interest_postings"."accrued_micros" IS 'accruals of the month plus the carry of the previous posting, in millionths of the minor unit';
+
+COMMENT ON COLUMN "interest_postings"."amount" IS 'whole minor units posted to the account';
+
+COMMENT ON COLUMN "interest_postings"."carry_micros" IS 'fraction left over, carried into the next posting';
+
+COMMENT ON COLUMN "interest_postings"."journal_id" IS 'null when the month earned less than one minor unit';
+
+COMMENT ON COLUMN "interest_accruals"."balance" IS 'ledger balance at the end of the day';
+
+COMMENT ON COLUMN "interest_accruals"."amount_micros" IS 'interest earned on the day, in millionths of the minor unit';
+
+COMMENT ON COLUMN "interest_accruals"."posting_id" IS 'null until the month is posted';
+
+ALTER TABLE "accounts" ADD FOREIGN KEY ("product") REFERENCES "products" ("code");
+
+ALTER TABLE "interest_rates" ADD FOREIGN KEY ("product") REFERENCES "products" ("code");
+
+ALTER TABLE "interest_rates" ADD FOREIGN KEY ("expense_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "interest_postings" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "interest_postings" ADD FOREIGN KEY ("journal_id") REFERENCES "journals" ("id");
+
+ALTER TABLE "interest_accruals" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "interest_accruals" ADD FOREIGN KEY ("posting_id") REFERENCES "interest_postings" ("id");
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS loan_repayments;
+
+DROP TABLE IF EXISTS loan_installments;
+
+DROP TABLE IF EXISTS loans;
+
+UPDATE accounts SET product = 'checking' WHERE product = 'loan';
+
+DELETE FROM products WHERE code = 'loan';
@@ -0,0 +1,99 @@
+INSERT INTO "products" ("code", "name", "day_count") VALUES ('loan', 'Loan', '30/360');
+
+CREATE TABLE "loans" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint NOT NULL,
+  "borrower_account_id" bigint NOT NULL,
+  "income_account_id" bigint NOT NULL,
+  "principal" bigint NOT NULL,
+  "rate_bps" int NOT NULL,
+  "term_months" int NOT NULL,
+  "method" varchar NOT NULL,
+  "currency" varchar NOT NULL,
+  "late_fee" bigint NOT NULL DEFAULT 0,
+  "grace_days" int NOT NULL DEFAULT 0,
+  "status" varchar NOT NULL DEFAULT 'active',
+  "created_by" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "loans" ADD CONSTRAINT "loans_terms_check" CHECK ("principal" > 0 AND "rate_bps" BETWEEN 0 AND 10000 AND "term_months" > 0 AND "late_fee" >= 0 AND "grace_days" >= 0);
+
+ALTER TABLE "loans" ADD CONSTRAINT "loans_method_check" CHECK ("method" IN ('annuity', 'straight_line'));
+
+ALTER TABLE "loans" ADD CONSTRAINT "loans_status_check" CHECK ("status" IN ('active', 'paid_off'));
+
+CREATE UNIQUE INDEX ON "loans" ("account_id");
+
+CREATE INDEX ON "loans" ("borrower_account_id");
+
+CREATE TABLE "loan_installments" (
+  "id" bigserial PRIMARY KEY,
+  "loan_id" bigint NOT NULL,
+  "number" int NOT NULL,
+  "due_date" date NOT NULL,
+  "principal" bigint NOT NULL,
+  "interest" bigint NOT NULL,
+  "penalty" bigint NOT NULL DEFAULT 0,
+  "paid_principal" bigint NOT NULL DEFAULT 0,
+  "paid_interest" bigint NOT NULL DEFAULT 0,
+  "paid_penalty" bigint NOT NULL DEFAULT 0,
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "loan_installments" ADD CONSTRAINT "loan_installments_paid_check" CHECK ("paid_principal" BETWEEN 0 AND "principal" AND "paid_interest" BETWEEN 0 AND "interest" AND "paid_penalty" BETWEEN 0 AND "penalty");
+
+ALTER TABLE "loan_installments" ADD CONSTRAINT "loan_installments_status_check" CHECK ("status" IN ('pending', 'overdue', 'paid'));
+
+CREATE UNIQUE INDEX ON "loan_installments" ("loan_id", "number");
+
+CREATE INDEX ON "loan_installments" ("due_date") WHERE "status" = 'pending';
+
+CREATE TABLE "loan_repayments" (
+  "id" bigserial PRIMARY KEY,
+  "loan_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "principal" bigint NOT NULL,
+  "interest" bigint NOT NULL,
+  "penalty" bigint NOT NULL,
+  "journal_id" bigint NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "loan_repayments" ADD CONSTRAINT "loan_repayments_split_check" CHECK ("amount" = "principal" + "interest" + "penalty");
+
+CREATE INDEX ON "loan_repayments" ("loan_id", "id");
+
+COMMENT ON COLUMN "loans"."account_id" IS 'loan account, its balance is minus the principal still owed';
+
+COMMENT ON COLUMN "loans"."borrower_account_id" IS 'receives the principal and pays the repayments';
+
+COMMENT ON COLUMN "loans"."income_account_id" IS 'credited with the interest and the late fees';
+
+COMMENT ON COLUMN "loans"."rate_bps" IS 'annual rate in basis points, 100 is 1%';
+
+COMMENT ON COLUMN "loans"."method" IS 'annuity or straight_line';
+
+COMMENT ON COLUMN "loans"."late_fee" IS 'added to the penalty of an installment once it is overdue';
+
+COMMENT ON COLUMN "loans"."grace_days" IS 'days after its due date before an unpaid installment is overdue';
+
+COMMENT ON COLUMN "loan_installments"."penalty" IS 'late fees charged on the installment';
+
+COMMENT ON COLUMN "loan_installments"."status" IS 'pending until paid, overdue once the grace days passed unpaid';
+
+COMMENT ON COLUMN "loan_repayments"."journal_id" IS 'the journal splitting the repayment into principal, interest and penalty entries';
+
+ALTER TABLE "loans" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "loans" ADD FOREIGN KEY ("borrower_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "loans" ADD FOREIGN KEY ("income_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "loan_installments" ADD FOREIGN KEY ("loan_id") REFERENCES "loans" ("id");
+
+ALTER TABLE "loan_repayments" ADD FOREIGN KEY ("loan_id") REFERENCES "loans" ("id");
+
+ALTER TABLE "loan_repayments" ADD FOREIGN KEY ("journal_id") REFERENCES "journals" ("id");
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS account_balance_snapshots;
+
+DROP INDEX IF EXISTS entries_account_id_created_at_idx;
+
+ALTER TABLE transfers ALTER COLUMN created_at DROP NOT NULL;
+
+ALTER TABLE entries ALTER COLUMN created_at DROP NOT NULL;
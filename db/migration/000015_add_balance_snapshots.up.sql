@@ -0,0 +1,34 @@
+UPDATE "entries" e
+SET "created_at" = COALESCE(
+  (SELECT j."created_at" FROM "journals" j WHERE j."id" = e."journal_id"),
+  (SELECT a."created_at" FROM "accounts" a WHERE a."id" = e."account_id")
+)
+WHERE e."created_at" IS NULL;
+
+UPDATE "transfers" t
+SET "created_at" = COALESCE(
+  (SELECT j."created_at" FROM "journals" j WHERE j."id" = t."journal_id"),
+  (SELECT GREATEST(f."created_at", o."created_at") FROM "accounts" f, "accounts" o
+   WHERE f."id" = t."from_account_id" AND o."id" = t."to_account_id")
+)
+WHERE t."created_at" IS NULL;
+
+ALTER TABLE "entries" ALTER COLUMN "created_at" SET NOT NULL;
+
+ALTER TABLE "transfers" ALTER COLUMN "created_at" SET NOT NULL;
+
+CREATE INDEX "entries_account_id_created_at_idx" ON "entries" ("account_id", "created_at") INCLUDE ("amount");
+
+CREATE TABLE "account_balance_snapshots" (
+  "account_id" bigint NOT NULL,
+  "day" date NOT NULL,
+  "balance" bigint NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("account_id", "day")
+);
+
+COMMENT ON COLUMN "account_balance_snapshots"."day" IS 'UTC day the snapshot closes';
+
+COMMENT ON COLUMN "account_balance_snapshots"."balance" IS 'ledger balance at the end of the day';
+
+ALTER TABLE "account_balance_snapshots" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
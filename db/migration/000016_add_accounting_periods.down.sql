@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS period_trial_balances;
+
+DROP TABLE IF EXISTS accounting_periods;
+
+ALTER TABLE journals DROP COLUMN IF EXISTS effective_at;
@@ -0,0 +1,46 @@
+ALTER TABLE "journals" ADD COLUMN "effective_at" timestamptz;
+
+UPDATE "journals" SET "effective_at" = "created_at";
+
+ALTER TABLE "journals" ALTER COLUMN "effective_at" SET NOT NULL;
+
+ALTER TABLE "journals" ALTER COLUMN "effective_at" SET DEFAULT (now());
+
+CREATE INDEX ON "journals" ("effective_at");
+
+COMMENT ON COLUMN "journals"."effective_at" IS 'when the journal applies, its entries are dated then; before created_at for back-dated adjustments';
+
+CREATE TABLE "accounting_periods" (
+  "id" bigserial PRIMARY KEY,
+  "kind" varchar NOT NULL,
+  "start_day" date NOT NULL,
+  "end_day" date NOT NULL,
+  "closed_by" varchar NOT NULL,
+  "closed_at" timestamptz NOT NULL DEFAULT (now()),
+  CHECK ("kind" IN ('day', 'month')),
+  CHECK ("start_day" <= "end_day"),
+  UNIQUE ("kind", "start_day")
+);
+
+CREATE INDEX ON "accounting_periods" ("end_day");
+
+COMMENT ON TABLE "accounting_periods" IS 'closed periods, no journal can be back-dated into them';
+
+COMMENT ON COLUMN "accounting_periods"."end_day" IS 'last UTC day of the period';
+
+COMMENT ON COLUMN "accounting_periods"."closed_by" IS 'admin or command that closed the period';
+
+CREATE TABLE "period_trial_balances" (
+  "period_id" bigint NOT NULL,
+  "currency" varchar NOT NULL,
+  "accounts" integer NOT NULL,
+  "debits" bigint NOT NULL,
+  "credits" bigint NOT NULL,
+  PRIMARY KEY ("period_id", "currency")
+);
+
+COMMENT ON COLUMN "period_trial_balances"."debits" IS 'sum of the negative closing balances, as a positive amount';
+
+COMMENT ON COLUMN "period_trial_balances"."credits" IS 'sum of the positive closing balances';
+
+ALTER TABLE "period_trial_balances" ADD FOREIGN KEY ("period_id") REFERENCES "accounting_periods" ("id");
@@ -0,0 +1,44 @@
+-- once anything was posted to an internal account, the opening balances and the fee, interest and loan
+-- entries cannot be taken back out of the customer balances, so the migration is irreversible from then on;
+-- nor can it be reverted while pricing rules, interest rates or loans point at an internal account
+DO $$
+BEGIN
+  IF EXISTS (SELECT 1 FROM "entries" e JOIN "accounts" a ON a."id" = e."account_id" WHERE a."code" IS NOT NULL)
+    OR EXISTS (SELECT 1 FROM "pricing_rules" r JOIN "accounts" a ON a."id" = r."fee_account_id" WHERE a."code" IS NOT NULL)
+    OR EXISTS (SELECT 1 FROM "interest_rates" r JOIN "accounts" a ON a."id" = r."expense_account_id" WHERE a."code" IS NOT NULL)
+    OR EXISTS (SELECT 1 FROM "loans" l JOIN "accounts" a ON a."id" = l."income_account_id" WHERE a."code" IS NOT NULL)
+  THEN
+    RAISE EXCEPTION 'cannot revert the chart of accounts once internal accounts are in use';
+  END IF;
+END;
+$$;
+
+ALTER TABLE "period_trial_balances" DROP CONSTRAINT IF EXISTS "period_trial_balances_pkey";
+
+-- fold the lines of every type back into one line per currency
+UPDATE "period_trial_balances" t
+SET "accounts" = s."accounts", "debits" = s."debits", "credits" = s."credits", "type" = ''
+FROM (
+  SELECT "period_id", "currency", SUM("accounts")::int AS "accounts", SUM("debits")::bigint AS "debits", SUM("credits")::bigint AS "credits", MIN("type") AS "type"
+  FROM "period_trial_balances"
+  GROUP BY "period_id", "currency"
+) s
+WHERE t."period_id" = s."period_id" AND t."currency" = s."currency" AND t."type" = s."type";
+
+DELETE FROM "period_trial_balances" WHERE "type" <> '';
+
+ALTER TABLE "period_trial_balances" DROP COLUMN IF EXISTS "type";
+
+ALTER TABLE "period_trial_balances" ADD PRIMARY KEY ("period_id", "currency");
+
+DELETE FROM "account_balance_snapshots" WHERE "account_id" IN (SELECT "id" FROM "accounts" WHERE "code" IS NOT NULL);
+
+DELETE FROM "accounts" WHERE "owner" = 'simplebank' AND "code" IS NOT NULL;
+
+DELETE FROM "users" WHERE "username" = 'simplebank';
+
+DELETE FROM "products" WHERE "code" = 'internal';
+
+ALTER TABLE "accounts" DROP COLUMN IF EXISTS "code";
+
+ALTER TABLE "accounts" DROP COLUMN IF EXISTS "type";
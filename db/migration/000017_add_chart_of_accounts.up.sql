@@ -0,0 +1,82 @@
+ALTER TABLE "accounts" ADD COLUMN "type" varchar NOT NULL DEFAULT 'liability';
+
+ALTER TABLE "accounts" ADD CONSTRAINT "accounts_type_check" CHECK ("type" IN ('asset', 'liability', 'income', 'expense', 'equity'));
+
+ALTER TABLE "accounts" ADD COLUMN "code" varchar;
+
+ALTER TABLE "accounts" ADD CONSTRAINT "accounts_code_currency_key" UNIQUE ("code", "currency");
+
+COMMENT ON COLUMN "accounts"."type" IS 'asset, liability, income, expense or equity; customer accounts are liabilities of the bank';
+
+COMMENT ON COLUMN "accounts"."code" IS 'general ledger code of an internal account, null for customer accounts';
+
+UPDATE "accounts" SET "type" = 'asset' WHERE "product" = 'loan';
+
+INSERT INTO "products" ("code", "name", "day_count") VALUES ('internal', 'Internal ledger account', 'ACT/365');
+
+-- owns the internal accounts, its password hash matches no password so nobody can log in as it
+INSERT INTO "users" ("username", "hashed_password", "full_name", "email")
+VALUES ('simplebank', '!', 'Simple Bank', 'ledger@simplebank.internal');
+
+INSERT INTO "accounts" ("owner", "balance", "currency", "product", "type", "code")
+SELECT 'simplebank', 0, c."currency", 'internal', g."type", g."code"
+FROM (VALUES ('USD'), ('EUR')) AS c ("currency")
+CROSS JOIN (VALUES
+  ('fee_income', 'income'),
+  ('interest_income', 'income'),
+  ('interest_expense', 'expense'),
+  ('fx_spread', 'income'),
+  ('suspense', 'liability'),
+  ('capital', 'equity')
+) AS g ("code", "type")
+ORDER BY c."currency", g."code";
+
+-- balances seeded without entries leave the books short, so each currency gets an opening journal posting
+-- what the entries of every account do not explain against capital; it is dated when the first account
+-- of the currency was opened, ahead of any balance snapshot
+CREATE TEMPORARY TABLE "opening_balances" AS
+SELECT a."id" AS "account_id", a."currency", a."created_at",
+  a."balance" - COALESCE((SELECT SUM(e."amount") FROM "entries" e WHERE e."account_id" = a."id"), 0)::bigint AS "amount"
+FROM "accounts" a
+WHERE a."code" IS NULL;
+
+DELETE FROM "opening_balances" WHERE "amount" = 0;
+
+INSERT INTO "journals" ("kind", "description", "effective_at")
+SELECT 'opening_balance', 'opening balances in ' || "currency", MIN("created_at")
+FROM "opening_balances"
+GROUP BY "currency"
+HAVING SUM("amount") <> 0
+ORDER BY "currency";
+
+INSERT INTO "entries" ("journal_id", "account_id", "amount", "created_at")
+SELECT j."id", o."account_id", o."amount", j."effective_at"
+FROM "opening_balances" o
+JOIN "journals" j ON j."kind" = 'opening_balance' AND j."description" = 'opening balances in ' || o."currency"
+ORDER BY o."account_id";
+
+INSERT INTO "entries" ("journal_id", "account_id", "amount", "created_at")
+SELECT j."id", a."id", -SUM(o."amount"), j."effective_at"
+FROM "opening_balances" o
+JOIN "journals" j ON j."kind" = 'opening_balance' AND j."description" = 'opening balances in ' || o."currency"
+JOIN "accounts" a ON a."code" = 'capital' AND a."currency" = o."currency"
+GROUP BY j."id", a."id";
+
+UPDATE "accounts" a
+SET "balance" = a."balance" + e."amount"
+FROM (
+  SELECT e."account_id", SUM(e."amount") AS "amount"
+  FROM "entries" e
+  JOIN "journals" j ON j."id" = e."journal_id"
+  WHERE j."kind" = 'opening_balance'
+  GROUP BY e."account_id"
+) e
+WHERE a."id" = e."account_id" AND a."code" = 'capital';
+
+DROP TABLE "opening_balances";
+
+ALTER TABLE "period_trial_balances" ADD COLUMN "type" varchar NOT NULL DEFAULT 'liability';
+
+ALTER TABLE "period_trial_balances" DROP CONSTRAINT "period_trial_balances_pkey";
+
+ALTER TABLE "period_trial_balances" ADD PRIMARY KEY ("period_id", "currency", "type");
@@ -0,0 +1,47 @@
+CREATE TABLE "audit_log" (
+  "id" bigserial PRIMARY KEY,
+  "actor" varchar NOT NULL,
+  "action" varchar NOT NULL,
+  "target_type" varchar NOT NULL,
+  "target_id" varchar NOT NULL,
+  "before" json NOT NULL DEFAULT 'null',
+  "after" json NOT NULL DEFAULT 'null',
+  "ip" varchar NOT NULL DEFAULT '',
+  "user_agent" varchar NOT NULL DEFAULT '',
+  "request_id" varchar NOT NULL DEFAULT '',
+  "prev_hash" varchar NOT NULL,
+  "hash" varchar UNIQUE NOT NULL,
+  "created_at" timestamptz NOT NULL
+);
+
+CREATE INDEX ON "audit_log" ("actor", "id");
+
+CREATE INDEX ON "audit_log" ("action", "id");
+
+CREATE INDEX ON "audit_log" ("target_type", "target_id", "id");
+
+CREATE INDEX ON "audit_log" ("created_at");
+
+COMMENT ON TABLE "audit_log" IS 'append-only record of who did what, each row hashes the previous one';
+
+COMMENT ON COLUMN "audit_log"."actor" IS 'username, system for workers and commands, anonymous before login';
+
+COMMENT ON COLUMN "audit_log"."before" IS 'target before the action, json null when it did not exist';
+
+COMMENT ON COLUMN "audit_log"."after" IS 'target after the action, json null when it was deleted';
+
+COMMENT ON COLUMN "audit_log"."prev_hash" IS 'hash of the previous row, empty for the first row';
+
+COMMENT ON COLUMN "audit_log"."hash" IS 'hex SHA-256 of prev_hash and every column but id';
+
+CREATE FUNCTION "audit_log_append_only"() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "audit_log_no_update" BEFORE UPDATE OR DELETE ON "audit_log"
+  FOR EACH ROW EXECUTE FUNCTION "audit_log_append_only"();
+
+CREATE TRIGGER "audit_log_no_truncate" BEFORE TRUNCATE ON "audit_log"
+  FOR EACH STATEMENT EXECUTE FUNCTION "audit_log_append_only"();
@@ -0,0 +1,5 @@
+ALTER TABLE "users" ADD COLUMN "role" varchar NOT NULL DEFAULT 'customer';
+
+ALTER TABLE "users" ADD CONSTRAINT "users_role_check" CHECK ("role" IN ('admin', 'support', 'customer'));
+
+COMMENT ON COLUMN "users"."role" IS 'admin, support or customer, it decides what the user is permitted to do';
@@ -0,0 +1,25 @@
+CREATE TABLE "api_keys" (
+  "id" bigserial PRIMARY KEY,
+  "owner" varchar NOT NULL,
+  "name" varchar NOT NULL,
+  "prefix" varchar UNIQUE NOT NULL,
+  "hashed_secret" varchar NOT NULL,
+  "scopes" varchar[] NOT NULL,
+  "allowed_ips" varchar[] NOT NULL DEFAULT '{}',
+  "expires_at" timestamptz NOT NULL,
+  "last_used_at" timestamptz,
+  "revoked_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "api_keys" ("owner", "id");
+
+COMMENT ON COLUMN "api_keys"."prefix" IS 'public part of the key, it finds the key without its secret';
+
+COMMENT ON COLUMN "api_keys"."hashed_secret" IS 'hex SHA-256 of the secret part of the key, the secret itself is never stored';
+
+COMMENT ON COLUMN "api_keys"."scopes" IS 'permissions the key is limited to, the role of its owner must grant them too';
+
+COMMENT ON COLUMN "api_keys"."allowed_ips" IS 'IPs and CIDR ranges the key is accepted from, empty for anywhere';
+
+ALTER TABLE "api_keys" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
@@ -0,0 +1,16 @@
+ALTER TABLE "api_keys" ADD COLUMN "signing_secret" varchar NOT NULL DEFAULT '';
+
+COMMENT ON COLUMN "api_keys"."signing_secret" IS 'signs the requests made with the key on the routes that require a signature, empty for the keys created before request signing';
+
+CREATE TABLE "request_nonces" (
+  "api_key_id" bigint NOT NULL,
+  "nonce" varchar NOT NULL,
+  "expires_at" timestamptz NOT NULL,
+  PRIMARY KEY ("api_key_id", "nonce")
+);
+
+CREATE INDEX ON "request_nonces" ("api_key_id", "expires_at");
+
+COMMENT ON TABLE "request_nonces" IS 'nonces of the signed requests, a nonce is kept until the timestamp signed with it is no longer accepted';
+
+ALTER TABLE "request_nonces" ADD FOREIGN KEY ("api_key_id") REFERENCES "api_keys" ("id") ON DELETE CASCADE;
@@ -0,0 +1,5 @@
+ALTER TABLE "users" DROP COLUMN IF EXISTS "locked_until";
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "failed_logins";
+
+DROP TABLE IF EXISTS "rate_limit_buckets";
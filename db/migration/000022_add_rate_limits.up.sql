@@ -0,0 +1,18 @@
+CREATE TABLE "rate_limit_buckets" (
+  "key" varchar PRIMARY KEY,
+  "full_at" timestamptz NOT NULL
+);
+
+CREATE INDEX ON "rate_limit_buckets" ("full_at");
+
+COMMENT ON TABLE "rate_limit_buckets" IS 'token buckets of the rate limits shared by every replica, a bucket full again is the same as none';
+
+COMMENT ON COLUMN "rate_limit_buckets"."full_at" IS 'when the bucket is full again, each token taken pushes it back by the refill interval of a token';
+
+ALTER TABLE "users" ADD COLUMN "failed_logins" int NOT NULL DEFAULT 0;
+
+ALTER TABLE "users" ADD COLUMN "locked_until" timestamptz;
+
+COMMENT ON COLUMN "users"."failed_logins" IS 'failed logins since the last successful one or the last lockout';
+
+COMMENT ON COLUMN "users"."locked_until" IS 'logins are refused until then after too many failed ones';
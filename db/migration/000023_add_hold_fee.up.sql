@@ -0,0 +1,5 @@
+ALTER TABLE "holds" ADD COLUMN "fee" bigint NOT NULL DEFAULT 0;
+
+ALTER TABLE "holds" ADD CONSTRAINT "holds_fee_check" CHECK ("fee" >= 0);
+
+COMMENT ON COLUMN "holds"."fee" IS 'fee quoted for amount at authorization, held on top of amount so that a capture cannot overdraw';
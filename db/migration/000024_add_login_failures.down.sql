@@ -0,0 +1,5 @@
+ALTER TABLE "users" ADD COLUMN "failed_logins" int NOT NULL DEFAULT 0;
+
+ALTER TABLE "users" ADD COLUMN "locked_until" timestamptz;
+
+DROP TABLE IF EXISTS "login_failures";
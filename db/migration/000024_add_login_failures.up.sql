@@ -0,0 +1,19 @@
+CREATE TABLE "login_failures" (
+  "username" varchar NOT NULL,
+  "client_ip" varchar NOT NULL,
+  "failures" int NOT NULL DEFAULT 0,
+  "locked_until" timestamptz,
+  PRIMARY KEY ("username", "client_ip")
+);
+
+COMMENT ON TABLE "login_failures" IS 'failed logins by username and client IP, a caller guessing passwords locks out its own IP rather than the user';
+
+COMMENT ON COLUMN "login_failures"."username" IS 'the username tried, whether or not such a user exists';
+
+COMMENT ON COLUMN "login_failures"."failures" IS 'failed logins since the last successful one or the last lockout';
+
+COMMENT ON COLUMN "login_failures"."locked_until" IS 'logins of the username from the client IP are refused until then after too many failed ones';
+
+ALTER TABLE "users" DROP COLUMN "locked_until";
+
+ALTER TABLE "users" DROP COLUMN "failed_logins";
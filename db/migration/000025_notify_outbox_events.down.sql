@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS "outbox_events_notify" ON "outbox_events";
+
+DROP FUNCTION IF EXISTS "notify_outbox_event";
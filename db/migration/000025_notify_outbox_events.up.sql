@@ -0,0 +1,11 @@
+-- every process listens on the channel to feed the live streams of its own clients,
+-- whichever process relays the event
+CREATE FUNCTION "notify_outbox_event"() RETURNS trigger AS $$
+BEGIN
+  PERFORM pg_notify('outbox_events', NEW."id"::text);
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "outbox_events_notify" AFTER INSERT ON "outbox_events"
+  FOR EACH ROW EXECUTE FUNCTION "notify_outbox_event"();
@@ -2,9 +2,11 @@
 INSERT INTO accounts (
   owner,
   balance,
-  currency
+  currency,
+  product,
+  type
 ) VALUES (
-  $1, $2, $3
+  sqlc.arg(owner), sqlc.arg(balance), sqlc.arg(currency), COALESCE(sqlc.narg(product), 'checking'), COALESCE(sqlc.narg(type), 'liability')
 ) RETURNING *;
 
 -- name: GetAccount :one
@@ -18,9 +20,25 @@ FOR NO KEY UPDATE;
 
 -- name: ListAccounts :many
 SELECT * FROM accounts
+WHERE owner = $1
 ORDER BY id
-LIMIT $1
-OFFSET $2;
+LIMIT $2
+OFFSET $3;
+
+-- name: ListAccountsAfter :many
+SELECT * FROM accounts
+WHERE owner = sqlc.arg(owner) AND id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg(limit_count);
+
+-- name: ListAllAccounts :many
+SELECT * FROM accounts
+WHERE (sqlc.narg(owner)::varchar IS NULL OR owner = sqlc.narg(owner))
+  AND (sqlc.narg(status)::varchar IS NULL OR status = sqlc.narg(status))
+  AND (sqlc.narg(currency)::varchar IS NULL OR currency = sqlc.narg(currency))
+  AND id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg(limit_count);
 
 -- name: UpdateAccount :one
 UPDATE accounts
@@ -34,6 +52,12 @@ SET balance = balance + sqlc.arg(amount)
 WHERE id = $1
 RETURNING *;
 
+-- name: UpdateAccountStatus :one
+UPDATE accounts
+SET status = $2
+WHERE id = $1
+RETURNING *;
+
 -- name: DeleteAccount :exec
 DELETE FROM accounts WHERE id = $1;
 
@@ -55,6 +79,12 @@ ORDER BY id
 LIMIT $1
 OFFSET $2;
 
+-- name: ListAccountEntries :many
+SELECT * FROM entries
+WHERE account_id = sqlc.arg(account_id) AND id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg(limit_count);
+
 -- name: UpdateEntrie :one
 UPDATE entries
 SET amount = $2
@@ -68,9 +98,10 @@ DELETE FROM entries WHERE id = $1;
 INSERT INTO transfers (
   from_account_id,
   to_account_id,
-  amount
+  amount,
+  journal_id
 ) VALUES (
-  $1, $2, $3
+  $1, $2, $3, $4
 ) RETURNING *;
 
 -- name: GetTransfer :one
@@ -90,4 +121,1151 @@ WHERE id = $1
 RETURNING *;
 
 -- name: DeleteTransfer :exec
-DELETE FROM transfers WHERE id = $1;
\ No newline at end of file
+DELETE FROM transfers WHERE id = $1;
+
+-- name: CreateUser :one
+INSERT INTO users (
+  username,
+  hashed_password,
+  full_name,
+  email
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING *;
+
+-- name: GetUser :one
+SELECT * FROM users
+WHERE username = $1 LIMIT 1;
+
+-- name: GetUserForUpdate :one
+SELECT * FROM users
+WHERE username = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: UpdateUserRole :one
+UPDATE users
+SET role = $2
+WHERE username = $1
+RETURNING *;
+
+-- name: GetLoginFailure :one
+SELECT * FROM login_failures
+WHERE username = $1 AND client_ip = $2 LIMIT 1;
+
+-- name: RecordFailedLogin :one
+INSERT INTO login_failures (
+  username,
+  client_ip,
+  failures,
+  locked_until
+) VALUES (
+  sqlc.arg(username),
+  sqlc.arg(client_ip),
+  CASE WHEN 1 >= sqlc.arg(max_failures)::int THEN 0 ELSE 1 END,
+  CASE WHEN 1 >= sqlc.arg(max_failures)::int THEN sqlc.arg(lock_until)::timestamptz END
+)
+ON CONFLICT (username, client_ip) DO UPDATE
+SET
+  failures = CASE WHEN login_failures.failures + 1 >= sqlc.arg(max_failures)::int THEN 0 ELSE login_failures.failures + 1 END,
+  locked_until = CASE WHEN login_failures.failures + 1 >= sqlc.arg(max_failures)::int THEN sqlc.arg(lock_until)::timestamptz ELSE login_failures.locked_until END
+RETURNING *;
+
+-- name: ResetFailedLogins :exec
+DELETE FROM login_failures
+WHERE username = $1 AND client_ip = $2;
+
+-- name: CreateSession :one
+INSERT INTO sessions (
+  id,
+  username,
+  refresh_token,
+  user_agent,
+  client_ip,
+  is_blocked,
+  expires_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7
+) RETURNING *;
+
+-- name: GetSession :one
+SELECT * FROM sessions
+WHERE id = $1 LIMIT 1;
+
+-- name: CreateIdempotencyKey :one
+INSERT INTO idempotency_keys (
+  username,
+  key,
+  request_method,
+  request_path,
+  request_hash
+) VALUES (
+  $1, $2, $3, $4, $5
+)
+ON CONFLICT DO NOTHING
+RETURNING *;
+
+-- name: GetIdempotencyKey :one
+SELECT * FROM idempotency_keys
+WHERE username = $1 AND key = $2 LIMIT 1;
+
+-- name: UpdateIdempotencyKeyResponse :exec
+UPDATE idempotency_keys
+SET response_status = $3, response_body = $4
+WHERE username = $1 AND key = $2;
+
+-- name: DeleteIdempotencyKey :exec
+DELETE FROM idempotency_keys
+WHERE username = $1 AND key = $2;
+-- name: CreateOutboxEvent :one
+INSERT INTO outbox_events (
+  account_id,
+  event_type,
+  schema_version,
+  payload
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING *;
+
+-- name: GetOutboxEvent :one
+SELECT * FROM outbox_events
+WHERE id = $1 LIMIT 1;
+
+-- name: ListUnpublishedOutboxEventsForUpdate :many
+SELECT * FROM outbox_events
+WHERE published_at IS NULL
+ORDER BY id
+LIMIT $1
+FOR UPDATE;
+
+-- name: ListAccountOutboxEvents :many
+SELECT * FROM outbox_events
+WHERE account_id = sqlc.arg(account_id) AND id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg(limit_count);
+
+-- name: GetLatestAccountOutboxEventID :one
+SELECT COALESCE(MAX(id), 0)::bigint AS latest_id FROM outbox_events
+WHERE account_id = $1;
+
+-- name: MarkOutboxEventPublished :exec
+UPDATE outbox_events
+SET published_at = now(), attempts = attempts + 1, last_error = NULL
+WHERE id = $1;
+
+-- name: MarkOutboxEventFailed :exec
+UPDATE outbox_events
+SET attempts = attempts + 1, last_error = $2
+WHERE id = $1;
+
+-- name: CreateWebhookSubscription :one
+INSERT INTO webhook_subscriptions (
+  owner,
+  url,
+  event_types,
+  secret
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING *;
+
+-- name: GetWebhookSubscription :one
+SELECT * FROM webhook_subscriptions
+WHERE id = $1 LIMIT 1;
+
+-- name: ListWebhookSubscriptions :many
+SELECT * FROM webhook_subscriptions
+WHERE owner = $1
+ORDER BY id;
+
+-- name: DeleteWebhookSubscription :exec
+DELETE FROM webhook_subscriptions WHERE id = $1;
+
+-- name: EnqueueWebhookDeliveries :execrows
+INSERT INTO webhook_deliveries (
+  subscription_id,
+  event_id,
+  event_type,
+  payload
+)
+SELECT DISTINCT s.id, sqlc.arg(event_id)::bigint, sqlc.arg(event_type)::varchar, sqlc.arg(payload)::jsonb
+FROM webhook_subscriptions s
+JOIN accounts a ON a.owner = s.owner
+WHERE a.id = ANY(sqlc.arg(account_ids)::bigint[])
+  AND sqlc.arg(event_type)::varchar = ANY(s.event_types)
+ON CONFLICT (subscription_id, event_id) DO NOTHING;
+
+-- name: ClaimDueWebhookDeliveries :many
+UPDATE webhook_deliveries
+SET next_attempt_at = sqlc.arg(lease_until)
+WHERE id IN (
+  SELECT id FROM webhook_deliveries
+  WHERE status = 'pending' AND next_attempt_at <= now()
+  ORDER BY next_attempt_at
+  LIMIT sqlc.arg(limit_count)
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: GetWebhookDelivery :one
+SELECT * FROM webhook_deliveries
+WHERE id = $1 LIMIT 1;
+
+-- name: ListWebhookDeliveries :many
+SELECT * FROM webhook_deliveries
+WHERE subscription_id = sqlc.arg(subscription_id) AND id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg(limit_count);
+
+-- name: UpdateWebhookDeliveryAttempt :one
+UPDATE webhook_deliveries
+SET
+  status = sqlc.arg(status),
+  attempts = attempts + 1,
+  next_attempt_at = sqlc.arg(next_attempt_at),
+  last_attempt_at = now(),
+  response_status = sqlc.arg(response_status),
+  last_error = sqlc.arg(last_error)
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: RedeliverWebhookDelivery :one
+UPDATE webhook_deliveries
+SET status = 'pending', next_attempt_at = now(), expires_at = now() + interval '24 hours'
+WHERE id = $1
+RETURNING *;
+
+-- name: CreateScheduledTransfer :one
+INSERT INTO scheduled_transfers (
+  owner,
+  from_account_id,
+  to_account_id,
+  amount,
+  currency,
+  start_at,
+  recurrence,
+  end_at,
+  next_occurrence_at,
+  next_run_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+) RETURNING *;
+
+-- name: GetScheduledTransfer :one
+SELECT * FROM scheduled_transfers
+WHERE id = $1 LIMIT 1;
+
+-- name: GetScheduledTransferForUpdate :one
+SELECT * FROM scheduled_transfers
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: ListScheduledTransfers :many
+SELECT * FROM scheduled_transfers
+WHERE owner = sqlc.arg(owner) AND id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg(limit_count);
+
+-- name: ClaimDueScheduledTransfer :one
+SELECT * FROM scheduled_transfers
+WHERE status = 'active' AND next_run_at <= sqlc.arg(now)
+ORDER BY next_run_at
+LIMIT 1
+FOR NO KEY UPDATE SKIP LOCKED;
+
+-- name: UpdateScheduledTransfer :one
+UPDATE scheduled_transfers
+SET
+  amount = sqlc.arg(amount),
+  end_at = sqlc.arg(end_at),
+  status = sqlc.arg(status),
+  next_occurrence_at = sqlc.arg(next_occurrence_at),
+  next_run_at = sqlc.arg(next_run_at),
+  attempts = sqlc.arg(attempts),
+  last_error = sqlc.arg(last_error),
+  updated_at = now()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: GetScheduledTransferRun :one
+SELECT * FROM scheduled_transfer_runs
+WHERE scheduled_transfer_id = $1 AND occurrence_at = $2 LIMIT 1;
+
+-- name: UpsertScheduledTransferRun :one
+INSERT INTO scheduled_transfer_runs (
+  scheduled_transfer_id,
+  occurrence_at,
+  status,
+  transfer_id,
+  last_error
+) VALUES (
+  $1, $2, $3, $4, $5
+)
+ON CONFLICT (scheduled_transfer_id, occurrence_at) DO UPDATE
+SET
+  status = EXCLUDED.status,
+  attempts = scheduled_transfer_runs.attempts + 1,
+  transfer_id = EXCLUDED.transfer_id,
+  last_error = EXCLUDED.last_error,
+  updated_at = now()
+RETURNING *;
+
+-- name: ListScheduledTransferRuns :many
+SELECT * FROM scheduled_transfer_runs
+WHERE scheduled_transfer_id = sqlc.arg(scheduled_transfer_id) AND id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg(limit_count);
+
+-- name: ListAccountsByIDs :many
+SELECT * FROM accounts
+WHERE id = ANY(sqlc.arg(ids)::bigint[])
+ORDER BY id;
+
+-- name: ListAccountsForUpdate :many
+SELECT * FROM accounts
+WHERE id = ANY(sqlc.arg(ids)::bigint[])
+ORDER BY id
+FOR NO KEY UPDATE;
+
+-- name: CreateTransferBatch :one
+INSERT INTO transfer_batches (
+  owner,
+  mode,
+  total_rows
+) VALUES (
+  $1, $2, $3
+) RETURNING *;
+
+-- name: CreateTransferBatchItems :execrows
+INSERT INTO transfer_batch_items (
+  batch_id,
+  row_number,
+  from_account_id,
+  to_account_id,
+  amount,
+  currency
+)
+SELECT
+  sqlc.arg(batch_id)::bigint,
+  unnest(sqlc.arg(row_numbers)::int[]),
+  unnest(sqlc.arg(from_account_ids)::bigint[]),
+  unnest(sqlc.arg(to_account_ids)::bigint[]),
+  unnest(sqlc.arg(amounts)::bigint[]),
+  unnest(sqlc.arg(currencies)::varchar[]);
+
+-- name: GetTransferBatch :one
+SELECT * FROM transfer_batches
+WHERE id = $1 LIMIT 1;
+
+-- name: GetTransferBatchForUpdate :one
+SELECT * FROM transfer_batches
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: ListTransferBatches :many
+SELECT * FROM transfer_batches
+WHERE owner = sqlc.arg(owner) AND id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg(limit_count);
+
+-- name: ClaimTransferBatch :one
+UPDATE transfer_batches
+SET
+  status = 'processing',
+  lease_until = sqlc.arg(lease_until)::timestamptz,
+  updated_at = now()
+WHERE id = (
+  SELECT id FROM transfer_batches
+  WHERE status = 'pending' OR (status = 'processing' AND lease_until < sqlc.arg(now)::timestamptz)
+  ORDER BY id
+  LIMIT 1
+  FOR NO KEY UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: UpdateTransferBatch :one
+UPDATE transfer_batches
+SET
+  status = sqlc.arg(status),
+  succeeded_rows = sqlc.arg(succeeded_rows),
+  failed_rows = sqlc.arg(failed_rows),
+  lease_until = sqlc.arg(lease_until),
+  completed_at = sqlc.arg(completed_at),
+  updated_at = now()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: ListPendingTransferBatchItems :many
+SELECT * FROM transfer_batch_items
+WHERE batch_id = sqlc.arg(batch_id) AND status = 'pending'
+ORDER BY row_number
+LIMIT sqlc.arg(limit_count);
+
+-- name: ListTransferBatchItems :many
+SELECT * FROM transfer_batch_items
+WHERE batch_id = sqlc.arg(batch_id) AND id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg(limit_count);
+
+-- name: UpdateTransferBatchItem :exec
+UPDATE transfer_batch_items
+SET
+  status = sqlc.arg(status),
+  transfer_id = sqlc.arg(transfer_id),
+  error = sqlc.arg(error)
+WHERE id = sqlc.arg(id);
+
+-- name: CreateJournal :one
+INSERT INTO journals (
+  kind,
+  description,
+  effective_at
+) VALUES (
+  sqlc.arg(kind), sqlc.arg(description), COALESCE(sqlc.narg(effective_at), now())
+) RETURNING *;
+
+-- name: GetJournal :one
+SELECT * FROM journals
+WHERE id = $1 LIMIT 1;
+
+-- name: CreateJournalEntry :one
+INSERT INTO entries (
+  journal_id,
+  account_id,
+  amount,
+  created_at
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING *;
+
+-- name: ListJournalEntries :many
+SELECT * FROM entries
+WHERE journal_id = $1
+ORDER BY id;
+
+-- name: UpdateAccountTier :one
+UPDATE accounts
+SET tier = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: CreatePricingRule :one
+INSERT INTO pricing_rules (
+  tier,
+  currency,
+  min_amount,
+  max_amount,
+  flat_fee,
+  percent_bps,
+  min_fee,
+  max_fee,
+  fee_account_id,
+  priority
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+) RETURNING *;
+
+-- name: ListActivePricingRules :many
+SELECT * FROM pricing_rules
+WHERE currency = $1 AND active
+ORDER BY id;
+
+-- name: GetPricingRule :one
+SELECT * FROM pricing_rules
+WHERE id = $1 LIMIT 1;
+
+-- name: GetPricingRuleForUpdate :one
+SELECT * FROM pricing_rules
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: ListPricingRules :many
+SELECT * FROM pricing_rules
+WHERE (sqlc.narg(currency)::varchar IS NULL OR currency = sqlc.narg(currency))
+  AND id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg(limit_count);
+
+-- name: UpdatePricingRule :one
+UPDATE pricing_rules
+SET
+  tier = sqlc.narg(tier),
+  currency = sqlc.arg(currency),
+  min_amount = sqlc.arg(min_amount),
+  max_amount = sqlc.narg(max_amount),
+  flat_fee = sqlc.arg(flat_fee),
+  percent_bps = sqlc.arg(percent_bps),
+  min_fee = sqlc.arg(min_fee),
+  max_fee = sqlc.narg(max_fee),
+  fee_account_id = sqlc.arg(fee_account_id),
+  priority = sqlc.arg(priority),
+  active = sqlc.arg(active)
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: CreateTransferLimit :one
+INSERT INTO transfer_limits (
+  account_id,
+  owner,
+  max_amount,
+  daily_amount,
+  monthly_amount,
+  hourly_count,
+  expires_at,
+  reason,
+  created_by
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9
+) RETURNING *;
+
+-- name: GetAccountTransferLimit :one
+SELECT * FROM transfer_limits
+WHERE (account_id = sqlc.arg(account_id)::bigint OR (account_id IS NULL AND owner IS NULL))
+  AND (expires_at IS NULL OR expires_at > sqlc.arg(now)::timestamptz)
+ORDER BY account_id IS NULL, id DESC
+LIMIT 1;
+
+-- name: GetOwnerTransferLimit :one
+SELECT * FROM transfer_limits
+WHERE owner = sqlc.arg(owner)::varchar
+  AND (expires_at IS NULL OR expires_at > sqlc.arg(now)::timestamptz)
+ORDER BY id DESC
+LIMIT 1;
+
+-- name: ListTransferLimits :many
+SELECT * FROM transfer_limits
+WHERE (sqlc.narg(account_id)::bigint IS NULL OR account_id = sqlc.narg(account_id))
+  AND (sqlc.narg(owner)::varchar IS NULL OR owner = sqlc.narg(owner))
+  AND id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg(limit_count);
+
+-- name: GetAccountTransferUsage :one
+SELECT
+  COALESCE(SUM(amount) FILTER (WHERE created_at >= sqlc.arg(day_start)::timestamptz), 0)::bigint AS daily_amount,
+  COALESCE(SUM(amount) FILTER (WHERE created_at >= sqlc.arg(month_start)::timestamptz), 0)::bigint AS monthly_amount,
+  COUNT(*) FILTER (WHERE created_at >= sqlc.arg(hour_start)::timestamptz) AS hourly_count
+FROM transfers
+WHERE from_account_id = sqlc.arg(account_id) AND created_at >= sqlc.arg(since)::timestamptz;
+
+-- name: GetOwnerTransferUsage :one
+SELECT
+  COALESCE(SUM(amount) FILTER (WHERE created_at >= sqlc.arg(day_start)::timestamptz), 0)::bigint AS daily_amount,
+  COALESCE(SUM(amount) FILTER (WHERE created_at >= sqlc.arg(month_start)::timestamptz), 0)::bigint AS monthly_amount,
+  COUNT(*) FILTER (WHERE created_at >= sqlc.arg(hour_start)::timestamptz) AS hourly_count
+FROM transfers
+WHERE from_account_id IN (SELECT id FROM accounts WHERE owner = sqlc.arg(owner))
+  AND created_at >= sqlc.arg(since)::timestamptz;
+
+-- name: LockOwnerTransfers :exec
+SELECT pg_advisory_xact_lock(hashtext('transfer_limits'), hashtext(sqlc.arg(owner)));
+
+-- name: CreateRiskAssessment :one
+INSERT INTO risk_assessments (
+  owner,
+  from_account_id,
+  to_account_id,
+  amount,
+  currency,
+  decision,
+  score,
+  hits,
+  status
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9
+) RETURNING *;
+
+-- name: GetRiskAssessment :one
+SELECT * FROM risk_assessments
+WHERE id = $1 LIMIT 1;
+
+-- name: GetRiskAssessmentForUpdate :one
+SELECT * FROM risk_assessments
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: ListRiskAssessments :many
+SELECT * FROM risk_assessments
+WHERE (sqlc.narg(status)::varchar IS NULL OR status = sqlc.narg(status))
+  AND id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg(limit_count);
+
+-- name: SetRiskAssessmentTransfer :exec
+UPDATE risk_assessments
+SET transfer_id = $2
+WHERE id = $1;
+
+-- name: ReviewRiskAssessment :one
+UPDATE risk_assessments
+SET
+  status = sqlc.arg(status),
+  transfer_id = sqlc.narg(transfer_id),
+  reviewed_by = sqlc.arg(reviewed_by)::varchar,
+  reviewed_at = now()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: CountTransfersBetween :one
+SELECT COUNT(*) FROM transfers
+WHERE from_account_id = sqlc.arg(from_account_id)
+  AND to_account_id = sqlc.arg(to_account_id)
+  AND created_at >= sqlc.arg(since)::timestamptz;
+
+-- name: CountTransfersFrom :one
+SELECT COUNT(*) FROM transfers
+WHERE from_account_id = sqlc.arg(from_account_id)
+  AND created_at >= sqlc.arg(since)::timestamptz;
+
+-- name: AddAccountHeldBalance :one
+UPDATE accounts
+SET held_balance = held_balance + sqlc.arg(amount)
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: CreateHold :one
+INSERT INTO holds (
+  account_id,
+  to_account_id,
+  amount,
+  currency,
+  description,
+  expires_at,
+  fee
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7
+) RETURNING *;
+
+-- name: GetHold :one
+SELECT * FROM holds
+WHERE id = $1 LIMIT 1;
+
+-- name: GetHoldForUpdate :one
+SELECT * FROM holds
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: ListAccountHolds :many
+SELECT * FROM holds
+WHERE account_id = sqlc.arg(account_id) AND id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg(limit_count);
+
+-- name: UpdateHold :one
+UPDATE holds
+SET captured_amount = sqlc.arg(captured_amount), status = sqlc.arg(status), updated_at = now()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: ClaimExpiredHold :one
+SELECT * FROM holds
+WHERE status = 'authorized' AND expires_at <= sqlc.arg(now)
+ORDER BY expires_at
+LIMIT 1
+FOR NO KEY UPDATE SKIP LOCKED;
+
+-- name: CreateProduct :one
+INSERT INTO products (
+  code,
+  name,
+  day_count
+) VALUES (
+  $1, $2, $3
+) RETURNING *;
+
+-- name: GetProduct :one
+SELECT * FROM products
+WHERE code = $1 LIMIT 1;
+
+-- name: ListProducts :many
+SELECT * FROM products
+ORDER BY code;
+
+-- name: CreateInterestRate :one
+INSERT INTO interest_rates (
+  product,
+  currency,
+  rate_bps,
+  effective_from,
+  expense_account_id,
+  created_by
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+) RETURNING *;
+
+-- name: GetInterestRate :one
+SELECT * FROM interest_rates
+WHERE product = sqlc.arg(product) AND currency = sqlc.arg(currency) AND effective_from <= sqlc.arg(day)
+ORDER BY effective_from DESC, id DESC
+LIMIT 1;
+
+-- name: ListInterestRates :many
+SELECT * FROM interest_rates
+WHERE (sqlc.narg(product)::varchar IS NULL OR product = sqlc.narg(product))
+  AND (sqlc.narg(currency)::varchar IS NULL OR currency = sqlc.narg(currency))
+  AND id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg(limit_count);
+
+-- name: GetAccountBalanceAt :one
+SELECT (CASE
+  WHEN s.balance IS NULL THEN a.balance - COALESCE((
+    SELECT SUM(e.amount) FROM entries e
+    WHERE e.account_id = a.id AND e.created_at >= sqlc.arg(at)
+  ), 0)
+  ELSE s.balance + COALESCE((
+    SELECT SUM(e.amount) FROM entries e
+    WHERE e.account_id = a.id AND e.created_at >= s.end_at AND e.created_at < sqlc.arg(at)
+  ), 0)
+END)::bigint AS balance
+FROM accounts a
+LEFT JOIN LATERAL (
+  SELECT b.balance, (b.day + 1)::timestamp AT TIME ZONE 'UTC' AS end_at
+  FROM account_balance_snapshots b
+  WHERE b.account_id = a.id AND b.day < (sqlc.arg(at)::timestamptz AT TIME ZONE 'UTC')::date
+  ORDER BY b.day DESC
+  LIMIT 1
+) s ON true
+WHERE a.id = sqlc.arg(account_id);
+
+-- name: CreateInterestAccrual :one
+INSERT INTO interest_accruals (
+  account_id,
+  day,
+  balance,
+  rate_bps,
+  day_count,
+  amount_micros
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+)
+ON CONFLICT (account_id, day) DO NOTHING
+RETURNING *;
+
+-- name: ListAccountInterestAccruals :many
+SELECT * FROM interest_accruals
+WHERE account_id = sqlc.arg(account_id) AND id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg(limit_count);
+
+-- name: ListAccountsToAccrue :many
+SELECT a.id FROM accounts a
+WHERE a.id > sqlc.arg(after_id)
+  AND a.status <> 'closed'
+  AND a.created_at < sqlc.arg(end_of_day)
+  AND EXISTS (
+    SELECT 1 FROM interest_rates r
+    WHERE r.product = a.product AND r.currency = a.currency AND r.effective_from <= sqlc.arg(day)
+  )
+  AND NOT EXISTS (
+    SELECT 1 FROM interest_accruals i
+    WHERE i.account_id = a.id AND i.day = sqlc.arg(day)
+  )
+  AND NOT EXISTS (
+    SELECT 1 FROM interest_postings p
+    WHERE p.account_id = a.id AND p.month >= date_trunc('month', sqlc.arg(day)::date)
+  )
+ORDER BY a.id
+LIMIT sqlc.arg(limit_count);
+
+-- name: SumUnpostedInterestAccruals :one
+SELECT COALESCE(SUM(amount_micros), 0)::bigint AS amount_micros FROM interest_accruals
+WHERE account_id = sqlc.arg(account_id) AND day >= sqlc.arg(month_start) AND day < sqlc.arg(month_end) AND posting_id IS NULL;
+
+-- name: MarkInterestAccrualsPosted :execrows
+UPDATE interest_accruals
+SET posting_id = sqlc.arg(posting_id)
+WHERE account_id = sqlc.arg(account_id) AND day >= sqlc.arg(month_start) AND day < sqlc.arg(month_end) AND posting_id IS NULL;
+
+-- name: ListUnpostedInterestMonths :many
+SELECT DISTINCT i.account_id, date_trunc('month', i.day)::date AS month FROM interest_accruals i
+WHERE i.posting_id IS NULL AND i.day < sqlc.arg(before)
+  AND NOT EXISTS (
+    SELECT 1 FROM interest_postings p
+    WHERE p.account_id = i.account_id AND p.month = date_trunc('month', i.day)
+  )
+ORDER BY month, i.account_id
+LIMIT sqlc.arg(limit_count);
+
+-- name: CreateInterestPosting :one
+INSERT INTO interest_postings (
+  account_id,
+  month,
+  accrued_micros,
+  amount,
+  carry_micros,
+  journal_id
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+)
+ON CONFLICT (account_id, month) DO NOTHING
+RETURNING *;
+
+-- name: GetLastInterestPosting :one
+SELECT * FROM interest_postings
+WHERE account_id = sqlc.arg(account_id) AND month < sqlc.arg(month)
+ORDER BY month DESC
+LIMIT 1;
+
+-- name: CreateLoan :one
+INSERT INTO loans (
+  account_id,
+  borrower_account_id,
+  income_account_id,
+  principal,
+  rate_bps,
+  term_months,
+  method,
+  currency,
+  late_fee,
+  grace_days,
+  created_by
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
+) RETURNING *;
+
+-- name: GetLoan :one
+SELECT * FROM loans
+WHERE id = $1 LIMIT 1;
+
+-- name: GetLoanForUpdate :one
+SELECT * FROM loans
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: ListOwnerLoans :many
+SELECT l.* FROM loans l
+JOIN accounts a ON a.id = l.borrower_account_id
+WHERE a.owner = $1 AND l.id > $2
+ORDER BY l.id
+LIMIT $3;
+
+-- name: UpdateLoanStatus :one
+UPDATE loans
+SET status = $2, updated_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: CreateLoanInstallment :one
+INSERT INTO loan_installments (
+  loan_id,
+  number,
+  due_date,
+  principal,
+  interest
+) VALUES (
+  $1, $2, $3, $4, $5
+) RETURNING *;
+
+-- name: ListLoanInstallments :many
+SELECT * FROM loan_installments
+WHERE loan_id = $1
+ORDER BY number;
+
+-- name: ListUnpaidLoanInstallmentsForUpdate :many
+SELECT * FROM loan_installments
+WHERE loan_id = $1 AND status <> 'paid'
+ORDER BY number
+FOR NO KEY UPDATE;
+
+-- name: PayLoanInstallment :one
+UPDATE loan_installments
+SET
+  paid_principal = paid_principal + sqlc.arg(principal),
+  paid_interest = paid_interest + sqlc.arg(interest),
+  paid_penalty = paid_penalty + sqlc.arg(penalty),
+  status = CASE
+    WHEN paid_principal + sqlc.arg(principal) = principal AND paid_interest + sqlc.arg(interest) = interest AND paid_penalty + sqlc.arg(penalty) = penalty THEN 'paid'
+    ELSE status
+  END,
+  updated_at = now()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: ClaimOverdueLoanInstallment :one
+SELECT i.* FROM loan_installments i
+JOIN loans l ON l.id = i.loan_id
+WHERE i.status = 'pending' AND i.due_date + l.grace_days < sqlc.arg(today)::date
+ORDER BY i.due_date
+LIMIT 1
+FOR NO KEY UPDATE OF i SKIP LOCKED;
+
+-- name: ChargeLoanInstallmentPenalty :one
+UPDATE loan_installments
+SET penalty = penalty + $2, status = 'overdue', updated_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: CreateLoanRepayment :one
+INSERT INTO loan_repayments (
+  loan_id,
+  amount,
+  principal,
+  interest,
+  penalty,
+  journal_id
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+) RETURNING *;
+
+-- name: ListLoanRepayments :many
+SELECT * FROM loan_repayments
+WHERE loan_id = sqlc.arg(loan_id) AND id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg(limit_count);
+
+-- name: ListAccountsToSnapshot :many
+SELECT a.id FROM accounts a
+WHERE a.id > sqlc.arg(after_id)
+  AND a.created_at < sqlc.arg(end_of_day)
+  AND NOT EXISTS (
+    SELECT 1 FROM account_balance_snapshots b
+    WHERE b.account_id = a.id AND b.day = sqlc.arg(day)
+  )
+  AND (
+    EXISTS (
+      SELECT 1 FROM entries e
+      WHERE e.account_id = a.id
+        AND e.created_at >= sqlc.arg(end_of_day)::timestamptz - interval '1 day'
+        AND e.created_at < sqlc.arg(end_of_day)
+    )
+    OR NOT EXISTS (
+      SELECT 1 FROM account_balance_snapshots b
+      WHERE b.account_id = a.id
+    )
+  )
+ORDER BY a.id
+LIMIT sqlc.arg(limit_count);
+
+-- name: CreateAccountBalanceSnapshot :one
+INSERT INTO account_balance_snapshots (
+  account_id,
+  day,
+  balance
+) VALUES (
+  $1, $2, $3
+)
+ON CONFLICT (account_id, day) DO NOTHING
+RETURNING *;
+
+-- name: LockAccountingPeriods :exec
+SELECT pg_advisory_xact_lock(hashtext('accounting_periods'));
+
+-- name: LockAccountingPeriodsShared :exec
+SELECT pg_advisory_xact_lock_shared(hashtext('accounting_periods'));
+
+-- name: GetClosedPeriod :one
+SELECT * FROM accounting_periods
+WHERE start_day <= sqlc.arg(start_day) AND end_day >= sqlc.arg(end_day)
+ORDER BY end_day - start_day DESC
+LIMIT 1;
+
+-- name: CreateAccountingPeriod :one
+INSERT INTO accounting_periods (
+  kind,
+  start_day,
+  end_day,
+  closed_by
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING *;
+
+-- name: GetAccountingPeriod :one
+SELECT * FROM accounting_periods
+WHERE id = $1 LIMIT 1;
+
+-- name: ListAccountingPeriods :many
+SELECT * FROM accounting_periods
+WHERE id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg(limit_count);
+
+-- name: ListUnbalancedJournals :many
+SELECT DISTINCT j.id FROM journals j
+JOIN entries e ON e.journal_id = j.id
+JOIN accounts a ON a.id = e.account_id
+WHERE j.effective_at >= sqlc.arg(from_at) AND j.effective_at < sqlc.arg(to_at)
+GROUP BY j.id, a.currency
+HAVING SUM(e.amount) <> 0
+ORDER BY j.id
+LIMIT sqlc.arg(limit_count);
+
+-- name: GetTrialBalanceAt :many
+SELECT
+  a.currency,
+  a.type,
+  COUNT(*)::int AS accounts,
+  COALESCE(SUM(-b.balance) FILTER (WHERE b.balance < 0), 0)::bigint AS debits,
+  COALESCE(SUM(b.balance) FILTER (WHERE b.balance > 0), 0)::bigint AS credits
+FROM accounts a
+CROSS JOIN LATERAL (
+  SELECT (CASE
+    WHEN s.balance IS NULL THEN a.balance - COALESCE((
+      SELECT SUM(e.amount) FROM entries e
+      WHERE e.account_id = a.id AND e.created_at >= sqlc.arg(at)
+    ), 0)
+    ELSE s.balance + COALESCE((
+      SELECT SUM(e.amount) FROM entries e
+      WHERE e.account_id = a.id AND e.created_at >= s.end_at AND e.created_at < sqlc.arg(at)
+    ), 0)
+  END)::bigint AS balance
+  FROM (SELECT 1) one
+  LEFT JOIN LATERAL (
+    SELECT b.balance, (b.day + 1)::timestamp AT TIME ZONE 'UTC' AS end_at
+    FROM account_balance_snapshots b
+    WHERE b.account_id = a.id AND b.day < (sqlc.arg(at)::timestamptz AT TIME ZONE 'UTC')::date
+    ORDER BY b.day DESC
+    LIMIT 1
+  ) s ON true
+) b
+WHERE a.created_at < sqlc.arg(at)
+GROUP BY a.currency, a.type
+ORDER BY a.currency, a.type;
+
+-- name: CreatePeriodTrialBalance :one
+INSERT INTO period_trial_balances (
+  period_id,
+  currency,
+  type,
+  accounts,
+  debits,
+  credits
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+) RETURNING *;
+
+-- name: ListPeriodTrialBalances :many
+SELECT * FROM period_trial_balances
+WHERE period_id = $1
+ORDER BY currency, type;
+
+-- name: DeleteAccountBalanceSnapshotsFrom :exec
+DELETE FROM account_balance_snapshots
+WHERE account_id = $1 AND day >= $2;
+
+-- name: GetInternalAccount :one
+SELECT * FROM accounts
+WHERE code = $1 AND currency = $2
+LIMIT 1;
+
+-- name: ListInternalAccounts :many
+SELECT * FROM accounts
+WHERE code IS NOT NULL
+ORDER BY currency, code;
+
+-- name: LockAuditLog :exec
+SELECT pg_advisory_xact_lock(hashtext('audit_log'));
+
+-- name: GetLastAuditLogHash :one
+SELECT hash FROM audit_log
+ORDER BY id DESC
+LIMIT 1;
+
+-- name: CreateAuditLog :one
+INSERT INTO audit_log (
+  actor,
+  action,
+  target_type,
+  target_id,
+  before,
+  after,
+  ip,
+  user_agent,
+  request_id,
+  prev_hash,
+  hash,
+  created_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+) RETURNING *;
+
+-- name: ListAuditLog :many
+SELECT * FROM audit_log
+WHERE (sqlc.narg(actor)::varchar IS NULL OR actor = sqlc.narg(actor))
+  AND (sqlc.narg(action)::varchar IS NULL OR action = sqlc.narg(action))
+  AND (sqlc.narg(target_type)::varchar IS NULL OR target_type = sqlc.narg(target_type))
+  AND (sqlc.narg(target_id)::varchar IS NULL OR target_id = sqlc.narg(target_id))
+  AND (sqlc.narg(from_at)::timestamptz IS NULL OR created_at >= sqlc.narg(from_at))
+  AND (sqlc.narg(to_at)::timestamptz IS NULL OR created_at < sqlc.narg(to_at))
+  AND id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg(limit_count);
+
+-- name: CreateApiKey :one
+INSERT INTO api_keys (
+  owner,
+  name,
+  prefix,
+  hashed_secret,
+  scopes,
+  allowed_ips,
+  expires_at,
+  signing_secret
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8
+) RETURNING *;
+
+-- name: GetApiKey :one
+SELECT * FROM api_keys
+WHERE id = $1 LIMIT 1;
+
+-- name: GetApiKeyForUpdate :one
+SELECT * FROM api_keys
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: GetApiKeyByPrefix :one
+SELECT k.*, u.role AS owner_role
+FROM api_keys k
+JOIN users u ON u.username = k.owner
+WHERE k.prefix = $1 LIMIT 1;
+
+-- name: ListApiKeys :many
+SELECT * FROM api_keys
+WHERE owner = $1
+ORDER BY id;
+
+-- name: RevokeApiKey :one
+UPDATE api_keys
+SET revoked_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: TouchApiKey :exec
+UPDATE api_keys
+SET last_used_at = now()
+WHERE id = $1
+  AND (last_used_at IS NULL OR last_used_at < now() - interval '1 minute');
+
+-- name: RememberRequestNonce :execrows
+WITH pruned AS (
+  DELETE FROM request_nonces
+  WHERE api_key_id = sqlc.arg(api_key_id) AND expires_at < now()
+)
+INSERT INTO request_nonces (
+  api_key_id,
+  nonce,
+  expires_at
+) VALUES (
+  sqlc.arg(api_key_id), sqlc.arg(nonce), sqlc.arg(expires_at)
+)
+ON CONFLICT DO NOTHING;
+
+-- name: TakeRateLimitToken :one
+INSERT INTO rate_limit_buckets AS b (
+  key,
+  full_at
+) VALUES (
+  sqlc.arg(key), sqlc.arg(now)::timestamptz + sqlc.arg(interval_us)::bigint * interval '1 microsecond'
+)
+ON CONFLICT (key) DO UPDATE
+SET full_at = GREATEST(b.full_at, sqlc.arg(now)) + sqlc.arg(interval_us)::bigint * interval '1 microsecond'
+WHERE GREATEST(b.full_at, sqlc.arg(now)) + sqlc.arg(interval_us)::bigint * interval '1 microsecond'
+  <= sqlc.arg(now)::timestamptz + sqlc.arg(period_us)::bigint * interval '1 microsecond'
+RETURNING full_at;
+
+-- name: GetRateLimitBucket :one
+SELECT full_at FROM rate_limit_buckets
+WHERE key = $1 LIMIT 1;
+
+-- name: DeleteFullRateLimitBuckets :execrows
+DELETE FROM rate_limit_buckets
+WHERE full_at < $1;
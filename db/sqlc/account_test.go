@@ -11,10 +11,17 @@ import (
 )
 
 func createRandomAccount(t *testing.T) Account {
+	return createRandomAccountWithCurrency(t, util.RanddomCurrency())
+}
+
+func createRandomAccountWithCurrency(t *testing.T, currency string) Account {
+	user := createRandomUser(t)
+
+	// funded so that the transfers of the tests never run out of money
 	arg := CreateAccountParams{
-		Owner:    util.RandomOwner(),
-		Balance:  util.RandomMoney(),
-		Currency: util.RanddomCurrency(),
+		Owner:    user.Username,
+		Balance:  util.RandomInt(1000, 2000),
+		Currency: currency,
 	}
 
 	account, err := testQueries.CreateAccount(context.Background(), arg)
@@ -24,6 +31,8 @@ func createRandomAccount(t *testing.T) Account {
 	require.Equal(t, arg.Owner, account.Owner)
 	require.Equal(t, arg.Balance, account.Balance)
 	require.Equal(t, arg.Currency, account.Currency)
+	require.Equal(t, AccountStatusActive, account.Status)
+	require.Equal(t, AccountTypeLiability, account.Type)
 
 	require.NotZero(t, account.ID)
 	require.NotZero(t, account.CreatedAt)
@@ -136,11 +145,21 @@ func TestDeleteAccount(t *testing.T) {
 }
 
 func TestListAccounts(t *testing.T) {
+	owner := createRandomUser(t).Username
+
+	var created []Account
 	for i := 0; i < 10; i++ {
-		createRandomAccount(t)
+		account, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+			Owner:    owner,
+			Balance:  util.RandomMoney(),
+			Currency: util.RanddomCurrency(),
+		})
+		require.NoError(t, err)
+		created = append(created, account)
 	}
 
 	arg := ListAccountsParams{
+		Owner:  owner,
 		Limit:  5,
 		Offset: 5,
 	}
@@ -150,8 +169,45 @@ func TestListAccounts(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, arg.Limit, int32(len(accounts)))
 
-	for _, account := range accounts {
+	for i, account := range accounts {
 		require.NotEmpty(t, account)
+		require.Equal(t, owner, account.Owner)
+		require.Equal(t, created[5+i].ID, account.ID)
+	}
+}
+
+func TestListAccountsAfter(t *testing.T) {
+	owner := createRandomUser(t).Username
+
+	var created []Account
+	for i := 0; i < 7; i++ {
+		account, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+			Owner:    owner,
+			Balance:  util.RandomMoney(),
+			Currency: util.RanddomCurrency(),
+		})
+		require.NoError(t, err)
+		created = append(created, account)
+	}
+
+	page1, err := testQueries.ListAccountsAfter(context.Background(), ListAccountsAfterParams{
+		Owner:      owner,
+		AfterID:    0,
+		LimitCount: 5,
+	})
+	require.NoError(t, err)
+	require.Len(t, page1, 5)
+
+	page2, err := testQueries.ListAccountsAfter(context.Background(), ListAccountsAfterParams{
+		Owner:      owner,
+		AfterID:    page1[len(page1)-1].ID,
+		LimitCount: 5,
+	})
+	require.NoError(t, err)
+	require.Len(t, page2, 2)
+
+	for i, account := range append(page1, page2...) {
+		require.Equal(t, created[i].ID, account.ID)
 	}
 }
 
@@ -186,6 +242,30 @@ func TestListEntries(t *testing.T) {
 	}
 }
 
+func TestListAccountEntries(t *testing.T) {
+	account := createRandomAccount(t)
+
+	var created []Entry
+	for i := 0; i < 3; i++ {
+		entrie, err := testQueries.CreateEntrie(context.Background(), CreateEntrieParams{
+			AccountID: account.ID,
+			Amount:    util.RandomMoney(),
+		})
+		require.NoError(t, err)
+		created = append(created, entrie)
+	}
+
+	entries, err := testQueries.ListAccountEntries(context.Background(), ListAccountEntriesParams{
+		AccountID:  account.ID,
+		AfterID:    created[0].ID,
+		LimitCount: 5,
+	})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	require.Equal(t, created[1].ID, entries[0].ID)
+	require.Equal(t, created[2].ID, entries[1].ID)
+}
+
 func TestUpdateEntrie(t *testing.T) {
 	entrie1 := createRandomEntrie(t)
 
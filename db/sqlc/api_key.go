@@ -0,0 +1,76 @@
+package db
+
+import (
+	"context"
+	"strconv"
+)
+
+// auditedApiKey is what the audit log keeps of a key, never its hashed secret
+func auditedApiKey(key ApiKey) map[string]any {
+	return map[string]any{
+		"owner":       key.Owner,
+		"name":        key.Name,
+		"prefix":      key.Prefix,
+		"scopes":      key.Scopes,
+		"allowed_ips": key.AllowedIps,
+		"expires_at":  key.ExpiresAt,
+		"revoked":     key.RevokedAt.Valid,
+	}
+}
+
+// CreateApiKeyTx stores a new API key and records it in the audit log
+func (store *Store) CreateApiKeyTx(ctx context.Context, arg CreateApiKeyParams) (ApiKey, error) {
+	var key ApiKey
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		key, err = q.CreateApiKey(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		_, err = recordAudit(ctx, q, AuditEntry{
+			Action:     AuditApiKeyCreate,
+			TargetType: AuditTargetApiKey,
+			TargetID:   strconv.FormatInt(key.ID, 10),
+			After:      auditedApiKey(key),
+		})
+		return err
+	})
+
+	return key, err
+}
+
+// RevokeApiKeyTx revokes an API key and records it in the audit log,
+// revoking a key again is a no-op that records nothing
+func (store *Store) RevokeApiKeyTx(ctx context.Context, id int64) (ApiKey, error) {
+	var key ApiKey
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		current, err := q.GetApiKeyForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		key = current
+		if current.RevokedAt.Valid {
+			return nil
+		}
+
+		key, err = q.RevokeApiKey(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		_, err = recordAudit(ctx, q, AuditEntry{
+			Action:     AuditApiKeyRevoke,
+			TargetType: AuditTargetApiKey,
+			TargetID:   strconv.FormatInt(key.ID, 10),
+			Before:     auditedApiKey(current),
+			After:      auditedApiKey(key),
+		})
+		return err
+	})
+
+	return key, err
+}
@@ -0,0 +1,131 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomApiKey(t *testing.T, owner string) ApiKey {
+	store := NewStore(testDb)
+
+	arg := CreateApiKeyParams{
+		Owner:        owner,
+		Name:         util.RandomOwner(),
+		Prefix:       util.RandomString(8),
+		HashedSecret: util.RandomString(64),
+		Scopes:       []string{"accounts:read", "transfers:write"},
+		AllowedIps:   []string{"192.0.2.0/24"},
+		ExpiresAt:    time.Now().Add(24 * time.Hour),
+	}
+
+	key, err := store.CreateApiKeyTx(testAuditContext(owner), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Owner, key.Owner)
+	require.Equal(t, arg.Prefix, key.Prefix)
+	require.Equal(t, arg.Scopes, key.Scopes)
+	require.Equal(t, arg.AllowedIps, key.AllowedIps)
+	require.WithinDuration(t, arg.ExpiresAt, key.ExpiresAt, time.Second)
+	require.False(t, key.LastUsedAt.Valid)
+	require.False(t, key.RevokedAt.Valid)
+
+	return key
+}
+
+func TestCreateApiKeyTx(t *testing.T) {
+	user := createRandomUser(t)
+	key := createRandomApiKey(t, user.Username)
+
+	log := lastAuditLog(t, AuditTargetApiKey, key.ID)
+	require.Equal(t, AuditApiKeyCreate, log.Action)
+	require.Equal(t, user.Username, log.Actor)
+	require.NotContains(t, string(log.After), key.HashedSecret)
+
+	row, err := testQueries.GetApiKeyByPrefix(context.Background(), key.Prefix)
+	require.NoError(t, err)
+	require.Equal(t, key.ID, row.ID)
+	require.Equal(t, user.Role, row.OwnerRole)
+
+	_, err = testQueries.GetApiKeyByPrefix(context.Background(), util.RandomString(8))
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestTouchApiKey(t *testing.T) {
+	user := createRandomUser(t)
+	key := createRandomApiKey(t, user.Username)
+
+	require.NoError(t, testQueries.TouchApiKey(context.Background(), key.ID))
+
+	touched, err := testQueries.GetApiKey(context.Background(), key.ID)
+	require.NoError(t, err)
+	require.True(t, touched.LastUsedAt.Valid)
+
+	// a key used again within a minute is not written again
+	require.NoError(t, testQueries.TouchApiKey(context.Background(), key.ID))
+
+	again, err := testQueries.GetApiKey(context.Background(), key.ID)
+	require.NoError(t, err)
+	require.Equal(t, touched.LastUsedAt.Time, again.LastUsedAt.Time)
+}
+
+func TestRevokeApiKeyTx(t *testing.T) {
+	store := NewStore(testDb)
+	user := createRandomUser(t)
+	key := createRandomApiKey(t, user.Username)
+
+	revoked, err := store.RevokeApiKeyTx(testAuditContext(user.Username), key.ID)
+	require.NoError(t, err)
+	require.True(t, revoked.RevokedAt.Valid)
+
+	// revoking it again keeps the first revocation and records nothing
+	again, err := store.RevokeApiKeyTx(context.Background(), key.ID)
+	require.NoError(t, err)
+	require.Equal(t, revoked.RevokedAt.Time, again.RevokedAt.Time)
+
+	log := lastAuditLog(t, AuditTargetApiKey, key.ID)
+	require.Equal(t, AuditApiKeyRevoke, log.Action)
+	require.Equal(t, user.Username, log.Actor)
+
+	var before, after map[string]any
+	require.NoError(t, json.Unmarshal(log.Before, &before))
+	require.NoError(t, json.Unmarshal(log.After, &after))
+	require.Equal(t, false, before["revoked"])
+	require.Equal(t, true, after["revoked"])
+
+	_, err = store.RevokeApiKeyTx(context.Background(), 0)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestRememberRequestNonce(t *testing.T) {
+	user := createRandomUser(t)
+	key := createRandomApiKey(t, user.Username)
+	other := createRandomApiKey(t, user.Username)
+
+	arg := RememberRequestNonceParams{
+		ApiKeyID:  key.ID,
+		Nonce:     util.RandomString(32),
+		ExpiresAt: time.Now().Add(time.Minute),
+	}
+
+	remembered, err := testQueries.RememberRequestNonce(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), remembered)
+
+	// the same nonce is a replay, with another key it is not
+	remembered, err = testQueries.RememberRequestNonce(context.Background(), arg)
+	require.NoError(t, err)
+	require.Zero(t, remembered)
+
+	remembered, err = testQueries.RememberRequestNonce(context.Background(), RememberRequestNonceParams{
+		ApiKeyID:  other.ID,
+		Nonce:     arg.Nonce,
+		ExpiresAt: arg.ExpiresAt,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), remembered)
+}
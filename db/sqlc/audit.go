@@ -0,0 +1,245 @@
+package db
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"simplebank/audit"
+	"strconv"
+	"time"
+)
+
+// Audit log actions
+const (
+	AuditAccountCreate     = "account.create"
+	AuditAccountUpdate     = "account.update"
+	AuditAccountStatus     = "account.status"
+	AuditAccountDelete     = "account.delete"
+	AuditAdjustment        = "journal.adjustment"
+	AuditPeriodClose       = "period.close"
+	AuditLoanCreate        = "loan.create"
+	AuditTransferReview    = "transfer.review"
+	AuditUserRole          = "user.role"
+	AuditApiKeyCreate      = "api_key.create"
+	AuditApiKeyRevoke      = "api_key.revoke"
+	AuditAccountTier       = "account.tier"
+	AuditPricingRuleCreate = "pricing_rule.create"
+	AuditPricingRuleUpdate = "pricing_rule.update"
+	// AuditRequest is written by the API for every state-changing request, and every request made with an API key, once it is served
+	AuditRequest = "http.request"
+)
+
+// Audit log target types
+const (
+	AuditTargetAccount        = "account"
+	AuditTargetJournal        = "journal"
+	AuditTargetPeriod         = "accounting_period"
+	AuditTargetLoan           = "loan"
+	AuditTargetRiskAssessment = "risk_assessment"
+	AuditTargetRoute          = "route"
+	AuditTargetUser           = "user"
+	AuditTargetApiKey         = "api_key"
+	AuditTargetPricingRule    = "pricing_rule"
+)
+
+// auditVerifyPageSize bounds the rows read at once while verifying the chain
+const auditVerifyPageSize = 1000
+
+var ErrAuditChainBroken = errors.New("audit log chain is broken")
+
+// AuditEntry is an action on a target, Before and After are marshalled to JSON, nil for a target that did not exist
+type AuditEntry struct {
+	Action     string
+	TargetType string
+	TargetID   string
+	Before     any
+	After      any
+}
+
+// RecordAudit appends an entry to the audit log in its own tx, the actor comes from ctx
+func (store *Store) RecordAudit(ctx context.Context, entry AuditEntry) (AuditLog, error) {
+	var log AuditLog
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		log, err = recordAudit(ctx, q, entry)
+		return err
+	})
+
+	return log, err
+}
+
+// recordAudit appends an entry to the audit log inside the tx of q; the lock orders the rows
+// so that each one hashes the row committed before it, call it last so that the tx commits right after
+func recordAudit(ctx context.Context, q *Queries, entry AuditEntry) (AuditLog, error) {
+	before, err := json.Marshal(entry.Before)
+	if err != nil {
+		return AuditLog{}, err
+	}
+
+	after, err := json.Marshal(entry.After)
+	if err != nil {
+		return AuditLog{}, err
+	}
+
+	if err := q.LockAuditLog(ctx); err != nil {
+		return AuditLog{}, err
+	}
+
+	prevHash, err := q.GetLastAuditLogHash(ctx)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return AuditLog{}, err
+	}
+
+	actor := audit.FromContext(ctx)
+	log := AuditLog{
+		Actor:      actor.Username,
+		Action:     entry.Action,
+		TargetType: entry.TargetType,
+		TargetID:   entry.TargetID,
+		Before:     before,
+		After:      after,
+		IP:         actor.IP,
+		UserAgent:  actor.UserAgent,
+		RequestID:  actor.RequestID,
+		PrevHash:   prevHash,
+		// postgres keeps microseconds, the hash must survive the round trip
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+
+	return q.CreateAuditLog(ctx, CreateAuditLogParams{
+		Actor:      log.Actor,
+		Action:     log.Action,
+		TargetType: log.TargetType,
+		TargetID:   log.TargetID,
+		Before:     log.Before,
+		After:      log.After,
+		IP:         log.IP,
+		UserAgent:  log.UserAgent,
+		RequestID:  log.RequestID,
+		PrevHash:   log.PrevHash,
+		Hash:       AuditHash(log),
+		CreatedAt:  log.CreatedAt,
+	})
+}
+
+// AuditHash returns the hex SHA-256 of the previous hash and every field of log but its id and hash,
+// each field is length-prefixed so that no two rows hash the same fields
+func AuditHash(log AuditLog) string {
+	h := sha256.New()
+
+	for _, field := range []string{
+		log.PrevHash,
+		log.Actor,
+		log.Action,
+		log.TargetType,
+		log.TargetID,
+		string(log.Before),
+		string(log.After),
+		log.IP,
+		log.UserAgent,
+		log.RequestID,
+		log.CreatedAt.UTC().Format(time.RFC3339Nano),
+	} {
+		h.Write([]byte(strconv.Itoa(len(field))))
+		h.Write([]byte{':'})
+		h.Write([]byte(field))
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// AuditVerification is how far the chain was checked
+type AuditVerification struct {
+	Rows     int64  `json:"rows"`
+	LastID   int64  `json:"last_id"`
+	LastHash string `json:"last_hash"`
+}
+
+// VerifyAuditLog walks the audit log in id order and checks that every row hashes its fields and the row before it,
+// it fails with ErrAuditChainBroken at the first row that does not
+func (store *Store) VerifyAuditLog(ctx context.Context) (AuditVerification, error) {
+	var verification AuditVerification
+
+	for {
+		logs, err := store.ListAuditLog(ctx, ListAuditLogParams{
+			AfterID:    verification.LastID,
+			LimitCount: auditVerifyPageSize,
+		})
+		if err != nil {
+			return verification, err
+		}
+
+		for _, log := range logs {
+			if log.PrevHash != verification.LastHash {
+				return verification, fmt.Errorf("%w: row %d does not follow row %d", ErrAuditChainBroken, log.ID, verification.LastID)
+			}
+
+			if AuditHash(log) != log.Hash {
+				return verification, fmt.Errorf("%w: row %d does not match its hash", ErrAuditChainBroken, log.ID)
+			}
+
+			verification.Rows++
+			verification.LastID = log.ID
+			verification.LastHash = log.Hash
+		}
+
+		if len(logs) < auditVerifyPageSize {
+			return verification, nil
+		}
+	}
+}
+
+// UpdateAccountTx sets the balance of an account outside of any journal and records the change in the audit log
+func (store *Store) UpdateAccountTx(ctx context.Context, arg UpdateAccountParams) (Account, error) {
+	var account Account
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		current, err := q.GetAccountForUpdate(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+
+		account, err = q.UpdateAccount(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		_, err = recordAudit(ctx, q, AuditEntry{
+			Action:     AuditAccountUpdate,
+			TargetType: AuditTargetAccount,
+			TargetID:   strconv.FormatInt(account.ID, 10),
+			Before:     current,
+			After:      account,
+		})
+		return err
+	})
+
+	return account, err
+}
+
+// DeleteAccountTx deletes an account and records it in the audit log
+func (store *Store) DeleteAccountTx(ctx context.Context, id int64) error {
+	return store.execTx(ctx, func(q *Queries) error {
+		current, err := q.GetAccountForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if err := q.DeleteAccount(ctx, id); err != nil {
+			return err
+		}
+
+		_, err = recordAudit(ctx, q, AuditEntry{
+			Action:     AuditAccountDelete,
+			TargetType: AuditTargetAccount,
+			TargetID:   strconv.FormatInt(id, 10),
+			Before:     current,
+		})
+		return err
+	})
+}
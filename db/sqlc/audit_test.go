@@ -0,0 +1,154 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"simplebank/audit"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditHash(t *testing.T) {
+	log := AuditLog{
+		Actor:      "alice",
+		Action:     AuditAccountCreate,
+		TargetType: AuditTargetAccount,
+		TargetID:   "1",
+		Before:     json.RawMessage(`null`),
+		After:      json.RawMessage(`{"id":1}`),
+		PrevHash:   "abc",
+		CreatedAt:  time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	hash := AuditHash(log)
+	require.Len(t, hash, 64)
+	require.Equal(t, hash, AuditHash(log))
+
+	changed := log
+	changed.PrevHash = "abd"
+	require.NotEqual(t, hash, AuditHash(changed))
+
+	// moving a byte between fields changes the hash too
+	changed = log
+	changed.Actor, changed.Action = "alic", "e"+log.Action
+	require.NotEqual(t, hash, AuditHash(changed))
+}
+
+func testAuditContext(username string) context.Context {
+	return audit.WithActor(context.Background(), audit.Actor{
+		Username:  username,
+		IP:        "192.0.2.1",
+		UserAgent: "audit-test",
+		RequestID: "req-" + username,
+	})
+}
+
+func lastAuditLog(t *testing.T, targetType string, targetID int64) AuditLog {
+	logs, err := testQueries.ListAuditLog(context.Background(), ListAuditLogParams{
+		TargetType: sql.NullString{String: targetType, Valid: true},
+		TargetID:   sql.NullString{String: strconv.FormatInt(targetID, 10), Valid: true},
+		LimitCount: 100,
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, logs)
+
+	return logs[len(logs)-1]
+}
+
+func TestCreateAccountTxAudit(t *testing.T) {
+	store := NewStore(testDb)
+	user := createRandomUser(t)
+
+	account, err := store.CreateAccountTx(testAuditContext(user.Username), CreateAccountParams{
+		Owner:    user.Username,
+		Currency: "USD",
+	})
+	require.NoError(t, err)
+
+	log := lastAuditLog(t, AuditTargetAccount, account.ID)
+	require.Equal(t, AuditAccountCreate, log.Action)
+	require.Equal(t, user.Username, log.Actor)
+	require.Equal(t, "192.0.2.1", log.IP)
+	require.Equal(t, "audit-test", log.UserAgent)
+	require.Equal(t, "req-"+user.Username, log.RequestID)
+	require.JSONEq(t, `null`, string(log.Before))
+	require.Equal(t, AuditHash(log), log.Hash)
+
+	var after Account
+	require.NoError(t, json.Unmarshal(log.After, &after))
+	require.Equal(t, account.ID, after.ID)
+}
+
+func TestUpdateAccountTxAudit(t *testing.T) {
+	store := NewStore(testDb)
+	account := createRandomAccount(t)
+
+	updated, err := store.UpdateAccountTx(testAuditContext("root"), UpdateAccountParams{
+		ID:      account.ID,
+		Balance: account.Balance + 10,
+	})
+	require.NoError(t, err)
+	require.Equal(t, account.Balance+10, updated.Balance)
+
+	log := lastAuditLog(t, AuditTargetAccount, account.ID)
+	require.Equal(t, AuditAccountUpdate, log.Action)
+	require.Equal(t, "root", log.Actor)
+
+	var before, after Account
+	require.NoError(t, json.Unmarshal(log.Before, &before))
+	require.NoError(t, json.Unmarshal(log.After, &after))
+	require.Equal(t, account.Balance, before.Balance)
+	require.Equal(t, updated.Balance, after.Balance)
+}
+
+func TestDeleteAccountTxAudit(t *testing.T) {
+	store := NewStore(testDb)
+	account := createRandomAccount(t)
+
+	require.NoError(t, store.DeleteAccountTx(context.Background(), account.ID))
+
+	_, err := testQueries.GetAccount(context.Background(), account.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	log := lastAuditLog(t, AuditTargetAccount, account.ID)
+	require.Equal(t, AuditAccountDelete, log.Action)
+	require.Equal(t, audit.SystemActor, log.Actor)
+	require.JSONEq(t, `null`, string(log.After))
+}
+
+func TestAuditLogIsAppendOnly(t *testing.T) {
+	store := NewStore(testDb)
+
+	log, err := store.RecordAudit(testAuditContext("root"), AuditEntry{
+		Action:     AuditRequest,
+		TargetType: AuditTargetRoute,
+		TargetID:   "/accounts",
+	})
+	require.NoError(t, err)
+
+	_, err = testDb.Exec("UPDATE audit_log SET actor = 'mallory' WHERE id = $1", log.ID)
+	require.Error(t, err)
+
+	_, err = testDb.Exec("DELETE FROM audit_log WHERE id = $1", log.ID)
+	require.Error(t, err)
+}
+
+func TestVerifyAuditLog(t *testing.T) {
+	store := NewStore(testDb)
+
+	log, err := store.RecordAudit(testAuditContext("root"), AuditEntry{
+		Action:     AuditRequest,
+		TargetType: AuditTargetRoute,
+		TargetID:   "/accounts",
+	})
+	require.NoError(t, err)
+
+	verification, err := store.VerifyAuditLog(context.Background())
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, verification.LastID, log.ID)
+	require.Positive(t, verification.Rows)
+}
@@ -0,0 +1,83 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// SettleDelay is how long after the end of a day the day is snapshotted and can be closed: a transaction open
+// at midnight posts entries dated before it, so a day is only final once those have committed
+const SettleDelay = 5 * time.Minute
+
+// SnapshotAccountBalanceParams snapshots the balance of an account at the end of one UTC day
+type SnapshotAccountBalanceParams struct {
+	AccountID int64
+	Day       time.Time
+}
+
+// SnapshotAccountBalance records the ledger balance of the account at the end of the day, from the previous
+// snapshot and the entries since; created is false when the day has a snapshot already, so reruns are idempotent
+// the day must have ended SettleDelay ago, so that no transaction posting entries in it is still open
+func (store *Store) SnapshotAccountBalance(ctx context.Context, arg SnapshotAccountBalanceParams) (snapshot AccountBalanceSnapshot, created bool, err error) {
+	day := arg.Day.UTC().Truncate(24 * time.Hour)
+
+	balance, err := store.GetAccountBalanceAt(ctx, GetAccountBalanceAtParams{
+		AccountID: arg.AccountID,
+		At:        day.AddDate(0, 0, 1),
+	})
+	if err != nil {
+		return snapshot, false, err
+	}
+
+	snapshot, err = store.CreateAccountBalanceSnapshot(ctx, CreateAccountBalanceSnapshotParams{
+		AccountID: arg.AccountID,
+		Day:       day,
+		Balance:   balance,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return snapshot, false, nil
+	}
+
+	return snapshot, err == nil, err
+}
+
+// snapshotPageSize bounds the accounts listed at once when snapshotting a day
+const snapshotPageSize = 100
+
+// SnapshotDay snapshots the day for every account that had entries on it or has no snapshot yet,
+// the accounts that did not move keep their previous snapshot; it returns how many snapshots it wrote
+func (store *Store) SnapshotDay(ctx context.Context, day time.Time) (int, error) {
+	day = day.UTC().Truncate(24 * time.Hour)
+
+	var snapshots int
+	var afterID int64
+	for {
+		ids, err := store.ListAccountsToSnapshot(ctx, ListAccountsToSnapshotParams{
+			AfterID:    afterID,
+			EndOfDay:   day.AddDate(0, 0, 1),
+			Day:        day,
+			LimitCount: snapshotPageSize,
+		})
+		if err != nil {
+			return snapshots, err
+		}
+
+		for _, id := range ids {
+			_, ok, err := store.SnapshotAccountBalance(ctx, SnapshotAccountBalanceParams{AccountID: id, Day: day})
+			if err != nil {
+				return snapshots, err
+			}
+
+			if ok {
+				snapshots++
+			}
+		}
+
+		if len(ids) < snapshotPageSize {
+			return snapshots, nil
+		}
+		afterID = ids[len(ids)-1]
+	}
+}
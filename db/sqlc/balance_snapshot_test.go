@@ -0,0 +1,60 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotAccountBalance(t *testing.T) {
+	store := NewStore(testDb)
+
+	account := createRandomAccountWithCurrency(t, "USD")
+	other := createRandomAccountWithCurrency(t, "USD")
+	before := time.Now()
+
+	_, err := store.PostJournalTx(context.Background(), PostJournalTxParams{
+		Kind: "test",
+		Legs: []Leg{
+			{AccountID: account.ID, Amount: -10},
+			{AccountID: other.ID, Amount: 10},
+		},
+	})
+	require.NoError(t, err)
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	snapshot, created, err := store.SnapshotAccountBalance(context.Background(), SnapshotAccountBalanceParams{
+		AccountID: account.ID,
+		Day:       today,
+	})
+	require.NoError(t, err)
+	require.True(t, created)
+	require.Equal(t, account.ID, snapshot.AccountID)
+	require.True(t, today.Equal(snapshot.Day))
+	require.Equal(t, account.Balance-10, snapshot.Balance)
+
+	_, created, err = store.SnapshotAccountBalance(context.Background(), SnapshotAccountBalanceParams{
+		AccountID: account.ID,
+		Day:       today,
+	})
+	require.NoError(t, err)
+	require.False(t, created)
+
+	// without a snapshot before it, the balance is worked back from the current one
+	balance, err := testQueries.GetAccountBalanceAt(context.Background(), GetAccountBalanceAtParams{
+		AccountID: account.ID,
+		At:        before,
+	})
+	require.NoError(t, err)
+	require.Equal(t, account.Balance, balance)
+
+	// after the day, the balance starts from its snapshot
+	balance, err = testQueries.GetAccountBalanceAt(context.Background(), GetAccountBalanceAtParams{
+		AccountID: account.ID,
+		At:        today.Add(36 * time.Hour),
+	})
+	require.NoError(t, err)
+	require.Equal(t, account.Balance-10, balance)
+}
@@ -0,0 +1,219 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Hold statuses
+const (
+	HoldAuthorized = "authorized"
+	HoldCaptured   = "captured"
+	HoldVoided     = "voided"
+	HoldExpired    = "expired"
+)
+
+var (
+	ErrHoldNotAuthorized  = errors.New("hold is not authorized")
+	ErrCaptureExceedsHold = errors.New("capture exceeds the held amount")
+)
+
+// AuthorizeHoldTxParams reserves Amount of the account for a later transfer to ToAccountID
+type AuthorizeHoldTxParams struct {
+	AccountID   int64
+	ToAccountID int64
+	Amount      int64
+	Currency    string
+	Description string
+	ExpiresAt   time.Time
+}
+
+// HoldTxResult is the hold after the tx and the account it holds funds of
+type HoldTxResult struct {
+	Hold    Hold    `json:"hold"`
+	Account Account `json:"account"`
+	// Transfer is the transfer a capture posted
+	Transfer *TransferTxResult `json:"transfer,omitempty"`
+}
+
+// AuthorizeHoldTx reserves funds of an account: they leave its available balance but stay in its ledger balance
+// until the hold is captured, voided or expires
+func (store *Store) AuthorizeHoldTx(ctx context.Context, arg AuthorizeHoldTxParams) (HoldTxResult, error) {
+	var result HoldTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		accounts, fee, err := lockTransferAccounts(ctx, q, arg.AccountID, arg.ToAccountID, arg.Amount)
+		if err != nil {
+			return err
+		}
+
+		// the fee the capture will charge is held too, so that capturing the hold cannot overdraw the account
+		if failure := checkTransfer(accounts[arg.AccountID], accounts[arg.ToAccountID], arg.Currency, arg.Amount+fee.Amount); failure != nil {
+			return failure
+		}
+
+		result.Account, err = q.AddAccountHeldBalance(ctx, AddAccountHeldBalanceParams{
+			ID:     arg.AccountID,
+			Amount: arg.Amount + fee.Amount,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Hold, err = q.CreateHold(ctx, CreateHoldParams{
+			AccountID:   arg.AccountID,
+			ToAccountID: arg.ToAccountID,
+			Amount:      arg.Amount,
+			Currency:    arg.Currency,
+			Description: arg.Description,
+			ExpiresAt:   arg.ExpiresAt,
+			Fee:         fee.Amount,
+		})
+		return err
+	})
+
+	return result, err
+}
+
+// CaptureHoldTxParams posts Amount of a hold, the rest stays held for later captures
+type CaptureHoldTxParams struct {
+	ID     int64
+	Amount int64
+}
+
+// CaptureHoldTx posts part or all of what a hold reserves as a transfer to the account of the hold
+// the hold is captured once all of it is posted
+// the captures are not screened again, they move no more than the hold screened with ScreenDeferredTransfer
+// when it was authorized and only to its account
+func (store *Store) CaptureHoldTx(ctx context.Context, arg CaptureHoldTxParams) (HoldTxResult, error) {
+	var result HoldTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		hold, err := q.GetHoldForUpdate(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+
+		if hold.Status != HoldAuthorized {
+			return fmt.Errorf("%w: hold %d is %s", ErrHoldNotAuthorized, hold.ID, hold.Status)
+		}
+
+		// the worker may not have expired it yet
+		if !hold.ExpiresAt.After(time.Now()) {
+			return fmt.Errorf("%w: hold %d expired at %s", ErrHoldNotAuthorized, hold.ID, hold.ExpiresAt)
+		}
+
+		if remaining := hold.Amount - hold.CapturedAmount; arg.Amount > remaining {
+			return fmt.Errorf("%w: hold %d has %d left, %d captured", ErrCaptureExceedsHold, hold.ID, remaining, arg.Amount)
+		}
+
+		// the accounts are locked in the order transferTx locks them before the held funds are released
+		if _, _, err := lockTransferAccounts(ctx, q, hold.AccountID, hold.ToAccountID, arg.Amount); err != nil {
+			return err
+		}
+
+		// the funds backing the capture are released for the transfer to spend them
+		_, err = q.AddAccountHeldBalance(ctx, AddAccountHeldBalanceParams{
+			ID:     hold.AccountID,
+			Amount: -(heldFor(hold, hold.CapturedAmount+arg.Amount) - heldFor(hold, hold.CapturedAmount)),
+		})
+		if err != nil {
+			return err
+		}
+
+		transfer, err := transferTx(ctx, q, TransferCreateParams{
+			FromAccountID: hold.AccountID,
+			ToAccountID:   hold.ToAccountID,
+			Amount:        arg.Amount,
+		})
+		if err != nil {
+			return err
+		}
+		result.Account = transfer.FromAccount
+		result.Transfer = &transfer
+
+		status := HoldAuthorized
+		if hold.CapturedAmount+arg.Amount == hold.Amount {
+			status = HoldCaptured
+		}
+
+		result.Hold, err = q.UpdateHold(ctx, UpdateHoldParams{
+			ID:             hold.ID,
+			CapturedAmount: hold.CapturedAmount + arg.Amount,
+			Status:         status,
+		})
+		return err
+	})
+
+	return result, err
+}
+
+// VoidHoldTx releases what a hold still reserves back to the available balance
+func (store *Store) VoidHoldTx(ctx context.Context, id int64) (HoldTxResult, error) {
+	var result HoldTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		hold, err := q.GetHoldForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if hold.Status != HoldAuthorized {
+			return fmt.Errorf("%w: hold %d is %s", ErrHoldNotAuthorized, hold.ID, hold.Status)
+		}
+
+		result, err = releaseHold(ctx, q, hold, HoldVoided)
+		return err
+	})
+
+	return result, err
+}
+
+// ExpireHoldTx releases one authorized hold whose expiry passed at now, found is false when there is none
+// concurrent callers skip the hold another one is expiring
+func (store *Store) ExpireHoldTx(ctx context.Context, now time.Time) (result HoldTxResult, found bool, err error) {
+	err = store.execTx(ctx, func(q *Queries) error {
+		hold, err := q.ClaimExpiredHold(ctx, now)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		found = true
+
+		result, err = releaseHold(ctx, q, hold, HoldExpired)
+		return err
+	})
+
+	return result, found, err
+}
+
+// releaseHold gives back what the locked hold still reserves and settles it with status
+func releaseHold(ctx context.Context, q *Queries, hold Hold, status string) (HoldTxResult, error) {
+	var result HoldTxResult
+	var err error
+
+	result.Account, err = q.AddAccountHeldBalance(ctx, AddAccountHeldBalanceParams{
+		ID:     hold.AccountID,
+		Amount: -(heldFor(hold, hold.Amount) - heldFor(hold, hold.CapturedAmount)),
+	})
+	if err != nil {
+		return result, err
+	}
+
+	result.Hold, err = q.UpdateHold(ctx, UpdateHoldParams{
+		ID:             hold.ID,
+		CapturedAmount: hold.CapturedAmount,
+		Status:         status,
+	})
+	return result, err
+}
+
+// heldFor is the part of what a hold reserves that backs amount of it, the fee held is spread over the amount
+func heldFor(hold Hold, amount int64) int64 {
+	return amount + hold.Fee*amount/hold.Amount
+}
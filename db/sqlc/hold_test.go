@@ -0,0 +1,233 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createHoldAccount(t *testing.T, balance int64) Account {
+	user := createRandomUser(t)
+
+	account, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    user.Username,
+		Balance:  balance,
+		Currency: "USD",
+	})
+	require.NoError(t, err)
+
+	return account
+}
+
+func authorizeHold(t *testing.T, from Account, to Account, amount int64) HoldTxResult {
+	return authorizeHoldUntil(t, from, to, amount, time.Now().Add(24*time.Hour))
+}
+
+func authorizeHoldUntil(t *testing.T, from Account, to Account, amount int64, expiresAt time.Time) HoldTxResult {
+	store := NewStore(testDb)
+
+	result, err := store.AuthorizeHoldTx(context.Background(), AuthorizeHoldTxParams{
+		AccountID:   from.ID,
+		ToAccountID: to.ID,
+		Amount:      amount,
+		Currency:    "USD",
+		Description: "card payment",
+		ExpiresAt:   expiresAt,
+	})
+	require.NoError(t, err)
+
+	return result
+}
+
+func requireHoldBalances(t *testing.T, accountID int64, balance int64, held int64) {
+	account, err := testQueries.GetAccount(context.Background(), accountID)
+	require.NoError(t, err)
+	require.Equal(t, balance, account.Balance)
+	require.Equal(t, held, account.HeldBalance)
+	require.Equal(t, balance-held, account.AvailableBalance)
+}
+
+func TestAuthorizeHoldTx(t *testing.T) {
+	from := createHoldAccount(t, 100)
+	to := createHoldAccount(t, 0)
+
+	result := authorizeHold(t, from, to, 60)
+	require.Equal(t, HoldAuthorized, result.Hold.Status)
+	require.Equal(t, int64(60), result.Hold.Amount)
+	require.Zero(t, result.Hold.CapturedAmount)
+	require.Equal(t, int64(60), result.Account.HeldBalance)
+	require.Equal(t, int64(40), result.Account.AvailableBalance)
+	require.Nil(t, result.Transfer)
+
+	requireHoldBalances(t, from.ID, 100, 60)
+	requireHoldBalances(t, to.ID, 0, 0)
+}
+
+func TestAuthorizeHoldTxInsufficientAvailableFunds(t *testing.T) {
+	store := NewStore(testDb)
+
+	from := createHoldAccount(t, 100)
+	to := createHoldAccount(t, 0)
+
+	authorizeHold(t, from, to, 60)
+
+	_, err := store.AuthorizeHoldTx(context.Background(), AuthorizeHoldTxParams{
+		AccountID:   from.ID,
+		ToAccountID: to.ID,
+		Amount:      50,
+		Currency:    "USD",
+		ExpiresAt:   time.Now().Add(24 * time.Hour),
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	requireHoldBalances(t, from.ID, 100, 60)
+}
+
+func TestCaptureHoldTx(t *testing.T) {
+	store := NewStore(testDb)
+
+	from := createHoldAccount(t, 100)
+	to := createHoldAccount(t, 0)
+
+	hold := authorizeHold(t, from, to, 60).Hold
+
+	result, err := store.CaptureHoldTx(context.Background(), CaptureHoldTxParams{ID: hold.ID, Amount: 25})
+	require.NoError(t, err)
+	require.Equal(t, HoldAuthorized, result.Hold.Status)
+	require.Equal(t, int64(25), result.Hold.CapturedAmount)
+	require.NotNil(t, result.Transfer)
+	require.Equal(t, int64(25), result.Transfer.Transfer.Amount)
+	require.Equal(t, int64(35), result.Transfer.FromAccount.HeldBalance)
+
+	requireHoldBalances(t, from.ID, 75, 35)
+	requireHoldBalances(t, to.ID, 25, 0)
+
+	_, err = store.CaptureHoldTx(context.Background(), CaptureHoldTxParams{ID: hold.ID, Amount: 36})
+	require.ErrorIs(t, err, ErrCaptureExceedsHold)
+
+	result, err = store.CaptureHoldTx(context.Background(), CaptureHoldTxParams{ID: hold.ID, Amount: 35})
+	require.NoError(t, err)
+	require.Equal(t, HoldCaptured, result.Hold.Status)
+	require.Equal(t, int64(60), result.Hold.CapturedAmount)
+
+	requireHoldBalances(t, from.ID, 40, 0)
+	requireHoldBalances(t, to.ID, 60, 0)
+
+	_, err = store.CaptureHoldTx(context.Background(), CaptureHoldTxParams{ID: hold.ID, Amount: 1})
+	require.ErrorIs(t, err, ErrHoldNotAuthorized)
+}
+
+func TestVoidHoldTx(t *testing.T) {
+	store := NewStore(testDb)
+
+	from := createHoldAccount(t, 100)
+	to := createHoldAccount(t, 0)
+
+	hold := authorizeHold(t, from, to, 60).Hold
+
+	_, err := store.CaptureHoldTx(context.Background(), CaptureHoldTxParams{ID: hold.ID, Amount: 10})
+	require.NoError(t, err)
+
+	result, err := store.VoidHoldTx(context.Background(), hold.ID)
+	require.NoError(t, err)
+	require.Equal(t, HoldVoided, result.Hold.Status)
+	require.Equal(t, int64(10), result.Hold.CapturedAmount)
+
+	requireHoldBalances(t, from.ID, 90, 0)
+	requireHoldBalances(t, to.ID, 10, 0)
+
+	_, err = store.VoidHoldTx(context.Background(), hold.ID)
+	require.ErrorIs(t, err, ErrHoldNotAuthorized)
+}
+
+func TestExpireHoldTx(t *testing.T) {
+	store := NewStore(testDb)
+
+	from := createHoldAccount(t, 100)
+	to := createHoldAccount(t, 0)
+
+	hold := authorizeHoldUntil(t, from, to, 60, time.Now().Add(-time.Minute)).Hold
+
+	// expired holds other tests left behind may come first
+	for {
+		result, found, err := store.ExpireHoldTx(context.Background(), time.Now())
+		require.NoError(t, err)
+		require.True(t, found)
+
+		if result.Hold.ID == hold.ID {
+			require.Equal(t, HoldExpired, result.Hold.Status)
+			break
+		}
+	}
+
+	requireHoldBalances(t, from.ID, 100, 0)
+
+	_, err := store.CaptureHoldTx(context.Background(), CaptureHoldTxParams{ID: hold.ID, Amount: 10})
+	require.ErrorIs(t, err, ErrHoldNotAuthorized)
+}
+
+func TestTransferTxCannotSpendHeldFunds(t *testing.T) {
+	store := NewStore(testDb)
+
+	from := createHoldAccount(t, 100)
+	to := createHoldAccount(t, 0)
+
+	authorizeHold(t, from, to, 60)
+
+	arg := TransferCreateParams{FromAccountID: from.ID, ToAccountID: to.ID, Amount: 41}
+	_, err := store.TransferTX(context.Background(), arg)
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	arg.Amount = 40
+	_, err = store.TransferTX(context.Background(), arg)
+	require.NoError(t, err)
+
+	requireHoldBalances(t, from.ID, 60, 60)
+}
+
+func TestHoldReservesFee(t *testing.T) {
+	store := NewStore(testDb)
+
+	from, err := testQueries.UpdateAccountTier(context.Background(), UpdateAccountTierParams{
+		ID:   createHoldAccount(t, 100).ID,
+		Tier: "test-" + util.RandomString(8),
+	})
+	require.NoError(t, err)
+	to := createHoldAccount(t, 0)
+	revenue := createHoldAccount(t, 0)
+
+	createRandomPricingRule(t, CreatePricingRuleParams{
+		Tier:         sql.NullString{String: from.Tier, Valid: true},
+		Currency:     "USD",
+		PercentBps:   1000,
+		FeeAccountID: revenue.ID,
+	})
+
+	// 95 and its fee of 10 are more than the account has
+	_, err = store.AuthorizeHoldTx(context.Background(), AuthorizeHoldTxParams{
+		AccountID:   from.ID,
+		ToAccountID: to.ID,
+		Amount:      95,
+		Currency:    "USD",
+		ExpiresAt:   time.Now().Add(time.Hour),
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	hold := authorizeHold(t, from, to, 60).Hold
+	require.Equal(t, int64(6), hold.Fee)
+	requireHoldBalances(t, from.ID, 100, 66)
+
+	// each capture releases its share of the fee held
+	_, err = store.CaptureHoldTx(context.Background(), CaptureHoldTxParams{ID: hold.ID, Amount: 30})
+	require.NoError(t, err)
+	requireHoldBalances(t, from.ID, 67, 33)
+
+	_, err = store.CaptureHoldTx(context.Background(), CaptureHoldTxParams{ID: hold.ID, Amount: 30})
+	require.NoError(t, err)
+	requireHoldBalances(t, from.ID, 34, 0)
+	requireHoldBalances(t, revenue.ID, 6, 0)
+}
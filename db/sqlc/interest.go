@@ -0,0 +1,196 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"simplebank/interest"
+	"time"
+)
+
+// Account products
+const (
+	AccountProductChecking = "checking"
+	AccountProductSavings  = "savings"
+)
+
+// errInterestPosted rolls back the journal of a month another posting got to first
+var errInterestPosted = errors.New("interest posted already")
+
+// AccrueInterestParams accrues the interest of an account for one UTC day
+type AccrueInterestParams struct {
+	AccountID int64
+	Day       time.Time
+}
+
+// AccrueInterest records the interest the account earned on its balance at the end of the day
+// at the rate of its product then; accrued is false when the day was accrued already or no rate applies,
+// so reruns are idempotent
+func (store *Store) AccrueInterest(ctx context.Context, arg AccrueInterestParams) (accrual InterestAccrual, accrued bool, err error) {
+	day := interest.DayStart(arg.Day)
+
+	account, err := store.GetAccount(ctx, arg.AccountID)
+	if err != nil {
+		return accrual, false, err
+	}
+
+	product, err := store.GetProduct(ctx, account.Product)
+	if err != nil {
+		return accrual, false, err
+	}
+
+	dc, err := interest.ParseDayCount(product.DayCount)
+	if err != nil {
+		return accrual, false, err
+	}
+
+	rate, err := store.GetInterestRate(ctx, GetInterestRateParams{
+		Product:  account.Product,
+		Currency: account.Currency,
+		Day:      day,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return accrual, false, nil
+	}
+	if err != nil {
+		return accrual, false, err
+	}
+
+	balance, err := store.GetAccountBalanceAt(ctx, GetAccountBalanceAtParams{
+		AccountID: account.ID,
+		At:        day.AddDate(0, 0, 1),
+	})
+	if err != nil {
+		return accrual, false, err
+	}
+
+	accrual, err = store.CreateInterestAccrual(ctx, CreateInterestAccrualParams{
+		AccountID:    account.ID,
+		Day:          day,
+		Balance:      balance,
+		RateBps:      rate.RateBps,
+		DayCount:     string(dc),
+		AmountMicros: interest.Accrue(balance, rate.RateBps, dc, day),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return accrual, false, nil
+	}
+
+	return accrual, err == nil, err
+}
+
+// PostInterestTxParams posts the interest an account accrued in the UTC month of Month
+type PostInterestTxParams struct {
+	AccountID int64
+	Month     time.Time
+}
+
+// PostInterestTxResult is the posting of a month and the journal paying it, nil when it was less than a minor unit
+type PostInterestTxResult struct {
+	Posting InterestPosting      `json:"posting"`
+	Journal *PostJournalTxResult `json:"journal,omitempty"`
+}
+
+// PostInterestTx pays the whole minor units accrued in a month, with the fraction carried from the previous posting,
+// from the interest-expense account of the rate to the account; the fraction left over is carried into the next one
+// posted is false when the month was posted already
+func (store *Store) PostInterestTx(ctx context.Context, arg PostInterestTxParams) (result PostInterestTxResult, posted bool, err error) {
+	start := interest.MonthStart(arg.Month)
+	end := start.AddDate(0, 1, 0)
+
+	err = store.execTx(ctx, func(q *Queries) error {
+		result = PostInterestTxResult{}
+		posted = false
+
+		account, err := q.GetAccount(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+
+		var carry int64
+		last, err := q.GetLastInterestPosting(ctx, GetLastInterestPostingParams{
+			AccountID: account.ID,
+			Month:     start,
+		})
+		if err == nil {
+			carry = last.CarryMicros
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		micros, err := q.SumUnpostedInterestAccruals(ctx, SumUnpostedInterestAccrualsParams{
+			AccountID:  account.ID,
+			MonthStart: start,
+			MonthEnd:   end,
+		})
+		if err != nil {
+			return err
+		}
+
+		accrued := micros + carry
+		amount, rest := interest.Split(accrued)
+
+		var journalID sql.NullInt64
+		if amount > 0 {
+			rate, err := q.GetInterestRate(ctx, GetInterestRateParams{
+				Product:  account.Product,
+				Currency: account.Currency,
+				Day:      end.AddDate(0, 0, -1),
+			})
+			if err != nil {
+				return fmt.Errorf("interest rate of account %d: %w", account.ID, err)
+			}
+
+			journal, err := postJournal(ctx, q, PostJournalTxParams{
+				Kind:        JournalKindInterest,
+				Description: fmt.Sprintf("interest for %s", start.Format("2006-01")),
+				Legs: []Leg{
+					{AccountID: rate.ExpenseAccountID, Amount: -amount},
+					{AccountID: account.ID, Amount: amount},
+				},
+			})
+			if err != nil {
+				return err
+			}
+
+			if err := insertJournalEvents(ctx, q, journal); err != nil {
+				return err
+			}
+
+			result.Journal = &journal
+			journalID = sql.NullInt64{Int64: journal.Journal.ID, Valid: true}
+		}
+
+		// a concurrent posting of the month commits first, this one finds the conflict and rolls back
+		result.Posting, err = q.CreateInterestPosting(ctx, CreateInterestPostingParams{
+			AccountID:     account.ID,
+			Month:         start,
+			AccruedMicros: accrued,
+			Amount:        amount,
+			CarryMicros:   rest,
+			JournalID:     journalID,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return errInterestPosted
+		}
+		if err != nil {
+			return err
+		}
+		posted = true
+
+		_, err = q.MarkInterestAccrualsPosted(ctx, MarkInterestAccrualsPostedParams{
+			PostingID:  sql.NullInt64{Int64: result.Posting.ID, Valid: true},
+			AccountID:  account.ID,
+			MonthStart: start,
+			MonthEnd:   end,
+		})
+		return err
+	})
+
+	if errors.Is(err, errInterestPosted) {
+		return PostInterestTxResult{}, false, nil
+	}
+
+	return result, posted, err
+}
@@ -0,0 +1,226 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"simplebank/interest"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// createRandomProduct creates a product of its own for a test, so that its rates apply to no other account
+func createRandomProduct(t *testing.T, dc interest.DayCount) Product {
+	product, err := testQueries.CreateProduct(context.Background(), CreateProductParams{
+		Code:     "test-" + util.RandomString(8),
+		Name:     "Test",
+		DayCount: string(dc),
+	})
+	require.NoError(t, err)
+
+	return product
+}
+
+func createRandomInterestRate(t *testing.T, product Product, rateBps int32, effectiveFrom time.Time) InterestRate {
+	expense := createRandomAccountWithCurrency(t, "USD")
+
+	rate, err := testQueries.CreateInterestRate(context.Background(), CreateInterestRateParams{
+		Product:          product.Code,
+		Currency:         "USD",
+		RateBps:          rateBps,
+		EffectiveFrom:    effectiveFrom,
+		ExpenseAccountID: expense.ID,
+		CreatedBy:        createRandomUser(t).Username,
+	})
+	require.NoError(t, err)
+
+	return rate
+}
+
+func createProductAccount(t *testing.T, product Product, balance int64) Account {
+	account, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    createRandomUser(t).Username,
+		Balance:  balance,
+		Currency: "USD",
+		Product:  sql.NullString{String: product.Code, Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, product.Code, account.Product)
+
+	return account
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestCreateAccountDefaultProduct(t *testing.T) {
+	account := createRandomAccount(t)
+	require.Equal(t, AccountProductChecking, account.Product)
+}
+
+func TestAccrueInterest(t *testing.T) {
+	store := NewStore(testDb)
+
+	product := createRandomProduct(t, interest.Actual365)
+	createRandomInterestRate(t, product, 500, date(2000, time.January, 1))
+	account := createProductAccount(t, product, 100000)
+
+	day := date(2026, time.March, 15)
+	accrual, accrued, err := store.AccrueInterest(context.Background(), AccrueInterestParams{AccountID: account.ID, Day: day})
+	require.NoError(t, err)
+	require.True(t, accrued)
+	require.Equal(t, int64(100000), accrual.Balance)
+	require.Equal(t, int32(500), accrual.RateBps)
+	require.Equal(t, string(interest.Actual365), accrual.DayCount)
+	require.Equal(t, int64(13698630), accrual.AmountMicros)
+	require.True(t, accrual.Day.Equal(day))
+	require.False(t, accrual.PostingID.Valid)
+
+	_, accrued, err = store.AccrueInterest(context.Background(), AccrueInterestParams{AccountID: account.ID, Day: day.Add(time.Hour)})
+	require.NoError(t, err)
+	require.False(t, accrued)
+}
+
+func TestAccrueInterestEndOfDayBalance(t *testing.T) {
+	store := NewStore(testDb)
+
+	product := createRandomProduct(t, interest.Actual365)
+	createRandomInterestRate(t, product, 500, date(2000, time.January, 1))
+	account := createProductAccount(t, product, 100000)
+
+	from := createRandomAccountWithCurrency(t, "USD")
+	_, err := store.TransferTX(context.Background(), TransferCreateParams{
+		FromAccountID: from.ID,
+		ToAccountID:   account.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	yesterday := interest.DayStart(time.Now()).AddDate(0, 0, -1)
+	accrual, accrued, err := store.AccrueInterest(context.Background(), AccrueInterestParams{AccountID: account.ID, Day: yesterday})
+	require.NoError(t, err)
+	require.True(t, accrued)
+	require.Equal(t, int64(100000), accrual.Balance)
+
+	accrual, accrued, err = store.AccrueInterest(context.Background(), AccrueInterestParams{AccountID: account.ID, Day: time.Now()})
+	require.NoError(t, err)
+	require.True(t, accrued)
+	require.Equal(t, int64(100010), accrual.Balance)
+}
+
+func TestAccrueInterestRateSchedule(t *testing.T) {
+	store := NewStore(testDb)
+
+	product := createRandomProduct(t, interest.Thirty360)
+	createRandomInterestRate(t, product, 360, date(2026, time.March, 1))
+	createRandomInterestRate(t, product, 720, date(2026, time.April, 1))
+	account := createProductAccount(t, product, 100000)
+
+	_, accrued, err := store.AccrueInterest(context.Background(), AccrueInterestParams{AccountID: account.ID, Day: date(2026, time.February, 27)})
+	require.NoError(t, err)
+	require.False(t, accrued)
+
+	accrual, accrued, err := store.AccrueInterest(context.Background(), AccrueInterestParams{AccountID: account.ID, Day: date(2026, time.March, 30)})
+	require.NoError(t, err)
+	require.True(t, accrued)
+	require.Equal(t, int32(360), accrual.RateBps)
+	require.Equal(t, int64(1000000), accrual.AmountMicros)
+
+	accrual, accrued, err = store.AccrueInterest(context.Background(), AccrueInterestParams{AccountID: account.ID, Day: date(2026, time.March, 31)})
+	require.NoError(t, err)
+	require.True(t, accrued)
+	require.Zero(t, accrual.AmountMicros)
+
+	accrual, accrued, err = store.AccrueInterest(context.Background(), AccrueInterestParams{AccountID: account.ID, Day: date(2026, time.April, 1)})
+	require.NoError(t, err)
+	require.True(t, accrued)
+	require.Equal(t, int32(720), accrual.RateBps)
+	require.Equal(t, int64(2000000), accrual.AmountMicros)
+}
+
+func TestPostInterestTx(t *testing.T) {
+	store := NewStore(testDb)
+
+	product := createRandomProduct(t, interest.Actual365)
+	rate := createRandomInterestRate(t, product, 500, date(2000, time.January, 1))
+	account := createProductAccount(t, product, 100000)
+
+	for day := date(2026, time.January, 1); day.Month() == time.January; day = day.AddDate(0, 0, 1) {
+		_, accrued, err := store.AccrueInterest(context.Background(), AccrueInterestParams{AccountID: account.ID, Day: day})
+		require.NoError(t, err)
+		require.True(t, accrued)
+	}
+
+	result, posted, err := store.PostInterestTx(context.Background(), PostInterestTxParams{
+		AccountID: account.ID,
+		Month:     date(2026, time.January, 20),
+	})
+	require.NoError(t, err)
+	require.True(t, posted)
+	require.Equal(t, int64(13698630*31), result.Posting.AccruedMicros)
+	require.Equal(t, int64(424), result.Posting.Amount)
+	require.Equal(t, int64(657530), result.Posting.CarryMicros)
+	require.True(t, result.Posting.Month.Equal(date(2026, time.January, 1)))
+	require.NotNil(t, result.Journal)
+	require.Equal(t, JournalKindInterest, result.Journal.Journal.Kind)
+	require.Equal(t, result.Journal.Journal.ID, result.Posting.JournalID.Int64)
+	require.Equal(t, int64(100424), result.Journal.Account(account.ID).Balance)
+	require.Equal(t, int64(-424), result.Journal.Entries[0].Amount)
+	require.Equal(t, rate.ExpenseAccountID, result.Journal.Entries[0].AccountID)
+
+	accruals, err := testQueries.ListAccountInterestAccruals(context.Background(), ListAccountInterestAccrualsParams{
+		AccountID:  account.ID,
+		LimitCount: 100,
+	})
+	require.NoError(t, err)
+	require.Len(t, accruals, 31)
+	for _, accrual := range accruals {
+		require.Equal(t, result.Posting.ID, accrual.PostingID.Int64)
+	}
+
+	_, posted, err = store.PostInterestTx(context.Background(), PostInterestTxParams{
+		AccountID: account.ID,
+		Month:     date(2026, time.January, 1),
+	})
+	require.NoError(t, err)
+	require.False(t, posted)
+
+	// february carries the fraction of january
+	_, _, err = store.AccrueInterest(context.Background(), AccrueInterestParams{AccountID: account.ID, Day: date(2026, time.February, 1)})
+	require.NoError(t, err)
+
+	result, posted, err = store.PostInterestTx(context.Background(), PostInterestTxParams{
+		AccountID: account.ID,
+		Month:     date(2026, time.February, 1),
+	})
+	require.NoError(t, err)
+	require.True(t, posted)
+	require.Equal(t, int64(657530+13698630), result.Posting.AccruedMicros)
+	require.Equal(t, int64(14), result.Posting.Amount)
+	require.Equal(t, int64(356160), result.Posting.CarryMicros)
+}
+
+func TestPostInterestTxLessThanAUnit(t *testing.T) {
+	store := NewStore(testDb)
+
+	product := createRandomProduct(t, interest.Actual365)
+	createRandomInterestRate(t, product, 1, date(2000, time.January, 1))
+	account := createProductAccount(t, product, 100)
+
+	_, _, err := store.AccrueInterest(context.Background(), AccrueInterestParams{AccountID: account.ID, Day: date(2026, time.January, 1)})
+	require.NoError(t, err)
+
+	result, posted, err := store.PostInterestTx(context.Background(), PostInterestTxParams{
+		AccountID: account.ID,
+		Month:     date(2026, time.January, 1),
+	})
+	require.NoError(t, err)
+	require.True(t, posted)
+	require.Zero(t, result.Posting.Amount)
+	require.Equal(t, int64(27), result.Posting.CarryMicros)
+	require.False(t, result.Posting.JournalID.Valid)
+	require.Nil(t, result.Journal)
+}
@@ -0,0 +1,259 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"simplebank/event"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Journal kinds
+const (
+	JournalKindTransfer = "transfer"
+	JournalKindInterest = "interest"
+	// JournalKindLoanDisbursement pays the principal of a loan out of its loan account
+	JournalKindLoanDisbursement = "loan_disbursement"
+	// JournalKindLoanRepayment splits a repayment into principal, interest and penalty entries
+	JournalKindLoanRepayment = "loan_repayment"
+	// JournalKindAdjustment corrects the books, possibly back-dated into an open period
+	JournalKindAdjustment = "adjustment"
+	// JournalKindOpeningBalance posts the balances seeded before the chart of accounts against capital
+	JournalKindOpeningBalance = "opening_balance"
+)
+
+var (
+	ErrInvalidJournal    = errors.New("invalid journal")
+	ErrUnbalancedJournal = errors.New("journal does not sum to zero")
+)
+
+// Leg posts one entry of a journal
+type Leg struct {
+	AccountID int64 `json:"account_id"`
+	// Amount is taken out of the account when negative and added to it when positive
+	Amount int64 `json:"amount"`
+}
+
+// PostJournalTxParams contains the legs of a journal, they must sum to zero in the currency of each account
+type PostJournalTxParams struct {
+	Kind        string
+	Description string
+	Legs        []Leg
+	// EffectiveAt back-dates the journal and its entries when set, it must fall in an open period
+	EffectiveAt time.Time
+}
+
+// PostJournalTxResult is the posted journal and the accounts it touched
+type PostJournalTxResult struct {
+	Journal Journal `json:"journal"`
+	// Entries are in the order of the legs
+	Entries []Entry `json:"entries"`
+	// Balances holds the balance of the account of each entry right after it
+	Balances []int64 `json:"balances"`
+	// Accounts are the touched accounts after the journal, in id order
+	Accounts []Account `json:"accounts"`
+}
+
+// Account returns the touched account with the given id after the journal
+func (result PostJournalTxResult) Account(id int64) Account {
+	i := sort.Search(len(result.Accounts), func(i int) bool { return result.Accounts[i].ID >= id })
+	if i < len(result.Accounts) && result.Accounts[i].ID == id {
+		return result.Accounts[i]
+	}
+
+	return Account{}
+}
+
+// PostJournalTx posts a journal of any number of legs and records its entries in the outbox,
+// adjustments are recorded in the audit log too
+func (store *Store) PostJournalTx(ctx context.Context, arg PostJournalTxParams) (PostJournalTxResult, error) {
+	var result PostJournalTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result, err = postJournal(ctx, q, arg)
+		if err != nil {
+			return err
+		}
+
+		if err := insertJournalEvents(ctx, q, result); err != nil {
+			return err
+		}
+
+		if arg.Kind != JournalKindAdjustment {
+			return nil
+		}
+
+		_, err = recordAudit(ctx, q, AuditEntry{
+			Action:     AuditAdjustment,
+			TargetType: AuditTargetJournal,
+			TargetID:   strconv.FormatInt(result.Journal.ID, 10),
+			After:      result,
+		})
+		return err
+	})
+
+	return result, err
+}
+
+// insertJournalEvents records the entries of a posted journal in the outbox
+func insertJournalEvents(ctx context.Context, q *Queries, result PostJournalTxResult) error {
+	for i, entry := range result.Entries {
+		err := insertEvent(ctx, q, event.EntryPostedV2{
+			EntryID:   entry.ID,
+			AccountID: entry.AccountID,
+			JournalID: result.Journal.ID,
+			Amount:    entry.Amount,
+			Balance:   result.Balances[i],
+			Currency:  result.Account(entry.AccountID).Currency,
+			CreatedAt: entry.CreatedAt,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// postJournal locks every account of the legs in id order, checks that the legs sum to zero per currency,
+// then posts an entry per leg and applies the net change of each account inside the tx of q
+func postJournal(ctx context.Context, q *Queries, arg PostJournalTxParams) (PostJournalTxResult, error) {
+	var result PostJournalTxResult
+
+	if len(arg.Legs) < 2 {
+		return result, fmt.Errorf("%w: a journal needs at least two legs", ErrInvalidJournal)
+	}
+
+	net := map[int64]int64{}
+	var ids []int64
+	for i, leg := range arg.Legs {
+		if leg.Amount == 0 {
+			return result, fmt.Errorf("%w: leg %d has no amount", ErrInvalidJournal, i+1)
+		}
+
+		if _, ok := net[leg.AccountID]; !ok {
+			ids = append(ids, leg.AccountID)
+		}
+		net[leg.AccountID] += leg.Amount
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	locked, err := q.ListAccountsForUpdate(ctx, ids)
+	if err != nil {
+		return result, err
+	}
+
+	if len(locked) != len(ids) {
+		return result, fmt.Errorf("journal account not found: %w", sql.ErrNoRows)
+	}
+
+	balances := map[int64]int64{}
+	sums := map[string]int64{}
+	for _, account := range locked {
+		balances[account.ID] = account.Balance
+		sums[account.Currency] += net[account.ID]
+	}
+
+	for _, currency := range sortedKeys(sums) {
+		if sums[currency] != 0 {
+			return result, fmt.Errorf("%w: %s is off by %d", ErrUnbalancedJournal, currency, sums[currency])
+		}
+	}
+
+	if !arg.EffectiveAt.IsZero() {
+		if err := checkBackDated(ctx, q, arg.EffectiveAt, ids); err != nil {
+			return result, err
+		}
+	}
+
+	result.Journal, err = q.CreateJournal(ctx, CreateJournalParams{
+		Kind:        arg.Kind,
+		Description: arg.Description,
+		EffectiveAt: sql.NullTime{Time: arg.EffectiveAt, Valid: !arg.EffectiveAt.IsZero()},
+	})
+	if err != nil {
+		return result, err
+	}
+
+	for _, leg := range arg.Legs {
+		entry, err := q.CreateJournalEntry(ctx, CreateJournalEntryParams{
+			JournalID: sql.NullInt64{Int64: result.Journal.ID, Valid: true},
+			AccountID: leg.AccountID,
+			Amount:    leg.Amount,
+			CreatedAt: result.Journal.EffectiveAt,
+		})
+		if err != nil {
+			return result, err
+		}
+
+		balances[leg.AccountID] += leg.Amount
+		result.Entries = append(result.Entries, entry)
+		result.Balances = append(result.Balances, balances[leg.AccountID])
+	}
+
+	for i, account := range locked {
+		if net[account.ID] != 0 {
+			account, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
+				ID:     account.ID,
+				Amount: net[account.ID],
+			})
+			if err != nil {
+				return result, err
+			}
+		}
+		locked[i] = account
+	}
+	result.Accounts = locked
+
+	return result, nil
+}
+
+// checkBackDated rejects a journal back-dated into a closed period and drops the balance snapshots
+// of its accounts from its day on, they no longer hold once the journal is posted
+func checkBackDated(ctx context.Context, q *Queries, effectiveAt time.Time, accountIDs []int64) error {
+	if effectiveAt.After(time.Now()) {
+		return fmt.Errorf("%w: effective at %s is in the future", ErrInvalidJournal, effectiveAt.Format(time.RFC3339))
+	}
+
+	// shared with other back-dated journals, exclusive with closing a period
+	if err := q.LockAccountingPeriodsShared(ctx); err != nil {
+		return err
+	}
+
+	day := effectiveAt.UTC().Truncate(24 * time.Hour)
+	period, err := q.GetClosedPeriod(ctx, GetClosedPeriodParams{StartDay: day, EndDay: day})
+	if err == nil {
+		return fmt.Errorf("%w: %s is in the %s period closed on %s", ErrPeriodClosed,
+			day.Format(time.DateOnly), period.Kind, period.ClosedAt.Format(time.DateOnly))
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	for _, id := range accountIDs {
+		err := q.DeleteAccountBalanceSnapshotsFrom(ctx, DeleteAccountBalanceSnapshotsFromParams{
+			AccountID: id,
+			Day:       day,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func sortedKeys(m map[string]int64) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,199 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"simplebank/event"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostJournalTx(t *testing.T) {
+	store := NewStore(testDb)
+
+	payer := createRandomAccountWithCurrency(t, "USD")
+	payee := createRandomAccountWithCurrency(t, "USD")
+	fees := createRandomAccountWithCurrency(t, "USD")
+
+	result, err := store.PostJournalTx(context.Background(), PostJournalTxParams{
+		Kind:        "split",
+		Description: "payment with a fee",
+		Legs: []Leg{
+			{AccountID: payer.ID, Amount: -100},
+			{AccountID: payee.ID, Amount: 90},
+			{AccountID: fees.ID, Amount: 10},
+		},
+	})
+	require.NoError(t, err)
+	require.NotZero(t, result.Journal.ID)
+	require.Equal(t, "split", result.Journal.Kind)
+	require.Len(t, result.Entries, 3)
+
+	for i, entry := range result.Entries {
+		require.Equal(t, result.Journal.ID, entry.JournalID.Int64)
+		require.Equal(t, result.Account(entry.AccountID).Balance, result.Balances[i])
+	}
+
+	require.Equal(t, payer.Balance-100, result.Account(payer.ID).Balance)
+	require.Equal(t, payee.Balance+90, result.Account(payee.ID).Balance)
+	require.Equal(t, fees.Balance+10, result.Account(fees.ID).Balance)
+
+	entries, err := testQueries.ListJournalEntries(context.Background(), result.Entries[0].JournalID)
+	require.NoError(t, err)
+	require.Equal(t, result.Entries, entries)
+
+	events := listAccountEvents(t, fees.ID)
+	last := events[len(events)-1]
+	require.Equal(t, string(event.EntryPosted), last.EventType)
+	require.Equal(t, int32(2), last.SchemaVersion)
+
+	var payload event.EntryPostedV2
+	require.NoError(t, json.Unmarshal(last.Payload, &payload))
+	require.Equal(t, result.Journal.ID, payload.JournalID)
+	require.Equal(t, fees.Balance+10, payload.Balance)
+}
+
+func TestPostJournalTxBalancesPerCurrency(t *testing.T) {
+	store := NewStore(testDb)
+
+	usd1 := createRandomAccountWithCurrency(t, "USD")
+	usd2 := createRandomAccountWithCurrency(t, "USD")
+	cop1 := createRandomAccountWithCurrency(t, "COP")
+	cop2 := createRandomAccountWithCurrency(t, "COP")
+
+	// an exchange: both currencies sum to zero on their own
+	_, err := store.PostJournalTx(context.Background(), PostJournalTxParams{
+		Kind: "exchange",
+		Legs: []Leg{
+			{AccountID: usd1.ID, Amount: -10},
+			{AccountID: usd2.ID, Amount: 10},
+			{AccountID: cop2.ID, Amount: -40000},
+			{AccountID: cop1.ID, Amount: 40000},
+		},
+	})
+	require.NoError(t, err)
+
+	_, err = store.PostJournalTx(context.Background(), PostJournalTxParams{
+		Kind: "exchange",
+		Legs: []Leg{
+			{AccountID: usd1.ID, Amount: -10},
+			{AccountID: cop1.ID, Amount: 10},
+		},
+	})
+	require.ErrorIs(t, err, ErrUnbalancedJournal)
+
+	account, err := testQueries.GetAccount(context.Background(), usd1.ID)
+	require.NoError(t, err)
+	require.Equal(t, usd1.Balance-10, account.Balance)
+}
+
+func TestPostJournalTxInvalid(t *testing.T) {
+	store := NewStore(testDb)
+
+	account1 := createRandomAccountWithCurrency(t, "USD")
+	account2 := createRandomAccountWithCurrency(t, "USD")
+
+	testCases := []struct {
+		name string
+		legs []Leg
+		err  error
+	}{
+		{
+			name: "OneLeg",
+			legs: []Leg{{AccountID: account1.ID, Amount: 10}},
+			err:  ErrInvalidJournal,
+		},
+		{
+			name: "ZeroAmount",
+			legs: []Leg{{AccountID: account1.ID, Amount: 0}, {AccountID: account2.ID, Amount: 0}},
+			err:  ErrInvalidJournal,
+		},
+		{
+			name: "Unbalanced",
+			legs: []Leg{{AccountID: account1.ID, Amount: -10}, {AccountID: account2.ID, Amount: 9}},
+			err:  ErrUnbalancedJournal,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := store.PostJournalTx(context.Background(), PostJournalTxParams{Kind: "test", Legs: tc.legs})
+			require.ErrorIs(t, err, tc.err)
+		})
+	}
+}
+
+func TestTransferTxPostsJournal(t *testing.T) {
+	store := NewStore(testDb)
+
+	account1 := createRandomAccountWithCurrency(t, "USD")
+	account2 := createRandomAccountWithCurrency(t, "USD")
+
+	result, err := store.TransferTX(context.Background(), TransferCreateParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+	require.True(t, result.Transfer.JournalID.Valid)
+	require.Equal(t, result.Transfer.JournalID, result.FromEntrie.JournalID)
+	require.Equal(t, result.Transfer.JournalID, result.ToEntrie.JournalID)
+
+	journal, err := testQueries.GetJournal(context.Background(), result.Transfer.JournalID.Int64)
+	require.NoError(t, err)
+	require.Equal(t, JournalKindTransfer, journal.Kind)
+
+	// a transfer between currencies is refused before its legs are posted
+	other := createRandomAccountWithCurrency(t, "COP")
+	_, err = store.TransferTX(context.Background(), TransferCreateParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   other.ID,
+		Amount:        10,
+	})
+	require.ErrorIs(t, err, ErrCurrencyMismatch)
+}
+
+// TestPostJournalTxConcurrent posts journals touching the same accounts in opposite orders side by side
+func TestPostJournalTxConcurrent(t *testing.T) {
+	store := NewStore(testDb)
+
+	accounts := []Account{
+		createRandomAccountWithCurrency(t, "USD"),
+		createRandomAccountWithCurrency(t, "USD"),
+		createRandomAccountWithCurrency(t, "USD"),
+	}
+
+	n := 10
+	errs := make(chan error)
+
+	for i := 0; i < n; i++ {
+		legs := []Leg{
+			{AccountID: accounts[0].ID, Amount: -20},
+			{AccountID: accounts[1].ID, Amount: 10},
+			{AccountID: accounts[2].ID, Amount: 10},
+		}
+		if i%2 == 1 {
+			legs = []Leg{
+				{AccountID: accounts[2].ID, Amount: -10},
+				{AccountID: accounts[1].ID, Amount: -10},
+				{AccountID: accounts[0].ID, Amount: 20},
+			}
+		}
+
+		go func() {
+			_, err := store.PostJournalTx(context.Background(), PostJournalTxParams{Kind: "test", Legs: legs})
+			errs <- err
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		require.NoError(t, <-errs)
+	}
+
+	for _, account := range accounts {
+		updated, err := testQueries.GetAccount(context.Background(), account.ID)
+		require.NoError(t, err)
+		require.Equal(t, account.Balance, updated.Balance)
+	}
+}
@@ -0,0 +1,107 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Account types of the chart of accounts, a positive balance is a credit and a negative one a debit
+const (
+	AccountTypeAsset     = "asset"
+	AccountTypeLiability = "liability"
+	AccountTypeIncome    = "income"
+	AccountTypeExpense   = "expense"
+	AccountTypeEquity    = "equity"
+)
+
+// SystemOwner owns the internal accounts, nobody can log in as it
+const SystemOwner = "simplebank"
+
+// AccountProductInternal is the product of the internal accounts, it pays no interest
+const AccountProductInternal = "internal"
+
+// CustomerProduct reports whether customers can open accounts of the product,
+// loan and internal accounts are only opened by the bank
+func CustomerProduct(code string) bool {
+	return code != AccountProductLoan && code != AccountProductInternal
+}
+
+// General ledger codes of the internal accounts, every currency has one account of each
+const (
+	GLFeeIncome       = "fee_income"
+	GLInterestIncome  = "interest_income"
+	GLInterestExpense = "interest_expense"
+	GLFXSpread        = "fx_spread"
+	GLSuspense        = "suspense"
+	GLCapital         = "capital"
+)
+
+// InternalAccount returns the internal account with the general ledger code in the currency
+func (store *Store) InternalAccount(ctx context.Context, code string, currency string) (Account, error) {
+	return store.GetInternalAccount(ctx, GetInternalAccountParams{
+		Code:     sql.NullString{String: code, Valid: true},
+		Currency: currency,
+	})
+}
+
+// TrialBalanceLine sums the balances of the accounts of one type in one currency
+type TrialBalanceLine struct {
+	Type     string `json:"type"`
+	Accounts int32  `json:"accounts"`
+	// Debits is the sum of the negative balances, as a positive amount
+	Debits int64 `json:"debits"`
+	// Credits is the sum of the positive balances
+	Credits int64 `json:"credits"`
+}
+
+// CurrencyTrialBalance is the trial balance of one currency, the books balance when its debits equal its credits
+type CurrencyTrialBalance struct {
+	Currency string             `json:"currency"`
+	Lines    []TrialBalanceLine `json:"lines"`
+	Debits   int64              `json:"debits"`
+	Credits  int64              `json:"credits"`
+	Balanced bool               `json:"balanced"`
+}
+
+// GroupTrialBalance totals the trial balance rows by currency, the rows come ordered by currency
+func GroupTrialBalance(rows []GetTrialBalanceAtRow) []CurrencyTrialBalance {
+	var balances []CurrencyTrialBalance
+
+	for _, row := range rows {
+		if len(balances) == 0 || balances[len(balances)-1].Currency != row.Currency {
+			balances = append(balances, CurrencyTrialBalance{Currency: row.Currency})
+		}
+
+		balance := &balances[len(balances)-1]
+		balance.Lines = append(balance.Lines, TrialBalanceLine{
+			Type:     row.Type,
+			Accounts: row.Accounts,
+			Debits:   row.Debits,
+			Credits:  row.Credits,
+		})
+		balance.Debits += row.Debits
+		balance.Credits += row.Credits
+	}
+
+	for i := range balances {
+		balances[i].Balanced = balances[i].Debits == balances[i].Credits
+	}
+
+	return balances
+}
+
+// GroupPeriodTrialBalance totals the trial balance recorded by a period close by currency
+func GroupPeriodTrialBalance(lines []PeriodTrialBalance) []CurrencyTrialBalance {
+	rows := make([]GetTrialBalanceAtRow, len(lines))
+	for i, line := range lines {
+		rows[i] = GetTrialBalanceAtRow{
+			Currency: line.Currency,
+			Type:     line.Type,
+			Accounts: line.Accounts,
+			Debits:   line.Debits,
+			Credits:  line.Credits,
+		}
+	}
+
+	return GroupTrialBalance(rows)
+}
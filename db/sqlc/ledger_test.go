@@ -0,0 +1,66 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInternalAccount(t *testing.T) {
+	store := NewStore(testDb)
+	types := map[string]string{
+		GLFeeIncome:       AccountTypeIncome,
+		GLInterestIncome:  AccountTypeIncome,
+		GLInterestExpense: AccountTypeExpense,
+		GLFXSpread:        AccountTypeIncome,
+		GLSuspense:        AccountTypeLiability,
+		GLCapital:         AccountTypeEquity,
+	}
+
+	for _, currency := range []string{"USD", "EUR"} {
+		for code, accountType := range types {
+			account, err := store.InternalAccount(context.Background(), code, currency)
+			require.NoError(t, err)
+			require.Equal(t, SystemOwner, account.Owner)
+			require.Equal(t, currency, account.Currency)
+			require.Equal(t, accountType, account.Type)
+			require.Equal(t, AccountProductInternal, account.Product)
+			require.Equal(t, code, account.Code.String)
+		}
+	}
+
+	accounts, err := testQueries.ListInternalAccounts(context.Background())
+	require.NoError(t, err)
+	require.Len(t, accounts, 2*len(types))
+}
+
+func TestGroupTrialBalance(t *testing.T) {
+	rows := []GetTrialBalanceAtRow{
+		{Currency: "EUR", Type: AccountTypeAsset, Accounts: 1, Debits: 500},
+		{Currency: "EUR", Type: AccountTypeLiability, Accounts: 2, Credits: 450},
+		{Currency: "EUR", Type: AccountTypeIncome, Accounts: 1, Credits: 50},
+		{Currency: "USD", Type: AccountTypeLiability, Accounts: 3, Debits: 10, Credits: 30},
+	}
+
+	balances := GroupTrialBalance(rows)
+	require.Len(t, balances, 2)
+
+	require.Equal(t, "EUR", balances[0].Currency)
+	require.Len(t, balances[0].Lines, 3)
+	require.Equal(t, int64(500), balances[0].Debits)
+	require.Equal(t, int64(500), balances[0].Credits)
+	require.True(t, balances[0].Balanced)
+
+	require.Equal(t, "USD", balances[1].Currency)
+	require.Len(t, balances[1].Lines, 1)
+	require.False(t, balances[1].Balanced)
+
+	require.Equal(t, balances, GroupPeriodTrialBalance([]PeriodTrialBalance{
+		{Currency: "EUR", Type: AccountTypeAsset, Accounts: 1, Debits: 500},
+		{Currency: "EUR", Type: AccountTypeLiability, Accounts: 2, Credits: 450},
+		{Currency: "EUR", Type: AccountTypeIncome, Accounts: 1, Credits: 50},
+		{Currency: "USD", Type: AccountTypeLiability, Accounts: 3, Debits: 10, Credits: 30},
+	}))
+	require.Empty(t, GroupTrialBalance(nil))
+}
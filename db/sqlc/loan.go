@@ -0,0 +1,348 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"simplebank/amortization"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// AccountProductLoan is the product of the account a loan is booked on
+const AccountProductLoan = "loan"
+
+// Loan statuses
+const (
+	LoanActive  = "active"
+	LoanPaidOff = "paid_off"
+)
+
+// Loan installment statuses
+const (
+	LoanInstallmentPending = "pending"
+	LoanInstallmentOverdue = "overdue"
+	LoanInstallmentPaid    = "paid"
+)
+
+var (
+	ErrLoanNotActive        = errors.New("loan is not active")
+	ErrRepaymentExceedsLoan = errors.New("repayment exceeds what the loan owes")
+)
+
+// CreateLoanTxParams lends Principal to the owner of the borrower account, paid back in TermMonths installments
+type CreateLoanTxParams struct {
+	BorrowerAccountID int64
+	// IncomeAccountID is credited with the interest and the late fees, it holds the currency of the borrower account
+	IncomeAccountID int64
+	Principal       int64
+	RateBps         int32
+	TermMonths      int32
+	Method          amortization.Method
+	LateFee         int64
+	GraceDays       int32
+	FirstDueDate    time.Time
+	CreatedBy       string
+}
+
+// LoanTxResult is a loan with its schedule and the transfer paying out its principal
+type LoanTxResult struct {
+	Loan         Loan              `json:"loan"`
+	Account      Account           `json:"account"`
+	Installments []LoanInstallment `json:"installments"`
+	Disbursement TransferTxResult  `json:"disbursement"`
+}
+
+// CreateLoanTx opens a loan account for the borrower, records the amortization schedule of the loan
+// and disburses the principal from the loan account to the borrower account
+func (store *Store) CreateLoanTx(ctx context.Context, arg CreateLoanTxParams) (LoanTxResult, error) {
+	var result LoanTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		result = LoanTxResult{}
+
+		borrower, err := q.GetAccount(ctx, arg.BorrowerAccountID)
+		if err != nil {
+			return err
+		}
+
+		income, err := q.GetAccount(ctx, arg.IncomeAccountID)
+		if err != nil {
+			return err
+		}
+
+		if borrower.Status != AccountStatusActive {
+			return fmt.Errorf("%w: account %d is %s", ErrAccountNotActive, borrower.ID, borrower.Status)
+		}
+
+		if income.Currency != borrower.Currency {
+			return fmt.Errorf("%w: account %d holds %s, not %s", ErrCurrencyMismatch, income.ID, income.Currency, borrower.Currency)
+		}
+
+		account, err := openAccount(ctx, q, CreateAccountParams{
+			Owner:    borrower.Owner,
+			Currency: borrower.Currency,
+			Product:  sql.NullString{String: AccountProductLoan, Valid: true},
+			Type:     sql.NullString{String: AccountTypeAsset, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Loan, err = q.CreateLoan(ctx, CreateLoanParams{
+			AccountID:         account.ID,
+			BorrowerAccountID: borrower.ID,
+			IncomeAccountID:   income.ID,
+			Principal:         arg.Principal,
+			RateBps:           arg.RateBps,
+			TermMonths:        arg.TermMonths,
+			Method:            string(arg.Method),
+			Currency:          borrower.Currency,
+			LateFee:           arg.LateFee,
+			GraceDays:         arg.GraceDays,
+			CreatedBy:         arg.CreatedBy,
+		})
+		if err != nil {
+			return err
+		}
+
+		schedule := amortization.Schedule(arg.Principal, arg.RateBps, arg.TermMonths, arg.Method, arg.FirstDueDate)
+		for _, installment := range schedule {
+			created, err := q.CreateLoanInstallment(ctx, CreateLoanInstallmentParams{
+				LoanID:    result.Loan.ID,
+				Number:    installment.Number,
+				DueDate:   installment.DueDate,
+				Principal: installment.Principal,
+				Interest:  installment.Interest,
+			})
+			if err != nil {
+				return err
+			}
+			result.Installments = append(result.Installments, created)
+		}
+
+		result.Disbursement, err = disburseLoan(ctx, q, result.Loan)
+		if err != nil {
+			return err
+		}
+		result.Account = result.Disbursement.FromAccount
+
+		_, err = recordAudit(ctx, q, AuditEntry{
+			Action:     AuditLoanCreate,
+			TargetType: AuditTargetLoan,
+			TargetID:   strconv.FormatInt(result.Loan.ID, 10),
+			After:      result.Loan,
+		})
+		return err
+	})
+
+	return result, err
+}
+
+// disburseLoan transfers the principal from the loan account to the borrower account, without fee or limits
+func disburseLoan(ctx context.Context, q *Queries, loan Loan) (TransferTxResult, error) {
+	var result TransferTxResult
+
+	posting, err := postJournal(ctx, q, PostJournalTxParams{
+		Kind:        JournalKindLoanDisbursement,
+		Description: fmt.Sprintf("loan %d", loan.ID),
+		Legs: []Leg{
+			{AccountID: loan.AccountID, Amount: -loan.Principal},
+			{AccountID: loan.BorrowerAccountID, Amount: loan.Principal},
+		},
+	})
+	if err != nil {
+		return result, err
+	}
+
+	result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
+		FromAccountID: loan.AccountID,
+		ToAccountID:   loan.BorrowerAccountID,
+		Amount:        loan.Principal,
+		JournalID:     sql.NullInt64{Int64: posting.Journal.ID, Valid: true},
+	})
+	if err != nil {
+		return result, err
+	}
+
+	result.FromEntrie, result.ToEntrie = posting.Entries[0], posting.Entries[1]
+	result.FromAccount, result.ToAccount = posting.Account(loan.AccountID), posting.Account(loan.BorrowerAccountID)
+
+	return result, insertTransferEvents(ctx, q, result, posting)
+}
+
+// RepayLoanTxParams pays Amount of a loan out of its borrower account
+type RepayLoanTxParams struct {
+	LoanID int64
+	Amount int64
+}
+
+// RepayLoanTxResult is the repayment, the installments it paid and the journal splitting it
+type RepayLoanTxResult struct {
+	Loan         Loan                `json:"loan"`
+	Repayment    LoanRepayment       `json:"repayment"`
+	Installments []LoanInstallment   `json:"installments"`
+	Journal      PostJournalTxResult `json:"journal"`
+}
+
+// RepayLoanTx splits a repayment over the unpaid installments, oldest first and each one penalty, interest, then
+// principal; the principal goes back to the loan account and the interest and penalty to the income account
+// the loan is paid off once every installment is paid
+func (store *Store) RepayLoanTx(ctx context.Context, arg RepayLoanTxParams) (RepayLoanTxResult, error) {
+	var result RepayLoanTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		result = RepayLoanTxResult{}
+
+		loan, err := q.GetLoanForUpdate(ctx, arg.LoanID)
+		if err != nil {
+			return err
+		}
+
+		if loan.Status != LoanActive {
+			return fmt.Errorf("%w: loan %d is %s", ErrLoanNotActive, loan.ID, loan.Status)
+		}
+
+		unpaid, err := q.ListUnpaidLoanInstallmentsForUpdate(ctx, loan.ID)
+		if err != nil {
+			return err
+		}
+
+		dues := make([]amortization.Due, len(unpaid))
+		for i, installment := range unpaid {
+			dues[i] = amortization.Due{
+				Number:    installment.Number,
+				Penalty:   installment.Penalty - installment.PaidPenalty,
+				Interest:  installment.Interest - installment.PaidInterest,
+				Principal: installment.Principal - installment.PaidPrincipal,
+			}
+		}
+
+		paid, rest := amortization.Allocate(arg.Amount, dues)
+		if rest > 0 {
+			return fmt.Errorf("%w: loan %d owes %d less than %d", ErrRepaymentExceedsLoan, loan.ID, rest, arg.Amount)
+		}
+
+		var split amortization.Due
+		for _, p := range paid {
+			split.Principal += p.Principal
+			split.Interest += p.Interest
+			split.Penalty += p.Penalty
+		}
+
+		if err := checkRepayment(ctx, q, loan, arg.Amount); err != nil {
+			return err
+		}
+
+		legs := []Leg{{AccountID: loan.BorrowerAccountID, Amount: -arg.Amount}}
+		if split.Principal > 0 {
+			legs = append(legs, Leg{AccountID: loan.AccountID, Amount: split.Principal})
+		}
+		if split.Interest > 0 {
+			legs = append(legs, Leg{AccountID: loan.IncomeAccountID, Amount: split.Interest})
+		}
+		if split.Penalty > 0 {
+			legs = append(legs, Leg{AccountID: loan.IncomeAccountID, Amount: split.Penalty})
+		}
+
+		result.Journal, err = postJournal(ctx, q, PostJournalTxParams{
+			Kind:        JournalKindLoanRepayment,
+			Description: fmt.Sprintf("loan %d", loan.ID),
+			Legs:        legs,
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := insertJournalEvents(ctx, q, result.Journal); err != nil {
+			return err
+		}
+
+		paidOff := len(paid) == len(unpaid)
+		for i, p := range paid {
+			installment, err := q.PayLoanInstallment(ctx, PayLoanInstallmentParams{
+				ID:        unpaid[i].ID,
+				Principal: p.Principal,
+				Interest:  p.Interest,
+				Penalty:   p.Penalty,
+			})
+			if err != nil {
+				return err
+			}
+
+			paidOff = paidOff && installment.Status == LoanInstallmentPaid
+			result.Installments = append(result.Installments, installment)
+		}
+
+		result.Repayment, err = q.CreateLoanRepayment(ctx, CreateLoanRepaymentParams{
+			LoanID:    loan.ID,
+			Amount:    arg.Amount,
+			Principal: split.Principal,
+			Interest:  split.Interest,
+			Penalty:   split.Penalty,
+			JournalID: result.Journal.Journal.ID,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Loan = loan
+		if paidOff {
+			result.Loan, err = q.UpdateLoanStatus(ctx, UpdateLoanStatusParams{
+				ID:     loan.ID,
+				Status: LoanPaidOff,
+			})
+		}
+		return err
+	})
+
+	return result, err
+}
+
+// checkRepayment locks the accounts of the loan and checks that the borrower account can pay amount
+func checkRepayment(ctx context.Context, q *Queries, loan Loan, amount int64) error {
+	ids := []int64{loan.AccountID, loan.BorrowerAccountID, loan.IncomeAccountID}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	locked, err := q.ListAccountsForUpdate(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	accounts := make(map[int64]Account, len(locked))
+	for _, account := range locked {
+		accounts[account.ID] = account
+	}
+
+	return checkTransfer(accounts[loan.BorrowerAccountID], accounts[loan.AccountID], loan.Currency, amount)
+}
+
+// ChargeOverdueLoanInstallmentTx marks one pending installment whose grace days passed by today as overdue
+// and charges the late fee of its loan on it; found is false when there is none
+func (store *Store) ChargeOverdueLoanInstallmentTx(ctx context.Context, today time.Time) (installment LoanInstallment, found bool, err error) {
+	err = store.execTx(ctx, func(q *Queries) error {
+		claimed, err := q.ClaimOverdueLoanInstallment(ctx, today)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		found = true
+
+		loan, err := q.GetLoan(ctx, claimed.LoanID)
+		if err != nil {
+			return err
+		}
+
+		installment, err = q.ChargeLoanInstallmentPenalty(ctx, ChargeLoanInstallmentPenaltyParams{
+			ID:      claimed.ID,
+			Penalty: loan.LateFee,
+		})
+		return err
+	})
+
+	return installment, found, err
+}
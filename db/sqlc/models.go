@@ -6,23 +6,389 @@ package db
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 type Account struct {
-	ID        int64     `json:"id"`
-	Owner     string    `json:"owner"`
+	ID    int64  `json:"id"`
+	Owner string `json:"owner"`
+	// ledger balance, the sum of the posted entries
 	Balance   int64     `json:"balance"`
 	Currency  string    `json:"currency"`
 	CreatedAt time.Time `json:"created_at"`
+	Status    string    `json:"status"`
+	// pricing tier, such as standard or premium
+	Tier string `json:"tier"`
+	// reserved by authorized holds
+	HeldBalance int64 `json:"held_balance"`
+	// what can be spent, the ledger balance less the held balance
+	AvailableBalance int64 `json:"available_balance"`
+	// account product, such as checking or savings
+	Product string `json:"product"`
+	// asset, liability, income, expense or equity; customer accounts are liabilities of the bank
+	Type string `json:"type"`
+	// general ledger code of an internal account, null for customer accounts
+	Code sql.NullString `json:"code"`
+}
+
+type AccountBalanceSnapshot struct {
+	AccountID int64 `json:"account_id"`
+	// UTC day the snapshot closes
+	Day time.Time `json:"day"`
+	// ledger balance at the end of the day
+	Balance   int64     `json:"balance"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// closed periods, no journal can be back-dated into them
+type AccountingPeriod struct {
+	ID       int64     `json:"id"`
+	Kind     string    `json:"kind"`
+	StartDay time.Time `json:"start_day"`
+	// last UTC day of the period
+	EndDay time.Time `json:"end_day"`
+	// admin or command that closed the period
+	ClosedBy string    `json:"closed_by"`
+	ClosedAt time.Time `json:"closed_at"`
+}
+
+// append-only record of who did what, each row hashes the previous one
+type ApiKey struct {
+	ID    int64  `json:"id"`
+	Owner string `json:"owner"`
+	Name  string `json:"name"`
+	// public part of the key, it finds the key without its secret
+	Prefix string `json:"prefix"`
+	// hex SHA-256 of the secret part of the key, the secret itself is never stored
+	HashedSecret string `json:"hashed_secret"`
+	// permissions the key is limited to, the role of its owner must grant them too
+	Scopes []string `json:"scopes"`
+	// IPs and CIDR ranges the key is accepted from, empty for anywhere
+	AllowedIps []string     `json:"allowed_ips"`
+	ExpiresAt  time.Time    `json:"expires_at"`
+	LastUsedAt sql.NullTime `json:"last_used_at"`
+	RevokedAt  sql.NullTime `json:"revoked_at"`
+	CreatedAt  time.Time    `json:"created_at"`
+	// signs the requests made with the key on the routes that require a signature, empty for the keys created before request signing
+	SigningSecret string `json:"signing_secret"`
+}
+
+type AuditLog struct {
+	ID int64 `json:"id"`
+	// username, system for workers and commands, anonymous before login
+	Actor      string `json:"actor"`
+	Action     string `json:"action"`
+	TargetType string `json:"target_type"`
+	TargetID   string `json:"target_id"`
+	// target before the action, json null when it did not exist
+	Before json.RawMessage `json:"before"`
+	// target after the action, json null when it was deleted
+	After     json.RawMessage `json:"after"`
+	IP        string          `json:"ip"`
+	UserAgent string          `json:"user_agent"`
+	RequestID string          `json:"request_id"`
+	// hash of the previous row, empty for the first row
+	PrevHash string `json:"prev_hash"`
+	// hex SHA-256 of prev_hash and every column but id
+	Hash      string    `json:"hash"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 type Entry struct {
 	ID        int64 `json:"id"`
 	AccountID int64 `json:"account_id"`
 	// can be negative or positive
-	Amount    int64        `json:"amount"`
-	CreatedAt sql.NullTime `json:"created_at"`
+	Amount    int64     `json:"amount"`
+	CreatedAt time.Time `json:"created_at"`
+	// the entries of a journal sum to zero per currency, null for entries posted before journals
+	JournalID sql.NullInt64 `json:"journal_id"`
+}
+
+type Hold struct {
+	ID        int64 `json:"id"`
+	AccountID int64 `json:"account_id"`
+	// credited when the hold is captured
+	ToAccountID int64 `json:"to_account_id"`
+	Amount      int64 `json:"amount"`
+	// posted so far, the rest of amount stays held until captured, voided or expired
+	CapturedAmount int64  `json:"captured_amount"`
+	Currency       string `json:"currency"`
+	// authorized until fully captured, voided or expired
+	Status      string    `json:"status"`
+	Description string    `json:"description"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	// fee quoted for amount at authorization, held on top of amount so that a capture cannot overdraw
+	Fee int64 `json:"fee"`
+}
+
+type IdempotencyKey struct {
+	Username      string `json:"username"`
+	Key           string `json:"key"`
+	RequestMethod string `json:"request_method"`
+	RequestPath   string `json:"request_path"`
+	RequestHash   string `json:"request_hash"`
+	// null while the first request is in flight
+	ResponseStatus sql.NullInt32 `json:"response_status"`
+	ResponseBody   []byte        `json:"response_body"`
+	CreatedAt      time.Time     `json:"created_at"`
+}
+
+type InterestAccrual struct {
+	ID        int64     `json:"id"`
+	AccountID int64     `json:"account_id"`
+	Day       time.Time `json:"day"`
+	// ledger balance at the end of the day
+	Balance  int64  `json:"balance"`
+	RateBps  int32  `json:"rate_bps"`
+	DayCount string `json:"day_count"`
+	// interest earned on the day, in millionths of the minor unit
+	AmountMicros int64 `json:"amount_micros"`
+	// null until the month is posted
+	PostingID sql.NullInt64 `json:"posting_id"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+type InterestPosting struct {
+	ID        int64 `json:"id"`
+	AccountID int64 `json:"account_id"`
+	// first day of the month whose accruals are posted
+	Month time.Time `json:"month"`
+	// accruals of the month plus the carry of the previous posting, in millionths of the minor unit
+	AccruedMicros int64 `json:"accrued_micros"`
+	// whole minor units posted to the account
+	Amount int64 `json:"amount"`
+	// fraction left over, carried into the next posting
+	CarryMicros int64 `json:"carry_micros"`
+	// null when the month earned less than one minor unit
+	JournalID sql.NullInt64 `json:"journal_id"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+// rate schedule of a product and currency, a rate applies from its effective day until the next one
+type InterestRate struct {
+	ID       int64  `json:"id"`
+	Product  string `json:"product"`
+	Currency string `json:"currency"`
+	// annual rate in basis points, 100 is 1%
+	RateBps       int32     `json:"rate_bps"`
+	EffectiveFrom time.Time `json:"effective_from"`
+	// interest-expense account paying the interest, it holds the currency of the rate
+	ExpenseAccountID int64     `json:"expense_account_id"`
+	CreatedBy        string    `json:"created_by"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+type Journal struct {
+	ID int64 `json:"id"`
+	// what posted the journal, such as transfer
+	Kind        string    `json:"kind"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	// when the journal applies, its entries are dated then; before created_at for back-dated adjustments
+	EffectiveAt time.Time `json:"effective_at"`
+}
+
+type Loan struct {
+	ID int64 `json:"id"`
+	// loan account, its balance is minus the principal still owed
+	AccountID int64 `json:"account_id"`
+	// receives the principal and pays the repayments
+	BorrowerAccountID int64 `json:"borrower_account_id"`
+	// credited with the interest and the late fees
+	IncomeAccountID int64 `json:"income_account_id"`
+	Principal       int64 `json:"principal"`
+	// annual rate in basis points, 100 is 1%
+	RateBps    int32 `json:"rate_bps"`
+	TermMonths int32 `json:"term_months"`
+	// annuity or straight_line
+	Method   string `json:"method"`
+	Currency string `json:"currency"`
+	// added to the penalty of an installment once it is overdue
+	LateFee int64 `json:"late_fee"`
+	// days after its due date before an unpaid installment is overdue
+	GraceDays int32     `json:"grace_days"`
+	Status    string    `json:"status"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type LoanInstallment struct {
+	ID        int64     `json:"id"`
+	LoanID    int64     `json:"loan_id"`
+	Number    int32     `json:"number"`
+	DueDate   time.Time `json:"due_date"`
+	Principal int64     `json:"principal"`
+	Interest  int64     `json:"interest"`
+	// late fees charged on the installment
+	Penalty       int64 `json:"penalty"`
+	PaidPrincipal int64 `json:"paid_principal"`
+	PaidInterest  int64 `json:"paid_interest"`
+	PaidPenalty   int64 `json:"paid_penalty"`
+	// pending until paid, overdue once the grace days passed unpaid
+	Status    string    `json:"status"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type LoanRepayment struct {
+	ID        int64 `json:"id"`
+	LoanID    int64 `json:"loan_id"`
+	Amount    int64 `json:"amount"`
+	Principal int64 `json:"principal"`
+	Interest  int64 `json:"interest"`
+	Penalty   int64 `json:"penalty"`
+	// the journal splitting the repayment into principal, interest and penalty entries
+	JournalID int64     `json:"journal_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// failed logins by username and client IP, a caller guessing passwords locks out its own IP rather than the user
+type LoginFailure struct {
+	// the username tried, whether or not such a user exists
+	Username string `json:"username"`
+	ClientIp string `json:"client_ip"`
+	// failed logins since the last successful one or the last lockout
+	Failures int32 `json:"failures"`
+	// logins of the username from the client IP are refused until then after too many failed ones
+	LockedUntil sql.NullTime `json:"locked_until"`
+}
+
+type OutboxEvent struct {
+	ID int64 `json:"id"`
+	// events of the same account are published in id order
+	AccountID     int64           `json:"account_id"`
+	EventType     string          `json:"event_type"`
+	SchemaVersion int32           `json:"schema_version"`
+	Payload       json.RawMessage `json:"payload"`
+	Attempts      int32           `json:"attempts"`
+	LastError     sql.NullString  `json:"last_error"`
+	PublishedAt   sql.NullTime    `json:"published_at"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+type PeriodTrialBalance struct {
+	PeriodID int64  `json:"period_id"`
+	Currency string `json:"currency"`
+	Accounts int32  `json:"accounts"`
+	// sum of the negative closing balances, as a positive amount
+	Debits int64 `json:"debits"`
+	// sum of the positive closing balances
+	Credits int64  `json:"credits"`
+	Type    string `json:"type"`
+}
+
+type PricingRule struct {
+	ID int64 `json:"id"`
+	// null applies to every tier
+	Tier      sql.NullString `json:"tier"`
+	Currency  string         `json:"currency"`
+	MinAmount int64          `json:"min_amount"`
+	// exclusive, null for no upper bound
+	MaxAmount sql.NullInt64 `json:"max_amount"`
+	FlatFee   int64         `json:"flat_fee"`
+	// percentage of the amount in basis points, 100 is 1%
+	PercentBps int32 `json:"percent_bps"`
+	MinFee     int64 `json:"min_fee"`
+	// null for no cap
+	MaxFee sql.NullInt64 `json:"max_fee"`
+	// revenue account credited with the fee, it holds the currency of the rule
+	FeeAccountID int64 `json:"fee_account_id"`
+	// the matching rule with the highest priority prices the transfer
+	Priority  int32     `json:"priority"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Product struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+	// day-count convention of the interest, ACT/365 or 30/360
+	DayCount  string    `json:"day_count"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type RateLimitBucket struct {
+	Key string `json:"key"`
+	// when the bucket is full again, each token taken pushes it back by the refill interval of a token
+	FullAt time.Time `json:"full_at"`
+}
+
+type RequestNonce struct {
+	ApiKeyID  int64     `json:"api_key_id"`
+	Nonce     string    `json:"nonce"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type RiskAssessment struct {
+	ID            int64  `json:"id"`
+	Owner         string `json:"owner"`
+	FromAccountID int64  `json:"from_account_id"`
+	ToAccountID   int64  `json:"to_account_id"`
+	Amount        int64  `json:"amount"`
+	Currency      string `json:"currency"`
+	Decision      string `json:"decision"`
+	Score         int32  `json:"score"`
+	// the rules that fired, with their score, decision and reason
+	Hits json.RawMessage `json:"hits"`
+	// allowed, blocked, or pending review until approved or rejected
+	Status string `json:"status"`
+	// the transfer made once allowed or approved
+	TransferID sql.NullInt64  `json:"transfer_id"`
+	ReviewedBy sql.NullString `json:"reviewed_by"`
+	ReviewedAt sql.NullTime   `json:"reviewed_at"`
+	CreatedAt  time.Time      `json:"created_at"`
+}
+
+type ScheduledTransfer struct {
+	ID            int64     `json:"id"`
+	Owner         string    `json:"owner"`
+	FromAccountID int64     `json:"from_account_id"`
+	ToAccountID   int64     `json:"to_account_id"`
+	Amount        int64     `json:"amount"`
+	Currency      string    `json:"currency"`
+	StartAt       time.Time `json:"start_at"`
+	// RRULE such as FREQ=MONTHLY;INTERVAL=1, empty for a one-off transfer at start_at
+	Recurrence string       `json:"recurrence"`
+	EndAt      sql.NullTime `json:"end_at"`
+	Status     string       `json:"status"`
+	// occurrence to execute next, null once the schedule is over
+	NextOccurrenceAt sql.NullTime `json:"next_occurrence_at"`
+	// when the next occurrence is attempted, later than it while retrying
+	NextRunAt sql.NullTime `json:"next_run_at"`
+	// failed attempts of the next occurrence
+	Attempts  int32          `json:"attempts"`
+	LastError sql.NullString `json:"last_error"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+type ScheduledTransferRun struct {
+	ID                  int64 `json:"id"`
+	ScheduledTransferID int64 `json:"scheduled_transfer_id"`
+	// an occurrence runs at most once: its run is recorded in the tx of its transfer
+	OccurrenceAt time.Time      `json:"occurrence_at"`
+	Status       string         `json:"status"`
+	Attempts     int32          `json:"attempts"`
+	TransferID   sql.NullInt64  `json:"transfer_id"`
+	LastError    sql.NullString `json:"last_error"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+}
+
+type Session struct {
+	ID           uuid.UUID `json:"id"`
+	Username     string    `json:"username"`
+	RefreshToken string    `json:"refresh_token"`
+	UserAgent    string    `json:"user_agent"`
+	ClientIp     string    `json:"client_ip"`
+	IsBlocked    bool      `json:"is_blocked"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	CreatedAt    time.Time `json:"created_at"`
 }
 
 type Transfer struct {
@@ -30,6 +396,101 @@ type Transfer struct {
 	FromAccountID int64 `json:"from_account_id"`
 	ToAccountID   int64 `json:"to_account_id"`
 	// must be positive
-	Amount    int64        `json:"amount"`
-	CreatedAt sql.NullTime `json:"created_at"`
+	Amount    int64     `json:"amount"`
+	CreatedAt time.Time `json:"created_at"`
+	// null for transfers made before journals
+	JournalID sql.NullInt64 `json:"journal_id"`
+}
+
+type TransferBatch struct {
+	ID    int64  `json:"id"`
+	Owner string `json:"owner"`
+	// atomic runs every row in one tx or none, best_effort runs the rows it can
+	Mode          string `json:"mode"`
+	Status        string `json:"status"`
+	TotalRows     int32  `json:"total_rows"`
+	SucceededRows int32  `json:"succeeded_rows"`
+	// rows not run, including the rows skipped by a failed atomic batch
+	FailedRows int32 `json:"failed_rows"`
+	// a processing batch whose lease ran out is picked up by another worker
+	LeaseUntil  sql.NullTime `json:"lease_until"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+	CompletedAt sql.NullTime `json:"completed_at"`
+}
+
+type TransferBatchItem struct {
+	ID      int64 `json:"id"`
+	BatchID int64 `json:"batch_id"`
+	// position of the row in the upload, starting at 1
+	RowNumber     int32  `json:"row_number"`
+	FromAccountID int64  `json:"from_account_id"`
+	ToAccountID   int64  `json:"to_account_id"`
+	Amount        int64  `json:"amount"`
+	Currency      string `json:"currency"`
+	// skipped when an atomic batch is rolled back because of another row
+	Status     string         `json:"status"`
+	TransferID sql.NullInt64  `json:"transfer_id"`
+	Error      sql.NullString `json:"error"`
+}
+
+// append only, every change of a limit is a new row
+type TransferLimit struct {
+	ID int64 `json:"id"`
+	// limits the account, a row without account and owner is the default of every account
+	AccountID sql.NullInt64 `json:"account_id"`
+	// limits every account of the user
+	Owner sql.NullString `json:"owner"`
+	// null for no limit, like every limit
+	MaxAmount sql.NullInt64 `json:"max_amount"`
+	// outgoing volume since the start of the UTC day
+	DailyAmount sql.NullInt64 `json:"daily_amount"`
+	// outgoing volume since the start of the UTC month
+	MonthlyAmount sql.NullInt64 `json:"monthly_amount"`
+	// outgoing transfers in the last hour
+	HourlyCount sql.NullInt32 `json:"hourly_count"`
+	// a temporary change, the previous limits apply again after it
+	ExpiresAt sql.NullTime `json:"expires_at"`
+	Reason    string       `json:"reason"`
+	// admin who changed the limits
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type User struct {
+	Username          string    `json:"username"`
+	HashedPassword    string    `json:"hashed_password"`
+	FullName          string    `json:"full_name"`
+	Email             string    `json:"email"`
+	PasswordChangedAt time.Time `json:"password_changed_at"`
+	CreatedAt         time.Time `json:"created_at"`
+	// admin, support or customer, it decides what the user is permitted to do
+	Role string `json:"role"`
+}
+
+type WebhookDelivery struct {
+	ID             int64           `json:"id"`
+	SubscriptionID int64           `json:"subscription_id"`
+	EventID        int64           `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"`
+	// pending, succeeded or dead
+	Status        string    `json:"status"`
+	Attempts      int32     `json:"attempts"`
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	// a delivery still failing after this time is dead-lettered
+	ExpiresAt      time.Time      `json:"expires_at"`
+	LastAttemptAt  sql.NullTime   `json:"last_attempt_at"`
+	ResponseStatus sql.NullInt32  `json:"response_status"`
+	LastError      sql.NullString `json:"last_error"`
+	CreatedAt      time.Time      `json:"created_at"`
+}
+
+type WebhookSubscription struct {
+	ID         int64     `json:"id"`
+	Owner      string    `json:"owner"`
+	Url        string    `json:"url"`
+	EventTypes []string  `json:"event_types"`
+	Secret     string    `json:"secret"`
+	CreatedAt  time.Time `json:"created_at"`
 }
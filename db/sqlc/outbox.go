@@ -0,0 +1,103 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"simplebank/event"
+	"simplebank/logging"
+)
+
+// insertEvent records payload in the outbox, inside the tx of q so it commits with the change it describes
+// it must run after the rows of the account are locked, so that ids follow the commit order of each account
+func insertEvent(ctx context.Context, q *Queries, payload event.Payload) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.CreateOutboxEvent(ctx, CreateOutboxEventParams{
+		AccountID:     payload.PartitionKey(),
+		EventType:     string(payload.EventType()),
+		SchemaVersion: payload.SchemaVersion(),
+		Payload:       data,
+	})
+
+	return err
+}
+
+// Event converts the outbox row into the published envelope
+func (e OutboxEvent) Event() event.Event {
+	return event.Event{
+		ID:         e.ID,
+		Type:       event.Type(e.EventType),
+		Version:    e.SchemaVersion,
+		AccountID:  e.AccountID,
+		OccurredAt: e.CreatedAt,
+		Data:       e.Payload,
+	}
+}
+
+// RelayOutboxTxResult counts what happened to a batch of outbox events
+type RelayOutboxTxResult struct {
+	Published int
+	Failed    int
+	Skipped   int
+}
+
+// RelayOutboxTx locks the oldest unpublished events and hands them to publish in id order
+// an event is marked published only once publish succeeded, so delivery is at least once
+// after a failure the later events of the same account are skipped until the failed one goes through,
+// which keeps the events of an account in order; concurrent relays wait for each other on the row locks
+func (store *Store) RelayOutboxTx(ctx context.Context, limit int32, publish func(context.Context, event.Event) error) (RelayOutboxTxResult, error) {
+	var result RelayOutboxTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		result = RelayOutboxTxResult{}
+
+		events, err := q.ListUnpublishedOutboxEventsForUpdate(ctx, limit)
+		if err != nil {
+			return err
+		}
+
+		blocked := make(map[int64]bool)
+
+		for _, e := range events {
+			if blocked[e.AccountID] {
+				result.Skipped++
+				continue
+			}
+
+			if publishErr := publish(ctx, e.Event()); publishErr != nil {
+				blocked[e.AccountID] = true
+				result.Failed++
+
+				logging.FromContext(ctx).WarnContext(ctx, "outbox publish failed",
+					slog.Int64("event_id", e.ID),
+					slog.String("event_type", e.EventType),
+					slog.Int64("account_id", e.AccountID),
+					slog.Any("error", publishErr),
+				)
+
+				err = q.MarkOutboxEventFailed(ctx, MarkOutboxEventFailedParams{
+					ID:        e.ID,
+					LastError: sql.NullString{String: publishErr.Error(), Valid: true},
+				})
+				if err != nil {
+					return err
+				}
+				continue
+			}
+
+			if err := q.MarkOutboxEventPublished(ctx, e.ID); err != nil {
+				return err
+			}
+			result.Published++
+		}
+
+		return nil
+	})
+
+	return result, err
+}
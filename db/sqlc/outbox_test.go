@@ -0,0 +1,165 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"simplebank/event"
+	"simplebank/util"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func listAccountEvents(t *testing.T, accountID int64) []OutboxEvent {
+	events, err := testQueries.ListAccountOutboxEvents(context.Background(), ListAccountOutboxEventsParams{
+		AccountID:  accountID,
+		AfterID:    0,
+		LimitCount: 100,
+	})
+	require.NoError(t, err)
+
+	for _, e := range events {
+		require.NoError(t, event.Validate(e.Event()))
+	}
+
+	return events
+}
+
+func TestCreateAccountTx(t *testing.T) {
+	store := NewStore(testDb)
+	user := createRandomUser(t)
+
+	account, err := store.CreateAccountTx(context.Background(), CreateAccountParams{
+		Owner:    user.Username,
+		Balance:  0,
+		Currency: util.RanddomCurrency(),
+	})
+	require.NoError(t, err)
+
+	events := listAccountEvents(t, account.ID)
+	require.Len(t, events, 1)
+	require.Equal(t, string(event.AccountCreated), events[0].EventType)
+	require.Equal(t, int32(1), events[0].SchemaVersion)
+
+	var payload event.AccountCreatedV1
+	require.NoError(t, json.Unmarshal(events[0].Payload, &payload))
+	require.Equal(t, account.ID, payload.AccountID)
+	require.Equal(t, user.Username, payload.Owner)
+}
+
+func TestTransferTxWritesOutboxEvents(t *testing.T) {
+	store := NewStore(testDb)
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+
+	// from the higher ID to the lower one, so the accounts are locked in reverse order
+	result, err := store.TransferTX(context.Background(), TransferCreateParams{
+		FromAccountID: account2.ID,
+		ToAccountID:   account1.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+	require.Equal(t, account2.ID, result.FromAccount.ID)
+	require.Equal(t, account1.ID, result.ToAccount.ID)
+
+	fromEvents := listAccountEvents(t, account2.ID)
+	require.Len(t, fromEvents, 2)
+	require.Equal(t, string(event.TransferCompleted), fromEvents[0].EventType)
+	require.Equal(t, string(event.EntryPosted), fromEvents[1].EventType)
+
+	var entry event.EntryPostedV1
+	require.NoError(t, json.Unmarshal(fromEvents[1].Payload, &entry))
+	require.Equal(t, result.FromEntrie.ID, entry.EntryID)
+	require.Equal(t, result.FromAccount.Balance, entry.Balance)
+
+	toEvents := listAccountEvents(t, account1.ID)
+	require.Len(t, toEvents, 1)
+	require.Equal(t, string(event.EntryPosted), toEvents[0].EventType)
+
+	require.NoError(t, json.Unmarshal(toEvents[0].Payload, &entry))
+	require.Equal(t, result.ToEntrie.ID, entry.EntryID)
+	require.Equal(t, result.ToAccount.Balance, entry.Balance)
+}
+
+func TestUpdateAccountStatusTx(t *testing.T) {
+	store := NewStore(testDb)
+	account := createRandomAccount(t)
+
+	updated, err := store.UpdateAccountStatusTx(context.Background(), UpdateAccountStatusParams{
+		ID:     account.ID,
+		Status: AccountStatusFrozen,
+	})
+	require.NoError(t, err)
+	require.Equal(t, AccountStatusFrozen, updated.Status)
+
+	// setting the same status again records nothing
+	_, err = store.UpdateAccountStatusTx(context.Background(), UpdateAccountStatusParams{
+		ID:     account.ID,
+		Status: AccountStatusFrozen,
+	})
+	require.NoError(t, err)
+
+	events := listAccountEvents(t, account.ID)
+	require.Len(t, events, 1)
+
+	var payload event.AccountStatusChangedV1
+	require.NoError(t, json.Unmarshal(events[0].Payload, &payload))
+	require.Equal(t, AccountStatusActive, payload.OldStatus)
+	require.Equal(t, AccountStatusFrozen, payload.NewStatus)
+}
+
+func TestRelayOutboxTx(t *testing.T) {
+	store := NewStore(testDb)
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	for i := 0; i < 2; i++ {
+		_, err := store.UpdateAccountStatusTx(context.Background(), UpdateAccountStatusParams{ID: account1.ID, Status: AccountStatusFrozen})
+		require.NoError(t, err)
+		_, err = store.UpdateAccountStatusTx(context.Background(), UpdateAccountStatusParams{ID: account1.ID, Status: AccountStatusActive})
+		require.NoError(t, err)
+	}
+
+	_, err := store.UpdateAccountStatusTx(context.Background(), UpdateAccountStatusParams{ID: account2.ID, Status: AccountStatusClosed})
+	require.NoError(t, err)
+
+	// the first event of account1 fails once, the events of other accounts go through
+	var published []event.Event
+	failed := false
+	publish := func(ctx context.Context, e event.Event) error {
+		if e.AccountID != account1.ID && e.AccountID != account2.ID {
+			return nil
+		}
+
+		if e.AccountID == account1.ID && !failed {
+			failed = true
+			return errors.New("broker unavailable")
+		}
+
+		published = append(published, e)
+		return nil
+	}
+
+	for i := 0; i < 10 && len(published) < 5; i++ {
+		_, err := store.RelayOutboxTx(context.Background(), 1000, publish)
+		require.NoError(t, err)
+	}
+
+	require.Len(t, published, 5)
+
+	var account1Events []int64
+	for _, e := range published {
+		if e.AccountID == account1.ID {
+			account1Events = append(account1Events, e.ID)
+		}
+	}
+	require.Len(t, account1Events, 4)
+	require.IsIncreasing(t, account1Events)
+
+	events := listAccountEvents(t, account1.ID)
+	require.True(t, events[0].PublishedAt.Valid)
+	require.Equal(t, int32(2), events[0].Attempts)
+}
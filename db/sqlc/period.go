@@ -0,0 +1,159 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Accounting period kinds
+const (
+	PeriodDay   = "day"
+	PeriodMonth = "month"
+)
+
+// unbalancedJournalsShown bounds the journals named when the integrity check fails
+const unbalancedJournalsShown = 20
+
+var (
+	ErrPeriodClosed         = errors.New("accounting period is closed")
+	ErrPeriodNotEnded       = errors.New("accounting period has not ended")
+	ErrIntegrityCheckFailed = errors.New("integrity check failed")
+)
+
+// PeriodBounds returns the first and last UTC day of the period of kind starting on start,
+// a month starts on its first day
+func PeriodBounds(kind string, start time.Time) (first time.Time, last time.Time, err error) {
+	first = start.UTC().Truncate(24 * time.Hour)
+
+	switch kind {
+	case PeriodDay:
+		return first, first, nil
+	case PeriodMonth:
+		if first.Day() != 1 {
+			return first, first, fmt.Errorf("a month starts on its first day, not %s", first.Format(time.DateOnly))
+		}
+		return first, first.AddDate(0, 1, -1), nil
+	default:
+		return first, first, fmt.Errorf("unknown period kind %q", kind)
+	}
+}
+
+// ClosePeriodTxParams closes the day or the month starting on Start
+type ClosePeriodTxParams struct {
+	Kind     string
+	Start    time.Time
+	ClosedBy string
+	// Now is when the close runs, the period must have ended SettleDelay before
+	Now time.Time
+}
+
+// ClosePeriodTxResult is the closed period and its trial balance by currency and account type
+type ClosePeriodTxResult struct {
+	Period       AccountingPeriod     `json:"period"`
+	TrialBalance []PeriodTrialBalance `json:"trial_balance"`
+	// Snapshots counts the balance snapshots the close wrote
+	Snapshots int `json:"snapshots"`
+}
+
+// ClosePeriodTx snapshots the balances of every day of the period, checks that every journal of the period
+// is balanced, then records the period as closed with the trial balance of its closing balances;
+// from then on no journal can be back-dated into the period
+func (store *Store) ClosePeriodTx(ctx context.Context, arg ClosePeriodTxParams) (ClosePeriodTxResult, error) {
+	var result ClosePeriodTxResult
+
+	first, last, err := PeriodBounds(arg.Kind, arg.Start)
+	if err != nil {
+		return result, err
+	}
+
+	// the transactions open at the end of the period may still post entries in it until SettleDelay has passed,
+	// the snapshots and the integrity check would miss them
+	end := last.AddDate(0, 0, 1)
+	if end.Add(SettleDelay).After(arg.Now) {
+		return result, fmt.Errorf("%w: it ends at %s and can be closed from %s", ErrPeriodNotEnded,
+			end.Format(time.RFC3339), end.Add(SettleDelay).Format(time.RFC3339))
+	}
+
+	for day := first; day.Before(end); day = day.AddDate(0, 0, 1) {
+		snapshots, err := store.SnapshotDay(ctx, day)
+		result.Snapshots += snapshots
+		if err != nil {
+			return result, err
+		}
+	}
+
+	err = store.execTx(ctx, func(q *Queries) error {
+		result.TrialBalance = nil
+
+		// back-dated journals wait for the close, or the close for them
+		if err := q.LockAccountingPeriods(ctx); err != nil {
+			return err
+		}
+
+		closed, err := q.GetClosedPeriod(ctx, GetClosedPeriodParams{StartDay: first, EndDay: last})
+		if err == nil {
+			return fmt.Errorf("%w: the %s period from %s was closed on %s", ErrPeriodClosed,
+				closed.Kind, closed.StartDay.Format(time.DateOnly), closed.ClosedAt.Format(time.DateOnly))
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		unbalanced, err := q.ListUnbalancedJournals(ctx, ListUnbalancedJournalsParams{
+			FromAt:     first,
+			ToAt:       end,
+			LimitCount: unbalancedJournalsShown,
+		})
+		if err != nil {
+			return err
+		}
+
+		if len(unbalanced) > 0 {
+			return fmt.Errorf("%w: journals %v do not sum to zero", ErrIntegrityCheckFailed, unbalanced)
+		}
+
+		result.Period, err = q.CreateAccountingPeriod(ctx, CreateAccountingPeriodParams{
+			Kind:     arg.Kind,
+			StartDay: first,
+			EndDay:   last,
+			ClosedBy: arg.ClosedBy,
+		})
+		if err != nil {
+			return err
+		}
+
+		rows, err := q.GetTrialBalanceAt(ctx, end)
+		if err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			line, err := q.CreatePeriodTrialBalance(ctx, CreatePeriodTrialBalanceParams{
+				PeriodID: result.Period.ID,
+				Currency: row.Currency,
+				Type:     row.Type,
+				Accounts: row.Accounts,
+				Debits:   row.Debits,
+				Credits:  row.Credits,
+			})
+			if err != nil {
+				return err
+			}
+			result.TrialBalance = append(result.TrialBalance, line)
+		}
+
+		_, err = recordAudit(ctx, q, AuditEntry{
+			Action:     AuditPeriodClose,
+			TargetType: AuditTargetPeriod,
+			TargetID:   strconv.FormatInt(result.Period.ID, 10),
+			After:      result.Period,
+		})
+		return err
+	})
+
+	return result, err
+}
@@ -0,0 +1,142 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// randomPastDay returns a day long before any account, so that closing it touches no other test
+func randomPastDay() time.Time {
+	return time.Date(int(util.RandomInt(1000, 1900)), time.Month(util.RandomInt(1, 12)), int(util.RandomInt(1, 28)), 0, 0, 0, 0, time.UTC)
+}
+
+func TestPeriodBounds(t *testing.T) {
+	day := time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)
+
+	first, last, err := PeriodBounds(PeriodDay, day)
+	require.NoError(t, err)
+	require.Equal(t, day, first)
+	require.Equal(t, day, last)
+
+	first, last, err = PeriodBounds(PeriodMonth, day)
+	require.NoError(t, err)
+	require.Equal(t, day, first)
+	require.Equal(t, time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC), last)
+
+	_, _, err = PeriodBounds(PeriodMonth, day.AddDate(0, 0, 1))
+	require.Error(t, err)
+
+	_, _, err = PeriodBounds("year", day)
+	require.Error(t, err)
+}
+
+func TestClosePeriodTx(t *testing.T) {
+	store := NewStore(testDb)
+	day := randomPastDay()
+
+	result, err := store.ClosePeriodTx(context.Background(), ClosePeriodTxParams{
+		Kind:     PeriodDay,
+		Start:    day,
+		ClosedBy: "test",
+		Now:      time.Now(),
+	})
+	require.NoError(t, err)
+	require.NotZero(t, result.Period.ID)
+	require.Equal(t, PeriodDay, result.Period.Kind)
+	require.True(t, day.Equal(result.Period.StartDay))
+	require.True(t, day.Equal(result.Period.EndDay))
+	require.Empty(t, result.TrialBalance)
+
+	_, err = store.ClosePeriodTx(context.Background(), ClosePeriodTxParams{
+		Kind:     PeriodDay,
+		Start:    day,
+		ClosedBy: "test",
+		Now:      time.Now(),
+	})
+	require.ErrorIs(t, err, ErrPeriodClosed)
+
+	// back-dated journals are rejected in the closed day and posted in an open one
+	payer := createRandomAccountWithCurrency(t, "USD")
+	payee := createRandomAccountWithCurrency(t, "USD")
+	legs := []Leg{
+		{AccountID: payer.ID, Amount: -10},
+		{AccountID: payee.ID, Amount: 10},
+	}
+
+	_, err = store.PostJournalTx(context.Background(), PostJournalTxParams{
+		Kind:        JournalKindAdjustment,
+		Legs:        legs,
+		EffectiveAt: day.Add(12 * time.Hour),
+	})
+	require.ErrorIs(t, err, ErrPeriodClosed)
+
+	effectiveAt := day.AddDate(0, 0, 1).Add(12 * time.Hour)
+	posted, err := store.PostJournalTx(context.Background(), PostJournalTxParams{
+		Kind:        JournalKindAdjustment,
+		Legs:        legs,
+		EffectiveAt: effectiveAt,
+	})
+	require.NoError(t, err)
+	require.True(t, effectiveAt.Equal(posted.Journal.EffectiveAt))
+	for _, entry := range posted.Entries {
+		require.True(t, effectiveAt.Equal(entry.CreatedAt))
+	}
+}
+
+func TestClosePeriodTxNotEnded(t *testing.T) {
+	store := NewStore(testDb)
+
+	_, err := store.ClosePeriodTx(context.Background(), ClosePeriodTxParams{
+		Kind:     PeriodDay,
+		Start:    time.Now(),
+		ClosedBy: "test",
+		Now:      time.Now(),
+	})
+	require.ErrorIs(t, err, ErrPeriodNotEnded)
+
+	// a day that just ended waits for the transactions still open at midnight
+	day := randomPastDay()
+	_, err = store.ClosePeriodTx(context.Background(), ClosePeriodTxParams{
+		Kind:     PeriodDay,
+		Start:    day,
+		ClosedBy: "test",
+		Now:      day.AddDate(0, 0, 1).Add(SettleDelay - time.Second),
+	})
+	require.ErrorIs(t, err, ErrPeriodNotEnded)
+}
+
+func TestClosePeriodTxUnbalancedJournal(t *testing.T) {
+	store := NewStore(testDb)
+	day := randomPastDay()
+	account := createRandomAccountWithCurrency(t, "USD")
+
+	journal, err := testQueries.CreateJournal(context.Background(), CreateJournalParams{
+		Kind:        JournalKindAdjustment,
+		EffectiveAt: sql.NullTime{Time: day.Add(time.Hour), Valid: true},
+	})
+	require.NoError(t, err)
+
+	_, err = testQueries.CreateJournalEntry(context.Background(), CreateJournalEntryParams{
+		JournalID: sql.NullInt64{Int64: journal.ID, Valid: true},
+		AccountID: account.ID,
+		Amount:    5,
+		CreatedAt: journal.EffectiveAt,
+	})
+	require.NoError(t, err)
+
+	_, err = store.ClosePeriodTx(context.Background(), ClosePeriodTxParams{
+		Kind:     PeriodDay,
+		Start:    day,
+		ClosedBy: "test",
+		Now:      time.Now(),
+	})
+	require.ErrorIs(t, err, ErrIntegrityCheckFailed)
+
+	_, err = testQueries.GetClosedPeriod(context.Background(), GetClosedPeriodParams{StartDay: day, EndDay: day})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
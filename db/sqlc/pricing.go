@@ -0,0 +1,174 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"simplebank/pricing"
+	"strconv"
+)
+
+// Account tiers
+const (
+	AccountTierStandard = "standard"
+	AccountTierPremium  = "premium"
+)
+
+// ErrInvalidFeeAccount is returned for a pricing rule crediting its fees to an account that cannot take them,
+// every transfer the rule priced would fail to post
+var ErrInvalidFeeAccount = errors.New("fee account must be an active internal income account in the currency of the rule")
+
+// checkFeeAccount makes sure the fee account of a rule is an active internal income account in the currency of the rule
+func checkFeeAccount(ctx context.Context, q *Queries, accountID int64, currency string) error {
+	account, err := q.GetAccount(ctx, accountID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: account [%d] not found", ErrInvalidFeeAccount, accountID)
+		}
+		return err
+	}
+
+	switch {
+	case !account.Code.Valid || account.Type != AccountTypeIncome:
+		return fmt.Errorf("%w: account [%d] is not an internal income account", ErrInvalidFeeAccount, account.ID)
+	case account.Currency != currency:
+		return fmt.Errorf("%w: account [%d] holds %s", ErrInvalidFeeAccount, account.ID, account.Currency)
+	case account.Status != AccountStatusActive:
+		return fmt.Errorf("%w: account [%d] is %s", ErrInvalidFeeAccount, account.ID, account.Status)
+	}
+
+	return nil
+}
+
+// CreatePricingRuleTx adds a pricing rule once its fee account is checked
+func (store *Store) CreatePricingRuleTx(ctx context.Context, arg CreatePricingRuleParams) (PricingRule, error) {
+	var rule PricingRule
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		err := checkFeeAccount(ctx, q, arg.FeeAccountID, arg.Currency)
+		if err != nil {
+			return err
+		}
+
+		rule, err = q.CreatePricingRule(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		_, err = recordAudit(ctx, q, AuditEntry{
+			Action:     AuditPricingRuleCreate,
+			TargetType: AuditTargetPricingRule,
+			TargetID:   strconv.FormatInt(rule.ID, 10),
+			After:      rule,
+		})
+		return err
+	})
+
+	return rule, err
+}
+
+// UpdatePricingRuleTx replaces a pricing rule, the fee account of an active rule is checked again;
+// rules are deactivated rather than deleted
+func (store *Store) UpdatePricingRuleTx(ctx context.Context, arg UpdatePricingRuleParams) (PricingRule, error) {
+	var rule PricingRule
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		current, err := q.GetPricingRuleForUpdate(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+
+		if arg.Active {
+			err = checkFeeAccount(ctx, q, arg.FeeAccountID, arg.Currency)
+			if err != nil {
+				return err
+			}
+		}
+
+		rule, err = q.UpdatePricingRule(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		_, err = recordAudit(ctx, q, AuditEntry{
+			Action:     AuditPricingRuleUpdate,
+			TargetType: AuditTargetPricingRule,
+			TargetID:   strconv.FormatInt(rule.ID, 10),
+			Before:     current,
+			After:      rule,
+		})
+		return err
+	})
+
+	return rule, err
+}
+
+// UpdateAccountTierTx moves an account to another pricing tier
+func (store *Store) UpdateAccountTierTx(ctx context.Context, arg UpdateAccountTierParams) (Account, error) {
+	var account Account
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		current, err := q.GetAccountForUpdate(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+
+		account = current
+		if current.Tier == arg.Tier {
+			return nil
+		}
+
+		account, err = q.UpdateAccountTier(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		_, err = recordAudit(ctx, q, AuditEntry{
+			Action:     AuditAccountTier,
+			TargetType: AuditTargetAccount,
+			TargetID:   strconv.FormatInt(account.ID, 10),
+			Before:     map[string]string{"tier": current.Tier},
+			After:      map[string]string{"tier": account.Tier},
+		})
+		return err
+	})
+
+	return account, err
+}
+
+// QuoteTransferFee previews the fee the pricing rules charge for moving amount out of the from account
+func (store *Store) QuoteTransferFee(ctx context.Context, from Account, amount int64) (pricing.Fee, error) {
+	return transferFee(ctx, store.Queries, from, amount)
+}
+
+// transferFee prices a transfer of amount out of the from account with the active rules of its currency
+func transferFee(ctx context.Context, q *Queries, from Account, amount int64) (pricing.Fee, error) {
+	rows, err := q.ListActivePricingRules(ctx, from.Currency)
+	if err != nil {
+		return pricing.Fee{}, err
+	}
+
+	rules := make([]pricing.Rule, len(rows))
+	for i, row := range rows {
+		rules[i] = pricingRule(row)
+	}
+
+	return pricing.Quote(rules, from.Tier, from.Currency, amount), nil
+}
+
+func pricingRule(row PricingRule) pricing.Rule {
+	return pricing.Rule{
+		ID:           row.ID,
+		Tier:         row.Tier.String,
+		Currency:     row.Currency,
+		MinAmount:    row.MinAmount,
+		MaxAmount:    row.MaxAmount.Int64,
+		FlatFee:      row.FlatFee,
+		PercentBps:   row.PercentBps,
+		MinFee:       row.MinFee,
+		MaxFee:       row.MaxFee.Int64,
+		FeeAccountID: row.FeeAccountID,
+		Priority:     row.Priority,
+	}
+}
@@ -0,0 +1,222 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"simplebank/event"
+	"simplebank/util"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// createRandomTierAccount moves a new account to a tier of its own, so that the rules of a test price no other transfer
+func createRandomTierAccount(t *testing.T, currency string) Account {
+	account := createRandomAccountWithCurrency(t, currency)
+
+	account, err := testQueries.UpdateAccountTier(context.Background(), UpdateAccountTierParams{
+		ID:   account.ID,
+		Tier: "test-" + util.RandomString(8),
+	})
+	require.NoError(t, err)
+
+	return account
+}
+
+func createRandomPricingRule(t *testing.T, arg CreatePricingRuleParams) PricingRule {
+	rule, err := testQueries.CreatePricingRule(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, rule.ID)
+	require.True(t, rule.Active)
+
+	return rule
+}
+
+func TestTransferTxChargesFee(t *testing.T) {
+	store := NewStore(testDb)
+
+	payer := createRandomTierAccount(t, "USD")
+	payee := createRandomAccountWithCurrency(t, "USD")
+	revenue := createRandomAccountWithCurrency(t, "USD")
+
+	rule := createRandomPricingRule(t, CreatePricingRuleParams{
+		Tier:         sql.NullString{String: payer.Tier, Valid: true},
+		Currency:     "USD",
+		FlatFee:      2,
+		PercentBps:   100,
+		FeeAccountID: revenue.ID,
+	})
+
+	result, err := store.TransferTX(context.Background(), TransferCreateParams{
+		FromAccountID: payer.ID,
+		ToAccountID:   payee.ID,
+		Amount:        500,
+	})
+	require.NoError(t, err)
+
+	require.NotNil(t, result.Fee)
+	require.Equal(t, rule.ID, result.Fee.RuleID)
+	require.Equal(t, revenue.ID, result.Fee.FeeAccountID)
+	require.Equal(t, int64(2), result.Fee.Flat)
+	require.Equal(t, int64(5), result.Fee.Percentage)
+	require.Equal(t, int64(7), result.Fee.Amount)
+
+	require.Equal(t, int64(500), result.Transfer.Amount)
+	require.Equal(t, int64(-507), result.FromEntrie.Amount)
+	require.Equal(t, int64(500), result.ToEntrie.Amount)
+	require.NotNil(t, result.FeeEntrie)
+	require.Equal(t, revenue.ID, result.FeeEntrie.AccountID)
+	require.Equal(t, int64(7), result.FeeEntrie.Amount)
+	require.Equal(t, result.Transfer.JournalID, result.FeeEntrie.JournalID)
+
+	require.Equal(t, payer.Balance-507, result.FromAccount.Balance)
+	require.Equal(t, payee.Balance+500, result.ToAccount.Balance)
+
+	updatedRevenue, err := testQueries.GetAccount(context.Background(), revenue.ID)
+	require.NoError(t, err)
+	require.Equal(t, revenue.Balance+7, updatedRevenue.Balance)
+
+	events := listAccountEvents(t, revenue.ID)
+	last := events[len(events)-1]
+	require.Equal(t, string(event.EntryPosted), last.EventType)
+
+	var payload event.EntryPostedV1
+	require.NoError(t, json.Unmarshal(last.Payload, &payload))
+	require.Equal(t, result.Transfer.ID, payload.TransferID)
+	require.Equal(t, int64(7), payload.Amount)
+	require.Equal(t, revenue.Balance+7, payload.Balance)
+}
+
+func TestTransferTxWithoutRuleIsFree(t *testing.T) {
+	store := NewStore(testDb)
+
+	payer := createRandomTierAccount(t, "USD")
+	payee := createRandomAccountWithCurrency(t, "USD")
+
+	result, err := store.TransferTX(context.Background(), TransferCreateParams{
+		FromAccountID: payer.ID,
+		ToAccountID:   payee.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+	require.Nil(t, result.Fee)
+	require.Nil(t, result.FeeEntrie)
+	require.Equal(t, payer.Balance-10, result.FromAccount.Balance)
+}
+
+func TestQuoteTransferFeeTiered(t *testing.T) {
+	store := NewStore(testDb)
+
+	payer := createRandomTierAccount(t, "EUR")
+	revenue := createRandomAccountWithCurrency(t, "EUR")
+	tier := sql.NullString{String: payer.Tier, Valid: true}
+
+	small := createRandomPricingRule(t, CreatePricingRuleParams{
+		Tier:         tier,
+		Currency:     "EUR",
+		MaxAmount:    sql.NullInt64{Int64: 1000, Valid: true},
+		FlatFee:      15,
+		FeeAccountID: revenue.ID,
+	})
+	large := createRandomPricingRule(t, CreatePricingRuleParams{
+		Tier:         tier,
+		Currency:     "EUR",
+		MinAmount:    1000,
+		PercentBps:   50,
+		MaxFee:       sql.NullInt64{Int64: 100, Valid: true},
+		FeeAccountID: revenue.ID,
+	})
+
+	fee, err := store.QuoteTransferFee(context.Background(), payer, 999)
+	require.NoError(t, err)
+	require.Equal(t, small.ID, fee.RuleID)
+	require.Equal(t, int64(15), fee.Amount)
+
+	fee, err = store.QuoteTransferFee(context.Background(), payer, 100000)
+	require.NoError(t, err)
+	require.Equal(t, large.ID, fee.RuleID)
+	require.Equal(t, int64(500), fee.Percentage)
+	require.Equal(t, int64(100), fee.Amount)
+}
+
+func TestCreatePricingRuleTxChecksFeeAccount(t *testing.T) {
+	store := NewStore(testDb)
+
+	feeIncome, err := store.InternalAccount(context.Background(), GLFeeIncome, "USD")
+	require.NoError(t, err)
+	eurFeeIncome, err := store.InternalAccount(context.Background(), GLFeeIncome, "EUR")
+	require.NoError(t, err)
+	suspense, err := store.InternalAccount(context.Background(), GLSuspense, "USD")
+	require.NoError(t, err)
+	customer := createRandomAccountWithCurrency(t, "USD")
+
+	testCases := []struct {
+		name         string
+		feeAccountID int64
+		ok           bool
+	}{
+		{name: "FeeIncome", feeAccountID: feeIncome.ID, ok: true},
+		{name: "CustomerAccount", feeAccountID: customer.ID},
+		{name: "OtherCurrency", feeAccountID: eurFeeIncome.ID},
+		{name: "NotIncome", feeAccountID: suspense.ID},
+		{name: "NotFound", feeAccountID: -1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rule, err := store.CreatePricingRuleTx(context.Background(), CreatePricingRuleParams{
+				// a tier no account is in, the rule prices no transfer of the other tests
+				Tier:         sql.NullString{String: "test-" + util.RandomString(8), Valid: true},
+				Currency:     "USD",
+				FlatFee:      1,
+				FeeAccountID: tc.feeAccountID,
+			})
+			if !tc.ok {
+				require.ErrorIs(t, err, ErrInvalidFeeAccount)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.feeAccountID, rule.FeeAccountID)
+		})
+	}
+}
+
+func TestUpdatePricingRuleTx(t *testing.T) {
+	store := NewStore(testDb)
+
+	feeIncome, err := store.InternalAccount(context.Background(), GLFeeIncome, "EUR")
+	require.NoError(t, err)
+	customer := createRandomAccountWithCurrency(t, "EUR")
+
+	rule := createRandomPricingRule(t, CreatePricingRuleParams{
+		Tier:         sql.NullString{String: "test-" + util.RandomString(8), Valid: true},
+		Currency:     "EUR",
+		FlatFee:      1,
+		FeeAccountID: feeIncome.ID,
+	})
+
+	arg := UpdatePricingRuleParams{
+		ID:           rule.ID,
+		Tier:         rule.Tier,
+		Currency:     rule.Currency,
+		FlatFee:      3,
+		FeeAccountID: customer.ID,
+		Active:       true,
+	}
+
+	_, err = store.UpdatePricingRuleTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrInvalidFeeAccount)
+
+	arg.FeeAccountID = feeIncome.ID
+	updated, err := store.UpdatePricingRuleTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), updated.FlatFee)
+	require.True(t, updated.Active)
+
+	arg.Active = false
+	updated, err = store.UpdatePricingRuleTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.False(t, updated.Active)
+}
@@ -7,13 +7,19 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const addAccountBalance = `-- name: AddAccountBalance :one
 UPDATE accounts
 SET balance = balance + $2
 WHERE id = $1
-RETURNING id, owner, balance, currency, created_at
+RETURNING id, owner, balance, currency, created_at, status, tier, held_balance, available_balance, product, type, code
 `
 
 type AddAccountBalanceParams struct {
@@ -30,28 +36,31 @@ func (q *Queries) AddAccountBalance(ctx context.Context, arg AddAccountBalancePa
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Status,
+		&i.Tier,
+		&i.HeldBalance,
+		&i.AvailableBalance,
+		&i.Product,
+		&i.Type,
+		&i.Code,
 	)
 	return i, err
 }
 
-const createAccount = `-- name: CreateAccount :one
-INSERT INTO accounts (
-  owner,
-  balance,
-  currency
-) VALUES (
-  $1, $2, $3
-) RETURNING id, owner, balance, currency, created_at
+const addAccountHeldBalance = `-- name: AddAccountHeldBalance :one
+UPDATE accounts
+SET held_balance = held_balance + $1
+WHERE id = $2
+RETURNING id, owner, balance, currency, created_at, status, tier, held_balance, available_balance, product, type, code
 `
 
-type CreateAccountParams struct {
-	Owner    string `json:"owner"`
-	Balance  int64  `json:"balance"`
-	Currency string `json:"currency"`
+type AddAccountHeldBalanceParams struct {
+	Amount int64 `json:"amount"`
+	ID     int64 `json:"id"`
 }
 
-func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error) {
-	row := q.db.QueryRowContext(ctx, createAccount, arg.Owner, arg.Balance, arg.Currency)
+func (q *Queries) AddAccountHeldBalance(ctx context.Context, arg AddAccountHeldBalanceParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, addAccountHeldBalance, arg.Amount, arg.ID)
 	var i Account
 	err := row.Scan(
 		&i.ID,
@@ -59,99 +68,299 @@ func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (A
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Status,
+		&i.Tier,
+		&i.HeldBalance,
+		&i.AvailableBalance,
+		&i.Product,
+		&i.Type,
+		&i.Code,
 	)
 	return i, err
 }
 
-const createEntrie = `-- name: CreateEntrie :one
-INSERT INTO entries (
-  account_id,
-  amount
-) VALUES (
-  $1, $2
-) RETURNING id, account_id, amount, created_at
+const chargeLoanInstallmentPenalty = `-- name: ChargeLoanInstallmentPenalty :one
+UPDATE loan_installments
+SET penalty = penalty + $2, status = 'overdue', updated_at = now()
+WHERE id = $1
+RETURNING id, loan_id, number, due_date, principal, interest, penalty, paid_principal, paid_interest, paid_penalty, status, updated_at
 `
 
-type CreateEntrieParams struct {
-	AccountID int64 `json:"account_id"`
-	Amount    int64 `json:"amount"`
+type ChargeLoanInstallmentPenaltyParams struct {
+	ID      int64 `json:"id"`
+	Penalty int64 `json:"penalty"`
 }
 
-func (q *Queries) CreateEntrie(ctx context.Context, arg CreateEntrieParams) (Entry, error) {
-	row := q.db.QueryRowContext(ctx, createEntrie, arg.AccountID, arg.Amount)
-	var i Entry
+func (q *Queries) ChargeLoanInstallmentPenalty(ctx context.Context, arg ChargeLoanInstallmentPenaltyParams) (LoanInstallment, error) {
+	row := q.db.QueryRowContext(ctx, chargeLoanInstallmentPenalty, arg.ID, arg.Penalty)
+	var i LoanInstallment
 	err := row.Scan(
 		&i.ID,
-		&i.AccountID,
+		&i.LoanID,
+		&i.Number,
+		&i.DueDate,
+		&i.Principal,
+		&i.Interest,
+		&i.Penalty,
+		&i.PaidPrincipal,
+		&i.PaidInterest,
+		&i.PaidPenalty,
+		&i.Status,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const claimDueScheduledTransfer = `-- name: ClaimDueScheduledTransfer :one
+SELECT id, owner, from_account_id, to_account_id, amount, currency, start_at, recurrence, end_at, status, next_occurrence_at, next_run_at, attempts, last_error, created_at, updated_at FROM scheduled_transfers
+WHERE status = 'active' AND next_run_at <= $1
+ORDER BY next_run_at
+LIMIT 1
+FOR NO KEY UPDATE SKIP LOCKED
+`
+
+func (q *Queries) ClaimDueScheduledTransfer(ctx context.Context, now time.Time) (ScheduledTransfer, error) {
+	row := q.db.QueryRowContext(ctx, claimDueScheduledTransfer, now)
+	var i ScheduledTransfer
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.FromAccountID,
+		&i.ToAccountID,
 		&i.Amount,
+		&i.Currency,
+		&i.StartAt,
+		&i.Recurrence,
+		&i.EndAt,
+		&i.Status,
+		&i.NextOccurrenceAt,
+		&i.NextRunAt,
+		&i.Attempts,
+		&i.LastError,
 		&i.CreatedAt,
+		&i.UpdatedAt,
 	)
 	return i, err
 }
 
-const createTransfer = `-- name: CreateTransfer :one
-INSERT INTO transfers (
-  from_account_id,
-  to_account_id,
-  amount
-) VALUES (
-  $1, $2, $3
-) RETURNING id, from_account_id, to_account_id, amount, created_at
+const claimDueWebhookDeliveries = `-- name: ClaimDueWebhookDeliveries :many
+UPDATE webhook_deliveries
+SET next_attempt_at = $1
+WHERE id IN (
+  SELECT id FROM webhook_deliveries
+  WHERE status = 'pending' AND next_attempt_at <= now()
+  ORDER BY next_attempt_at
+  LIMIT $2
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at, expires_at, last_attempt_at, response_status, last_error, created_at
 `
 
-type CreateTransferParams struct {
-	FromAccountID int64 `json:"from_account_id"`
-	ToAccountID   int64 `json:"to_account_id"`
-	Amount        int64 `json:"amount"`
+type ClaimDueWebhookDeliveriesParams struct {
+	LeaseUntil time.Time `json:"lease_until"`
+	LimitCount int32     `json:"limit_count"`
 }
 
-func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error) {
-	row := q.db.QueryRowContext(ctx, createTransfer, arg.FromAccountID, arg.ToAccountID, arg.Amount)
-	var i Transfer
+func (q *Queries) ClaimDueWebhookDeliveries(ctx context.Context, arg ClaimDueWebhookDeliveriesParams) ([]WebhookDelivery, error) {
+	rows, err := q.db.QueryContext(ctx, claimDueWebhookDeliveries, arg.LeaseUntil, arg.LimitCount)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []WebhookDelivery{}
+	for rows.Next() {
+		var i WebhookDelivery
+		if err := rows.Scan(
+			&i.ID,
+			&i.SubscriptionID,
+			&i.EventID,
+			&i.EventType,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.NextAttemptAt,
+			&i.ExpiresAt,
+			&i.LastAttemptAt,
+			&i.ResponseStatus,
+			&i.LastError,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const claimExpiredHold = `-- name: ClaimExpiredHold :one
+SELECT id, account_id, to_account_id, amount, captured_amount, currency, status, description, expires_at, created_at, updated_at, fee FROM holds
+WHERE status = 'authorized' AND expires_at <= $1
+ORDER BY expires_at
+LIMIT 1
+FOR NO KEY UPDATE SKIP LOCKED
+`
+
+func (q *Queries) ClaimExpiredHold(ctx context.Context, now time.Time) (Hold, error) {
+	row := q.db.QueryRowContext(ctx, claimExpiredHold, now)
+	var i Hold
 	err := row.Scan(
 		&i.ID,
-		&i.FromAccountID,
+		&i.AccountID,
 		&i.ToAccountID,
 		&i.Amount,
+		&i.CapturedAmount,
+		&i.Currency,
+		&i.Status,
+		&i.Description,
+		&i.ExpiresAt,
 		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Fee,
 	)
 	return i, err
 }
 
-const deleteAccount = `-- name: DeleteAccount :exec
-DELETE FROM accounts WHERE id = $1
+const claimOverdueLoanInstallment = `-- name: ClaimOverdueLoanInstallment :one
+SELECT i.id, i.loan_id, i.number, i.due_date, i.principal, i.interest, i.penalty, i.paid_principal, i.paid_interest, i.paid_penalty, i.status, i.updated_at FROM loan_installments i
+JOIN loans l ON l.id = i.loan_id
+WHERE i.status = 'pending' AND i.due_date + l.grace_days < $1::date
+ORDER BY i.due_date
+LIMIT 1
+FOR NO KEY UPDATE OF i SKIP LOCKED
 `
 
-func (q *Queries) DeleteAccount(ctx context.Context, id int64) error {
-	_, err := q.db.ExecContext(ctx, deleteAccount, id)
-	return err
+func (q *Queries) ClaimOverdueLoanInstallment(ctx context.Context, today time.Time) (LoanInstallment, error) {
+	row := q.db.QueryRowContext(ctx, claimOverdueLoanInstallment, today)
+	var i LoanInstallment
+	err := row.Scan(
+		&i.ID,
+		&i.LoanID,
+		&i.Number,
+		&i.DueDate,
+		&i.Principal,
+		&i.Interest,
+		&i.Penalty,
+		&i.PaidPrincipal,
+		&i.PaidInterest,
+		&i.PaidPenalty,
+		&i.Status,
+		&i.UpdatedAt,
+	)
+	return i, err
 }
 
-const deleteEntrie = `-- name: DeleteEntrie :exec
-DELETE FROM entries WHERE id = $1
+const claimTransferBatch = `-- name: ClaimTransferBatch :one
+UPDATE transfer_batches
+SET
+  status = 'processing',
+  lease_until = $1::timestamptz,
+  updated_at = now()
+WHERE id = (
+  SELECT id FROM transfer_batches
+  WHERE status = 'pending' OR (status = 'processing' AND lease_until < $2::timestamptz)
+  ORDER BY id
+  LIMIT 1
+  FOR NO KEY UPDATE SKIP LOCKED
+)
+RETURNING id, owner, mode, status, total_rows, succeeded_rows, failed_rows, lease_until, created_at, updated_at, completed_at
 `
 
-func (q *Queries) DeleteEntrie(ctx context.Context, id int64) error {
-	_, err := q.db.ExecContext(ctx, deleteEntrie, id)
-	return err
+type ClaimTransferBatchParams struct {
+	LeaseUntil time.Time `json:"lease_until"`
+	Now        time.Time `json:"now"`
 }
 
-const deleteTransfer = `-- name: DeleteTransfer :exec
-DELETE FROM transfers WHERE id = $1
+func (q *Queries) ClaimTransferBatch(ctx context.Context, arg ClaimTransferBatchParams) (TransferBatch, error) {
+	row := q.db.QueryRowContext(ctx, claimTransferBatch, arg.LeaseUntil, arg.Now)
+	var i TransferBatch
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Mode,
+		&i.Status,
+		&i.TotalRows,
+		&i.SucceededRows,
+		&i.FailedRows,
+		&i.LeaseUntil,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CompletedAt,
+	)
+	return i, err
+}
+
+const countTransfersBetween = `-- name: CountTransfersBetween :one
+SELECT COUNT(*) FROM transfers
+WHERE from_account_id = $1
+  AND to_account_id = $2
+  AND created_at >= $3::timestamptz
 `
 
-func (q *Queries) DeleteTransfer(ctx context.Context, id int64) error {
-	_, err := q.db.ExecContext(ctx, deleteTransfer, id)
-	return err
+type CountTransfersBetweenParams struct {
+	FromAccountID int64     `json:"from_account_id"`
+	ToAccountID   int64     `json:"to_account_id"`
+	Since         time.Time `json:"since"`
 }
 
-const getAccount = `-- name: GetAccount :one
-SELECT id, owner, balance, currency, created_at FROM accounts
-WHERE id = $1 LIMIT 1
+func (q *Queries) CountTransfersBetween(ctx context.Context, arg CountTransfersBetweenParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countTransfersBetween, arg.FromAccountID, arg.ToAccountID, arg.Since)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countTransfersFrom = `-- name: CountTransfersFrom :one
+SELECT COUNT(*) FROM transfers
+WHERE from_account_id = $1
+  AND created_at >= $2::timestamptz
 `
 
-func (q *Queries) GetAccount(ctx context.Context, id int64) (Account, error) {
-	row := q.db.QueryRowContext(ctx, getAccount, id)
+type CountTransfersFromParams struct {
+	FromAccountID int64     `json:"from_account_id"`
+	Since         time.Time `json:"since"`
+}
+
+func (q *Queries) CountTransfersFrom(ctx context.Context, arg CountTransfersFromParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countTransfersFrom, arg.FromAccountID, arg.Since)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createAccount = `-- name: CreateAccount :one
+INSERT INTO accounts (
+  owner,
+  balance,
+  currency,
+  product,
+  type
+) VALUES (
+  $1, $2, $3, COALESCE($4, 'checking'), COALESCE($5, 'liability')
+) RETURNING id, owner, balance, currency, created_at, status, tier, held_balance, available_balance, product, type, code
+`
+
+type CreateAccountParams struct {
+	Owner    string         `json:"owner"`
+	Balance  int64          `json:"balance"`
+	Currency string         `json:"currency"`
+	Product  sql.NullString `json:"product"`
+	Type     sql.NullString `json:"type"`
+}
+
+func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, createAccount,
+		arg.Owner,
+		arg.Balance,
+		arg.Currency,
+		arg.Product,
+		arg.Type,
+	)
 	var i Account
 	err := row.Scan(
 		&i.ID,
@@ -159,191 +368,4788 @@ func (q *Queries) GetAccount(ctx context.Context, id int64) (Account, error) {
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Status,
+		&i.Tier,
+		&i.HeldBalance,
+		&i.AvailableBalance,
+		&i.Product,
+		&i.Type,
+		&i.Code,
 	)
 	return i, err
 }
 
-const getAccountForUpdate = `-- name: GetAccountForUpdate :one
-SELECT id, owner, balance, currency, created_at FROM accounts
-WHERE id = $1 LIMIT 1
-FOR NO KEY UPDATE
+const createAccountBalanceSnapshot = `-- name: CreateAccountBalanceSnapshot :one
+INSERT INTO account_balance_snapshots (
+  account_id,
+  day,
+  balance
+) VALUES (
+  $1, $2, $3
+)
+ON CONFLICT (account_id, day) DO NOTHING
+RETURNING account_id, day, balance, created_at
 `
 
-func (q *Queries) GetAccountForUpdate(ctx context.Context, id int64) (Account, error) {
-	row := q.db.QueryRowContext(ctx, getAccountForUpdate, id)
-	var i Account
+type CreateAccountBalanceSnapshotParams struct {
+	AccountID int64     `json:"account_id"`
+	Day       time.Time `json:"day"`
+	Balance   int64     `json:"balance"`
+}
+
+func (q *Queries) CreateAccountBalanceSnapshot(ctx context.Context, arg CreateAccountBalanceSnapshotParams) (AccountBalanceSnapshot, error) {
+	row := q.db.QueryRowContext(ctx, createAccountBalanceSnapshot, arg.AccountID, arg.Day, arg.Balance)
+	var i AccountBalanceSnapshot
+	err := row.Scan(
+		&i.AccountID,
+		&i.Day,
+		&i.Balance,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createAccountingPeriod = `-- name: CreateAccountingPeriod :one
+INSERT INTO accounting_periods (
+  kind,
+  start_day,
+  end_day,
+  closed_by
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING id, kind, start_day, end_day, closed_by, closed_at
+`
+
+type CreateAccountingPeriodParams struct {
+	Kind     string    `json:"kind"`
+	StartDay time.Time `json:"start_day"`
+	EndDay   time.Time `json:"end_day"`
+	ClosedBy string    `json:"closed_by"`
+}
+
+func (q *Queries) CreateAccountingPeriod(ctx context.Context, arg CreateAccountingPeriodParams) (AccountingPeriod, error) {
+	row := q.db.QueryRowContext(ctx, createAccountingPeriod,
+		arg.Kind,
+		arg.StartDay,
+		arg.EndDay,
+		arg.ClosedBy,
+	)
+	var i AccountingPeriod
+	err := row.Scan(
+		&i.ID,
+		&i.Kind,
+		&i.StartDay,
+		&i.EndDay,
+		&i.ClosedBy,
+		&i.ClosedAt,
+	)
+	return i, err
+}
+
+const createApiKey = `-- name: CreateApiKey :one
+INSERT INTO api_keys (
+  owner,
+  name,
+  prefix,
+  hashed_secret,
+  scopes,
+  allowed_ips,
+  expires_at,
+  signing_secret
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8
+) RETURNING id, owner, name, prefix, hashed_secret, scopes, allowed_ips, expires_at, last_used_at, revoked_at, created_at, signing_secret
+`
+
+type CreateApiKeyParams struct {
+	Owner         string    `json:"owner"`
+	Name          string    `json:"name"`
+	Prefix        string    `json:"prefix"`
+	HashedSecret  string    `json:"hashed_secret"`
+	Scopes        []string  `json:"scopes"`
+	AllowedIps    []string  `json:"allowed_ips"`
+	ExpiresAt     time.Time `json:"expires_at"`
+	SigningSecret string    `json:"signing_secret"`
+}
+
+func (q *Queries) CreateApiKey(ctx context.Context, arg CreateApiKeyParams) (ApiKey, error) {
+	row := q.db.QueryRowContext(ctx, createApiKey,
+		arg.Owner,
+		arg.Name,
+		arg.Prefix,
+		arg.HashedSecret,
+		pq.Array(arg.Scopes),
+		pq.Array(arg.AllowedIps),
+		arg.ExpiresAt,
+		arg.SigningSecret,
+	)
+	var i ApiKey
 	err := row.Scan(
 		&i.ID,
 		&i.Owner,
-		&i.Balance,
-		&i.Currency,
+		&i.Name,
+		&i.Prefix,
+		&i.HashedSecret,
+		pq.Array(&i.Scopes),
+		pq.Array(&i.AllowedIps),
+		&i.ExpiresAt,
+		&i.LastUsedAt,
+		&i.RevokedAt,
 		&i.CreatedAt,
+		&i.SigningSecret,
 	)
 	return i, err
 }
 
-const getEntrie = `-- name: GetEntrie :one
-SELECT id, account_id, amount, created_at FROM entries
-WHERE id = $1 LIMIT 1
+const createAuditLog = `-- name: CreateAuditLog :one
+INSERT INTO audit_log (
+  actor,
+  action,
+  target_type,
+  target_id,
+  before,
+  after,
+  ip,
+  user_agent,
+  request_id,
+  prev_hash,
+  hash,
+  created_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+) RETURNING id, actor, action, target_type, target_id, before, after, ip, user_agent, request_id, prev_hash, hash, created_at
 `
 
-func (q *Queries) GetEntrie(ctx context.Context, id int64) (Entry, error) {
-	row := q.db.QueryRowContext(ctx, getEntrie, id)
+type CreateAuditLogParams struct {
+	Actor      string          `json:"actor"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"target_type"`
+	TargetID   string          `json:"target_id"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+	IP         string          `json:"ip"`
+	UserAgent  string          `json:"user_agent"`
+	RequestID  string          `json:"request_id"`
+	PrevHash   string          `json:"prev_hash"`
+	Hash       string          `json:"hash"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+func (q *Queries) CreateAuditLog(ctx context.Context, arg CreateAuditLogParams) (AuditLog, error) {
+	row := q.db.QueryRowContext(ctx, createAuditLog,
+		arg.Actor,
+		arg.Action,
+		arg.TargetType,
+		arg.TargetID,
+		arg.Before,
+		arg.After,
+		arg.IP,
+		arg.UserAgent,
+		arg.RequestID,
+		arg.PrevHash,
+		arg.Hash,
+		arg.CreatedAt,
+	)
+	var i AuditLog
+	err := row.Scan(
+		&i.ID,
+		&i.Actor,
+		&i.Action,
+		&i.TargetType,
+		&i.TargetID,
+		&i.Before,
+		&i.After,
+		&i.IP,
+		&i.UserAgent,
+		&i.RequestID,
+		&i.PrevHash,
+		&i.Hash,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createEntrie = `-- name: CreateEntrie :one
+INSERT INTO entries (
+  account_id,
+  amount
+) VALUES (
+  $1, $2
+) RETURNING id, account_id, amount, created_at, journal_id
+`
+
+type CreateEntrieParams struct {
+	AccountID int64 `json:"account_id"`
+	Amount    int64 `json:"amount"`
+}
+
+func (q *Queries) CreateEntrie(ctx context.Context, arg CreateEntrieParams) (Entry, error) {
+	row := q.db.QueryRowContext(ctx, createEntrie, arg.AccountID, arg.Amount)
 	var i Entry
 	err := row.Scan(
 		&i.ID,
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.JournalID,
 	)
 	return i, err
 }
 
-const getTransfer = `-- name: GetTransfer :one
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
-WHERE id = $1 LIMIT 1
+const createHold = `-- name: CreateHold :one
+INSERT INTO holds (
+  account_id,
+  to_account_id,
+  amount,
+  currency,
+  description,
+  expires_at,
+  fee
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7
+) RETURNING id, account_id, to_account_id, amount, captured_amount, currency, status, description, expires_at, created_at, updated_at, fee
 `
 
-func (q *Queries) GetTransfer(ctx context.Context, id int64) (Transfer, error) {
-	row := q.db.QueryRowContext(ctx, getTransfer, id)
-	var i Transfer
+type CreateHoldParams struct {
+	AccountID   int64     `json:"account_id"`
+	ToAccountID int64     `json:"to_account_id"`
+	Amount      int64     `json:"amount"`
+	Currency    string    `json:"currency"`
+	Description string    `json:"description"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	Fee         int64     `json:"fee"`
+}
+
+func (q *Queries) CreateHold(ctx context.Context, arg CreateHoldParams) (Hold, error) {
+	row := q.db.QueryRowContext(ctx, createHold,
+		arg.AccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.Currency,
+		arg.Description,
+		arg.ExpiresAt,
+		arg.Fee,
+	)
+	var i Hold
 	err := row.Scan(
 		&i.ID,
-		&i.FromAccountID,
+		&i.AccountID,
 		&i.ToAccountID,
 		&i.Amount,
+		&i.CapturedAmount,
+		&i.Currency,
+		&i.Status,
+		&i.Description,
+		&i.ExpiresAt,
 		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Fee,
 	)
 	return i, err
 }
 
-const listAccounts = `-- name: ListAccounts :many
-SELECT id, owner, balance, currency, created_at FROM accounts
-ORDER BY id
-LIMIT $1
-OFFSET $2
+const createIdempotencyKey = `-- name: CreateIdempotencyKey :one
+INSERT INTO idempotency_keys (
+  username,
+  key,
+  request_method,
+  request_path,
+  request_hash
+) VALUES (
+  $1, $2, $3, $4, $5
+)
+ON CONFLICT DO NOTHING
+RETURNING username, key, request_method, request_path, request_hash, response_status, response_body, created_at
 `
 
-type ListAccountsParams struct {
-	Limit  int32 `json:"limit"`
-	Offset int32 `json:"offset"`
+type CreateIdempotencyKeyParams struct {
+	Username      string `json:"username"`
+	Key           string `json:"key"`
+	RequestMethod string `json:"request_method"`
+	RequestPath   string `json:"request_path"`
+	RequestHash   string `json:"request_hash"`
 }
 
-func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error) {
-	rows, err := q.db.QueryContext(ctx, listAccounts, arg.Limit, arg.Offset)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	items := []Account{}
-	for rows.Next() {
-		var i Account
-		if err := rows.Scan(
-			&i.ID,
-			&i.Owner,
-			&i.Balance,
-			&i.Currency,
-			&i.CreatedAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+func (q *Queries) CreateIdempotencyKey(ctx context.Context, arg CreateIdempotencyKeyParams) (IdempotencyKey, error) {
+	row := q.db.QueryRowContext(ctx, createIdempotencyKey,
+		arg.Username,
+		arg.Key,
+		arg.RequestMethod,
+		arg.RequestPath,
+		arg.RequestHash,
+	)
+	var i IdempotencyKey
+	err := row.Scan(
+		&i.Username,
+		&i.Key,
+		&i.RequestMethod,
+		&i.RequestPath,
+		&i.RequestHash,
+		&i.ResponseStatus,
+		&i.ResponseBody,
+		&i.CreatedAt,
+	)
+	return i, err
 }
 
-const listEntries = `-- name: ListEntries :many
-SELECT id, account_id, amount, created_at FROM entries
-ORDER BY id
+const createInterestAccrual = `-- name: CreateInterestAccrual :one
+INSERT INTO interest_accruals (
+  account_id,
+  day,
+  balance,
+  rate_bps,
+  day_count,
+  amount_micros
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+)
+ON CONFLICT (account_id, day) DO NOTHING
+RETURNING id, account_id, day, balance, rate_bps, day_count, amount_micros, posting_id, created_at
+`
+
+type CreateInterestAccrualParams struct {
+	AccountID    int64     `json:"account_id"`
+	Day          time.Time `json:"day"`
+	Balance      int64     `json:"balance"`
+	RateBps      int32     `json:"rate_bps"`
+	DayCount     string    `json:"day_count"`
+	AmountMicros int64     `json:"amount_micros"`
+}
+
+func (q *Queries) CreateInterestAccrual(ctx context.Context, arg CreateInterestAccrualParams) (InterestAccrual, error) {
+	row := q.db.QueryRowContext(ctx, createInterestAccrual,
+		arg.AccountID,
+		arg.Day,
+		arg.Balance,
+		arg.RateBps,
+		arg.DayCount,
+		arg.AmountMicros,
+	)
+	var i InterestAccrual
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.Day,
+		&i.Balance,
+		&i.RateBps,
+		&i.DayCount,
+		&i.AmountMicros,
+		&i.PostingID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createInterestPosting = `-- name: CreateInterestPosting :one
+INSERT INTO interest_postings (
+  account_id,
+  month,
+  accrued_micros,
+  amount,
+  carry_micros,
+  journal_id
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+)
+ON CONFLICT (account_id, month) DO NOTHING
+RETURNING id, account_id, month, accrued_micros, amount, carry_micros, journal_id, created_at
+`
+
+type CreateInterestPostingParams struct {
+	AccountID     int64         `json:"account_id"`
+	Month         time.Time     `json:"month"`
+	AccruedMicros int64         `json:"accrued_micros"`
+	Amount        int64         `json:"amount"`
+	CarryMicros   int64         `json:"carry_micros"`
+	JournalID     sql.NullInt64 `json:"journal_id"`
+}
+
+func (q *Queries) CreateInterestPosting(ctx context.Context, arg CreateInterestPostingParams) (InterestPosting, error) {
+	row := q.db.QueryRowContext(ctx, createInterestPosting,
+		arg.AccountID,
+		arg.Month,
+		arg.AccruedMicros,
+		arg.Amount,
+		arg.CarryMicros,
+		arg.JournalID,
+	)
+	var i InterestPosting
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.Month,
+		&i.AccruedMicros,
+		&i.Amount,
+		&i.CarryMicros,
+		&i.JournalID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createInterestRate = `-- name: CreateInterestRate :one
+INSERT INTO interest_rates (
+  product,
+  currency,
+  rate_bps,
+  effective_from,
+  expense_account_id,
+  created_by
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+) RETURNING id, product, currency, rate_bps, effective_from, expense_account_id, created_by, created_at
+`
+
+type CreateInterestRateParams struct {
+	Product          string    `json:"product"`
+	Currency         string    `json:"currency"`
+	RateBps          int32     `json:"rate_bps"`
+	EffectiveFrom    time.Time `json:"effective_from"`
+	ExpenseAccountID int64     `json:"expense_account_id"`
+	CreatedBy        string    `json:"created_by"`
+}
+
+func (q *Queries) CreateInterestRate(ctx context.Context, arg CreateInterestRateParams) (InterestRate, error) {
+	row := q.db.QueryRowContext(ctx, createInterestRate,
+		arg.Product,
+		arg.Currency,
+		arg.RateBps,
+		arg.EffectiveFrom,
+		arg.ExpenseAccountID,
+		arg.CreatedBy,
+	)
+	var i InterestRate
+	err := row.Scan(
+		&i.ID,
+		&i.Product,
+		&i.Currency,
+		&i.RateBps,
+		&i.EffectiveFrom,
+		&i.ExpenseAccountID,
+		&i.CreatedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createJournal = `-- name: CreateJournal :one
+INSERT INTO journals (
+  kind,
+  description,
+  effective_at
+) VALUES (
+  $1, $2, COALESCE($3, now())
+) RETURNING id, kind, description, created_at, effective_at
+`
+
+type CreateJournalParams struct {
+	Kind        string       `json:"kind"`
+	Description string       `json:"description"`
+	EffectiveAt sql.NullTime `json:"effective_at"`
+}
+
+func (q *Queries) CreateJournal(ctx context.Context, arg CreateJournalParams) (Journal, error) {
+	row := q.db.QueryRowContext(ctx, createJournal, arg.Kind, arg.Description, arg.EffectiveAt)
+	var i Journal
+	err := row.Scan(
+		&i.ID,
+		&i.Kind,
+		&i.Description,
+		&i.CreatedAt,
+		&i.EffectiveAt,
+	)
+	return i, err
+}
+
+const createJournalEntry = `-- name: CreateJournalEntry :one
+INSERT INTO entries (
+  journal_id,
+  account_id,
+  amount,
+  created_at
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING id, account_id, amount, created_at, journal_id
+`
+
+type CreateJournalEntryParams struct {
+	JournalID sql.NullInt64 `json:"journal_id"`
+	AccountID int64         `json:"account_id"`
+	Amount    int64         `json:"amount"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+func (q *Queries) CreateJournalEntry(ctx context.Context, arg CreateJournalEntryParams) (Entry, error) {
+	row := q.db.QueryRowContext(ctx, createJournalEntry,
+		arg.JournalID,
+		arg.AccountID,
+		arg.Amount,
+		arg.CreatedAt,
+	)
+	var i Entry
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.JournalID,
+	)
+	return i, err
+}
+
+const createLoan = `-- name: CreateLoan :one
+INSERT INTO loans (
+  account_id,
+  borrower_account_id,
+  income_account_id,
+  principal,
+  rate_bps,
+  term_months,
+  method,
+  currency,
+  late_fee,
+  grace_days,
+  created_by
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
+) RETURNING id, account_id, borrower_account_id, income_account_id, principal, rate_bps, term_months, method, currency, late_fee, grace_days, status, created_by, created_at, updated_at
+`
+
+type CreateLoanParams struct {
+	AccountID         int64  `json:"account_id"`
+	BorrowerAccountID int64  `json:"borrower_account_id"`
+	IncomeAccountID   int64  `json:"income_account_id"`
+	Principal         int64  `json:"principal"`
+	RateBps           int32  `json:"rate_bps"`
+	TermMonths        int32  `json:"term_months"`
+	Method            string `json:"method"`
+	Currency          string `json:"currency"`
+	LateFee           int64  `json:"late_fee"`
+	GraceDays         int32  `json:"grace_days"`
+	CreatedBy         string `json:"created_by"`
+}
+
+func (q *Queries) CreateLoan(ctx context.Context, arg CreateLoanParams) (Loan, error) {
+	row := q.db.QueryRowContext(ctx, createLoan,
+		arg.AccountID,
+		arg.BorrowerAccountID,
+		arg.IncomeAccountID,
+		arg.Principal,
+		arg.RateBps,
+		arg.TermMonths,
+		arg.Method,
+		arg.Currency,
+		arg.LateFee,
+		arg.GraceDays,
+		arg.CreatedBy,
+	)
+	var i Loan
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.BorrowerAccountID,
+		&i.IncomeAccountID,
+		&i.Principal,
+		&i.RateBps,
+		&i.TermMonths,
+		&i.Method,
+		&i.Currency,
+		&i.LateFee,
+		&i.GraceDays,
+		&i.Status,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const createLoanInstallment = `-- name: CreateLoanInstallment :one
+INSERT INTO loan_installments (
+  loan_id,
+  number,
+  due_date,
+  principal,
+  interest
+) VALUES (
+  $1, $2, $3, $4, $5
+) RETURNING id, loan_id, number, due_date, principal, interest, penalty, paid_principal, paid_interest, paid_penalty, status, updated_at
+`
+
+type CreateLoanInstallmentParams struct {
+	LoanID    int64     `json:"loan_id"`
+	Number    int32     `json:"number"`
+	DueDate   time.Time `json:"due_date"`
+	Principal int64     `json:"principal"`
+	Interest  int64     `json:"interest"`
+}
+
+func (q *Queries) CreateLoanInstallment(ctx context.Context, arg CreateLoanInstallmentParams) (LoanInstallment, error) {
+	row := q.db.QueryRowContext(ctx, createLoanInstallment,
+		arg.LoanID,
+		arg.Number,
+		arg.DueDate,
+		arg.Principal,
+		arg.Interest,
+	)
+	var i LoanInstallment
+	err := row.Scan(
+		&i.ID,
+		&i.LoanID,
+		&i.Number,
+		&i.DueDate,
+		&i.Principal,
+		&i.Interest,
+		&i.Penalty,
+		&i.PaidPrincipal,
+		&i.PaidInterest,
+		&i.PaidPenalty,
+		&i.Status,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const createLoanRepayment = `-- name: CreateLoanRepayment :one
+INSERT INTO loan_repayments (
+  loan_id,
+  amount,
+  principal,
+  interest,
+  penalty,
+  journal_id
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+) RETURNING id, loan_id, amount, principal, interest, penalty, journal_id, created_at
+`
+
+type CreateLoanRepaymentParams struct {
+	LoanID    int64 `json:"loan_id"`
+	Amount    int64 `json:"amount"`
+	Principal int64 `json:"principal"`
+	Interest  int64 `json:"interest"`
+	Penalty   int64 `json:"penalty"`
+	JournalID int64 `json:"journal_id"`
+}
+
+func (q *Queries) CreateLoanRepayment(ctx context.Context, arg CreateLoanRepaymentParams) (LoanRepayment, error) {
+	row := q.db.QueryRowContext(ctx, createLoanRepayment,
+		arg.LoanID,
+		arg.Amount,
+		arg.Principal,
+		arg.Interest,
+		arg.Penalty,
+		arg.JournalID,
+	)
+	var i LoanRepayment
+	err := row.Scan(
+		&i.ID,
+		&i.LoanID,
+		&i.Amount,
+		&i.Principal,
+		&i.Interest,
+		&i.Penalty,
+		&i.JournalID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createOutboxEvent = `-- name: CreateOutboxEvent :one
+INSERT INTO outbox_events (
+  account_id,
+  event_type,
+  schema_version,
+  payload
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING id, account_id, event_type, schema_version, payload, attempts, last_error, published_at, created_at
+`
+
+type CreateOutboxEventParams struct {
+	AccountID     int64           `json:"account_id"`
+	EventType     string          `json:"event_type"`
+	SchemaVersion int32           `json:"schema_version"`
+	Payload       json.RawMessage `json:"payload"`
+}
+
+func (q *Queries) CreateOutboxEvent(ctx context.Context, arg CreateOutboxEventParams) (OutboxEvent, error) {
+	row := q.db.QueryRowContext(ctx, createOutboxEvent,
+		arg.AccountID,
+		arg.EventType,
+		arg.SchemaVersion,
+		arg.Payload,
+	)
+	var i OutboxEvent
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.EventType,
+		&i.SchemaVersion,
+		&i.Payload,
+		&i.Attempts,
+		&i.LastError,
+		&i.PublishedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createPeriodTrialBalance = `-- name: CreatePeriodTrialBalance :one
+INSERT INTO period_trial_balances (
+  period_id,
+  currency,
+  type,
+  accounts,
+  debits,
+  credits
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+) RETURNING period_id, currency, accounts, debits, credits, type
+`
+
+type CreatePeriodTrialBalanceParams struct {
+	PeriodID int64  `json:"period_id"`
+	Currency string `json:"currency"`
+	Type     string `json:"type"`
+	Accounts int32  `json:"accounts"`
+	Debits   int64  `json:"debits"`
+	Credits  int64  `json:"credits"`
+}
+
+func (q *Queries) CreatePeriodTrialBalance(ctx context.Context, arg CreatePeriodTrialBalanceParams) (PeriodTrialBalance, error) {
+	row := q.db.QueryRowContext(ctx, createPeriodTrialBalance,
+		arg.PeriodID,
+		arg.Currency,
+		arg.Type,
+		arg.Accounts,
+		arg.Debits,
+		arg.Credits,
+	)
+	var i PeriodTrialBalance
+	err := row.Scan(
+		&i.PeriodID,
+		&i.Currency,
+		&i.Accounts,
+		&i.Debits,
+		&i.Credits,
+		&i.Type,
+	)
+	return i, err
+}
+
+const createPricingRule = `-- name: CreatePricingRule :one
+INSERT INTO pricing_rules (
+  tier,
+  currency,
+  min_amount,
+  max_amount,
+  flat_fee,
+  percent_bps,
+  min_fee,
+  max_fee,
+  fee_account_id,
+  priority
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+) RETURNING id, tier, currency, min_amount, max_amount, flat_fee, percent_bps, min_fee, max_fee, fee_account_id, priority, active, created_at
+`
+
+type CreatePricingRuleParams struct {
+	Tier         sql.NullString `json:"tier"`
+	Currency     string         `json:"currency"`
+	MinAmount    int64          `json:"min_amount"`
+	MaxAmount    sql.NullInt64  `json:"max_amount"`
+	FlatFee      int64          `json:"flat_fee"`
+	PercentBps   int32          `json:"percent_bps"`
+	MinFee       int64          `json:"min_fee"`
+	MaxFee       sql.NullInt64  `json:"max_fee"`
+	FeeAccountID int64          `json:"fee_account_id"`
+	Priority     int32          `json:"priority"`
+}
+
+func (q *Queries) CreatePricingRule(ctx context.Context, arg CreatePricingRuleParams) (PricingRule, error) {
+	row := q.db.QueryRowContext(ctx, createPricingRule,
+		arg.Tier,
+		arg.Currency,
+		arg.MinAmount,
+		arg.MaxAmount,
+		arg.FlatFee,
+		arg.PercentBps,
+		arg.MinFee,
+		arg.MaxFee,
+		arg.FeeAccountID,
+		arg.Priority,
+	)
+	var i PricingRule
+	err := row.Scan(
+		&i.ID,
+		&i.Tier,
+		&i.Currency,
+		&i.MinAmount,
+		&i.MaxAmount,
+		&i.FlatFee,
+		&i.PercentBps,
+		&i.MinFee,
+		&i.MaxFee,
+		&i.FeeAccountID,
+		&i.Priority,
+		&i.Active,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createProduct = `-- name: CreateProduct :one
+INSERT INTO products (
+  code,
+  name,
+  day_count
+) VALUES (
+  $1, $2, $3
+) RETURNING code, name, day_count, created_at
+`
+
+type CreateProductParams struct {
+	Code     string `json:"code"`
+	Name     string `json:"name"`
+	DayCount string `json:"day_count"`
+}
+
+func (q *Queries) CreateProduct(ctx context.Context, arg CreateProductParams) (Product, error) {
+	row := q.db.QueryRowContext(ctx, createProduct, arg.Code, arg.Name, arg.DayCount)
+	var i Product
+	err := row.Scan(
+		&i.Code,
+		&i.Name,
+		&i.DayCount,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createRiskAssessment = `-- name: CreateRiskAssessment :one
+INSERT INTO risk_assessments (
+  owner,
+  from_account_id,
+  to_account_id,
+  amount,
+  currency,
+  decision,
+  score,
+  hits,
+  status
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9
+) RETURNING id, owner, from_account_id, to_account_id, amount, currency, decision, score, hits, status, transfer_id, reviewed_by, reviewed_at, created_at
+`
+
+type CreateRiskAssessmentParams struct {
+	Owner         string          `json:"owner"`
+	FromAccountID int64           `json:"from_account_id"`
+	ToAccountID   int64           `json:"to_account_id"`
+	Amount        int64           `json:"amount"`
+	Currency      string          `json:"currency"`
+	Decision      string          `json:"decision"`
+	Score         int32           `json:"score"`
+	Hits          json.RawMessage `json:"hits"`
+	Status        string          `json:"status"`
+}
+
+func (q *Queries) CreateRiskAssessment(ctx context.Context, arg CreateRiskAssessmentParams) (RiskAssessment, error) {
+	row := q.db.QueryRowContext(ctx, createRiskAssessment,
+		arg.Owner,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.Currency,
+		arg.Decision,
+		arg.Score,
+		arg.Hits,
+		arg.Status,
+	)
+	var i RiskAssessment
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Currency,
+		&i.Decision,
+		&i.Score,
+		&i.Hits,
+		&i.Status,
+		&i.TransferID,
+		&i.ReviewedBy,
+		&i.ReviewedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createScheduledTransfer = `-- name: CreateScheduledTransfer :one
+INSERT INTO scheduled_transfers (
+  owner,
+  from_account_id,
+  to_account_id,
+  amount,
+  currency,
+  start_at,
+  recurrence,
+  end_at,
+  next_occurrence_at,
+  next_run_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+) RETURNING id, owner, from_account_id, to_account_id, amount, currency, start_at, recurrence, end_at, status, next_occurrence_at, next_run_at, attempts, last_error, created_at, updated_at
+`
+
+type CreateScheduledTransferParams struct {
+	Owner            string       `json:"owner"`
+	FromAccountID    int64        `json:"from_account_id"`
+	ToAccountID      int64        `json:"to_account_id"`
+	Amount           int64        `json:"amount"`
+	Currency         string       `json:"currency"`
+	StartAt          time.Time    `json:"start_at"`
+	Recurrence       string       `json:"recurrence"`
+	EndAt            sql.NullTime `json:"end_at"`
+	NextOccurrenceAt sql.NullTime `json:"next_occurrence_at"`
+	NextRunAt        sql.NullTime `json:"next_run_at"`
+}
+
+func (q *Queries) CreateScheduledTransfer(ctx context.Context, arg CreateScheduledTransferParams) (ScheduledTransfer, error) {
+	row := q.db.QueryRowContext(ctx, createScheduledTransfer,
+		arg.Owner,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.Currency,
+		arg.StartAt,
+		arg.Recurrence,
+		arg.EndAt,
+		arg.NextOccurrenceAt,
+		arg.NextRunAt,
+	)
+	var i ScheduledTransfer
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Currency,
+		&i.StartAt,
+		&i.Recurrence,
+		&i.EndAt,
+		&i.Status,
+		&i.NextOccurrenceAt,
+		&i.NextRunAt,
+		&i.Attempts,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const createSession = `-- name: CreateSession :one
+INSERT INTO sessions (
+  id,
+  username,
+  refresh_token,
+  user_agent,
+  client_ip,
+  is_blocked,
+  expires_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7
+) RETURNING id, username, refresh_token, user_agent, client_ip, is_blocked, expires_at, created_at
+`
+
+type CreateSessionParams struct {
+	ID           uuid.UUID `json:"id"`
+	Username     string    `json:"username"`
+	RefreshToken string    `json:"refresh_token"`
+	UserAgent    string    `json:"user_agent"`
+	ClientIp     string    `json:"client_ip"`
+	IsBlocked    bool      `json:"is_blocked"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error) {
+	row := q.db.QueryRowContext(ctx, createSession,
+		arg.ID,
+		arg.Username,
+		arg.RefreshToken,
+		arg.UserAgent,
+		arg.ClientIp,
+		arg.IsBlocked,
+		arg.ExpiresAt,
+	)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.RefreshToken,
+		&i.UserAgent,
+		&i.ClientIp,
+		&i.IsBlocked,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createTransfer = `-- name: CreateTransfer :one
+INSERT INTO transfers (
+  from_account_id,
+  to_account_id,
+  amount,
+  journal_id
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING id, from_account_id, to_account_id, amount, created_at, journal_id
+`
+
+type CreateTransferParams struct {
+	FromAccountID int64         `json:"from_account_id"`
+	ToAccountID   int64         `json:"to_account_id"`
+	Amount        int64         `json:"amount"`
+	JournalID     sql.NullInt64 `json:"journal_id"`
+}
+
+func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error) {
+	row := q.db.QueryRowContext(ctx, createTransfer,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.JournalID,
+	)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.JournalID,
+	)
+	return i, err
+}
+
+const createTransferBatch = `-- name: CreateTransferBatch :one
+INSERT INTO transfer_batches (
+  owner,
+  mode,
+  total_rows
+) VALUES (
+  $1, $2, $3
+) RETURNING id, owner, mode, status, total_rows, succeeded_rows, failed_rows, lease_until, created_at, updated_at, completed_at
+`
+
+type CreateTransferBatchParams struct {
+	Owner     string `json:"owner"`
+	Mode      string `json:"mode"`
+	TotalRows int32  `json:"total_rows"`
+}
+
+func (q *Queries) CreateTransferBatch(ctx context.Context, arg CreateTransferBatchParams) (TransferBatch, error) {
+	row := q.db.QueryRowContext(ctx, createTransferBatch, arg.Owner, arg.Mode, arg.TotalRows)
+	var i TransferBatch
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Mode,
+		&i.Status,
+		&i.TotalRows,
+		&i.SucceededRows,
+		&i.FailedRows,
+		&i.LeaseUntil,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CompletedAt,
+	)
+	return i, err
+}
+
+const createTransferBatchItems = `-- name: CreateTransferBatchItems :execrows
+INSERT INTO transfer_batch_items (
+  batch_id,
+  row_number,
+  from_account_id,
+  to_account_id,
+  amount,
+  currency
+)
+SELECT
+  $1::bigint,
+  unnest($2::int[]),
+  unnest($3::bigint[]),
+  unnest($4::bigint[]),
+  unnest($5::bigint[]),
+  unnest($6::varchar[])
+`
+
+type CreateTransferBatchItemsParams struct {
+	BatchID        int64    `json:"batch_id"`
+	RowNumbers     []int32  `json:"row_numbers"`
+	FromAccountIds []int64  `json:"from_account_ids"`
+	ToAccountIds   []int64  `json:"to_account_ids"`
+	Amounts        []int64  `json:"amounts"`
+	Currencies     []string `json:"currencies"`
+}
+
+func (q *Queries) CreateTransferBatchItems(ctx context.Context, arg CreateTransferBatchItemsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, createTransferBatchItems,
+		arg.BatchID,
+		pq.Array(arg.RowNumbers),
+		pq.Array(arg.FromAccountIds),
+		pq.Array(arg.ToAccountIds),
+		pq.Array(arg.Amounts),
+		pq.Array(arg.Currencies),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const createTransferLimit = `-- name: CreateTransferLimit :one
+INSERT INTO transfer_limits (
+  account_id,
+  owner,
+  max_amount,
+  daily_amount,
+  monthly_amount,
+  hourly_count,
+  expires_at,
+  reason,
+  created_by
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9
+) RETURNING id, account_id, owner, max_amount, daily_amount, monthly_amount, hourly_count, expires_at, reason, created_by, created_at
+`
+
+type CreateTransferLimitParams struct {
+	AccountID     sql.NullInt64  `json:"account_id"`
+	Owner         sql.NullString `json:"owner"`
+	MaxAmount     sql.NullInt64  `json:"max_amount"`
+	DailyAmount   sql.NullInt64  `json:"daily_amount"`
+	MonthlyAmount sql.NullInt64  `json:"monthly_amount"`
+	HourlyCount   sql.NullInt32  `json:"hourly_count"`
+	ExpiresAt     sql.NullTime   `json:"expires_at"`
+	Reason        string         `json:"reason"`
+	CreatedBy     string         `json:"created_by"`
+}
+
+func (q *Queries) CreateTransferLimit(ctx context.Context, arg CreateTransferLimitParams) (TransferLimit, error) {
+	row := q.db.QueryRowContext(ctx, createTransferLimit,
+		arg.AccountID,
+		arg.Owner,
+		arg.MaxAmount,
+		arg.DailyAmount,
+		arg.MonthlyAmount,
+		arg.HourlyCount,
+		arg.ExpiresAt,
+		arg.Reason,
+		arg.CreatedBy,
+	)
+	var i TransferLimit
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.Owner,
+		&i.MaxAmount,
+		&i.DailyAmount,
+		&i.MonthlyAmount,
+		&i.HourlyCount,
+		&i.ExpiresAt,
+		&i.Reason,
+		&i.CreatedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createUser = `-- name: CreateUser :one
+INSERT INTO users (
+  username,
+  hashed_password,
+  full_name,
+  email
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, role
+`
+
+type CreateUserParams struct {
+	Username       string `json:"username"`
+	HashedPassword string `json:"hashed_password"`
+	FullName       string `json:"full_name"`
+	Email          string `json:"email"`
+}
+
+func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, createUser,
+		arg.Username,
+		arg.HashedPassword,
+		arg.FullName,
+		arg.Email,
+	)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.Role,
+	)
+	return i, err
+}
+
+const createWebhookSubscription = `-- name: CreateWebhookSubscription :one
+INSERT INTO webhook_subscriptions (
+  owner,
+  url,
+  event_types,
+  secret
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING id, owner, url, event_types, secret, created_at
+`
+
+type CreateWebhookSubscriptionParams struct {
+	Owner      string   `json:"owner"`
+	Url        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+	Secret     string   `json:"secret"`
+}
+
+func (q *Queries) CreateWebhookSubscription(ctx context.Context, arg CreateWebhookSubscriptionParams) (WebhookSubscription, error) {
+	row := q.db.QueryRowContext(ctx, createWebhookSubscription,
+		arg.Owner,
+		arg.Url,
+		pq.Array(arg.EventTypes),
+		arg.Secret,
+	)
+	var i WebhookSubscription
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Url,
+		pq.Array(&i.EventTypes),
+		&i.Secret,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteAccount = `-- name: DeleteAccount :exec
+DELETE FROM accounts WHERE id = $1
+`
+
+func (q *Queries) DeleteAccount(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteAccount, id)
+	return err
+}
+
+const deleteAccountBalanceSnapshotsFrom = `-- name: DeleteAccountBalanceSnapshotsFrom :exec
+DELETE FROM account_balance_snapshots
+WHERE account_id = $1 AND day >= $2
+`
+
+type DeleteAccountBalanceSnapshotsFromParams struct {
+	AccountID int64     `json:"account_id"`
+	Day       time.Time `json:"day"`
+}
+
+func (q *Queries) DeleteAccountBalanceSnapshotsFrom(ctx context.Context, arg DeleteAccountBalanceSnapshotsFromParams) error {
+	_, err := q.db.ExecContext(ctx, deleteAccountBalanceSnapshotsFrom, arg.AccountID, arg.Day)
+	return err
+}
+
+const deleteEntrie = `-- name: DeleteEntrie :exec
+DELETE FROM entries WHERE id = $1
+`
+
+func (q *Queries) DeleteEntrie(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteEntrie, id)
+	return err
+}
+
+const deleteFullRateLimitBuckets = `-- name: DeleteFullRateLimitBuckets :execrows
+DELETE FROM rate_limit_buckets
+WHERE full_at < $1
+`
+
+func (q *Queries) DeleteFullRateLimitBuckets(ctx context.Context, fullAt time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteFullRateLimitBuckets, fullAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteIdempotencyKey = `-- name: DeleteIdempotencyKey :exec
+DELETE FROM idempotency_keys
+WHERE username = $1 AND key = $2
+`
+
+type DeleteIdempotencyKeyParams struct {
+	Username string `json:"username"`
+	Key      string `json:"key"`
+}
+
+func (q *Queries) DeleteIdempotencyKey(ctx context.Context, arg DeleteIdempotencyKeyParams) error {
+	_, err := q.db.ExecContext(ctx, deleteIdempotencyKey, arg.Username, arg.Key)
+	return err
+}
+
+const deleteTransfer = `-- name: DeleteTransfer :exec
+DELETE FROM transfers WHERE id = $1
+`
+
+func (q *Queries) DeleteTransfer(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteTransfer, id)
+	return err
+}
+
+const deleteWebhookSubscription = `-- name: DeleteWebhookSubscription :exec
+DELETE FROM webhook_subscriptions WHERE id = $1
+`
+
+func (q *Queries) DeleteWebhookSubscription(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteWebhookSubscription, id)
+	return err
+}
+
+const enqueueWebhookDeliveries = `-- name: EnqueueWebhookDeliveries :execrows
+INSERT INTO webhook_deliveries (
+  subscription_id,
+  event_id,
+  event_type,
+  payload
+)
+SELECT DISTINCT s.id, $1::bigint, $2::varchar, $3::jsonb
+FROM webhook_subscriptions s
+JOIN accounts a ON a.owner = s.owner
+WHERE a.id = ANY($4::bigint[])
+  AND $2::varchar = ANY(s.event_types)
+ON CONFLICT (subscription_id, event_id) DO NOTHING
+`
+
+type EnqueueWebhookDeliveriesParams struct {
+	EventID    int64           `json:"event_id"`
+	EventType  string          `json:"event_type"`
+	Payload    json.RawMessage `json:"payload"`
+	AccountIds []int64         `json:"account_ids"`
+}
+
+func (q *Queries) EnqueueWebhookDeliveries(ctx context.Context, arg EnqueueWebhookDeliveriesParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, enqueueWebhookDeliveries,
+		arg.EventID,
+		arg.EventType,
+		arg.Payload,
+		pq.Array(arg.AccountIds),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getAccount = `-- name: GetAccount :one
+SELECT id, owner, balance, currency, created_at, status, tier, held_balance, available_balance, product, type, code FROM accounts
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetAccount(ctx context.Context, id int64) (Account, error) {
+	row := q.db.QueryRowContext(ctx, getAccount, id)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.Status,
+		&i.Tier,
+		&i.HeldBalance,
+		&i.AvailableBalance,
+		&i.Product,
+		&i.Type,
+		&i.Code,
+	)
+	return i, err
+}
+
+const getAccountBalanceAt = `-- name: GetAccountBalanceAt :one
+SELECT (CASE
+  WHEN s.balance IS NULL THEN a.balance - COALESCE((
+    SELECT SUM(e.amount) FROM entries e
+    WHERE e.account_id = a.id AND e.created_at >= $1
+  ), 0)
+  ELSE s.balance + COALESCE((
+    SELECT SUM(e.amount) FROM entries e
+    WHERE e.account_id = a.id AND e.created_at >= s.end_at AND e.created_at < $1
+  ), 0)
+END)::bigint AS balance
+FROM accounts a
+LEFT JOIN LATERAL (
+  SELECT b.balance, (b.day + 1)::timestamp AT TIME ZONE 'UTC' AS end_at
+  FROM account_balance_snapshots b
+  WHERE b.account_id = a.id AND b.day < ($1::timestamptz AT TIME ZONE 'UTC')::date
+  ORDER BY b.day DESC
+  LIMIT 1
+) s ON true
+WHERE a.id = $2
+`
+
+type GetAccountBalanceAtParams struct {
+	At        time.Time `json:"at"`
+	AccountID int64     `json:"account_id"`
+}
+
+func (q *Queries) GetAccountBalanceAt(ctx context.Context, arg GetAccountBalanceAtParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getAccountBalanceAt, arg.At, arg.AccountID)
+	var balance int64
+	err := row.Scan(&balance)
+	return balance, err
+}
+
+const getAccountForUpdate = `-- name: GetAccountForUpdate :one
+SELECT id, owner, balance, currency, created_at, status, tier, held_balance, available_balance, product, type, code FROM accounts
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetAccountForUpdate(ctx context.Context, id int64) (Account, error) {
+	row := q.db.QueryRowContext(ctx, getAccountForUpdate, id)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.Status,
+		&i.Tier,
+		&i.HeldBalance,
+		&i.AvailableBalance,
+		&i.Product,
+		&i.Type,
+		&i.Code,
+	)
+	return i, err
+}
+
+const getAccountTransferLimit = `-- name: GetAccountTransferLimit :one
+SELECT id, account_id, owner, max_amount, daily_amount, monthly_amount, hourly_count, expires_at, reason, created_by, created_at FROM transfer_limits
+WHERE (account_id = $1::bigint OR (account_id IS NULL AND owner IS NULL))
+  AND (expires_at IS NULL OR expires_at > $2::timestamptz)
+ORDER BY account_id IS NULL, id DESC
+LIMIT 1
+`
+
+type GetAccountTransferLimitParams struct {
+	AccountID int64     `json:"account_id"`
+	Now       time.Time `json:"now"`
+}
+
+func (q *Queries) GetAccountTransferLimit(ctx context.Context, arg GetAccountTransferLimitParams) (TransferLimit, error) {
+	row := q.db.QueryRowContext(ctx, getAccountTransferLimit, arg.AccountID, arg.Now)
+	var i TransferLimit
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.Owner,
+		&i.MaxAmount,
+		&i.DailyAmount,
+		&i.MonthlyAmount,
+		&i.HourlyCount,
+		&i.ExpiresAt,
+		&i.Reason,
+		&i.CreatedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getAccountTransferUsage = `-- name: GetAccountTransferUsage :one
+SELECT
+  COALESCE(SUM(amount) FILTER (WHERE created_at >= $1::timestamptz), 0)::bigint AS daily_amount,
+  COALESCE(SUM(amount) FILTER (WHERE created_at >= $2::timestamptz), 0)::bigint AS monthly_amount,
+  COUNT(*) FILTER (WHERE created_at >= $3::timestamptz) AS hourly_count
+FROM transfers
+WHERE from_account_id = $4 AND created_at >= $5::timestamptz
+`
+
+type GetAccountTransferUsageParams struct {
+	DayStart   time.Time `json:"day_start"`
+	MonthStart time.Time `json:"month_start"`
+	HourStart  time.Time `json:"hour_start"`
+	AccountID  int64     `json:"account_id"`
+	Since      time.Time `json:"since"`
+}
+
+type GetAccountTransferUsageRow struct {
+	DailyAmount   int64 `json:"daily_amount"`
+	MonthlyAmount int64 `json:"monthly_amount"`
+	HourlyCount   int64 `json:"hourly_count"`
+}
+
+func (q *Queries) GetAccountTransferUsage(ctx context.Context, arg GetAccountTransferUsageParams) (GetAccountTransferUsageRow, error) {
+	row := q.db.QueryRowContext(ctx, getAccountTransferUsage,
+		arg.DayStart,
+		arg.MonthStart,
+		arg.HourStart,
+		arg.AccountID,
+		arg.Since,
+	)
+	var i GetAccountTransferUsageRow
+	err := row.Scan(
+		&i.DailyAmount,
+		&i.MonthlyAmount,
+		&i.HourlyCount,
+	)
+	return i, err
+}
+
+const getAccountingPeriod = `-- name: GetAccountingPeriod :one
+SELECT id, kind, start_day, end_day, closed_by, closed_at FROM accounting_periods
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetAccountingPeriod(ctx context.Context, id int64) (AccountingPeriod, error) {
+	row := q.db.QueryRowContext(ctx, getAccountingPeriod, id)
+	var i AccountingPeriod
+	err := row.Scan(
+		&i.ID,
+		&i.Kind,
+		&i.StartDay,
+		&i.EndDay,
+		&i.ClosedBy,
+		&i.ClosedAt,
+	)
+	return i, err
+}
+
+const getApiKey = `-- name: GetApiKey :one
+SELECT id, owner, name, prefix, hashed_secret, scopes, allowed_ips, expires_at, last_used_at, revoked_at, created_at, signing_secret FROM api_keys
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetApiKey(ctx context.Context, id int64) (ApiKey, error) {
+	row := q.db.QueryRowContext(ctx, getApiKey, id)
+	var i ApiKey
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Name,
+		&i.Prefix,
+		&i.HashedSecret,
+		pq.Array(&i.Scopes),
+		pq.Array(&i.AllowedIps),
+		&i.ExpiresAt,
+		&i.LastUsedAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+		&i.SigningSecret,
+	)
+	return i, err
+}
+
+const getApiKeyByPrefix = `-- name: GetApiKeyByPrefix :one
+SELECT k.id, k.owner, k.name, k.prefix, k.hashed_secret, k.scopes, k.allowed_ips, k.expires_at, k.last_used_at, k.revoked_at, k.created_at, k.signing_secret, u.role AS owner_role
+FROM api_keys k
+JOIN users u ON u.username = k.owner
+WHERE k.prefix = $1 LIMIT 1
+`
+
+type GetApiKeyByPrefixRow struct {
+	ID            int64        `json:"id"`
+	Owner         string       `json:"owner"`
+	Name          string       `json:"name"`
+	Prefix        string       `json:"prefix"`
+	HashedSecret  string       `json:"hashed_secret"`
+	Scopes        []string     `json:"scopes"`
+	AllowedIps    []string     `json:"allowed_ips"`
+	ExpiresAt     time.Time    `json:"expires_at"`
+	LastUsedAt    sql.NullTime `json:"last_used_at"`
+	RevokedAt     sql.NullTime `json:"revoked_at"`
+	CreatedAt     time.Time    `json:"created_at"`
+	SigningSecret string       `json:"signing_secret"`
+	OwnerRole     string       `json:"owner_role"`
+}
+
+func (q *Queries) GetApiKeyByPrefix(ctx context.Context, prefix string) (GetApiKeyByPrefixRow, error) {
+	row := q.db.QueryRowContext(ctx, getApiKeyByPrefix, prefix)
+	var i GetApiKeyByPrefixRow
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Name,
+		&i.Prefix,
+		&i.HashedSecret,
+		pq.Array(&i.Scopes),
+		pq.Array(&i.AllowedIps),
+		&i.ExpiresAt,
+		&i.LastUsedAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+		&i.SigningSecret,
+		&i.OwnerRole,
+	)
+	return i, err
+}
+
+const getApiKeyForUpdate = `-- name: GetApiKeyForUpdate :one
+SELECT id, owner, name, prefix, hashed_secret, scopes, allowed_ips, expires_at, last_used_at, revoked_at, created_at, signing_secret FROM api_keys
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetApiKeyForUpdate(ctx context.Context, id int64) (ApiKey, error) {
+	row := q.db.QueryRowContext(ctx, getApiKeyForUpdate, id)
+	var i ApiKey
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Name,
+		&i.Prefix,
+		&i.HashedSecret,
+		pq.Array(&i.Scopes),
+		pq.Array(&i.AllowedIps),
+		&i.ExpiresAt,
+		&i.LastUsedAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+		&i.SigningSecret,
+	)
+	return i, err
+}
+
+const getClosedPeriod = `-- name: GetClosedPeriod :one
+SELECT id, kind, start_day, end_day, closed_by, closed_at FROM accounting_periods
+WHERE start_day <= $1 AND end_day >= $2
+ORDER BY end_day - start_day DESC
+LIMIT 1
+`
+
+type GetClosedPeriodParams struct {
+	StartDay time.Time `json:"start_day"`
+	EndDay   time.Time `json:"end_day"`
+}
+
+func (q *Queries) GetClosedPeriod(ctx context.Context, arg GetClosedPeriodParams) (AccountingPeriod, error) {
+	row := q.db.QueryRowContext(ctx, getClosedPeriod, arg.StartDay, arg.EndDay)
+	var i AccountingPeriod
+	err := row.Scan(
+		&i.ID,
+		&i.Kind,
+		&i.StartDay,
+		&i.EndDay,
+		&i.ClosedBy,
+		&i.ClosedAt,
+	)
+	return i, err
+}
+
+const getEntrie = `-- name: GetEntrie :one
+SELECT id, account_id, amount, created_at, journal_id FROM entries
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetEntrie(ctx context.Context, id int64) (Entry, error) {
+	row := q.db.QueryRowContext(ctx, getEntrie, id)
+	var i Entry
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.JournalID,
+	)
+	return i, err
+}
+
+const getHold = `-- name: GetHold :one
+SELECT id, account_id, to_account_id, amount, captured_amount, currency, status, description, expires_at, created_at, updated_at, fee FROM holds
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetHold(ctx context.Context, id int64) (Hold, error) {
+	row := q.db.QueryRowContext(ctx, getHold, id)
+	var i Hold
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CapturedAmount,
+		&i.Currency,
+		&i.Status,
+		&i.Description,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Fee,
+	)
+	return i, err
+}
+
+const getHoldForUpdate = `-- name: GetHoldForUpdate :one
+SELECT id, account_id, to_account_id, amount, captured_amount, currency, status, description, expires_at, created_at, updated_at, fee FROM holds
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetHoldForUpdate(ctx context.Context, id int64) (Hold, error) {
+	row := q.db.QueryRowContext(ctx, getHoldForUpdate, id)
+	var i Hold
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CapturedAmount,
+		&i.Currency,
+		&i.Status,
+		&i.Description,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Fee,
+	)
+	return i, err
+}
+
+const getIdempotencyKey = `-- name: GetIdempotencyKey :one
+SELECT username, key, request_method, request_path, request_hash, response_status, response_body, created_at FROM idempotency_keys
+WHERE username = $1 AND key = $2 LIMIT 1
+`
+
+type GetIdempotencyKeyParams struct {
+	Username string `json:"username"`
+	Key      string `json:"key"`
+}
+
+func (q *Queries) GetIdempotencyKey(ctx context.Context, arg GetIdempotencyKeyParams) (IdempotencyKey, error) {
+	row := q.db.QueryRowContext(ctx, getIdempotencyKey, arg.Username, arg.Key)
+	var i IdempotencyKey
+	err := row.Scan(
+		&i.Username,
+		&i.Key,
+		&i.RequestMethod,
+		&i.RequestPath,
+		&i.RequestHash,
+		&i.ResponseStatus,
+		&i.ResponseBody,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getInterestRate = `-- name: GetInterestRate :one
+SELECT id, product, currency, rate_bps, effective_from, expense_account_id, created_by, created_at FROM interest_rates
+WHERE product = $1 AND currency = $2 AND effective_from <= $3
+ORDER BY effective_from DESC, id DESC
+LIMIT 1
+`
+
+type GetInterestRateParams struct {
+	Product  string    `json:"product"`
+	Currency string    `json:"currency"`
+	Day      time.Time `json:"day"`
+}
+
+func (q *Queries) GetInterestRate(ctx context.Context, arg GetInterestRateParams) (InterestRate, error) {
+	row := q.db.QueryRowContext(ctx, getInterestRate, arg.Product, arg.Currency, arg.Day)
+	var i InterestRate
+	err := row.Scan(
+		&i.ID,
+		&i.Product,
+		&i.Currency,
+		&i.RateBps,
+		&i.EffectiveFrom,
+		&i.ExpenseAccountID,
+		&i.CreatedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getInternalAccount = `-- name: GetInternalAccount :one
+SELECT id, owner, balance, currency, created_at, status, tier, held_balance, available_balance, product, type, code FROM accounts
+WHERE code = $1 AND currency = $2
+LIMIT 1
+`
+
+type GetInternalAccountParams struct {
+	Code     sql.NullString `json:"code"`
+	Currency string         `json:"currency"`
+}
+
+func (q *Queries) GetInternalAccount(ctx context.Context, arg GetInternalAccountParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, getInternalAccount, arg.Code, arg.Currency)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.Status,
+		&i.Tier,
+		&i.HeldBalance,
+		&i.AvailableBalance,
+		&i.Product,
+		&i.Type,
+		&i.Code,
+	)
+	return i, err
+}
+
+const getJournal = `-- name: GetJournal :one
+SELECT id, kind, description, created_at, effective_at FROM journals
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetJournal(ctx context.Context, id int64) (Journal, error) {
+	row := q.db.QueryRowContext(ctx, getJournal, id)
+	var i Journal
+	err := row.Scan(
+		&i.ID,
+		&i.Kind,
+		&i.Description,
+		&i.CreatedAt,
+		&i.EffectiveAt,
+	)
+	return i, err
+}
+
+const getLastAuditLogHash = `-- name: GetLastAuditLogHash :one
+SELECT hash FROM audit_log
+ORDER BY id DESC
+LIMIT 1
+`
+
+func (q *Queries) GetLastAuditLogHash(ctx context.Context) (string, error) {
+	row := q.db.QueryRowContext(ctx, getLastAuditLogHash)
+	var hash string
+	err := row.Scan(&hash)
+	return hash, err
+}
+
+const getLastInterestPosting = `-- name: GetLastInterestPosting :one
+SELECT id, account_id, month, accrued_micros, amount, carry_micros, journal_id, created_at FROM interest_postings
+WHERE account_id = $1 AND month < $2
+ORDER BY month DESC
+LIMIT 1
+`
+
+type GetLastInterestPostingParams struct {
+	AccountID int64     `json:"account_id"`
+	Month     time.Time `json:"month"`
+}
+
+func (q *Queries) GetLastInterestPosting(ctx context.Context, arg GetLastInterestPostingParams) (InterestPosting, error) {
+	row := q.db.QueryRowContext(ctx, getLastInterestPosting, arg.AccountID, arg.Month)
+	var i InterestPosting
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.Month,
+		&i.AccruedMicros,
+		&i.Amount,
+		&i.CarryMicros,
+		&i.JournalID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getLatestAccountOutboxEventID = `-- name: GetLatestAccountOutboxEventID :one
+SELECT COALESCE(MAX(id), 0)::bigint AS latest_id FROM outbox_events
+WHERE account_id = $1
+`
+
+func (q *Queries) GetLatestAccountOutboxEventID(ctx context.Context, accountID int64) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getLatestAccountOutboxEventID, accountID)
+	var latest_id int64
+	err := row.Scan(&latest_id)
+	return latest_id, err
+}
+
+const getLoginFailure = `-- name: GetLoginFailure :one
+SELECT username, client_ip, failures, locked_until FROM login_failures
+WHERE username = $1 AND client_ip = $2 LIMIT 1
+`
+
+type GetLoginFailureParams struct {
+	Username string `json:"username"`
+	ClientIp string `json:"client_ip"`
+}
+
+func (q *Queries) GetLoginFailure(ctx context.Context, arg GetLoginFailureParams) (LoginFailure, error) {
+	row := q.db.QueryRowContext(ctx, getLoginFailure, arg.Username, arg.ClientIp)
+	var i LoginFailure
+	err := row.Scan(
+		&i.Username,
+		&i.ClientIp,
+		&i.Failures,
+		&i.LockedUntil,
+	)
+	return i, err
+}
+
+const getLoan = `-- name: GetLoan :one
+SELECT id, account_id, borrower_account_id, income_account_id, principal, rate_bps, term_months, method, currency, late_fee, grace_days, status, created_by, created_at, updated_at FROM loans
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetLoan(ctx context.Context, id int64) (Loan, error) {
+	row := q.db.QueryRowContext(ctx, getLoan, id)
+	var i Loan
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.BorrowerAccountID,
+		&i.IncomeAccountID,
+		&i.Principal,
+		&i.RateBps,
+		&i.TermMonths,
+		&i.Method,
+		&i.Currency,
+		&i.LateFee,
+		&i.GraceDays,
+		&i.Status,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getLoanForUpdate = `-- name: GetLoanForUpdate :one
+SELECT id, account_id, borrower_account_id, income_account_id, principal, rate_bps, term_months, method, currency, late_fee, grace_days, status, created_by, created_at, updated_at FROM loans
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetLoanForUpdate(ctx context.Context, id int64) (Loan, error) {
+	row := q.db.QueryRowContext(ctx, getLoanForUpdate, id)
+	var i Loan
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.BorrowerAccountID,
+		&i.IncomeAccountID,
+		&i.Principal,
+		&i.RateBps,
+		&i.TermMonths,
+		&i.Method,
+		&i.Currency,
+		&i.LateFee,
+		&i.GraceDays,
+		&i.Status,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getOutboxEvent = `-- name: GetOutboxEvent :one
+SELECT id, account_id, event_type, schema_version, payload, attempts, last_error, published_at, created_at FROM outbox_events
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetOutboxEvent(ctx context.Context, id int64) (OutboxEvent, error) {
+	row := q.db.QueryRowContext(ctx, getOutboxEvent, id)
+	var i OutboxEvent
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.EventType,
+		&i.SchemaVersion,
+		&i.Payload,
+		&i.Attempts,
+		&i.LastError,
+		&i.PublishedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getOwnerTransferLimit = `-- name: GetOwnerTransferLimit :one
+SELECT id, account_id, owner, max_amount, daily_amount, monthly_amount, hourly_count, expires_at, reason, created_by, created_at FROM transfer_limits
+WHERE owner = $1::varchar
+  AND (expires_at IS NULL OR expires_at > $2::timestamptz)
+ORDER BY id DESC
+LIMIT 1
+`
+
+type GetOwnerTransferLimitParams struct {
+	Owner string    `json:"owner"`
+	Now   time.Time `json:"now"`
+}
+
+func (q *Queries) GetOwnerTransferLimit(ctx context.Context, arg GetOwnerTransferLimitParams) (TransferLimit, error) {
+	row := q.db.QueryRowContext(ctx, getOwnerTransferLimit, arg.Owner, arg.Now)
+	var i TransferLimit
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.Owner,
+		&i.MaxAmount,
+		&i.DailyAmount,
+		&i.MonthlyAmount,
+		&i.HourlyCount,
+		&i.ExpiresAt,
+		&i.Reason,
+		&i.CreatedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getOwnerTransferUsage = `-- name: GetOwnerTransferUsage :one
+SELECT
+  COALESCE(SUM(amount) FILTER (WHERE created_at >= $1::timestamptz), 0)::bigint AS daily_amount,
+  COALESCE(SUM(amount) FILTER (WHERE created_at >= $2::timestamptz), 0)::bigint AS monthly_amount,
+  COUNT(*) FILTER (WHERE created_at >= $3::timestamptz) AS hourly_count
+FROM transfers
+WHERE from_account_id IN (SELECT id FROM accounts WHERE owner = $4)
+  AND created_at >= $5::timestamptz
+`
+
+type GetOwnerTransferUsageParams struct {
+	DayStart   time.Time `json:"day_start"`
+	MonthStart time.Time `json:"month_start"`
+	HourStart  time.Time `json:"hour_start"`
+	Owner      string    `json:"owner"`
+	Since      time.Time `json:"since"`
+}
+
+type GetOwnerTransferUsageRow struct {
+	DailyAmount   int64 `json:"daily_amount"`
+	MonthlyAmount int64 `json:"monthly_amount"`
+	HourlyCount   int64 `json:"hourly_count"`
+}
+
+func (q *Queries) GetOwnerTransferUsage(ctx context.Context, arg GetOwnerTransferUsageParams) (GetOwnerTransferUsageRow, error) {
+	row := q.db.QueryRowContext(ctx, getOwnerTransferUsage,
+		arg.DayStart,
+		arg.MonthStart,
+		arg.HourStart,
+		arg.Owner,
+		arg.Since,
+	)
+	var i GetOwnerTransferUsageRow
+	err := row.Scan(
+		&i.DailyAmount,
+		&i.MonthlyAmount,
+		&i.HourlyCount,
+	)
+	return i, err
+}
+
+const getPricingRule = `-- name: GetPricingRule :one
+SELECT id, tier, currency, min_amount, max_amount, flat_fee, percent_bps, min_fee, max_fee, fee_account_id, priority, active, created_at FROM pricing_rules
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetPricingRule(ctx context.Context, id int64) (PricingRule, error) {
+	row := q.db.QueryRowContext(ctx, getPricingRule, id)
+	var i PricingRule
+	err := row.Scan(
+		&i.ID,
+		&i.Tier,
+		&i.Currency,
+		&i.MinAmount,
+		&i.MaxAmount,
+		&i.FlatFee,
+		&i.PercentBps,
+		&i.MinFee,
+		&i.MaxFee,
+		&i.FeeAccountID,
+		&i.Priority,
+		&i.Active,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getPricingRuleForUpdate = `-- name: GetPricingRuleForUpdate :one
+SELECT id, tier, currency, min_amount, max_amount, flat_fee, percent_bps, min_fee, max_fee, fee_account_id, priority, active, created_at FROM pricing_rules
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetPricingRuleForUpdate(ctx context.Context, id int64) (PricingRule, error) {
+	row := q.db.QueryRowContext(ctx, getPricingRuleForUpdate, id)
+	var i PricingRule
+	err := row.Scan(
+		&i.ID,
+		&i.Tier,
+		&i.Currency,
+		&i.MinAmount,
+		&i.MaxAmount,
+		&i.FlatFee,
+		&i.PercentBps,
+		&i.MinFee,
+		&i.MaxFee,
+		&i.FeeAccountID,
+		&i.Priority,
+		&i.Active,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getProduct = `-- name: GetProduct :one
+SELECT code, name, day_count, created_at FROM products
+WHERE code = $1 LIMIT 1
+`
+
+func (q *Queries) GetProduct(ctx context.Context, code string) (Product, error) {
+	row := q.db.QueryRowContext(ctx, getProduct, code)
+	var i Product
+	err := row.Scan(
+		&i.Code,
+		&i.Name,
+		&i.DayCount,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getRateLimitBucket = `-- name: GetRateLimitBucket :one
+SELECT full_at FROM rate_limit_buckets
+WHERE key = $1 LIMIT 1
+`
+
+func (q *Queries) GetRateLimitBucket(ctx context.Context, key string) (time.Time, error) {
+	row := q.db.QueryRowContext(ctx, getRateLimitBucket, key)
+	var full_at time.Time
+	err := row.Scan(&full_at)
+	return full_at, err
+}
+
+const getRiskAssessment = `-- name: GetRiskAssessment :one
+SELECT id, owner, from_account_id, to_account_id, amount, currency, decision, score, hits, status, transfer_id, reviewed_by, reviewed_at, created_at FROM risk_assessments
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetRiskAssessment(ctx context.Context, id int64) (RiskAssessment, error) {
+	row := q.db.QueryRowContext(ctx, getRiskAssessment, id)
+	var i RiskAssessment
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Currency,
+		&i.Decision,
+		&i.Score,
+		&i.Hits,
+		&i.Status,
+		&i.TransferID,
+		&i.ReviewedBy,
+		&i.ReviewedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getRiskAssessmentForUpdate = `-- name: GetRiskAssessmentForUpdate :one
+SELECT id, owner, from_account_id, to_account_id, amount, currency, decision, score, hits, status, transfer_id, reviewed_by, reviewed_at, created_at FROM risk_assessments
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetRiskAssessmentForUpdate(ctx context.Context, id int64) (RiskAssessment, error) {
+	row := q.db.QueryRowContext(ctx, getRiskAssessmentForUpdate, id)
+	var i RiskAssessment
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Currency,
+		&i.Decision,
+		&i.Score,
+		&i.Hits,
+		&i.Status,
+		&i.TransferID,
+		&i.ReviewedBy,
+		&i.ReviewedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getScheduledTransfer = `-- name: GetScheduledTransfer :one
+SELECT id, owner, from_account_id, to_account_id, amount, currency, start_at, recurrence, end_at, status, next_occurrence_at, next_run_at, attempts, last_error, created_at, updated_at FROM scheduled_transfers
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetScheduledTransfer(ctx context.Context, id int64) (ScheduledTransfer, error) {
+	row := q.db.QueryRowContext(ctx, getScheduledTransfer, id)
+	var i ScheduledTransfer
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Currency,
+		&i.StartAt,
+		&i.Recurrence,
+		&i.EndAt,
+		&i.Status,
+		&i.NextOccurrenceAt,
+		&i.NextRunAt,
+		&i.Attempts,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getScheduledTransferForUpdate = `-- name: GetScheduledTransferForUpdate :one
+SELECT id, owner, from_account_id, to_account_id, amount, currency, start_at, recurrence, end_at, status, next_occurrence_at, next_run_at, attempts, last_error, created_at, updated_at FROM scheduled_transfers
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetScheduledTransferForUpdate(ctx context.Context, id int64) (ScheduledTransfer, error) {
+	row := q.db.QueryRowContext(ctx, getScheduledTransferForUpdate, id)
+	var i ScheduledTransfer
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Currency,
+		&i.StartAt,
+		&i.Recurrence,
+		&i.EndAt,
+		&i.Status,
+		&i.NextOccurrenceAt,
+		&i.NextRunAt,
+		&i.Attempts,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getScheduledTransferRun = `-- name: GetScheduledTransferRun :one
+SELECT id, scheduled_transfer_id, occurrence_at, status, attempts, transfer_id, last_error, created_at, updated_at FROM scheduled_transfer_runs
+WHERE scheduled_transfer_id = $1 AND occurrence_at = $2 LIMIT 1
+`
+
+type GetScheduledTransferRunParams struct {
+	ScheduledTransferID int64     `json:"scheduled_transfer_id"`
+	OccurrenceAt        time.Time `json:"occurrence_at"`
+}
+
+func (q *Queries) GetScheduledTransferRun(ctx context.Context, arg GetScheduledTransferRunParams) (ScheduledTransferRun, error) {
+	row := q.db.QueryRowContext(ctx, getScheduledTransferRun, arg.ScheduledTransferID, arg.OccurrenceAt)
+	var i ScheduledTransferRun
+	err := row.Scan(
+		&i.ID,
+		&i.ScheduledTransferID,
+		&i.OccurrenceAt,
+		&i.Status,
+		&i.Attempts,
+		&i.TransferID,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getSession = `-- name: GetSession :one
+SELECT id, username, refresh_token, user_agent, client_ip, is_blocked, expires_at, created_at FROM sessions
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetSession(ctx context.Context, id uuid.UUID) (Session, error) {
+	row := q.db.QueryRowContext(ctx, getSession, id)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.RefreshToken,
+		&i.UserAgent,
+		&i.ClientIp,
+		&i.IsBlocked,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getTransfer = `-- name: GetTransfer :one
+SELECT id, from_account_id, to_account_id, amount, created_at, journal_id FROM transfers
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetTransfer(ctx context.Context, id int64) (Transfer, error) {
+	row := q.db.QueryRowContext(ctx, getTransfer, id)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.JournalID,
+	)
+	return i, err
+}
+
+const getTransferBatch = `-- name: GetTransferBatch :one
+SELECT id, owner, mode, status, total_rows, succeeded_rows, failed_rows, lease_until, created_at, updated_at, completed_at FROM transfer_batches
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetTransferBatch(ctx context.Context, id int64) (TransferBatch, error) {
+	row := q.db.QueryRowContext(ctx, getTransferBatch, id)
+	var i TransferBatch
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Mode,
+		&i.Status,
+		&i.TotalRows,
+		&i.SucceededRows,
+		&i.FailedRows,
+		&i.LeaseUntil,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CompletedAt,
+	)
+	return i, err
+}
+
+const getTransferBatchForUpdate = `-- name: GetTransferBatchForUpdate :one
+SELECT id, owner, mode, status, total_rows, succeeded_rows, failed_rows, lease_until, created_at, updated_at, completed_at FROM transfer_batches
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetTransferBatchForUpdate(ctx context.Context, id int64) (TransferBatch, error) {
+	row := q.db.QueryRowContext(ctx, getTransferBatchForUpdate, id)
+	var i TransferBatch
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Mode,
+		&i.Status,
+		&i.TotalRows,
+		&i.SucceededRows,
+		&i.FailedRows,
+		&i.LeaseUntil,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CompletedAt,
+	)
+	return i, err
+}
+
+const getTrialBalanceAt = `-- name: GetTrialBalanceAt :many
+SELECT
+  a.currency,
+  a.type,
+  COUNT(*)::int AS accounts,
+  COALESCE(SUM(-b.balance) FILTER (WHERE b.balance < 0), 0)::bigint AS debits,
+  COALESCE(SUM(b.balance) FILTER (WHERE b.balance > 0), 0)::bigint AS credits
+FROM accounts a
+CROSS JOIN LATERAL (
+  SELECT (CASE
+    WHEN s.balance IS NULL THEN a.balance - COALESCE((
+      SELECT SUM(e.amount) FROM entries e
+      WHERE e.account_id = a.id AND e.created_at >= $1
+    ), 0)
+    ELSE s.balance + COALESCE((
+      SELECT SUM(e.amount) FROM entries e
+      WHERE e.account_id = a.id AND e.created_at >= s.end_at AND e.created_at < $1
+    ), 0)
+  END)::bigint AS balance
+  FROM (SELECT 1) one
+  LEFT JOIN LATERAL (
+    SELECT b.balance, (b.day + 1)::timestamp AT TIME ZONE 'UTC' AS end_at
+    FROM account_balance_snapshots b
+    WHERE b.account_id = a.id AND b.day < ($1::timestamptz AT TIME ZONE 'UTC')::date
+    ORDER BY b.day DESC
+    LIMIT 1
+  ) s ON true
+) b
+WHERE a.created_at < $1
+GROUP BY a.currency, a.type
+ORDER BY a.currency, a.type
+`
+
+type GetTrialBalanceAtRow struct {
+	Currency string `json:"currency"`
+	Type     string `json:"type"`
+	Accounts int32  `json:"accounts"`
+	Debits   int64  `json:"debits"`
+	Credits  int64  `json:"credits"`
+}
+
+func (q *Queries) GetTrialBalanceAt(ctx context.Context, at time.Time) ([]GetTrialBalanceAtRow, error) {
+	rows, err := q.db.QueryContext(ctx, getTrialBalanceAt, at)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetTrialBalanceAtRow{}
+	for rows.Next() {
+		var i GetTrialBalanceAtRow
+		if err := rows.Scan(
+			&i.Currency,
+			&i.Type,
+			&i.Accounts,
+			&i.Debits,
+			&i.Credits,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUser = `-- name: GetUser :one
+SELECT username, hashed_password, full_name, email, password_changed_at, created_at, role FROM users
+WHERE username = $1 LIMIT 1
+`
+
+func (q *Queries) GetUser(ctx context.Context, username string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUser, username)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.Role,
+	)
+	return i, err
+}
+
+const getUserForUpdate = `-- name: GetUserForUpdate :one
+SELECT username, hashed_password, full_name, email, password_changed_at, created_at, role FROM users
+WHERE username = $1 LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetUserForUpdate(ctx context.Context, username string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserForUpdate, username)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.Role,
+	)
+	return i, err
+}
+
+const getWebhookDelivery = `-- name: GetWebhookDelivery :one
+SELECT id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at, expires_at, last_attempt_at, response_status, last_error, created_at FROM webhook_deliveries
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetWebhookDelivery(ctx context.Context, id int64) (WebhookDelivery, error) {
+	row := q.db.QueryRowContext(ctx, getWebhookDelivery, id)
+	var i WebhookDelivery
+	err := row.Scan(
+		&i.ID,
+		&i.SubscriptionID,
+		&i.EventID,
+		&i.EventType,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.NextAttemptAt,
+		&i.ExpiresAt,
+		&i.LastAttemptAt,
+		&i.ResponseStatus,
+		&i.LastError,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getWebhookSubscription = `-- name: GetWebhookSubscription :one
+SELECT id, owner, url, event_types, secret, created_at FROM webhook_subscriptions
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetWebhookSubscription(ctx context.Context, id int64) (WebhookSubscription, error) {
+	row := q.db.QueryRowContext(ctx, getWebhookSubscription, id)
+	var i WebhookSubscription
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Url,
+		pq.Array(&i.EventTypes),
+		&i.Secret,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listAccountEntries = `-- name: ListAccountEntries :many
+SELECT id, account_id, amount, created_at, journal_id FROM entries
+WHERE account_id = $1 AND id > $2
+ORDER BY id
+LIMIT $3
+`
+
+type ListAccountEntriesParams struct {
+	AccountID  int64 `json:"account_id"`
+	AfterID    int64 `json:"after_id"`
+	LimitCount int32 `json:"limit_count"`
+}
+
+func (q *Queries) ListAccountEntries(ctx context.Context, arg ListAccountEntriesParams) ([]Entry, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountEntries, arg.AccountID, arg.AfterID, arg.LimitCount)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Entry{}
+	for rows.Next() {
+		var i Entry
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.JournalID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAccountHolds = `-- name: ListAccountHolds :many
+SELECT id, account_id, to_account_id, amount, captured_amount, currency, status, description, expires_at, created_at, updated_at, fee FROM holds
+WHERE account_id = $1 AND id > $2
+ORDER BY id
+LIMIT $3
+`
+
+type ListAccountHoldsParams struct {
+	AccountID  int64 `json:"account_id"`
+	AfterID    int64 `json:"after_id"`
+	LimitCount int32 `json:"limit_count"`
+}
+
+func (q *Queries) ListAccountHolds(ctx context.Context, arg ListAccountHoldsParams) ([]Hold, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountHolds, arg.AccountID, arg.AfterID, arg.LimitCount)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Hold{}
+	for rows.Next() {
+		var i Hold
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.CapturedAmount,
+			&i.Currency,
+			&i.Status,
+			&i.Description,
+			&i.ExpiresAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Fee,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAccountInterestAccruals = `-- name: ListAccountInterestAccruals :many
+SELECT id, account_id, day, balance, rate_bps, day_count, amount_micros, posting_id, created_at FROM interest_accruals
+WHERE account_id = $1 AND id > $2
+ORDER BY id
+LIMIT $3
+`
+
+type ListAccountInterestAccrualsParams struct {
+	AccountID  int64 `json:"account_id"`
+	AfterID    int64 `json:"after_id"`
+	LimitCount int32 `json:"limit_count"`
+}
+
+func (q *Queries) ListAccountInterestAccruals(ctx context.Context, arg ListAccountInterestAccrualsParams) ([]InterestAccrual, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountInterestAccruals, arg.AccountID, arg.AfterID, arg.LimitCount)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []InterestAccrual{}
+	for rows.Next() {
+		var i InterestAccrual
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Day,
+			&i.Balance,
+			&i.RateBps,
+			&i.DayCount,
+			&i.AmountMicros,
+			&i.PostingID,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAccountOutboxEvents = `-- name: ListAccountOutboxEvents :many
+SELECT id, account_id, event_type, schema_version, payload, attempts, last_error, published_at, created_at FROM outbox_events
+WHERE account_id = $1 AND id > $2
+ORDER BY id
+LIMIT $3
+`
+
+type ListAccountOutboxEventsParams struct {
+	AccountID  int64 `json:"account_id"`
+	AfterID    int64 `json:"after_id"`
+	LimitCount int32 `json:"limit_count"`
+}
+
+func (q *Queries) ListAccountOutboxEvents(ctx context.Context, arg ListAccountOutboxEventsParams) ([]OutboxEvent, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountOutboxEvents, arg.AccountID, arg.AfterID, arg.LimitCount)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []OutboxEvent{}
+	for rows.Next() {
+		var i OutboxEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.EventType,
+			&i.SchemaVersion,
+			&i.Payload,
+			&i.Attempts,
+			&i.LastError,
+			&i.PublishedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAccountingPeriods = `-- name: ListAccountingPeriods :many
+SELECT id, kind, start_day, end_day, closed_by, closed_at FROM accounting_periods
+WHERE id > $1
+ORDER BY id
+LIMIT $2
+`
+
+type ListAccountingPeriodsParams struct {
+	AfterID    int64 `json:"after_id"`
+	LimitCount int32 `json:"limit_count"`
+}
+
+func (q *Queries) ListAccountingPeriods(ctx context.Context, arg ListAccountingPeriodsParams) ([]AccountingPeriod, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountingPeriods, arg.AfterID, arg.LimitCount)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AccountingPeriod{}
+	for rows.Next() {
+		var i AccountingPeriod
+		if err := rows.Scan(
+			&i.ID,
+			&i.Kind,
+			&i.StartDay,
+			&i.EndDay,
+			&i.ClosedBy,
+			&i.ClosedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAccounts = `-- name: ListAccounts :many
+SELECT id, owner, balance, currency, created_at, status, tier, held_balance, available_balance, product, type, code FROM accounts
+WHERE owner = $1
+ORDER BY id
+LIMIT $2
+OFFSET $3
+`
+
+type ListAccountsParams struct {
+	Owner  string `json:"owner"`
+	Limit  int32  `json:"limit"`
+	Offset int32  `json:"offset"`
+}
+
+func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error) {
+	rows, err := q.db.QueryContext(ctx, listAccounts, arg.Owner, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Account{}
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Balance,
+			&i.Currency,
+			&i.CreatedAt,
+			&i.Status,
+			&i.Tier,
+			&i.HeldBalance,
+			&i.AvailableBalance,
+			&i.Product,
+			&i.Type,
+			&i.Code,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAccountsAfter = `-- name: ListAccountsAfter :many
+SELECT id, owner, balance, currency, created_at, status, tier, held_balance, available_balance, product, type, code FROM accounts
+WHERE owner = $1 AND id > $2
+ORDER BY id
+LIMIT $3
+`
+
+type ListAccountsAfterParams struct {
+	Owner      string `json:"owner"`
+	AfterID    int64  `json:"after_id"`
+	LimitCount int32  `json:"limit_count"`
+}
+
+func (q *Queries) ListAccountsAfter(ctx context.Context, arg ListAccountsAfterParams) ([]Account, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountsAfter, arg.Owner, arg.AfterID, arg.LimitCount)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Account{}
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Balance,
+			&i.Currency,
+			&i.CreatedAt,
+			&i.Status,
+			&i.Tier,
+			&i.HeldBalance,
+			&i.AvailableBalance,
+			&i.Product,
+			&i.Type,
+			&i.Code,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAccountsByIDs = `-- name: ListAccountsByIDs :many
+SELECT id, owner, balance, currency, created_at, status, tier, held_balance, available_balance, product, type, code FROM accounts
+WHERE id = ANY($1::bigint[])
+ORDER BY id
+`
+
+func (q *Queries) ListAccountsByIDs(ctx context.Context, ids []int64) ([]Account, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountsByIDs, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Account{}
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Balance,
+			&i.Currency,
+			&i.CreatedAt,
+			&i.Status,
+			&i.Tier,
+			&i.HeldBalance,
+			&i.AvailableBalance,
+			&i.Product,
+			&i.Type,
+			&i.Code,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAccountsForUpdate = `-- name: ListAccountsForUpdate :many
+SELECT id, owner, balance, currency, created_at, status, tier, held_balance, available_balance, product, type, code FROM accounts
+WHERE id = ANY($1::bigint[])
+ORDER BY id
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) ListAccountsForUpdate(ctx context.Context, ids []int64) ([]Account, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountsForUpdate, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Account{}
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Balance,
+			&i.Currency,
+			&i.CreatedAt,
+			&i.Status,
+			&i.Tier,
+			&i.HeldBalance,
+			&i.AvailableBalance,
+			&i.Product,
+			&i.Type,
+			&i.Code,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAccountsToAccrue = `-- name: ListAccountsToAccrue :many
+SELECT a.id FROM accounts a
+WHERE a.id > $1
+  AND a.status <> 'closed'
+  AND a.created_at < $2
+  AND EXISTS (
+    SELECT 1 FROM interest_rates r
+    WHERE r.product = a.product AND r.currency = a.currency AND r.effective_from <= $3
+  )
+  AND NOT EXISTS (
+    SELECT 1 FROM interest_accruals i
+    WHERE i.account_id = a.id AND i.day = $3
+  )
+  AND NOT EXISTS (
+    SELECT 1 FROM interest_postings p
+    WHERE p.account_id = a.id AND p.month >= date_trunc('month', $3::date)
+  )
+ORDER BY a.id
+LIMIT $4
+`
+
+type ListAccountsToAccrueParams struct {
+	AfterID    int64     `json:"after_id"`
+	EndOfDay   time.Time `json:"end_of_day"`
+	Day        time.Time `json:"day"`
+	LimitCount int32     `json:"limit_count"`
+}
+
+func (q *Queries) ListAccountsToAccrue(ctx context.Context, arg ListAccountsToAccrueParams) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountsToAccrue,
+		arg.AfterID,
+		arg.EndOfDay,
+		arg.Day,
+		arg.LimitCount,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAccountsToSnapshot = `-- name: ListAccountsToSnapshot :many
+SELECT a.id FROM accounts a
+WHERE a.id > $1
+  AND a.created_at < $2
+  AND NOT EXISTS (
+    SELECT 1 FROM account_balance_snapshots b
+    WHERE b.account_id = a.id AND b.day = $3
+  )
+  AND (
+    EXISTS (
+      SELECT 1 FROM entries e
+      WHERE e.account_id = a.id
+        AND e.created_at >= $2::timestamptz - interval '1 day'
+        AND e.created_at < $2
+    )
+    OR NOT EXISTS (
+      SELECT 1 FROM account_balance_snapshots b
+      WHERE b.account_id = a.id
+    )
+  )
+ORDER BY a.id
+LIMIT $4
+`
+
+type ListAccountsToSnapshotParams struct {
+	AfterID    int64     `json:"after_id"`
+	EndOfDay   time.Time `json:"end_of_day"`
+	Day        time.Time `json:"day"`
+	LimitCount int32     `json:"limit_count"`
+}
+
+func (q *Queries) ListAccountsToSnapshot(ctx context.Context, arg ListAccountsToSnapshotParams) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountsToSnapshot,
+		arg.AfterID,
+		arg.EndOfDay,
+		arg.Day,
+		arg.LimitCount,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listActivePricingRules = `-- name: ListActivePricingRules :many
+SELECT id, tier, currency, min_amount, max_amount, flat_fee, percent_bps, min_fee, max_fee, fee_account_id, priority, active, created_at FROM pricing_rules
+WHERE currency = $1 AND active
+ORDER BY id
+`
+
+func (q *Queries) ListActivePricingRules(ctx context.Context, currency string) ([]PricingRule, error) {
+	rows, err := q.db.QueryContext(ctx, listActivePricingRules, currency)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []PricingRule{}
+	for rows.Next() {
+		var i PricingRule
+		if err := rows.Scan(
+			&i.ID,
+			&i.Tier,
+			&i.Currency,
+			&i.MinAmount,
+			&i.MaxAmount,
+			&i.FlatFee,
+			&i.PercentBps,
+			&i.MinFee,
+			&i.MaxFee,
+			&i.FeeAccountID,
+			&i.Priority,
+			&i.Active,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAllAccounts = `-- name: ListAllAccounts :many
+SELECT id, owner, balance, currency, created_at, status, tier, held_balance, available_balance, product, type, code FROM accounts
+WHERE ($1::varchar IS NULL OR owner = $1)
+  AND ($2::varchar IS NULL OR status = $2)
+  AND ($3::varchar IS NULL OR currency = $3)
+  AND id > $4
+ORDER BY id
+LIMIT $5
+`
+
+type ListAllAccountsParams struct {
+	Owner      sql.NullString `json:"owner"`
+	Status     sql.NullString `json:"status"`
+	Currency   sql.NullString `json:"currency"`
+	AfterID    int64          `json:"after_id"`
+	LimitCount int32          `json:"limit_count"`
+}
+
+func (q *Queries) ListAllAccounts(ctx context.Context, arg ListAllAccountsParams) ([]Account, error) {
+	rows, err := q.db.QueryContext(ctx, listAllAccounts,
+		arg.Owner,
+		arg.Status,
+		arg.Currency,
+		arg.AfterID,
+		arg.LimitCount,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Account{}
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Balance,
+			&i.Currency,
+			&i.CreatedAt,
+			&i.Status,
+			&i.Tier,
+			&i.HeldBalance,
+			&i.AvailableBalance,
+			&i.Product,
+			&i.Type,
+			&i.Code,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listApiKeys = `-- name: ListApiKeys :many
+SELECT id, owner, name, prefix, hashed_secret, scopes, allowed_ips, expires_at, last_used_at, revoked_at, created_at, signing_secret FROM api_keys
+WHERE owner = $1
+ORDER BY id
+`
+
+func (q *Queries) ListApiKeys(ctx context.Context, owner string) ([]ApiKey, error) {
+	rows, err := q.db.QueryContext(ctx, listApiKeys, owner)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ApiKey{}
+	for rows.Next() {
+		var i ApiKey
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Name,
+			&i.Prefix,
+			&i.HashedSecret,
+			pq.Array(&i.Scopes),
+			pq.Array(&i.AllowedIps),
+			&i.ExpiresAt,
+			&i.LastUsedAt,
+			&i.RevokedAt,
+			&i.CreatedAt,
+			&i.SigningSecret,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAuditLog = `-- name: ListAuditLog :many
+SELECT id, actor, action, target_type, target_id, before, after, ip, user_agent, request_id, prev_hash, hash, created_at FROM audit_log
+WHERE ($1::varchar IS NULL OR actor = $1)
+  AND ($2::varchar IS NULL OR action = $2)
+  AND ($3::varchar IS NULL OR target_type = $3)
+  AND ($4::varchar IS NULL OR target_id = $4)
+  AND ($5::timestamptz IS NULL OR created_at >= $5)
+  AND ($6::timestamptz IS NULL OR created_at < $6)
+  AND id > $7
+ORDER BY id
+LIMIT $8
+`
+
+type ListAuditLogParams struct {
+	Actor      sql.NullString `json:"actor"`
+	Action     sql.NullString `json:"action"`
+	TargetType sql.NullString `json:"target_type"`
+	TargetID   sql.NullString `json:"target_id"`
+	FromAt     sql.NullTime   `json:"from_at"`
+	ToAt       sql.NullTime   `json:"to_at"`
+	AfterID    int64          `json:"after_id"`
+	LimitCount int32          `json:"limit_count"`
+}
+
+func (q *Queries) ListAuditLog(ctx context.Context, arg ListAuditLogParams) ([]AuditLog, error) {
+	rows, err := q.db.QueryContext(ctx, listAuditLog,
+		arg.Actor,
+		arg.Action,
+		arg.TargetType,
+		arg.TargetID,
+		arg.FromAt,
+		arg.ToAt,
+		arg.AfterID,
+		arg.LimitCount,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AuditLog{}
+	for rows.Next() {
+		var i AuditLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.Actor,
+			&i.Action,
+			&i.TargetType,
+			&i.TargetID,
+			&i.Before,
+			&i.After,
+			&i.IP,
+			&i.UserAgent,
+			&i.RequestID,
+			&i.PrevHash,
+			&i.Hash,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listEntries = `-- name: ListEntries :many
+SELECT id, account_id, amount, created_at, journal_id FROM entries
+ORDER BY id
+LIMIT $1
+OFFSET $2
+`
+
+type ListEntriesParams struct {
+	Limit  int32 `json:"limit"`
+	Offset int32 `json:"offset"`
+}
+
+func (q *Queries) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entry, error) {
+	rows, err := q.db.QueryContext(ctx, listEntries, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Entry{}
+	for rows.Next() {
+		var i Entry
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.JournalID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listInterestRates = `-- name: ListInterestRates :many
+SELECT id, product, currency, rate_bps, effective_from, expense_account_id, created_by, created_at FROM interest_rates
+WHERE ($1::varchar IS NULL OR product = $1)
+  AND ($2::varchar IS NULL OR currency = $2)
+  AND id > $3
+ORDER BY id
+LIMIT $4
+`
+
+type ListInterestRatesParams struct {
+	Product    sql.NullString `json:"product"`
+	Currency   sql.NullString `json:"currency"`
+	AfterID    int64          `json:"after_id"`
+	LimitCount int32          `json:"limit_count"`
+}
+
+func (q *Queries) ListInterestRates(ctx context.Context, arg ListInterestRatesParams) ([]InterestRate, error) {
+	rows, err := q.db.QueryContext(ctx, listInterestRates,
+		arg.Product,
+		arg.Currency,
+		arg.AfterID,
+		arg.LimitCount,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []InterestRate{}
+	for rows.Next() {
+		var i InterestRate
+		if err := rows.Scan(
+			&i.ID,
+			&i.Product,
+			&i.Currency,
+			&i.RateBps,
+			&i.EffectiveFrom,
+			&i.ExpenseAccountID,
+			&i.CreatedBy,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listInternalAccounts = `-- name: ListInternalAccounts :many
+SELECT id, owner, balance, currency, created_at, status, tier, held_balance, available_balance, product, type, code FROM accounts
+WHERE code IS NOT NULL
+ORDER BY currency, code
+`
+
+func (q *Queries) ListInternalAccounts(ctx context.Context) ([]Account, error) {
+	rows, err := q.db.QueryContext(ctx, listInternalAccounts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Account{}
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Balance,
+			&i.Currency,
+			&i.CreatedAt,
+			&i.Status,
+			&i.Tier,
+			&i.HeldBalance,
+			&i.AvailableBalance,
+			&i.Product,
+			&i.Type,
+			&i.Code,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listJournalEntries = `-- name: ListJournalEntries :many
+SELECT id, account_id, amount, created_at, journal_id FROM entries
+WHERE journal_id = $1
+ORDER BY id
+`
+
+func (q *Queries) ListJournalEntries(ctx context.Context, journalID sql.NullInt64) ([]Entry, error) {
+	rows, err := q.db.QueryContext(ctx, listJournalEntries, journalID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Entry{}
+	for rows.Next() {
+		var i Entry
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.JournalID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listLoanInstallments = `-- name: ListLoanInstallments :many
+SELECT id, loan_id, number, due_date, principal, interest, penalty, paid_principal, paid_interest, paid_penalty, status, updated_at FROM loan_installments
+WHERE loan_id = $1
+ORDER BY number
+`
+
+func (q *Queries) ListLoanInstallments(ctx context.Context, loanID int64) ([]LoanInstallment, error) {
+	rows, err := q.db.QueryContext(ctx, listLoanInstallments, loanID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []LoanInstallment{}
+	for rows.Next() {
+		var i LoanInstallment
+		if err := rows.Scan(
+			&i.ID,
+			&i.LoanID,
+			&i.Number,
+			&i.DueDate,
+			&i.Principal,
+			&i.Interest,
+			&i.Penalty,
+			&i.PaidPrincipal,
+			&i.PaidInterest,
+			&i.PaidPenalty,
+			&i.Status,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listLoanRepayments = `-- name: ListLoanRepayments :many
+SELECT id, loan_id, amount, principal, interest, penalty, journal_id, created_at FROM loan_repayments
+WHERE loan_id = $1 AND id > $2
+ORDER BY id
+LIMIT $3
+`
+
+type ListLoanRepaymentsParams struct {
+	LoanID     int64 `json:"loan_id"`
+	AfterID    int64 `json:"after_id"`
+	LimitCount int32 `json:"limit_count"`
+}
+
+func (q *Queries) ListLoanRepayments(ctx context.Context, arg ListLoanRepaymentsParams) ([]LoanRepayment, error) {
+	rows, err := q.db.QueryContext(ctx, listLoanRepayments, arg.LoanID, arg.AfterID, arg.LimitCount)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []LoanRepayment{}
+	for rows.Next() {
+		var i LoanRepayment
+		if err := rows.Scan(
+			&i.ID,
+			&i.LoanID,
+			&i.Amount,
+			&i.Principal,
+			&i.Interest,
+			&i.Penalty,
+			&i.JournalID,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOwnerLoans = `-- name: ListOwnerLoans :many
+SELECT l.id, l.account_id, l.borrower_account_id, l.income_account_id, l.principal, l.rate_bps, l.term_months, l.method, l.currency, l.late_fee, l.grace_days, l.status, l.created_by, l.created_at, l.updated_at FROM loans l
+JOIN accounts a ON a.id = l.borrower_account_id
+WHERE a.owner = $1 AND l.id > $2
+ORDER BY l.id
+LIMIT $3
+`
+
+type ListOwnerLoansParams struct {
+	Owner      string `json:"owner"`
+	AfterID    int64  `json:"after_id"`
+	LimitCount int32  `json:"limit_count"`
+}
+
+func (q *Queries) ListOwnerLoans(ctx context.Context, arg ListOwnerLoansParams) ([]Loan, error) {
+	rows, err := q.db.QueryContext(ctx, listOwnerLoans, arg.Owner, arg.AfterID, arg.LimitCount)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Loan{}
+	for rows.Next() {
+		var i Loan
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.BorrowerAccountID,
+			&i.IncomeAccountID,
+			&i.Principal,
+			&i.RateBps,
+			&i.TermMonths,
+			&i.Method,
+			&i.Currency,
+			&i.LateFee,
+			&i.GraceDays,
+			&i.Status,
+			&i.CreatedBy,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPendingTransferBatchItems = `-- name: ListPendingTransferBatchItems :many
+SELECT id, batch_id, row_number, from_account_id, to_account_id, amount, currency, status, transfer_id, error FROM transfer_batch_items
+WHERE batch_id = $1 AND status = 'pending'
+ORDER BY row_number
+LIMIT $2
+`
+
+type ListPendingTransferBatchItemsParams struct {
+	BatchID    int64 `json:"batch_id"`
+	LimitCount int32 `json:"limit_count"`
+}
+
+func (q *Queries) ListPendingTransferBatchItems(ctx context.Context, arg ListPendingTransferBatchItemsParams) ([]TransferBatchItem, error) {
+	rows, err := q.db.QueryContext(ctx, listPendingTransferBatchItems, arg.BatchID, arg.LimitCount)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []TransferBatchItem{}
+	for rows.Next() {
+		var i TransferBatchItem
+		if err := rows.Scan(
+			&i.ID,
+			&i.BatchID,
+			&i.RowNumber,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.Currency,
+			&i.Status,
+			&i.TransferID,
+			&i.Error,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPeriodTrialBalances = `-- name: ListPeriodTrialBalances :many
+SELECT period_id, currency, accounts, debits, credits, type FROM period_trial_balances
+WHERE period_id = $1
+ORDER BY currency, type
+`
+
+func (q *Queries) ListPeriodTrialBalances(ctx context.Context, periodID int64) ([]PeriodTrialBalance, error) {
+	rows, err := q.db.QueryContext(ctx, listPeriodTrialBalances, periodID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []PeriodTrialBalance{}
+	for rows.Next() {
+		var i PeriodTrialBalance
+		if err := rows.Scan(
+			&i.PeriodID,
+			&i.Currency,
+			&i.Accounts,
+			&i.Debits,
+			&i.Credits,
+			&i.Type,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPricingRules = `-- name: ListPricingRules :many
+SELECT id, tier, currency, min_amount, max_amount, flat_fee, percent_bps, min_fee, max_fee, fee_account_id, priority, active, created_at FROM pricing_rules
+WHERE ($1::varchar IS NULL OR currency = $1)
+  AND id > $2
+ORDER BY id
+LIMIT $3
+`
+
+type ListPricingRulesParams struct {
+	Currency   sql.NullString `json:"currency"`
+	AfterID    int64          `json:"after_id"`
+	LimitCount int32          `json:"limit_count"`
+}
+
+func (q *Queries) ListPricingRules(ctx context.Context, arg ListPricingRulesParams) ([]PricingRule, error) {
+	rows, err := q.db.QueryContext(ctx, listPricingRules, arg.Currency, arg.AfterID, arg.LimitCount)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []PricingRule{}
+	for rows.Next() {
+		var i PricingRule
+		if err := rows.Scan(
+			&i.ID,
+			&i.Tier,
+			&i.Currency,
+			&i.MinAmount,
+			&i.MaxAmount,
+			&i.FlatFee,
+			&i.PercentBps,
+			&i.MinFee,
+			&i.MaxFee,
+			&i.FeeAccountID,
+			&i.Priority,
+			&i.Active,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listProducts = `-- name: ListProducts :many
+SELECT code, name, day_count, created_at FROM products
+ORDER BY code
+`
+
+func (q *Queries) ListProducts(ctx context.Context) ([]Product, error) {
+	rows, err := q.db.QueryContext(ctx, listProducts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Product{}
+	for rows.Next() {
+		var i Product
+		if err := rows.Scan(
+			&i.Code,
+			&i.Name,
+			&i.DayCount,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRiskAssessments = `-- name: ListRiskAssessments :many
+SELECT id, owner, from_account_id, to_account_id, amount, currency, decision, score, hits, status, transfer_id, reviewed_by, reviewed_at, created_at FROM risk_assessments
+WHERE ($1::varchar IS NULL OR status = $1)
+  AND id > $2
+ORDER BY id
+LIMIT $3
+`
+
+type ListRiskAssessmentsParams struct {
+	Status     sql.NullString `json:"status"`
+	AfterID    int64          `json:"after_id"`
+	LimitCount int32          `json:"limit_count"`
+}
+
+func (q *Queries) ListRiskAssessments(ctx context.Context, arg ListRiskAssessmentsParams) ([]RiskAssessment, error) {
+	rows, err := q.db.QueryContext(ctx, listRiskAssessments, arg.Status, arg.AfterID, arg.LimitCount)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []RiskAssessment{}
+	for rows.Next() {
+		var i RiskAssessment
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.Currency,
+			&i.Decision,
+			&i.Score,
+			&i.Hits,
+			&i.Status,
+			&i.TransferID,
+			&i.ReviewedBy,
+			&i.ReviewedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listScheduledTransferRuns = `-- name: ListScheduledTransferRuns :many
+SELECT id, scheduled_transfer_id, occurrence_at, status, attempts, transfer_id, last_error, created_at, updated_at FROM scheduled_transfer_runs
+WHERE scheduled_transfer_id = $1 AND id > $2
+ORDER BY id
+LIMIT $3
+`
+
+type ListScheduledTransferRunsParams struct {
+	ScheduledTransferID int64 `json:"scheduled_transfer_id"`
+	AfterID             int64 `json:"after_id"`
+	LimitCount          int32 `json:"limit_count"`
+}
+
+func (q *Queries) ListScheduledTransferRuns(ctx context.Context, arg ListScheduledTransferRunsParams) ([]ScheduledTransferRun, error) {
+	rows, err := q.db.QueryContext(ctx, listScheduledTransferRuns, arg.ScheduledTransferID, arg.AfterID, arg.LimitCount)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ScheduledTransferRun{}
+	for rows.Next() {
+		var i ScheduledTransferRun
+		if err := rows.Scan(
+			&i.ID,
+			&i.ScheduledTransferID,
+			&i.OccurrenceAt,
+			&i.Status,
+			&i.Attempts,
+			&i.TransferID,
+			&i.LastError,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listScheduledTransfers = `-- name: ListScheduledTransfers :many
+SELECT id, owner, from_account_id, to_account_id, amount, currency, start_at, recurrence, end_at, status, next_occurrence_at, next_run_at, attempts, last_error, created_at, updated_at FROM scheduled_transfers
+WHERE owner = $1 AND id > $2
+ORDER BY id
+LIMIT $3
+`
+
+type ListScheduledTransfersParams struct {
+	Owner      string `json:"owner"`
+	AfterID    int64  `json:"after_id"`
+	LimitCount int32  `json:"limit_count"`
+}
+
+func (q *Queries) ListScheduledTransfers(ctx context.Context, arg ListScheduledTransfersParams) ([]ScheduledTransfer, error) {
+	rows, err := q.db.QueryContext(ctx, listScheduledTransfers, arg.Owner, arg.AfterID, arg.LimitCount)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ScheduledTransfer{}
+	for rows.Next() {
+		var i ScheduledTransfer
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.Currency,
+			&i.StartAt,
+			&i.Recurrence,
+			&i.EndAt,
+			&i.Status,
+			&i.NextOccurrenceAt,
+			&i.NextRunAt,
+			&i.Attempts,
+			&i.LastError,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTransferBatchItems = `-- name: ListTransferBatchItems :many
+SELECT id, batch_id, row_number, from_account_id, to_account_id, amount, currency, status, transfer_id, error FROM transfer_batch_items
+WHERE batch_id = $1 AND id > $2
+ORDER BY id
+LIMIT $3
+`
+
+type ListTransferBatchItemsParams struct {
+	BatchID    int64 `json:"batch_id"`
+	AfterID    int64 `json:"after_id"`
+	LimitCount int32 `json:"limit_count"`
+}
+
+func (q *Queries) ListTransferBatchItems(ctx context.Context, arg ListTransferBatchItemsParams) ([]TransferBatchItem, error) {
+	rows, err := q.db.QueryContext(ctx, listTransferBatchItems, arg.BatchID, arg.AfterID, arg.LimitCount)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []TransferBatchItem{}
+	for rows.Next() {
+		var i TransferBatchItem
+		if err := rows.Scan(
+			&i.ID,
+			&i.BatchID,
+			&i.RowNumber,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.Currency,
+			&i.Status,
+			&i.TransferID,
+			&i.Error,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTransferBatches = `-- name: ListTransferBatches :many
+SELECT id, owner, mode, status, total_rows, succeeded_rows, failed_rows, lease_until, created_at, updated_at, completed_at FROM transfer_batches
+WHERE owner = $1 AND id > $2
+ORDER BY id
+LIMIT $3
+`
+
+type ListTransferBatchesParams struct {
+	Owner      string `json:"owner"`
+	AfterID    int64  `json:"after_id"`
+	LimitCount int32  `json:"limit_count"`
+}
+
+func (q *Queries) ListTransferBatches(ctx context.Context, arg ListTransferBatchesParams) ([]TransferBatch, error) {
+	rows, err := q.db.QueryContext(ctx, listTransferBatches, arg.Owner, arg.AfterID, arg.LimitCount)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []TransferBatch{}
+	for rows.Next() {
+		var i TransferBatch
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Mode,
+			&i.Status,
+			&i.TotalRows,
+			&i.SucceededRows,
+			&i.FailedRows,
+			&i.LeaseUntil,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.CompletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTransferLimits = `-- name: ListTransferLimits :many
+SELECT id, account_id, owner, max_amount, daily_amount, monthly_amount, hourly_count, expires_at, reason, created_by, created_at FROM transfer_limits
+WHERE ($1::bigint IS NULL OR account_id = $1)
+  AND ($2::varchar IS NULL OR owner = $2)
+  AND id > $3
+ORDER BY id
+LIMIT $4
+`
+
+type ListTransferLimitsParams struct {
+	AccountID  sql.NullInt64  `json:"account_id"`
+	Owner      sql.NullString `json:"owner"`
+	AfterID    int64          `json:"after_id"`
+	LimitCount int32          `json:"limit_count"`
+}
+
+func (q *Queries) ListTransferLimits(ctx context.Context, arg ListTransferLimitsParams) ([]TransferLimit, error) {
+	rows, err := q.db.QueryContext(ctx, listTransferLimits,
+		arg.AccountID,
+		arg.Owner,
+		arg.AfterID,
+		arg.LimitCount,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []TransferLimit{}
+	for rows.Next() {
+		var i TransferLimit
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Owner,
+			&i.MaxAmount,
+			&i.DailyAmount,
+			&i.MonthlyAmount,
+			&i.HourlyCount,
+			&i.ExpiresAt,
+			&i.Reason,
+			&i.CreatedBy,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTransfers = `-- name: ListTransfers :many
+SELECT id, from_account_id, to_account_id, amount, created_at, journal_id FROM transfers
+ORDER BY id
+LIMIT $1
+OFFSET $2
+`
+
+type ListTransfersParams struct {
+	Limit  int32 `json:"limit"`
+	Offset int32 `json:"offset"`
+}
+
+func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error) {
+	rows, err := q.db.QueryContext(ctx, listTransfers, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Transfer{}
+	for rows.Next() {
+		var i Transfer
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.JournalID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUnbalancedJournals = `-- name: ListUnbalancedJournals :many
+SELECT DISTINCT j.id FROM journals j
+JOIN entries e ON e.journal_id = j.id
+JOIN accounts a ON a.id = e.account_id
+WHERE j.effective_at >= $1 AND j.effective_at < $2
+GROUP BY j.id, a.currency
+HAVING SUM(e.amount) <> 0
+ORDER BY j.id
+LIMIT $3
+`
+
+type ListUnbalancedJournalsParams struct {
+	FromAt     time.Time `json:"from_at"`
+	ToAt       time.Time `json:"to_at"`
+	LimitCount int32     `json:"limit_count"`
+}
+
+func (q *Queries) ListUnbalancedJournals(ctx context.Context, arg ListUnbalancedJournalsParams) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, listUnbalancedJournals, arg.FromAt, arg.ToAt, arg.LimitCount)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUnpaidLoanInstallmentsForUpdate = `-- name: ListUnpaidLoanInstallmentsForUpdate :many
+SELECT id, loan_id, number, due_date, principal, interest, penalty, paid_principal, paid_interest, paid_penalty, status, updated_at FROM loan_installments
+WHERE loan_id = $1 AND status <> 'paid'
+ORDER BY number
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) ListUnpaidLoanInstallmentsForUpdate(ctx context.Context, loanID int64) ([]LoanInstallment, error) {
+	rows, err := q.db.QueryContext(ctx, listUnpaidLoanInstallmentsForUpdate, loanID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []LoanInstallment{}
+	for rows.Next() {
+		var i LoanInstallment
+		if err := rows.Scan(
+			&i.ID,
+			&i.LoanID,
+			&i.Number,
+			&i.DueDate,
+			&i.Principal,
+			&i.Interest,
+			&i.Penalty,
+			&i.PaidPrincipal,
+			&i.PaidInterest,
+			&i.PaidPenalty,
+			&i.Status,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUnpostedInterestMonths = `-- name: ListUnpostedInterestMonths :many
+SELECT DISTINCT i.account_id, date_trunc('month', i.day)::date AS month FROM interest_accruals i
+WHERE i.posting_id IS NULL AND i.day < $1
+  AND NOT EXISTS (
+    SELECT 1 FROM interest_postings p
+    WHERE p.account_id = i.account_id AND p.month = date_trunc('month', i.day)
+  )
+ORDER BY month, i.account_id
+LIMIT $2
+`
+
+type ListUnpostedInterestMonthsParams struct {
+	Before     time.Time `json:"before"`
+	LimitCount int32     `json:"limit_count"`
+}
+
+type ListUnpostedInterestMonthsRow struct {
+	AccountID int64     `json:"account_id"`
+	Month     time.Time `json:"month"`
+}
+
+func (q *Queries) ListUnpostedInterestMonths(ctx context.Context, arg ListUnpostedInterestMonthsParams) ([]ListUnpostedInterestMonthsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listUnpostedInterestMonths, arg.Before, arg.LimitCount)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListUnpostedInterestMonthsRow{}
+	for rows.Next() {
+		var i ListUnpostedInterestMonthsRow
+		if err := rows.Scan(
+			&i.AccountID,
+			&i.Month,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUnpublishedOutboxEventsForUpdate = `-- name: ListUnpublishedOutboxEventsForUpdate :many
+SELECT id, account_id, event_type, schema_version, payload, attempts, last_error, published_at, created_at FROM outbox_events
+WHERE published_at IS NULL
+ORDER BY id
 LIMIT $1
-OFFSET $2
+FOR UPDATE
+`
+
+func (q *Queries) ListUnpublishedOutboxEventsForUpdate(ctx context.Context, limit int32) ([]OutboxEvent, error) {
+	rows, err := q.db.QueryContext(ctx, listUnpublishedOutboxEventsForUpdate, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []OutboxEvent{}
+	for rows.Next() {
+		var i OutboxEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.EventType,
+			&i.SchemaVersion,
+			&i.Payload,
+			&i.Attempts,
+			&i.LastError,
+			&i.PublishedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWebhookDeliveries = `-- name: ListWebhookDeliveries :many
+SELECT id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at, expires_at, last_attempt_at, response_status, last_error, created_at FROM webhook_deliveries
+WHERE subscription_id = $1 AND id > $2
+ORDER BY id
+LIMIT $3
+`
+
+type ListWebhookDeliveriesParams struct {
+	SubscriptionID int64 `json:"subscription_id"`
+	AfterID        int64 `json:"after_id"`
+	LimitCount     int32 `json:"limit_count"`
+}
+
+func (q *Queries) ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]WebhookDelivery, error) {
+	rows, err := q.db.QueryContext(ctx, listWebhookDeliveries, arg.SubscriptionID, arg.AfterID, arg.LimitCount)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []WebhookDelivery{}
+	for rows.Next() {
+		var i WebhookDelivery
+		if err := rows.Scan(
+			&i.ID,
+			&i.SubscriptionID,
+			&i.EventID,
+			&i.EventType,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.NextAttemptAt,
+			&i.ExpiresAt,
+			&i.LastAttemptAt,
+			&i.ResponseStatus,
+			&i.LastError,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWebhookSubscriptions = `-- name: ListWebhookSubscriptions :many
+SELECT id, owner, url, event_types, secret, created_at FROM webhook_subscriptions
+WHERE owner = $1
+ORDER BY id
+`
+
+func (q *Queries) ListWebhookSubscriptions(ctx context.Context, owner string) ([]WebhookSubscription, error) {
+	rows, err := q.db.QueryContext(ctx, listWebhookSubscriptions, owner)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []WebhookSubscription{}
+	for rows.Next() {
+		var i WebhookSubscription
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Url,
+			pq.Array(&i.EventTypes),
+			&i.Secret,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockAccountingPeriods = `-- name: LockAccountingPeriods :exec
+SELECT pg_advisory_xact_lock(hashtext('accounting_periods'))
+`
+
+func (q *Queries) LockAccountingPeriods(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, lockAccountingPeriods)
+	return err
+}
+
+const lockAccountingPeriodsShared = `-- name: LockAccountingPeriodsShared :exec
+SELECT pg_advisory_xact_lock_shared(hashtext('accounting_periods'))
+`
+
+func (q *Queries) LockAccountingPeriodsShared(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, lockAccountingPeriodsShared)
+	return err
+}
+
+const lockAuditLog = `-- name: LockAuditLog :exec
+SELECT pg_advisory_xact_lock(hashtext('audit_log'))
+`
+
+func (q *Queries) LockAuditLog(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, lockAuditLog)
+	return err
+}
+
+const lockOwnerTransfers = `-- name: LockOwnerTransfers :exec
+SELECT pg_advisory_xact_lock(hashtext('transfer_limits'), hashtext($1))
+`
+
+func (q *Queries) LockOwnerTransfers(ctx context.Context, owner string) error {
+	_, err := q.db.ExecContext(ctx, lockOwnerTransfers, owner)
+	return err
+}
+
+const markInterestAccrualsPosted = `-- name: MarkInterestAccrualsPosted :execrows
+UPDATE interest_accruals
+SET posting_id = $1
+WHERE account_id = $2 AND day >= $3 AND day < $4 AND posting_id IS NULL
+`
+
+type MarkInterestAccrualsPostedParams struct {
+	PostingID  sql.NullInt64 `json:"posting_id"`
+	AccountID  int64         `json:"account_id"`
+	MonthStart time.Time     `json:"month_start"`
+	MonthEnd   time.Time     `json:"month_end"`
+}
+
+func (q *Queries) MarkInterestAccrualsPosted(ctx context.Context, arg MarkInterestAccrualsPostedParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, markInterestAccrualsPosted,
+		arg.PostingID,
+		arg.AccountID,
+		arg.MonthStart,
+		arg.MonthEnd,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const markOutboxEventFailed = `-- name: MarkOutboxEventFailed :exec
+UPDATE outbox_events
+SET attempts = attempts + 1, last_error = $2
+WHERE id = $1
+`
+
+type MarkOutboxEventFailedParams struct {
+	ID        int64          `json:"id"`
+	LastError sql.NullString `json:"last_error"`
+}
+
+func (q *Queries) MarkOutboxEventFailed(ctx context.Context, arg MarkOutboxEventFailedParams) error {
+	_, err := q.db.ExecContext(ctx, markOutboxEventFailed, arg.ID, arg.LastError)
+	return err
+}
+
+const markOutboxEventPublished = `-- name: MarkOutboxEventPublished :exec
+UPDATE outbox_events
+SET published_at = now(), attempts = attempts + 1, last_error = NULL
+WHERE id = $1
+`
+
+func (q *Queries) MarkOutboxEventPublished(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, markOutboxEventPublished, id)
+	return err
+}
+
+const payLoanInstallment = `-- name: PayLoanInstallment :one
+UPDATE loan_installments
+SET
+  paid_principal = paid_principal + $1,
+  paid_interest = paid_interest + $2,
+  paid_penalty = paid_penalty + $3,
+  status = CASE
+    WHEN paid_principal + $1 = principal AND paid_interest + $2 = interest AND paid_penalty + $3 = penalty THEN 'paid'
+    ELSE status
+  END,
+  updated_at = now()
+WHERE id = $4
+RETURNING id, loan_id, number, due_date, principal, interest, penalty, paid_principal, paid_interest, paid_penalty, status, updated_at
+`
+
+type PayLoanInstallmentParams struct {
+	Principal int64 `json:"principal"`
+	Interest  int64 `json:"interest"`
+	Penalty   int64 `json:"penalty"`
+	ID        int64 `json:"id"`
+}
+
+func (q *Queries) PayLoanInstallment(ctx context.Context, arg PayLoanInstallmentParams) (LoanInstallment, error) {
+	row := q.db.QueryRowContext(ctx, payLoanInstallment,
+		arg.Principal,
+		arg.Interest,
+		arg.Penalty,
+		arg.ID,
+	)
+	var i LoanInstallment
+	err := row.Scan(
+		&i.ID,
+		&i.LoanID,
+		&i.Number,
+		&i.DueDate,
+		&i.Principal,
+		&i.Interest,
+		&i.Penalty,
+		&i.PaidPrincipal,
+		&i.PaidInterest,
+		&i.PaidPenalty,
+		&i.Status,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const recordFailedLogin = `-- name: RecordFailedLogin :one
+INSERT INTO login_failures (
+  username,
+  client_ip,
+  failures,
+  locked_until
+) VALUES (
+  $1,
+  $2,
+  CASE WHEN 1 >= $3::int THEN 0 ELSE 1 END,
+  CASE WHEN 1 >= $3::int THEN $4::timestamptz END
+)
+ON CONFLICT (username, client_ip) DO UPDATE
+SET
+  failures = CASE WHEN login_failures.failures + 1 >= $3::int THEN 0 ELSE login_failures.failures + 1 END,
+  locked_until = CASE WHEN login_failures.failures + 1 >= $3::int THEN $4::timestamptz ELSE login_failures.locked_until END
+RETURNING username, client_ip, failures, locked_until
+`
+
+type RecordFailedLoginParams struct {
+	Username    string    `json:"username"`
+	ClientIp    string    `json:"client_ip"`
+	MaxFailures int32     `json:"max_failures"`
+	LockUntil   time.Time `json:"lock_until"`
+}
+
+func (q *Queries) RecordFailedLogin(ctx context.Context, arg RecordFailedLoginParams) (LoginFailure, error) {
+	row := q.db.QueryRowContext(ctx, recordFailedLogin,
+		arg.Username,
+		arg.ClientIp,
+		arg.MaxFailures,
+		arg.LockUntil,
+	)
+	var i LoginFailure
+	err := row.Scan(
+		&i.Username,
+		&i.ClientIp,
+		&i.Failures,
+		&i.LockedUntil,
+	)
+	return i, err
+}
+
+const redeliverWebhookDelivery = `-- name: RedeliverWebhookDelivery :one
+UPDATE webhook_deliveries
+SET status = 'pending', next_attempt_at = now(), expires_at = now() + interval '24 hours'
+WHERE id = $1
+RETURNING id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at, expires_at, last_attempt_at, response_status, last_error, created_at
+`
+
+func (q *Queries) RedeliverWebhookDelivery(ctx context.Context, id int64) (WebhookDelivery, error) {
+	row := q.db.QueryRowContext(ctx, redeliverWebhookDelivery, id)
+	var i WebhookDelivery
+	err := row.Scan(
+		&i.ID,
+		&i.SubscriptionID,
+		&i.EventID,
+		&i.EventType,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.NextAttemptAt,
+		&i.ExpiresAt,
+		&i.LastAttemptAt,
+		&i.ResponseStatus,
+		&i.LastError,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const rememberRequestNonce = `-- name: RememberRequestNonce :execrows
+WITH pruned AS (
+  DELETE FROM request_nonces
+  WHERE api_key_id = $1 AND expires_at < now()
+)
+INSERT INTO request_nonces (
+  api_key_id,
+  nonce,
+  expires_at
+) VALUES (
+  $1, $2, $3
+)
+ON CONFLICT DO NOTHING
+`
+
+type RememberRequestNonceParams struct {
+	ApiKeyID  int64     `json:"api_key_id"`
+	Nonce     string    `json:"nonce"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (q *Queries) RememberRequestNonce(ctx context.Context, arg RememberRequestNonceParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, rememberRequestNonce, arg.ApiKeyID, arg.Nonce, arg.ExpiresAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const resetFailedLogins = `-- name: ResetFailedLogins :exec
+DELETE FROM login_failures
+WHERE username = $1 AND client_ip = $2
+`
+
+type ResetFailedLoginsParams struct {
+	Username string `json:"username"`
+	ClientIp string `json:"client_ip"`
+}
+
+func (q *Queries) ResetFailedLogins(ctx context.Context, arg ResetFailedLoginsParams) error {
+	_, err := q.db.ExecContext(ctx, resetFailedLogins, arg.Username, arg.ClientIp)
+	return err
+}
+
+const reviewRiskAssessment = `-- name: ReviewRiskAssessment :one
+UPDATE risk_assessments
+SET
+  status = $1,
+  transfer_id = $2,
+  reviewed_by = $3::varchar,
+  reviewed_at = now()
+WHERE id = $4
+RETURNING id, owner, from_account_id, to_account_id, amount, currency, decision, score, hits, status, transfer_id, reviewed_by, reviewed_at, created_at
+`
+
+type ReviewRiskAssessmentParams struct {
+	Status     string        `json:"status"`
+	TransferID sql.NullInt64 `json:"transfer_id"`
+	ReviewedBy string        `json:"reviewed_by"`
+	ID         int64         `json:"id"`
+}
+
+func (q *Queries) ReviewRiskAssessment(ctx context.Context, arg ReviewRiskAssessmentParams) (RiskAssessment, error) {
+	row := q.db.QueryRowContext(ctx, reviewRiskAssessment,
+		arg.Status,
+		arg.TransferID,
+		arg.ReviewedBy,
+		arg.ID,
+	)
+	var i RiskAssessment
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Currency,
+		&i.Decision,
+		&i.Score,
+		&i.Hits,
+		&i.Status,
+		&i.TransferID,
+		&i.ReviewedBy,
+		&i.ReviewedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const revokeApiKey = `-- name: RevokeApiKey :one
+UPDATE api_keys
+SET revoked_at = now()
+WHERE id = $1
+RETURNING id, owner, name, prefix, hashed_secret, scopes, allowed_ips, expires_at, last_used_at, revoked_at, created_at, signing_secret
 `
 
-type ListEntriesParams struct {
-	Limit  int32 `json:"limit"`
-	Offset int32 `json:"offset"`
+func (q *Queries) RevokeApiKey(ctx context.Context, id int64) (ApiKey, error) {
+	row := q.db.QueryRowContext(ctx, revokeApiKey, id)
+	var i ApiKey
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Name,
+		&i.Prefix,
+		&i.HashedSecret,
+		pq.Array(&i.Scopes),
+		pq.Array(&i.AllowedIps),
+		&i.ExpiresAt,
+		&i.LastUsedAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+		&i.SigningSecret,
+	)
+	return i, err
 }
 
-func (q *Queries) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entry, error) {
-	rows, err := q.db.QueryContext(ctx, listEntries, arg.Limit, arg.Offset)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	items := []Entry{}
-	for rows.Next() {
-		var i Entry
-		if err := rows.Scan(
-			&i.ID,
-			&i.AccountID,
-			&i.Amount,
-			&i.CreatedAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+const setRiskAssessmentTransfer = `-- name: SetRiskAssessmentTransfer :exec
+UPDATE risk_assessments
+SET transfer_id = $2
+WHERE id = $1
+`
+
+type SetRiskAssessmentTransferParams struct {
+	ID         int64         `json:"id"`
+	TransferID sql.NullInt64 `json:"transfer_id"`
 }
 
-const listTransfers = `-- name: ListTransfers :many
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
-ORDER BY id
-LIMIT $1
-OFFSET $2
+func (q *Queries) SetRiskAssessmentTransfer(ctx context.Context, arg SetRiskAssessmentTransferParams) error {
+	_, err := q.db.ExecContext(ctx, setRiskAssessmentTransfer, arg.ID, arg.TransferID)
+	return err
+}
+
+const sumUnpostedInterestAccruals = `-- name: SumUnpostedInterestAccruals :one
+SELECT COALESCE(SUM(amount_micros), 0)::bigint AS amount_micros FROM interest_accruals
+WHERE account_id = $1 AND day >= $2 AND day < $3 AND posting_id IS NULL
 `
 
-type ListTransfersParams struct {
-	Limit  int32 `json:"limit"`
-	Offset int32 `json:"offset"`
+type SumUnpostedInterestAccrualsParams struct {
+	AccountID  int64     `json:"account_id"`
+	MonthStart time.Time `json:"month_start"`
+	MonthEnd   time.Time `json:"month_end"`
 }
 
-func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error) {
-	rows, err := q.db.QueryContext(ctx, listTransfers, arg.Limit, arg.Offset)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	items := []Transfer{}
-	for rows.Next() {
-		var i Transfer
-		if err := rows.Scan(
-			&i.ID,
-			&i.FromAccountID,
-			&i.ToAccountID,
-			&i.Amount,
-			&i.CreatedAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+func (q *Queries) SumUnpostedInterestAccruals(ctx context.Context, arg SumUnpostedInterestAccrualsParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, sumUnpostedInterestAccruals, arg.AccountID, arg.MonthStart, arg.MonthEnd)
+	var amount_micros int64
+	err := row.Scan(&amount_micros)
+	return amount_micros, err
+}
+
+const takeRateLimitToken = `-- name: TakeRateLimitToken :one
+INSERT INTO rate_limit_buckets AS b (
+  key,
+  full_at
+) VALUES (
+  $1, $2::timestamptz + $3::bigint * interval '1 microsecond'
+)
+ON CONFLICT (key) DO UPDATE
+SET full_at = GREATEST(b.full_at, $2) + $3::bigint * interval '1 microsecond'
+WHERE GREATEST(b.full_at, $2) + $3::bigint * interval '1 microsecond'
+  <= $2::timestamptz + $4::bigint * interval '1 microsecond'
+RETURNING full_at
+`
+
+type TakeRateLimitTokenParams struct {
+	Key        string    `json:"key"`
+	Now        time.Time `json:"now"`
+	IntervalUs int64     `json:"interval_us"`
+	PeriodUs   int64     `json:"period_us"`
+}
+
+func (q *Queries) TakeRateLimitToken(ctx context.Context, arg TakeRateLimitTokenParams) (time.Time, error) {
+	row := q.db.QueryRowContext(ctx, takeRateLimitToken,
+		arg.Key,
+		arg.Now,
+		arg.IntervalUs,
+		arg.PeriodUs,
+	)
+	var full_at time.Time
+	err := row.Scan(&full_at)
+	return full_at, err
+}
+
+const touchApiKey = `-- name: TouchApiKey :exec
+UPDATE api_keys
+SET last_used_at = now()
+WHERE id = $1
+  AND (last_used_at IS NULL OR last_used_at < now() - interval '1 minute')
+`
+
+func (q *Queries) TouchApiKey(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, touchApiKey, id)
+	return err
 }
 
 const updateAccount = `-- name: UpdateAccount :one
 UPDATE accounts
 SET balance = $2
 WHERE id = $1
-RETURNING id, owner, balance, currency, created_at
+RETURNING id, owner, balance, currency, created_at, status, tier, held_balance, available_balance, product, type, code
 `
 
 type UpdateAccountParams struct {
@@ -360,6 +5166,77 @@ func (q *Queries) UpdateAccount(ctx context.Context, arg UpdateAccountParams) (A
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Status,
+		&i.Tier,
+		&i.HeldBalance,
+		&i.AvailableBalance,
+		&i.Product,
+		&i.Type,
+		&i.Code,
+	)
+	return i, err
+}
+
+const updateAccountStatus = `-- name: UpdateAccountStatus :one
+UPDATE accounts
+SET status = $2
+WHERE id = $1
+RETURNING id, owner, balance, currency, created_at, status, tier, held_balance, available_balance, product, type, code
+`
+
+type UpdateAccountStatusParams struct {
+	ID     int64  `json:"id"`
+	Status string `json:"status"`
+}
+
+func (q *Queries) UpdateAccountStatus(ctx context.Context, arg UpdateAccountStatusParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, updateAccountStatus, arg.ID, arg.Status)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.Status,
+		&i.Tier,
+		&i.HeldBalance,
+		&i.AvailableBalance,
+		&i.Product,
+		&i.Type,
+		&i.Code,
+	)
+	return i, err
+}
+
+const updateAccountTier = `-- name: UpdateAccountTier :one
+UPDATE accounts
+SET tier = $2
+WHERE id = $1
+RETURNING id, owner, balance, currency, created_at, status, tier, held_balance, available_balance, product, type, code
+`
+
+type UpdateAccountTierParams struct {
+	ID   int64  `json:"id"`
+	Tier string `json:"tier"`
+}
+
+func (q *Queries) UpdateAccountTier(ctx context.Context, arg UpdateAccountTierParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, updateAccountTier, arg.ID, arg.Tier)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.Status,
+		&i.Tier,
+		&i.HeldBalance,
+		&i.AvailableBalance,
+		&i.Product,
+		&i.Type,
+		&i.Code,
 	)
 	return i, err
 }
@@ -368,7 +5245,7 @@ const updateEntrie = `-- name: UpdateEntrie :one
 UPDATE entries
 SET amount = $2
 WHERE id = $1
-RETURNING id, account_id, amount, created_at
+RETURNING id, account_id, amount, created_at, journal_id
 `
 
 type UpdateEntrieParams struct {
@@ -384,6 +5261,224 @@ func (q *Queries) UpdateEntrie(ctx context.Context, arg UpdateEntrieParams) (Ent
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.JournalID,
+	)
+	return i, err
+}
+
+const updateHold = `-- name: UpdateHold :one
+UPDATE holds
+SET captured_amount = $1, status = $2, updated_at = now()
+WHERE id = $3
+RETURNING id, account_id, to_account_id, amount, captured_amount, currency, status, description, expires_at, created_at, updated_at, fee
+`
+
+type UpdateHoldParams struct {
+	CapturedAmount int64  `json:"captured_amount"`
+	Status         string `json:"status"`
+	ID             int64  `json:"id"`
+}
+
+func (q *Queries) UpdateHold(ctx context.Context, arg UpdateHoldParams) (Hold, error) {
+	row := q.db.QueryRowContext(ctx, updateHold, arg.CapturedAmount, arg.Status, arg.ID)
+	var i Hold
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CapturedAmount,
+		&i.Currency,
+		&i.Status,
+		&i.Description,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Fee,
+	)
+	return i, err
+}
+
+const updateIdempotencyKeyResponse = `-- name: UpdateIdempotencyKeyResponse :exec
+UPDATE idempotency_keys
+SET response_status = $3, response_body = $4
+WHERE username = $1 AND key = $2
+`
+
+type UpdateIdempotencyKeyResponseParams struct {
+	Username       string        `json:"username"`
+	Key            string        `json:"key"`
+	ResponseStatus sql.NullInt32 `json:"response_status"`
+	ResponseBody   []byte        `json:"response_body"`
+}
+
+func (q *Queries) UpdateIdempotencyKeyResponse(ctx context.Context, arg UpdateIdempotencyKeyResponseParams) error {
+	_, err := q.db.ExecContext(ctx, updateIdempotencyKeyResponse,
+		arg.Username,
+		arg.Key,
+		arg.ResponseStatus,
+		arg.ResponseBody,
+	)
+	return err
+}
+
+const updateLoanStatus = `-- name: UpdateLoanStatus :one
+UPDATE loans
+SET status = $2, updated_at = now()
+WHERE id = $1
+RETURNING id, account_id, borrower_account_id, income_account_id, principal, rate_bps, term_months, method, currency, late_fee, grace_days, status, created_by, created_at, updated_at
+`
+
+type UpdateLoanStatusParams struct {
+	ID     int64  `json:"id"`
+	Status string `json:"status"`
+}
+
+func (q *Queries) UpdateLoanStatus(ctx context.Context, arg UpdateLoanStatusParams) (Loan, error) {
+	row := q.db.QueryRowContext(ctx, updateLoanStatus, arg.ID, arg.Status)
+	var i Loan
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.BorrowerAccountID,
+		&i.IncomeAccountID,
+		&i.Principal,
+		&i.RateBps,
+		&i.TermMonths,
+		&i.Method,
+		&i.Currency,
+		&i.LateFee,
+		&i.GraceDays,
+		&i.Status,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const updatePricingRule = `-- name: UpdatePricingRule :one
+UPDATE pricing_rules
+SET
+  tier = $1,
+  currency = $2,
+  min_amount = $3,
+  max_amount = $4,
+  flat_fee = $5,
+  percent_bps = $6,
+  min_fee = $7,
+  max_fee = $8,
+  fee_account_id = $9,
+  priority = $10,
+  active = $11
+WHERE id = $12
+RETURNING id, tier, currency, min_amount, max_amount, flat_fee, percent_bps, min_fee, max_fee, fee_account_id, priority, active, created_at
+`
+
+type UpdatePricingRuleParams struct {
+	Tier         sql.NullString `json:"tier"`
+	Currency     string         `json:"currency"`
+	MinAmount    int64          `json:"min_amount"`
+	MaxAmount    sql.NullInt64  `json:"max_amount"`
+	FlatFee      int64          `json:"flat_fee"`
+	PercentBps   int32          `json:"percent_bps"`
+	MinFee       int64          `json:"min_fee"`
+	MaxFee       sql.NullInt64  `json:"max_fee"`
+	FeeAccountID int64          `json:"fee_account_id"`
+	Priority     int32          `json:"priority"`
+	Active       bool           `json:"active"`
+	ID           int64          `json:"id"`
+}
+
+func (q *Queries) UpdatePricingRule(ctx context.Context, arg UpdatePricingRuleParams) (PricingRule, error) {
+	row := q.db.QueryRowContext(ctx, updatePricingRule,
+		arg.Tier,
+		arg.Currency,
+		arg.MinAmount,
+		arg.MaxAmount,
+		arg.FlatFee,
+		arg.PercentBps,
+		arg.MinFee,
+		arg.MaxFee,
+		arg.FeeAccountID,
+		arg.Priority,
+		arg.Active,
+		arg.ID,
+	)
+	var i PricingRule
+	err := row.Scan(
+		&i.ID,
+		&i.Tier,
+		&i.Currency,
+		&i.MinAmount,
+		&i.MaxAmount,
+		&i.FlatFee,
+		&i.PercentBps,
+		&i.MinFee,
+		&i.MaxFee,
+		&i.FeeAccountID,
+		&i.Priority,
+		&i.Active,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const updateScheduledTransfer = `-- name: UpdateScheduledTransfer :one
+UPDATE scheduled_transfers
+SET
+  amount = $1,
+  end_at = $2,
+  status = $3,
+  next_occurrence_at = $4,
+  next_run_at = $5,
+  attempts = $6,
+  last_error = $7,
+  updated_at = now()
+WHERE id = $8
+RETURNING id, owner, from_account_id, to_account_id, amount, currency, start_at, recurrence, end_at, status, next_occurrence_at, next_run_at, attempts, last_error, created_at, updated_at
+`
+
+type UpdateScheduledTransferParams struct {
+	Amount           int64          `json:"amount"`
+	EndAt            sql.NullTime   `json:"end_at"`
+	Status           string         `json:"status"`
+	NextOccurrenceAt sql.NullTime   `json:"next_occurrence_at"`
+	NextRunAt        sql.NullTime   `json:"next_run_at"`
+	Attempts         int32          `json:"attempts"`
+	LastError        sql.NullString `json:"last_error"`
+	ID               int64          `json:"id"`
+}
+
+func (q *Queries) UpdateScheduledTransfer(ctx context.Context, arg UpdateScheduledTransferParams) (ScheduledTransfer, error) {
+	row := q.db.QueryRowContext(ctx, updateScheduledTransfer,
+		arg.Amount,
+		arg.EndAt,
+		arg.Status,
+		arg.NextOccurrenceAt,
+		arg.NextRunAt,
+		arg.Attempts,
+		arg.LastError,
+		arg.ID,
+	)
+	var i ScheduledTransfer
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Currency,
+		&i.StartAt,
+		&i.Recurrence,
+		&i.EndAt,
+		&i.Status,
+		&i.NextOccurrenceAt,
+		&i.NextRunAt,
+		&i.Attempts,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.UpdatedAt,
 	)
 	return i, err
 }
@@ -392,7 +5487,7 @@ const updateTransfer = `-- name: UpdateTransfer :one
 UPDATE transfers
 SET amount = $2
 WHERE id = $1
-RETURNING id, from_account_id, to_account_id, amount, created_at
+RETURNING id, from_account_id, to_account_id, amount, created_at, journal_id
 `
 
 type UpdateTransferParams struct {
@@ -409,6 +5504,207 @@ func (q *Queries) UpdateTransfer(ctx context.Context, arg UpdateTransferParams)
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.JournalID,
+	)
+	return i, err
+}
+
+const updateTransferBatch = `-- name: UpdateTransferBatch :one
+UPDATE transfer_batches
+SET
+  status = $1,
+  succeeded_rows = $2,
+  failed_rows = $3,
+  lease_until = $4,
+  completed_at = $5,
+  updated_at = now()
+WHERE id = $6
+RETURNING id, owner, mode, status, total_rows, succeeded_rows, failed_rows, lease_until, created_at, updated_at, completed_at
+`
+
+type UpdateTransferBatchParams struct {
+	Status        string       `json:"status"`
+	SucceededRows int32        `json:"succeeded_rows"`
+	FailedRows    int32        `json:"failed_rows"`
+	LeaseUntil    sql.NullTime `json:"lease_until"`
+	CompletedAt   sql.NullTime `json:"completed_at"`
+	ID            int64        `json:"id"`
+}
+
+func (q *Queries) UpdateTransferBatch(ctx context.Context, arg UpdateTransferBatchParams) (TransferBatch, error) {
+	row := q.db.QueryRowContext(ctx, updateTransferBatch,
+		arg.Status,
+		arg.SucceededRows,
+		arg.FailedRows,
+		arg.LeaseUntil,
+		arg.CompletedAt,
+		arg.ID,
+	)
+	var i TransferBatch
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Mode,
+		&i.Status,
+		&i.TotalRows,
+		&i.SucceededRows,
+		&i.FailedRows,
+		&i.LeaseUntil,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CompletedAt,
+	)
+	return i, err
+}
+
+const updateTransferBatchItem = `-- name: UpdateTransferBatchItem :exec
+UPDATE transfer_batch_items
+SET
+  status = $1,
+  transfer_id = $2,
+  error = $3
+WHERE id = $4
+`
+
+type UpdateTransferBatchItemParams struct {
+	Status     string         `json:"status"`
+	TransferID sql.NullInt64  `json:"transfer_id"`
+	Error      sql.NullString `json:"error"`
+	ID         int64          `json:"id"`
+}
+
+func (q *Queries) UpdateTransferBatchItem(ctx context.Context, arg UpdateTransferBatchItemParams) error {
+	_, err := q.db.ExecContext(ctx, updateTransferBatchItem,
+		arg.Status,
+		arg.TransferID,
+		arg.Error,
+		arg.ID,
+	)
+	return err
+}
+
+const updateUserRole = `-- name: UpdateUserRole :one
+UPDATE users
+SET role = $2
+WHERE username = $1
+RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, role
+`
+
+type UpdateUserRoleParams struct {
+	Username string `json:"username"`
+	Role     string `json:"role"`
+}
+
+func (q *Queries) UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserRole, arg.Username, arg.Role)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.Role,
+	)
+	return i, err
+}
+
+const updateWebhookDeliveryAttempt = `-- name: UpdateWebhookDeliveryAttempt :one
+UPDATE webhook_deliveries
+SET
+  status = $1,
+  attempts = attempts + 1,
+  next_attempt_at = $2,
+  last_attempt_at = now(),
+  response_status = $3,
+  last_error = $4
+WHERE id = $5
+RETURNING id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at, expires_at, last_attempt_at, response_status, last_error, created_at
+`
+
+type UpdateWebhookDeliveryAttemptParams struct {
+	Status         string         `json:"status"`
+	NextAttemptAt  time.Time      `json:"next_attempt_at"`
+	ResponseStatus sql.NullInt32  `json:"response_status"`
+	LastError      sql.NullString `json:"last_error"`
+	ID             int64          `json:"id"`
+}
+
+func (q *Queries) UpdateWebhookDeliveryAttempt(ctx context.Context, arg UpdateWebhookDeliveryAttemptParams) (WebhookDelivery, error) {
+	row := q.db.QueryRowContext(ctx, updateWebhookDeliveryAttempt,
+		arg.Status,
+		arg.NextAttemptAt,
+		arg.ResponseStatus,
+		arg.LastError,
+		arg.ID,
+	)
+	var i WebhookDelivery
+	err := row.Scan(
+		&i.ID,
+		&i.SubscriptionID,
+		&i.EventID,
+		&i.EventType,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.NextAttemptAt,
+		&i.ExpiresAt,
+		&i.LastAttemptAt,
+		&i.ResponseStatus,
+		&i.LastError,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const upsertScheduledTransferRun = `-- name: UpsertScheduledTransferRun :one
+INSERT INTO scheduled_transfer_runs (
+  scheduled_transfer_id,
+  occurrence_at,
+  status,
+  transfer_id,
+  last_error
+) VALUES (
+  $1, $2, $3, $4, $5
+)
+ON CONFLICT (scheduled_transfer_id, occurrence_at) DO UPDATE
+SET
+  status = EXCLUDED.status,
+  attempts = scheduled_transfer_runs.attempts + 1,
+  transfer_id = EXCLUDED.transfer_id,
+  last_error = EXCLUDED.last_error,
+  updated_at = now()
+RETURNING id, scheduled_transfer_id, occurrence_at, status, attempts, transfer_id, last_error, created_at, updated_at
+`
+
+type UpsertScheduledTransferRunParams struct {
+	ScheduledTransferID int64          `json:"scheduled_transfer_id"`
+	OccurrenceAt        time.Time      `json:"occurrence_at"`
+	Status              string         `json:"status"`
+	TransferID          sql.NullInt64  `json:"transfer_id"`
+	LastError           sql.NullString `json:"last_error"`
+}
+
+func (q *Queries) UpsertScheduledTransferRun(ctx context.Context, arg UpsertScheduledTransferRunParams) (ScheduledTransferRun, error) {
+	row := q.db.QueryRowContext(ctx, upsertScheduledTransferRun,
+		arg.ScheduledTransferID,
+		arg.OccurrenceAt,
+		arg.Status,
+		arg.TransferID,
+		arg.LastError,
+	)
+	var i ScheduledTransferRun
+	err := row.Scan(
+		&i.ID,
+		&i.ScheduledTransferID,
+		&i.OccurrenceAt,
+		&i.Status,
+		&i.Attempts,
+		&i.TransferID,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.UpdatedAt,
 	)
 	return i, err
 }
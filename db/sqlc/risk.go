@@ -0,0 +1,235 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"simplebank/risk"
+	"strconv"
+	"time"
+)
+
+// Risk assessment statuses
+const (
+	RiskAssessmentAllowed  = "allowed"
+	RiskAssessmentBlocked  = "blocked"
+	RiskAssessmentPending  = "pending"
+	RiskAssessmentApproved = "approved"
+	RiskAssessmentRejected = "rejected"
+)
+
+var (
+	ErrTransferBlocked  = errors.New("transfer blocked by risk screening")
+	ErrReviewNotPending = errors.New("transfer is not pending review")
+)
+
+// riskHistory answers the questions of the risk rules from the db
+type riskHistory struct {
+	q *Queries
+}
+
+func (history riskHistory) TransfersBetween(ctx context.Context, fromAccountID int64, toAccountID int64, since time.Time) (int64, error) {
+	return history.q.CountTransfersBetween(ctx, CountTransfersBetweenParams{
+		FromAccountID: fromAccountID,
+		ToAccountID:   toAccountID,
+		Since:         since,
+	})
+}
+
+func (history riskHistory) TransfersFrom(ctx context.Context, accountID int64, since time.Time) (int64, error) {
+	return history.q.CountTransfersFrom(ctx, CountTransfersFromParams{
+		FromAccountID: accountID,
+		Since:         since,
+	})
+}
+
+func (history riskHistory) PasswordChangedAt(ctx context.Context, username string) (time.Time, error) {
+	user, err := history.q.GetUser(ctx, username)
+	return user.PasswordChangedAt, err
+}
+
+// ScreenTransferTxParams is a transfer requested by Owner, screened before it is posted
+type ScreenTransferTxParams struct {
+	TransferCreateParams
+	Currency string
+	Owner    string
+}
+
+// ScreenTransferTxResult is the recorded assessment, and the transfer when it was allowed
+type ScreenTransferTxResult struct {
+	Assessment RiskAssessment
+	Transfer   *TransferTxResult
+}
+
+// ScreenTransferTx assesses a transfer with engine and records the decision, then makes the transfer when it is allowed
+// a transfer held for review waits for ReviewTransferTx, a blocked one fails with ErrTransferBlocked
+func (store *Store) ScreenTransferTx(ctx context.Context, engine *risk.Engine, arg ScreenTransferTxParams) (ScreenTransferTxResult, error) {
+	var result ScreenTransferTxResult
+
+	assessment, err := store.assessTransfer(ctx, engine, arg, true)
+	if err != nil {
+		return result, err
+	}
+
+	if assessment.Status != RiskAssessmentAllowed {
+		result.Assessment, err = store.CreateRiskAssessment(ctx, assessment)
+		if err != nil {
+			return result, err
+		}
+
+		if result.Assessment.Status == RiskAssessmentBlocked {
+			return result, fmt.Errorf("%w: risk assessment %d", ErrTransferBlocked, result.Assessment.ID)
+		}
+		return result, nil
+	}
+
+	// an allowed assessment is recorded in the tx of its transfer, so a transfer that fails leaves none behind
+	// for the review queue and the velocity rules to count
+	err = store.execTx(ctx, func(q *Queries) error {
+		transfer, err := transferTx(ctx, q, arg.TransferCreateParams)
+		if err != nil {
+			return err
+		}
+		result.Transfer = &transfer
+
+		result.Assessment, err = q.CreateRiskAssessment(ctx, assessment)
+		if err != nil {
+			return err
+		}
+
+		result.Assessment.TransferID = sql.NullInt64{Int64: transfer.Transfer.ID, Valid: true}
+		return q.SetRiskAssessmentTransfer(ctx, SetRiskAssessmentTransferParams{
+			ID:         result.Assessment.ID,
+			TransferID: result.Assessment.TransferID,
+		})
+	})
+	if err != nil {
+		return ScreenTransferTxResult{}, err
+	}
+
+	return result, nil
+}
+
+// ScreenDeferredTransfer screens a transfer the owner sets up now to be posted later without them: a scheduled transfer,
+// a row of a batch or a hold to capture; those are posted as screened, so nobody is there to wait on a review
+// and a transfer the engine would hold for review fails with ErrTransferBlocked like a blocked one
+func (store *Store) ScreenDeferredTransfer(ctx context.Context, engine *risk.Engine, arg ScreenTransferTxParams) (RiskAssessment, error) {
+	params, err := store.assessTransfer(ctx, engine, arg, false)
+	if err != nil {
+		return RiskAssessment{}, err
+	}
+
+	assessment, err := store.CreateRiskAssessment(ctx, params)
+	if err != nil {
+		return assessment, err
+	}
+
+	if assessment.Status == RiskAssessmentBlocked {
+		return assessment, fmt.Errorf("%w: risk assessment %d", ErrTransferBlocked, assessment.ID)
+	}
+
+	return assessment, nil
+}
+
+// assessTransfer runs engine on the transfer and returns the assessment to record, a transfer to be held
+// for review is pending when reviewable and blocked otherwise
+func (store *Store) assessTransfer(ctx context.Context, engine *risk.Engine, arg ScreenTransferTxParams, reviewable bool) (CreateRiskAssessmentParams, error) {
+	assessment, err := engine.Assess(ctx, risk.Transfer{
+		FromAccountID: arg.FromAccountID,
+		ToAccountID:   arg.ToAccountID,
+		Amount:        arg.Amount,
+		Currency:      arg.Currency,
+		Owner:         arg.Owner,
+		At:            time.Now(),
+	}, riskHistory{q: store.Queries})
+	if err != nil {
+		return CreateRiskAssessmentParams{}, err
+	}
+
+	hits, err := json.Marshal(assessment.Hits)
+	if err != nil {
+		return CreateRiskAssessmentParams{}, err
+	}
+
+	status := RiskAssessmentAllowed
+	switch {
+	case assessment.Decision == risk.Review && reviewable:
+		status = RiskAssessmentPending
+	case assessment.Decision == risk.Review, assessment.Decision == risk.Block:
+		status = RiskAssessmentBlocked
+	}
+
+	return CreateRiskAssessmentParams{
+		Owner:         arg.Owner,
+		FromAccountID: arg.FromAccountID,
+		ToAccountID:   arg.ToAccountID,
+		Amount:        arg.Amount,
+		Currency:      arg.Currency,
+		Decision:      string(assessment.Decision),
+		Score:         int32(assessment.Score),
+		Hits:          hits,
+		Status:        status,
+	}, nil
+}
+
+// ReviewTransferTxParams approves or rejects a transfer held for review
+type ReviewTransferTxParams struct {
+	ID         int64
+	ReviewedBy string
+	Approve    bool
+}
+
+// ReviewTransferTx settles a transfer pending review, an approved one is made right away
+func (store *Store) ReviewTransferTx(ctx context.Context, arg ReviewTransferTxParams) (ScreenTransferTxResult, error) {
+	var result ScreenTransferTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		assessment, err := q.GetRiskAssessmentForUpdate(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+
+		if assessment.Status != RiskAssessmentPending {
+			return fmt.Errorf("%w: risk assessment %d is %s", ErrReviewNotPending, assessment.ID, assessment.Status)
+		}
+
+		review := ReviewRiskAssessmentParams{
+			ID:         assessment.ID,
+			Status:     RiskAssessmentRejected,
+			ReviewedBy: arg.ReviewedBy,
+		}
+
+		if arg.Approve {
+			transfer, err := transferTx(ctx, q, TransferCreateParams{
+				FromAccountID: assessment.FromAccountID,
+				ToAccountID:   assessment.ToAccountID,
+				Amount:        assessment.Amount,
+			})
+			if err != nil {
+				return err
+			}
+			result.Transfer = &transfer
+
+			review.Status = RiskAssessmentApproved
+			review.TransferID = sql.NullInt64{Int64: transfer.Transfer.ID, Valid: true}
+		}
+
+		result.Assessment, err = q.ReviewRiskAssessment(ctx, review)
+		if err != nil {
+			return err
+		}
+
+		_, err = recordAudit(ctx, q, AuditEntry{
+			Action:     AuditTransferReview,
+			TargetType: AuditTargetRiskAssessment,
+			TargetID:   strconv.FormatInt(assessment.ID, 10),
+			Before:     assessment,
+			After:      result.Assessment,
+		})
+		return err
+	})
+
+	return result, err
+}
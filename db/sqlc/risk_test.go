@@ -0,0 +1,235 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"math"
+	"simplebank/risk"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func screenTransfer(t *testing.T, engine *risk.Engine, from Account, to Account, amount int64) (ScreenTransferTxResult, error) {
+	store := NewStore(testDb)
+
+	return store.ScreenTransferTx(context.Background(), engine, ScreenTransferTxParams{
+		TransferCreateParams: TransferCreateParams{
+			FromAccountID: from.ID,
+			ToAccountID:   to.ID,
+			Amount:        amount,
+		},
+		Currency: from.Currency,
+		Owner:    from.Owner,
+	})
+}
+
+func TestScreenTransferTxAllows(t *testing.T) {
+	engine := risk.NewEngine(risk.DefaultRules()...)
+
+	from := createRandomAccountWithCurrency(t, "USD")
+	to := createRandomAccountWithCurrency(t, "USD")
+
+	result, err := screenTransfer(t, engine, from, to, 10)
+	require.NoError(t, err)
+	require.NotNil(t, result.Transfer)
+	require.Equal(t, RiskAssessmentAllowed, result.Assessment.Status)
+	require.Equal(t, string(risk.Allow), result.Assessment.Decision)
+
+	stored, err := testQueries.GetRiskAssessment(context.Background(), result.Assessment.ID)
+	require.NoError(t, err)
+	require.Equal(t, result.Transfer.Transfer.ID, stored.TransferID.Int64)
+	require.JSONEq(t, `[]`, string(stored.Hits))
+}
+
+// TestScreenTransferTxFailedTransfer checks that a transfer failing after it was allowed leaves no allowed assessment behind
+func TestScreenTransferTxFailedTransfer(t *testing.T) {
+	from := createRandomAccountWithCurrency(t, "USD")
+	to := createRandomAccountWithCurrency(t, "USD")
+
+	result, err := screenTransfer(t, risk.NewEngine(), from, to, from.Balance+1)
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+	require.Nil(t, result.Transfer)
+	require.Zero(t, result.Assessment.ID)
+
+	assessments, err := testQueries.ListRiskAssessments(context.Background(), ListRiskAssessmentsParams{
+		Status:     sql.NullString{String: RiskAssessmentAllowed, Valid: true},
+		LimitCount: math.MaxInt32,
+	})
+	require.NoError(t, err)
+	for _, assessment := range assessments {
+		require.NotEqual(t, from.ID, assessment.FromAccountID)
+	}
+}
+
+func TestScreenTransferTxHoldsForReview(t *testing.T) {
+	store := NewStore(testDb)
+	engine := risk.NewEngine(risk.DefaultRules()...)
+
+	from := createRandomAccountWithCurrency(t, "USD")
+	to := createRandomAccountWithCurrency(t, "USD")
+	admin := createRandomUser(t)
+
+	result, err := screenTransfer(t, engine, from, to, 100000)
+	require.NoError(t, err)
+	require.Nil(t, result.Transfer)
+	require.Equal(t, RiskAssessmentPending, result.Assessment.Status)
+	require.False(t, result.Assessment.TransferID.Valid)
+
+	var hits []risk.Hit
+	require.NoError(t, json.Unmarshal(result.Assessment.Hits, &hits))
+	require.Len(t, hits, 1)
+	require.Equal(t, "new_payee_large_amount", hits[0].Rule)
+
+	unchanged, err := testQueries.GetAccount(context.Background(), from.ID)
+	require.NoError(t, err)
+	require.Equal(t, from.Balance, unchanged.Balance)
+
+	// the approved transfer needs the funds, paid in from capital so that the books still balance
+	capital, err := store.InternalAccount(context.Background(), GLCapital, "USD")
+	require.NoError(t, err)
+
+	funding, err := store.PostJournalTx(context.Background(), PostJournalTxParams{
+		Kind: "test",
+		Legs: []Leg{
+			{AccountID: capital.ID, Amount: -100000},
+			{AccountID: from.ID, Amount: 100000},
+		},
+	})
+	require.NoError(t, err)
+	from = funding.Account(from.ID)
+
+	reviewed, err := store.ReviewTransferTx(context.Background(), ReviewTransferTxParams{
+		ID:         result.Assessment.ID,
+		ReviewedBy: admin.Username,
+		Approve:    true,
+	})
+	require.NoError(t, err)
+	require.NotNil(t, reviewed.Transfer)
+	require.Equal(t, RiskAssessmentApproved, reviewed.Assessment.Status)
+	require.Equal(t, reviewed.Transfer.Transfer.ID, reviewed.Assessment.TransferID.Int64)
+	require.Equal(t, admin.Username, reviewed.Assessment.ReviewedBy.String)
+	require.True(t, reviewed.Assessment.ReviewedAt.Valid)
+	require.Equal(t, from.Balance-100000, reviewed.Transfer.FromAccount.Balance)
+
+	_, err = store.ReviewTransferTx(context.Background(), ReviewTransferTxParams{
+		ID:         result.Assessment.ID,
+		ReviewedBy: admin.Username,
+	})
+	require.ErrorIs(t, err, ErrReviewNotPending)
+}
+
+func TestReviewTransferTxReject(t *testing.T) {
+	store := NewStore(testDb)
+	engine := risk.NewEngine(risk.DefaultRules()...)
+
+	from := createRandomAccountWithCurrency(t, "USD")
+	to := createRandomAccountWithCurrency(t, "USD")
+	admin := createRandomUser(t)
+
+	result, err := screenTransfer(t, engine, from, to, 100000)
+	require.NoError(t, err)
+	require.Equal(t, RiskAssessmentPending, result.Assessment.Status)
+
+	reviewed, err := store.ReviewTransferTx(context.Background(), ReviewTransferTxParams{
+		ID:         result.Assessment.ID,
+		ReviewedBy: admin.Username,
+	})
+	require.NoError(t, err)
+	require.Nil(t, reviewed.Transfer)
+	require.Equal(t, RiskAssessmentRejected, reviewed.Assessment.Status)
+	require.False(t, reviewed.Assessment.TransferID.Valid)
+
+	unchanged, err := testQueries.GetAccount(context.Background(), from.ID)
+	require.NoError(t, err)
+	require.Equal(t, from.Balance, unchanged.Balance)
+}
+
+type blockEveryTransfer struct{}
+
+func (blockEveryTransfer) Name() string { return "block_every_transfer" }
+
+func (blockEveryTransfer) Evaluate(context.Context, risk.Transfer, risk.History) (*risk.Hit, error) {
+	return &risk.Hit{Rule: "block_every_transfer", Score: 100, Decision: risk.Block, Reason: "test"}, nil
+}
+
+func TestScreenTransferTxBlocks(t *testing.T) {
+	from := createRandomAccountWithCurrency(t, "USD")
+	to := createRandomAccountWithCurrency(t, "USD")
+
+	result, err := screenTransfer(t, risk.NewEngine(blockEveryTransfer{}), from, to, 10)
+	require.ErrorIs(t, err, ErrTransferBlocked)
+	require.Nil(t, result.Transfer)
+	require.Equal(t, RiskAssessmentBlocked, result.Assessment.Status)
+
+	stored, err := testQueries.GetRiskAssessment(context.Background(), result.Assessment.ID)
+	require.NoError(t, err)
+	require.Equal(t, RiskAssessmentBlocked, stored.Status)
+	require.Equal(t, int32(100), stored.Score)
+}
+
+func TestScreenDeferredTransfer(t *testing.T) {
+	store := NewStore(testDb)
+
+	testCases := []struct {
+		name     string
+		engine   *risk.Engine
+		amount   int64
+		decision risk.Decision
+		status   string
+	}{
+		{
+			name:     "Allowed",
+			engine:   risk.NewEngine(risk.DefaultRules()...),
+			amount:   10,
+			decision: risk.Allow,
+			status:   RiskAssessmentAllowed,
+		},
+		{
+			name:     "ReviewIsBlocked",
+			engine:   risk.NewEngine(risk.DefaultRules()...),
+			amount:   100000,
+			decision: risk.Review,
+			status:   RiskAssessmentBlocked,
+		},
+		{
+			name:     "Blocked",
+			engine:   risk.NewEngine(blockEveryTransfer{}),
+			amount:   10,
+			decision: risk.Block,
+			status:   RiskAssessmentBlocked,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			from := createRandomAccountWithCurrency(t, "USD")
+			to := createRandomAccountWithCurrency(t, "USD")
+
+			assessment, err := store.ScreenDeferredTransfer(context.Background(), tc.engine, ScreenTransferTxParams{
+				TransferCreateParams: TransferCreateParams{
+					FromAccountID: from.ID,
+					ToAccountID:   to.ID,
+					Amount:        tc.amount,
+				},
+				Currency: from.Currency,
+				Owner:    from.Owner,
+			})
+			if tc.status == RiskAssessmentBlocked {
+				require.ErrorIs(t, err, ErrTransferBlocked)
+			} else {
+				require.NoError(t, err)
+			}
+
+			require.Equal(t, string(tc.decision), assessment.Decision)
+			require.Equal(t, tc.status, assessment.Status)
+			require.False(t, assessment.TransferID.Valid)
+
+			// nothing is posted until the worker runs the transfer
+			unchanged, err := testQueries.GetAccount(context.Background(), from.ID)
+			require.NoError(t, err)
+			require.Equal(t, from.Balance, unchanged.Balance)
+		})
+	}
+}
@@ -0,0 +1,235 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"simplebank/event"
+	"time"
+)
+
+// Scheduled transfer statuses
+const (
+	ScheduledTransferStatusActive    = "active"
+	ScheduledTransferStatusPaused    = "paused"
+	ScheduledTransferStatusCompleted = "completed"
+	ScheduledTransferStatusFailed    = "failed"
+	ScheduledTransferStatusCancelled = "cancelled"
+)
+
+// Scheduled transfer run statuses
+const (
+	ScheduledTransferRunSucceeded = "succeeded"
+	ScheduledTransferRunFailed    = "failed"
+)
+
+// RunScheduledTransferTxParams tells RunScheduledTransferTx when it runs and how to retry and reschedule
+type RunScheduledTransferTxParams struct {
+	Now time.Time
+	// MaxAttempts is how many times an occurrence is tried before it is given up
+	MaxAttempts int32
+	// RetryDelay returns the wait before the next attempt after the given number of failed ones
+	RetryDelay func(attempts int32) time.Duration
+	// NextOccurrence returns the occurrence following after, false once the schedule is over
+	NextOccurrence func(st ScheduledTransfer, after time.Time) (time.Time, bool, error)
+}
+
+// RunScheduledTransferTxResult is the outcome of one occurrence
+type RunScheduledTransferTxResult struct {
+	ScheduledTransfer ScheduledTransfer
+	Run               ScheduledTransferRun
+	// Transfer is set when the occurrence was executed
+	Transfer *TransferTxResult
+}
+
+// RunScheduledTransferTx claims the most overdue active scheduled transfer, skipping the ones other workers hold,
+// and attempts its next occurrence; it returns false when nothing is due
+// the transfer, its run and the rescheduling commit together and an occurrence with a succeeded run is never
+// transferred again, so an occurrence is executed at most once however many workers run
+// a failed attempt is retried after RetryDelay, after MaxAttempts the occurrence is skipped and the owner notified
+// the occurrences are not screened again, the schedule was screened with ScreenDeferredTransfer when it was set up
+func (store *Store) RunScheduledTransferTx(ctx context.Context, arg RunScheduledTransferTxParams) (RunScheduledTransferTxResult, bool, error) {
+	var result RunScheduledTransferTxResult
+	var found bool
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		result = RunScheduledTransferTxResult{}
+
+		st, err := q.ClaimDueScheduledTransfer(ctx, arg.Now)
+		if errors.Is(err, sql.ErrNoRows) {
+			found = false
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		found = true
+
+		occurrence := st.NextOccurrenceAt.Time
+
+		run, err := q.GetScheduledTransferRun(ctx, GetScheduledTransferRunParams{
+			ScheduledTransferID: st.ID,
+			OccurrenceAt:        occurrence,
+		})
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		if err == nil && run.Status == ScheduledTransferRunSucceeded {
+			result.Run = run
+			result.ScheduledTransfer, err = advanceScheduledTransfer(ctx, q, st, arg, ScheduledTransferStatusCompleted)
+			return err
+		}
+
+		failure, err := checkScheduledTransfer(ctx, q, st)
+		if err != nil {
+			return err
+		}
+
+		if failure != nil {
+			return failScheduledTransfer(ctx, q, st, arg, failure, &result)
+		}
+
+		transfer, err := transferTx(ctx, q, TransferCreateParams{
+			FromAccountID: st.FromAccountID,
+			ToAccountID:   st.ToAccountID,
+			Amount:        st.Amount,
+		})
+		if err != nil {
+			return err
+		}
+		result.Transfer = &transfer
+		st.LastError = sql.NullString{}
+
+		result.Run, err = q.UpsertScheduledTransferRun(ctx, UpsertScheduledTransferRunParams{
+			ScheduledTransferID: st.ID,
+			OccurrenceAt:        occurrence,
+			Status:              ScheduledTransferRunSucceeded,
+			TransferID:          sql.NullInt64{Int64: transfer.Transfer.ID, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+
+		result.ScheduledTransfer, err = advanceScheduledTransfer(ctx, q, st, arg, ScheduledTransferStatusCompleted)
+		return err
+	})
+
+	return result, found, err
+}
+
+// checkScheduledTransfer locks both accounts and the fee account in id order, like a transfer would, and reports
+// why the transfer cannot be made, if it cannot
+func checkScheduledTransfer(ctx context.Context, q *Queries, st ScheduledTransfer) (failure error, err error) {
+	accounts, fee, err := lockTransferAccounts(ctx, q, st.FromAccountID, st.ToAccountID, st.Amount)
+	if err != nil {
+		return nil, err
+	}
+
+	if failure := checkTransfer(accounts[st.FromAccountID], accounts[st.ToAccountID], st.Currency, st.Amount+fee.Amount); failure != nil {
+		return failure, nil
+	}
+
+	return checkTransferLimits(ctx, q, accounts[st.FromAccountID], st.Amount, pendingTransfers{})
+}
+
+// failScheduledTransfer records a failed attempt and schedules a retry, or gives the occurrence up after the last attempt
+func failScheduledTransfer(ctx context.Context, q *Queries, st ScheduledTransfer, arg RunScheduledTransferTxParams, failure error, result *RunScheduledTransferTxResult) error {
+	var err error
+	lastError := sql.NullString{String: failure.Error(), Valid: true}
+
+	result.Run, err = q.UpsertScheduledTransferRun(ctx, UpsertScheduledTransferRunParams{
+		ScheduledTransferID: st.ID,
+		OccurrenceAt:        st.NextOccurrenceAt.Time,
+		Status:              ScheduledTransferRunFailed,
+		LastError:           lastError,
+	})
+	if err != nil {
+		return err
+	}
+
+	attempts := st.Attempts + 1
+	st.LastError = lastError
+
+	if attempts < arg.MaxAttempts {
+		result.ScheduledTransfer, err = q.UpdateScheduledTransfer(ctx, UpdateScheduledTransferParams{
+			ID:               st.ID,
+			Amount:           st.Amount,
+			EndAt:            st.EndAt,
+			Status:           st.Status,
+			NextOccurrenceAt: st.NextOccurrenceAt,
+			NextRunAt:        sql.NullTime{Time: arg.Now.Add(arg.RetryDelay(attempts)), Valid: true},
+			Attempts:         attempts,
+			LastError:        lastError,
+		})
+		return err
+	}
+
+	err = insertEvent(ctx, q, event.ScheduledTransferFailedV1{
+		ScheduledTransferID: st.ID,
+		FromAccountID:       st.FromAccountID,
+		ToAccountID:         st.ToAccountID,
+		Amount:              st.Amount,
+		Currency:            st.Currency,
+		OccurrenceAt:        st.NextOccurrenceAt.Time,
+		Attempts:            attempts,
+		Error:               failure.Error(),
+	})
+	if err != nil {
+		return err
+	}
+
+	result.ScheduledTransfer, err = advanceScheduledTransfer(ctx, q, st, arg, ScheduledTransferStatusFailed)
+	return err
+}
+
+// advanceScheduledTransfer moves on to the occurrence after the current one, catching up missed occurrences one by one,
+// and ends the schedule with finalStatus when there is none left
+func advanceScheduledTransfer(ctx context.Context, q *Queries, st ScheduledTransfer, arg RunScheduledTransferTxParams, finalStatus string) (ScheduledTransfer, error) {
+	next, ok, err := arg.NextOccurrence(st, st.NextOccurrenceAt.Time)
+	if err != nil {
+		return st, err
+	}
+
+	update := UpdateScheduledTransferParams{
+		ID:        st.ID,
+		Amount:    st.Amount,
+		EndAt:     st.EndAt,
+		Status:    st.Status,
+		Attempts:  0,
+		LastError: st.LastError,
+	}
+
+	if ok {
+		update.NextOccurrenceAt = sql.NullTime{Time: next, Valid: true}
+		update.NextRunAt = update.NextOccurrenceAt
+	} else {
+		update.Status = finalStatus
+	}
+
+	return q.UpdateScheduledTransfer(ctx, update)
+}
+
+// UpdateScheduledTransferTx applies the changes update computes from the locked current row,
+// so they cannot interleave with a worker running the schedule
+func (store *Store) UpdateScheduledTransferTx(ctx context.Context, id int64, update func(current ScheduledTransfer) (UpdateScheduledTransferParams, error)) (ScheduledTransfer, error) {
+	var st ScheduledTransfer
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		current, err := q.GetScheduledTransferForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		arg, err := update(current)
+		if err != nil {
+			return err
+		}
+		arg.ID = id
+
+		st, err = q.UpdateScheduledTransfer(ctx, arg)
+		return err
+	})
+
+	return st, err
+}
@@ -3,7 +3,19 @@ package db
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"log/slog"
+	"simplebank/event"
+	"simplebank/logging"
+	"simplebank/pricing"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/lib/pq"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Store provide all faunctions to execute DB queries and transactions
@@ -16,32 +28,117 @@ type Store struct {
 func NewStore(db *sql.DB) *Store {
 	return &Store{
 		db:      db,
-		Queries: New(db),
+		Queries: New(newTracedDB(db)),
 	}
 }
 
+// maxTxAttempts bounds how many times a tx is retried after a deadlock or serialization failure
+const maxTxAttempts = 3
+
+// Tx outcomes recorded on the execTx span
+const (
+	txOutcomeCommit      = "commit"
+	txOutcomeRollback    = "rollback"
+	txOutcomeBeginError  = "begin_error"
+	txOutcomeCommitError = "commit_error"
+)
+
 // execTx executes a function within a database transaction
+// the tx is retried when postgres aborts it with a deadlock or serialization failure
 func (store *Store) execTx(ctx context.Context, fn func(*Queries) error) error {
-	tx, err := store.db.BeginTx(ctx, nil)
+	opts := &sql.TxOptions{Isolation: sql.LevelReadCommitted}
+
+	ctx, span := tracer.Start(ctx, "Store.execTx", trace.WithAttributes(
+		attribute.String("db.tx.isolation", opts.Isolation.String()),
+	))
+	defer span.End()
+
+	var (
+		err     error
+		outcome string
+		retries int
+	)
+
+	for attempt := 1; ; attempt++ {
+		outcome, err = store.runTx(ctx, opts, fn)
+
+		if err == nil || attempt == maxTxAttempts || !isRetryableTxError(err) || ctx.Err() != nil {
+			break
+		}
+
+		retries++
+		logging.FromContext(ctx).WarnContext(ctx, "tx retry",
+			slog.Int("attempt", attempt),
+			slog.Any("error", err),
+		)
+	}
+
+	span.SetAttributes(
+		attribute.Int("db.tx.retries", retries),
+		attribute.String("db.tx.outcome", outcome),
+	)
+	recordError(span, err)
+
+	return err
+}
+
+// runTx runs fn once inside a tx and reports how the tx ended
+// the logger carried by ctx records begin, commit and rollback along with the tx duration
+func (store *Store) runTx(ctx context.Context, opts *sql.TxOptions, fn func(*Queries) error) (string, error) {
+	logger := logging.FromContext(ctx)
+	start := time.Now()
+
+	tx, err := store.db.BeginTx(ctx, opts)
 
 	if err != nil {
-		return err
+		logger.ErrorContext(ctx, "tx begin failed", slog.Any("error", err))
+		return txOutcomeBeginError, err
 	}
 
-	q := New(tx)
+	logger.InfoContext(ctx, "tx begin")
+
+	q := New(newTracedDB(tx))
 
 	err = fn(q)
 
 	if err != nil {
 
 		if rbErr := tx.Rollback(); rbErr != nil {
-			return fmt.Errorf("tx err: %v, rb err: %v", err, rbErr)
+			logger.ErrorContext(ctx, "tx rollback failed",
+				slog.Any("error", err),
+				slog.Any("rollback_error", rbErr),
+				slog.Duration("duration", time.Since(start)),
+			)
+			return txOutcomeRollback, fmt.Errorf("tx err: %w, rb err: %v", err, rbErr)
 		}
 
-		return err
+		logger.WarnContext(ctx, "tx rollback",
+			slog.Any("error", err),
+			slog.Duration("duration", time.Since(start)),
+		)
+		return txOutcomeRollback, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.ErrorContext(ctx, "tx commit failed",
+			slog.Any("error", err),
+			slog.Duration("duration", time.Since(start)),
+		)
+		return txOutcomeCommitError, err
+	}
+
+	logger.InfoContext(ctx, "tx commit", slog.Duration("duration", time.Since(start)))
+	return txOutcomeCommit, nil
+}
+
+// isRetryableTxError reports whether postgres aborted the tx with a deadlock or serialization failure
+func isRetryableTxError(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
 	}
 
-	return tx.Commit()
+	return pqErr.Code == "40001" || pqErr.Code == "40P01"
 }
 
 // TransferCreateParams contains input parameters to transfer transaction
@@ -57,7 +154,10 @@ type TransferTxResult struct {
 	FromAccount Account  `json:"from_account"`
 	ToAccount   Account  `json:"to_account"`
 	FromEntrie  Entry    `json:"from_entrie"`
-	ToEntrie    Entry    `kson:"to_entrie"`
+	ToEntrie    Entry    `json:"to_entrie"`
+	// Fee is the breakdown of the fee taken from the from account on top of the amount, nil for a free transfer
+	Fee       *pricing.Fee `json:"fee,omitempty"`
+	FeeEntrie *Entry       `json:"fee_entrie,omitempty"`
 }
 
 var txKey = struct{}{}
@@ -68,81 +168,302 @@ var txKey = struct{}{}
 func (store *Store) TransferTX(ctx context.Context, arg TransferCreateParams) (TransferTxResult, error) {
 	var result TransferTxResult
 
+	logger := logging.FromContext(ctx).With(
+		slog.String("tx", "transfer"),
+		slog.Int64("from_account_id", arg.FromAccountID),
+		slog.Int64("to_account_id", arg.ToAccountID),
+		slog.Int64("amount", arg.Amount),
+	)
+	ctx = logging.WithLogger(ctx, logger)
+
 	err := store.execTx(ctx, func(q *Queries) error {
 		var err error
+		result, err = transferTx(ctx, q, arg)
+		return err
+	})
 
-		// Create transfer
-		result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
-			FromAccountID: arg.FromAccountID,
-			ToAccountID:   arg.ToAccountID,
-			Amount:        arg.Amount,
-		})
+	return result, err
+}
 
-		if err != nil {
-			return err
+// transferTx locks the accounts of the transfer, checks that both are active and that the from account has
+// the amount and the fee available, posts the legs of the transfer and of its fee as a journal, checks the
+// transfer limits of the from account and records the transfer in the outbox inside the tx of q
+func transferTx(ctx context.Context, q *Queries, arg TransferCreateParams) (TransferTxResult, error) {
+	var result TransferTxResult
+
+	accounts, fee, err := lockTransferAccounts(ctx, q, arg.FromAccountID, arg.ToAccountID, arg.Amount)
+	if err != nil {
+		return result, err
+	}
+
+	from := accounts[arg.FromAccountID]
+	if failure := checkTransfer(from, accounts[arg.ToAccountID], from.Currency, arg.Amount+fee.Amount); failure != nil {
+		return result, failure
+	}
+
+	legs := []Leg{
+		{AccountID: arg.FromAccountID, Amount: -arg.Amount - fee.Amount},
+		{AccountID: arg.ToAccountID, Amount: arg.Amount},
+	}
+	if fee.Amount > 0 {
+		legs = append(legs, Leg{AccountID: fee.FeeAccountID, Amount: fee.Amount})
+	}
+
+	posting, err := postJournal(ctx, q, PostJournalTxParams{
+		Kind: JournalKindTransfer,
+		Legs: legs,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	failure, err := checkTransferLimits(ctx, q, posting.Account(arg.FromAccountID), arg.Amount, pendingTransfers{})
+	if err != nil {
+		return result, err
+	}
+	if failure != nil {
+		return result, failure
+	}
+
+	result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
+		FromAccountID: arg.FromAccountID,
+		ToAccountID:   arg.ToAccountID,
+		Amount:        arg.Amount,
+		JournalID:     sql.NullInt64{Int64: posting.Journal.ID, Valid: true},
+	})
+	if err != nil {
+		return result, err
+	}
+
+	result.FromEntrie, result.ToEntrie = posting.Entries[0], posting.Entries[1]
+	result.FromAccount, result.ToAccount = posting.Account(arg.FromAccountID), posting.Account(arg.ToAccountID)
+
+	if fee.Amount > 0 {
+		result.Fee = &fee
+		result.FeeEntrie = &posting.Entries[2]
+	}
+
+	return result, insertTransferEvents(ctx, q, result, posting)
+}
+
+// Reasons checkTransfer refuses a transfer, a scheduled transfer records them on its run rather than aborting the tx
+var (
+	ErrAccountNotActive  = errors.New("account is not active")
+	ErrCurrencyMismatch  = errors.New("account currency mismatch")
+	ErrInsufficientFunds = errors.New("insufficient funds")
+)
+
+// checkTransfer reports why amount cannot be moved between the locked accounts from and to, if it cannot
+// amount includes the fee of the transfer and must be available, funds reserved by holds cannot be spent
+func checkTransfer(from Account, to Account, currency string, amount int64) error {
+	for _, account := range []Account{from, to} {
+		if account.Status != AccountStatusActive {
+			return fmt.Errorf("%w: account %d is %s", ErrAccountNotActive, account.ID, account.Status)
 		}
 
-		// Create Account From entrie
-		result.FromEntrie, err = q.CreateEntrie(ctx, CreateEntrieParams{
-			AccountID: arg.FromAccountID,
-			Amount:    -arg.Amount,
-		})
+		if account.Currency != currency {
+			return fmt.Errorf("%w: account %d holds %s, not %s", ErrCurrencyMismatch, account.ID, account.Currency, currency)
+		}
+	}
 
-		if err != nil {
-			return err
+	if from.AvailableBalance < amount {
+		return fmt.Errorf("%w: account %d has %d available, %d needed", ErrInsufficientFunds, from.ID, from.AvailableBalance, amount)
+	}
+
+	return nil
+}
+
+// lockTransferAccounts quotes the fee of a transfer and locks its from, to and fee accounts with lockAccounts
+func lockTransferAccounts(ctx context.Context, q *Queries, fromID int64, toID int64, amount int64) (map[int64]Account, pricing.Fee, error) {
+	from, err := q.GetAccount(ctx, fromID)
+	if err != nil {
+		return nil, pricing.Fee{}, err
+	}
+
+	fee, err := transferFee(ctx, q, from, amount)
+	if err != nil {
+		return nil, pricing.Fee{}, err
+	}
+
+	ids := []int64{fromID, toID}
+	if fee.Amount > 0 {
+		ids = append(ids, fee.FeeAccountID)
+	}
+
+	accounts, err := lockAccounts(ctx, q, ids...)
+	return accounts, fee, err
+}
+
+// lockAccounts locks the accounts in id order, every tx taking more than one account lock takes them
+// all up front in that order so that no two txs wait on each other
+func lockAccounts(ctx context.Context, q *Queries, ids ...int64) (map[int64]Account, error) {
+	seen := map[int64]bool{}
+	var sorted []int64
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			sorted = append(sorted, id)
 		}
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	locked, err := q.ListAccountsForUpdate(ctx, sorted)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(locked) != len(sorted) {
+		return nil, fmt.Errorf("account not found: %w", sql.ErrNoRows)
+	}
+
+	accounts := make(map[int64]Account, len(locked))
+	for _, account := range locked {
+		accounts[account.ID] = account
+	}
 
-		// Create Account TO entrie
-		result.ToEntrie, err = q.CreateEntrie(ctx, CreateEntrieParams{
-			AccountID: arg.ToAccountID,
-			Amount:    arg.Amount,
+	return accounts, nil
+}
+
+// insertTransferEvents records the transfer and its entries in the outbox
+func insertTransferEvents(ctx context.Context, q *Queries, result TransferTxResult, posting PostJournalTxResult) error {
+	currency := result.FromAccount.Currency
+
+	payloads := []event.Payload{
+		event.TransferCompletedV1{
+			TransferID:    result.Transfer.ID,
+			FromAccountID: result.Transfer.FromAccountID,
+			ToAccountID:   result.Transfer.ToAccountID,
+			Amount:        result.Transfer.Amount,
+			Currency:      currency,
+			CreatedAt:     result.Transfer.CreatedAt,
+		},
+		event.EntryPostedV1{
+			EntryID:    result.FromEntrie.ID,
+			AccountID:  result.FromEntrie.AccountID,
+			TransferID: result.Transfer.ID,
+			Amount:     result.FromEntrie.Amount,
+			Balance:    posting.Balances[0],
+			Currency:   currency,
+			CreatedAt:  result.FromEntrie.CreatedAt,
+		},
+		event.EntryPostedV1{
+			EntryID:    result.ToEntrie.ID,
+			AccountID:  result.ToEntrie.AccountID,
+			TransferID: result.Transfer.ID,
+			Amount:     result.ToEntrie.Amount,
+			Balance:    posting.Balances[1],
+			Currency:   currency,
+			CreatedAt:  result.ToEntrie.CreatedAt,
+		},
+	}
+
+	if result.FeeEntrie != nil {
+		payloads = append(payloads, event.EntryPostedV1{
+			EntryID:    result.FeeEntrie.ID,
+			AccountID:  result.FeeEntrie.AccountID,
+			TransferID: result.Transfer.ID,
+			Amount:     result.FeeEntrie.Amount,
+			Balance:    posting.Balances[2],
+			Currency:   currency,
+			CreatedAt:  result.FeeEntrie.CreatedAt,
 		})
+	}
 
-		if err != nil {
+	for _, payload := range payloads {
+		if err := insertEvent(ctx, q, payload); err != nil {
 			return err
 		}
+	}
 
-		if arg.FromAccountID < arg.ToAccountID {
-			result.FromAccount, result.ToAccount, err = addMoney(ctx, q, arg.FromAccountID, -arg.Amount, arg.ToAccountID, arg.Amount)
-			if err != nil {
-				return err
-			}
+	return nil
+}
 
-		} else {
-			result.FromAccount, result.ToAccount, err = addMoney(ctx, q, arg.ToAccountID, arg.Amount, arg.FromAccountID, -arg.Amount)
-			if err != nil {
-				return err
-			}
+// CreateAccountTx opens an account and records it in the outbox and the audit log
+func (store *Store) CreateAccountTx(ctx context.Context, arg CreateAccountParams) (Account, error) {
+	var account Account
 
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		account, err = openAccount(ctx, q, arg)
+		if err != nil {
+			return err
 		}
 
-		return nil
+		_, err = recordAudit(ctx, q, AuditEntry{
+			Action:     AuditAccountCreate,
+			TargetType: AuditTargetAccount,
+			TargetID:   strconv.FormatInt(account.ID, 10),
+			After:      account,
+		})
+		return err
 	})
 
-	return result, err
+	return account, err
 }
 
-func addMoney(
-	ctx context.Context,
-	q *Queries,
-	accountID1 int64,
-	amount1 int64,
-	accountID2 int64,
-	amount2 int64) (account1 Account, account2 Account, err error) {
+// openAccount opens an account and records it in the outbox inside the tx of q
+func openAccount(ctx context.Context, q *Queries, arg CreateAccountParams) (Account, error) {
+	account, err := q.CreateAccount(ctx, arg)
+	if err != nil {
+		return account, err
+	}
 
-	account1, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
-		ID:     accountID1,
-		Amount: amount1,
+	return account, insertEvent(ctx, q, event.AccountCreatedV1{
+		AccountID: account.ID,
+		Owner:     account.Owner,
+		Currency:  account.Currency,
+		Balance:   account.Balance,
+		Status:    account.Status,
+		CreatedAt: account.CreatedAt,
 	})
+}
 
-	if err != nil {
-		return
-	}
+// Account statuses
+const (
+	AccountStatusActive = "active"
+	AccountStatusFrozen = "frozen"
+	AccountStatusClosed = "closed"
+)
+
+// UpdateAccountStatusTx changes the status of an account and records the change in the outbox and the audit log
+// setting the current status again is a no-op that records nothing
+func (store *Store) UpdateAccountStatusTx(ctx context.Context, arg UpdateAccountStatusParams) (Account, error) {
+	var account Account
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		current, err := q.GetAccountForUpdate(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+
+		account = current
+		if current.Status == arg.Status {
+			return nil
+		}
+
+		account, err = q.UpdateAccountStatus(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		err = insertEvent(ctx, q, event.AccountStatusChangedV1{
+			AccountID: account.ID,
+			OldStatus: current.Status,
+			NewStatus: account.Status,
+		})
+		if err != nil {
+			return err
+		}
 
-	account2, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
-		ID:     accountID2,
-		Amount: amount2,
+		_, err = recordAudit(ctx, q, AuditEntry{
+			Action:     AuditAccountStatus,
+			TargetType: AuditTargetAccount,
+			TargetID:   strconv.FormatInt(account.ID, 10),
+			Before:     current,
+			After:      account,
+		})
+		return err
 	})
 
-	return
+	return account, err
 }
@@ -11,7 +11,7 @@ func TestYTransferTX(t *testing.T) {
 	store := NewStore(testDb)
 
 	account1 := createRandomAccount(t)
-	account2 := createRandomAccount(t)
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
 
 	// run n concurrent transfer transactions
 
@@ -111,7 +111,7 @@ func TestYTransferTXDeadlock(t *testing.T) {
 	store := NewStore(testDb)
 
 	account1 := createRandomAccount(t)
-	account2 := createRandomAccount(t)
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
 
 	// run n concurrent transfer transactions
 
@@ -158,3 +158,26 @@ func TestYTransferTXDeadlock(t *testing.T) {
 	require.Equal(t, account1.Balance, updateAccount1.Balance)
 	require.Equal(t, account2.Balance, updateAccount2.Balance)
 }
+
+func TestTransferTxFrozenAccount(t *testing.T) {
+	store := NewStore(testDb)
+
+	account1 := createRandomAccountWithCurrency(t, "USD")
+	account2 := createRandomAccountWithCurrency(t, "USD")
+
+	_, err := store.UpdateAccountStatusTx(context.Background(), UpdateAccountStatusParams{ID: account2.ID, Status: AccountStatusFrozen})
+	require.NoError(t, err)
+
+	// money moves neither in nor out of a frozen account
+	_, err = store.TransferTX(context.Background(), TransferCreateParams{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 10})
+	require.ErrorIs(t, err, ErrAccountNotActive)
+
+	_, err = store.TransferTX(context.Background(), TransferCreateParams{FromAccountID: account2.ID, ToAccountID: account1.ID, Amount: 10})
+	require.ErrorIs(t, err, ErrAccountNotActive)
+
+	_, err = store.UpdateAccountStatusTx(context.Background(), UpdateAccountStatusParams{ID: account2.ID, Status: AccountStatusActive})
+	require.NoError(t, err)
+
+	_, err = store.TransferTX(context.Background(), TransferCreateParams{FromAccountID: account2.ID, ToAccountID: account1.ID, Amount: 10})
+	require.NoError(t, err)
+}
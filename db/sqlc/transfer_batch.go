@@ -0,0 +1,337 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Transfer batch modes
+const (
+	TransferBatchModeAtomic     = "atomic"
+	TransferBatchModeBestEffort = "best_effort"
+)
+
+// Transfer batch statuses
+const (
+	TransferBatchStatusPending    = "pending"
+	TransferBatchStatusProcessing = "processing"
+	TransferBatchStatusCompleted  = "completed"
+	TransferBatchStatusFailed     = "failed"
+)
+
+// Transfer batch item statuses
+const (
+	TransferBatchItemPending   = "pending"
+	TransferBatchItemSucceeded = "succeeded"
+	TransferBatchItemFailed    = "failed"
+	TransferBatchItemSkipped   = "skipped"
+)
+
+// TransferBatchRow is one transfer of an uploaded batch
+type TransferBatchRow struct {
+	FromAccountID int64
+	ToAccountID   int64
+	Amount        int64
+	Currency      string
+}
+
+// CreateTransferBatchTxParams contains the validated rows of an upload
+type CreateTransferBatchTxParams struct {
+	Owner string
+	Mode  string
+	Rows  []TransferBatchRow
+}
+
+// CreateTransferBatchTx stores a pending batch and its rows, numbered from 1 in upload order
+func (store *Store) CreateTransferBatchTx(ctx context.Context, arg CreateTransferBatchTxParams) (TransferBatch, error) {
+	var batch TransferBatch
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		batch, err = q.CreateTransferBatch(ctx, CreateTransferBatchParams{
+			Owner:     arg.Owner,
+			Mode:      arg.Mode,
+			TotalRows: int32(len(arg.Rows)),
+		})
+		if err != nil {
+			return err
+		}
+
+		items := CreateTransferBatchItemsParams{BatchID: batch.ID}
+		for i, row := range arg.Rows {
+			items.RowNumbers = append(items.RowNumbers, int32(i+1))
+			items.FromAccountIds = append(items.FromAccountIds, row.FromAccountID)
+			items.ToAccountIds = append(items.ToAccountIds, row.ToAccountID)
+			items.Amounts = append(items.Amounts, row.Amount)
+			items.Currencies = append(items.Currencies, row.Currency)
+		}
+
+		_, err = q.CreateTransferBatchItems(ctx, items)
+		return err
+	})
+
+	return batch, err
+}
+
+// ProcessTransferBatchTxParams tells ProcessTransferBatchTx how much of a batch to run
+type ProcessTransferBatchTxParams struct {
+	BatchID int64
+	Now     time.Time
+	// ChunkSize bounds the rows of a best-effort batch run in one tx, an atomic batch runs all its rows at once
+	ChunkSize int32
+	// Lease is how long the batch stays claimed after the chunk
+	Lease time.Duration
+}
+
+// ProcessTransferBatchTx runs the next chunk of a processing batch and completes the batch after its last row
+// the accounts of the chunk are locked up front in id order, so batches and transfers touching them cannot deadlock
+// an atomic batch runs every row or, when one cannot be made, none: the rows that cannot be made fail
+// and the others are skipped
+// the rows are not screened again, every one was screened with ScreenDeferredTransfer when the batch was uploaded
+func (store *Store) ProcessTransferBatchTx(ctx context.Context, arg ProcessTransferBatchTxParams) (TransferBatch, error) {
+	var batch TransferBatch
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		batch, err = q.GetTransferBatchForUpdate(ctx, arg.BatchID)
+		if err != nil {
+			return err
+		}
+
+		if batch.Status != TransferBatchStatusProcessing {
+			return nil
+		}
+
+		limit := arg.ChunkSize
+		if batch.Mode == TransferBatchModeAtomic {
+			limit = batch.TotalRows
+		}
+
+		items, err := q.ListPendingTransferBatchItems(ctx, ListPendingTransferBatchItemsParams{
+			BatchID:    batch.ID,
+			LimitCount: limit,
+		})
+		if err != nil {
+			return err
+		}
+
+		accounts, err := lockTransferBatchAccounts(ctx, q, items)
+		if err != nil {
+			return err
+		}
+
+		update := UpdateTransferBatchParams{
+			ID:            batch.ID,
+			Status:        batch.Status,
+			SucceededRows: batch.SucceededRows,
+			FailedRows:    batch.FailedRows,
+			LeaseUntil:    sql.NullTime{Time: arg.Now.Add(arg.Lease), Valid: true},
+		}
+
+		if batch.Mode == TransferBatchModeAtomic {
+			err = runAtomicTransferBatch(ctx, q, items, accounts, &update)
+		} else {
+			err = runTransferBatchItems(ctx, q, items, accounts, &update)
+		}
+		if err != nil {
+			return err
+		}
+
+		if batch.Mode == TransferBatchModeAtomic || len(items) < int(limit) {
+			if update.Status == TransferBatchStatusProcessing {
+				update.Status = TransferBatchStatusCompleted
+			}
+			update.LeaseUntil = sql.NullTime{}
+			update.CompletedAt = sql.NullTime{Time: arg.Now, Valid: true}
+		}
+
+		batch, err = q.UpdateTransferBatch(ctx, update)
+		return err
+	})
+
+	return batch, err
+}
+
+// lockTransferBatchAccounts locks every account the items touch, the fee accounts of their rows included, in id order
+// an account that no longer exists is left out and the rows touching it fail
+func lockTransferBatchAccounts(ctx context.Context, q *Queries, items []TransferBatchItem) (map[int64]Account, error) {
+	var ids []int64
+	for _, item := range items {
+		ids = append(ids, item.FromAccountID, item.ToAccountID)
+	}
+
+	found, err := q.ListAccountsByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := make(map[int64]Account, len(found))
+	ids = ids[:0]
+	for _, account := range found {
+		accounts[account.ID] = account
+		ids = append(ids, account.ID)
+	}
+
+	// the fee depends on the tier and the currency of the from account, not on its balance, so it is quoted before the lock
+	for _, item := range items {
+		from, ok := accounts[item.FromAccountID]
+		if !ok {
+			continue
+		}
+
+		fee, err := transferFee(ctx, q, from, item.Amount)
+		if err != nil {
+			return nil, err
+		}
+		if fee.Amount > 0 {
+			ids = append(ids, fee.FeeAccountID)
+		}
+	}
+
+	return lockAccounts(ctx, q, ids...)
+}
+
+// runAtomicTransferBatch checks every row against the balances and the limits the rows before it leave
+// and runs them all only when none fails
+func runAtomicTransferBatch(ctx context.Context, q *Queries, items []TransferBatchItem, accounts map[int64]Account, update *UpdateTransferBatchParams) error {
+	balances := make(map[int64]Account, len(accounts))
+	for id, account := range accounts {
+		balances[id] = account
+	}
+
+	failures := map[int64]error{}
+	pending := newPendingTransfers()
+	var firstFailed int32
+
+	for _, item := range items {
+		from, to := balances[item.FromAccountID], balances[item.ToAccountID]
+
+		fee, err := transferFee(ctx, q, from, item.Amount)
+		if err != nil {
+			return err
+		}
+
+		failure := checkTransfer(from, to, item.Currency, item.Amount+fee.Amount)
+		if failure == nil {
+			failure, err = checkTransferLimits(ctx, q, from, item.Amount, pending)
+			if err != nil {
+				return err
+			}
+		}
+
+		if failure != nil {
+			failures[item.ID] = failure
+			if firstFailed == 0 {
+				firstFailed = item.RowNumber
+			}
+			continue
+		}
+		pending.add(from, item.Amount)
+
+		from.Balance -= item.Amount + fee.Amount
+		from.AvailableBalance -= item.Amount + fee.Amount
+		balances[from.ID] = from
+		to = balances[item.ToAccountID]
+		to.Balance += item.Amount
+		to.AvailableBalance += item.Amount
+		balances[to.ID] = to
+		if feeAccount, ok := balances[fee.FeeAccountID]; ok {
+			feeAccount.Balance += fee.Amount
+			feeAccount.AvailableBalance += fee.Amount
+			balances[fee.FeeAccountID] = feeAccount
+		}
+	}
+
+	if len(failures) == 0 {
+		return runTransferBatchItems(ctx, q, items, accounts, update)
+	}
+
+	for _, item := range items {
+		status := TransferBatchItemSkipped
+		message := fmt.Sprintf("not run because row %d failed", firstFailed)
+
+		if failure, ok := failures[item.ID]; ok {
+			status = TransferBatchItemFailed
+			message = failure.Error()
+		}
+
+		err := q.UpdateTransferBatchItem(ctx, UpdateTransferBatchItemParams{
+			ID:     item.ID,
+			Status: status,
+			Error:  sql.NullString{String: message, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	update.FailedRows += int32(len(items))
+	update.Status = TransferBatchStatusFailed
+	return nil
+}
+
+// runTransferBatchItems runs the rows in order, a row that cannot be made fails without stopping the others
+func runTransferBatchItems(ctx context.Context, q *Queries, items []TransferBatchItem, accounts map[int64]Account, update *UpdateTransferBatchParams) error {
+	for _, item := range items {
+		fee, err := transferFee(ctx, q, accounts[item.FromAccountID], item.Amount)
+		if err != nil {
+			return err
+		}
+
+		failure := checkTransfer(accounts[item.FromAccountID], accounts[item.ToAccountID], item.Currency, item.Amount+fee.Amount)
+		if failure == nil {
+			failure, err = checkTransferLimits(ctx, q, accounts[item.FromAccountID], item.Amount, pendingTransfers{})
+			if err != nil {
+				return err
+			}
+		}
+
+		if failure != nil {
+			err := q.UpdateTransferBatchItem(ctx, UpdateTransferBatchItemParams{
+				ID:     item.ID,
+				Status: TransferBatchItemFailed,
+				Error:  sql.NullString{String: failure.Error(), Valid: true},
+			})
+			if err != nil {
+				return err
+			}
+
+			update.FailedRows++
+			continue
+		}
+
+		result, err := transferTx(ctx, q, TransferCreateParams{
+			FromAccountID: item.FromAccountID,
+			ToAccountID:   item.ToAccountID,
+			Amount:        item.Amount,
+		})
+		if err != nil {
+			return err
+		}
+		accounts[item.FromAccountID] = result.FromAccount
+		accounts[item.ToAccountID] = result.ToAccount
+		if _, ok := accounts[fee.FeeAccountID]; ok && result.Fee != nil {
+			accounts[fee.FeeAccountID], err = q.GetAccount(ctx, fee.FeeAccountID)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = q.UpdateTransferBatchItem(ctx, UpdateTransferBatchItemParams{
+			ID:         item.ID,
+			Status:     TransferBatchItemSucceeded,
+			TransferID: sql.NullInt64{Int64: result.Transfer.ID, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+
+		update.SucceededRows++
+	}
+
+	return nil
+}
@@ -0,0 +1,187 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrTransferLimitExceeded = errors.New("transfer limit exceeded")
+
+// Transfer limit scopes
+const (
+	TransferLimitScopeAccount = "account"
+	TransferLimitScopeUser    = "user"
+)
+
+// Limits a transfer can exceed
+const (
+	TransferLimitMaxAmount     = "max_amount"
+	TransferLimitDailyAmount   = "daily_amount"
+	TransferLimitMonthlyAmount = "monthly_amount"
+	TransferLimitHourlyCount   = "hourly_count"
+)
+
+// TransferLimitError is the limit a transfer exceeds, it matches ErrTransferLimitExceeded
+type TransferLimitError struct {
+	Scope string
+	Limit string
+	Max   int64
+	// Value is what the transfer would take the limited amount or count to
+	Value int64
+}
+
+func (e *TransferLimitError) Error() string {
+	return fmt.Sprintf("%s: %s %s is %d, the transfer would take it to %d", ErrTransferLimitExceeded, e.Scope, e.Limit, e.Max, e.Value)
+}
+
+func (e *TransferLimitError) Unwrap() error {
+	return ErrTransferLimitExceeded
+}
+
+// TransferUsage is what an account or user transferred out within the windows of the limits
+type TransferUsage struct {
+	DailyAmount   int64 `json:"daily_amount"`
+	MonthlyAmount int64 `json:"monthly_amount"`
+	HourlyCount   int64 `json:"hourly_count"`
+}
+
+// add returns the usage after a transfer of amount
+func (usage TransferUsage) add(amount int64) TransferUsage {
+	return TransferUsage{
+		DailyAmount:   usage.DailyAmount + amount,
+		MonthlyAmount: usage.MonthlyAmount + amount,
+		HourlyCount:   usage.HourlyCount + 1,
+	}
+}
+
+func (usage TransferUsage) plus(other TransferUsage) TransferUsage {
+	return TransferUsage{
+		DailyAmount:   usage.DailyAmount + other.DailyAmount,
+		MonthlyAmount: usage.MonthlyAmount + other.MonthlyAmount,
+		HourlyCount:   usage.HourlyCount + other.HourlyCount,
+	}
+}
+
+// exceededTransferLimit returns the first limit a transfer of amount exceeds on top of usage, nil when it exceeds none
+func exceededTransferLimit(limit TransferLimit, scope string, amount int64, usage TransferUsage) error {
+	after := usage.add(amount)
+
+	checks := []struct {
+		name  string
+		max   sql.NullInt64
+		value int64
+	}{
+		{TransferLimitMaxAmount, limit.MaxAmount, amount},
+		{TransferLimitDailyAmount, limit.DailyAmount, after.DailyAmount},
+		{TransferLimitMonthlyAmount, limit.MonthlyAmount, after.MonthlyAmount},
+		{TransferLimitHourlyCount, sql.NullInt64{Int64: int64(limit.HourlyCount.Int32), Valid: limit.HourlyCount.Valid}, after.HourlyCount},
+	}
+
+	for _, check := range checks {
+		if check.max.Valid && check.value > check.max.Int64 {
+			return &TransferLimitError{Scope: scope, Limit: check.name, Max: check.max.Int64, Value: check.value}
+		}
+	}
+
+	return nil
+}
+
+// transferWindows are the starts of the windows the usage is summed over
+type transferWindows struct {
+	hour  time.Time
+	day   time.Time
+	month time.Time
+}
+
+func newTransferWindows(now time.Time) transferWindows {
+	now = now.UTC()
+
+	return transferWindows{
+		hour:  now.Add(-time.Hour),
+		day:   time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC),
+		month: time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+// since is the start of the earliest window
+func (windows transferWindows) since() time.Time {
+	if windows.hour.Before(windows.month) {
+		return windows.hour
+	}
+
+	return windows.month
+}
+
+// pendingTransfers is the usage of transfers checked but not made yet, by account and by owner
+type pendingTransfers struct {
+	accounts map[int64]TransferUsage
+	owners   map[string]TransferUsage
+}
+
+func newPendingTransfers() pendingTransfers {
+	return pendingTransfers{
+		accounts: map[int64]TransferUsage{},
+		owners:   map[string]TransferUsage{},
+	}
+}
+
+func (pending pendingTransfers) add(from Account, amount int64) {
+	pending.accounts[from.ID] = pending.accounts[from.ID].add(amount)
+	pending.owners[from.Owner] = pending.owners[from.Owner].add(amount)
+}
+
+// checkTransferLimits reports the limit a transfer of amount out of the locked from account would exceed, if any
+// checking the limits of the user locks the transfers of every account of the owner until the tx ends
+func checkTransferLimits(ctx context.Context, q *Queries, from Account, amount int64, pending pendingTransfers) (failure error, err error) {
+	now := time.Now()
+	windows := newTransferWindows(now)
+
+	limit, err := q.GetAccountTransferLimit(ctx, GetAccountTransferLimitParams{AccountID: from.ID, Now: now})
+	switch {
+	case err == nil:
+		usage, err := q.GetAccountTransferUsage(ctx, GetAccountTransferUsageParams{
+			DayStart:   windows.day,
+			MonthStart: windows.month,
+			HourStart:  windows.hour,
+			AccountID:  from.ID,
+			Since:      windows.since(),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if failure := exceededTransferLimit(limit, TransferLimitScopeAccount, amount, TransferUsage(usage).plus(pending.accounts[from.ID])); failure != nil {
+			return failure, nil
+		}
+	case !errors.Is(err, sql.ErrNoRows):
+		return nil, err
+	}
+
+	limit, err = q.GetOwnerTransferLimit(ctx, GetOwnerTransferLimitParams{Owner: from.Owner, Now: now})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := q.LockOwnerTransfers(ctx, from.Owner); err != nil {
+		return nil, err
+	}
+
+	usage, err := q.GetOwnerTransferUsage(ctx, GetOwnerTransferUsageParams{
+		DayStart:   windows.day,
+		MonthStart: windows.month,
+		HourStart:  windows.hour,
+		Owner:      from.Owner,
+		Since:      windows.since(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return exceededTransferLimit(limit, TransferLimitScopeUser, amount, TransferUsage(usage).plus(pending.owners[from.Owner])), nil
+}
@@ -0,0 +1,196 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExceededTransferLimit(t *testing.T) {
+	limit := TransferLimit{
+		MaxAmount:     sql.NullInt64{Int64: 100, Valid: true},
+		DailyAmount:   sql.NullInt64{Int64: 300, Valid: true},
+		MonthlyAmount: sql.NullInt64{Int64: 1000, Valid: true},
+		HourlyCount:   sql.NullInt32{Int32: 3, Valid: true},
+	}
+
+	testCases := []struct {
+		name   string
+		amount int64
+		usage  TransferUsage
+		limit  string
+		value  int64
+	}{
+		{name: "WithinLimits", amount: 100, usage: TransferUsage{DailyAmount: 200, MonthlyAmount: 900, HourlyCount: 2}},
+		{name: "MaxAmount", amount: 101, limit: TransferLimitMaxAmount, value: 101},
+		{name: "DailyAmount", amount: 50, usage: TransferUsage{DailyAmount: 260, MonthlyAmount: 260}, limit: TransferLimitDailyAmount, value: 310},
+		{name: "MonthlyAmount", amount: 50, usage: TransferUsage{MonthlyAmount: 990}, limit: TransferLimitMonthlyAmount, value: 1040},
+		{name: "HourlyCount", amount: 1, usage: TransferUsage{DailyAmount: 3, MonthlyAmount: 3, HourlyCount: 3}, limit: TransferLimitHourlyCount, value: 4},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := exceededTransferLimit(limit, TransferLimitScopeAccount, tc.amount, tc.usage)
+			if tc.limit == "" {
+				require.NoError(t, err)
+				return
+			}
+
+			require.ErrorIs(t, err, ErrTransferLimitExceeded)
+
+			var limitErr *TransferLimitError
+			require.True(t, errors.As(err, &limitErr))
+			require.Equal(t, TransferLimitScopeAccount, limitErr.Scope)
+			require.Equal(t, tc.limit, limitErr.Limit)
+			require.Equal(t, tc.value, limitErr.Value)
+		})
+	}
+
+	require.NoError(t, exceededTransferLimit(TransferLimit{}, TransferLimitScopeUser, 1<<40, TransferUsage{HourlyCount: 1 << 20}))
+}
+
+func TestNewTransferWindows(t *testing.T) {
+	now := time.Date(2024, time.March, 1, 0, 30, 0, 0, time.FixedZone("CET", 3600))
+	windows := newTransferWindows(now)
+
+	require.Equal(t, time.Date(2024, time.February, 29, 22, 30, 0, 0, time.UTC), windows.hour)
+	require.Equal(t, time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC), windows.day)
+	require.Equal(t, time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC), windows.month)
+	require.Equal(t, windows.month, windows.since())
+
+	windows = newTransferWindows(time.Date(2024, time.March, 1, 0, 30, 0, 0, time.UTC))
+	require.Equal(t, time.Date(2024, time.February, 29, 23, 30, 0, 0, time.UTC), windows.since())
+}
+
+func createRandomTransferLimit(t *testing.T, arg CreateTransferLimitParams) TransferLimit {
+	if arg.CreatedBy == "" {
+		arg.CreatedBy = createRandomUser(t).Username
+	}
+	if arg.Reason == "" {
+		arg.Reason = "test"
+	}
+
+	limit, err := testQueries.CreateTransferLimit(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, limit.ID)
+
+	return limit
+}
+
+func requireTransferLimitError(t *testing.T, err error, scope string, limit string) {
+	var limitErr *TransferLimitError
+	require.True(t, errors.As(err, &limitErr), "%v is not a transfer limit error", err)
+	require.Equal(t, scope, limitErr.Scope)
+	require.Equal(t, limit, limitErr.Limit)
+}
+
+func TestTransferTxAccountLimits(t *testing.T) {
+	store := NewStore(testDb)
+
+	account1 := createRandomAccountWithCurrency(t, "USD")
+	account2 := createRandomAccountWithCurrency(t, "USD")
+
+	createRandomTransferLimit(t, CreateTransferLimitParams{
+		AccountID:   sql.NullInt64{Int64: account1.ID, Valid: true},
+		MaxAmount:   sql.NullInt64{Int64: 50, Valid: true},
+		HourlyCount: sql.NullInt32{Int32: 2, Valid: true},
+	})
+
+	transfer := func(amount int64) error {
+		_, err := store.TransferTX(context.Background(), TransferCreateParams{
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+			Amount:        amount,
+		})
+		return err
+	}
+
+	requireTransferLimitError(t, transfer(51), TransferLimitScopeAccount, TransferLimitMaxAmount)
+	require.NoError(t, transfer(50))
+	require.NoError(t, transfer(10))
+	requireTransferLimitError(t, transfer(10), TransferLimitScopeAccount, TransferLimitHourlyCount)
+
+	// the breaches rolled back
+	updated, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance-60, updated.Balance)
+
+	// the other way is not limited
+	_, err = store.TransferTX(context.Background(), TransferCreateParams{
+		FromAccountID: account2.ID,
+		ToAccountID:   account1.ID,
+		Amount:        100,
+	})
+	require.NoError(t, err)
+}
+
+func TestTransferTxTemporaryLimitRaise(t *testing.T) {
+	store := NewStore(testDb)
+
+	account1 := createRandomAccountWithCurrency(t, "USD")
+	account2 := createRandomAccountWithCurrency(t, "USD")
+	accountID := sql.NullInt64{Int64: account1.ID, Valid: true}
+
+	createRandomTransferLimit(t, CreateTransferLimitParams{
+		AccountID: accountID,
+		MaxAmount: sql.NullInt64{Int64: 10, Valid: true},
+	})
+	createRandomTransferLimit(t, CreateTransferLimitParams{
+		AccountID: accountID,
+		MaxAmount: sql.NullInt64{Int64: 1000, Valid: true},
+		ExpiresAt: sql.NullTime{Time: time.Now().Add(-time.Minute), Valid: true},
+	})
+
+	arg := TransferCreateParams{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 100}
+
+	// the raise expired
+	_, err := store.TransferTX(context.Background(), arg)
+	requireTransferLimitError(t, err, TransferLimitScopeAccount, TransferLimitMaxAmount)
+
+	createRandomTransferLimit(t, CreateTransferLimitParams{
+		AccountID: accountID,
+		MaxAmount: sql.NullInt64{Int64: 1000, Valid: true},
+		ExpiresAt: sql.NullTime{Time: time.Now().Add(time.Hour), Valid: true},
+	})
+
+	_, err = store.TransferTX(context.Background(), arg)
+	require.NoError(t, err)
+
+	limits, err := testQueries.ListTransferLimits(context.Background(), ListTransferLimitsParams{
+		AccountID:  accountID,
+		LimitCount: 10,
+	})
+	require.NoError(t, err)
+	require.Len(t, limits, 3)
+}
+
+func TestTransferTxOwnerLimits(t *testing.T) {
+	store := NewStore(testDb)
+
+	account1 := createRandomAccountWithCurrency(t, "USD")
+	account2, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    account1.Owner,
+		Balance:  1000,
+		Currency: "USD",
+	})
+	require.NoError(t, err)
+	payee := createRandomAccountWithCurrency(t, "USD")
+
+	createRandomTransferLimit(t, CreateTransferLimitParams{
+		Owner:       sql.NullString{String: account1.Owner, Valid: true},
+		DailyAmount: sql.NullInt64{Int64: 150, Valid: true},
+	})
+
+	_, err = store.TransferTX(context.Background(), TransferCreateParams{FromAccountID: account1.ID, ToAccountID: payee.ID, Amount: 100})
+	require.NoError(t, err)
+
+	_, err = store.TransferTX(context.Background(), TransferCreateParams{FromAccountID: account2.ID, ToAccountID: payee.ID, Amount: 100})
+	requireTransferLimitError(t, err, TransferLimitScopeUser, TransferLimitDailyAmount)
+
+	_, err = store.TransferTX(context.Background(), TransferCreateParams{FromAccountID: account2.ID, ToAccountID: payee.ID, Amount: 50})
+	require.NoError(t, err)
+}
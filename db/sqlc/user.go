@@ -0,0 +1,38 @@
+package db
+
+import "context"
+
+// UpdateUserRoleTx changes the role of a user and records the change in the audit log,
+// setting the current role again is a no-op that records nothing
+func (store *Store) UpdateUserRoleTx(ctx context.Context, arg UpdateUserRoleParams) (User, error) {
+	var user User
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		current, err := q.GetUserForUpdate(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		user = current
+		if current.Role == arg.Role {
+			return nil
+		}
+
+		user, err = q.UpdateUserRole(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		// the row holds the password hash, only the role goes to the audit log
+		_, err = recordAudit(ctx, q, AuditEntry{
+			Action:     AuditUserRole,
+			TargetType: AuditTargetUser,
+			TargetID:   user.Username,
+			Before:     map[string]string{"role": current.Role},
+			After:      map[string]string{"role": user.Role},
+		})
+		return err
+	})
+
+	return user, err
+}
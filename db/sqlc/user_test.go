@@ -0,0 +1,142 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomUser(t *testing.T) User {
+	hashedPassword, err := util.HashPassword(util.RandomString(6))
+	require.NoError(t, err)
+
+	arg := CreateUserParams{
+		Username:       util.RandomOwner(),
+		HashedPassword: hashedPassword,
+		FullName:       util.RandomOwner(),
+		Email:          util.RandomEmail(),
+	}
+
+	user, err := testQueries.CreateUser(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, user)
+
+	require.Equal(t, arg.Username, user.Username)
+	require.Equal(t, arg.HashedPassword, user.HashedPassword)
+	require.Equal(t, arg.FullName, user.FullName)
+	require.Equal(t, arg.Email, user.Email)
+	require.True(t, user.PasswordChangedAt.IsZero())
+	require.NotZero(t, user.CreatedAt)
+
+	return user
+}
+
+func TestCreateUser(t *testing.T) {
+	createRandomUser(t)
+}
+
+func TestGetUser(t *testing.T) {
+	user1 := createRandomUser(t)
+	user2, err := testQueries.GetUser(context.Background(), user1.Username)
+	require.NoError(t, err)
+	require.NotEmpty(t, user2)
+
+	require.Equal(t, user1.Username, user2.Username)
+	require.Equal(t, user1.HashedPassword, user2.HashedPassword)
+	require.Equal(t, user1.FullName, user2.FullName)
+	require.Equal(t, user1.Email, user2.Email)
+	require.WithinDuration(t, user1.PasswordChangedAt, user2.PasswordChangedAt, time.Second)
+	require.WithinDuration(t, user1.CreatedAt, user2.CreatedAt, time.Second)
+
+	_, err = testQueries.GetUser(context.Background(), util.RandomString(12))
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+}
+
+func TestUpdateUserRoleTx(t *testing.T) {
+	store := NewStore(testDb)
+	user := createRandomUser(t)
+	require.Equal(t, "customer", user.Role)
+
+	updated, err := store.UpdateUserRoleTx(testAuditContext("root"), UpdateUserRoleParams{
+		Username: user.Username,
+		Role:     "support",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "support", updated.Role)
+
+	// setting the same role again records nothing
+	_, err = store.UpdateUserRoleTx(testAuditContext("root"), UpdateUserRoleParams{
+		Username: user.Username,
+		Role:     "support",
+	})
+	require.NoError(t, err)
+
+	logs, err := testQueries.ListAuditLog(context.Background(), ListAuditLogParams{
+		TargetType: sql.NullString{String: AuditTargetUser, Valid: true},
+		TargetID:   sql.NullString{String: user.Username, Valid: true},
+		LimitCount: 100,
+	})
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	require.Equal(t, AuditUserRole, logs[0].Action)
+	require.Equal(t, "root", logs[0].Actor)
+	require.JSONEq(t, `{"role":"customer"}`, string(logs[0].Before))
+	require.JSONEq(t, `{"role":"support"}`, string(logs[0].After))
+
+	_, err = store.UpdateUserRoleTx(context.Background(), UpdateUserRoleParams{
+		Username: util.RandomString(12),
+		Role:     "admin",
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestRecordFailedLogin(t *testing.T) {
+	user := createRandomUser(t)
+	clientIP := "192.0.2.1"
+
+	_, err := testQueries.GetLoginFailure(context.Background(), GetLoginFailureParams{Username: user.Username, ClientIp: clientIP})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	lockUntil := time.Now().Add(15 * time.Minute)
+	arg := RecordFailedLoginParams{
+		Username:    user.Username,
+		ClientIp:    clientIP,
+		MaxFailures: 3,
+		LockUntil:   lockUntil,
+	}
+
+	for failures := int32(1); failures < arg.MaxFailures; failures++ {
+		failure, err := testQueries.RecordFailedLogin(context.Background(), arg)
+		require.NoError(t, err)
+		require.Equal(t, failures, failure.Failures)
+		require.False(t, failure.LockedUntil.Valid)
+	}
+
+	// the last failure locks the username out from the client IP and starts counting again
+	locked, err := testQueries.RecordFailedLogin(context.Background(), arg)
+	require.NoError(t, err)
+	require.Zero(t, locked.Failures)
+	require.True(t, locked.LockedUntil.Valid)
+	require.WithinDuration(t, lockUntil, locked.LockedUntil.Time, time.Second)
+
+	// the other client IPs are not locked out
+	other, err := testQueries.RecordFailedLogin(context.Background(), RecordFailedLoginParams{
+		Username:    user.Username,
+		ClientIp:    "198.51.100.7",
+		MaxFailures: arg.MaxFailures,
+		LockUntil:   lockUntil,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(1), other.Failures)
+	require.False(t, other.LockedUntil.Valid)
+
+	reset := ResetFailedLoginsParams{Username: user.Username, ClientIp: clientIP}
+	require.NoError(t, testQueries.ResetFailedLogins(context.Background(), reset))
+
+	_, err = testQueries.GetLoginFailure(context.Background(), GetLoginFailureParams(reset))
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
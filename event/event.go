@@ -0,0 +1,125 @@
+// Package event defines the domain events recorded in the outbox for every ledger change
+package event
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Type names a kind of event, it never changes once published
+type Type string
+
+// Event types
+const (
+	AccountCreated       Type = "account.created"
+	AccountStatusChanged Type = "account.status_changed"
+	EntryPosted          Type = "entry.posted"
+	TransferCompleted    Type = "transfer.completed"
+	// ScheduledTransferFailed notifies the owner that an occurrence of a scheduled transfer was given up
+	ScheduledTransferFailed Type = "scheduled_transfer.failed"
+)
+
+// Event is the envelope published for an outbox row
+// consumers must be idempotent: delivery is at least once and ID identifies duplicates
+type Event struct {
+	ID         int64           `json:"id"`
+	Type       Type            `json:"type"`
+	Version    int32           `json:"version"`
+	AccountID  int64           `json:"account_id"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// Payload is the data of an event, its JSON shape is described by the schema of its type and version
+type Payload interface {
+	EventType() Type
+	SchemaVersion() int32
+	// PartitionKey is the account whose events must be published in order with this one
+	PartitionKey() int64
+}
+
+// AccountCreatedV1 is published when an account is opened
+type AccountCreatedV1 struct {
+	AccountID int64     `json:"account_id"`
+	Owner     string    `json:"owner"`
+	Currency  string    `json:"currency"`
+	Balance   int64     `json:"balance"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (p AccountCreatedV1) EventType() Type      { return AccountCreated }
+func (p AccountCreatedV1) SchemaVersion() int32 { return 1 }
+func (p AccountCreatedV1) PartitionKey() int64  { return p.AccountID }
+
+// AccountStatusChangedV1 is published when an account is frozen, closed or reactivated
+type AccountStatusChangedV1 struct {
+	AccountID int64  `json:"account_id"`
+	OldStatus string `json:"old_status"`
+	NewStatus string `json:"new_status"`
+}
+
+func (p AccountStatusChangedV1) EventType() Type      { return AccountStatusChanged }
+func (p AccountStatusChangedV1) SchemaVersion() int32 { return 1 }
+func (p AccountStatusChangedV1) PartitionKey() int64  { return p.AccountID }
+
+// EntryPostedV1 is published for every entry, Balance is the account balance right after it
+type EntryPostedV1 struct {
+	EntryID    int64     `json:"entry_id"`
+	AccountID  int64     `json:"account_id"`
+	TransferID int64     `json:"transfer_id"`
+	Amount     int64     `json:"amount"`
+	Balance    int64     `json:"balance"`
+	Currency   string    `json:"currency"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (p EntryPostedV1) EventType() Type      { return EntryPosted }
+func (p EntryPostedV1) SchemaVersion() int32 { return 1 }
+func (p EntryPostedV1) PartitionKey() int64  { return p.AccountID }
+
+// EntryPostedV2 is published for the entries of journals other than transfers, which have no TransferID
+// Balance is the account balance right after the entry
+type EntryPostedV2 struct {
+	EntryID   int64     `json:"entry_id"`
+	AccountID int64     `json:"account_id"`
+	JournalID int64     `json:"journal_id"`
+	Amount    int64     `json:"amount"`
+	Balance   int64     `json:"balance"`
+	Currency  string    `json:"currency"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (p EntryPostedV2) EventType() Type      { return EntryPosted }
+func (p EntryPostedV2) SchemaVersion() int32 { return 2 }
+func (p EntryPostedV2) PartitionKey() int64  { return p.AccountID }
+
+// TransferCompletedV1 is published once a transfer is committed, ordered with the events of the source account
+type TransferCompletedV1 struct {
+	TransferID    int64     `json:"transfer_id"`
+	FromAccountID int64     `json:"from_account_id"`
+	ToAccountID   int64     `json:"to_account_id"`
+	Amount        int64     `json:"amount"`
+	Currency      string    `json:"currency"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+func (p TransferCompletedV1) EventType() Type      { return TransferCompleted }
+func (p TransferCompletedV1) SchemaVersion() int32 { return 1 }
+func (p TransferCompletedV1) PartitionKey() int64  { return p.FromAccountID }
+
+// ScheduledTransferFailedV1 is published when an occurrence of a scheduled transfer failed its last attempt
+type ScheduledTransferFailedV1 struct {
+	ScheduledTransferID int64     `json:"scheduled_transfer_id"`
+	FromAccountID       int64     `json:"from_account_id"`
+	ToAccountID         int64     `json:"to_account_id"`
+	Amount              int64     `json:"amount"`
+	Currency            string    `json:"currency"`
+	OccurrenceAt        time.Time `json:"occurrence_at"`
+	Attempts            int32     `json:"attempts"`
+	Error               string    `json:"error"`
+}
+
+func (p ScheduledTransferFailedV1) EventType() Type      { return ScheduledTransferFailed }
+func (p ScheduledTransferFailedV1) SchemaVersion() int32 { return 1 }
+func (p ScheduledTransferFailedV1) PartitionKey() int64  { return p.FromAccountID }
@@ -0,0 +1,148 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newEvent(t *testing.T, payload Payload) Event {
+	data, err := json.Marshal(payload)
+	require.NoError(t, err)
+
+	return Event{
+		ID:         1,
+		Type:       payload.EventType(),
+		Version:    payload.SchemaVersion(),
+		AccountID:  payload.PartitionKey(),
+		OccurredAt: time.Now(),
+		Data:       data,
+	}
+}
+
+func TestPayloadsMatchSchemas(t *testing.T) {
+	now := time.Now()
+
+	payloads := []Payload{
+		AccountCreatedV1{AccountID: 1, Owner: "alice", Currency: "USD", Balance: 0, Status: "active", CreatedAt: now},
+		AccountStatusChangedV1{AccountID: 1, OldStatus: "active", NewStatus: "frozen"},
+		EntryPostedV1{EntryID: 1, AccountID: 1, TransferID: 1, Amount: -10, Balance: 90, Currency: "USD", CreatedAt: now},
+		EntryPostedV2{EntryID: 1, AccountID: 1, JournalID: 1, Amount: -10, Balance: 90, Currency: "USD", CreatedAt: now},
+		TransferCompletedV1{TransferID: 1, FromAccountID: 1, ToAccountID: 2, Amount: 10, Currency: "USD", CreatedAt: now},
+		ScheduledTransferFailedV1{ScheduledTransferID: 1, FromAccountID: 1, ToAccountID: 2, Amount: 10, Currency: "USD", OccurrenceAt: now, Attempts: 4, Error: "insufficient funds"},
+	}
+
+	for _, payload := range payloads {
+		require.NoError(t, Validate(newEvent(t, payload)), payload.EventType())
+	}
+}
+
+func TestValidateRejectsInvalidData(t *testing.T) {
+	e := newEvent(t, AccountStatusChangedV1{AccountID: 1, OldStatus: "active", NewStatus: "gone"})
+	require.Error(t, Validate(e))
+
+	e = newEvent(t, TransferCompletedV1{TransferID: 1, FromAccountID: 1, ToAccountID: 2, Amount: 0, Currency: "USD"})
+	require.Error(t, Validate(e))
+
+	e.Data = json.RawMessage(`{"transfer_id": 1}`)
+	require.Error(t, Validate(e))
+
+	e.Version = 99
+	require.Error(t, Validate(e))
+}
+
+func TestBus(t *testing.T) {
+	bus := NewBus()
+
+	var first, second []int64
+	bus.Subscribe(PublisherFunc(func(ctx context.Context, e Event) error {
+		first = append(first, e.ID)
+		return nil
+	}))
+	bus.Subscribe(PublisherFunc(func(ctx context.Context, e Event) error {
+		second = append(second, e.ID)
+		if e.ID == 2 {
+			return errors.New("unavailable")
+		}
+		return nil
+	}))
+
+	require.NoError(t, bus.Publish(context.Background(), Event{ID: 1}))
+	require.Error(t, bus.Publish(context.Background(), Event{ID: 2}))
+
+	require.Equal(t, []int64{1, 2}, first)
+	require.Equal(t, []int64{1, 2}, second)
+}
+
+func TestHub(t *testing.T) {
+	hub := NewHub()
+
+	sub1 := hub.Subscribe(1, 10)
+	sub2 := hub.Subscribe(1, 10)
+	other := hub.Subscribe(2, 10)
+	require.Equal(t, 3, hub.Subscribers())
+
+	e := newEvent(t, EntryPostedV1{EntryID: 1, AccountID: 1})
+	require.NoError(t, hub.Publish(context.Background(), e))
+
+	require.Equal(t, e, <-sub1.C())
+	require.Equal(t, e, <-sub2.C())
+	require.Empty(t, other.C())
+
+	sub1.Close()
+	sub1.Close()
+	_, ok := <-sub1.C()
+	require.False(t, ok)
+	require.False(t, sub1.Lagged())
+	require.Equal(t, 2, hub.Subscribers())
+
+	other.Close()
+	sub2.Close()
+	require.Zero(t, hub.Subscribers())
+}
+
+func TestHubDropsSlowSubscribers(t *testing.T) {
+	hub := NewHub()
+
+	slow := hub.Subscribe(1, 1)
+	fast := hub.Subscribe(1, 3)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, hub.Publish(context.Background(), newEvent(t, EntryPostedV1{EntryID: int64(i), AccountID: 1})))
+	}
+
+	// the slow subscriber keeps what it buffered and is closed instead of blocking the publisher
+	_, ok := <-slow.C()
+	require.True(t, ok)
+	_, ok = <-slow.C()
+	require.False(t, ok)
+	require.True(t, slow.Lagged())
+
+	require.Len(t, fast.C(), 3)
+	require.False(t, fast.Lagged())
+	require.Equal(t, 1, hub.Subscribers())
+
+	slow.Close()
+	fast.Close()
+}
+
+func TestHubDropAll(t *testing.T) {
+	hub := NewHub()
+
+	sub1 := hub.Subscribe(1, 10)
+	sub2 := hub.Subscribe(2, 10)
+
+	hub.DropAll()
+	require.Zero(t, hub.Subscribers())
+
+	for _, sub := range []*Subscription{sub1, sub2} {
+		_, ok := <-sub.C()
+		require.False(t, ok)
+		require.True(t, sub.Lagged())
+		sub.Close()
+	}
+}
@@ -0,0 +1,125 @@
+package event
+
+import (
+	"context"
+	"sync"
+)
+
+// Hub is a Publisher fanning events out to the live subscribers of each account
+// publishing never blocks: a subscriber whose buffer is full is dropped and marked lagged,
+// it is up to its owner to catch up from the outbox and subscribe again
+type Hub struct {
+	mu          sync.Mutex
+	subscribers map[int64]map[*Subscription]struct{}
+}
+
+// NewHub creates a hub without subscribers
+func NewHub() *Hub {
+	return &Hub{subscribers: map[int64]map[*Subscription]struct{}{}}
+}
+
+// Subscription receives the events of one account on C until it is closed or dropped
+type Subscription struct {
+	hub       *Hub
+	accountID int64
+	events    chan Event
+	lagged    bool
+	closed    bool
+}
+
+// Subscribe starts receiving the events of accountID, buffering up to buffer of them
+func (h *Hub) Subscribe(accountID int64, buffer int) *Subscription {
+	sub := &Subscription{
+		hub:       h,
+		accountID: accountID,
+		events:    make(chan Event, buffer),
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.subscribers[accountID] == nil {
+		h.subscribers[accountID] = map[*Subscription]struct{}{}
+	}
+	h.subscribers[accountID][sub] = struct{}{}
+
+	return sub
+}
+
+// C delivers the events, it is closed when the subscription is closed or dropped
+func (s *Subscription) C() <-chan Event {
+	return s.events
+}
+
+// Lagged reports whether the subscription was dropped because it fell behind
+func (s *Subscription) Lagged() bool {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	return s.lagged
+}
+
+// Close stops the subscription
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	s.hub.remove(s)
+}
+
+// Publish hands e to the subscribers of its account
+func (h *Hub) Publish(ctx context.Context, e Event) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subscribers[e.AccountID] {
+		select {
+		case sub.events <- e:
+		default:
+			sub.lagged = true
+			h.remove(sub)
+		}
+	}
+
+	return nil
+}
+
+// DropAll drops every subscriber and marks it lagged, for when events may have been missed
+func (h *Hub) DropAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, subs := range h.subscribers {
+		for sub := range subs {
+			sub.lagged = true
+			h.remove(sub)
+		}
+	}
+}
+
+// Subscribers returns the number of live subscriptions
+func (h *Hub) Subscribers() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	n := 0
+	for _, subs := range h.subscribers {
+		n += len(subs)
+	}
+	return n
+}
+
+// remove must be called with h.mu held
+func (h *Hub) remove(sub *Subscription) {
+	if sub.closed {
+		return
+	}
+
+	sub.closed = true
+	close(sub.events)
+
+	delete(h.subscribers[sub.accountID], sub)
+	if len(h.subscribers[sub.accountID]) == 0 {
+		delete(h.subscribers, sub.accountID)
+	}
+}
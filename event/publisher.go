@@ -0,0 +1,57 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// Publisher delivers events to downstream systems
+// Publish returns an error when the event was not accepted, the relay then retries it
+type Publisher interface {
+	Publish(ctx context.Context, e Event) error
+}
+
+// PublisherFunc adapts a function to a Publisher
+type PublisherFunc func(ctx context.Context, e Event) error
+
+// Publish calls f
+func (f PublisherFunc) Publish(ctx context.Context, e Event) error {
+	return f(ctx, e)
+}
+
+// Bus is an in-process Publisher fanning events out to its subscribers
+type Bus struct {
+	mu          sync.RWMutex
+	subscribers []Publisher
+}
+
+// NewBus creates an in-process event bus
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe registers a subscriber for every event published on the bus
+func (b *Bus) Subscribe(subscriber Publisher) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.subscribers = append(b.subscribers, subscriber)
+}
+
+// Publish hands e to every subscriber in turn and fails when any of them does,
+// so the event is retried and subscribers must tolerate duplicates
+func (b *Bus) Publish(ctx context.Context, e Event) error {
+	b.mu.RLock()
+	subscribers := b.subscribers
+	b.mu.RUnlock()
+
+	var errs []error
+	for _, subscriber := range subscribers {
+		if err := subscriber.Publish(ctx, e); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
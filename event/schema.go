@@ -0,0 +1,45 @@
+package event
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// schemas holds the JSON schema of the data of every event type and version, named <type>.v<version>.json
+// a published schema is never changed: a breaking change gets a new version
+//
+//go:embed schemas/*.json
+var schemas embed.FS
+
+// Schema returns the JSON schema of the data of an event type and version
+func Schema(eventType Type, version int32) ([]byte, error) {
+	data, err := schemas.ReadFile(fmt.Sprintf("schemas/%s.v%d.json", eventType, version))
+	if err != nil {
+		return nil, fmt.Errorf("no schema for %s v%d", eventType, version)
+	}
+
+	return data, nil
+}
+
+// Validate checks the data of e against the schema of its type and version
+func Validate(e Event) error {
+	raw, err := Schema(e.Type, e.Version)
+	if err != nil {
+		return err
+	}
+
+	var schema openapi3.Schema
+	if err := json.Unmarshal(raw, &schema); err != nil {
+		return fmt.Errorf("invalid schema for %s v%d: %w", e.Type, e.Version, err)
+	}
+
+	var data any
+	if err := json.Unmarshal(e.Data, &data); err != nil {
+		return fmt.Errorf("invalid %s data: %w", e.Type, err)
+	}
+
+	return schema.VisitJSON(data)
+}
@@ -0,0 +1,91 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+	db "simplebank/db/sqlc"
+	"simplebank/pb"
+	"simplebank/util"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// CreateAccount opens an account owned by the caller
+func (server *Server) CreateAccount(ctx context.Context, req *pb.CreateAccountRequest) (*pb.CreateAccountResponse, error) {
+	if err := validateCurrency(req.GetCurrency()); err != nil {
+		return nil, err
+	}
+
+	account, err := server.store.CreateAccountTx(ctx, db.CreateAccountParams{
+		Owner:    authPayload(ctx).Username,
+		Currency: req.GetCurrency(),
+		Balance:  0,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.CreateAccountResponse{Account: convertAccount(account)}, nil
+}
+
+// GetAccount returns an account of the caller
+func (server *Server) GetAccount(ctx context.Context, req *pb.GetAccountRequest) (*pb.GetAccountResponse, error) {
+	if err := validateID("id", req.GetId()); err != nil {
+		return nil, err
+	}
+
+	account, err := server.ownedAccount(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.GetAccountResponse{Account: convertAccount(account)}, nil
+}
+
+// ListAccounts pages through the accounts of the caller
+func (server *Server) ListAccounts(ctx context.Context, req *pb.ListAccountsRequest) (*pb.ListAccountsResponse, error) {
+	if err := validatePageSize(req.GetPageSize(), minAccountsPageSize, maxAccountsPageSize); err != nil {
+		return nil, err
+	}
+
+	afterID, err := util.DecodeCursor(req.GetCursor())
+	if err != nil {
+		return nil, invalidArgument("cursor", err)
+	}
+
+	accounts, err := server.store.ListAccountsAfter(ctx, db.ListAccountsAfterParams{
+		Owner:      authPayload(ctx).Username,
+		AfterID:    afterID,
+		LimitCount: req.GetPageSize(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rsp := &pb.ListAccountsResponse{}
+	for _, account := range accounts {
+		rsp.Accounts = append(rsp.Accounts, convertAccount(account))
+	}
+
+	if len(accounts) > 0 {
+		rsp.NextCursor = util.NextCursor(len(accounts), req.GetPageSize(), accounts[len(accounts)-1].ID)
+	}
+
+	return rsp, nil
+}
+
+// ownedAccount loads an account that belongs to the caller
+func (server *Server) ownedAccount(ctx context.Context, accountID int64) (db.Account, error) {
+	account, err := server.store.GetAccount(ctx, accountID)
+	if err != nil {
+		return account, err
+	}
+
+	if account.Owner != authPayload(ctx).Username {
+		err := errors.New("account doesn't belong to the authenticated user")
+		return account, status.Error(codes.PermissionDenied, err.Error())
+	}
+
+	return account, nil
+}
@@ -0,0 +1,47 @@
+package gapi
+
+import (
+	"context"
+	db "simplebank/db/sqlc"
+	"simplebank/pb"
+	"simplebank/util"
+)
+
+// ListAccountEntries returns the statement of an account of the caller, oldest entry first
+func (server *Server) ListAccountEntries(ctx context.Context, req *pb.ListAccountEntriesRequest) (*pb.ListAccountEntriesResponse, error) {
+	if err := validateID("account_id", req.GetAccountId()); err != nil {
+		return nil, err
+	}
+	if err := validatePageSize(req.GetPageSize(), 1, maxEntriesPageSize); err != nil {
+		return nil, err
+	}
+
+	afterID, err := util.DecodeCursor(req.GetCursor())
+	if err != nil {
+		return nil, invalidArgument("cursor", err)
+	}
+
+	if _, err := server.ownedAccount(ctx, req.GetAccountId()); err != nil {
+		return nil, err
+	}
+
+	entries, err := server.store.ListAccountEntries(ctx, db.ListAccountEntriesParams{
+		AccountID:  req.GetAccountId(),
+		AfterID:    afterID,
+		LimitCount: req.GetPageSize(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rsp := &pb.ListAccountEntriesResponse{}
+	for _, entry := range entries {
+		rsp.Entries = append(rsp.Entries, convertEntry(entry))
+	}
+
+	if len(entries) > 0 {
+		rsp.NextCursor = util.NextCursor(len(entries), req.GetPageSize(), entries[len(entries)-1].ID)
+	}
+
+	return rsp, nil
+}
@@ -0,0 +1,94 @@
+package gapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"simplebank/pb"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const requestIDHeader = "X-Request-ID"
+
+// errorResponse is the error envelope of the HTTP API, the gateway answers with the same shape
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// Gateway creates the REST gateway of the gRPC API served at endpoint
+// every request is forwarded as a gRPC call, so it goes through the same interceptors
+// the connection is closed when ctx is done
+func Gateway(ctx context.Context, endpoint string) (http.Handler, error) {
+	mux := runtime.NewServeMux(
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.JSONPb{
+			MarshalOptions: protojson.MarshalOptions{
+				UseProtoNames:   true,
+				EmitUnpopulated: true,
+			},
+			UnmarshalOptions: protojson.UnmarshalOptions{
+				DiscardUnknown: true,
+			},
+		}),
+		runtime.WithIncomingHeaderMatcher(incomingHeaderMatcher),
+		runtime.WithOutgoingHeaderMatcher(outgoingHeaderMatcher),
+		runtime.WithErrorHandler(errorHandler),
+	)
+
+	opts := []grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+	}
+
+	if err := pb.RegisterSimpleBankServiceHandlerFromEndpoint(ctx, mux, endpoint, opts); err != nil {
+		return nil, err
+	}
+
+	return mux, nil
+}
+
+// incomingHeaderMatcher forwards X-Request-ID so the gRPC call keeps the ID chosen by the HTTP caller
+func incomingHeaderMatcher(key string) (string, bool) {
+	if strings.EqualFold(key, requestIDHeader) {
+		return requestIDKey, true
+	}
+
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+func outgoingHeaderMatcher(key string) (string, bool) {
+	if key == requestIDKey {
+		return requestIDHeader, true
+	}
+
+	return runtime.MetadataHeaderPrefix + key, true
+}
+
+// errorHandler writes gRPC errors in the error envelope of the HTTP API
+func errorHandler(ctx context.Context, mux *runtime.ServeMux, marshaler runtime.Marshaler, w http.ResponseWriter, r *http.Request, err error) {
+	st := status.Convert(err)
+	httpStatus := runtime.HTTPStatusFromCode(st.Code())
+
+	var httpErr *runtime.HTTPStatusError
+	if errors.As(err, &httpErr) {
+		st = status.Convert(httpErr.Err)
+		httpStatus = httpErr.HTTPStatus
+	}
+
+	if md, ok := runtime.ServerMetadataFromContext(ctx); ok {
+		if requestIDs := md.HeaderMD.Get(requestIDKey); len(requestIDs) > 0 {
+			w.Header().Set(requestIDHeader, requestIDs[0])
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(httpStatus)
+	json.NewEncoder(w).Encode(errorResponse{Error: st.Message()})
+}
@@ -0,0 +1,113 @@
+package gapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"simplebank/rbac"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGateway(t *testing.T) {
+	server := newTestServer(t, nil)
+	address := startTestServer(t, server)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	gateway, err := Gateway(ctx, address)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		method        string
+		url           string
+		body          string
+		authorize     bool
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "Unauthenticated",
+			method: http.MethodGet,
+			url:    "/v1/accounts/1",
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				requireErrorEnvelope(t, recorder)
+			},
+		},
+		{
+			name:      "InvalidPathParameter",
+			method:    http.MethodGet,
+			url:       "/v1/accounts/0",
+			authorize: true,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireErrorEnvelope(t, recorder)
+			},
+		},
+		{
+			name:      "InvalidQueryParameter",
+			method:    http.MethodGet,
+			url:       "/v1/accounts?page_size=50",
+			authorize: true,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireErrorEnvelope(t, recorder)
+			},
+		},
+		{
+			name:   "InvalidBody",
+			method: http.MethodPost,
+			url:    "/v1/users",
+			body:   `{"username": "bob", "password": "123"}`,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Contains(t, requireErrorEnvelope(t, recorder), "password")
+			},
+		},
+		{
+			name:   "UnknownRoute",
+			method: http.MethodGet,
+			url:    "/v1/unknown",
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+				requireErrorEnvelope(t, recorder)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			request := httptest.NewRequest(tc.method, tc.url, strings.NewReader(tc.body))
+			request.Header.Set(requestIDHeader, "gateway-"+tc.name)
+
+			if tc.authorize {
+				accessToken, _, err := server.tokenMaker.CreateToken("user", rbac.RoleCustomer, server.config.AccessTokenDuration)
+				require.NoError(t, err)
+				request.Header.Set("Authorization", "Bearer "+accessToken)
+			}
+
+			recorder := httptest.NewRecorder()
+			gateway.ServeHTTP(recorder, request)
+
+			tc.checkResponse(t, recorder)
+			if tc.name != "UnknownRoute" {
+				require.Equal(t, "gateway-"+tc.name, recorder.Header().Get(requestIDHeader))
+			}
+		})
+	}
+}
+
+func requireErrorEnvelope(t *testing.T, recorder *httptest.ResponseRecorder) string {
+	var rsp errorResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+	require.NotEmpty(t, rsp.Error)
+
+	return rsp.Error
+}
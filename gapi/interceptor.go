@@ -0,0 +1,247 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/netip"
+	"simplebank/audit"
+	"simplebank/logging"
+	"simplebank/pb"
+	"simplebank/rbac"
+	"simplebank/token"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	requestIDKey       = "x-request-id"
+	maxRequestIDLength = 128
+)
+
+// requestLogger tags every call with a request ID and carries a logger for it in the context
+func requestLogger() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+
+		requestID := firstMetadata(ctx, requestIDKey)
+		if !validRequestID(requestID) {
+			requestID = uuid.NewString()
+		}
+		grpc.SetHeader(ctx, metadata.Pairs(requestIDKey, requestID))
+
+		logger := logging.FromContext(ctx).With(slog.String("request_id", requestID))
+
+		span := trace.SpanFromContext(ctx)
+		if span.SpanContext().IsValid() {
+			span.SetAttributes(attribute.String("rpc.request_id", requestID))
+			logger = logger.With(slog.String("trace_id", span.SpanContext().TraceID().String()))
+		}
+
+		ctx = logging.WithRequestID(ctx, requestID)
+		ctx = logging.WithLogger(ctx, logger)
+
+		rsp, err := handler(ctx, req)
+
+		code := status.Code(err)
+		attrs := []slog.Attr{
+			slog.String("method", info.FullMethod),
+			slog.String("code", code.String()),
+			slog.Duration("duration", time.Since(start)),
+		}
+
+		if err != nil {
+			attrs = append(attrs, slog.String("error", status.Convert(err).Message()))
+		}
+
+		level := slog.LevelInfo
+		switch code {
+		case codes.OK:
+		case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unavailable:
+			level = slog.LevelError
+		default:
+			level = slog.LevelWarn
+		}
+
+		logger.LogAttrs(ctx, level, "grpc request", attrs...)
+
+		return rsp, err
+	}
+}
+
+// validRequestID accepts only short printable IDs so callers cannot inject into the logs
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+
+	for _, c := range requestID {
+		if c < 0x21 || c > 0x7e {
+			return false
+		}
+	}
+
+	return true
+}
+
+// errorMapper turns the errors of the store into gRPC status errors
+func errorMapper() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		rsp, err := handler(ctx, req)
+		if err != nil {
+			return rsp, toStatusError(ctx, err)
+		}
+
+		return rsp, nil
+	}
+}
+
+// toStatusError keeps the status errors of the handlers and maps the errors of the store to a code with a generic
+// message, the SQL and driver messages stay in the log with the request ID and never reach the caller
+func toStatusError(ctx context.Context, err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	var pqErr *pq.Error
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return status.Error(codes.NotFound, "not found")
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case errors.As(err, &pqErr) && pqErr.Code.Name() == "unique_violation":
+		return status.Error(codes.AlreadyExists, "already exists")
+	case errors.As(err, &pqErr) && pqErr.Code.Name() == "foreign_key_violation":
+		return status.Error(codes.FailedPrecondition, "refers to a record that does not exist")
+	}
+
+	logging.FromContext(ctx).ErrorContext(ctx, "internal error", slog.Any("error", err))
+	return status.Error(codes.Internal, "internal error")
+}
+
+const (
+	authorizationHeaderKey  = "authorization"
+	authorizationTypeBearer = "bearer"
+)
+
+// publicMethods can be called without an access token
+var publicMethods = map[string]bool{
+	pb.SimpleBankService_CreateUser_FullMethodName:       true,
+	pb.SimpleBankService_LoginUser_FullMethodName:        true,
+	pb.SimpleBankService_RenewAccessToken_FullMethodName: true,
+}
+
+type authPayloadKey struct{}
+
+// authInterceptor rejects calls to private methods without a valid bearer access token,
+// and hands the caller to the audit log of the store through the context
+func authInterceptor(tokenMaker token.Maker, trustedProxies []netip.Prefix) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		mtdt := extractMetadata(ctx, trustedProxies)
+		actor := audit.Actor{
+			Username:  audit.AnonymousActor,
+			IP:        mtdt.ClientIP,
+			UserAgent: mtdt.UserAgent,
+			RequestID: logging.RequestIDFromContext(ctx),
+		}
+
+		if publicMethods[info.FullMethod] {
+			return handler(audit.WithActor(ctx, actor), req)
+		}
+
+		payload, err := verifyAccessToken(ctx, tokenMaker)
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+
+		logger := logging.FromContext(ctx).With(slog.String("username", payload.Username))
+		ctx = logging.WithLogger(ctx, logger)
+		ctx = context.WithValue(ctx, authPayloadKey{}, payload)
+
+		actor.Username = payload.Username
+		ctx = audit.WithActor(ctx, actor)
+
+		return handler(ctx, req)
+	}
+}
+
+// methodPermissions is the permission each private method requires, as the matching HTTP route does
+var methodPermissions = map[string]rbac.Permission{
+	pb.SimpleBankService_CreateAccount_FullMethodName:      rbac.AccountsWrite,
+	pb.SimpleBankService_GetAccount_FullMethodName:         rbac.AccountsRead,
+	pb.SimpleBankService_ListAccounts_FullMethodName:       rbac.AccountsRead,
+	pb.SimpleBankService_ListAccountEntries_FullMethodName: rbac.AccountsRead,
+	pb.SimpleBankService_CreateTransfer_FullMethodName:     rbac.TransfersWrite,
+}
+
+// permissionInterceptor rejects calls to private methods whose permission the role of the caller is not granted,
+// it runs after authInterceptor; a private method missing from methodPermissions is refused to everyone
+func permissionInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if publicMethods[info.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		permission, ok := methodPermissions[info.FullMethod]
+		if !ok {
+			return nil, status.Errorf(codes.PermissionDenied, "method %s is not granted to any role", info.FullMethod)
+		}
+
+		if !rbac.Can(authPayload(ctx).Role, permission) {
+			return nil, status.Errorf(codes.PermissionDenied, "permission %s required", permission)
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+func verifyAccessToken(ctx context.Context, tokenMaker token.Maker) (*token.Payload, error) {
+	authorizationHeader := firstMetadata(ctx, authorizationHeaderKey)
+	if len(authorizationHeader) == 0 {
+		return nil, errors.New("authorization header is not provided")
+	}
+
+	fields := strings.Fields(authorizationHeader)
+	if len(fields) < 2 {
+		return nil, errors.New("invalid authorization header format")
+	}
+
+	authorizationType := strings.ToLower(fields[0])
+	if authorizationType != authorizationTypeBearer {
+		return nil, fmt.Errorf("unsupported authorization type %s", authorizationType)
+	}
+
+	return tokenMaker.VerifyToken(fields[1])
+}
+
+// authPayload returns the access token payload of the caller, set by authInterceptor
+func authPayload(ctx context.Context) *token.Payload {
+	return ctx.Value(authPayloadKey{}).(*token.Payload)
+}
+
+func firstMetadata(ctx context.Context, key string) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+
+	return ""
+}
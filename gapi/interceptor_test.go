@@ -0,0 +1,152 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"simplebank/pb"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestAuthInterceptor(t *testing.T) {
+	server := newTestServer(t, nil)
+	client := newTestClient(t, startTestServer(t, server))
+
+	testCases := []struct {
+		name string
+		ctx  func(t *testing.T) context.Context
+		code codes.Code
+	}{
+		{
+			name: "NoAuthorization",
+			ctx: func(t *testing.T) context.Context {
+				return context.Background()
+			},
+			code: codes.Unauthenticated,
+		},
+		{
+			name: "InvalidFormat",
+			ctx: func(t *testing.T) context.Context {
+				return metadata.AppendToOutgoingContext(context.Background(), authorizationHeaderKey, "bearer")
+			},
+			code: codes.Unauthenticated,
+		},
+		{
+			name: "UnsupportedType",
+			ctx: func(t *testing.T) context.Context {
+				return metadata.AppendToOutgoingContext(context.Background(), authorizationHeaderKey, "basic abc")
+			},
+			code: codes.Unauthenticated,
+		},
+		{
+			name: "InvalidToken",
+			ctx: func(t *testing.T) context.Context {
+				return metadata.AppendToOutgoingContext(context.Background(), authorizationHeaderKey, "bearer abc")
+			},
+			code: codes.Unauthenticated,
+		},
+		{
+			name: "RoleNotGranted",
+			ctx: func(t *testing.T) context.Context {
+				accessToken, _, err := server.tokenMaker.CreateToken("user", "nobody", time.Minute)
+				require.NoError(t, err)
+
+				return metadata.AppendToOutgoingContext(context.Background(), authorizationHeaderKey, authorizationTypeBearer+" "+accessToken)
+			},
+			code: codes.PermissionDenied,
+		},
+		{
+			name: "Authorized",
+			ctx: func(t *testing.T) context.Context {
+				return withAuthorization(t, context.Background(), server.tokenMaker, "user")
+			},
+			// the request is rejected by the handler, past the interceptor
+			code: codes.InvalidArgument,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := client.GetAccount(tc.ctx(t), &pb.GetAccountRequest{Id: 0})
+			require.Equal(t, tc.code, status.Code(err))
+		})
+	}
+}
+
+func TestEveryPrivateMethodRequiresAPermission(t *testing.T) {
+	for _, method := range pb.SimpleBankService_ServiceDesc.Methods {
+		fullMethod := "/" + pb.SimpleBankService_ServiceDesc.ServiceName + "/" + method.MethodName
+		if publicMethods[fullMethod] {
+			continue
+		}
+
+		_, ok := methodPermissions[fullMethod]
+		require.True(t, ok, "method %s has no permission", fullMethod)
+	}
+}
+
+func TestPublicMethodsSkipAuth(t *testing.T) {
+	client := newTestClient(t, startTestServer(t, newTestServer(t, nil)))
+
+	_, err := client.CreateUser(context.Background(), &pb.CreateUserRequest{Username: "not valid"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestRequestIDHeader(t *testing.T) {
+	client := newTestClient(t, startTestServer(t, newTestServer(t, nil)))
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), requestIDKey, "req-42")
+
+	var header metadata.MD
+	_, err := client.LoginUser(ctx, &pb.LoginUserRequest{}, grpc.Header(&header))
+	require.Error(t, err)
+	require.Equal(t, []string{"req-42"}, header.Get(requestIDKey))
+}
+
+func TestToStatusError(t *testing.T) {
+	testCases := []struct {
+		err  error
+		code codes.Code
+	}{
+		{err: status.Error(codes.PermissionDenied, "denied"), code: codes.PermissionDenied},
+		{err: sql.ErrNoRows, code: codes.NotFound},
+		{err: fmt.Errorf("get account: %w", sql.ErrNoRows), code: codes.NotFound},
+		{err: context.Canceled, code: codes.Canceled},
+		{err: context.DeadlineExceeded, code: codes.DeadlineExceeded},
+		{err: &pq.Error{Code: "23505"}, code: codes.AlreadyExists},
+		{err: &pq.Error{Code: "23503"}, code: codes.FailedPrecondition},
+		{err: errors.New("boom"), code: codes.Internal},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.code, status.Code(toStatusError(context.Background(), tc.err)), tc.err.Error())
+	}
+}
+
+func TestToStatusErrorHidesStoreMessages(t *testing.T) {
+	testCases := []error{
+		fmt.Errorf("get account: %w", sql.ErrNoRows),
+		&pq.Error{Code: "23505", Message: `duplicate key value violates unique constraint "users_pkey"`},
+		&pq.Error{Code: "23503", Message: `insert or update on table "accounts" violates foreign key constraint "accounts_owner_fkey"`},
+		errors.New(`pq: relation "accounts" does not exist`),
+	}
+
+	for _, err := range testCases {
+		message := status.Convert(toStatusError(context.Background(), err)).Message()
+		require.NotContains(t, message, "pq:", err.Error())
+		require.NotContains(t, message, "sql:", err.Error())
+		require.NotContains(t, message, "constraint", err.Error())
+		require.NotContains(t, message, "accounts", err.Error())
+	}
+}
@@ -0,0 +1,59 @@
+package gapi
+
+import (
+	"context"
+	"net"
+	db "simplebank/db/sqlc"
+	"simplebank/pb"
+	"simplebank/ratelimit"
+	"simplebank/rbac"
+	"simplebank/token"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+func newTestServer(t *testing.T, store *db.Store) *Server {
+	config := util.Config{
+		TokenSymmetricKey:    util.RandomString(32),
+		AccessTokenDuration:  time.Minute,
+		RefreshTokenDuration: time.Hour,
+	}
+
+	server, err := NewServer(config, store, ratelimit.NewLimiter(ratelimit.NewMemoryStore()))
+	require.NoError(t, err)
+
+	return server
+}
+
+// startTestServer serves server over gRPC on a local port and returns its address
+func startTestServer(t *testing.T, server *Server) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	grpcServer := server.GRPCServer()
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	return listener.Addr().String()
+}
+
+func newTestClient(t *testing.T, address string) pb.SimpleBankServiceClient {
+	conn, err := grpc.Dial(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return pb.NewSimpleBankServiceClient(conn)
+}
+
+func withAuthorization(t *testing.T, ctx context.Context, tokenMaker token.Maker, username string) context.Context {
+	accessToken, _, err := tokenMaker.CreateToken(username, rbac.RoleCustomer, time.Minute)
+	require.NoError(t, err)
+
+	return metadata.AppendToOutgoingContext(ctx, authorizationHeaderKey, authorizationTypeBearer+" "+accessToken)
+}
@@ -0,0 +1,108 @@
+package gapi
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"strings"
+
+	"google.golang.org/grpc/peer"
+)
+
+const (
+	grpcGatewayUserAgentKey = "grpcgateway-user-agent"
+	userAgentKey            = "user-agent"
+	xForwardedForKey        = "x-forwarded-for"
+)
+
+// clientMetadata describes the caller of a login, whether it came through the gateway or over gRPC
+type clientMetadata struct {
+	UserAgent string
+	ClientIP  string
+}
+
+func extractMetadata(ctx context.Context, trustedProxies []netip.Prefix) clientMetadata {
+	mtdt := clientMetadata{
+		UserAgent: firstMetadata(ctx, grpcGatewayUserAgentKey),
+		ClientIP:  clientIP(ctx, trustedProxies),
+	}
+
+	if mtdt.UserAgent == "" {
+		mtdt.UserAgent = firstMetadata(ctx, userAgentKey)
+	}
+
+	return mtdt
+}
+
+// clientIP is the address of the peer, unless the peer is a trusted proxy or the gateway on the loopback interface;
+// then it is the rightmost address of x-forwarded-for that is not a proxy, every proxy appends the address it sees
+// so the ones left of it are whatever the caller sent
+func clientIP(ctx context.Context, trustedProxies []netip.Prefix) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	addrPort, err := netip.ParseAddrPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+
+	ip := addrPort.Addr().Unmap()
+	if !trustedProxy(ip, trustedProxies) {
+		return ip.String()
+	}
+
+	forwarded := strings.Split(firstMetadata(ctx, xForwardedForKey), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(forwarded[i]))
+		if err != nil {
+			break
+		}
+
+		ip = hop.Unmap()
+		if !trustedProxy(ip, trustedProxies) {
+			break
+		}
+	}
+
+	return ip.String()
+}
+
+func trustedProxy(ip netip.Addr, trustedProxies []netip.Prefix) bool {
+	if ip.IsLoopback() {
+		return true
+	}
+
+	for _, prefix := range trustedProxies {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseTrustedProxies parses the trusted proxies of the config, IPs or CIDR ranges as the HTTP server takes them
+func parseTrustedProxies(proxies []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(proxies))
+
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			ip, err := netip.ParseAddr(proxy)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %w", proxy, err)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(ip.Unmap(), ip.Unmap().BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", proxy, err)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+
+	return prefixes, nil
+}
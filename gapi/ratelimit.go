@@ -0,0 +1,89 @@
+package gapi
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"net/netip"
+	"simplebank/logging"
+	"simplebank/pb"
+	"simplebank/ratelimit"
+	"strconv"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const retryAfterKey = "retry-after"
+
+// methodRatePolicies is the policy each method takes a token from on top of the default one, as the matching HTTP route does
+var methodRatePolicies = map[string]ratelimit.Policy{
+	pb.SimpleBankService_CreateUser_FullMethodName:         ratelimit.CreateUserPolicy,
+	pb.SimpleBankService_LoginUser_FullMethodName:          ratelimit.LoginPolicy,
+	pb.SimpleBankService_RenewAccessToken_FullMethodName:   ratelimit.RenewTokenPolicy,
+	pb.SimpleBankService_CreateAccount_FullMethodName:      ratelimit.CreateAccountPolicy,
+	pb.SimpleBankService_ListAccounts_FullMethodName:       ratelimit.ListPolicy,
+	pb.SimpleBankService_ListAccountEntries_FullMethodName: ratelimit.ListPolicy,
+	pb.SimpleBankService_CreateTransfer_FullMethodName:     ratelimit.CreateTransferPolicy,
+}
+
+// ipRateLimiter limits the calls of every client IP before authInterceptor checks their token,
+// a public method takes a token from its own policy for the IP as well
+// the buckets are keyed as the HTTP server keys them, so the limits hold across both
+func ipRateLimiter(limiter *ratelimit.Limiter, trustedProxies []netip.Prefix) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		policies := []ratelimit.Policy{ratelimit.IPPolicy}
+		if publicMethods[info.FullMethod] {
+			policies = append(policies, methodRatePolicies[info.FullMethod])
+		}
+
+		if err := takeTokens(ctx, limiter, "ip:"+clientIP(ctx, trustedProxies), policies...); err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// userRateLimiter limits the calls of the authenticated user to the private methods, it runs after authInterceptor
+func userRateLimiter(limiter *ratelimit.Limiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if publicMethods[info.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		policies := []ratelimit.Policy{ratelimit.DefaultPolicy}
+		if policy, ok := methodRatePolicies[info.FullMethod]; ok {
+			policies = append(policies, policy)
+		}
+
+		if err := takeTokens(ctx, limiter, "user:"+authPayload(ctx).Username, policies...); err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// takeTokens takes a token from the bucket of key for every policy and refuses the call with ResourceExhausted
+// once one has none left; it fails open when the store is unreachable, as the HTTP server does
+func takeTokens(ctx context.Context, limiter *ratelimit.Limiter, key string, policies ...ratelimit.Policy) error {
+	for _, policy := range policies {
+		decision, err := limiter.Allow(ctx, key, policy)
+		if err != nil {
+			logging.FromContext(ctx).ErrorContext(ctx, "rate limit not checked",
+				slog.String("policy", policy.Name), slog.Any("error", err))
+			continue
+		}
+
+		if !decision.Allowed {
+			retryAfter := max(int64(math.Ceil(decision.RetryAfter.Seconds())), 1)
+			grpc.SetHeader(ctx, metadata.Pairs(retryAfterKey, strconv.FormatInt(retryAfter, 10)))
+			return status.Error(codes.ResourceExhausted, "too many requests, retry later")
+		}
+	}
+
+	return nil
+}
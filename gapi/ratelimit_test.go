@@ -0,0 +1,125 @@
+package gapi
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"simplebank/pb"
+	"simplebank/ratelimit"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestLoginRateLimit(t *testing.T) {
+	server := newTestServer(t, nil)
+	client := newTestClient(t, startTestServer(t, server))
+
+	for i := 0; i < ratelimit.LoginPolicy.Limit; i++ {
+		_, err := client.LoginUser(context.Background(), &pb.LoginUserRequest{})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	}
+
+	var header metadata.MD
+	_, err := client.LoginUser(context.Background(), &pb.LoginUserRequest{}, grpc.Header(&header))
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+	require.NotEmpty(t, header.Get(retryAfterKey))
+
+	// the bucket is keyed as the HTTP server keys it, so logins over HTTP take from it too
+	decision, err := server.limiter.Allow(context.Background(), "ip:127.0.0.1", ratelimit.LoginPolicy)
+	require.NoError(t, err)
+	require.False(t, decision.Allowed)
+}
+
+func TestUserRateLimit(t *testing.T) {
+	server := newTestServer(t, nil)
+	client := newTestClient(t, startTestServer(t, server))
+
+	alice := withAuthorization(t, context.Background(), server.tokenMaker, "alice")
+
+	// the requests are rejected by the handler, past the limiter
+	for i := 0; i < ratelimit.ListPolicy.Limit; i++ {
+		_, err := client.ListAccounts(alice, &pb.ListAccountsRequest{})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	}
+
+	_, err := client.ListAccounts(alice, &pb.ListAccountsRequest{})
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// the other methods and the other users have buckets of their own
+	_, err = client.GetAccount(alice, &pb.GetAccountRequest{Id: 0})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	bob := withAuthorization(t, context.Background(), server.tokenMaker, "bob")
+	_, err = client.ListAccounts(bob, &pb.ListAccountsRequest{})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestClientIP(t *testing.T) {
+	trustedProxies, err := parseTrustedProxies([]string{"192.0.2.0/24"})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name         string
+		peer         string
+		forwardedFor string
+		expectedIP   string
+	}{
+		{
+			name:       "Direct",
+			peer:       "198.51.100.7:5000",
+			expectedIP: "198.51.100.7",
+		},
+		{
+			name:         "ForgedForwardedFor",
+			peer:         "198.51.100.7:5000",
+			forwardedFor: "10.0.0.1",
+			expectedIP:   "198.51.100.7",
+		},
+		{
+			name:         "Gateway",
+			peer:         "127.0.0.1:5000",
+			forwardedFor: "10.0.0.1, 198.51.100.7",
+			expectedIP:   "198.51.100.7",
+		},
+		{
+			name:         "TrustedProxy",
+			peer:         "127.0.0.1:5000",
+			forwardedFor: "10.0.0.1, 198.51.100.7, 192.0.2.10",
+			expectedIP:   "198.51.100.7",
+		},
+		{
+			name:       "GatewayWithoutForwardedFor",
+			peer:       "127.0.0.1:5000",
+			expectedIP: "127.0.0.1",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			addr, err := net.ResolveTCPAddr("tcp", tc.peer)
+			require.NoError(t, err)
+
+			ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: addr})
+			if tc.forwardedFor != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(xForwardedForKey, tc.forwardedFor))
+			}
+
+			require.Equal(t, tc.expectedIP, clientIP(ctx, trustedProxies))
+		})
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	prefixes, err := parseTrustedProxies([]string{"192.0.2.1", "10.0.0.0/8"})
+	require.NoError(t, err)
+	require.Equal(t, []netip.Prefix{netip.MustParsePrefix("192.0.2.1/32"), netip.MustParsePrefix("10.0.0.0/8")}, prefixes)
+
+	_, err = parseTrustedProxies([]string{"not an ip"})
+	require.Error(t, err)
+}
@@ -0,0 +1,75 @@
+// Package gapi serves the simplebank API over gRPC and, through grpc-gateway, over REST under /v1
+package gapi
+
+import (
+	"fmt"
+	"net/netip"
+	db "simplebank/db/sqlc"
+	"simplebank/pb"
+	"simplebank/ratelimit"
+	"simplebank/risk"
+	"simplebank/token"
+	"simplebank/util"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+)
+
+// Server serves gRPC requests for our banking service
+type Server struct {
+	pb.UnimplementedSimpleBankServiceServer
+	config     util.Config
+	store      *db.Store
+	tokenMaker token.Maker
+	risk       *risk.Engine
+	limiter    *ratelimit.Limiter
+	// the proxies trusted to set x-forwarded-for, the gateway on the loopback interface always is
+	trustedProxies []netip.Prefix
+}
+
+// NewServer creates a new gRPC server
+// limiter is shared with the HTTP server, so that a caller takes its tokens from the same buckets on both
+func NewServer(config util.Config, store *db.Store, limiter *ratelimit.Limiter) (*Server, error) {
+	tokenMaker, err := token.NewJWTMaker(config.TokenSymmetricKey)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create token maker: %w", err)
+	}
+
+	trustedProxies, err := parseTrustedProxies(config.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+
+	server := &Server{
+		config:         config,
+		store:          store,
+		tokenMaker:     tokenMaker,
+		risk:           risk.NewEngine(risk.DefaultRules()...),
+		limiter:        limiter,
+		trustedProxies: trustedProxies,
+	}
+
+	return server, nil
+}
+
+// GRPCServer creates a grpc.Server serving the API behind the tracing, logging, error mapping, rate limiting,
+// auth and permission interceptors
+func (server *Server) GRPCServer() *grpc.Server {
+	grpcServer := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(
+			requestLogger(),
+			errorMapper(),
+			ipRateLimiter(server.limiter, server.trustedProxies),
+			authInterceptor(server.tokenMaker, server.trustedProxies),
+			permissionInterceptor(),
+			userRateLimiter(server.limiter),
+		),
+	)
+
+	pb.RegisterSimpleBankServiceServer(grpcServer, server)
+	reflection.Register(grpcServer)
+
+	return grpcServer
+}
@@ -0,0 +1,111 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	db "simplebank/db/sqlc"
+	"simplebank/pb"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// CreateTransfer moves money out of an account of the caller
+func (server *Server) CreateTransfer(ctx context.Context, req *pb.CreateTransferRequest) (*pb.CreateTransferResponse, error) {
+	if err := validateID("from_account_id", req.GetFromAccountId()); err != nil {
+		return nil, err
+	}
+	if err := validateID("to_account_id", req.GetToAccountId()); err != nil {
+		return nil, err
+	}
+	if req.GetAmount() <= 0 {
+		return nil, invalidArgument("amount", errors.New("must be greater than 0"))
+	}
+	if err := validateCurrency(req.GetCurrency()); err != nil {
+		return nil, err
+	}
+
+	if req.GetFromAccountId() == req.GetToAccountId() {
+		return nil, status.Error(codes.InvalidArgument, "cannot transfer to the same account")
+	}
+
+	fromAccount, err := server.customerAccount(ctx, req.GetFromAccountId(), req.GetCurrency())
+	if err != nil {
+		return nil, err
+	}
+
+	if fromAccount.Owner != authPayload(ctx).Username {
+		return nil, status.Error(codes.PermissionDenied, "from account doesn't belong to the authenticated user")
+	}
+
+	if _, err := server.customerAccount(ctx, req.GetToAccountId(), req.GetCurrency()); err != nil {
+		return nil, err
+	}
+
+	screened, err := server.store.ScreenTransferTx(ctx, server.risk, db.ScreenTransferTxParams{
+		TransferCreateParams: db.TransferCreateParams{
+			FromAccountID: req.GetFromAccountId(),
+			ToAccountID:   req.GetToAccountId(),
+			Amount:        req.GetAmount(),
+		},
+		Currency: req.GetCurrency(),
+		Owner:    fromAccount.Owner,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrTransferLimitExceeded):
+			return nil, status.Error(codes.ResourceExhausted, err.Error())
+		case errors.Is(err, db.ErrTransferBlocked):
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		case errors.Is(err, db.ErrInsufficientFunds), errors.Is(err, db.ErrAccountNotActive), errors.Is(err, db.ErrCurrencyMismatch):
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+		return nil, err
+	}
+
+	if screened.Transfer == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "transfer held for review as risk assessment %d", screened.Assessment.ID)
+	}
+	result := *screened.Transfer
+
+	rsp := &pb.CreateTransferResponse{
+		Transfer:    convertTransfer(result.Transfer),
+		FromAccount: convertAccount(result.FromAccount),
+		ToAccount:   convertAccount(result.ToAccount),
+		FromEntry:   convertEntry(result.FromEntrie),
+		ToEntry:     convertEntry(result.ToEntrie),
+	}
+
+	return rsp, nil
+}
+
+// validAccount checks that the account exists and holds the given currency
+func (server *Server) validAccount(ctx context.Context, accountID int64, currency string) (db.Account, error) {
+	account, err := server.store.GetAccount(ctx, accountID)
+	if err != nil {
+		return account, err
+	}
+
+	if account.Currency != currency {
+		err := fmt.Errorf("account [%d] currency mismatch: %s vs %s", account.ID, account.Currency, currency)
+		return account, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return account, nil
+}
+
+// customerAccount checks that the account is a valid customer account, loan and internal accounts
+// only move through the bank's own postings and cannot be a leg of a customer transfer
+func (server *Server) customerAccount(ctx context.Context, accountID int64, currency string) (db.Account, error) {
+	account, err := server.validAccount(ctx, accountID, currency)
+	if err != nil {
+		return account, err
+	}
+
+	if !db.CustomerProduct(account.Product) {
+		return account, status.Errorf(codes.PermissionDenied, "account [%d] is not a customer account", account.ID)
+	}
+
+	return account, nil
+}
@@ -0,0 +1,206 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	db "simplebank/db/sqlc"
+	"simplebank/pb"
+	"simplebank/util"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// CreateUser registers a new user
+func (server *Server) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.CreateUserResponse, error) {
+	if err := validateUsername(req.GetUsername()); err != nil {
+		return nil, err
+	}
+	if err := validatePassword(req.GetPassword()); err != nil {
+		return nil, err
+	}
+	if req.GetFullName() == "" {
+		return nil, invalidArgument("full_name", errors.New("is required"))
+	}
+	if err := validateEmail(req.GetEmail()); err != nil {
+		return nil, err
+	}
+
+	hashedPassword, err := util.HashPassword(req.GetPassword())
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := server.store.CreateUser(ctx, db.CreateUserParams{
+		Username:       req.GetUsername(),
+		HashedPassword: hashedPassword,
+		FullName:       req.GetFullName(),
+		Email:          req.GetEmail(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.CreateUserResponse{User: convertUser(user)}, nil
+}
+
+// LoginUser opens a session and returns an access and a refresh token
+func (server *Server) LoginUser(ctx context.Context, req *pb.LoginUserRequest) (*pb.LoginUserResponse, error) {
+	if err := validateUsername(req.GetUsername()); err != nil {
+		return nil, err
+	}
+	if err := validatePassword(req.GetPassword()); err != nil {
+		return nil, err
+	}
+
+	// the lockout is keyed by the client IP too, so a third party guessing passwords cannot lock the user out
+	mtdt := extractMetadata(ctx, server.trustedProxies)
+
+	failure, err := server.store.GetLoginFailure(ctx, db.GetLoginFailureParams{
+		Username: req.GetUsername(),
+		ClientIp: mtdt.ClientIP,
+	})
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	if failure.LockedUntil.Valid && failure.LockedUntil.Time.After(time.Now()) {
+		return nil, status.Errorf(codes.PermissionDenied, "too many failed logins from this address until %s", failure.LockedUntil.Time.Format(time.RFC3339))
+	}
+
+	user, err := server.store.GetUser(ctx, req.GetUsername())
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, server.failLogin(ctx, req.GetUsername(), mtdt.ClientIP)
+		}
+		return nil, err
+	}
+
+	if err := util.CheckPassword(req.GetPassword(), user.HashedPassword); err != nil {
+		return nil, server.failLogin(ctx, req.GetUsername(), mtdt.ClientIP)
+	}
+
+	if failure.Username != "" {
+		err := server.store.ResetFailedLogins(ctx, db.ResetFailedLoginsParams{
+			Username: user.Username,
+			ClientIp: mtdt.ClientIP,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	accessToken, accessPayload, err := server.tokenMaker.CreateToken(user.Username, user.Role, server.config.AccessTokenDuration)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, refreshPayload, err := server.tokenMaker.CreateToken(user.Username, user.Role, server.config.RefreshTokenDuration)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := server.store.CreateSession(ctx, db.CreateSessionParams{
+		ID:           refreshPayload.ID,
+		Username:     user.Username,
+		RefreshToken: refreshToken,
+		UserAgent:    mtdt.UserAgent,
+		ClientIp:     mtdt.ClientIP,
+		IsBlocked:    false,
+		ExpiresAt:    refreshPayload.ExpiredAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rsp := &pb.LoginUserResponse{
+		SessionId:             session.ID.String(),
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  timestamppb.New(accessPayload.ExpiredAt),
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: timestamppb.New(refreshPayload.ExpiredAt),
+		User:                  convertUser(user),
+	}
+
+	return rsp, nil
+}
+
+// failLogin returns the same error for an unknown username and a wrong password; every LoginMaxFailures
+// failed logins of a username from a client IP in a row lock them out for LoginLockoutDuration, 0 turns it off
+func (server *Server) failLogin(ctx context.Context, username string, clientIP string) error {
+	if server.config.LoginMaxFailures > 0 {
+		_, err := server.store.RecordFailedLogin(ctx, db.RecordFailedLoginParams{
+			Username:    username,
+			ClientIp:    clientIP,
+			MaxFailures: int32(server.config.LoginMaxFailures),
+			LockUntil:   time.Now().Add(server.config.LoginLockoutDuration),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return status.Error(codes.Unauthenticated, "invalid username or password")
+}
+
+// RenewAccessToken exchanges the refresh token of a live session for a new access token
+func (server *Server) RenewAccessToken(ctx context.Context, req *pb.RenewAccessTokenRequest) (*pb.RenewAccessTokenResponse, error) {
+	if req.GetRefreshToken() == "" {
+		return nil, invalidArgument("refresh_token", errors.New("is required"))
+	}
+
+	refreshPayload, err := server.tokenMaker.VerifyToken(req.GetRefreshToken())
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	session, err := server.store.GetSession(ctx, refreshPayload.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, status.Error(codes.Unauthenticated, "session not found")
+		}
+
+		return nil, err
+	}
+
+	if err := checkSession(session, refreshPayload.Username, req.GetRefreshToken()); err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	// the role is read again so that a changed role applies from the next renewal
+	user, err := server.store.GetUser(ctx, session.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, accessPayload, err := server.tokenMaker.CreateToken(user.Username, user.Role, server.config.AccessTokenDuration)
+	if err != nil {
+		return nil, err
+	}
+
+	rsp := &pb.RenewAccessTokenResponse{
+		AccessToken:          accessToken,
+		AccessTokenExpiresAt: timestamppb.New(accessPayload.ExpiredAt),
+	}
+
+	return rsp, nil
+}
+
+func checkSession(session db.Session, username string, refreshToken string) error {
+	switch {
+	case session.IsBlocked:
+		return fmt.Errorf("blocked session")
+	case session.Username != username:
+		return fmt.Errorf("incorrect session user")
+	case session.RefreshToken != refreshToken:
+		return fmt.Errorf("mismatched session token")
+	case time.Now().After(session.ExpiresAt):
+		return fmt.Errorf("expired session")
+	}
+
+	return nil
+}
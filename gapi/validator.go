@@ -0,0 +1,72 @@
+package gapi
+
+import (
+	"fmt"
+	"net/mail"
+	"regexp"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// the rules mirror the binding tags of the HTTP API
+var (
+	isAlphanumeric      = regexp.MustCompile(`^[a-zA-Z0-9]+$`).MatchString
+	supportedCurrencies = map[string]bool{"USD": true, "EUR": true}
+	minPasswordLength   = 6
+	minAccountsPageSize = int32(5)
+	maxAccountsPageSize = int32(10)
+	maxEntriesPageSize  = int32(100)
+)
+
+func invalidArgument(field string, err error) error {
+	return status.Errorf(codes.InvalidArgument, "invalid %s: %v", field, err)
+}
+
+func validateUsername(username string) error {
+	if !isAlphanumeric(username) {
+		return invalidArgument("username", fmt.Errorf("must contain only letters and digits"))
+	}
+
+	return nil
+}
+
+func validatePassword(password string) error {
+	if len(password) < minPasswordLength {
+		return invalidArgument("password", fmt.Errorf("must be at least %d characters", minPasswordLength))
+	}
+
+	return nil
+}
+
+func validateEmail(email string) error {
+	if _, err := mail.ParseAddress(email); err != nil {
+		return invalidArgument("email", err)
+	}
+
+	return nil
+}
+
+func validateCurrency(currency string) error {
+	if !supportedCurrencies[currency] {
+		return invalidArgument("currency", fmt.Errorf("unsupported currency %q", currency))
+	}
+
+	return nil
+}
+
+func validateID(field string, id int64) error {
+	if id < 1 {
+		return invalidArgument(field, fmt.Errorf("must be at least 1"))
+	}
+
+	return nil
+}
+
+func validatePageSize(pageSize int32, min int32, max int32) error {
+	if pageSize < min || pageSize > max {
+		return invalidArgument("page_size", fmt.Errorf("must be between %d and %d", min, max))
+	}
+
+	return nil
+}
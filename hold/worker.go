@@ -0,0 +1,81 @@
+// Package hold expires the holds nobody captured or voided in time
+package hold
+
+import (
+	"context"
+	"log/slog"
+	db "simplebank/db/sqlc"
+	"simplebank/logging"
+	"time"
+)
+
+// defaultBatchSize bounds how many holds a worker expires before polling again
+const defaultBatchSize = 100
+
+// RunResult counts the holds a worker expired
+type RunResult struct {
+	Expired int
+}
+
+// Worker releases the funds of expired holds, replicas can run side by side
+type Worker struct {
+	store     *db.Store
+	interval  time.Duration
+	batchSize int
+	now       func() time.Time
+}
+
+// NewWorker creates a worker looking for expired holds every interval
+func NewWorker(store *db.Store, interval time.Duration) *Worker {
+	return &Worker{
+		store:     store,
+		interval:  interval,
+		batchSize: defaultBatchSize,
+		now:       time.Now,
+	}
+}
+
+// Run expires holds until ctx is done
+func (w *Worker) Run(ctx context.Context) error {
+	logger := logging.FromContext(ctx).With(slog.String("worker", "hold_expiry"))
+	ctx = logging.WithLogger(ctx, logger)
+
+	for {
+		result, err := w.RunOnce(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		if err != nil {
+			logger.ErrorContext(ctx, "hold expiry failed", slog.Any("error", err))
+		} else if result.Expired > 0 {
+			logger.InfoContext(ctx, "holds expired", slog.Int("expired", result.Expired))
+		}
+
+		if err == nil && result.Expired == w.batchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(w.interval):
+		}
+	}
+}
+
+// RunOnce expires holds one per tx until none is left or the batch size is reached
+func (w *Worker) RunOnce(ctx context.Context) (RunResult, error) {
+	var result RunResult
+
+	for result.Expired < w.batchSize {
+		_, found, err := w.store.ExpireHoldTx(ctx, w.now())
+		if err != nil || !found {
+			return result, err
+		}
+
+		result.Expired++
+	}
+
+	return result, nil
+}
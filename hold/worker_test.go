@@ -0,0 +1,107 @@
+package hold
+
+import (
+	"context"
+	db "simplebank/db/sqlc"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createAccount(t *testing.T, balance int64) db.Account {
+	user, err := testStore.CreateUser(context.Background(), db.CreateUserParams{
+		Username:       util.RandomOwner(),
+		HashedPassword: "secret",
+		FullName:       util.RandomOwner(),
+		Email:          util.RandomEmail(),
+	})
+	require.NoError(t, err)
+
+	account, err := testStore.CreateAccountTx(context.Background(), db.CreateAccountParams{
+		Owner:    user.Username,
+		Balance:  balance,
+		Currency: "USD",
+	})
+	require.NoError(t, err)
+
+	return account
+}
+
+func authorizeHold(t *testing.T, from db.Account, to db.Account, amount int64, expiresAt time.Time) db.Hold {
+	result, err := testStore.AuthorizeHoldTx(context.Background(), db.AuthorizeHoldTxParams{
+		AccountID:   from.ID,
+		ToAccountID: to.ID,
+		Amount:      amount,
+		Currency:    "USD",
+		ExpiresAt:   expiresAt,
+	})
+	require.NoError(t, err)
+
+	return result.Hold
+}
+
+// runAll runs the worker until no expired hold is left
+func runAll(t *testing.T) {
+	worker := NewWorker(testStore, time.Second)
+
+	for {
+		result, err := worker.RunOnce(context.Background())
+		require.NoError(t, err)
+
+		if result.Expired < worker.batchSize {
+			return
+		}
+	}
+}
+
+func TestWorkerExpiresHolds(t *testing.T) {
+	from := createAccount(t, 100)
+	to := createAccount(t, 0)
+
+	expiring := authorizeHold(t, from, to, 30, time.Now().Add(-time.Minute))
+	later := authorizeHold(t, from, to, 20, time.Now().Add(time.Hour))
+
+	runAll(t)
+
+	hold, err := testStore.GetHold(context.Background(), expiring.ID)
+	require.NoError(t, err)
+	require.Equal(t, db.HoldExpired, hold.Status)
+
+	hold, err = testStore.GetHold(context.Background(), later.ID)
+	require.NoError(t, err)
+	require.Equal(t, db.HoldAuthorized, hold.Status)
+
+	account, err := testStore.GetAccount(context.Background(), from.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(100), account.Balance)
+	require.Equal(t, int64(20), account.HeldBalance)
+	require.Equal(t, int64(80), account.AvailableBalance)
+}
+
+func TestWorkerExpiresAsTimePasses(t *testing.T) {
+	from := createAccount(t, 100)
+	to := createAccount(t, 0)
+
+	hold := authorizeHold(t, from, to, 30, time.Now().Add(time.Hour))
+
+	worker := NewWorker(testStore, time.Second)
+	worker.now = func() time.Time { return hold.ExpiresAt.Add(time.Second) }
+
+	for {
+		result, err := worker.RunOnce(context.Background())
+		require.NoError(t, err)
+
+		expired, err := testStore.GetHold(context.Background(), hold.ID)
+		require.NoError(t, err)
+		if expired.Status == db.HoldExpired {
+			break
+		}
+		require.Equal(t, worker.batchSize, result.Expired, "hold not expired")
+	}
+
+	account, err := testStore.GetAccount(context.Background(), from.ID)
+	require.NoError(t, err)
+	require.Zero(t, account.HeldBalance)
+}
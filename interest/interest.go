@@ -0,0 +1,89 @@
+// Package interest computes the interest accrued on balances by day-count convention
+package interest
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// basisPoints is 100%
+const basisPoints = 10000
+
+// MicrosPerUnit is how many accrual micros make one minor unit, accruals keep the fractions of a cent
+const MicrosPerUnit = 1_000_000
+
+// DayCount is the convention that counts the days of interest in a year
+type DayCount string
+
+// Day-count conventions
+const (
+	// Actual365 counts every calendar day over a 365-day year
+	Actual365 DayCount = "ACT/365"
+	// Thirty360 counts 30 days in every month over a 360-day year
+	Thirty360 DayCount = "30/360"
+)
+
+// ParseDayCount checks that s is a known convention
+func ParseDayCount(s string) (DayCount, error) {
+	switch dc := DayCount(s); dc {
+	case Actual365, Thirty360:
+		return dc, nil
+	default:
+		return "", fmt.Errorf("unknown day count %q", s)
+	}
+}
+
+// Fraction returns the part of a year the day is worth as days over days in the year
+// under 30/360 the 31st is worth nothing and the last day of February makes up the month to 30 days
+func (dc DayCount) Fraction(day time.Time) (days int64, year int64) {
+	if dc != Thirty360 {
+		return 1, 365
+	}
+
+	switch {
+	case day.Day() == 31:
+		return 0, 360
+	case day.Month() == time.February && day.AddDate(0, 0, 1).Month() == time.March:
+		return int64(30 - day.Day() + 1), 360
+	default:
+		return 1, 360
+	}
+}
+
+// Accrue returns the interest in micros a balance earns over a day at the annual rate in basis points,
+// rounded half up; a balance that is not positive earns nothing
+func Accrue(balance int64, rateBps int32, dc DayCount, day time.Time) int64 {
+	if balance <= 0 || rateBps <= 0 {
+		return 0
+	}
+
+	days, year := dc.Fraction(day)
+
+	num := big.NewInt(balance)
+	num.Mul(num, big.NewInt(int64(rateBps)))
+	num.Mul(num, big.NewInt(days))
+	num.Mul(num, big.NewInt(MicrosPerUnit))
+
+	den := big.NewInt(basisPoints * year)
+
+	num.Add(num, new(big.Int).Rsh(den, 1))
+	return num.Quo(num, den).Int64()
+}
+
+// Split returns the whole minor units of micros and the fraction left over
+func Split(micros int64) (amount int64, carry int64) {
+	return micros / MicrosPerUnit, micros % MicrosPerUnit
+}
+
+// DayStart truncates t to the start of its UTC day
+func DayStart(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// MonthStart truncates t to the start of its UTC month
+func MonthStart(t time.Time) time.Time {
+	y, m, _ := t.UTC().Date()
+	return time.Date(y, m, 1, 0, 0, 0, 0, time.UTC)
+}
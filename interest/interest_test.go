@@ -0,0 +1,104 @@
+package interest
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestFraction(t *testing.T) {
+	testCases := []struct {
+		name string
+		dc   DayCount
+		day  time.Time
+		days int64
+		year int64
+	}{
+		{name: "Actual365", dc: Actual365, day: date(2026, time.March, 31), days: 1, year: 365},
+		{name: "Actual365LeapDay", dc: Actual365, day: date(2024, time.February, 29), days: 1, year: 365},
+		{name: "Thirty360", dc: Thirty360, day: date(2026, time.March, 15), days: 1, year: 360},
+		{name: "Thirty360ThirtyFirst", dc: Thirty360, day: date(2026, time.March, 31), days: 0, year: 360},
+		{name: "Thirty360EndOfFebruary", dc: Thirty360, day: date(2026, time.February, 28), days: 3, year: 360},
+		{name: "Thirty360LeapYearFebruary28", dc: Thirty360, day: date(2024, time.February, 28), days: 1, year: 360},
+		{name: "Thirty360LeapDay", dc: Thirty360, day: date(2024, time.February, 29), days: 2, year: 360},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			days, year := tc.dc.Fraction(tc.day)
+			require.Equal(t, tc.days, days)
+			require.Equal(t, tc.year, year)
+		})
+	}
+}
+
+func TestThirty360MonthsHaveThirtyDays(t *testing.T) {
+	for _, year := range []int{2024, 2026} {
+		for month := time.January; month <= time.December; month++ {
+			var total int64
+			for day := date(year, month, 1); day.Month() == month; day = day.AddDate(0, 0, 1) {
+				days, _ := Thirty360.Fraction(day)
+				total += days
+			}
+			require.Equal(t, int64(30), total, "%d-%02d", year, month)
+		}
+	}
+}
+
+func TestAccrue(t *testing.T) {
+	day := date(2026, time.March, 15)
+
+	testCases := []struct {
+		name    string
+		balance int64
+		rateBps int32
+		dc      DayCount
+		micros  int64
+	}{
+		{name: "Actual365", balance: 100000, rateBps: 500, dc: Actual365, micros: 13698630},
+		{name: "RoundsHalfUp", balance: 9, rateBps: 1, dc: Thirty360, micros: 3},
+		{name: "Thirty360", balance: 360000, rateBps: 1000, dc: Thirty360, micros: 100 * MicrosPerUnit},
+		{name: "ZeroBalance", balance: 0, rateBps: 500, dc: Actual365, micros: 0},
+		{name: "NegativeBalance", balance: -100000, rateBps: 500, dc: Actual365, micros: 0},
+		{name: "ZeroRate", balance: 100000, rateBps: 0, dc: Actual365, micros: 0},
+		{name: "LargeBalance", balance: math.MaxInt64 / MicrosPerUnit, rateBps: 10000, dc: Actual365, micros: 25269512429736986},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.micros, Accrue(tc.balance, tc.rateBps, tc.dc, day))
+		})
+	}
+}
+
+func TestAccrueThirty360ThirtyFirst(t *testing.T) {
+	require.Zero(t, Accrue(100000, 500, Thirty360, date(2026, time.March, 31)))
+}
+
+func TestSplit(t *testing.T) {
+	amount, carry := Split(13698630 * 31)
+	require.Equal(t, int64(424), amount)
+	require.Equal(t, int64(657530), carry)
+}
+
+func TestParseDayCount(t *testing.T) {
+	dc, err := ParseDayCount("30/360")
+	require.NoError(t, err)
+	require.Equal(t, Thirty360, dc)
+
+	_, err = ParseDayCount("ACT/360")
+	require.Error(t, err)
+}
+
+func TestDayAndMonthStart(t *testing.T) {
+	at := time.Date(2026, time.March, 15, 23, 30, 0, 0, time.FixedZone("UTC-2", -2*60*60))
+
+	require.Equal(t, date(2026, time.March, 16), DayStart(at))
+	require.Equal(t, date(2026, time.March, 1), MonthStart(at))
+}
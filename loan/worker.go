@@ -0,0 +1,82 @@
+// Package loan charges late fees on the loan installments left unpaid past their grace days
+package loan
+
+import (
+	"context"
+	"log/slog"
+	db "simplebank/db/sqlc"
+	"simplebank/logging"
+	"time"
+)
+
+// defaultBatchSize bounds how many installments a worker charges before polling again
+const defaultBatchSize = 100
+
+// RunResult counts the installments a worker found overdue
+type RunResult struct {
+	Overdue int
+}
+
+// Worker finds overdue installments, replicas can run side by side
+type Worker struct {
+	store     *db.Store
+	interval  time.Duration
+	batchSize int
+	now       func() time.Time
+}
+
+// NewWorker creates a worker looking for overdue installments every interval
+func NewWorker(store *db.Store, interval time.Duration) *Worker {
+	return &Worker{
+		store:     store,
+		interval:  interval,
+		batchSize: defaultBatchSize,
+		now:       time.Now,
+	}
+}
+
+// Run charges overdue installments until ctx is done
+func (w *Worker) Run(ctx context.Context) error {
+	logger := logging.FromContext(ctx).With(slog.String("worker", "loan_overdue"))
+	ctx = logging.WithLogger(ctx, logger)
+
+	for {
+		result, err := w.RunOnce(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		if err != nil {
+			logger.ErrorContext(ctx, "loan overdue check failed", slog.Any("error", err))
+		} else if result.Overdue > 0 {
+			logger.InfoContext(ctx, "loan installments overdue", slog.Int("overdue", result.Overdue))
+		}
+
+		if err == nil && result.Overdue == w.batchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(w.interval):
+		}
+	}
+}
+
+// RunOnce charges the late fee on installments one per tx until none is overdue or the batch size is reached
+func (w *Worker) RunOnce(ctx context.Context) (RunResult, error) {
+	var result RunResult
+	today := w.now().UTC().Truncate(24 * time.Hour)
+
+	for result.Overdue < w.batchSize {
+		_, found, err := w.store.ChargeOverdueLoanInstallmentTx(ctx, today)
+		if err != nil || !found {
+			return result, err
+		}
+
+		result.Overdue++
+	}
+
+	return result, nil
+}
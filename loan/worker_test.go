@@ -0,0 +1,93 @@
+package loan
+
+import (
+	"context"
+	"simplebank/amortization"
+	db "simplebank/db/sqlc"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createAccount(t *testing.T) db.Account {
+	user, err := testStore.CreateUser(context.Background(), db.CreateUserParams{
+		Username:       util.RandomOwner(),
+		HashedPassword: "secret",
+		FullName:       util.RandomOwner(),
+		Email:          util.RandomEmail(),
+	})
+	require.NoError(t, err)
+
+	account, err := testStore.CreateAccountTx(context.Background(), db.CreateAccountParams{
+		Owner:    user.Username,
+		Currency: "USD",
+	})
+	require.NoError(t, err)
+
+	return account
+}
+
+func createLoan(t *testing.T, firstDue time.Time, graceDays int32) db.LoanTxResult {
+	borrower := createAccount(t)
+	income := createAccount(t)
+
+	result, err := testStore.CreateLoanTx(context.Background(), db.CreateLoanTxParams{
+		BorrowerAccountID: borrower.ID,
+		IncomeAccountID:   income.ID,
+		Principal:         1000,
+		RateBps:           1200,
+		TermMonths:        3,
+		Method:            amortization.StraightLine,
+		LateFee:           250,
+		GraceDays:         graceDays,
+		FirstDueDate:      firstDue,
+		CreatedBy:         borrower.Owner,
+	})
+	require.NoError(t, err)
+
+	return result
+}
+
+func runAll(t *testing.T, now time.Time) {
+	worker := NewWorker(testStore, time.Second)
+	worker.now = func() time.Time { return now }
+
+	for {
+		result, err := worker.RunOnce(context.Background())
+		require.NoError(t, err)
+
+		if result.Overdue < worker.batchSize {
+			return
+		}
+	}
+}
+
+func TestWorkerChargesLateFees(t *testing.T) {
+	now := time.Now()
+	today := now.UTC().Truncate(24 * time.Hour)
+
+	overdue := createLoan(t, today.AddDate(0, 0, -5), 3)
+	inGrace := createLoan(t, today.AddDate(0, 0, -2), 3)
+
+	runAll(t, now)
+
+	installments, err := testStore.ListLoanInstallments(context.Background(), overdue.Loan.ID)
+	require.NoError(t, err)
+	require.Equal(t, db.LoanInstallmentOverdue, installments[0].Status)
+	require.Equal(t, int64(250), installments[0].Penalty)
+	require.Equal(t, db.LoanInstallmentPending, installments[1].Status)
+
+	installments, err = testStore.ListLoanInstallments(context.Background(), inGrace.Loan.ID)
+	require.NoError(t, err)
+	require.Equal(t, db.LoanInstallmentPending, installments[0].Status)
+	require.Zero(t, installments[0].Penalty)
+
+	// the late fee is charged once
+	runAll(t, now)
+
+	installments, err = testStore.ListLoanInstallments(context.Background(), overdue.Loan.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(250), installments[0].Penalty)
+}
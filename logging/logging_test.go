@@ -0,0 +1,45 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactSecrets(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, slog.LevelInfo)
+
+	logger.Info("login",
+		slog.String("username", "alice"),
+		slog.String("password", "secret123"),
+		slog.String("access_token", "v2.local.abc"),
+		slog.Int64("amount", 10),
+	)
+
+	var line map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+
+	require.Equal(t, "alice", line["username"])
+	require.Equal(t, RedactedValue, line["password"])
+	require.Equal(t, RedactedValue, line["access_token"])
+	require.Equal(t, float64(10), line["amount"])
+	require.NotContains(t, buf.String(), "secret123")
+}
+
+func TestLoggerContext(t *testing.T) {
+	ctx := context.Background()
+	require.Equal(t, slog.Default(), FromContext(ctx))
+	require.Empty(t, RequestIDFromContext(ctx))
+
+	logger := New(&bytes.Buffer{}, slog.LevelInfo)
+	ctx = WithLogger(ctx, logger)
+	ctx = WithRequestID(ctx, "req-1")
+
+	require.Equal(t, logger, FromContext(ctx))
+	require.Equal(t, "req-1", RequestIDFromContext(ctx))
+}
@@ -0,0 +1,104 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	db "simplebank/db/sqlc"
+	"simplebank/event"
+	"simplebank/logging"
+	"strconv"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	// notifyChannel is the channel the outbox_events_notify trigger notifies with the id of each event
+	notifyChannel = "outbox_events"
+	// listenerPingInterval checks an idle listener connection is still alive
+	listenerPingInterval = 90 * time.Second
+)
+
+// Listener feeds a hub with every event inserted into the outbox by any process,
+// so the live streams of a process do not depend on which process relays the events
+type Listener struct {
+	store    *db.Store
+	dbSource string
+	hub      *event.Hub
+}
+
+// NewListener creates a listener feeding hub with the events notified on dbSource
+func NewListener(store *db.Store, dbSource string, hub *event.Hub) *Listener {
+	return &Listener{
+		store:    store,
+		dbSource: dbSource,
+		hub:      hub,
+	}
+}
+
+// Run listens until ctx is done
+// the notifications sent while the connection was lost are gone, so on reconnect every subscriber
+// of the hub is dropped as lagged and catches up from the outbox
+func (listener *Listener) Run(ctx context.Context) error {
+	logger := logging.FromContext(ctx).With(slog.String("worker", "outbox_listener"))
+	ctx = logging.WithLogger(ctx, logger)
+
+	l := pq.NewListener(listener.dbSource, time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			logger.ErrorContext(ctx, "outbox listener connection failed", slog.Any("error", err))
+		}
+	})
+	defer l.Close()
+
+	// Listen waits for the connection, closing the listener stops the wait
+	stop := context.AfterFunc(ctx, func() { l.Close() })
+	defer stop()
+
+	if err := l.Listen(notifyChannel); err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case n := <-l.Notify:
+			if n == nil {
+				listener.hub.DropAll()
+				continue
+			}
+
+			if err := listener.publish(ctx, n.Extra); err != nil {
+				logger.ErrorContext(ctx, "outbox listener failed", slog.Any("error", err))
+			}
+		case <-time.After(listenerPingInterval):
+			go l.Ping()
+		}
+	}
+}
+
+// publish hands the event notified with payload to the hub
+func (listener *Listener) publish(ctx context.Context, payload string) error {
+	id, err := strconv.ParseInt(payload, 10, 64)
+	if err != nil {
+		return err
+	}
+
+	row, err := listener.store.GetOutboxEvent(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+
+		// the subscribers would miss the event, they catch up from the outbox instead
+		listener.hub.DropAll()
+		return err
+	}
+
+	return listener.hub.Publish(ctx, row.Event())
+}
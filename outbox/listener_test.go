@@ -0,0 +1,61 @@
+package outbox
+
+import (
+	"context"
+	db "simplebank/db/sqlc"
+	"simplebank/event"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListener(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	hub := event.NewHub()
+	sub := hub.Subscribe(account2.ID, 10)
+	defer sub.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	listener := NewListener(testStore, dbSource, hub)
+	done := make(chan error)
+	go func() {
+		done <- listener.Run(ctx)
+	}()
+
+	// nothing tells when the listener is listening, so transfer until an entry of account2 is fed to the hub
+	var received event.Event
+	deadline := time.After(10 * time.Second)
+	for received.ID == 0 {
+		_, err := testStore.TransferTX(context.Background(), db.TransferCreateParams{
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+			Amount:        1,
+		})
+		require.NoError(t, err)
+
+		select {
+		case received = <-sub.C():
+		case <-time.After(100 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("no event fed to the hub")
+		}
+	}
+
+	// the event reaches the hub without any relay running
+	require.Equal(t, account2.ID, received.AccountID)
+	require.Equal(t, event.EntryPosted, received.Type)
+	require.NoError(t, event.Validate(received))
+
+	row, err := testStore.GetOutboxEvent(context.Background(), received.ID)
+	require.NoError(t, err)
+	require.False(t, row.PublishedAt.Valid)
+
+	cancel()
+	require.NoError(t, <-done)
+	require.False(t, sub.Lagged())
+}
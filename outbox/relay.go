@@ -0,0 +1,71 @@
+// Package outbox publishes the events recorded in the outbox table
+package outbox
+
+import (
+	"context"
+	"log/slog"
+	db "simplebank/db/sqlc"
+	"simplebank/event"
+	"simplebank/logging"
+	"time"
+)
+
+// defaultBatchSize bounds how many events a relay locks and publishes per tx
+const defaultBatchSize = 100
+
+// Relay moves committed outbox events to a Publisher
+type Relay struct {
+	store     *db.Store
+	publisher event.Publisher
+	interval  time.Duration
+	batchSize int32
+}
+
+// NewRelay creates a relay polling the outbox every interval
+func NewRelay(store *db.Store, publisher event.Publisher, interval time.Duration) *Relay {
+	return &Relay{
+		store:     store,
+		publisher: publisher,
+		interval:  interval,
+		batchSize: defaultBatchSize,
+	}
+}
+
+// Run relays events until ctx is done
+// a full batch is followed immediately by the next one, otherwise the relay waits for the next poll
+func (relay *Relay) Run(ctx context.Context) error {
+	logger := logging.FromContext(ctx).With(slog.String("worker", "outbox_relay"))
+	ctx = logging.WithLogger(ctx, logger)
+
+	for {
+		result, err := relay.RelayOnce(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		if err != nil {
+			logger.ErrorContext(ctx, "outbox relay failed", slog.Any("error", err))
+		} else if result.Published > 0 || result.Failed > 0 {
+			logger.InfoContext(ctx, "outbox relayed",
+				slog.Int("published", result.Published),
+				slog.Int("failed", result.Failed),
+				slog.Int("skipped", result.Skipped),
+			)
+		}
+
+		if err == nil && result.Published == int(relay.batchSize) {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(relay.interval):
+		}
+	}
+}
+
+// RelayOnce publishes one batch of events
+func (relay *Relay) RelayOnce(ctx context.Context) (db.RelayOutboxTxResult, error) {
+	return relay.store.RelayOutboxTx(ctx, relay.batchSize, relay.publisher.Publish)
+}
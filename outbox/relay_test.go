@@ -0,0 +1,123 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	db "simplebank/db/sqlc"
+	"simplebank/event"
+	"simplebank/util"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomAccount(t *testing.T) db.Account {
+	user, err := testStore.CreateUser(context.Background(), db.CreateUserParams{
+		Username:       util.RandomOwner(),
+		HashedPassword: "secret",
+		FullName:       util.RandomOwner(),
+		Email:          util.RandomEmail(),
+	})
+	require.NoError(t, err)
+
+	account, err := testStore.CreateAccountTx(context.Background(), db.CreateAccountParams{
+		Owner:    user.Username,
+		Balance:  100,
+		Currency: "USD",
+	})
+	require.NoError(t, err)
+
+	return account
+}
+
+// recorder collects the events of the watched accounts, failing the first delivery of each event once
+type recorder struct {
+	mu       sync.Mutex
+	accounts map[int64]bool
+	attempts map[int64]int
+	events   map[int64][]event.Event
+}
+
+func (r *recorder) Publish(ctx context.Context, e event.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.accounts[e.AccountID] {
+		return nil
+	}
+
+	r.attempts[e.ID]++
+	if r.attempts[e.ID] == 1 {
+		return errors.New("subscriber unavailable")
+	}
+
+	r.events[e.AccountID] = append(r.events[e.AccountID], e)
+	return nil
+}
+
+func (r *recorder) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := 0
+	for _, events := range r.events {
+		n += len(events)
+	}
+	return n
+}
+
+func TestRelay(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	for i := 0; i < 3; i++ {
+		_, err := testStore.TransferTX(context.Background(), db.TransferCreateParams{
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+			Amount:        10,
+		})
+		require.NoError(t, err)
+	}
+
+	rec := &recorder{
+		accounts: map[int64]bool{account1.ID: true, account2.ID: true},
+		attempts: make(map[int64]int),
+		events:   make(map[int64][]event.Event),
+	}
+
+	bus := event.NewBus()
+	bus.Subscribe(rec)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	relay := NewRelay(testStore, bus, 10*time.Millisecond)
+	done := make(chan error)
+	go func() {
+		done <- relay.Run(ctx)
+	}()
+
+	// account1: created + 3 transfers and 3 entries, account2: created + 3 entries
+	require.Eventually(t, func() bool {
+		return rec.count() == 11
+	}, 10*time.Second, 10*time.Millisecond)
+
+	cancel()
+	require.NoError(t, <-done)
+
+	for accountID, events := range rec.events {
+		require.Equal(t, event.AccountCreated, events[0].Type)
+
+		for i := 1; i < len(events); i++ {
+			require.Equal(t, accountID, events[i].AccountID)
+			require.Greater(t, events[i].ID, events[i-1].ID)
+		}
+
+		for _, e := range events {
+			require.NoError(t, event.Validate(e))
+			require.Equal(t, 2, rec.attempts[e.ID])
+		}
+	}
+}
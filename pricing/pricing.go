@@ -0,0 +1,111 @@
+// Package pricing computes the fees charged on transfers from pricing rules
+package pricing
+
+// basisPoints is 100%
+const basisPoints = 10000
+
+// Rule prices the transfers of an account tier and currency whose amount falls in a band
+// a tiered price is several rules with consecutive bands
+type Rule struct {
+	ID int64
+	// Tier is the account tier the rule applies to, empty for every tier
+	Tier     string
+	Currency string
+	// MinAmount and MaxAmount bound the amounts the rule applies to, MaxAmount is exclusive and 0 for no bound
+	MinAmount int64
+	MaxAmount int64
+	FlatFee   int64
+	// PercentBps is the part of the amount charged, in basis points
+	PercentBps int32
+	// MinFee and MaxFee bound the fee, MaxFee is 0 for no cap
+	MinFee int64
+	MaxFee int64
+	// FeeAccountID is the revenue account credited with the fee
+	FeeAccountID int64
+	Priority     int32
+}
+
+// Fee is the breakdown of the fee of a transfer
+type Fee struct {
+	RuleID       int64 `json:"rule_id"`
+	FeeAccountID int64 `json:"fee_account_id"`
+	Flat         int64 `json:"flat"`
+	Percentage   int64 `json:"percentage"`
+	// Amount is the fee charged on top of the transfer: Flat plus Percentage within the bounds of the rule
+	Amount int64 `json:"amount"`
+}
+
+// Matches reports whether the rule applies to a transfer
+func (rule Rule) Matches(tier string, currency string, amount int64) bool {
+	return (rule.Tier == "" || rule.Tier == tier) &&
+		rule.Currency == currency &&
+		amount >= rule.MinAmount &&
+		(rule.MaxAmount == 0 || amount < rule.MaxAmount)
+}
+
+// Fee computes the fee of amount, the percentage is rounded half up
+func (rule Rule) Fee(amount int64) Fee {
+	bps := int64(rule.PercentBps)
+
+	// split the amount so that large amounts cannot overflow
+	percentage := amount/basisPoints*bps + (amount%basisPoints*bps+basisPoints/2)/basisPoints
+
+	fee := Fee{
+		RuleID:       rule.ID,
+		FeeAccountID: rule.FeeAccountID,
+		Flat:         rule.FlatFee,
+		Percentage:   percentage,
+		Amount:       rule.FlatFee + percentage,
+	}
+
+	if fee.Amount < rule.MinFee {
+		fee.Amount = rule.MinFee
+	}
+	if rule.MaxFee > 0 && fee.Amount > rule.MaxFee {
+		fee.Amount = rule.MaxFee
+	}
+
+	return fee
+}
+
+// Match returns the rule pricing a transfer: the matching rule with the highest priority,
+// then the one for the tier over the one for every tier, then the oldest
+func Match(rules []Rule, tier string, currency string, amount int64) (Rule, bool) {
+	var best Rule
+	found := false
+
+	for _, rule := range rules {
+		if !rule.Matches(tier, currency, amount) {
+			continue
+		}
+
+		if !found || outranks(rule, best) {
+			best = rule
+			found = true
+		}
+	}
+
+	return best, found
+}
+
+func outranks(rule Rule, other Rule) bool {
+	if rule.Priority != other.Priority {
+		return rule.Priority > other.Priority
+	}
+
+	if (rule.Tier != "") != (other.Tier != "") {
+		return rule.Tier != ""
+	}
+
+	return rule.ID < other.ID
+}
+
+// Quote prices a transfer, it is free when no rule matches
+func Quote(rules []Rule, tier string, currency string, amount int64) Fee {
+	rule, ok := Match(rules, tier, currency, amount)
+	if !ok {
+		return Fee{}
+	}
+
+	return rule.Fee(amount)
+}
@@ -0,0 +1,107 @@
+package pricing
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRuleFee(t *testing.T) {
+	testCases := []struct {
+		name   string
+		rule   Rule
+		amount int64
+		fee    Fee
+	}{
+		{
+			name:   "Flat",
+			rule:   Rule{ID: 1, FeeAccountID: 9, FlatFee: 25},
+			amount: 1000,
+			fee:    Fee{RuleID: 1, FeeAccountID: 9, Flat: 25, Amount: 25},
+		},
+		{
+			name:   "Percentage",
+			rule:   Rule{PercentBps: 150},
+			amount: 1000,
+			fee:    Fee{Percentage: 15, Amount: 15},
+		},
+		{
+			name:   "PercentageRoundsHalfUp",
+			rule:   Rule{PercentBps: 150},
+			amount: 100,
+			fee:    Fee{Percentage: 2, Amount: 2},
+		},
+		{
+			name:   "FlatAndPercentage",
+			rule:   Rule{FlatFee: 30, PercentBps: 290},
+			amount: 10000,
+			fee:    Fee{Flat: 30, Percentage: 290, Amount: 320},
+		},
+		{
+			name:   "MinFee",
+			rule:   Rule{PercentBps: 100, MinFee: 50},
+			amount: 1000,
+			fee:    Fee{Percentage: 10, Amount: 50},
+		},
+		{
+			name:   "MaxFee",
+			rule:   Rule{PercentBps: 100, MaxFee: 500},
+			amount: 1000000,
+			fee:    Fee{Percentage: 10000, Amount: 500},
+		},
+		{
+			name:   "LargeAmount",
+			rule:   Rule{PercentBps: 10000},
+			amount: math.MaxInt64 / 2,
+			fee:    Fee{Percentage: math.MaxInt64 / 2, Amount: math.MaxInt64 / 2},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.fee, tc.rule.Fee(tc.amount))
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	rules := []Rule{
+		// tiered USD pricing for every tier
+		{ID: 1, Currency: "USD", MaxAmount: 1000, FlatFee: 10},
+		{ID: 2, Currency: "USD", MinAmount: 1000, MaxAmount: 100000, PercentBps: 100},
+		{ID: 3, Currency: "USD", MinAmount: 100000, PercentBps: 50},
+		// premium accounts pay less
+		{ID: 4, Tier: "premium", Currency: "USD", PercentBps: 10},
+		// a promotion outranks everything
+		{ID: 5, Currency: "EUR", MinAmount: 500, Priority: 1},
+		{ID: 6, Tier: "premium", Currency: "EUR", FlatFee: 5},
+	}
+
+	testCases := []struct {
+		name     string
+		tier     string
+		currency string
+		amount   int64
+		ruleID   int64
+	}{
+		{name: "FirstBand", tier: "standard", currency: "USD", amount: 999, ruleID: 1},
+		{name: "BandLowerBoundIsInclusive", tier: "standard", currency: "USD", amount: 1000, ruleID: 2},
+		{name: "OpenBand", tier: "standard", currency: "USD", amount: 5000000, ruleID: 3},
+		{name: "TierOverEveryTier", tier: "premium", currency: "USD", amount: 5000, ruleID: 4},
+		{name: "PriorityOverTier", tier: "premium", currency: "EUR", amount: 500, ruleID: 5},
+		{name: "OutsidePriorityBand", tier: "premium", currency: "EUR", amount: 100, ruleID: 6},
+		{name: "NoRule", tier: "standard", currency: "EUR", amount: 100},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rule, ok := Match(rules, tc.tier, tc.currency, tc.amount)
+			require.Equal(t, tc.ruleID != 0, ok)
+			require.Equal(t, tc.ruleID, rule.ID)
+		})
+	}
+
+	require.Equal(t, Fee{}, Quote(rules, "standard", "EUR", 100))
+	require.Equal(t, int64(50), Quote(rules, "standard", "USD", 5000).Amount)
+}
@@ -0,0 +1,57 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// pruneInterval bounds how often a store drops the buckets that are full again, a full bucket is the same as none
+const pruneInterval = time.Minute
+
+// MemoryStore keeps the buckets in memory, every replica limits its callers on its own
+type MemoryStore struct {
+	mu       sync.Mutex
+	buckets  map[string]time.Time
+	prunedAt time.Time
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]time.Time),
+	}
+}
+
+// Take takes a token from the bucket of key when one is left
+func (store *MemoryStore) Take(ctx context.Context, key string, policy Policy, now time.Time) (time.Time, bool, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if now.Sub(store.prunedAt) >= pruneInterval {
+		store.prune(now)
+	}
+
+	fullAt := store.buckets[key]
+	if fullAt.Before(now) {
+		fullAt = now
+	}
+
+	next := fullAt.Add(policy.interval())
+	if next.Sub(now) > policy.Period {
+		return fullAt, false, nil
+	}
+
+	store.buckets[key] = next
+	return next, true, nil
+}
+
+func (store *MemoryStore) prune(now time.Time) {
+	for key, fullAt := range store.buckets {
+		if fullAt.Before(now) {
+			delete(store.buckets, key)
+		}
+	}
+
+	store.prunedAt = now
+}
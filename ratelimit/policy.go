@@ -0,0 +1,20 @@
+package ratelimit
+
+import "time"
+
+// The policies of the HTTP and the gRPC API, a method takes a token from the same bucket as the matching route
+// so that a caller switching between the two gets no more requests through
+// every authenticated call takes a token from DefaultPolicy and some take one from a stricter policy of their own as well
+var (
+	DefaultPolicy        = Policy{Name: "default", Limit: 300, Period: time.Minute}
+	ListPolicy           = Policy{Name: "list", Limit: 60, Period: time.Minute}
+	CreateAccountPolicy  = Policy{Name: "accounts.create", Limit: 10, Period: time.Hour}
+	CreateTransferPolicy = Policy{Name: "transfers.create", Limit: 60, Period: time.Minute}
+	// the public calls are limited by client IP
+	CreateUserPolicy = Policy{Name: "users.create", Limit: 5, Period: time.Hour}
+	LoginPolicy      = Policy{Name: "users.login", Limit: 10, Period: time.Minute}
+	RenewTokenPolicy = Policy{Name: "tokens.renew", Limit: 30, Period: time.Minute}
+	// IPPolicy limits every call of a client IP before its credentials are checked,
+	// so that guessing tokens or API keys is throttled too; it is above DefaultPolicy so that one user does not hit it first
+	IPPolicy = Policy{Name: "ip", Limit: 600, Period: time.Minute}
+)
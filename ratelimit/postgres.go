@@ -0,0 +1,64 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	db "simplebank/db/sqlc"
+	"simplebank/logging"
+	"sync"
+	"time"
+)
+
+// PostgresStore keeps the buckets in Postgres so that every replica limits a caller with the same bucket,
+// a token is taken in a single statement
+type PostgresStore struct {
+	store *db.Store
+
+	mu       sync.Mutex
+	prunedAt time.Time
+}
+
+// NewPostgresStore creates a store keeping the buckets in the rate_limit_buckets table
+func NewPostgresStore(store *db.Store) *PostgresStore {
+	return &PostgresStore{store: store}
+}
+
+// Take takes a token from the bucket of key when one is left
+func (store *PostgresStore) Take(ctx context.Context, key string, policy Policy, now time.Time) (time.Time, bool, error) {
+	store.pruneEvery(ctx, now)
+
+	fullAt, err := store.store.TakeRateLimitToken(ctx, db.TakeRateLimitTokenParams{
+		Key:        key,
+		Now:        now,
+		IntervalUs: policy.interval().Microseconds(),
+		PeriodUs:   policy.Period.Microseconds(),
+	})
+	if err == nil {
+		return fullAt, true, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return fullAt, false, err
+	}
+
+	// the bucket is empty, the update was skipped
+	fullAt, err = store.store.GetRateLimitBucket(ctx, key)
+	return fullAt, false, err
+}
+
+// pruneEvery drops the buckets full again at most once per pruneInterval on this replica
+func (store *PostgresStore) pruneEvery(ctx context.Context, now time.Time) {
+	store.mu.Lock()
+	if now.Sub(store.prunedAt) < pruneInterval {
+		store.mu.Unlock()
+		return
+	}
+	store.prunedAt = now
+	store.mu.Unlock()
+
+	// a bucket left behind only costs a row, it must not fail the request
+	if _, err := store.store.DeleteFullRateLimitBuckets(ctx, now); err != nil {
+		logging.FromContext(ctx).WarnContext(ctx, "rate limit buckets not pruned", slog.Any("error", err))
+	}
+}
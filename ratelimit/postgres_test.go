@@ -0,0 +1,46 @@
+package ratelimit
+
+import (
+	"context"
+	"simplebank/util"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgresStore(t *testing.T) {
+	policy := Policy{Name: "test", Limit: 5, Period: time.Minute}
+	limiter := NewLimiter(NewPostgresStore(testStore))
+	key := util.RandomString(12)
+
+	// replicas share the bucket, concurrent requests take exactly Limit tokens
+	var wg sync.WaitGroup
+	allowed := make(chan bool, 2*policy.Limit)
+	for i := 0; i < 2*policy.Limit; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			decision, err := limiter.Allow(context.Background(), key, policy)
+			require.NoError(t, err)
+			allowed <- decision.Allowed
+		}()
+	}
+	wg.Wait()
+	close(allowed)
+
+	taken := 0
+	for ok := range allowed {
+		if ok {
+			taken++
+		}
+	}
+	require.Equal(t, policy.Limit, taken)
+
+	decision, err := limiter.Allow(context.Background(), key, policy)
+	require.NoError(t, err)
+	require.False(t, decision.Allowed)
+	require.Positive(t, decision.RetryAfter)
+	require.LessOrEqual(t, decision.RetryAfter, policy.interval())
+}
@@ -0,0 +1,97 @@
+// Package ratelimit limits how fast callers hit the API with token buckets shared by every replica or kept in memory
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	db "simplebank/db/sqlc"
+	"time"
+)
+
+// Policy is a token bucket holding Limit tokens and refilled with Limit tokens every Period,
+// each request takes a token so a caller can burst Limit requests and then keep up Limit requests per Period
+type Policy struct {
+	// Name keeps apart the buckets of the policies a caller is limited by
+	Name   string
+	Limit  int
+	Period time.Duration
+}
+
+// interval is how long the bucket takes to refill one token
+func (policy Policy) interval() time.Duration {
+	return policy.Period / time.Duration(policy.Limit)
+}
+
+// Store keeps the buckets; a bucket is kept as the time it will be full again, taking a token pushes it back
+// by the refill interval of a token and a bucket whose full time is more than Period away has no token left
+type Store interface {
+	// Take takes a token from the bucket of key when one is left, it returns the time the bucket will be full again
+	// and whether a token was taken
+	Take(ctx context.Context, key string, policy Policy, now time.Time) (fullAt time.Time, taken bool, err error)
+}
+
+// NewStore picks the store named by the config, the Postgres store shares the buckets between replicas
+func NewStore(name string, store *db.Store) (Store, error) {
+	switch name {
+	case "", "memory":
+		return NewMemoryStore(), nil
+	case "postgres":
+		return NewPostgresStore(store), nil
+	default:
+		return nil, fmt.Errorf("unknown rate limit store %q", name)
+	}
+}
+
+// Decision is what a caller is told about its bucket after a request
+type Decision struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// RetryAfter is how long a refused caller waits for the next token
+	RetryAfter time.Duration
+	// ResetAfter is how long the bucket takes to be full again
+	ResetAfter time.Duration
+}
+
+// Limiter takes tokens from the buckets of a store
+type Limiter struct {
+	store Store
+	now   func() time.Time
+}
+
+// NewLimiter creates a limiter keeping its buckets in store
+func NewLimiter(store Store) *Limiter {
+	return &Limiter{
+		store: store,
+		now:   time.Now,
+	}
+}
+
+// Allow takes a token from the bucket of key for policy and reports whether the request is allowed
+func (limiter *Limiter) Allow(ctx context.Context, key string, policy Policy) (Decision, error) {
+	now := limiter.now()
+
+	fullAt, taken, err := limiter.store.Take(ctx, policy.Name+":"+key, policy, now)
+	if err != nil {
+		return Decision{}, err
+	}
+
+	return decide(policy, fullAt, taken, now), nil
+}
+
+func decide(policy Policy, fullAt time.Time, taken bool, now time.Time) Decision {
+	decision := Decision{
+		Allowed:    taken,
+		Limit:      policy.Limit,
+		ResetAfter: max(fullAt.Sub(now), 0),
+	}
+
+	if !taken {
+		// the next token is there once the bucket is one interval short of Period from full
+		decision.RetryAfter = max(fullAt.Sub(now)-policy.Period+policy.interval(), 0)
+		return decision
+	}
+
+	decision.Remaining = int((policy.Period - decision.ResetAfter) / policy.interval())
+	return decision
+}
@@ -0,0 +1,94 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiter(t *testing.T) {
+	policy := Policy{Name: "test", Limit: 3, Period: 3 * time.Second}
+
+	store := NewMemoryStore()
+	limiter := NewLimiter(store)
+
+	now := time.Now()
+	limiter.now = func() time.Time { return now }
+
+	// a fresh bucket lets Limit requests through at once
+	for remaining := 2; remaining >= 0; remaining-- {
+		decision, err := limiter.Allow(context.Background(), "alice", policy)
+		require.NoError(t, err)
+		require.True(t, decision.Allowed)
+		require.Equal(t, 3, decision.Limit)
+		require.Equal(t, remaining, decision.Remaining)
+	}
+
+	decision, err := limiter.Allow(context.Background(), "alice", policy)
+	require.NoError(t, err)
+	require.False(t, decision.Allowed)
+	require.Zero(t, decision.Remaining)
+	require.Equal(t, time.Second, decision.RetryAfter)
+	require.Equal(t, 3*time.Second, decision.ResetAfter)
+
+	// another caller has its own bucket
+	decision, err = limiter.Allow(context.Background(), "bob", policy)
+	require.NoError(t, err)
+	require.True(t, decision.Allowed)
+
+	// and so has another policy
+	decision, err = limiter.Allow(context.Background(), "alice", Policy{Name: "other", Limit: 1, Period: time.Minute})
+	require.NoError(t, err)
+	require.True(t, decision.Allowed)
+
+	// a token is refilled every Period / Limit
+	now = now.Add(time.Second)
+	decision, err = limiter.Allow(context.Background(), "alice", policy)
+	require.NoError(t, err)
+	require.True(t, decision.Allowed)
+	require.Zero(t, decision.Remaining)
+
+	decision, err = limiter.Allow(context.Background(), "alice", policy)
+	require.NoError(t, err)
+	require.False(t, decision.Allowed)
+
+	// an idle bucket fills up to Limit, never more
+	now = now.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		decision, err = limiter.Allow(context.Background(), "alice", policy)
+		require.NoError(t, err)
+		require.True(t, decision.Allowed)
+	}
+
+	decision, err = limiter.Allow(context.Background(), "alice", policy)
+	require.NoError(t, err)
+	require.False(t, decision.Allowed)
+}
+
+func TestMemoryStorePrunesFullBuckets(t *testing.T) {
+	policy := Policy{Name: "test", Limit: 10, Period: time.Second}
+	store := NewMemoryStore()
+
+	now := time.Now()
+	for _, key := range []string{"a", "b", "c"} {
+		_, taken, err := store.Take(context.Background(), key, policy, now)
+		require.NoError(t, err)
+		require.True(t, taken)
+	}
+	require.Len(t, store.buckets, 3)
+
+	_, _, err := store.Take(context.Background(), "d", policy, now.Add(pruneInterval))
+	require.NoError(t, err)
+	require.Len(t, store.buckets, 1)
+}
+
+func TestNewStore(t *testing.T) {
+	_, err := NewStore("redis", nil)
+	require.EqualError(t, err, `unknown rate limit store "redis"`)
+
+	store, err := NewStore("", nil)
+	require.NoError(t, err)
+	require.IsType(t, &MemoryStore{}, store)
+}
@@ -0,0 +1,102 @@
+// Package rbac holds the roles of the users and the permissions each role is granted
+package rbac
+
+// Roles a user can hold
+const (
+	RoleAdmin    = "admin"
+	RoleSupport  = "support"
+	RoleCustomer = "customer"
+)
+
+// Permission names an action a route requires
+type Permission string
+
+// Permissions, the ones without a suffix act on the resources of the caller only
+const (
+	// AccountsRead reads the accounts of the caller and everything hanging off them
+	AccountsRead Permission = "accounts:read"
+	// AccountsWrite opens accounts for the caller
+	AccountsWrite Permission = "accounts:write"
+	// AccountsReadAny reads any account, whoever owns it
+	AccountsReadAny Permission = "accounts:read_any"
+	// AccountsList lists the accounts of every owner
+	AccountsList Permission = "accounts:list"
+	// AccountsFreeze freezes and unfreezes any account
+	AccountsFreeze Permission = "accounts:freeze"
+	// TransfersWrite moves money out of the accounts of the caller: transfers, holds, batches, repayments
+	TransfersWrite Permission = "transfers:write"
+	// WebhooksManage manages the webhook subscriptions of the caller
+	WebhooksManage Permission = "webhooks:manage"
+	// APIKeysManage creates and revokes the API keys of the caller, no API key can be granted it
+	APIKeysManage Permission = "api_keys:manage"
+	// BalancesAdjust posts adjustments and closes accounting periods
+	BalancesAdjust Permission = "balances:adjust"
+	// LimitsManage sets transfer limits
+	LimitsManage Permission = "limits:manage"
+	// ProductsManage sets interest rates and opens loans
+	ProductsManage Permission = "products:manage"
+	// LedgerRead reads the trial balance, the internal accounts and the closed periods
+	LedgerRead Permission = "ledger:read"
+	// RiskReview reviews the transfers held by risk screening
+	RiskReview Permission = "risk:review"
+	// AuditRead reads the audit log
+	AuditRead Permission = "audit:read"
+	// UsersManage changes the roles of users
+	UsersManage Permission = "users:manage"
+)
+
+var customerPermissions = []Permission{
+	AccountsRead,
+	AccountsWrite,
+	TransfersWrite,
+	WebhooksManage,
+	APIKeysManage,
+}
+
+// matrix grants each role its permissions, an admin is granted everything support is and more
+var matrix = map[string]map[Permission]bool{
+	RoleCustomer: grant(customerPermissions),
+	RoleSupport: grant(customerPermissions,
+		AccountsReadAny,
+		AccountsFreeze,
+	),
+	RoleAdmin: grant(customerPermissions,
+		AccountsReadAny,
+		AccountsFreeze,
+		AccountsList,
+		BalancesAdjust,
+		LimitsManage,
+		ProductsManage,
+		LedgerRead,
+		RiskReview,
+		AuditRead,
+		UsersManage,
+	),
+}
+
+func grant(base []Permission, extra ...Permission) map[Permission]bool {
+	granted := make(map[Permission]bool, len(base)+len(extra))
+	for _, permission := range base {
+		granted[permission] = true
+	}
+	for _, permission := range extra {
+		granted[permission] = true
+	}
+	return granted
+}
+
+// ValidPermission reports whether permission is one of the permissions, an admin is granted every one
+func ValidPermission(permission Permission) bool {
+	return matrix[RoleAdmin][permission]
+}
+
+// ValidRole reports whether role is one of the roles
+func ValidRole(role string) bool {
+	_, ok := matrix[role]
+	return ok
+}
+
+// Can reports whether role is granted permission, an unknown role is granted nothing
+func Can(role string, permission Permission) bool {
+	return matrix[role][permission]
+}
@@ -0,0 +1,41 @@
+package rbac
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPermissionMatrix(t *testing.T) {
+	testCases := []struct {
+		permission Permission
+		customer   bool
+		support    bool
+		admin      bool
+	}{
+		{permission: AccountsRead, customer: true, support: true, admin: true},
+		{permission: TransfersWrite, customer: true, support: true, admin: true},
+		{permission: APIKeysManage, customer: true, support: true, admin: true},
+		{permission: AccountsReadAny, customer: false, support: true, admin: true},
+		{permission: AccountsFreeze, customer: false, support: true, admin: true},
+		{permission: AccountsList, customer: false, support: false, admin: true},
+		{permission: BalancesAdjust, customer: false, support: false, admin: true},
+		{permission: LimitsManage, customer: false, support: false, admin: true},
+		{permission: UsersManage, customer: false, support: false, admin: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(string(tc.permission), func(t *testing.T) {
+			require.Equal(t, tc.customer, Can(RoleCustomer, tc.permission))
+			require.Equal(t, tc.support, Can(RoleSupport, tc.permission))
+			require.Equal(t, tc.admin, Can(RoleAdmin, tc.permission))
+			require.False(t, Can("", tc.permission))
+		})
+	}
+
+	require.True(t, ValidRole(RoleSupport))
+	require.False(t, ValidRole("root"))
+
+	require.True(t, ValidPermission(AccountsRead))
+	require.False(t, ValidPermission("accounts:delete"))
+}
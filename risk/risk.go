@@ -0,0 +1,125 @@
+// Package risk screens transfers with pluggable rules before they are posted
+package risk
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Decision is what to do with a screened transfer
+type Decision string
+
+// Decisions from the mildest to the most severe
+const (
+	Allow  Decision = "allow"
+	Review Decision = "review"
+	Block  Decision = "block"
+)
+
+func (decision Decision) severity() int {
+	switch decision {
+	case Review:
+		return 1
+	case Block:
+		return 2
+	}
+
+	return 0
+}
+
+// Transfer is the transfer being screened
+type Transfer struct {
+	FromAccountID int64
+	ToAccountID   int64
+	Amount        int64
+	Currency      string
+	// Owner is the user moving the money
+	Owner string
+	At    time.Time
+}
+
+// History answers what rules ask about the past of the accounts and users
+type History interface {
+	// TransfersBetween counts the transfers from one account to another made since a time
+	TransfersBetween(ctx context.Context, fromAccountID int64, toAccountID int64, since time.Time) (int64, error)
+	// TransfersFrom counts the transfers out of an account made since a time
+	TransfersFrom(ctx context.Context, accountID int64, since time.Time) (int64, error)
+	// PasswordChangedAt is when the user last changed password, zero when never
+	PasswordChangedAt(ctx context.Context, username string) (time.Time, error)
+}
+
+// Hit is a rule that fired on a transfer
+type Hit struct {
+	Rule     string   `json:"rule"`
+	Score    int      `json:"score"`
+	Decision Decision `json:"decision"`
+	Reason   string   `json:"reason"`
+}
+
+// Rule screens a transfer, it returns nil when it does not fire
+type Rule interface {
+	Name() string
+	Evaluate(ctx context.Context, transfer Transfer, history History) (*Hit, error)
+}
+
+// Assessment is the outcome of screening a transfer
+type Assessment struct {
+	Decision Decision `json:"decision"`
+	// Score sums the scores of the hits
+	Score int   `json:"score"`
+	Hits  []Hit `json:"hits"`
+}
+
+// Scores from which the hits of a transfer together hold it for review or block it
+const (
+	DefaultReviewScore = 50
+	DefaultBlockScore  = 100
+)
+
+// Engine runs every rule on a transfer, the most severe decision of the hits wins unless their scores
+// together reach the review or block score
+type Engine struct {
+	rules       []Rule
+	reviewScore int
+	blockScore  int
+}
+
+// NewEngine creates an engine running rules with the default scores
+func NewEngine(rules ...Rule) *Engine {
+	return &Engine{
+		rules:       rules,
+		reviewScore: DefaultReviewScore,
+		blockScore:  DefaultBlockScore,
+	}
+}
+
+// Assess runs every rule on transfer
+func (engine *Engine) Assess(ctx context.Context, transfer Transfer, history History) (Assessment, error) {
+	assessment := Assessment{Decision: Allow, Hits: []Hit{}}
+
+	for _, rule := range engine.rules {
+		hit, err := rule.Evaluate(ctx, transfer, history)
+		if err != nil {
+			return assessment, fmt.Errorf("risk rule %s: %w", rule.Name(), err)
+		}
+		if hit == nil {
+			continue
+		}
+
+		assessment.Hits = append(assessment.Hits, *hit)
+		assessment.Score += hit.Score
+		if hit.Decision.severity() > assessment.Decision.severity() {
+			assessment.Decision = hit.Decision
+		}
+	}
+
+	switch {
+	case assessment.Score >= engine.blockScore:
+		assessment.Decision = Block
+	case assessment.Score >= engine.reviewScore && assessment.Decision == Allow:
+		assessment.Decision = Review
+	}
+
+	return assessment, nil
+}
@@ -0,0 +1,170 @@
+package risk
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type pastTransfer struct {
+	from int64
+	to   int64
+	at   time.Time
+}
+
+// fakeHistory answers from an in-memory list of transfers
+type fakeHistory struct {
+	transfers         []pastTransfer
+	passwordChangedAt time.Time
+	err               error
+}
+
+func (history fakeHistory) TransfersBetween(_ context.Context, from int64, to int64, since time.Time) (int64, error) {
+	var count int64
+	for _, transfer := range history.transfers {
+		if transfer.from == from && transfer.to == to && !transfer.at.Before(since) {
+			count++
+		}
+	}
+	return count, history.err
+}
+
+func (history fakeHistory) TransfersFrom(_ context.Context, from int64, since time.Time) (int64, error) {
+	var count int64
+	for _, transfer := range history.transfers {
+		if transfer.from == from && !transfer.at.Before(since) {
+			count++
+		}
+	}
+	return count, history.err
+}
+
+func (history fakeHistory) PasswordChangedAt(context.Context, string) (time.Time, error) {
+	return history.passwordChangedAt, history.err
+}
+
+func TestEngineAssess(t *testing.T) {
+	now := time.Date(2024, time.May, 1, 12, 0, 0, 0, time.UTC)
+	engine := NewEngine(DefaultRules()...)
+
+	burst := make([]pastTransfer, 5)
+	for i := range burst {
+		burst[i] = pastTransfer{from: 1, to: 2, at: now.Add(-time.Duration(i) * time.Second)}
+	}
+
+	testCases := []struct {
+		name     string
+		amount   int64
+		history  fakeHistory
+		decision Decision
+		rules    []string
+	}{
+		{
+			name:     "KnownPayee",
+			amount:   500000,
+			history:  fakeHistory{transfers: []pastTransfer{{from: 1, to: 2, at: now.AddDate(0, -1, 0)}}},
+			decision: Allow,
+		},
+		{
+			name:     "NewPayeeSmallAmount",
+			amount:   100,
+			decision: Allow,
+		},
+		{
+			name:     "NewPayeeLargeAmount",
+			amount:   100000,
+			decision: Review,
+			rules:    []string{"new_payee_large_amount"},
+		},
+		{
+			name:     "RapidFire",
+			amount:   10,
+			history:  fakeHistory{transfers: burst},
+			decision: Review,
+			rules:    []string{"rapid_fire"},
+		},
+		{
+			name:     "RoundTripAlone",
+			amount:   10,
+			history:  fakeHistory{transfers: []pastTransfer{{from: 2, to: 1, at: now.Add(-time.Hour)}}},
+			decision: Allow,
+			rules:    []string{"round_trip"},
+		},
+		{
+			name:   "RoundTripAfterPasswordChange",
+			amount: 10,
+			history: fakeHistory{
+				transfers:         []pastTransfer{{from: 2, to: 1, at: now.Add(-time.Hour)}},
+				passwordChangedAt: now.Add(-time.Hour),
+			},
+			decision: Review,
+			rules:    []string{"round_trip", "recent_password_change"},
+		},
+		{
+			name:     "NewPayeeLargeAmountAfterPasswordChange",
+			amount:   200000,
+			history:  fakeHistory{passwordChangedAt: now.Add(-time.Minute)},
+			decision: Block,
+			rules:    []string{"new_payee_large_amount", "recent_password_change"},
+		},
+		{
+			name:     "OldPasswordChange",
+			amount:   10,
+			history:  fakeHistory{passwordChangedAt: now.AddDate(0, 0, -2)},
+			decision: Allow,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assessment, err := engine.Assess(context.Background(), Transfer{
+				FromAccountID: 1,
+				ToAccountID:   2,
+				Amount:        tc.amount,
+				Currency:      "USD",
+				Owner:         "alice",
+				At:            now,
+			}, tc.history)
+			require.NoError(t, err)
+			require.Equal(t, tc.decision, assessment.Decision)
+
+			rules := []string{}
+			score := 0
+			for _, hit := range assessment.Hits {
+				rules = append(rules, hit.Rule)
+				score += hit.Score
+				require.NotEmpty(t, hit.Reason)
+			}
+			if tc.rules == nil {
+				tc.rules = []string{}
+			}
+			require.Equal(t, tc.rules, rules)
+			require.Equal(t, score, assessment.Score)
+		})
+	}
+}
+
+type blockingRule struct{}
+
+func (blockingRule) Name() string { return "blocking" }
+
+func (blockingRule) Evaluate(context.Context, Transfer, History) (*Hit, error) {
+	return &Hit{Rule: "blocking", Decision: Block, Reason: "always"}, nil
+}
+
+func TestEngineAssessCustomRule(t *testing.T) {
+	assessment, err := NewEngine(blockingRule{}).Assess(context.Background(), Transfer{}, fakeHistory{})
+	require.NoError(t, err)
+	require.Equal(t, Block, assessment.Decision)
+	require.Zero(t, assessment.Score)
+}
+
+func TestEngineAssessHistoryError(t *testing.T) {
+	history := fakeHistory{err: errors.New("db down")}
+
+	_, err := NewEngine(DefaultRules()...).Assess(context.Background(), Transfer{At: time.Now()}, history)
+	require.ErrorIs(t, err, history.err)
+}
@@ -0,0 +1,118 @@
+package risk
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// DefaultRules are the rules the API screens transfers with
+func DefaultRules() []Rule {
+	return []Rule{
+		NewPayeeLargeAmount{MinAmount: 100000, Score: 60},
+		RapidFire{MaxTransfers: 5, Window: time.Minute, Score: 50},
+		RoundTrip{Window: 24 * time.Hour, Score: 30},
+		RecentPasswordChange{Window: 24 * time.Hour, Score: 40},
+	}
+}
+
+// NewPayeeLargeAmount holds a transfer of at least MinAmount to an account the from account never paid
+type NewPayeeLargeAmount struct {
+	MinAmount int64
+	Score     int
+}
+
+func (rule NewPayeeLargeAmount) Name() string {
+	return "new_payee_large_amount"
+}
+
+func (rule NewPayeeLargeAmount) Evaluate(ctx context.Context, transfer Transfer, history History) (*Hit, error) {
+	if transfer.Amount < rule.MinAmount {
+		return nil, nil
+	}
+
+	count, err := history.TransfersBetween(ctx, transfer.FromAccountID, transfer.ToAccountID, time.Time{})
+	if err != nil || count > 0 {
+		return nil, err
+	}
+
+	return &Hit{
+		Rule:     rule.Name(),
+		Score:    rule.Score,
+		Decision: Review,
+		Reason:   fmt.Sprintf("first transfer to account %d is %d, at least %d", transfer.ToAccountID, transfer.Amount, rule.MinAmount),
+	}, nil
+}
+
+// RapidFire holds a transfer when the from account already made MaxTransfers within Window
+type RapidFire struct {
+	MaxTransfers int64
+	Window       time.Duration
+	Score        int
+}
+
+func (rule RapidFire) Name() string {
+	return "rapid_fire"
+}
+
+func (rule RapidFire) Evaluate(ctx context.Context, transfer Transfer, history History) (*Hit, error) {
+	count, err := history.TransfersFrom(ctx, transfer.FromAccountID, transfer.At.Add(-rule.Window))
+	if err != nil || count < rule.MaxTransfers {
+		return nil, err
+	}
+
+	return &Hit{
+		Rule:     rule.Name(),
+		Score:    rule.Score,
+		Decision: Review,
+		Reason:   fmt.Sprintf("%d transfers out of account %d in the last %s", count, transfer.FromAccountID, rule.Window),
+	}, nil
+}
+
+// RoundTrip scores a transfer sending money back to an account that paid the from account within Window
+type RoundTrip struct {
+	Window time.Duration
+	Score  int
+}
+
+func (rule RoundTrip) Name() string {
+	return "round_trip"
+}
+
+func (rule RoundTrip) Evaluate(ctx context.Context, transfer Transfer, history History) (*Hit, error) {
+	count, err := history.TransfersBetween(ctx, transfer.ToAccountID, transfer.FromAccountID, transfer.At.Add(-rule.Window))
+	if err != nil || count == 0 {
+		return nil, err
+	}
+
+	return &Hit{
+		Rule:     rule.Name(),
+		Score:    rule.Score,
+		Decision: Allow,
+		Reason:   fmt.Sprintf("account %d paid account %d in the last %s", transfer.ToAccountID, transfer.FromAccountID, rule.Window),
+	}, nil
+}
+
+// RecentPasswordChange scores a transfer made within Window of a password change, a takeover often starts with one
+type RecentPasswordChange struct {
+	Window time.Duration
+	Score  int
+}
+
+func (rule RecentPasswordChange) Name() string {
+	return "recent_password_change"
+}
+
+func (rule RecentPasswordChange) Evaluate(ctx context.Context, transfer Transfer, history History) (*Hit, error) {
+	changedAt, err := history.PasswordChangedAt(ctx, transfer.Owner)
+	if err != nil || changedAt.IsZero() || transfer.At.Sub(changedAt) > rule.Window {
+		return nil, err
+	}
+
+	return &Hit{
+		Rule:     rule.Name(),
+		Score:    rule.Score,
+		Decision: Allow,
+		Reason:   fmt.Sprintf("password changed at %s", changedAt.UTC().Format(time.RFC3339)),
+	}, nil
+}
@@ -0,0 +1,146 @@
+// Package schedule computes the occurrences of scheduled transfers
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequency is the unit a Rule repeats in
+type Frequency string
+
+// Supported frequencies
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+// maxInterval keeps rules within a sensible range
+const maxInterval = 1000
+
+// maxPeriod is an upper bound of one step of each frequency, used to skip ahead without overshooting
+var maxPeriod = map[Frequency]time.Duration{
+	Daily:   25 * time.Hour,
+	Weekly:  7*24*time.Hour + time.Hour,
+	Monthly: 31*24*time.Hour + time.Hour,
+	Yearly:  366*24*time.Hour + time.Hour,
+}
+
+// ErrInvalidRule is returned for recurrences outside the supported RRULE subset
+var ErrInvalidRule = errors.New("invalid recurrence rule")
+
+// Rule repeats every Interval units of Freq, it is the FREQ and INTERVAL subset of an RFC 5545 RRULE
+// occurrences are anchored on the start so that e.g. a monthly rule started on the 31st runs on the
+// last day of shorter months and back on the 31st afterwards
+type Rule struct {
+	Freq     Frequency
+	Interval int
+}
+
+// Parse reads a rule such as FREQ=MONTHLY;INTERVAL=1, with or without the RRULE: prefix
+func Parse(s string) (Rule, error) {
+	rule := Rule{Interval: 1}
+	seen := map[string]bool{}
+
+	for _, part := range strings.Split(strings.TrimPrefix(strings.TrimSpace(s), "RRULE:"), ";") {
+		key, value, ok := strings.Cut(part, "=")
+		key = strings.ToUpper(strings.TrimSpace(key))
+		value = strings.ToUpper(strings.TrimSpace(value))
+
+		if !ok || value == "" || seen[key] {
+			return Rule{}, fmt.Errorf("%w: malformed part %q", ErrInvalidRule, part)
+		}
+		seen[key] = true
+
+		switch key {
+		case "FREQ":
+			rule.Freq = Frequency(value)
+			if _, ok := maxPeriod[rule.Freq]; !ok {
+				return Rule{}, fmt.Errorf("%w: unsupported FREQ %s", ErrInvalidRule, value)
+			}
+		case "INTERVAL":
+			interval, err := strconv.Atoi(value)
+			if err != nil || interval < 1 || interval > maxInterval {
+				return Rule{}, fmt.Errorf("%w: INTERVAL must be between 1 and %d", ErrInvalidRule, maxInterval)
+			}
+			rule.Interval = interval
+		default:
+			return Rule{}, fmt.Errorf("%w: unsupported part %s", ErrInvalidRule, key)
+		}
+	}
+
+	if rule.Freq == "" {
+		return Rule{}, fmt.Errorf("%w: FREQ is required", ErrInvalidRule)
+	}
+
+	return rule, nil
+}
+
+// String formats the rule as an RRULE value
+func (r Rule) String() string {
+	return fmt.Sprintf("FREQ=%s;INTERVAL=%d", r.Freq, r.Interval)
+}
+
+// Occurrence returns the nth occurrence of the rule started at start, the 0th being start itself
+func (r Rule) Occurrence(start time.Time, n int) time.Time {
+	steps := n * r.Interval
+
+	switch r.Freq {
+	case Daily:
+		return start.AddDate(0, 0, steps)
+	case Weekly:
+		return start.AddDate(0, 0, 7*steps)
+	case Monthly:
+		return addMonths(start, steps)
+	case Yearly:
+		return addMonths(start, 12*steps)
+	}
+
+	return start
+}
+
+// addMonths moves t by months, clamping the day to the length of the target month
+func addMonths(t time.Time, months int) time.Time {
+	year, month, day := t.Date()
+	first := time.Date(year, month+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+
+	if last := first.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+
+	return first.AddDate(0, 0, day-1)
+}
+
+// Next returns the first occurrence strictly after after of a schedule starting at start
+// an empty recurrence is a one-off at start; a non-zero end excludes the occurrences after it
+// it returns false once the schedule has no occurrence left
+func Next(recurrence string, start time.Time, end time.Time, after time.Time) (time.Time, bool, error) {
+	next := start
+
+	if recurrence != "" {
+		rule, err := Parse(recurrence)
+		if err != nil {
+			return time.Time{}, false, err
+		}
+
+		n := 0
+		if after.After(start) {
+			n = int(after.Sub(start) / (maxPeriod[rule.Freq] * time.Duration(rule.Interval)))
+		}
+
+		for next = rule.Occurrence(start, n); !next.After(after); n++ {
+			next = rule.Occurrence(start, n+1)
+		}
+	}
+
+	if !next.After(after) || (!end.IsZero() && next.After(end)) {
+		return time.Time{}, false, nil
+	}
+
+	return next, true, nil
+}
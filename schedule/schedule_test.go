@@ -0,0 +1,127 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	rule, err := Parse("RRULE:FREQ=MONTHLY;INTERVAL=3")
+	require.NoError(t, err)
+	require.Equal(t, Rule{Freq: Monthly, Interval: 3}, rule)
+	require.Equal(t, "FREQ=MONTHLY;INTERVAL=3", rule.String())
+
+	rule, err = Parse("freq=weekly")
+	require.NoError(t, err)
+	require.Equal(t, Rule{Freq: Weekly, Interval: 1}, rule)
+
+	for _, s := range []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=HOURLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;INTERVAL=x",
+		"FREQ=DAILY;FREQ=WEEKLY",
+		"FREQ=DAILY;BYDAY=MO",
+		"FREQ=DAILY;",
+	} {
+		_, err := Parse(s)
+		require.ErrorIs(t, err, ErrInvalidRule, s)
+	}
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 9, 0, 0, 0, time.UTC)
+}
+
+func TestOccurrenceClampsMonthEnd(t *testing.T) {
+	rule := Rule{Freq: Monthly, Interval: 1}
+	start := date(2024, time.January, 31)
+
+	require.Equal(t, date(2024, time.February, 29), rule.Occurrence(start, 1))
+	require.Equal(t, date(2024, time.March, 31), rule.Occurrence(start, 2))
+	require.Equal(t, date(2024, time.April, 30), rule.Occurrence(start, 3))
+
+	yearly := Rule{Freq: Yearly, Interval: 1}
+	require.Equal(t, date(2025, time.February, 28), yearly.Occurrence(date(2024, time.February, 29), 1))
+}
+
+func TestNext(t *testing.T) {
+	start := date(2024, time.January, 15)
+
+	testCases := []struct {
+		name       string
+		recurrence string
+		end        time.Time
+		after      time.Time
+		next       time.Time
+		ok         bool
+	}{
+		{
+			name:  "OneOffBeforeStart",
+			after: start.Add(-time.Second),
+			next:  start,
+			ok:    true,
+		},
+		{
+			name:  "OneOffDone",
+			after: start,
+		},
+		{
+			name:       "FirstOccurrenceIsStart",
+			recurrence: "FREQ=WEEKLY",
+			after:      start.Add(-time.Second),
+			next:       start,
+			ok:         true,
+		},
+		{
+			name:       "AfterAnOccurrence",
+			recurrence: "FREQ=WEEKLY;INTERVAL=2",
+			after:      start,
+			next:       date(2024, time.January, 29),
+			ok:         true,
+		},
+		{
+			name:       "BetweenOccurrences",
+			recurrence: "FREQ=DAILY",
+			after:      date(2026, time.March, 3).Add(time.Hour),
+			next:       date(2026, time.March, 4),
+			ok:         true,
+		},
+		{
+			name:       "FarAhead",
+			recurrence: "FREQ=MONTHLY",
+			after:      date(2030, time.June, 15),
+			next:       date(2030, time.July, 15),
+			ok:         true,
+		},
+		{
+			name:       "EndIncluded",
+			recurrence: "FREQ=MONTHLY",
+			end:        date(2024, time.March, 15),
+			after:      date(2024, time.February, 15),
+			next:       date(2024, time.March, 15),
+			ok:         true,
+		},
+		{
+			name:       "PastEnd",
+			recurrence: "FREQ=MONTHLY",
+			end:        date(2024, time.March, 14),
+			after:      date(2024, time.February, 15),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			next, ok, err := Next(tc.recurrence, start, tc.end, tc.after)
+			require.NoError(t, err)
+			require.Equal(t, tc.ok, ok)
+			require.Equal(t, tc.next, next)
+		})
+	}
+
+	_, _, err := Next("FREQ=SOMETIMES", start, time.Time{}, start)
+	require.ErrorIs(t, err, ErrInvalidRule)
+}
@@ -0,0 +1,124 @@
+// Package scheduler executes the occurrences of scheduled transfers
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	db "simplebank/db/sqlc"
+	"simplebank/logging"
+	"simplebank/schedule"
+	"time"
+)
+
+const (
+	// defaultBatchSize bounds how many occurrences a worker runs before polling again
+	defaultBatchSize = 100
+	// maxAttempts is how many times an occurrence is tried before it is given up and the owner notified
+	maxAttempts = 4
+)
+
+// retryDelays are the waits after each failed attempt, e.g. for the salary to arrive before the rent is paid
+var retryDelays = []time.Duration{15 * time.Minute, time.Hour, 4 * time.Hour}
+
+// RunResult counts what happened to a batch of occurrences
+type RunResult struct {
+	Succeeded int
+	Retried   int
+	GivenUp   int
+}
+
+// Worker runs the due occurrences of scheduled transfers
+// replicas can run side by side: each occurrence is claimed by one of them and executed at most once
+type Worker struct {
+	store     *db.Store
+	interval  time.Duration
+	batchSize int
+	now       func() time.Time
+}
+
+// NewWorker creates a worker polling for due occurrences every interval
+func NewWorker(store *db.Store, interval time.Duration) *Worker {
+	return &Worker{
+		store:     store,
+		interval:  interval,
+		batchSize: defaultBatchSize,
+		now:       time.Now,
+	}
+}
+
+// Run executes occurrences until ctx is done
+func (w *Worker) Run(ctx context.Context) error {
+	logger := logging.FromContext(ctx).With(slog.String("worker", "scheduler"))
+	ctx = logging.WithLogger(ctx, logger)
+
+	for {
+		result, err := w.RunOnce(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		total := result.Succeeded + result.Retried + result.GivenUp
+		if err != nil {
+			logger.ErrorContext(ctx, "scheduled transfers failed", slog.Any("error", err))
+		} else if total > 0 {
+			logger.InfoContext(ctx, "scheduled transfers run",
+				slog.Int("succeeded", result.Succeeded),
+				slog.Int("retried", result.Retried),
+				slog.Int("given_up", result.GivenUp),
+			)
+		}
+
+		if err == nil && total == w.batchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(w.interval):
+		}
+	}
+}
+
+// RunOnce runs due occurrences one tx at a time until none is left or the batch is full
+func (w *Worker) RunOnce(ctx context.Context) (RunResult, error) {
+	var result RunResult
+
+	arg := db.RunScheduledTransferTxParams{
+		Now:            w.now(),
+		MaxAttempts:    maxAttempts,
+		RetryDelay:     retryDelay,
+		NextOccurrence: NextOccurrence,
+	}
+
+	for i := 0; i < w.batchSize; i++ {
+		txResult, found, err := w.store.RunScheduledTransferTx(ctx, arg)
+		if err != nil || !found {
+			return result, err
+		}
+
+		switch {
+		case txResult.Run.Status == db.ScheduledTransferRunSucceeded:
+			result.Succeeded++
+		case txResult.ScheduledTransfer.Attempts > 0:
+			result.Retried++
+		default:
+			result.GivenUp++
+		}
+	}
+
+	return result, nil
+}
+
+// NextOccurrence returns the occurrence of st following after
+func NextOccurrence(st db.ScheduledTransfer, after time.Time) (time.Time, bool, error) {
+	return schedule.Next(st.Recurrence, st.StartAt, st.EndAt.Time, after)
+}
+
+func retryDelay(attempts int32) time.Duration {
+	if int(attempts) > len(retryDelays) {
+		return retryDelays[len(retryDelays)-1]
+	}
+
+	return retryDelays[attempts-1]
+}
@@ -0,0 +1,225 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	db "simplebank/db/sqlc"
+	"simplebank/event"
+	"simplebank/util"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomAccount(t *testing.T, balance int64) db.Account {
+	user, err := testStore.CreateUser(context.Background(), db.CreateUserParams{
+		Username:       util.RandomOwner(),
+		HashedPassword: "secret",
+		FullName:       util.RandomOwner(),
+		Email:          util.RandomEmail(),
+	})
+	require.NoError(t, err)
+
+	account, err := testStore.CreateAccountTx(context.Background(), db.CreateAccountParams{
+		Owner:    user.Username,
+		Balance:  balance,
+		Currency: "USD",
+	})
+	require.NoError(t, err)
+
+	return account
+}
+
+func createScheduledTransfer(t *testing.T, from db.Account, to db.Account, amount int64, start time.Time, recurrence string) db.ScheduledTransfer {
+	st, err := testStore.CreateScheduledTransfer(context.Background(), db.CreateScheduledTransferParams{
+		Owner:            from.Owner,
+		FromAccountID:    from.ID,
+		ToAccountID:      to.ID,
+		Amount:           amount,
+		Currency:         from.Currency,
+		StartAt:          start,
+		Recurrence:       recurrence,
+		NextOccurrenceAt: sql.NullTime{Time: start, Valid: true},
+		NextRunAt:        sql.NullTime{Time: start, Valid: true},
+	})
+	require.NoError(t, err)
+
+	return st
+}
+
+func listRuns(t *testing.T, id int64) []db.ScheduledTransferRun {
+	runs, err := testStore.ListScheduledTransferRuns(context.Background(), db.ListScheduledTransferRunsParams{
+		ScheduledTransferID: id,
+		AfterID:             0,
+		LimitCount:          100,
+	})
+	require.NoError(t, err)
+
+	return runs
+}
+
+func requireBalance(t *testing.T, accountID int64, balance int64) {
+	account, err := testStore.GetAccount(context.Background(), accountID)
+	require.NoError(t, err)
+	require.Equal(t, balance, account.Balance)
+}
+
+func TestRunCatchesUpMissedOccurrences(t *testing.T) {
+	from := createRandomAccount(t, 100)
+	to := createRandomAccount(t, 0)
+
+	start := time.Now().UTC().AddDate(0, -2, 0).Add(-time.Hour).Truncate(time.Second)
+	st := createScheduledTransfer(t, from, to, 10, start, "FREQ=MONTHLY")
+
+	worker := NewWorker(testStore, time.Second)
+	_, err := worker.RunOnce(context.Background())
+	require.NoError(t, err)
+
+	runs := listRuns(t, st.ID)
+	require.Len(t, runs, 3)
+	for i, run := range runs {
+		require.Equal(t, db.ScheduledTransferRunSucceeded, run.Status)
+		require.True(t, run.TransferID.Valid)
+		require.WithinDuration(t, start.AddDate(0, i, 0), run.OccurrenceAt, 0)
+	}
+
+	st, err = testStore.GetScheduledTransfer(context.Background(), st.ID)
+	require.NoError(t, err)
+	require.Equal(t, db.ScheduledTransferStatusActive, st.Status)
+	require.WithinDuration(t, start.AddDate(0, 3, 0), st.NextOccurrenceAt.Time, 0)
+	require.Equal(t, st.NextOccurrenceAt, st.NextRunAt)
+
+	requireBalance(t, from.ID, 70)
+	requireBalance(t, to.ID, 30)
+
+	// nothing is due until the next month
+	_, err = worker.RunOnce(context.Background())
+	require.NoError(t, err)
+	require.Len(t, listRuns(t, st.ID), 3)
+}
+
+func TestRunRetriesThenGivesUp(t *testing.T) {
+	from := createRandomAccount(t, 5)
+	to := createRandomAccount(t, 0)
+
+	now := time.Now()
+	st := createScheduledTransfer(t, from, to, 10, now.Add(-time.Minute), "")
+
+	worker := NewWorker(testStore, time.Second)
+	worker.now = func() time.Time { return now }
+
+	for attempt := 1; attempt < maxAttempts; attempt++ {
+		_, err := worker.RunOnce(context.Background())
+		require.NoError(t, err)
+
+		st, err = testStore.GetScheduledTransfer(context.Background(), st.ID)
+		require.NoError(t, err)
+		require.Equal(t, db.ScheduledTransferStatusActive, st.Status)
+		require.Equal(t, int32(attempt), st.Attempts)
+		require.Contains(t, st.LastError.String, "insufficient funds")
+		require.WithinDuration(t, now.Add(retryDelays[attempt-1]), st.NextRunAt.Time, time.Millisecond)
+
+		now = st.NextRunAt.Time
+	}
+
+	_, err := worker.RunOnce(context.Background())
+	require.NoError(t, err)
+
+	st, err = testStore.GetScheduledTransfer(context.Background(), st.ID)
+	require.NoError(t, err)
+	require.Equal(t, db.ScheduledTransferStatusFailed, st.Status)
+	require.False(t, st.NextRunAt.Valid)
+
+	runs := listRuns(t, st.ID)
+	require.Len(t, runs, 1)
+	require.Equal(t, db.ScheduledTransferRunFailed, runs[0].Status)
+	require.Equal(t, int32(maxAttempts), runs[0].Attempts)
+	require.False(t, runs[0].TransferID.Valid)
+
+	requireBalance(t, from.ID, 5)
+
+	// the owner is notified through the outbox
+	events, err := testStore.ListAccountOutboxEvents(context.Background(), db.ListAccountOutboxEventsParams{
+		AccountID:  from.ID,
+		AfterID:    0,
+		LimitCount: 100,
+	})
+	require.NoError(t, err)
+
+	last := events[len(events)-1].Event()
+	require.Equal(t, event.ScheduledTransferFailed, last.Type)
+	require.NoError(t, event.Validate(last))
+}
+
+func TestRunSucceedsOnRetry(t *testing.T) {
+	from := createRandomAccount(t, 5)
+	to := createRandomAccount(t, 0)
+
+	now := time.Now()
+	st := createScheduledTransfer(t, from, to, 10, now.Add(-time.Minute), "")
+
+	worker := NewWorker(testStore, time.Second)
+	worker.now = func() time.Time { return now }
+
+	_, err := worker.RunOnce(context.Background())
+	require.NoError(t, err)
+
+	_, err = testStore.AddAccountBalance(context.Background(), db.AddAccountBalanceParams{ID: from.ID, Amount: 5})
+	require.NoError(t, err)
+
+	now = now.Add(retryDelays[0])
+	result, err := worker.RunOnce(context.Background())
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, result.Succeeded, 1)
+
+	st, err = testStore.GetScheduledTransfer(context.Background(), st.ID)
+	require.NoError(t, err)
+	require.Equal(t, db.ScheduledTransferStatusCompleted, st.Status)
+	require.Zero(t, st.Attempts)
+	require.False(t, st.LastError.Valid)
+
+	runs := listRuns(t, st.ID)
+	require.Len(t, runs, 1)
+	require.Equal(t, db.ScheduledTransferRunSucceeded, runs[0].Status)
+	require.Equal(t, int32(2), runs[0].Attempts)
+
+	requireBalance(t, from.ID, 0)
+	requireBalance(t, to.ID, 10)
+}
+
+func TestConcurrentWorkersRunEachOccurrenceOnce(t *testing.T) {
+	from := createRandomAccount(t, 1000)
+	to := createRandomAccount(t, 0)
+
+	start := time.Now().UTC().AddDate(0, 0, -9).Truncate(time.Second)
+	st := createScheduledTransfer(t, from, to, 10, start, "FREQ=DAILY")
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 5)
+
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := NewWorker(testStore, time.Second).RunOnce(context.Background())
+			errs <- err
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	runs := listRuns(t, st.ID)
+	require.Len(t, runs, 10)
+	for _, run := range runs {
+		require.Equal(t, int32(1), run.Attempts)
+	}
+
+	requireBalance(t, from.ID, 900)
+	requireBalance(t, to.ID, 100)
+}
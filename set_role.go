@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	db "simplebank/db/sqlc"
+	"simplebank/rbac"
+)
+
+// setRoleCommand names the command changing the role of a user: simplebank set-role,
+// it grants the first admin, who then manages roles over the API
+const setRoleCommand = "set-role"
+
+// setRole changes the role of the user named by args and writes the user's username and role to out as JSON
+func setRole(ctx context.Context, store *db.Store, args []string, out io.Writer) error {
+	flags := flag.NewFlagSet(setRoleCommand, flag.ContinueOnError)
+	username := flags.String("user", "", "username of the user")
+	role := flags.String("role", rbac.RoleAdmin, "role to grant: admin, support or customer")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *username == "" {
+		return errors.New("-user is required")
+	}
+
+	if !rbac.ValidRole(*role) {
+		return fmt.Errorf("unknown role %q", *role)
+	}
+
+	user, err := store.UpdateUserRoleTx(ctx, db.UpdateUserRoleParams{
+		Username: *username,
+		Role:     *role,
+	})
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(map[string]string{"username": user.Username, "role": user.Role})
+}
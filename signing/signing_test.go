@@ -0,0 +1,160 @@
+package signing
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	secret, err := NewSecret()
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(secret, secretPrefix))
+
+	nonce, err := NewNonce()
+	require.NoError(t, err)
+
+	body := []byte(`{"from_account_id":1,"to_account_id":2,"amount":100,"currency":"USD"}`)
+	signedAt := time.Now()
+	timestamp := strconv.FormatInt(signedAt.Unix(), 10)
+	signature := Sign(secret, "POST", "/transfers", signedAt, nonce, body)
+
+	require.NoError(t, Verify(secret, "POST", "/transfers", timestamp, nonce, signature, body, signedAt.Add(time.Minute), 5*time.Minute))
+
+	testCases := []struct {
+		name      string
+		secret    string
+		method    string
+		target    string
+		timestamp string
+		nonce     string
+		signature string
+		body      []byte
+		now       time.Time
+		err       error
+	}{
+		{
+			name: "NotSigned",
+			now:  signedAt,
+			err:  ErrMissingSignature,
+		},
+		{
+			name:      "WrongSecret",
+			secret:    "sbsig_other",
+			method:    "POST",
+			target:    "/transfers",
+			timestamp: timestamp,
+			nonce:     nonce,
+			signature: signature,
+			body:      body,
+			now:       signedAt,
+			err:       ErrSignatureMismatch,
+		},
+		{
+			name:      "OtherMethod",
+			secret:    secret,
+			method:    "PUT",
+			target:    "/transfers",
+			timestamp: timestamp,
+			nonce:     nonce,
+			signature: signature,
+			body:      body,
+			now:       signedAt,
+			err:       ErrSignatureMismatch,
+		},
+		{
+			name:      "OtherPath",
+			secret:    secret,
+			method:    "POST",
+			target:    "/holds",
+			timestamp: timestamp,
+			nonce:     nonce,
+			signature: signature,
+			body:      body,
+			now:       signedAt,
+			err:       ErrSignatureMismatch,
+		},
+		{
+			name:      "OtherNonce",
+			secret:    secret,
+			method:    "POST",
+			target:    "/transfers",
+			timestamp: timestamp,
+			nonce:     nonce + "0",
+			signature: signature,
+			body:      body,
+			now:       signedAt,
+			err:       ErrSignatureMismatch,
+		},
+		{
+			name:      "TamperedBody",
+			secret:    secret,
+			method:    "POST",
+			target:    "/transfers",
+			timestamp: timestamp,
+			nonce:     nonce,
+			signature: signature,
+			body:      []byte(`{"from_account_id":1,"to_account_id":3,"amount":100,"currency":"USD"}`),
+			now:       signedAt,
+			err:       ErrSignatureMismatch,
+		},
+		{
+			name:      "TooOld",
+			secret:    secret,
+			method:    "POST",
+			target:    "/transfers",
+			timestamp: timestamp,
+			nonce:     nonce,
+			signature: signature,
+			body:      body,
+			now:       signedAt.Add(6 * time.Minute),
+			err:       ErrTimestampSkew,
+		},
+		{
+			name:      "FromTheFuture",
+			secret:    secret,
+			method:    "POST",
+			target:    "/transfers",
+			timestamp: timestamp,
+			nonce:     nonce,
+			signature: signature,
+			body:      body,
+			now:       signedAt.Add(-6 * time.Minute),
+			err:       ErrTimestampSkew,
+		},
+		{
+			name:      "MalformedTimestamp",
+			secret:    secret,
+			method:    "POST",
+			target:    "/transfers",
+			timestamp: "yesterday",
+			nonce:     nonce,
+			signature: signature,
+			body:      body,
+			now:       signedAt,
+			err:       ErrInvalidTimestamp,
+		},
+		{
+			name:      "ShortNonce",
+			secret:    secret,
+			method:    "POST",
+			target:    "/transfers",
+			timestamp: timestamp,
+			nonce:     "abc",
+			signature: signature,
+			body:      body,
+			now:       signedAt,
+			err:       ErrInvalidNonce,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Verify(tc.secret, tc.method, tc.target, tc.timestamp, tc.nonce, tc.signature, tc.body, tc.now, 5*time.Minute)
+			require.ErrorIs(t, err, tc.err)
+		})
+	}
+}
@@ -0,0 +1,76 @@
+// Package snapshot writes the daily balance snapshots that point-in-time balance queries start from
+package snapshot
+
+import (
+	"context"
+	"log/slog"
+	db "simplebank/db/sqlc"
+	"simplebank/logging"
+	"time"
+)
+
+// catchUpDays is how many past days a run snapshots, so that a worker that was down for a while catches up
+const catchUpDays = 7
+
+// RunResult counts the snapshots a run wrote
+type RunResult struct {
+	Snapshots int
+}
+
+// Worker snapshots the balances of the accounts that moved on the days that ended,
+// runs are idempotent, replicas can run side by side
+type Worker struct {
+	store    *db.Store
+	interval time.Duration
+	now      func() time.Time
+}
+
+// NewWorker creates a worker running every interval
+func NewWorker(store *db.Store, interval time.Duration) *Worker {
+	return &Worker{
+		store:    store,
+		interval: interval,
+		now:      time.Now,
+	}
+}
+
+// Run writes snapshots until ctx is done
+func (w *Worker) Run(ctx context.Context) error {
+	logger := logging.FromContext(ctx).With(slog.String("worker", "balance_snapshot"))
+	ctx = logging.WithLogger(ctx, logger)
+
+	for {
+		result, err := w.RunOnce(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		if err != nil {
+			logger.ErrorContext(ctx, "balance snapshot failed", slog.Any("error", err))
+		} else if result.Snapshots > 0 {
+			logger.InfoContext(ctx, "balances snapshotted", slog.Int("snapshots", result.Snapshots))
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(w.interval):
+		}
+	}
+}
+
+// RunOnce snapshots the last days that ended, oldest first
+func (w *Worker) RunOnce(ctx context.Context) (RunResult, error) {
+	var result RunResult
+	today := w.now().Add(-db.SettleDelay).UTC().Truncate(24 * time.Hour)
+
+	for day := today.AddDate(0, 0, -catchUpDays); day.Before(today); day = day.AddDate(0, 0, 1) {
+		snapshots, err := w.store.SnapshotDay(ctx, day)
+		result.Snapshots += snapshots
+		if err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,21 @@
+package snapshot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkerIsIdempotent(t *testing.T) {
+	worker := NewWorker(testStore, time.Hour)
+
+	_, err := worker.RunOnce(context.Background())
+	require.NoError(t, err)
+
+	// entries are only ever posted now, so the days that ended have nothing left to snapshot
+	result, err := worker.RunOnce(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, result.Snapshots)
+}
@@ -0,0 +1,21 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfigRequiresTokenSymmetricKey(t *testing.T) {
+	t.Setenv("TOKEN_SYMMETRIC_KEY", "")
+
+	_, err := LoadConfig()
+	require.EqualError(t, err, "TOKEN_SYMMETRIC_KEY is required")
+
+	key := RandomString(32)
+	t.Setenv("TOKEN_SYMMETRIC_KEY", key)
+
+	config, err := LoadConfig()
+	require.NoError(t, err)
+	require.Equal(t, key, config.TokenSymmetricKey)
+}
@@ -0,0 +1,44 @@
+package util
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+)
+
+// ErrInvalidCursor is returned for a pagination cursor that was not issued by EncodeCursor
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// EncodeCursor hides the last seen ID behind an opaque token
+func EncodeCursor(lastID int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(lastID, 10)))
+}
+
+// DecodeCursor returns the ID after which the next page starts, an empty cursor starts from the beginning
+func DecodeCursor(cursor string) (int64, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+
+	lastID, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil || lastID < 0 {
+		return 0, ErrInvalidCursor
+	}
+
+	return lastID, nil
+}
+
+// NextCursor returns the cursor of the page after a full page ending at lastID,
+// or an empty string when the page is the last one
+func NextCursor(count int, pageSize int32, lastID int64) string {
+	if count > 0 && count == int(pageSize) {
+		return EncodeCursor(lastID)
+	}
+
+	return ""
+}
@@ -51,3 +51,8 @@ func RanddomCurrency() string {
 
 	return currencies[rand.Intn(k)]
 }
+
+// RandomEmail generates a random email
+func RandomEmail() string {
+	return RandomString(6) + "@email.com"
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"io"
+	db "simplebank/db/sqlc"
+)
+
+// verifyAuditLogCommand names the command checking the hash chain of the audit log: simplebank verify-audit-log
+const verifyAuditLogCommand = "verify-audit-log"
+
+// verifyAuditLog checks every row of the audit log against its hash and the row before it,
+// and writes how far the chain was checked to out as JSON
+func verifyAuditLog(ctx context.Context, store *db.Store, args []string, out io.Writer) error {
+	flags := flag.NewFlagSet(verifyAuditLogCommand, flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	verification, err := store.VerifyAuditLog(ctx)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(verification)
+}
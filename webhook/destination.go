@@ -0,0 +1,82 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+)
+
+// ErrForbiddenDestination is returned for a webhook URL reaching an address of the bank's own network
+var ErrForbiddenDestination = errors.New("webhook destination is not a public address")
+
+// sharedAddressSpace is the carrier-grade NAT range, private in practice though IsPrivate leaves it out
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// publicAddress reports whether webhooks may be sent to ip: loopback, private, link-local addresses,
+// the cloud metadata service at 169.254.169.254 among them, and the unspecified and multicast ones are refused
+func publicAddress(ip netip.Addr) bool {
+	ip = ip.Unmap()
+
+	return ip.IsValid() &&
+		!ip.IsLoopback() &&
+		!ip.IsPrivate() &&
+		!ip.IsLinkLocalUnicast() &&
+		!ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() &&
+		!ip.IsMulticast() &&
+		!ip.IsUnspecified() &&
+		!sharedAddressSpace.Contains(ip)
+}
+
+// CheckURL refuses a webhook URL unless every address its host resolves to is public
+func CheckURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+
+	ips, err := net.DefaultResolver.LookupNetIP(ctx, "ip", u.Hostname())
+	if err != nil {
+		return fmt.Errorf("cannot resolve webhook host %q: %w", u.Hostname(), err)
+	}
+
+	for _, ip := range ips {
+		if !publicAddress(ip) {
+			return fmt.Errorf("%w: %s resolves to %s", ErrForbiddenDestination, u.Hostname(), ip.Unmap())
+		}
+	}
+
+	return nil
+}
+
+// newHTTPClient returns the client the deliveries are sent with, it checks every address it dials once resolved,
+// so a DNS record changed after CheckURL, or a redirect, cannot point the worker at an internal address
+func newHTTPClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: requestTimeout,
+		Control: func(network string, address string, conn syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+
+			if !publicAddress(addrPort.Addr()) {
+				return fmt.Errorf("%w: %s", ErrForbiddenDestination, addrPort.Addr().Unmap())
+			}
+
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// no proxy from the environment, the dialer would only see the address of the proxy
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{Timeout: requestTimeout, Transport: transport}
+}
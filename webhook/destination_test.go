@@ -0,0 +1,79 @@
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublicAddress(t *testing.T) {
+	testCases := []struct {
+		ip     string
+		public bool
+	}{
+		{ip: "93.184.216.34", public: true},
+		{ip: "2606:2800:220:1:248:1893:25c8:1946", public: true},
+		{ip: "127.0.0.1"},
+		{ip: "::1"},
+		{ip: "10.1.2.3"},
+		{ip: "172.16.0.1"},
+		{ip: "192.168.1.1"},
+		{ip: "100.64.0.1"},
+		{ip: "169.254.169.254"},
+		{ip: "fe80::1"},
+		{ip: "fd00::1"},
+		{ip: "0.0.0.0"},
+		{ip: "224.0.0.1"},
+		{ip: "::ffff:127.0.0.1"},
+		{ip: "::ffff:169.254.169.254"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.ip, func(t *testing.T) {
+			require.Equal(t, tc.public, publicAddress(netip.MustParseAddr(tc.ip)))
+		})
+	}
+}
+
+func TestCheckURL(t *testing.T) {
+	testCases := []struct {
+		name string
+		url  string
+		ok   bool
+	}{
+		{name: "Public", url: "https://93.184.216.34/hooks", ok: true},
+		{name: "Loopback", url: "http://127.0.0.1:8080/hooks"},
+		{name: "Localhost", url: "http://localhost/hooks"},
+		{name: "Metadata", url: "http://169.254.169.254/latest/meta-data"},
+		{name: "Private", url: "https://10.0.0.5/hooks"},
+		{name: "IPv6Loopback", url: "http://[::1]/hooks"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := CheckURL(context.Background(), tc.url)
+			if tc.ok {
+				require.NoError(t, err)
+				return
+			}
+
+			require.ErrorIs(t, err, ErrForbiddenDestination)
+		})
+	}
+}
+
+// TestHTTPClientRefusesInternalAddress dials a receiver on the loopback interface, as a host whose DNS record
+// was changed after the subscription was created would make the worker do
+func TestHTTPClientRefusesInternalAddress(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		t.Error("the receiver must not be reached")
+	}))
+	defer receiver.Close()
+
+	_, err := newHTTPClient().Post(receiver.URL, "application/json", nil)
+	require.ErrorIs(t, err, ErrForbiddenDestination)
+}
@@ -0,0 +1,66 @@
+// Package webhook pushes domain events to the URLs users subscribed to
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	db "simplebank/db/sqlc"
+	"simplebank/event"
+)
+
+// EventTypes lists the events a webhook can subscribe to
+var EventTypes = []event.Type{event.TransferCompleted, event.EntryPosted, event.ScheduledTransferFailed}
+
+// Dispatcher is an event.Publisher queueing a delivery for every subscription interested in an event
+// it only queues, the Worker sends the deliveries
+type Dispatcher struct {
+	store *db.Store
+}
+
+// NewDispatcher creates a dispatcher, subscribe it to the event bus
+func NewDispatcher(store *db.Store) *Dispatcher {
+	return &Dispatcher{store: store}
+}
+
+// Publish queues e for the subscriptions of the owners of the accounts it concerns
+// queueing the same event twice is a no-op, so the relay can retry it
+func (d *Dispatcher) Publish(ctx context.Context, e event.Event) error {
+	accountIDs, err := recipients(e)
+	if err != nil || len(accountIDs) == 0 {
+		return err
+	}
+
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	_, err = d.store.EnqueueWebhookDeliveries(ctx, db.EnqueueWebhookDeliveriesParams{
+		EventID:    e.ID,
+		EventType:  string(e.Type),
+		Payload:    payload,
+		AccountIds: accountIDs,
+	})
+	if err != nil {
+		return fmt.Errorf("cannot queue webhook deliveries of event %d: %w", e.ID, err)
+	}
+
+	return nil
+}
+
+// recipients returns the accounts whose owners are notified of e, none for the events webhooks don't carry
+func recipients(e event.Event) ([]int64, error) {
+	switch e.Type {
+	case event.TransferCompleted:
+		var data event.TransferCompletedV1
+		if err := json.Unmarshal(e.Data, &data); err != nil {
+			return nil, fmt.Errorf("cannot decode event %d: %w", e.ID, err)
+		}
+		return []int64{data.FromAccountID, data.ToAccountID}, nil
+	case event.EntryPosted, event.ScheduledTransferFailed:
+		return []int64{e.AccountID}, nil
+	}
+
+	return nil, nil
+}
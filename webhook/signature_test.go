@@ -0,0 +1,89 @@
+package webhook
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	secret, err := NewSecret()
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(secret, secretPrefix))
+
+	body := []byte(`{"id":1,"type":"entry.posted"}`)
+	sentAt := time.Now()
+	header := Sign(secret, sentAt, body)
+
+	require.NoError(t, Verify(secret, header, body, sentAt.Add(time.Minute), 5*time.Minute))
+
+	testCases := []struct {
+		name   string
+		secret string
+		header string
+		body   []byte
+		now    time.Time
+		err    error
+	}{
+		{
+			name:   "WrongSecret",
+			secret: "whsec_other",
+			header: header,
+			body:   body,
+			now:    sentAt,
+			err:    ErrSignatureMismatch,
+		},
+		{
+			name:   "TamperedBody",
+			secret: secret,
+			header: header,
+			body:   []byte(`{"id":2,"type":"entry.posted"}`),
+			now:    sentAt,
+			err:    ErrSignatureMismatch,
+		},
+		{
+			name:   "Replayed",
+			secret: secret,
+			header: header,
+			body:   body,
+			now:    sentAt.Add(time.Hour),
+			err:    ErrSignatureExpired,
+		},
+		{
+			name:   "MissingSignature",
+			secret: secret,
+			header: strings.Split(header, ",")[0],
+			body:   body,
+			now:    sentAt,
+			err:    ErrInvalidSignatureHeader,
+		},
+		{
+			name:   "Malformed",
+			secret: secret,
+			header: "garbage",
+			body:   body,
+			now:    sentAt,
+			err:    ErrInvalidSignatureHeader,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Verify(tc.secret, tc.header, tc.body, tc.now, 5*time.Minute)
+			require.ErrorIs(t, err, tc.err)
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	require.GreaterOrEqual(t, backoff(1), minBackoff)
+	require.LessOrEqual(t, backoff(1), minBackoff+minBackoff/5)
+
+	require.GreaterOrEqual(t, backoff(3), 4*minBackoff)
+	require.LessOrEqual(t, backoff(3), 4*minBackoff+4*minBackoff/5)
+
+	require.GreaterOrEqual(t, backoff(40), maxBackoff)
+	require.LessOrEqual(t, backoff(40), maxBackoff+maxBackoff/5)
+}
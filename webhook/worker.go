@@ -0,0 +1,213 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	db "simplebank/db/sqlc"
+	"simplebank/logging"
+	"strconv"
+	"time"
+)
+
+// Delivery statuses
+const (
+	StatusPending   = "pending"
+	StatusSucceeded = "succeeded"
+	StatusDead      = "dead"
+)
+
+const (
+	defaultBatchSize = 20
+	// requestTimeout bounds how long a receiver may take to answer
+	requestTimeout = 10 * time.Second
+	// leaseDuration hides claimed deliveries from other workers, a worker that dies mid-batch
+	// only delays its deliveries by this long
+	leaseDuration = time.Minute
+	minBackoff    = 30 * time.Second
+	maxBackoff    = time.Hour
+	// maxErrorLength bounds the receiver output kept in the delivery log
+	maxErrorLength = 512
+)
+
+// DeliverResult counts what happened to a batch of deliveries
+type DeliverResult struct {
+	Succeeded int
+	Retried   int
+	Dead      int
+}
+
+// Worker sends the queued deliveries
+// failed deliveries are retried with exponential backoff until they expire, then dead-lettered
+type Worker struct {
+	store      *db.Store
+	httpClient *http.Client
+	interval   time.Duration
+	batchSize  int32
+	now        func() time.Time
+}
+
+// NewWorker creates a worker polling for due deliveries every interval
+func NewWorker(store *db.Store, interval time.Duration) *Worker {
+	return &Worker{
+		store:      store,
+		httpClient: newHTTPClient(),
+		interval:   interval,
+		batchSize:  defaultBatchSize,
+		now:        time.Now,
+	}
+}
+
+// Run sends deliveries until ctx is done
+func (w *Worker) Run(ctx context.Context) error {
+	logger := logging.FromContext(ctx).With(slog.String("worker", "webhook_delivery"))
+	ctx = logging.WithLogger(ctx, logger)
+
+	for {
+		result, err := w.DeliverOnce(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		total := result.Succeeded + result.Retried + result.Dead
+		if err != nil {
+			logger.ErrorContext(ctx, "webhook delivery failed", slog.Any("error", err))
+		} else if total > 0 {
+			logger.InfoContext(ctx, "webhooks delivered",
+				slog.Int("succeeded", result.Succeeded),
+				slog.Int("retried", result.Retried),
+				slog.Int("dead", result.Dead),
+			)
+		}
+
+		if err == nil && total == int(w.batchSize) {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(w.interval):
+		}
+	}
+}
+
+// DeliverOnce claims a batch of due deliveries and sends them
+func (w *Worker) DeliverOnce(ctx context.Context) (DeliverResult, error) {
+	var result DeliverResult
+
+	deliveries, err := w.store.ClaimDueWebhookDeliveries(ctx, db.ClaimDueWebhookDeliveriesParams{
+		LeaseUntil: w.now().Add(leaseDuration),
+		LimitCount: w.batchSize,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	for _, delivery := range deliveries {
+		updated, err := w.deliver(ctx, delivery)
+		if err != nil {
+			return result, err
+		}
+
+		switch updated.Status {
+		case StatusSucceeded:
+			result.Succeeded++
+		case StatusDead:
+			result.Dead++
+		default:
+			result.Retried++
+		}
+	}
+
+	return result, nil
+}
+
+// deliver sends one delivery and records the outcome of the attempt
+func (w *Worker) deliver(ctx context.Context, delivery db.WebhookDelivery) (db.WebhookDelivery, error) {
+	subscription, err := w.store.GetWebhookSubscription(ctx, delivery.SubscriptionID)
+	if err != nil {
+		return delivery, err
+	}
+
+	statusCode, sendErr := w.send(ctx, subscription, delivery)
+
+	arg := db.UpdateWebhookDeliveryAttemptParams{
+		ID:             delivery.ID,
+		Status:         StatusSucceeded,
+		NextAttemptAt:  delivery.NextAttemptAt,
+		ResponseStatus: sql.NullInt32{Int32: int32(statusCode), Valid: statusCode != 0},
+	}
+
+	if sendErr != nil {
+		now := w.now()
+		arg.Status = StatusPending
+		arg.NextAttemptAt = now.Add(backoff(delivery.Attempts + 1))
+		arg.LastError = sql.NullString{String: truncate(sendErr.Error(), maxErrorLength), Valid: true}
+
+		if arg.NextAttemptAt.After(delivery.ExpiresAt) {
+			arg.Status = StatusDead
+			arg.NextAttemptAt = now
+		}
+	}
+
+	return w.store.UpdateWebhookDeliveryAttempt(ctx, arg)
+}
+
+// send POSTs the event envelope to the subscription, any 2xx answer is a success
+func (w *Worker) send(ctx context.Context, subscription db.WebhookSubscription, delivery db.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.Url, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "simplebank-webhooks/1")
+	req.Header.Set(EventHeader, delivery.EventType)
+	req.Header.Set(DeliveryHeader, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(SignatureHeader, Sign(subscription.Secret, w.now(), delivery.Payload))
+
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorLength))
+		return resp.StatusCode, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+
+	io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode, nil
+}
+
+// backoff returns the delay before the next attempt after the given number of attempts,
+// doubling from minBackoff up to maxBackoff with up to 20% jitter so failing receivers are not hit in bursts
+func backoff(attempts int32) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	delay := maxBackoff
+	if attempts < 32 {
+		if d := minBackoff << (attempts - 1); d > 0 && d < maxBackoff {
+			delay = d
+		}
+	}
+
+	return delay + time.Duration(rand.Int63n(int64(delay)/5+1))
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+
+	return s[:n]
+}
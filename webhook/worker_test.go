@@ -0,0 +1,292 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	db "simplebank/db/sqlc"
+	"simplebank/event"
+	"simplebank/util"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomAccount(t *testing.T) db.Account {
+	user, err := testStore.CreateUser(context.Background(), db.CreateUserParams{
+		Username:       util.RandomOwner(),
+		HashedPassword: "secret",
+		FullName:       util.RandomOwner(),
+		Email:          util.RandomEmail(),
+	})
+	require.NoError(t, err)
+
+	account, err := testStore.CreateAccountTx(context.Background(), db.CreateAccountParams{
+		Owner:    user.Username,
+		Balance:  100,
+		Currency: "USD",
+	})
+	require.NoError(t, err)
+
+	return account
+}
+
+// receiver is an httptest webhook endpoint answering with the queued statuses, then 200
+type receiver struct {
+	*httptest.Server
+	secret string
+
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func newReceiver(t *testing.T, statuses ...int) *receiver {
+	r := &receiver{secret: "whsec_" + util.RandomString(32), statuses: statuses}
+
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		r.requests = append(r.requests, req)
+		r.bodies = append(r.bodies, body)
+
+		status := http.StatusOK
+		if len(r.statuses) > 0 {
+			status, r.statuses = r.statuses[0], r.statuses[1:]
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(r.Close)
+
+	return r
+}
+
+// newLoopbackWorker is a worker allowed to deliver to the receivers, which listen on the loopback interface
+func newLoopbackWorker() *Worker {
+	worker := NewWorker(testStore, time.Second)
+	worker.httpClient = &http.Client{Timeout: requestTimeout}
+
+	return worker
+}
+
+func (r *receiver) received() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return len(r.requests)
+}
+
+func createSubscription(t *testing.T, owner string, url string, secret string, eventTypes ...string) db.WebhookSubscription {
+	subscription, err := testStore.CreateWebhookSubscription(context.Background(), db.CreateWebhookSubscriptionParams{
+		Owner:      owner,
+		Url:        url,
+		EventTypes: eventTypes,
+		Secret:     secret,
+	})
+	require.NoError(t, err)
+
+	return subscription
+}
+
+// dispatchAccountEvents hands the outbox events of account to the dispatcher, as the relay would
+func dispatchAccountEvents(t *testing.T, dispatcher *Dispatcher, accountID int64) {
+	events, err := testStore.ListAccountOutboxEvents(context.Background(), db.ListAccountOutboxEventsParams{
+		AccountID:  accountID,
+		AfterID:    0,
+		LimitCount: 100,
+	})
+	require.NoError(t, err)
+
+	for _, e := range events {
+		require.NoError(t, dispatcher.Publish(context.Background(), e.Event()))
+	}
+}
+
+func listDeliveries(t *testing.T, subscriptionID int64) []db.WebhookDelivery {
+	deliveries, err := testStore.ListWebhookDeliveries(context.Background(), db.ListWebhookDeliveriesParams{
+		SubscriptionID: subscriptionID,
+		AfterID:        0,
+		LimitCount:     100,
+	})
+	require.NoError(t, err)
+
+	return deliveries
+}
+
+// attempt runs the worker until the delivery was attempted once more, other tests may have due deliveries too
+func attempt(t *testing.T, worker *Worker, delivery db.WebhookDelivery) db.WebhookDelivery {
+	for i := 0; i < 20; i++ {
+		_, err := worker.DeliverOnce(context.Background())
+		require.NoError(t, err)
+
+		updated, err := testStore.GetWebhookDelivery(context.Background(), delivery.ID)
+		require.NoError(t, err)
+
+		if updated.Attempts > delivery.Attempts {
+			return updated
+		}
+	}
+
+	t.Fatalf("delivery %d was not attempted", delivery.ID)
+	return delivery
+}
+
+func TestDeliverTransfer(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	recv := newReceiver(t)
+	subscription := createSubscription(t, account2.Owner, recv.URL, recv.secret, string(event.EntryPosted))
+	other := createSubscription(t, account2.Owner, recv.URL, recv.secret, string(event.AccountCreated))
+
+	result, err := testStore.TransferTX(context.Background(), db.TransferCreateParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	dispatcher := NewDispatcher(testStore)
+	dispatchAccountEvents(t, dispatcher, account1.ID)
+	dispatchAccountEvents(t, dispatcher, account2.ID)
+	// a retried event is queued once
+	dispatchAccountEvents(t, dispatcher, account2.ID)
+
+	// only the entry of the recipient is delivered, the sender belongs to someone else
+	deliveries := listDeliveries(t, subscription.ID)
+	require.Len(t, deliveries, 1)
+	require.Equal(t, string(event.EntryPosted), deliveries[0].EventType)
+	require.Empty(t, listDeliveries(t, other.ID))
+
+	worker := newLoopbackWorker()
+	delivered := attempt(t, worker, deliveries[0])
+	require.Equal(t, StatusSucceeded, delivered.Status)
+	require.Equal(t, int32(http.StatusOK), delivered.ResponseStatus.Int32)
+	require.True(t, delivered.LastAttemptAt.Valid)
+
+	require.Equal(t, 1, recv.received())
+	req, body := recv.requests[0], recv.bodies[0]
+	require.Equal(t, string(event.EntryPosted), req.Header.Get(EventHeader))
+	require.NoError(t, Verify(recv.secret, req.Header.Get(SignatureHeader), body, time.Now(), time.Minute))
+
+	var e event.Event
+	require.NoError(t, json.Unmarshal(body, &e))
+	require.Equal(t, deliveries[0].EventID, e.ID)
+
+	var data event.EntryPostedV1
+	require.NoError(t, json.Unmarshal(e.Data, &data))
+	require.Equal(t, result.ToEntrie.ID, data.EntryID)
+	require.Equal(t, account2.ID, data.AccountID)
+	require.Equal(t, int64(10), data.Amount)
+}
+
+func TestDeliverRetriesWithBackoff(t *testing.T) {
+	account := createRandomAccount(t)
+
+	recv := newReceiver(t, http.StatusInternalServerError, http.StatusServiceUnavailable)
+	subscription := createSubscription(t, account.Owner, recv.URL, recv.secret, string(event.TransferCompleted))
+
+	_, err := testStore.TransferTX(context.Background(), db.TransferCreateParams{
+		FromAccountID: account.ID,
+		ToAccountID:   createRandomAccount(t).ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	dispatchAccountEvents(t, NewDispatcher(testStore), account.ID)
+
+	deliveries := listDeliveries(t, subscription.ID)
+	require.Len(t, deliveries, 1)
+	delivery := deliveries[0]
+
+	worker := newLoopbackWorker()
+
+	attemptedAt := time.Now()
+	delivery = attempt(t, worker, delivery)
+	require.Equal(t, StatusPending, delivery.Status)
+	require.Equal(t, int32(1), delivery.Attempts)
+	require.Equal(t, int32(http.StatusInternalServerError), delivery.ResponseStatus.Int32)
+	require.Contains(t, delivery.LastError.String, "unexpected status 500")
+	require.WithinRange(t, delivery.NextAttemptAt, attemptedAt.Add(minBackoff), time.Now().Add(minBackoff+minBackoff/5))
+
+	// the delivery is not due yet
+	_, err = worker.DeliverOnce(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, recv.received())
+
+	// pretend the backoff elapsed, the second attempt waits twice as long
+	delivery, err = testStore.RedeliverWebhookDelivery(context.Background(), delivery.ID)
+	require.NoError(t, err)
+
+	attemptedAt = time.Now()
+	delivery = attempt(t, worker, delivery)
+	require.Equal(t, StatusPending, delivery.Status)
+	require.Equal(t, int32(2), delivery.Attempts)
+	require.WithinRange(t, delivery.NextAttemptAt, attemptedAt.Add(2*minBackoff), time.Now().Add(2*minBackoff+2*minBackoff/5))
+
+	delivery, err = testStore.RedeliverWebhookDelivery(context.Background(), delivery.ID)
+	require.NoError(t, err)
+
+	delivery = attempt(t, worker, delivery)
+	require.Equal(t, StatusSucceeded, delivery.Status)
+	require.Equal(t, int32(3), delivery.Attempts)
+	require.Equal(t, 3, recv.received())
+}
+
+func TestDeliverDeadLetters(t *testing.T) {
+	account := createRandomAccount(t)
+
+	recv := newReceiver(t, http.StatusInternalServerError, http.StatusInternalServerError)
+	subscription := createSubscription(t, account.Owner, recv.URL, recv.secret, string(event.EntryPosted))
+
+	_, err := testStore.TransferTX(context.Background(), db.TransferCreateParams{
+		FromAccountID: account.ID,
+		ToAccountID:   createRandomAccount(t).ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	dispatchAccountEvents(t, NewDispatcher(testStore), account.ID)
+
+	deliveries := listDeliveries(t, subscription.ID)
+	require.Len(t, deliveries, 1)
+
+	// a failure after the 24h window dead-letters the delivery
+	worker := newLoopbackWorker()
+	worker.now = func() time.Time { return time.Now().Add(25 * time.Hour) }
+
+	delivery := attempt(t, worker, deliveries[0])
+	require.Equal(t, StatusDead, delivery.Status)
+	require.Equal(t, 1, recv.received())
+
+	// dead deliveries are not retried until redelivered by hand
+	_, err = worker.DeliverOnce(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, recv.received())
+
+	delivery, err = testStore.RedeliverWebhookDelivery(context.Background(), delivery.ID)
+	require.NoError(t, err)
+	require.Equal(t, StatusPending, delivery.Status)
+	require.True(t, delivery.ExpiresAt.After(time.Now().Add(23*time.Hour)))
+
+	worker.now = time.Now
+	delivery = attempt(t, worker, delivery)
+	require.Equal(t, StatusPending, delivery.Status)
+	require.Equal(t, int32(2), delivery.Attempts)
+
+	delivery, err = testStore.RedeliverWebhookDelivery(context.Background(), delivery.ID)
+	require.NoError(t, err)
+
+	delivery = attempt(t, worker, delivery)
+	require.Equal(t, StatusSucceeded, delivery.Status)
+	require.Equal(t, 3, recv.received())
+}